@@ -2,6 +2,7 @@ package binarysort
 
 import (
 	"bytes"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -40,6 +41,28 @@ func TestOrdering(t *testing.T) {
 	}
 }
 
+func TestFloat64EdgeCases(t *testing.T) {
+	enc := func(x float64) []byte {
+		return AppendFloat64(nil, x)
+	}
+
+	// signed zeros share the same representation
+	require.Equal(t, enc(0), enc(math.Copysign(0, -1)))
+
+	// NaNs share the same representation, sorted after +Inf
+	require.Equal(t, enc(math.NaN()), enc(-math.NaN()))
+	require.Equal(t, -1, bytes.Compare(enc(math.Inf(1)), enc(math.NaN())))
+	require.Equal(t, -1, bytes.Compare(enc(math.Inf(-1)), enc(-1)))
+
+	x, err := DecodeFloat64(enc(math.NaN()))
+	require.NoError(t, err)
+	require.True(t, math.IsNaN(x))
+
+	x, err = DecodeFloat64(enc(math.Copysign(0, -1)))
+	require.NoError(t, err)
+	require.False(t, math.Signbit(x))
+}
+
 func TestTwoWays(t *testing.T) {
 	tests := []struct {
 		name string
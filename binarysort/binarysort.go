@@ -65,9 +65,18 @@ func DecodeInt64(buf []byte) (int64, error) {
 }
 
 // AppendFloat64 takes an float64 and returns its binary representation.
+// -0.0 is encoded like +0.0 and all NaNs share the same representation,
+// which is sorted after +Inf.
 func AppendFloat64(buf []byte, x float64) []byte {
+	switch {
+	case x == 0:
+		x = 0
+	case math.IsNaN(x):
+		x = math.NaN()
+	}
+
 	fb := math.Float64bits(x)
-	if x >= 0 {
+	if x >= 0 || math.IsNaN(x) {
 		fb ^= 1 << 63
 	} else {
 		fb ^= 1<<64 - 1
@@ -7,7 +7,7 @@ require (
 	github.com/c-bata/go-prompt v0.2.5
 	github.com/dgraph-io/badger/v2 v2.2007.2
 	github.com/genjidb/genji v0.10.0
-	github.com/genjidb/genji/engine/badgerengine v0.9.0
+	github.com/genjidb/genji/engine/badgerengine v0.10.0
 	github.com/stretchr/testify v1.6.1
 	github.com/urfave/cli/v2 v2.3.0
 	go.uber.org/multierr v1.6.0
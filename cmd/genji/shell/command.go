@@ -55,6 +55,12 @@ var commands = []struct {
 		DisplayName: ".dump",
 		Description: "Dump database content or table content as SQL statements.",
 	},
+	{
+		Name:        ".mode",
+		Options:     "[json|csv|table]",
+		DisplayName: ".mode",
+		Description: "Set the output format of query results, or display it if no format is given.",
+	},
 	{
 		Name:        ".save",
 		Options:     "[badger?] [filename]",
@@ -63,6 +69,29 @@ var commands = []struct {
 	},
 }
 
+// runModeCmd sets the output mode of query results, or writes it to w if cmd has no argument.
+func (sh *Shell) runModeCmd(cmd []string, w io.Writer) error {
+	switch len(cmd) {
+	case 1:
+		mode := sh.mode
+		if mode == "" {
+			mode = jsonMode
+		}
+
+		_, err := fmt.Fprintln(w, mode)
+		return err
+	case 2:
+		for _, m := range outputModes {
+			if cmd[1] == m {
+				sh.mode = m
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("usage: .mode [%s]", strings.Join(outputModes, "|"))
+}
+
 // runTablesCmd shows all tables.
 func runTablesCmd(db *genji.DB, cmd []string) error {
 	if len(cmd) > 1 {
@@ -223,7 +252,7 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 
 	fcs := ti.FieldConstraints
 	// Fields constraints should be displayed between parenthesis.
-	if len(fcs) > 0 {
+	if len(fcs) > 0 || len(ti.ForeignKeys) > 0 {
 		buf.WriteString(" (\n")
 	}
 
@@ -239,13 +268,43 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 			buf.WriteString(" PRIMARY KEY")
 		}
 
+		if fc.IsAutoIncrement {
+			buf.WriteString(" AUTOINCREMENT")
+		}
+
 		if fc.IsNotNull {
 			buf.WriteString(" NOT NULL")
 		}
+
+		if fc.IsUnique {
+			buf.WriteString(" UNIQUE")
+		}
+
+		if fc.IsTTL {
+			buf.WriteString(" TTL")
+		}
+
+		if fc.IsGenerated() {
+			fmt.Fprintf(&buf, " AS (%s)", fc.GeneratedExpr)
+			if !fc.IsVirtual {
+				buf.WriteString(" STORED")
+			}
+		}
+	}
+
+	for i, fk := range ti.ForeignKeys {
+		if i > 0 || len(fcs) > 0 {
+			buf.WriteString(",\n")
+		}
+
+		fmt.Fprintf(&buf, "  FOREIGN KEY (%s) REFERENCES %s (%s)", fk.Path, fk.ReferencedTable, fk.ReferencedPath)
+		if fk.OnDelete == database.ForeignKeyCascade {
+			buf.WriteString(" ON DELETE CASCADE")
+		}
 	}
 
 	// Fields constraints close parenthesis.
-	if len(fcs) > 0 {
+	if len(fcs) > 0 || len(ti.ForeignKeys) > 0 {
 		buf.WriteString("\n);\n")
 	} else {
 		buf.WriteString(";\n")
@@ -263,13 +322,26 @@ func dumpTable(tx *genji.Tx, tableName string, w io.Writer) error {
 	}
 
 	for _, index := range indexes {
+		// indexes of UNIQUE constraints are created with the table
+		if index.Opts.Constraint {
+			continue
+		}
+
 		u := ""
 		if index.Opts.Unique {
 			u = " UNIQUE"
 		}
+		if index.Opts.FullText {
+			u = " FULLTEXT"
+		}
+
+		where := ""
+		if index.Opts.IsPartial() {
+			where = " WHERE " + index.Opts.Where
+		}
 
-		_, err = fmt.Fprintf(w, "CREATE%s INDEX %s ON %s (%s);\n", u, index.Opts.IndexName, index.Opts.TableName,
-			index.Opts.Path)
+		_, err = fmt.Fprintf(w, "CREATE%s INDEX %s ON %s (%s)%s;\n", u, index.Opts.IndexName, index.Opts.TableName,
+			index.Opts.PathsString(), where)
 		if err != nil {
 			return err
 		}
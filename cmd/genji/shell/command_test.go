@@ -267,3 +267,64 @@ func TestSaveCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestRunModeCmd(t *testing.T) {
+	var sh Shell
+	var buf bytes.Buffer
+
+	err := sh.runModeCmd(strings.Fields(".mode"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, "json\n", buf.String())
+
+	err = sh.runModeCmd(strings.Fields(".mode csv"), &buf)
+	require.NoError(t, err)
+	require.Equal(t, csvMode, sh.mode)
+
+	err = sh.runModeCmd(strings.Fields(".mode xml"), &buf)
+	require.Error(t, err)
+	err = sh.runModeCmd(strings.Fields(".mode csv table"), &buf)
+	require.Error(t, err)
+	require.Equal(t, csvMode, sh.mode)
+}
+
+func TestWriteResult(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		INSERT INTO test (a, b, c) VALUES (1, 'foo, "bar"', NULL), (2, 'baz', ?);
+		INSERT INTO test (a, d) VALUES (3, 1.5);
+	`, []byte{1, 2})
+	require.NoError(t, err)
+
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{csvMode, `a,b,c
+1,"foo, ""bar""",NULL
+2,baz,x'0102'
+3,,
+`},
+		{tableMode, `a  b               c
+1  "foo, \"bar\""  NULL
+2  "baz"           x'0102'
+3                  
+`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			res, err := db.Query("SELECT * FROM test")
+			require.NoError(t, err)
+			defer res.Close()
+
+			var buf bytes.Buffer
+			err = writeResult(context.Background(), res, test.mode, &buf)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+		})
+	}
+}
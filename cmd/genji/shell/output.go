@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/genjidb/genji/document"
+)
+
+// output modes of query results.
+const (
+	jsonMode  = "json"
+	csvMode   = "csv"
+	tableMode = "table"
+)
+
+var outputModes = []string{jsonMode, csvMode, tableMode}
+
+// writeResult writes the documents of the stream to w in the given output mode.
+func writeResult(ctx context.Context, st document.Iterator, mode string, w io.Writer) error {
+	switch mode {
+	case csvMode:
+		return writeCSV(ctx, st, w)
+	case tableMode:
+		return writeTable(ctx, st, w)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return iterate(ctx, st, func(d document.Document) error {
+		return enc.Encode(d)
+	})
+}
+
+// writeCSV writes the documents of the stream as CSV records, preceded by a header.
+// The columns are the top-level fields of the first document,
+// fields missing from the other documents are left empty.
+// Text values are written as is and blobs are never truncated, so that no data is lost.
+func writeCSV(ctx context.Context, st document.Iterator, w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	var columns []string
+	err := iterate(ctx, st, func(d document.Document) error {
+		if columns == nil {
+			var err error
+			columns, err = document.Fields(d)
+			if err != nil {
+				return err
+			}
+
+			err = cw.Write(columns)
+			if err != nil {
+				return err
+			}
+		}
+
+		record, err := formatRow(d, columns, document.FormatOptions{RawText: true, MaxBlobSize: -1})
+		if err != nil {
+			return err
+		}
+
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTable writes the documents of the stream as an aligned table, preceded by a header.
+// The columns are the top-level fields of the first document,
+// fields missing from the other documents are left empty.
+// Large blobs are truncated.
+func writeTable(ctx context.Context, st document.Iterator, w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	var columns []string
+	err := iterate(ctx, st, func(d document.Document) error {
+		if columns == nil {
+			var err error
+			columns, err = document.Fields(d)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(tw, strings.Join(columns, "\t"))
+			if err != nil {
+				return err
+			}
+		}
+
+		row, err := formatRow(d, columns, document.FormatOptions{})
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(tw, strings.Join(row, "\t"))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+// formatRow returns the formatted values of the given top-level fields of d.
+func formatRow(d document.Document, columns []string, opts document.FormatOptions) ([]string, error) {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		v, err := d.GetByField(c)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row[i] = document.FormatValue(v, opts)
+	}
+
+	return row, nil
+}
+
+// iterate calls fn for every document of the stream, until ctx is canceled.
+func iterate(ctx context.Context, st document.Iterator, fn func(d document.Document) error) error {
+	return st.Iterate(func(d document.Document) error {
+		select {
+		case <-ctx.Done():
+			return errors.New("interrupted")
+		default:
+		}
+
+		return fn(d)
+	})
+}
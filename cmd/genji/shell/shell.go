@@ -3,7 +3,6 @@ package shell
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -53,6 +52,9 @@ type Shell struct {
 
 	cmdSuggestions []prompt.Suggest
 
+	// output mode of query results, see the .mode command.
+	mode string
+
 	// context used for execution cancelation,
 	// these must not be used manually.
 	// Use getExecContext and cancelExecContext instead.
@@ -488,6 +490,8 @@ func (sh *Shell) runCommand(ctx context.Context, in string) error {
 		}
 
 		return runDumpCmd(db, cmd[1:], os.Stdout)
+	case ".mode":
+		return sh.runModeCmd(cmd, os.Stdout)
 	case ".save":
 		db, err := sh.getDB(ctx)
 		if err != nil {
@@ -525,18 +529,7 @@ func (sh *Shell) runQuery(ctx context.Context, q string) error {
 
 	defer res.Close()
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetEscapeHTML(false)
-	enc.SetIndent("", "  ")
-	return res.Iterate(func(d document.Document) error {
-		select {
-		case <-ctx.Done():
-			return errors.New("interrupted")
-		default:
-		}
-
-		return enc.Encode(d)
-	})
+	return writeResult(ctx, res, sh.mode, os.Stdout)
 }
 
 func (sh *Shell) getDB(ctx context.Context) (*genji.DB, error) {
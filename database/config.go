@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/fulltext"
 	"github.com/genjidb/genji/index"
 )
 
@@ -19,10 +21,89 @@ type FieldConstraint struct {
 	IsPrimaryKey bool
 	IsNotNull    bool
 	DefaultValue document.Value
+	// DefaultExpr is the text of an expression evaluated every time a document
+	// is inserted without the field, e.g. now(). It is only set if the default value
+	// is not constant, otherwise it is evaluated once and stored in DefaultValue.
+	DefaultExpr string
+	// IsUnique ensures no two documents have the same value at the path.
+	// It is enforced by a unique index created with the table.
+	IsUnique bool
+	// GeneratedExpr is the text of the expression that computes the value of a generated field
+	// from the other fields of the document, every time it is inserted or updated.
+	// The values given to generated fields by writes are ignored.
+	GeneratedExpr string
+	// IsVirtual indicates that the value of a generated field is not stored
+	// but evaluated every time the document is read.
+	IsVirtual bool
+	// IsAutoIncrement indicates that documents inserted without a value for this
+	// integer primary key are given the next value of the sequence of the table.
+	IsAutoIncrement bool
+	// IsTTL indicates that the field contains the time at which the document expires.
+	// Expired documents are not returned by queries and are deleted by PurgeExpired.
+	IsTTL bool
+
+	// DefaultExpr and GeneratedExpr, parsed when the table information is loaded.
+	// Like the expressions of indexes, they are nil if parsing failed.
+	defaultExpr, generatedExpr Expr
 }
 
+// HasDefaultValue returns true if the field has a default value,
+// constant or not.
 func (f *FieldConstraint) HasDefaultValue() bool {
-	return f.DefaultValue.Type != 0
+	return f.DefaultValue.Type != 0 || f.DefaultExpr != ""
+}
+
+// defaultValue returns the default value of the field, evaluating
+// the default expression if any.
+func (f *FieldConstraint) defaultValue(tx *Transaction) (document.Value, error) {
+	if f.DefaultExpr == "" {
+		return f.DefaultValue, nil
+	}
+
+	v, err := tx.evalExpr(f.defaultExpr, f.DefaultExpr, nil)
+	if err != nil {
+		return v, fmt.Errorf("default value of field %q: %w", f.Path, err)
+	}
+
+	return f.convertComputedValue(v)
+}
+
+// IsGenerated returns true if the value of the field is computed from an expression.
+func (f *FieldConstraint) IsGenerated() bool {
+	return f.GeneratedExpr != ""
+}
+
+// generatedValue evaluates the expression of a generated field on d.
+func (f *FieldConstraint) generatedValue(tx *Transaction, d document.Document) (document.Value, error) {
+	v, err := tx.evalExpr(f.generatedExpr, f.GeneratedExpr, d)
+	if err != nil {
+		return v, fmt.Errorf("generated field %q: %w", f.Path, err)
+	}
+
+	return f.convertComputedValue(v)
+}
+
+// parseExprs parses the default and generating expressions of the field, if any.
+func (f *FieldConstraint) parseExprs(db *Database) {
+	if f.DefaultExpr != "" {
+		f.defaultExpr, _ = db.parseExpr(f.DefaultExpr)
+	}
+	if f.GeneratedExpr != "" {
+		f.generatedExpr, _ = db.parseExpr(f.GeneratedExpr)
+	}
+}
+
+// convertComputedValue converts a value computed by an expression
+// like any other value of the document.
+func (f *FieldConstraint) convertComputedValue(v document.Value) (document.Value, error) {
+	switch {
+	case f.Type != 0:
+		return v.CastAs(f.Type)
+	case v.Type == document.IntegerValue:
+		return v.CastAsDouble()
+	}
+
+	return v, nil
 }
 
 // ToDocument returns a document from f.
@@ -33,9 +114,27 @@ func (f *FieldConstraint) ToDocument() document.Document {
 	buf.Add("type", document.NewIntegerValue(int64(f.Type)))
 	buf.Add("is_primary_key", document.NewBoolValue(f.IsPrimaryKey))
 	buf.Add("is_not_null", document.NewBoolValue(f.IsNotNull))
-	if f.HasDefaultValue() {
+	if f.DefaultValue.Type != 0 {
 		buf.Add("default_value", f.DefaultValue)
 	}
+	if f.DefaultExpr != "" {
+		buf.Add("default_expr", document.NewTextValue(f.DefaultExpr))
+	}
+	if f.IsUnique {
+		buf.Add("is_unique", document.NewBoolValue(f.IsUnique))
+	}
+	if f.GeneratedExpr != "" {
+		buf.Add("generated_expr", document.NewTextValue(f.GeneratedExpr))
+	}
+	if f.IsVirtual {
+		buf.Add("is_virtual", document.NewBoolValue(f.IsVirtual))
+	}
+	if f.IsAutoIncrement {
+		buf.Add("is_autoincrement", document.NewBoolValue(f.IsAutoIncrement))
+	}
+	if f.IsTTL {
+		buf.Add("is_ttl", document.NewBoolValue(f.IsTTL))
+	}
 	return buf
 }
 
@@ -77,14 +176,112 @@ func (f *FieldConstraint) ScanDocument(d document.Document) error {
 		f.DefaultValue = v
 	}
 
+	v, err = d.GetByField("default_expr")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.DefaultExpr = v.V.(string)
+	}
+
+	v, err = d.GetByField("is_unique")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.IsUnique = v.V.(bool)
+	}
+
+	v, err = d.GetByField("generated_expr")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.GeneratedExpr = v.V.(string)
+	}
+
+	v, err = d.GetByField("is_virtual")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.IsVirtual = v.V.(bool)
+	}
+
+	v, err = d.GetByField("is_autoincrement")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.IsAutoIncrement = v.V.(bool)
+	}
+
+	v, err = d.GetByField("is_ttl")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		f.IsTTL = v.V.(bool)
+	}
+
 	return nil
 }
 
+// IsEqual returns true if f and other describe the same constraint.
+func (f *FieldConstraint) IsEqual(other *FieldConstraint) bool {
+	if !f.Path.IsEqual(other.Path) || f.Type != other.Type ||
+		f.IsPrimaryKey != other.IsPrimaryKey || f.IsNotNull != other.IsNotNull ||
+		f.IsUnique != other.IsUnique || f.GeneratedExpr != other.GeneratedExpr ||
+		f.IsVirtual != other.IsVirtual || f.IsAutoIncrement != other.IsAutoIncrement ||
+		f.IsTTL != other.IsTTL {
+		return false
+	}
+
+	if f.DefaultExpr != other.DefaultExpr {
+		return false
+	}
+	if (f.DefaultValue.Type != 0) != (other.DefaultValue.Type != 0) {
+		return false
+	}
+	if f.DefaultValue.Type == 0 {
+		return true
+	}
+
+	ok, err := f.DefaultValue.IsEqual(other.DefaultValue)
+	return err == nil && ok
+}
+
 // FieldConstraints is a list of field constraints.
 type FieldConstraints []FieldConstraint
 
+// IsEqual returns true if f and other contain the same constraints,
+// regardless of their order.
+func (f FieldConstraints) IsEqual(other FieldConstraints) bool {
+	if len(f) != len(other) {
+		return false
+	}
+
+	for i := range f {
+		var found bool
+		for j := range other {
+			if f[i].IsEqual(&other[j]) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 // ValidateDocument calls Convert then ensures the document validates against the field constraints.
-func (f FieldConstraints) ValidateDocument(d document.Document) (*document.FieldBuffer, error) {
+// Default values of missing fields and generated fields are evaluated within tx.
+// Generated fields are evaluated last, in the order of the constraints.
+func (f FieldConstraints) ValidateDocument(tx *Transaction, d document.Document) (*document.FieldBuffer, error) {
 	fb, err := f.Convert(d)
 	if err != nil {
 		return nil, err
@@ -92,6 +289,10 @@ func (f FieldConstraints) ValidateDocument(d document.Document) (*document.Field
 
 	// ensure no field is missing
 	for _, fc := range f {
+		if fc.IsGenerated() {
+			continue
+		}
+
 		v, err := fc.Path.GetValueFromDocument(fb)
 		if err == nil {
 			// if field is found, it has already been converted
@@ -109,21 +310,104 @@ func (f FieldConstraints) ValidateDocument(d document.Document) (*document.Field
 
 		// if field is not found
 		// check if there is a default value
-		if fc.DefaultValue.Type != 0 {
-			err = fb.Set(fc.Path, fc.DefaultValue)
+		if fc.HasDefaultValue() {
+			v, err := fc.defaultValue(tx)
 			if err != nil {
 				return nil, err
 			}
-			// if there is no default value
-			// check if field is required
-		} else if fc.IsNotNull {
+
+			err = fb.Set(fc.Path, v)
+			if err == nil {
+				continue
+			}
+			// the default value can't be set if the parent
+			// of the field doesn't exist
+			if err != document.ErrFieldNotFound {
+				return nil, err
+			}
+		}
+
+		// if there is no default value
+		// check if field is required
+		if fc.IsNotNull {
 			return nil, fmt.Errorf("field %q is required and must be not null", fc.Path)
 		}
 	}
 
+	err = f.generate(tx, fb, false)
+	if err != nil {
+		return nil, err
+	}
+
 	return fb, nil
 }
 
+// generate evaluates the generated fields on fb and sets their values.
+// If virtualOnly is true, stored generated fields are left untouched.
+func (f FieldConstraints) generate(tx *Transaction, fb *document.FieldBuffer, virtualOnly bool) error {
+	for _, fc := range f {
+		if !fc.IsGenerated() || (virtualOnly && !fc.IsVirtual) {
+			continue
+		}
+
+		v, err := fc.generatedValue(tx, fb)
+		if err != nil {
+			return err
+		}
+
+		err = fb.Set(fc.Path, v)
+		// the value can't be set if the parent
+		// of the field doesn't exist
+		if err != nil && err != document.ErrFieldNotFound {
+			return err
+		}
+
+		if fc.IsNotNull && (err != nil || v.Type == document.NullValue) {
+			return fmt.Errorf("field %q is required and must be not null", fc.Path)
+		}
+	}
+
+	return nil
+}
+
+// hasVirtualFields returns true if one of the fields is a virtual generated field.
+func (f FieldConstraints) hasVirtualFields() bool {
+	for _, fc := range f {
+		if fc.IsVirtual {
+			return true
+		}
+	}
+
+	return false
+}
+
+// storedDocument returns a copy of fb without the values of the virtual generated fields,
+// which are not stored. fb is returned as is if there are no virtual fields.
+func (f FieldConstraints) storedDocument(fb *document.FieldBuffer) (*document.FieldBuffer, error) {
+	if !f.hasVirtualFields() {
+		return fb, nil
+	}
+
+	stored := document.NewFieldBuffer()
+	err := stored.Copy(fb)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fc := range f {
+		if !fc.IsVirtual {
+			continue
+		}
+
+		err = stored.Delete(fc.Path)
+		if err != nil && err != document.ErrFieldNotFound {
+			return nil, err
+		}
+	}
+
+	return stored, nil
+}
+
 // Convert the document using the field constraints.
 // It converts any path that has a field constraint on it into the specified type.
 // If there is no constraint on an integer field or value, it converts it into a double.
@@ -138,30 +422,39 @@ func (f FieldConstraints) Convert(d document.Document) (*document.FieldBuffer, e
 	// convert the document using field constraints type information.
 	// if there is a type constraint on a path, apply it.
 	// if a value is an integer and has no constraint, convert it to double.
-	err = fb.Apply(func(p document.Path, v document.Value) (document.Value, error) {
-		for _, fc := range f {
-			if !fc.Path.IsEqual(p) {
-				continue
-			}
+	err = fb.Apply(f.convertValue)
 
-			// check if the constraint enforce a particular type
-			// and if so convert the value to the new type.
-			if fc.Type != 0 {
-				return v.CastAs(fc.Type)
-			}
-			break
+	return fb, err
+}
+
+// convertValue converts the value v found at path p like Convert does.
+func (f FieldConstraints) convertValue(p document.Path, v document.Value) (document.Value, error) {
+	for _, fc := range f {
+		if !fc.Path.IsEqual(p) {
+			continue
 		}
 
-		// no constraint have been found for this path.
-		// check if this is an integer and convert it to double.
-		if v.Type == document.IntegerValue {
-			return v.CastAsDouble()
+		// the values of generated fields are replaced
+		// and don't have to be converted.
+		if fc.IsGenerated() {
+			return v, nil
 		}
 
-		return v, nil
-	})
+		// check if the constraint enforce a particular type
+		// and if so convert the value to the new type.
+		if fc.Type != 0 {
+			return v.CastAs(fc.Type)
+		}
+		break
+	}
 
-	return fb, err
+	// no constraint have been found for this path.
+	// check if this is an integer and convert it to double.
+	if v.Type == document.IntegerValue {
+		return v.CastAsDouble()
+	}
+
+	return v, nil
 }
 
 // TableInfo contains information about a table.
@@ -173,6 +466,17 @@ type TableInfo struct {
 	readOnly  bool
 
 	FieldConstraints FieldConstraints
+
+	// ViewQuery is the query of a materialized view, whose documents
+	// can only be replaced by running the query again.
+	// It is empty for regular tables.
+	ViewQuery string
+
+	// Triggers of the table, in the order they are fired.
+	Triggers []TriggerConfig
+
+	// ForeignKeys of the table, checked every time a document is written.
+	ForeignKeys []ForeignKeyConfig
 }
 
 // GetPrimaryKey returns the field constraint of the primary key.
@@ -187,6 +491,19 @@ func (ti *TableInfo) GetPrimaryKey() *FieldConstraint {
 	return nil
 }
 
+// GetTTLField returns the field constraint of the field containing
+// the expiration time of the documents.
+// Returns nil if the documents of the table don't expire.
+func (ti *TableInfo) GetTTLField() *FieldConstraint {
+	for _, f := range ti.FieldConstraints {
+		if f.IsTTL {
+			return &f
+		}
+	}
+
+	return nil
+}
+
 // ToDocument turns ti into a document.
 func (ti *TableInfo) ToDocument() document.Document {
 	buf := document.NewFieldBuffer()
@@ -202,6 +519,23 @@ func (ti *TableInfo) ToDocument() document.Document {
 	buf.Add("field_constraints", document.NewArrayValue(vbuf))
 
 	buf.Add("read_only", document.NewBoolValue(ti.readOnly))
+	if ti.ViewQuery != "" {
+		buf.Add("view_query", document.NewTextValue(ti.ViewQuery))
+	}
+	if len(ti.Triggers) > 0 {
+		tbuf := document.NewValueBuffer()
+		for _, t := range ti.Triggers {
+			tbuf = tbuf.Append(document.NewDocumentValue(t.ToDocument()))
+		}
+		buf.Add("triggers", document.NewArrayValue(tbuf))
+	}
+	if len(ti.ForeignKeys) > 0 {
+		fbuf := document.NewValueBuffer()
+		for _, fk := range ti.ForeignKeys {
+			fbuf = fbuf.Append(document.NewDocumentValue(fk.ToDocument()))
+		}
+		buf.Add("foreign_keys", document.NewArrayValue(fbuf))
+	}
 	return buf
 }
 
@@ -246,6 +580,49 @@ func (ti *TableInfo) ScanDocument(d document.Document) error {
 	}
 
 	ti.readOnly = v.V.(bool)
+
+	v, err = d.GetByField("view_query")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		ti.ViewQuery = v.V.(string)
+	}
+
+	v, err = d.GetByField("triggers")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		ar := v.V.(document.Array)
+		err = ar.Iterate(func(i int, value document.Value) error {
+			var t TriggerConfig
+			err := t.ScanDocument(value.V.(document.Document))
+			ti.Triggers = append(ti.Triggers, t)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	v, err = d.GetByField("foreign_keys")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		ar := v.V.(document.Array)
+		err = ar.Iterate(func(i int, value document.Value) error {
+			var fk ForeignKeyConfig
+			err := fk.ScanDocument(value.V.(document.Document))
+			ti.ForeignKeys = append(ti.ForeignKeys, fk)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -294,9 +671,27 @@ func (t *tableInfoStore) Insert(tx *Transaction, tableName string, info *TableIn
 		return err
 	}
 
+	tx.foreignKeyRefs = nil
 	return nil
 }
 
+// ListAll returns the names of all the tables, sorted by name.
+// System tables are not included.
+func (t *tableInfoStore) ListAll() ([]string, error) {
+	it := t.st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var names []string
+	for it.Seek(nil); it.Valid(); it.Next() {
+		names = append(names, string(it.Item().Key()))
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
 func (t *tableInfoStore) Get(tx *Transaction, tableName string) (*TableInfo, error) {
 	if tableName == tableInfoStoreName {
 		return &TableInfo{
@@ -345,11 +740,16 @@ func (t *tableInfoStore) Get(tx *Transaction, tableName string) (*TableInfo, err
 	if err != nil {
 		return nil, err
 	}
+	for i := range ti.FieldConstraints {
+		ti.FieldConstraints[i].parseExprs(t.db)
+	}
 
 	return &ti, nil
 }
 
 func (t *tableInfoStore) Delete(tx *Transaction, tableName string) error {
+	tx.foreignKeyRefs = nil
+
 	err := t.st.Delete([]byte(tableName))
 	if err != nil {
 		if err == engine.ErrKeyNotFound {
@@ -382,6 +782,7 @@ func (t *tableInfoStore) Replace(tx *Transaction, tableName string, info *TableI
 		return err
 	}
 
+	tx.foreignKeyRefs = nil
 	return t.st.Put(tbName, buf.Bytes())
 }
 
@@ -391,11 +792,128 @@ type IndexConfig struct {
 	IndexName string
 	Path      document.Path
 
+	// ExtraPaths are the paths indexed after Path by a composite index.
+	// Composite indexes associate the array of the values of all their paths
+	// with the keys of the documents, they are never typed.
+	ExtraPaths []document.Path
+
 	// If set to true, values will be associated with at most one key. False by default.
 	Unique bool
 
 	// If set, the index is typed and only accepts that type
 	Type document.ValueType
+
+	// If set to true, the index enforces the UNIQUE constraint of a field
+	// and is managed along with the table.
+	Constraint bool
+
+	// Where is the text of the predicate of a partial index.
+	// If set, only the documents matching the predicate are indexed.
+	Where string
+
+	// Expr is the text of the expression indexed by an expression index, instead of Path.
+	// The value of the expression is evaluated every time a document is written.
+	// Expression indexes are never typed.
+	Expr string
+
+	// If set to true, the index is a full-text index: it associates the terms of the text
+	// stored at Path with the keys of the documents. Full-text indexes are never typed or unique.
+	FullText bool
+
+	// Where and Expr, parsed when the index is loaded.
+	// They are nil if parsing failed, e.g. because the expression calls a function
+	// that is not registered yet. The error is then reported when the index is written.
+	where, expr Expr
+}
+
+// IsEqual returns true if i and other describe the same index.
+// The type of the index is ignored as it is inferred from the field constraints of the table.
+func (i *IndexConfig) IsEqual(other *IndexConfig) bool {
+	return i.TableName == other.TableName &&
+		i.IndexName == other.IndexName &&
+		i.Unique == other.Unique &&
+		i.Where == other.Where &&
+		i.Expr == other.Expr &&
+		i.FullText == other.FullText &&
+		pathsAreEqual(i.Paths(), other.Paths())
+}
+
+// parseExprs parses the predicate and the expression of the index, if any.
+func (i *IndexConfig) parseExprs(db *Database) {
+	if i.Where != "" {
+		i.where, _ = db.parseExpr(i.Where)
+	}
+	if i.Expr != "" {
+		i.expr, _ = db.parseExpr(i.Expr)
+	}
+}
+
+// IsExpression returns true if the index indexes the value of an expression.
+func (i *IndexConfig) IsExpression() bool {
+	return i.Expr != ""
+}
+
+// IsPartial returns true if the index only indexes the documents matching a predicate.
+func (i *IndexConfig) IsPartial() bool {
+	return i.Where != ""
+}
+
+// Paths returns all the paths of the index.
+func (i *IndexConfig) Paths() []document.Path {
+	return append([]document.Path{i.Path}, i.ExtraPaths...)
+}
+
+// IsComposite returns true if the index indexes more than one path.
+func (i *IndexConfig) IsComposite() bool {
+	return len(i.ExtraPaths) > 0
+}
+
+// PathsString returns the paths of the index separated by commas,
+// or the indexed expression of expression indexes.
+func (i *IndexConfig) PathsString() string {
+	if i.Expr != "" {
+		return i.Expr
+	}
+
+	var sb strings.Builder
+	for j, p := range i.Paths() {
+		if j > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.String())
+	}
+
+	return sb.String()
+}
+
+// key returns the string identifying the index among the indexes of its table:
+// the paths or the expression of the index, followed by the predicate of partial indexes.
+// Keys of full-text indexes are prefixed with FULLTEXT, they are never selected by path.
+func (i *IndexConfig) key() string {
+	k := i.PathsString()
+	if i.FullText {
+		k = "FULLTEXT " + k
+	}
+
+	if i.Where == "" {
+		return k
+	}
+
+	return k + " WHERE " + i.Where
+}
+
+func pathsAreEqual(a, b []document.Path) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !a[i].IsEqual(b[i]) {
+			return false
+		}
+	}
+
+	return true
 }
 
 // ToDocument creates a document from an IndexConfig.
@@ -406,9 +924,28 @@ func (i *IndexConfig) ToDocument() document.Document {
 	buf.Add("index_name", document.NewTextValue(i.IndexName))
 	buf.Add("table_name", document.NewTextValue(i.TableName))
 	buf.Add("path", document.NewArrayValue(pathToArray(i.Path)))
+	if len(i.ExtraPaths) > 0 {
+		vbuf := document.NewValueBuffer()
+		for _, p := range i.ExtraPaths {
+			vbuf = vbuf.Append(document.NewArrayValue(pathToArray(p)))
+		}
+		buf.Add("extra_paths", document.NewArrayValue(vbuf))
+	}
 	if i.Type != 0 {
 		buf.Add("type", document.NewIntegerValue(int64(i.Type)))
 	}
+	if i.Constraint {
+		buf.Add("constraint", document.NewBoolValue(i.Constraint))
+	}
+	if i.Where != "" {
+		buf.Add("where", document.NewTextValue(i.Where))
+	}
+	if i.Expr != "" {
+		buf.Add("expr", document.NewTextValue(i.Expr))
+	}
+	if i.FullText {
+		buf.Add("fulltext", document.NewBoolValue(i.FullText))
+	}
 	return buf
 }
 
@@ -441,6 +978,21 @@ func (i *IndexConfig) ScanDocument(d document.Document) error {
 		return err
 	}
 
+	v, err = d.GetByField("extra_paths")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		err = v.V.(document.Array).Iterate(func(_ int, value document.Value) error {
+			p, err := arrayToPath(value.V.(document.Array))
+			i.ExtraPaths = append(i.ExtraPaths, p)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	v, err = d.GetByField("type")
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
@@ -449,6 +1001,38 @@ func (i *IndexConfig) ScanDocument(d document.Document) error {
 		i.Type = document.ValueType(v.V.(int64))
 	}
 
+	v, err = d.GetByField("constraint")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.Constraint = v.V.(bool)
+	}
+
+	v, err = d.GetByField("where")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.Where = v.V.(string)
+	}
+
+	v, err = d.GetByField("expr")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.Expr = v.V.(string)
+	}
+
+	v, err = d.GetByField("fulltext")
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err == nil {
+		i.FullText = v.V.(bool)
+	}
+
 	return nil
 }
 
@@ -457,6 +1041,70 @@ func (i *IndexConfig) ScanDocument(d document.Document) error {
 type Index struct {
 	*index.Index
 	Opts IndexConfig
+
+	// FullText is the inverted index of full-text indexes, nil otherwise.
+	FullText *fulltext.Index
+}
+
+// WithReadAhead returns a copy of the index whose range scans fetch up to n
+// items ahead of the current one, see engine.IteratorOptions.ReadAhead.
+func (idx Index) WithReadAhead(n int) *Index {
+	ii := *idx.Index
+	ii.ReadAhead = n
+	idx.Index = &ii
+	return &idx
+}
+
+func newIndex(tx engine.Transaction, opts IndexConfig) Index {
+	idx := Index{
+		Index: index.New(tx, opts.IndexName, index.Options{
+			Unique: opts.Unique,
+			Type:   opts.Type,
+		}),
+		Opts: opts,
+	}
+
+	if opts.FullText {
+		idx.FullText = fulltext.New(tx, opts.IndexName)
+	}
+
+	return idx
+}
+
+// Set associates a value with a key.
+// Full-text indexes associate the terms of text values with the key and ignore other values.
+func (idx Index) Set(v document.Value, k []byte) error {
+	if idx.FullText == nil {
+		return idx.Index.Set(v, k)
+	}
+
+	if v.Type != document.TextValue {
+		return nil
+	}
+
+	return idx.FullText.Set(v.V.(string), k)
+}
+
+// Delete all the references to the key from the index.
+func (idx Index) Delete(v document.Value, k []byte) error {
+	if idx.FullText == nil {
+		return idx.Index.Delete(v, k)
+	}
+
+	if v.Type != document.TextValue {
+		return nil
+	}
+
+	return idx.FullText.Delete(v.V.(string), k)
+}
+
+// Truncate deletes all the index data.
+func (idx Index) Truncate() error {
+	if idx.FullText == nil {
+		return idx.Index.Truncate()
+	}
+
+	return idx.FullText.Truncate()
 }
 
 type indexStore struct {
@@ -500,6 +1148,7 @@ func (t *indexStore) Get(indexName string) (*IndexConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	idxopts.parseExprs(t.db)
 
 	return &idxopts, nil
 }
@@ -544,6 +1193,7 @@ func (t *indexStore) ListAll() ([]*IndexConfig, error) {
 		if err != nil {
 			return nil, err
 		}
+		opts.parseExprs(t.db)
 
 		idxList = append(idxList, &opts)
 	}
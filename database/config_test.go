@@ -32,6 +32,43 @@ func TestTableInfo(t *testing.T) {
 	var res TableInfo
 	err := res.ScanDocument(doc)
 	require.NoError(t, err)
+	require.Empty(t, res.ViewQuery)
+
+	info.ViewQuery = "SELECT * FROM foo"
+	err = res.ScanDocument(info.ToDocument())
+	require.NoError(t, err)
+	require.Equal(t, info.ViewQuery, res.ViewQuery)
+
+	info.Triggers = []TriggerConfig{
+		{TriggerName: "trg", Timing: TriggerAfter, Event: TriggerDelete, Statement: "DELETE FROM foo"},
+	}
+	res = TableInfo{}
+	err = res.ScanDocument(info.ToDocument())
+	require.NoError(t, err)
+	require.Equal(t, info.Triggers, res.Triggers)
+
+	info.ForeignKeys = []ForeignKeyConfig{
+		{Path: newPath("b"), ReferencedTable: "bar", ReferencedPath: newPath("id"), OnDelete: ForeignKeyCascade},
+	}
+	res = TableInfo{}
+	err = res.ScanDocument(info.ToDocument())
+	require.NoError(t, err)
+	require.Equal(t, info.ForeignKeys, res.ForeignKeys)
+
+	info.FieldConstraints = append(info.FieldConstraints, FieldConstraint{Path: newPath("g"), GeneratedExpr: "a + 1", IsVirtual: true})
+	res = TableInfo{}
+	err = res.ScanDocument(info.ToDocument())
+	require.NoError(t, err)
+	require.True(t, info.FieldConstraints.IsEqual(res.FieldConstraints))
+
+	info.FieldConstraints = FieldConstraints{
+		{Path: newPath("id"), Type: document.IntegerValue, IsPrimaryKey: true, IsAutoIncrement: true},
+		{Path: newPath("exp"), Type: document.TimestampValue, IsTTL: true},
+	}
+	res = TableInfo{}
+	err = res.ScanDocument(info.ToDocument())
+	require.NoError(t, err)
+	require.Equal(t, info.FieldConstraints, res.FieldConstraints)
 }
 
 func TestTableInfoStore(t *testing.T) {
@@ -198,6 +235,55 @@ func TestIndexStore(t *testing.T) {
 		require.EqualError(t, err, ErrIndexNotFound.Error())
 	})
 
+	t.Run("Composite and partial index", func(t *testing.T) {
+		cfg := IndexConfig{
+			TableName: "test",
+			IndexName: "idx_composite",
+			Path:      document.Path{document.PathFragment{FieldName: "a"}},
+			ExtraPaths: []document.Path{
+				{document.PathFragment{FieldName: "b"}, document.PathFragment{FieldName: "c"}},
+				{document.PathFragment{FieldName: "d"}},
+			},
+			Where: "a > 10",
+		}
+
+		err = idxs.Insert(cfg)
+		require.NoError(t, err)
+
+		idxcfg, err := idxs.Get("idx_composite")
+		require.NoError(t, err)
+		// the predicate is parsed when the index is loaded
+		require.NotNil(t, idxcfg.where)
+		idxcfg.where = nil
+		require.Equal(t, &cfg, idxcfg)
+		require.True(t, idxcfg.IsComposite())
+		require.True(t, idxcfg.IsPartial())
+		require.Equal(t, "a, b.c, d", idxcfg.PathsString())
+
+		err = idxs.Delete("idx_composite")
+		require.NoError(t, err)
+	})
+
+	t.Run("Full-text index", func(t *testing.T) {
+		cfg := IndexConfig{
+			TableName: "test",
+			IndexName: "idx_fulltext",
+			Path:      document.Path{document.PathFragment{FieldName: "body"}},
+			FullText:  true,
+		}
+
+		err = idxs.Insert(cfg)
+		require.NoError(t, err)
+
+		idxcfg, err := idxs.Get("idx_fulltext")
+		require.NoError(t, err)
+		require.Equal(t, &cfg, idxcfg)
+		require.Equal(t, "FULLTEXT body", idxcfg.key())
+
+		err = idxs.Delete("idx_fulltext")
+		require.NoError(t, err)
+	})
+
 	t.Run("List all indexes", func(t *testing.T) {
 		idxcfgs := []*IndexConfig{
 			{TableName: "test1", IndexName: "idx_test1", Unique: true},
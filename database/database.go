@@ -4,8 +4,10 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
 	"github.com/genjidb/genji/engine"
 )
@@ -28,12 +30,218 @@ type Database struct {
 
 	// Codec used to encode documents. Defaults to MessagePack.
 	Codec encoding.Codec
+
+	// If set to true, selecting a field that doesn't exist
+	// in a document returns an error instead of NULL.
+	StrictProjections bool
+
+	// Without ORDER BY, documents are returned in primary key order when
+	// reading a table, but in index order when an index is used to filter them.
+	// If set to true, documents read using an index are always returned
+	// in primary key order. Keys are collected from the index and sorted
+	// before documents are read, which requires buffering all the matching keys.
+	StableOrder bool
+
+	// Maximum number of documents kept in memory by ORDER BY clauses that can't use an index.
+	// If zero, which is the default, all the documents are sorted in memory, which is the fastest
+	// but requires as much memory as the result of the query.
+	// Otherwise, memory usage is bounded by the limit: every time it is reached, the buffered documents
+	// are written to a temporary store, keyed by their sort value, which returns them in order.
+	// This trades memory for I/O, each spilled document being encoded, written and read back once.
+	// Documents whose key must be kept, like the ones of DELETE ... ORDER BY statements, are always
+	// sorted in memory.
+	// GROUP BY clauses that can't use an index are sorted the same way when the limit is set,
+	// instead of keeping every group in memory. ORDER BY ... LIMIT k clauses with k lower than
+	// the limit never spill, they only keep k documents in memory.
+	// Writable transactions create the temporary stores in the database engine, where they are
+	// removed once the query returns, read-only transactions in the engine returned by NewTempEngine.
+	// If NewTempEngine is nil, read-only transactions keep everything in memory.
+	SortBufferSize int
+
+	// Maximum number of distinct values kept in memory by DISTINCT clauses.
+	// If zero, which is the default, only a 64-bit hash of every distinct value is kept in memory:
+	// memory usage grows with the number of distinct values and, although it is unlikely,
+	// two different values with the same hash are considered equal.
+	// Otherwise, values are compared exactly and at most that many of them are kept in memory:
+	// once the limit is reached, they are moved to a temporary store and every value that isn't
+	// in memory is looked up in the store, which is slower.
+	// Temporary stores are created like the ones of SortBufferSize, and read-only transactions
+	// keep everything in memory if NewTempEngine is nil.
+	DistinctBufferSize int
+
+	// Number of items fetched ahead of the current one by the iterators of the table
+	// and index scans of queries. If zero, which is the default, items are read one at a time.
+	// Otherwise, engines read them in batches, which speeds up large scans at the cost of
+	// memory, but wastes reads when only a few documents are needed. See engine.IteratorOptions.
+	ReadAheadSize int
+
+	// NewTempEngine creates the engine in which read-only transactions create
+	// their temporary stores and in which transactions create their temporary tables.
+	// If nil, read-only transactions never spill and temporary tables are kept in memory.
+	NewTempEngine func() (engine.Engine, error)
+
+	// By default, additions, subtractions, multiplications and divisions
+	// of integers whose result doesn't fit in an int64 return a double, which can lose precision.
+	// If set to true, these operations fail with document.ErrIntegerOverflow instead.
+	// This only applies to arithmetic operators, SUM always converts its result to a double
+	// when it overflows.
+	StrictArithmetic bool
+
+	// Maximum number of iterators that can be open at the same time by a transaction.
+	// Iterators opened after the limit is reached fail with ErrTooManyOpenIterators,
+	// which helps catching iterators that are never closed.
+	// If zero, which is the default, the number of iterators is not limited.
+	MaxOpenIterators int
+
+	// By default, the result of a query run outside of an explicit transaction
+	// owns the transaction used to run it, which stays open until the result is closed.
+	// Since SELECT statements run in writable transactions, consuming a result lazily
+	// holds the write lock for the whole iteration.
+	// If set to true, the documents of the result are copied in memory
+	// and the transaction is committed before the result is returned.
+	// This releases the lock as soon as possible, at the cost of holding
+	// the entire result in memory, which can be a problem for large results.
+	// It has no effect on queries run within an explicit transaction.
+	MaterializeResults bool
+
+	// Maximum number of iterations of a recursive common table expression.
+	// Queries whose recursion doesn't stop before reaching the limit fail with an error.
+	// If zero, which is the default, DefaultMaxRecursion is used.
+	MaxRecursion int
+
+	// RunTrigger runs the statements of the triggers fired by writes.
+	// It is set by the package that parses statements, writing to a table
+	// that has triggers fails if it is nil.
+	RunTrigger TriggerRunner
+
+	// user defined functions, registered with RegisterFunc.
+	funcs   map[string]userFunc
+	funcsMu sync.RWMutex
+
+	// virtual tables, registered with RegisterPrefixTable.
+	prefixTables   map[string]*PrefixTableConfig
+	prefixTablesMu sync.RWMutex
+
+	// expressions stored with tables and indexes, by text.
+	exprs   map[string]Expr
+	exprsMu sync.RWMutex
+}
+
+// An Expr is an expression stored with a table or an index,
+// such as a default value or the predicate of a partial index.
+type Expr interface {
+	// Eval evaluates the expression within tx.
+	// If d is not nil, it is the current document of the expression.
+	Eval(tx *Transaction, d document.Document) (document.Value, error)
+}
+
+// An ExprParser parses the text of an expression stored with a table or an index.
+// The expression can call the functions registered on db.
+type ExprParser func(db *Database, e string) (Expr, error)
+
+var exprParser ExprParser
+
+// RegisterExprParser sets the parser of the expressions stored with tables and indexes.
+// It is called by the package that parses statements.
+func RegisterExprParser(p ExprParser) {
+	exprParser = p
+}
+
+// parseExpr returns the expression parsed from e.
+// Since the same expressions are loaded every time a table is written,
+// they are only parsed the first time and cached by the database.
+func (db *Database) parseExpr(e string) (Expr, error) {
+	db.exprsMu.RLock()
+	pe, ok := db.exprs[e]
+	db.exprsMu.RUnlock()
+	if ok {
+		return pe, nil
+	}
+
+	if exprParser == nil {
+		return nil, fmt.Errorf("cannot parse expression %q: no expression parser", e)
+	}
+
+	pe, err := exprParser(db, e)
+	if err != nil {
+		return nil, err
+	}
+
+	db.exprsMu.Lock()
+	if db.exprs == nil {
+		db.exprs = make(map[string]Expr)
+	}
+	db.exprs[e] = pe
+	db.exprsMu.Unlock()
+
+	return pe, nil
+}
+
+// evalExpr evaluates the expression stored as text on d.
+// e is the expression parsed when the table or index was loaded,
+// it is nil if it couldn't be parsed, in which case parsing is tried again
+// to report the error.
+func (tx *Transaction) evalExpr(e Expr, text string, d document.Document) (document.Value, error) {
+	if e == nil {
+		var err error
+		e, err = tx.db.parseExpr(text)
+		if err != nil {
+			return document.Value{}, err
+		}
+	}
+
+	return e.Eval(tx, d)
 }
 
 type Options struct {
 	Codec encoding.Codec
+
+	// StrictProjections makes projections fail when
+	// a selected field doesn't exist.
+	StrictProjections bool
+
+	// StableOrder makes index based queries return
+	// documents in primary key order.
+	StableOrder bool
+
+	// SortBufferSize limits the number of documents sorted in memory by ORDER BY
+	// and GROUP BY clauses, the others being spilled to temporary stores.
+	// See Database.SortBufferSize.
+	SortBufferSize int
+
+	// DistinctBufferSize limits the number of distinct values kept in memory by DISTINCT
+	// clauses, the others being spilled to temporary stores.
+	// See Database.DistinctBufferSize.
+	DistinctBufferSize int
+
+	// ReadAheadSize is the number of items fetched ahead by the table
+	// and index scans of queries.
+	// See Database.ReadAheadSize.
+	ReadAheadSize int
+
+	// NewTempEngine creates the engine used by read-only transactions for temporary stores
+	// and by transactions for temporary tables.
+	NewTempEngine func() (engine.Engine, error)
+
+	// StrictArithmetic makes integer operations fail
+	// when they overflow.
+	StrictArithmetic bool
+
+	// MaxOpenIterators limits the number of iterators open at the same time by a transaction.
+	MaxOpenIterators int
+
+	// MaterializeResults copies query results in memory and
+	// releases their transaction before returning them.
+	MaterializeResults bool
+
+	// MaxRecursion limits the number of iterations of recursive common table expressions.
+	MaxRecursion int
 }
 
+// DefaultMaxRecursion is the maximum number of iterations of a recursive
+// common table expression used when the MaxRecursion option is not set.
+const DefaultMaxRecursion = 1000
+
 // New initializes the DB using the given engine.
 func New(ctx context.Context, ng engine.Engine, opts Options) (*Database, error) {
 	if opts.Codec == nil {
@@ -41,8 +249,18 @@ func New(ctx context.Context, ng engine.Engine, opts Options) (*Database, error)
 	}
 
 	db := Database{
-		ng:    ng,
-		Codec: opts.Codec,
+		ng:                 ng,
+		Codec:              opts.Codec,
+		StrictProjections:  opts.StrictProjections,
+		StableOrder:        opts.StableOrder,
+		SortBufferSize:     opts.SortBufferSize,
+		DistinctBufferSize: opts.DistinctBufferSize,
+		ReadAheadSize:      opts.ReadAheadSize,
+		NewTempEngine:      opts.NewTempEngine,
+		StrictArithmetic:   opts.StrictArithmetic,
+		MaxOpenIterators:   opts.MaxOpenIterators,
+		MaterializeResults: opts.MaterializeResults,
+		MaxRecursion:       opts.MaxRecursion,
 	}
 
 	ntx, err := db.ng.Begin(ctx, engine.TxOptions{
@@ -122,10 +340,13 @@ func (db *Database) BeginTx(ctx context.Context, opts *TxOptions) (*Transaction,
 
 	tx := Transaction{
 		db:       db,
-		tx:       ntx,
 		writable: !opts.ReadOnly,
 		attached: opts.Attached,
 	}
+	tx.tx = &iteratorTrackingTransaction{
+		Transaction: &journalingTransaction{Transaction: ntx, tx: &tx},
+		tx:          &tx,
+	}
 
 	tx.tableInfoStore, err = tx.getTableInfoStore()
 	if err != nil {
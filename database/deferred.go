@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/index"
+)
+
+// DeferIndexUpdates stops updating the indexes every time a document is inserted
+// during this transaction. Instead, the keys of the inserted documents are buffered
+// and the corresponding index entries are written in sorted order when the indexes are flushed,
+// which is much faster than updating every index for every document when bulk loading data.
+//
+// Indexes are flushed when calling FlushIndexes, when the transaction is committed,
+// and before any operation that reads or modifies an index, like running a query that
+// uses an index or deleting a document. Queries are thus guaranteed to see consistent indexes.
+//
+// Since index entries are written later, unique index violations are reported
+// by the operation that flushes the indexes instead of Insert.
+func (tx *Transaction) DeferIndexUpdates() error {
+	if !tx.writable {
+		return errors.New("cannot defer index updates in a read-only transaction")
+	}
+
+	tx.deferIndexes = true
+	return nil
+}
+
+// FlushIndexes writes the index entries of the documents inserted since index updates were deferred.
+// Index updates remain deferred for the rest of the transaction.
+func (tx *Transaction) FlushIndexes() error {
+	tables, keys := tx.deferredTables, tx.deferredKeys
+	tx.deferredTables, tx.deferredKeys = nil, nil
+
+	for _, name := range tables {
+		tb, err := tx.GetTable(name)
+		if err != nil {
+			return err
+		}
+
+		err = tb.flushIndexes(keys[name])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deferIndexUpdate buffers the key of a document inserted in the given table
+// until the indexes are flushed.
+func (tx *Transaction) deferIndexUpdate(tableName string, key []byte) {
+	if tx.deferredKeys == nil {
+		tx.deferredKeys = make(map[string][][]byte)
+	}
+
+	keys, ok := tx.deferredKeys[tableName]
+	if !ok {
+		tx.deferredTables = append(tx.deferredTables, tableName)
+	}
+
+	tx.deferredKeys[tableName] = append(keys, append([]byte(nil), key...))
+}
+
+// discardDeferredIndexUpdates forgets the keys buffered for the given table.
+func (tx *Transaction) discardDeferredIndexUpdates(tableName string) {
+	if _, ok := tx.deferredKeys[tableName]; !ok {
+		return
+	}
+
+	delete(tx.deferredKeys, tableName)
+	for i, name := range tx.deferredTables {
+		if name == tableName {
+			tx.deferredTables = append(tx.deferredTables[:i], tx.deferredTables[i+1:]...)
+			break
+		}
+	}
+}
+
+// an indexEntry associates an indexed value with the key of a document.
+type indexEntry struct {
+	enc []byte
+	v   document.Value
+	key []byte
+}
+
+// flushIndexes reads the documents associated with the given keys and
+// adds them to every index of the table. Entries are sorted by encoded value
+// before being written to each index.
+// Documents associated with the same value are written in insertion order.
+func (t *Table) flushIndexes(keys [][]byte) error {
+	indexes, err := t.indexes()
+	if err != nil {
+		return err
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	entries := make(map[string][]indexEntry, len(indexes))
+	for _, k := range keys {
+		d, err := t.GetDocument(k)
+		if err != nil {
+			return err
+		}
+
+		for name, idx := range indexes {
+			v, ok, err := t.tx.indexedValue(idx, d)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			enc, err := idx.EncodeValue(v)
+			if err != nil {
+				return err
+			}
+
+			entries[name] = append(entries[name], indexEntry{enc: enc, v: v, key: k})
+		}
+	}
+
+	for name, idx := range indexes {
+		es := entries[name]
+		sort.SliceStable(es, func(i, j int) bool {
+			return bytes.Compare(es[i].enc, es[j].enc) < 0
+		})
+
+		for _, e := range es {
+			err = idx.Set(e.v, e.key)
+			if err != nil {
+				if err == index.ErrDuplicate {
+					return duplicateError(idx, e.v)
+				}
+
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+func indexKeys(t *testing.T, idx *database.Index) [][]byte {
+	var keys [][]byte
+	err := idx.AscendGreaterOrEqual(document.Value{}, func(val, k []byte, isEqual bool) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	})
+	require.NoError(t, err)
+	return keys
+}
+
+func TestTxDeferIndexUpdates(t *testing.T) {
+	setup := func(t *testing.T, unique bool) (*database.Transaction, *database.Table, *database.Index, func()) {
+		tx, cleanup := newTestDB(t)
+
+		err := tx.CreateTable("test", nil)
+		require.NoError(t, err)
+		err = tx.CreateIndex(database.IndexConfig{
+			IndexName: "idx_test_a", TableName: "test", Path: parsePath(t, "a"), Unique: unique,
+		})
+		require.NoError(t, err)
+
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+		idx, err := tx.GetIndex("idx_test_a")
+		require.NoError(t, err)
+
+		err = tx.DeferIndexUpdates()
+		require.NoError(t, err)
+
+		return tx, tb, idx, cleanup
+	}
+
+	insert := func(t *testing.T, tb *database.Table, values ...int64) [][]byte {
+		var keys [][]byte
+		for _, v := range values {
+			k, err := tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(v)))
+			require.NoError(t, err)
+			keys = append(keys, k)
+		}
+		return keys
+	}
+
+	t.Run("Should write sorted entries on flush", func(t *testing.T) {
+		tx, tb, idx, cleanup := setup(t, false)
+		defer cleanup()
+
+		keys := insert(t, tb, 3, 1, 2, 1)
+		require.Empty(t, indexKeys(t, idx))
+
+		err := tx.FlushIndexes()
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{keys[1], keys[3], keys[2], keys[0]}, indexKeys(t, idx))
+
+		// updates remain deferred after a flush
+		keys = append(keys, insert(t, tb, 0)...)
+		require.Len(t, indexKeys(t, idx), 4)
+		err = tx.FlushIndexes()
+		require.NoError(t, err)
+		require.Equal(t, keys[4], indexKeys(t, idx)[0])
+	})
+
+	t.Run("Should flush before reading indexes", func(t *testing.T) {
+		tx, tb, _, cleanup := setup(t, false)
+		defer cleanup()
+
+		insert(t, tb, 2, 1)
+
+		idx, err := tx.GetIndex("idx_test_a")
+		require.NoError(t, err)
+		require.Len(t, indexKeys(t, idx), 2)
+
+		insert(t, tb, 3)
+		indexes, err := tb.Indexes()
+		require.NoError(t, err)
+		i := indexes["a"]
+		require.Len(t, indexKeys(t, &i), 3)
+	})
+
+	t.Run("Should flush before deleting documents", func(t *testing.T) {
+		tx, tb, idx, cleanup := setup(t, false)
+		defer cleanup()
+
+		keys := insert(t, tb, 2, 1)
+		err := tb.Delete(keys[0])
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{keys[1]}, indexKeys(t, idx))
+
+		err = tx.FlushIndexes()
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{keys[1]}, indexKeys(t, idx))
+	})
+
+	t.Run("Should flush on commit", func(t *testing.T) {
+		db, err := database.New(context.Background(), memoryengine.NewEngine(), database.Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+		defer db.Close()
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		err = tx.CreateTable("test", nil)
+		require.NoError(t, err)
+		err = tx.CreateIndex(database.IndexConfig{IndexName: "idx_test_a", TableName: "test", Path: parsePath(t, "a")})
+		require.NoError(t, err)
+		err = tx.DeferIndexUpdates()
+		require.NoError(t, err)
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+		insert(t, tb, 2, 1)
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		idx, err := tx.GetIndex("idx_test_a")
+		require.NoError(t, err)
+		require.Len(t, indexKeys(t, idx), 2)
+	})
+
+	t.Run("Should report unique violations on flush", func(t *testing.T) {
+		tx, tb, _, cleanup := setup(t, true)
+		defer cleanup()
+
+		insert(t, tb, 1, 1)
+		err := tx.FlushIndexes()
+		require.Equal(t, database.ErrDuplicateDocument, err)
+	})
+
+	t.Run("Should discard updates of dropped tables", func(t *testing.T) {
+		tx, tb, _, cleanup := setup(t, false)
+		defer cleanup()
+
+		insert(t, tb, 1)
+		err := tx.DropTable("test")
+		require.NoError(t, err)
+		err = tx.FlushIndexes()
+		require.NoError(t, err)
+	})
+
+	t.Run("Should fail with a read-only transaction", func(t *testing.T) {
+		db, err := database.New(context.Background(), memoryengine.NewEngine(), database.Options{Codec: msgpack.NewCodec()})
+		require.NoError(t, err)
+		defer db.Close()
+
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		require.Error(t, tx.DeferIndexUpdates())
+	})
+}
+
+func BenchmarkTableInsertWithIndexes(b *testing.B) {
+	for _, deferred := range []bool{false, true} {
+		b.Run(fmt.Sprintf("deferred=%v", deferred), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				tx, cleanup := newTestDB(b)
+				err := tx.CreateTable("test", nil)
+				require.NoError(b, err)
+				for _, f := range []string{"a", "b", "c"} {
+					err = tx.CreateIndex(database.IndexConfig{IndexName: "idx_" + f, TableName: "test", Path: parsePath(b, f)})
+					require.NoError(b, err)
+				}
+				if deferred {
+					require.NoError(b, tx.DeferIndexUpdates())
+				}
+				tb, err := tx.GetTable("test")
+				require.NoError(b, err)
+				b.StartTimer()
+
+				for j := 0; j < 1000; j++ {
+					fb := document.NewFieldBuffer().
+						Add("a", document.NewIntegerValue(int64(j*7919%1000))).
+						Add("b", document.NewIntegerValue(int64(j*104729%1000))).
+						Add("c", document.NewIntegerValue(int64(j)))
+					_, err = tb.Insert(fb)
+					require.NoError(b, err)
+				}
+				require.NoError(b, tx.FlushIndexes())
+
+				b.StopTimer()
+				cleanup()
+				b.StartTimer()
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package database
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/document"
 )
 
 var (
@@ -19,10 +22,45 @@ var (
 	// same name as an existing one.
 	ErrIndexAlreadyExists = errors.New("index already exists")
 
+	// ErrTriggerNotFound is returned when the targeted trigger doesn't exist.
+	ErrTriggerNotFound = errors.New("trigger not found")
+
+	// ErrTriggerAlreadyExists is returned when attempting to create a trigger with the
+	// same name as an existing one.
+	ErrTriggerAlreadyExists = errors.New("trigger already exists")
+
+	// ErrSequenceNotFound is returned when the targeted sequence doesn't exist.
+	ErrSequenceNotFound = errors.New("sequence not found")
+
+	// ErrSequenceAlreadyExists is returned when attempting to create a sequence with the
+	// same name as an existing one.
+	ErrSequenceAlreadyExists = errors.New("sequence already exists")
+
 	// ErrDocumentNotFound is returned when no document is associated with the provided key.
 	ErrDocumentNotFound = errors.New("document not found")
 
 	// ErrDuplicateDocument is returned when another document is already associated with a given key, primary key,
 	// or if there is a unique index violation.
 	ErrDuplicateDocument = errors.New("duplicate document")
+
+	// ErrTooManyOpenIterators is returned by iterators opened after the
+	// maximum number of open iterators of a transaction is reached.
+	ErrTooManyOpenIterators = errors.New("too many open iterators")
 )
+
+// ConstraintViolationError is returned when a document violates a constraint
+// of a field of its table. Violations of UNIQUE constraints wrap ErrDuplicateDocument.
+type ConstraintViolationError struct {
+	Constraint string
+	Path       document.Path
+	Value      document.Value
+}
+
+func (e *ConstraintViolationError) Error() string {
+	return fmt.Sprintf("%s constraint violation: field %q already has value %s", e.Constraint, e.Path, e.Value)
+}
+
+// Unwrap returns ErrDuplicateDocument.
+func (e *ConstraintViolationError) Unwrap() error {
+	return ErrDuplicateDocument
+}
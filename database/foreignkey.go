@@ -0,0 +1,485 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/document"
+)
+
+// ForeignKeyAction defines what happens to the documents referencing a document
+// that is deleted.
+type ForeignKeyAction int
+
+// List of foreign key actions.
+const (
+	// ForeignKeyRestrict prevents deleting documents that are still referenced.
+	ForeignKeyRestrict ForeignKeyAction = iota
+	// ForeignKeyCascade deletes the referencing documents along with the referenced one.
+	ForeignKeyCascade
+)
+
+func (a ForeignKeyAction) String() string {
+	switch a {
+	case ForeignKeyRestrict:
+		return "RESTRICT"
+	case ForeignKeyCascade:
+		return "CASCADE"
+	}
+
+	return ""
+}
+
+// ForeignKeyConfig holds the definition of a foreign key.
+// A foreign key ensures that every non-null value at Path refers
+// to an existing document of the referenced table, identified
+// by its primary key or by a field with a UNIQUE constraint.
+type ForeignKeyConfig struct {
+	Path            document.Path
+	ReferencedTable string
+	ReferencedPath  document.Path
+	OnDelete        ForeignKeyAction
+}
+
+// ToDocument returns a document from f.
+func (f *ForeignKeyConfig) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("path", document.NewArrayValue(pathToArray(f.Path)))
+	buf.Add("referenced_table", document.NewTextValue(f.ReferencedTable))
+	buf.Add("referenced_path", document.NewArrayValue(pathToArray(f.ReferencedPath)))
+	buf.Add("on_delete", document.NewIntegerValue(int64(f.OnDelete)))
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (f *ForeignKeyConfig) ScanDocument(d document.Document) error {
+	v, err := d.GetByField("path")
+	if err != nil {
+		return err
+	}
+	f.Path, err = arrayToPath(v.V.(document.Array))
+	if err != nil {
+		return err
+	}
+
+	v, err = d.GetByField("referenced_table")
+	if err != nil {
+		return err
+	}
+	f.ReferencedTable = v.V.(string)
+
+	v, err = d.GetByField("referenced_path")
+	if err != nil {
+		return err
+	}
+	f.ReferencedPath, err = arrayToPath(v.V.(document.Array))
+	if err != nil {
+		return err
+	}
+
+	v, err = d.GetByField("on_delete")
+	if err != nil {
+		return err
+	}
+	f.OnDelete = ForeignKeyAction(v.V.(int64))
+
+	return nil
+}
+
+// IsEqual returns true if f and other describe the same foreign key.
+func (f *ForeignKeyConfig) IsEqual(other *ForeignKeyConfig) bool {
+	return f.Path.IsEqual(other.Path) &&
+		f.ReferencedTable == other.ReferencedTable &&
+		f.ReferencedPath.IsEqual(other.ReferencedPath) &&
+		f.OnDelete == other.OnDelete
+}
+
+// ForeignKeyViolationError is returned when a write breaks a foreign key.
+type ForeignKeyViolationError struct {
+	// Table and Path are the table and the field of the foreign key.
+	Table string
+	Path  document.Path
+	// ReferencedTable and ReferencedPath are the table and the field it references.
+	ReferencedTable string
+	ReferencedPath  document.Path
+	Value           document.Value
+	// Referenced is true if the referenced document can't be deleted or modified
+	// because documents still reference it. Otherwise, no document has the value
+	// at the referenced path.
+	Referenced bool
+}
+
+func (e *ForeignKeyViolationError) Error() string {
+	if e.Referenced {
+		return fmt.Sprintf("FOREIGN KEY constraint violation: value %s of field %q of table %q is referenced by field %q of table %q",
+			e.Value, e.ReferencedPath, e.ReferencedTable, e.Path, e.Table)
+	}
+
+	return fmt.Sprintf("FOREIGN KEY constraint violation: field %q of table %q references value %s but no document of table %q has it at field %q",
+		e.Path, e.Table, e.Value, e.ReferencedTable, e.ReferencedPath)
+}
+
+// referencedField returns the field constraint of the field referenced by fk.
+// It must be the primary key of the referenced table or have a UNIQUE constraint.
+func (f *ForeignKeyConfig) referencedField(info *TableInfo) (*FieldConstraint, error) {
+	for i := range info.FieldConstraints {
+		fc := &info.FieldConstraints[i]
+		if !fc.Path.IsEqual(f.ReferencedPath) {
+			continue
+		}
+
+		if fc.IsPrimaryKey || fc.IsUnique {
+			return fc, nil
+		}
+		break
+	}
+
+	return nil, fmt.Errorf("foreign key references field %q of table %q which is neither its primary key nor unique", f.ReferencedPath, f.ReferencedTable)
+}
+
+// validateForeignKeys ensures the foreign keys of a table about to be created
+// reference existing fields, primary keys or unique.
+func (tx *Transaction) validateForeignKeys(name string, info *TableInfo) error {
+	for i := range info.ForeignKeys {
+		fk := &info.ForeignKeys[i]
+
+		// tables can reference themselves
+		refInfo := info
+		if fk.ReferencedTable != name {
+			if _, ok := tx.db.prefixTable(fk.ReferencedTable); ok {
+				return fmt.Errorf("foreign key cannot reference read-only table %q", fk.ReferencedTable)
+			}
+
+			var err error
+			refInfo, err = tx.tableInfoStore.Get(tx, fk.ReferencedTable)
+			if err != nil {
+				return err
+			}
+
+			if refInfo.ViewQuery != "" {
+				return fmt.Errorf("foreign key cannot reference materialized view %q", fk.ReferencedTable)
+			}
+		}
+
+		_, err := fk.referencedField(refInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// foreignKeyRef is a foreign key of another table.
+type foreignKeyRef struct {
+	tableName string
+	fk        *ForeignKeyConfig
+}
+
+// referencingForeignKeys returns the foreign keys referencing the given table.
+// The foreign keys of all the tables are loaded once and kept until the
+// catalog is modified, as this is called for every deleted or replaced document.
+func (tx *Transaction) referencingForeignKeys(tableName string) ([]foreignKeyRef, error) {
+	if tx.foreignKeyRefs != nil {
+		return tx.foreignKeyRefs[tableName], nil
+	}
+
+	names, err := tx.tableInfoStore.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string][]foreignKeyRef)
+	for _, name := range names {
+		info, err := tx.tableInfoStore.Get(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range info.ForeignKeys {
+			fk := &info.ForeignKeys[i]
+			refs[fk.ReferencedTable] = append(refs[fk.ReferencedTable], foreignKeyRef{tableName: name, fk: fk})
+		}
+	}
+
+	tx.foreignKeyRefs = refs
+	return refs[tableName], nil
+}
+
+// referencingForeignKeys returns the foreign keys referencing the table.
+// Temporary tables cannot be referenced.
+func (t *Table) referencingForeignKeys() ([]foreignKeyRef, error) {
+	if t.temporary {
+		return nil, nil
+	}
+
+	return t.tx.referencingForeignKeys(t.name)
+}
+
+// renameForeignKeyReferences updates the foreign keys referencing a renamed table.
+func (tx *Transaction) renameForeignKeyReferences(oldName, newName string) error {
+	refs, err := tx.referencingForeignKeys(oldName)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		info, err := tx.tableInfoStore.Get(tx, ref.tableName)
+		if err != nil {
+			return err
+		}
+
+		for i := range info.ForeignKeys {
+			if info.ForeignKeys[i].ReferencedTable == oldName {
+				info.ForeignKeys[i].ReferencedTable = newName
+			}
+		}
+
+		err = tx.tableInfoStore.Replace(tx, ref.tableName, info)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkForeignKeys ensures the values referenced by the foreign keys of the table
+// exist in the referenced tables.
+func (t *Table) checkForeignKeys(info *TableInfo, d document.Document) error {
+	for i := range info.ForeignKeys {
+		fk := &info.ForeignKeys[i]
+
+		v, err := fk.Path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// like in SQL, null values reference nothing
+		if v.Type == document.NullValue {
+			continue
+		}
+
+		ok, err := t.tx.hasReferencedDocument(fk, v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &ForeignKeyViolationError{
+				Table:           t.name,
+				Path:            fk.Path,
+				ReferencedTable: fk.ReferencedTable,
+				ReferencedPath:  fk.ReferencedPath,
+				Value:           v,
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasReferencedDocument returns true if the table referenced by fk
+// has a document with the value v at the referenced path.
+func (tx *Transaction) hasReferencedDocument(fk *ForeignKeyConfig, v document.Value) (bool, error) {
+	t, err := tx.getDatabaseTable(fk.ReferencedTable)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := t.Info()
+	if err != nil {
+		return false, err
+	}
+
+	fc, err := fk.referencedField(info)
+	if err != nil {
+		return false, err
+	}
+
+	// the value is converted to the type of the referenced field,
+	// values that can't be converted can't be referenced.
+	v, err = info.FieldConstraints.convertValue(fc.Path, v)
+	if err != nil {
+		return false, nil
+	}
+
+	if fc.IsPrimaryKey {
+		key, err := encodeKey(fc, v)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = t.GetDocument(key)
+		if err == ErrDocumentNotFound {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return false, err
+	}
+
+	idx, ok := indexes[fc.Path.String()]
+	if !ok {
+		return false, fmt.Errorf("no index found for the UNIQUE constraint of field %q", fc.Path)
+	}
+
+	var found bool
+	err = idx.AscendGreaterOrEqual(v, func(_, _ []byte, isEqual bool) error {
+		found = isEqual
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return false, err
+	}
+
+	return found, nil
+}
+
+// checkReferences applies the foreign keys referencing the table when the document
+// with the given key is deleted, or when its referenced fields are modified.
+// Documents referencing a deleted document are deleted if the foreign key cascades,
+// otherwise they prevent the referenced document from being deleted or modified.
+// If new is nil, the document is deleted.
+func (t *Table) checkReferences(refs []foreignKeyRef, key []byte, old, new document.Document) error {
+	for _, ref := range refs {
+		v, err := ref.fk.ReferencedPath.GetValueFromDocument(old)
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if v.Type == document.NullValue {
+			continue
+		}
+
+		// updates that don't modify the referenced value are always allowed
+		if new != nil {
+			nv, err := ref.fk.ReferencedPath.GetValueFromDocument(new)
+			if err != nil && err != document.ErrFieldNotFound {
+				return err
+			}
+			if err == nil {
+				ok, err := v.IsEqual(nv)
+				if err != nil {
+					return err
+				}
+				if ok {
+					continue
+				}
+			}
+		}
+
+		rt, err := t.tx.getDatabaseTable(ref.tableName)
+		if err != nil {
+			return err
+		}
+
+		keys, err := rt.referencingKeys(ref.fk, v)
+		if err != nil {
+			return err
+		}
+
+		// a document referencing itself doesn't prevent its own deletion
+		if ref.tableName == t.name && new == nil {
+			for i := range keys {
+				if bytes.Equal(keys[i], key) {
+					keys = append(keys[:i], keys[i+1:]...)
+					break
+				}
+			}
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		if new != nil || ref.fk.OnDelete != ForeignKeyCascade {
+			return &ForeignKeyViolationError{
+				Table:           ref.tableName,
+				Path:            ref.fk.Path,
+				ReferencedTable: t.name,
+				ReferencedPath:  ref.fk.ReferencedPath,
+				Value:           v,
+				Referenced:      true,
+			}
+		}
+
+		for _, k := range keys {
+			err = rt.Delete(k)
+			// documents can be deleted by a previous cascade
+			if err != nil && !errors.Is(err, ErrDocumentNotFound) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// referencingKeys returns the keys of the documents of the table that have the value v
+// at the path of the foreign key.
+func (t *Table) referencingKeys(fk *ForeignKeyConfig, v document.Value) ([][]byte, error) {
+	info, err := t.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys [][]byte
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return nil, err
+	}
+
+	// use an index of the field if any, otherwise go through the whole table
+	if idx, ok := indexes[fk.Path.String()]; ok {
+		cv, err := info.FieldConstraints.convertValue(fk.Path, v)
+		if err != nil {
+			return nil, nil
+		}
+
+		err = idx.AscendGreaterOrEqual(cv, func(_, k []byte, isEqual bool) error {
+			if !isEqual {
+				return errStop
+			}
+
+			keys = append(keys, append([]byte{}, k...))
+			return nil
+		})
+		if err != nil && err != errStop {
+			return nil, err
+		}
+
+		return keys, nil
+	}
+
+	err = t.Iterate(func(d document.Document) error {
+		dv, err := fk.Path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ok, err := dv.IsEqual(v)
+		if err != nil || !ok {
+			return err
+		}
+
+		k := d.(document.Keyer).RawKey()
+		keys = append(keys, append([]byte{}, k...))
+		return nil
+	})
+
+	return keys, err
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+)
+
+// A ScalarFunc is a user defined function that can be called from SQL queries.
+// It receives the evaluated arguments of the call and returns a single value.
+type ScalarFunc func(args []document.Value) (document.Value, error)
+
+// An Accumulator computes the result of an aggregate function.
+// A new accumulator is created for every group of documents: Add is called
+// once per document of the group with the value of the argument of the function,
+// then Result is called once to get the aggregated value of the group.
+type Accumulator interface {
+	Add(v document.Value) error
+	Result() (document.Value, error)
+}
+
+// An AggregateFunc is a user defined aggregate function that can be called from SQL queries.
+// It returns a new accumulator every time it is called.
+type AggregateFunc func() Accumulator
+
+// A userFunc is a ScalarFunc or an AggregateFunc registered in the database.
+type userFunc struct {
+	arity int
+	fn    ScalarFunc
+	agg   AggregateFunc
+}
+
+// RegisterFunc makes fn callable from any query run on the database under the given name.
+// Names are case insensitive and a function can only be registered once.
+// Arity is the number of arguments the function expects, a negative arity
+// means the function accepts any number of arguments.
+func (db *Database) RegisterFunc(name string, arity int, fn ScalarFunc) error {
+	if fn == nil {
+		return errors.New("missing function")
+	}
+
+	return db.registerFunc(name, userFunc{arity: arity, fn: fn})
+}
+
+// RegisterAggregateFunc makes an aggregate function callable from queries run on the database
+// under the given name. Aggregate functions take exactly one argument.
+// Queries only see the aggregate functions registered before they were parsed.
+func (db *Database) RegisterAggregateFunc(name string, fn AggregateFunc) error {
+	if fn == nil {
+		return errors.New("missing function")
+	}
+
+	return db.registerFunc(name, userFunc{arity: 1, agg: fn})
+}
+
+func (db *Database) registerFunc(name string, f userFunc) error {
+	if name == "" {
+		return errors.New("missing function name")
+	}
+
+	name = strings.ToLower(name)
+
+	db.funcsMu.Lock()
+	defer db.funcsMu.Unlock()
+
+	if _, ok := db.funcs[name]; ok {
+		return fmt.Errorf("function %q already registered", name)
+	}
+
+	if db.funcs == nil {
+		db.funcs = make(map[string]userFunc)
+	}
+	db.funcs[name] = f
+	return nil
+}
+
+// AggregateFuncs returns the aggregate functions registered in the database, by name.
+func (db *Database) AggregateFuncs() map[string]AggregateFunc {
+	db.funcsMu.RLock()
+	defer db.funcsMu.RUnlock()
+
+	m := make(map[string]AggregateFunc)
+	for name, f := range db.funcs {
+		if f.agg != nil {
+			m[name] = f.agg
+		}
+	}
+
+	return m
+}
+
+// CallFunc calls the function registered under the given name with args.
+// It returns an error if no function was registered under that name
+// or if the number of arguments doesn't match the arity of the function.
+func (db *Database) CallFunc(name string, args []document.Value) (document.Value, error) {
+	db.funcsMu.RLock()
+	f, ok := db.funcs[strings.ToLower(name)]
+	db.funcsMu.RUnlock()
+	if !ok {
+		return document.Value{}, fmt.Errorf("no such function: %q", name)
+	}
+
+	if f.agg != nil {
+		return document.Value{}, fmt.Errorf("misuse of aggregate function %s()", name)
+	}
+
+	if f.arity >= 0 && len(args) != f.arity {
+		return document.Value{}, fmt.Errorf("%s() takes %d argument(s), got %d", name, f.arity, len(args))
+	}
+
+	return f.fn(args)
+}
@@ -0,0 +1,96 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseFuncs(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	db := tx.DB()
+
+	sum := func(args []document.Value) (document.Value, error) {
+		var total int64
+		for _, a := range args {
+			total += a.V.(int64)
+		}
+		return document.NewIntegerValue(total), nil
+	}
+
+	require.Error(t, db.RegisterFunc("", 1, sum))
+	require.Error(t, db.RegisterFunc("foo", 1, nil))
+
+	require.NoError(t, db.RegisterFunc("Add", 2, sum))
+	require.NoError(t, db.RegisterFunc("sum_all", -1, sum))
+	require.Error(t, db.RegisterFunc("ADD", 2, sum))
+
+	v, err := db.CallFunc("add", []document.Value{document.NewIntegerValue(1), document.NewIntegerValue(2)})
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(3), v)
+
+	_, err = db.CallFunc("add", []document.Value{document.NewIntegerValue(1)})
+	require.EqualError(t, err, "add() takes 2 argument(s), got 1")
+
+	v, err = db.CallFunc("SUM_ALL", nil)
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(0), v)
+
+	_, err = db.CallFunc("unknown", nil)
+	require.EqualError(t, err, `no such function: "unknown"`)
+
+	// errors returned by the function are returned as is
+	errFail := errors.New("fail")
+	require.NoError(t, db.RegisterFunc("fail", 0, func(args []document.Value) (document.Value, error) {
+		return document.Value{}, errFail
+	}))
+	_, err = db.CallFunc("fail", nil)
+	require.Equal(t, errFail, err)
+}
+
+type countAccumulator int64
+
+func (c *countAccumulator) Add(v document.Value) error {
+	*c++
+	return nil
+}
+
+func (c *countAccumulator) Result() (document.Value, error) {
+	return document.NewIntegerValue(int64(*c)), nil
+}
+
+func TestDatabaseAggregateFuncs(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	db := tx.DB()
+
+	newCount := func() database.Accumulator {
+		return new(countAccumulator)
+	}
+
+	require.Error(t, db.RegisterAggregateFunc("", newCount))
+	require.Error(t, db.RegisterAggregateFunc("foo", nil))
+	require.NoError(t, db.RegisterAggregateFunc("My_Count", newCount))
+	require.NoError(t, db.RegisterFunc("scalar", 0, func(args []document.Value) (document.Value, error) {
+		return document.NewNullValue(), nil
+	}))
+
+	// aggregate and scalar functions share the same namespace
+	require.Error(t, db.RegisterAggregateFunc("scalar", newCount))
+	require.Error(t, db.RegisterFunc("my_count", 1, func(args []document.Value) (document.Value, error) {
+		return document.NewNullValue(), nil
+	}))
+
+	aggs := db.AggregateFuncs()
+	require.Len(t, aggs, 1)
+	require.NotNil(t, aggs["my_count"])
+
+	_, err := db.CallFunc("my_count", []document.Value{document.NewNullValue()})
+	require.EqualError(t, err, "misuse of aggregate function my_count()")
+}
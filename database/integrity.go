@@ -0,0 +1,271 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/fulltext"
+)
+
+// IntegrityErrorKind describes the type of inconsistency found between a table and an index.
+type IntegrityErrorKind int
+
+const (
+	// OrphanedIndexEntry means that an index entry references a document
+	// that doesn't exist or whose indexed value is different.
+	OrphanedIndexEntry IntegrityErrorKind = iota + 1
+	// MissingIndexEntry means that a document is not referenced by an index
+	// of its table.
+	MissingIndexEntry
+)
+
+func (k IntegrityErrorKind) String() string {
+	switch k {
+	case OrphanedIndexEntry:
+		return "orphaned index entry"
+	case MissingIndexEntry:
+		return "missing index entry"
+	}
+
+	return ""
+}
+
+// An IntegrityError describes an inconsistency between a table and one of its indexes.
+// It can be fixed by reindexing the index.
+type IntegrityError struct {
+	Kind      IntegrityErrorKind
+	TableName string
+	IndexName string
+	// Key of the document.
+	Key []byte
+}
+
+func (e IntegrityError) Error() string {
+	return fmt.Sprintf("%s: table %q, index %q, key %q", e.Kind, e.TableName, e.IndexName, e.Key)
+}
+
+// VerifyIntegrity scans every index and its table and ensures that each index entry references
+// an existing document with the same indexed value, and that each document is referenced by all the
+// indexes of its table. The entries of full-text indexes must match the terms of the indexed texts,
+// and their number of occurrences.
+// It returns the list of inconsistencies found, which can be fixed by running ReIndex.
+func (tx *Transaction) VerifyIntegrity() ([]IntegrityError, error) {
+	list, err := tx.ListIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []IntegrityError
+	for _, cfg := range list {
+		verify := tx.verifyIndexIntegrity
+		if cfg.FullText {
+			verify = tx.verifyFullTextIndexIntegrity
+		}
+
+		idxErrs, err := verify(cfg.IndexName)
+		if err != nil {
+			return nil, err
+		}
+
+		errs = append(errs, idxErrs...)
+	}
+
+	return errs, nil
+}
+
+func (tx *Transaction) verifyIndexIntegrity(indexName string) ([]IntegrityError, error) {
+	idx, err := tx.GetIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	tb, err := tx.GetTable(idx.Opts.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []IntegrityError
+	newError := func(kind IntegrityErrorKind, key []byte) IntegrityError {
+		return IntegrityError{
+			Kind:      kind,
+			TableName: idx.Opts.TableName,
+			IndexName: idx.Opts.IndexName,
+			Key:       append([]byte{}, key...),
+		}
+	}
+
+	// encoded values referenced by the index, by document key.
+	entries := make(map[string][][]byte)
+
+	err = idx.AscendGreaterOrEqual(document.Value{}, func(val, key []byte, isEqual bool) error {
+		entries[string(key)] = append(entries[string(key)], append([]byte{}, val...))
+
+		d, err := tb.GetDocument(key)
+		if err == ErrDocumentNotFound {
+			errs = append(errs, newError(OrphanedIndexEntry, key))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		enc, err := tx.encodeIndexedValue(*idx, d)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(enc, val) {
+			errs = append(errs, newError(OrphanedIndexEntry, key))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tb.Iterate(func(d document.Document) error {
+		key := d.(document.Keyer).RawKey()
+
+		v, ok, err := tx.indexedValue(*idx, d)
+		if err != nil {
+			return err
+		}
+
+		// documents not matching the predicate of partial indexes,
+		// and null values of UNIQUE constraints, are not indexed
+		if !ok {
+			return nil
+		}
+
+		// null values are not stored in typed indexes
+		if idx.Type != 0 && v.Type == document.NullValue {
+			return nil
+		}
+
+		enc, err := idx.EncodeValue(v)
+		if err != nil {
+			return err
+		}
+
+		for _, val := range entries[string(key)] {
+			if bytes.Equal(enc, val) {
+				return nil
+			}
+		}
+
+		errs = append(errs, newError(MissingIndexEntry, key))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return errs, nil
+}
+
+// verifyFullTextIndexIntegrity ensures that the terms associated with each key of a full-text index
+// are the ones of the text indexed in the document with that key, with the same frequencies.
+func (tx *Transaction) verifyFullTextIndexIntegrity(indexName string) ([]IntegrityError, error) {
+	idx, err := tx.GetIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	tb, err := tx.GetTable(idx.Opts.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []IntegrityError
+	newError := func(kind IntegrityErrorKind, key []byte) IntegrityError {
+		return IntegrityError{
+			Kind:      kind,
+			TableName: idx.Opts.TableName,
+			IndexName: idx.Opts.IndexName,
+			Key:       append([]byte{}, key...),
+		}
+	}
+
+	// indexedTerms returns the frequencies of the terms of the text of d indexed by idx.
+	indexedTerms := func(d document.Document) (map[string]int, error) {
+		v, ok, err := tx.indexedValue(*idx, d)
+		if err != nil || !ok || v.Type != document.TextValue {
+			return nil, err
+		}
+
+		return fulltext.TermFrequencies(v.V.(string)), nil
+	}
+
+	// frequencies of the terms stored in the index, by key.
+	entries := make(map[string]map[string]int)
+	err = idx.FullText.Iterate(func(term string, key []byte, freq int) error {
+		terms, ok := entries[string(key)]
+		if !ok {
+			terms = make(map[string]int)
+			entries[string(key)] = terms
+		}
+
+		terms[term] = freq
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tb.Iterate(func(d document.Document) error {
+		key := d.(document.Keyer).RawKey()
+
+		want, err := indexedTerms(d)
+		if err != nil {
+			return err
+		}
+
+		got := entries[string(key)]
+		delete(entries, string(key))
+
+		for term, freq := range got {
+			if want[term] != freq {
+				errs = append(errs, newError(OrphanedIndexEntry, key))
+				break
+			}
+		}
+
+		for term := range want {
+			if _, ok := got[term]; !ok {
+				errs = append(errs, newError(MissingIndexEntry, key))
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the remaining entries reference documents that don't exist
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		errs = append(errs, newError(OrphanedIndexEntry, []byte(k)))
+	}
+
+	return errs, nil
+}
+
+// encodeIndexedValue returns the encoded value of d indexed by idx,
+// or nil if d is not indexed by idx.
+func (tx *Transaction) encodeIndexedValue(idx Index, d document.Document) ([]byte, error) {
+	v, ok, err := tx.indexedValue(idx, d)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	return idx.EncodeValue(v)
+}
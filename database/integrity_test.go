@@ -0,0 +1,134 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyIntegrity(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	err := tx.CreateTable("test", &database.TableInfo{
+		FieldConstraints: []database.FieldConstraint{
+			{Path: parsePath(t, "b"), Type: document.IntegerValue},
+		},
+	})
+	require.NoError(t, err)
+	for _, name := range []string{"idx_a", "idx_b"} {
+		err = tx.CreateIndex(database.IndexConfig{
+			IndexName: name,
+			TableName: "test",
+			Path:      parsePath(t, name[4:]),
+		})
+		require.NoError(t, err)
+	}
+
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	var keys [][]byte
+	for _, d := range []string{`{"a": 1, "b": 1}`, `{"a": 2}`, `{"b": 3}`} {
+		k, err := tb.Insert(document.NewFromJSON([]byte(d)))
+		require.NoError(t, err)
+		keys = append(keys, k)
+	}
+
+	errs, err := tx.VerifyIntegrity()
+	require.NoError(t, err)
+	require.Empty(t, errs)
+
+	idxA, err := tx.GetIndex("idx_a")
+	require.NoError(t, err)
+	idxB, err := tx.GetIndex("idx_b")
+	require.NoError(t, err)
+
+	// reference a document that doesn't exist
+	err = idxA.Set(document.NewDoubleValue(10), []byte("unknown"))
+	require.NoError(t, err)
+	// reference a document with the wrong value
+	err = idxA.Set(document.NewDoubleValue(20), keys[2])
+	require.NoError(t, err)
+	// remove the reference to a document
+	err = idxB.Delete(document.NewIntegerValue(1), keys[0])
+	require.NoError(t, err)
+
+	errs, err = tx.VerifyIntegrity()
+	require.NoError(t, err)
+	require.Equal(t, []database.IntegrityError{
+		{Kind: database.OrphanedIndexEntry, TableName: "test", IndexName: "idx_a", Key: []byte("unknown")},
+		{Kind: database.OrphanedIndexEntry, TableName: "test", IndexName: "idx_a", Key: keys[2]},
+		{Kind: database.MissingIndexEntry, TableName: "test", IndexName: "idx_b", Key: keys[0]},
+	}, errs)
+
+	err = tx.ReIndexAll()
+	require.NoError(t, err)
+
+	errs, err = tx.VerifyIntegrity()
+	require.NoError(t, err)
+	require.Empty(t, errs)
+}
+
+func TestVerifyIntegrityFullText(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	err := tx.CreateTable("test", nil)
+	require.NoError(t, err)
+	err = tx.CreateIndex(database.IndexConfig{
+		IndexName: "idx_a",
+		TableName: "test",
+		Path:      parsePath(t, "a"),
+		FullText:  true,
+	})
+	require.NoError(t, err)
+
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	var keys [][]byte
+	for _, d := range []string{`{"a": "quick brown fox"}`, `{"a": "lazy lazy dog"}`, `{"a": 1}`} {
+		k, err := tb.Insert(document.NewFromJSON([]byte(d)))
+		require.NoError(t, err)
+		keys = append(keys, k)
+	}
+
+	errs, err := tx.VerifyIntegrity()
+	require.NoError(t, err)
+	require.Empty(t, errs)
+
+	idx, err := tx.GetIndex("idx_a")
+	require.NoError(t, err)
+
+	// reference a document that doesn't exist
+	err = idx.Set(document.NewTextValue("fox"), []byte("unknown"))
+	require.NoError(t, err)
+	// remove a term of a document
+	err = idx.Delete(document.NewTextValue("brown"), keys[0])
+	require.NoError(t, err)
+	// store the wrong number of occurrences
+	err = idx.Set(document.NewTextValue("lazy"), keys[1])
+	require.NoError(t, err)
+	// index a document whose value isn't a text
+	err = idx.Set(document.NewTextValue("one"), keys[2])
+	require.NoError(t, err)
+
+	errs, err = tx.VerifyIntegrity()
+	require.NoError(t, err)
+	require.Equal(t, []database.IntegrityError{
+		{Kind: database.MissingIndexEntry, TableName: "test", IndexName: "idx_a", Key: keys[0]},
+		{Kind: database.OrphanedIndexEntry, TableName: "test", IndexName: "idx_a", Key: keys[1]},
+		{Kind: database.OrphanedIndexEntry, TableName: "test", IndexName: "idx_a", Key: keys[2]},
+		{Kind: database.OrphanedIndexEntry, TableName: "test", IndexName: "idx_a", Key: []byte("unknown")},
+	}, errs)
+
+	err = tx.ReIndexAll()
+	require.NoError(t, err)
+
+	errs, err = tx.VerifyIntegrity()
+	require.NoError(t, err)
+	require.Empty(t, errs)
+}
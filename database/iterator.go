@@ -0,0 +1,71 @@
+package database
+
+import "github.com/genjidb/genji/engine"
+
+// iteratorTrackingTransaction wraps an engine transaction and counts
+// the iterators opened by its stores.
+type iteratorTrackingTransaction struct {
+	engine.Transaction
+
+	tx *Transaction
+}
+
+func (t *iteratorTrackingTransaction) GetStore(name []byte) (engine.Store, error) {
+	st, err := t.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &iteratorTrackingStore{Store: st, tx: t.tx}, nil
+}
+
+type iteratorTrackingStore struct {
+	engine.Store
+
+	tx *Transaction
+}
+
+// Unwrap returns the wrapped store.
+func (s *iteratorTrackingStore) Unwrap() engine.Store {
+	return s.Store
+}
+
+// Iterator returns an iterator that fails with ErrTooManyOpenIterators
+// if the transaction already reached the maximum number of open iterators.
+func (s *iteratorTrackingStore) Iterator(opts engine.IteratorOptions) engine.Iterator {
+	if max := s.tx.db.MaxOpenIterators; max > 0 && s.tx.openIterators >= max {
+		return errIterator{err: ErrTooManyOpenIterators}
+	}
+
+	s.tx.openIterators++
+	return &trackedIterator{Iterator: s.Store.Iterator(opts), tx: s.tx}
+}
+
+type trackedIterator struct {
+	engine.Iterator
+
+	tx     *Transaction
+	closed bool
+}
+
+func (it *trackedIterator) Close() error {
+	if !it.closed {
+		it.closed = true
+		it.tx.openIterators--
+	}
+
+	return it.Iterator.Close()
+}
+
+// errIterator is an iterator that is never valid
+// and always returns the same error.
+type errIterator struct {
+	err error
+}
+
+func (it errIterator) Seek(k []byte)     {}
+func (it errIterator) Next()             {}
+func (it errIterator) Err() error        { return it.err }
+func (it errIterator) Valid() bool       { return false }
+func (it errIterator) Item() engine.Item { return nil }
+func (it errIterator) Close() error      { return nil }
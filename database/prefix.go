@@ -0,0 +1,202 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+)
+
+// PrefixTableConfig describes a virtual table that exposes the documents of another table
+// whose primary key starts with a given prefix. It can be used to query hierarchical keys,
+// like "user:123:orders:456", as if they were stored in their own table.
+type PrefixTableConfig struct {
+	// Name of the table containing the documents.
+	// Its primary key must be of type TEXT.
+	TableName string
+
+	// Only the documents whose primary key starts with Prefix are selected.
+	Prefix string
+
+	// The rest of the primary key is split using Separator and every part
+	// is added to the selected documents as a TEXT field, using the names listed in Fields, in order.
+	// If the key contains more parts than Fields, the remaining parts are kept in the last field.
+	// If the key contains less parts than Fields, the missing fields are not added.
+	// Fields of the suffix take precedence over fields of the document with the same name.
+	Separator string
+	Fields    []string
+}
+
+// RegisterPrefixTable makes a prefix table available to every transaction under the given name.
+// Prefix tables are read-only and their documents are never indexed:
+// scanning them reads the keys starting with the prefix in primary key order.
+// A prefix table hides any table with the same name and no table can be created with that name
+// once the prefix table is registered.
+func (db *Database) RegisterPrefixTable(name string, cfg PrefixTableConfig) error {
+	if name == "" {
+		return errors.New("missing table name")
+	}
+	if strings.HasPrefix(name, internalPrefix) {
+		return fmt.Errorf("table name must not start with %s", internalPrefix)
+	}
+	if cfg.TableName == "" {
+		return errors.New("missing prefix table source")
+	}
+	if cfg.Prefix == "" {
+		return errors.New("missing prefix")
+	}
+	if len(cfg.Fields) > 1 && cfg.Separator == "" {
+		return errors.New("missing separator")
+	}
+
+	db.prefixTablesMu.Lock()
+	defer db.prefixTablesMu.Unlock()
+
+	if _, ok := db.prefixTables[name]; ok {
+		return fmt.Errorf("prefix table %q already registered", name)
+	}
+
+	if db.prefixTables == nil {
+		db.prefixTables = make(map[string]*PrefixTableConfig)
+	}
+	cfg.Fields = append([]string(nil), cfg.Fields...)
+	db.prefixTables[name] = &cfg
+	return nil
+}
+
+// prefixTable returns the configuration of the prefix table registered under the given name, if any.
+func (db *Database) prefixTable(name string) (*PrefixTableConfig, bool) {
+	db.prefixTablesMu.RLock()
+	defer db.prefixTablesMu.RUnlock()
+
+	cfg, ok := db.prefixTables[name]
+	return cfg, ok
+}
+
+// getPrefixTable returns a read-only copy of the source table of cfg
+// that only reads the documents whose key starts with the prefix.
+func (tx *Transaction) getPrefixTable(name string, cfg *PrefixTableConfig) (*Table, error) {
+	tb, err := tx.GetTable(cfg.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	if pk := info.GetPrimaryKey(); pk == nil || pk.Type != document.TextValue {
+		return nil, fmt.Errorf("prefix table %q requires table %q to have a primary key of type TEXT", name, cfg.TableName)
+	}
+
+	tb.name = name
+	tb.prefix = cfg
+	return tb, nil
+}
+
+// prefixTableInfo returns the info of the source table, marked as read-only.
+func (t *Table) prefixTableInfo() (*TableInfo, error) {
+	info, err := t.infoStore.Get(t.tx, t.prefix.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ti := *info
+	ti.tableName = t.name
+	ti.readOnly = true
+	return &ti, nil
+}
+
+// getPrefixDocument returns the document associated with the key if it starts with the prefix.
+func (t *Table) getPrefixDocument(key []byte) (document.Document, error) {
+	if !bytes.HasPrefix(key, []byte(t.prefix.Prefix)) {
+		return nil, ErrDocumentNotFound
+	}
+
+	tb := *t
+	tb.name = t.prefix.TableName
+	tb.prefix = nil
+	d, err := tb.GetDocument(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &prefixDocument{Keyer: d.(document.Keyer), d: d, cfg: t.prefix}, nil
+}
+
+// decodeSuffix decodes the suffix of the key into the fields of the prefix table
+// and adds them to fb.
+func (cfg *PrefixTableConfig) decodeSuffix(key []byte, fb *document.FieldBuffer) {
+	suffix := string(key[len(cfg.Prefix):])
+
+	var parts []string
+	switch len(cfg.Fields) {
+	case 0:
+		return
+	case 1:
+		parts = []string{suffix}
+	default:
+		parts = strings.SplitN(suffix, cfg.Separator, len(cfg.Fields))
+	}
+
+	for i, p := range parts {
+		fb.Add(cfg.Fields[i], document.NewTextValue(p))
+	}
+}
+
+// prefixDocument adds the fields decoded from the key suffix
+// to the documents of a prefix table.
+type prefixDocument struct {
+	document.Keyer
+
+	d   document.Document
+	cfg *PrefixTableConfig
+	fb  document.FieldBuffer
+	// true if fb contains the fields of the current key.
+	decoded bool
+}
+
+func (d *prefixDocument) decode() {
+	if !d.decoded {
+		d.cfg.decodeSuffix(d.RawKey(), &d.fb)
+		d.decoded = true
+	}
+}
+
+func (d *prefixDocument) GetByField(field string) (document.Value, error) {
+	d.decode()
+
+	v, err := d.fb.GetByField(field)
+	if err != document.ErrFieldNotFound {
+		return v, err
+	}
+
+	return d.d.GetByField(field)
+}
+
+func (d *prefixDocument) Iterate(fn func(field string, value document.Value) error) error {
+	d.decode()
+
+	err := d.fb.Iterate(fn)
+	if err != nil {
+		return err
+	}
+
+	return d.d.Iterate(func(field string, value document.Value) error {
+		_, err := d.fb.GetByField(field)
+		if err == nil {
+			return nil
+		}
+
+		return fn(field, value)
+	})
+}
+
+// Reset must be called every time the underlying document moves to another key.
+func (d *prefixDocument) Reset() {
+	d.fb.Reset()
+	d.decoded = false
+}
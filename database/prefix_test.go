@@ -0,0 +1,70 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixTable(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	err := tx.DB().RegisterPrefixTable("items", database.PrefixTableConfig{
+		TableName: "test",
+		Prefix:    "a/",
+		Separator: "/",
+		Fields:    []string{"x", "y"},
+	})
+	require.NoError(t, err)
+
+	err = tx.CreateTable("test", &database.TableInfo{
+		FieldConstraints: []database.FieldConstraint{
+			{Path: parsePath(t, "k"), Type: document.TextValue, IsPrimaryKey: true},
+		},
+	})
+	require.NoError(t, err)
+
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+	for _, k := range []string{"a", "a/1/2/3", "a/4", "b/1"} {
+		_, err = tb.Insert(document.NewFieldBuffer().Add("k", document.NewTextValue(k)))
+		require.NoError(t, err)
+	}
+
+	items, err := tx.GetTable("items")
+	require.NoError(t, err)
+
+	t.Run("Iterate", func(t *testing.T) {
+		var docs []string
+		err = items.Iterate(func(d document.Document) error {
+			data, err := document.MarshalJSON(d)
+			docs = append(docs, string(data))
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{`{"x": "1", "y": "2/3", "k": "a/1/2/3"}`, `{"x": "4", "k": "a/4"}`}, docs)
+	})
+
+	t.Run("GetDocument", func(t *testing.T) {
+		d, err := items.GetDocument([]byte("a/4"))
+		require.NoError(t, err)
+		v, err := d.GetByField("x")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("4"), v)
+		_, err = d.GetByField("y")
+		require.Equal(t, document.ErrFieldNotFound, err)
+
+		_, err = items.GetDocument([]byte("b/1"))
+		require.Equal(t, database.ErrDocumentNotFound, err)
+	})
+
+	t.Run("Read-only", func(t *testing.T) {
+		_, err := items.Insert(document.NewFieldBuffer().Add("k", document.NewTextValue("a/5")))
+		require.Error(t, err)
+		require.Error(t, items.Delete([]byte("a/4")))
+		require.Error(t, items.Truncate())
+	})
+}
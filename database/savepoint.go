@@ -0,0 +1,399 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// ErrSavepointNotFound is returned when the targeted savepoint doesn't exist.
+var ErrSavepointNotFound = errors.New("savepoint not found")
+
+// A savepoint marks a position in the journal of a transaction.
+type savepoint struct {
+	name string
+	// number of entries of the journal when the savepoint was created.
+	pos int
+
+	lastInsertKey document.Value
+}
+
+// Savepoint creates a savepoint with the given name.
+// Changes made after the savepoint can be cancelled by calling RollbackToSavepoint,
+// without rolling back the whole transaction.
+// If a savepoint with the same name already exists, the new one hides it until it is released.
+//
+// Deferred index updates are flushed before creating the savepoint.
+// Sequences, like the ones used to generate document keys, and changes made
+// to temporary tables are not rolled back.
+func (tx *Transaction) Savepoint(name string) error {
+	err := tx.FlushIndexes()
+	if err != nil {
+		return err
+	}
+
+	tx.savepoints = append(tx.savepoints, savepoint{
+		name:          name,
+		pos:           len(tx.journal),
+		lastInsertKey: tx.lastInsertKey,
+	})
+
+	return nil
+}
+
+// RollbackToSavepoint cancels all the changes made since the most recent savepoint
+// with the given name was created. The savepoint remains and can be rolled back to again,
+// savepoints created after it are released.
+// If the savepoint doesn't exist, it returns ErrSavepointNotFound.
+func (tx *Transaction) RollbackToSavepoint(name string) error {
+	i, err := tx.getSavepoint(name)
+	if err != nil {
+		return err
+	}
+
+	sp := tx.savepoints[i]
+	tx.savepoints = tx.savepoints[:i+1]
+
+	// the indexes were flushed when the savepoint was created,
+	// all the deferred updates belong to documents written after it.
+	tx.deferredTables, tx.deferredKeys = nil, nil
+	// the catalog may be restored
+	tx.foreignKeyRefs = nil
+
+	jtx := tx.journalingTx()
+	for len(tx.journal) > sp.pos {
+		n := len(tx.journal) - 1
+		err = tx.journal[n].undo(jtx.Transaction)
+		if err != nil {
+			return fmt.Errorf("failed to rollback to savepoint %q: %w", name, err)
+		}
+
+		tx.journal = tx.journal[:n]
+	}
+
+	tx.lastInsertKey = sp.lastInsertKey
+
+	return nil
+}
+
+// ReleaseSavepoint removes the most recent savepoint with the given name and all
+// the savepoints created after it. The changes made since it was created are kept.
+// If the savepoint doesn't exist, it returns ErrSavepointNotFound.
+func (tx *Transaction) ReleaseSavepoint(name string) error {
+	i, err := tx.getSavepoint(name)
+	if err != nil {
+		return err
+	}
+
+	tx.savepoints = tx.savepoints[:i]
+	if len(tx.savepoints) == 0 {
+		tx.journal = nil
+	}
+
+	return nil
+}
+
+func (tx *Transaction) getSavepoint(name string) (int, error) {
+	for i := len(tx.savepoints) - 1; i >= 0; i-- {
+		if tx.savepoints[i].name == name {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrSavepointNotFound, name)
+}
+
+func (tx *Transaction) journalingTx() *journalingTransaction {
+	return tx.tx.(*iteratorTrackingTransaction).Transaction.(*journalingTransaction)
+}
+
+type journalOp uint8
+
+const (
+	// the key didn't exist and must be deleted.
+	journalDeleteKey journalOp = iota + 1
+	// the key must be restored to its previous value.
+	journalPutKey
+	// the store was created and must be dropped.
+	journalDropStore
+	// the store was dropped and must be recreated with its previous content.
+	journalCreateStore
+	// the store was truncated and its previous content must be restored.
+	journalRestoreStore
+)
+
+// A journalEntry describes how to undo a change made to the engine.
+type journalEntry struct {
+	op    journalOp
+	store []byte
+	key   []byte
+	value []byte
+	// content of the store, for drops and truncations.
+	items []journalItem
+}
+
+type journalItem struct {
+	key, value []byte
+}
+
+func (e *journalEntry) undo(tx engine.Transaction) error {
+	switch e.op {
+	case journalDropStore:
+		err := tx.DropStore(e.store)
+		if errors.Is(err, engine.ErrStoreNotFound) {
+			return nil
+		}
+		return err
+	case journalCreateStore:
+		err := tx.CreateStore(e.store)
+		if err != nil {
+			return err
+		}
+	}
+
+	st, err := tx.GetStore(e.store)
+	if err != nil {
+		return err
+	}
+
+	switch e.op {
+	case journalDeleteKey:
+		err = st.Delete(e.key)
+		if errors.Is(err, engine.ErrKeyNotFound) {
+			return nil
+		}
+		return err
+	case journalPutKey:
+		return st.Put(e.key, e.value)
+	}
+
+	for _, item := range e.items {
+		err = st.Put(item.key, item.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// journalingTransaction wraps an engine transaction and records how to undo
+// the changes made to its stores while the transaction has savepoints.
+type journalingTransaction struct {
+	engine.Transaction
+
+	tx *Transaction
+}
+
+func (t *journalingTransaction) journaling() bool {
+	return len(t.tx.savepoints) > 0
+}
+
+func (t *journalingTransaction) GetStore(name []byte) (engine.Store, error) {
+	st, err := t.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &journalingStore{Store: st, name: append([]byte(nil), name...), tx: t}, nil
+}
+
+func (t *journalingTransaction) CreateStore(name []byte) error {
+	err := t.Transaction.CreateStore(name)
+	if err != nil || !t.journaling() {
+		return err
+	}
+
+	t.tx.journal = append(t.tx.journal, journalEntry{
+		op:    journalDropStore,
+		store: append([]byte(nil), name...),
+	})
+	return nil
+}
+
+func (t *journalingTransaction) DropStore(name []byte) error {
+	if !t.journaling() {
+		return t.Transaction.DropStore(name)
+	}
+
+	st, err := t.Transaction.GetStore(name)
+	if err != nil {
+		return err
+	}
+
+	items, err := storeItems(st)
+	if err != nil {
+		return err
+	}
+
+	err = t.Transaction.DropStore(name)
+	if err != nil {
+		return err
+	}
+
+	t.tx.journal = append(t.tx.journal, journalEntry{
+		op:    journalCreateStore,
+		store: append([]byte(nil), name...),
+		items: items,
+	})
+	return nil
+}
+
+type journalingStore struct {
+	engine.Store
+
+	name []byte
+	tx   *journalingTransaction
+}
+
+// Unwrap returns the wrapped store.
+func (s *journalingStore) Unwrap() engine.Store {
+	return s.Store
+}
+
+func (s *journalingStore) Put(k, v []byte) error {
+	if !s.tx.journaling() {
+		return s.Store.Put(k, v)
+	}
+
+	e, err := s.keyEntry(k)
+	if err != nil {
+		return err
+	}
+
+	err = s.Store.Put(k, v)
+	if err != nil {
+		return err
+	}
+
+	s.tx.tx.journal = append(s.tx.tx.journal, e)
+	return nil
+}
+
+// asJournalingStore returns the journaling store of the chain of wrapped stores starting at st.
+func asJournalingStore(st engine.Store) (*journalingStore, bool) {
+	for st != nil {
+		if js, ok := st.(*journalingStore); ok {
+			return js, true
+		}
+
+		u, ok := st.(interface{ Unwrap() engine.Store })
+		if !ok {
+			break
+		}
+		st = u.Unwrap()
+	}
+
+	return nil, false
+}
+
+// putWithExpiration stores a key value pair that expires at expiresAt using es,
+// the engine store wrapped by s.
+// Rolling back to a savepoint restores the previous value without expiration,
+// expired documents are still skipped by readers until they are purged.
+func (s *journalingStore) putWithExpiration(es engine.ExpiringStore, k, v []byte, expiresAt time.Time) error {
+	if !s.tx.journaling() {
+		return es.PutWithExpiration(k, v, expiresAt)
+	}
+
+	e, err := s.keyEntry(k)
+	if err != nil {
+		return err
+	}
+
+	err = es.PutWithExpiration(k, v, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	s.tx.tx.journal = append(s.tx.tx.journal, e)
+	return nil
+}
+
+func (s *journalingStore) Delete(k []byte) error {
+	if !s.tx.journaling() {
+		return s.Store.Delete(k)
+	}
+
+	e, err := s.keyEntry(k)
+	if err != nil {
+		return err
+	}
+
+	err = s.Store.Delete(k)
+	if err != nil {
+		return err
+	}
+
+	s.tx.tx.journal = append(s.tx.tx.journal, e)
+	return nil
+}
+
+func (s *journalingStore) Truncate() error {
+	if !s.tx.journaling() {
+		return s.Store.Truncate()
+	}
+
+	items, err := storeItems(s.Store)
+	if err != nil {
+		return err
+	}
+
+	err = s.Store.Truncate()
+	if err != nil {
+		return err
+	}
+
+	s.tx.tx.journal = append(s.tx.tx.journal, journalEntry{
+		op:    journalRestoreStore,
+		store: s.name,
+		items: items,
+	})
+	return nil
+}
+
+// keyEntry returns the entry restoring the current value of k.
+func (s *journalingStore) keyEntry(k []byte) (journalEntry, error) {
+	e := journalEntry{
+		store: s.name,
+		key:   append([]byte(nil), k...),
+	}
+
+	v, err := s.Store.Get(k)
+	switch {
+	case errors.Is(err, engine.ErrKeyNotFound):
+		e.op = journalDeleteKey
+	case err != nil:
+		return e, err
+	default:
+		e.op = journalPutKey
+		e.value = append([]byte(nil), v...)
+	}
+
+	return e, nil
+}
+
+// storeItems returns a copy of all the key value pairs of st.
+func storeItems(st engine.Store) ([]journalItem, error) {
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var items []journalItem
+	for it.Seek(nil); it.Valid(); it.Next() {
+		item := it.Item()
+		v, err := item.ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, journalItem{
+			key:   append([]byte(nil), item.Key()...),
+			value: v,
+		})
+	}
+
+	return items, it.Err()
+}
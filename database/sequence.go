@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/genjidb/genji/engine"
+)
+
+// sequenceStorePrefix is the prefix of the names of the stores backing sequences.
+var sequenceStorePrefix = internalPrefix + "sequence_"
+
+// CreateSequence creates a sequence with the given name.
+// Each sequence is backed by its own store, whose values are generated by Store.NextSequence.
+// If it already exists, returns ErrSequenceAlreadyExists.
+func (tx *Transaction) CreateSequence(name string) error {
+	if name == "" {
+		return errors.New("missing sequence name")
+	}
+
+	err := tx.tx.CreateStore([]byte(sequenceStorePrefix + name))
+	if err == engine.ErrStoreAlreadyExists {
+		return fmt.Errorf("%w: %q", ErrSequenceAlreadyExists, name)
+	}
+
+	return err
+}
+
+// DropSequence removes a sequence.
+// If it doesn't exist, returns ErrSequenceNotFound.
+func (tx *Transaction) DropSequence(name string) error {
+	err := tx.tx.DropStore([]byte(sequenceStorePrefix + name))
+	if err == engine.ErrStoreNotFound {
+		return fmt.Errorf("%w: %q", ErrSequenceNotFound, name)
+	}
+
+	return err
+}
+
+// NextSequenceValue increments the sequence and returns its new value.
+// The first value of a sequence is 1. Like the keys generated for tables
+// without primary key, values are never reused, even if the transaction is rolled back.
+// If the sequence doesn't exist, returns ErrSequenceNotFound.
+func (tx *Transaction) NextSequenceValue(name string) (int64, error) {
+	st, err := tx.tx.GetStore([]byte(sequenceStorePrefix + name))
+	if err == engine.ErrStoreNotFound {
+		return 0, fmt.Errorf("%w: %q", ErrSequenceNotFound, name)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	seq, err := st.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	if seq > math.MaxInt64 {
+		return 0, fmt.Errorf("sequence %q has reached its maximum value", name)
+	}
+
+	return int64(seq), nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+
+	"github.com/genjidb/genji/engine"
+)
+
+// Stats contains statistics about a database.
+type Stats struct {
+	// TableCount is the number of tables, excluding system tables.
+	TableCount int
+	// IndexCount is the number of indexes.
+	IndexCount int
+	// Tables contains the statistics of each table, sorted by name.
+	Tables []TableStats
+	// Engine contains the statistics returned by the engine if it, or the engine it wraps, implements
+	// the engine.StatsEngine interface, nil otherwise.
+	Engine interface{}
+}
+
+// TableStats contains statistics about a table.
+type TableStats struct {
+	Name string
+	// Indexes contains the names of the indexes of the table.
+	Indexes []string
+	// ApproximateDocumentCount is an estimation of the number of documents of the table.
+	// If the store of the table implements the engine.KeyCounter interface, it is computed
+	// without reading the documents, otherwise by iterating over their keys.
+	ApproximateDocumentCount int
+}
+
+// Stats returns statistics about the database, computed from the catalog and the engine.
+// It opens a read-only transaction and fails if a transaction is attached to the database.
+func (db *Database) Stats(ctx context.Context) (*Stats, error) {
+	stats, err := db.catalogStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the engine statistics are read once the transaction is closed,
+	// since some engines need to lock the database to compute them.
+	if ng, ok := engine.AsStatsEngine(db.ng); ok {
+		stats.Engine, err = ng.Stats()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+func (db *Database) catalogStats(ctx context.Context) (*Stats, error) {
+	tx, err := db.BeginTx(ctx, &TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	names, err := tx.tableInfoStore.ListAll()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, err := tx.ListIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := Stats{
+		TableCount: len(names),
+		IndexCount: len(indexes),
+		Tables:     make([]TableStats, len(names)),
+	}
+
+	for i, name := range names {
+		ts := &stats.Tables[i]
+		ts.Name = name
+
+		for _, idx := range indexes {
+			if idx.TableName == name {
+				ts.Indexes = append(ts.Indexes, idx.IndexName)
+			}
+		}
+
+		tb, err := tx.GetTable(name)
+		if err != nil {
+			return nil, err
+		}
+
+		ts.ApproximateDocumentCount, err = approximateKeyCount(tb.Store)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &stats, nil
+}
+
+// approximateKeyCount uses the engine.KeyCounter interface if st or one of the stores it wraps implements it,
+// otherwise it iterates over the keys of st.
+func approximateKeyCount(st engine.Store) (int, error) {
+	if kc, ok := engine.AsKeyCounter(st); ok {
+		return kc.ApproximateKeyCount()
+	}
+
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var n int
+	for it.Seek(nil); it.Valid(); it.Next() {
+		n++
+	}
+
+	return n, it.Err()
+}
@@ -0,0 +1,108 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/boltengine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseStats(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		db, err := genji.New(context.Background(), memoryengine.NewEngine())
+		require.NoError(t, err)
+		defer db.Close()
+
+		stats := testStats(t, db)
+		ng, ok := stats.Engine.(memoryengine.Stats)
+		require.True(t, ok)
+		require.NotZero(t, ng.StoreCount)
+		require.NotZero(t, ng.KeyCount)
+	})
+
+	t.Run("logging", func(t *testing.T) {
+		var nexts int
+		ng := engine.NewLoggingEngine(memoryengine.NewEngine(), engine.LoggerFunc(func(op engine.Operation) {
+			if op.Name == "Next" {
+				nexts++
+			}
+		}))
+		db, err := genji.New(context.Background(), ng)
+		require.NoError(t, err)
+		defer db.Close()
+
+		stats := testStats(t, db)
+		_, ok := stats.Engine.(memoryengine.Stats)
+		require.True(t, ok)
+
+		// the documents of the tables are counted by the wrapped stores,
+		// the number of iterations doesn't depend on the number of documents
+		count := func() int {
+			nexts = 0
+			_, err := db.Stats()
+			require.NoError(t, err)
+			return nexts
+		}
+		n := count()
+		err = db.Exec("INSERT INTO foo (a) SELECT a FROM bar; INSERT INTO foo (a) SELECT a FROM foo")
+		require.NoError(t, err)
+		require.Equal(t, n, count())
+	})
+
+	t.Run("bolt", func(t *testing.T) {
+		ng, err := boltengine.NewEngine(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+		require.NoError(t, err)
+
+		db, err := genji.New(context.Background(), ng)
+		require.NoError(t, err)
+		defer db.Close()
+
+		stats := testStats(t, db)
+		bs, ok := stats.Engine.(boltengine.Stats)
+		require.True(t, ok)
+		require.NotZero(t, bs.FileSize)
+		require.NotZero(t, bs.PageSize)
+	})
+}
+
+func testStats(t *testing.T, db *genji.DB) *database.Stats {
+	t.Helper()
+
+	err := db.Exec(`
+		CREATE TABLE foo;
+		CREATE TABLE bar(a INTEGER PRIMARY KEY);
+		CREATE INDEX idx_foo_a ON foo(a);
+		CREATE INDEX idx_foo_b ON foo(b);
+		CREATE INDEX idx_bar_b ON bar(b);
+		INSERT INTO foo (a) VALUES (1), (2), (3);
+		INSERT INTO bar (a) VALUES (1), (2);
+		DELETE FROM foo WHERE a = 2;
+	`)
+	require.NoError(t, err)
+
+	stats, err := db.Stats()
+	require.NoError(t, err)
+
+	require.Equal(t, 2, stats.TableCount)
+	require.Equal(t, 3, stats.IndexCount)
+	require.Equal(t, []database.TableStats{
+		{Name: "bar", Indexes: []string{"idx_bar_b"}, ApproximateDocumentCount: 2},
+		{Name: "foo", Indexes: []string{"idx_foo_a", "idx_foo_b"}, ApproximateDocumentCount: 2},
+	}, stats.Tables)
+
+	// stats can't be computed within a transaction attached to the database
+	err = db.Exec("BEGIN")
+	require.NoError(t, err)
+	_, err = db.Stats()
+	require.Error(t, err)
+	err = db.Exec("ROLLBACK")
+	require.NoError(t, err)
+
+	return stats
+}
@@ -18,6 +18,21 @@ type Table struct {
 	Store     engine.Store
 	name      string
 	infoStore *tableInfoStore
+	// if true, GetDocument doesn't read documents
+	// from the store until one of their fields is accessed.
+	keysOnly bool
+	// if not nil, documents returned by Iterate and GetDocument
+	// only contain these top-level fields.
+	fields []string
+	// number of items fetched ahead by the iterator of Iterate.
+	readAhead int
+	// if not nil, the table is a prefix table that only reads
+	// the documents whose key starts with the prefix.
+	prefix *PrefixTableConfig
+	// if true, the documents of the materialized view can be written.
+	refreshing bool
+	// if true, the table is a temporary table of the transaction.
+	temporary bool
 }
 
 // Tx returns the current transaction.
@@ -27,9 +42,52 @@ func (t *Table) Tx() *Transaction {
 
 // Info of the table.
 func (t *Table) Info() (*TableInfo, error) {
+	if t.prefix != nil {
+		return t.prefixTableInfo()
+	}
+
 	return t.infoStore.Get(t.tx, t.name)
 }
 
+// KeysOnly returns a copy of the table whose GetDocument method doesn't read the document
+// from the store until one of its fields is accessed. It can be used to read the keys
+// selected by an index without fetching the documents.
+// Since the document isn't read, GetDocument doesn't return ErrDocumentNotFound
+// if the key doesn't exist, accessing the fields of the document will instead.
+// Its Iterate method tells the engine that only the keys of the store are read, and
+// the values are only read if the documents are accessed.
+// If the primary key of the table is a top-level field, its value is decoded
+// from the key of the documents instead of being read from the store.
+func (t *Table) KeysOnly() *Table {
+	tb := *t
+	tb.keysOnly = true
+	return &tb
+}
+
+// WithFields returns a copy of the table whose Iterate and GetDocument methods return documents
+// that only contain the given top-level fields. These fields are decoded in a single pass
+// the first time the document is accessed and, if the codec implements encoding.FieldsDecoder,
+// the values of the other fields are never decoded.
+// It can be used to read a few fields of large documents.
+// The returned table must only be used to read documents.
+func (t *Table) WithFields(fields []string) *Table {
+	tb := *t
+	tb.fields = fields
+	if tb.fields == nil {
+		tb.fields = []string{}
+	}
+	return &tb
+}
+
+// WithReadAhead returns a copy of the table whose Iterate method fetches up to n
+// documents ahead of the current one, see engine.IteratorOptions.ReadAhead.
+// It speeds up full table scans at the cost of memory.
+func (t *Table) WithReadAhead(n int) *Table {
+	tb := *t
+	tb.readAhead = n
+	return &tb
+}
+
 // Name returns the name of the table.
 func (t *Table) Name() string {
 	return t.name
@@ -37,6 +95,15 @@ func (t *Table) Name() string {
 
 // Truncate deletes all the documents from the table.
 func (t *Table) Truncate() error {
+	if t.prefix != nil {
+		return errors.New("cannot write to read-only table")
+	}
+
+	err := t.tx.FlushIndexes()
+	if err != nil {
+		return err
+	}
+
 	return t.Store.Truncate()
 }
 
@@ -50,15 +117,28 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 		return nil, err
 	}
 
-	if info.readOnly {
-		return nil, errors.New("cannot write to read-only table")
+	if err := t.checkWritable(info); err != nil {
+		return nil, err
+	}
+
+	fb, err := info.FieldConstraints.ValidateDocument(t.tx, d)
+	if err != nil {
+		return nil, err
 	}
 
-	fb, err := info.FieldConstraints.ValidateDocument(d)
+	err = t.fireTriggers(info, TriggerBefore, TriggerInsert, nil, fb)
 	if err != nil {
 		return nil, err
 	}
 
+	err = t.deleteExpiredConflicts(info, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	pk := info.GetPrimaryKey()
+	explicitKey := pk != nil && pk.IsAutoIncrement && !needsAutoIncrement(pk, fb)
+
 	key, err := t.generateKey(info, fb)
 	if err != nil {
 		return nil, err
@@ -69,53 +149,184 @@ func (t *Table) Insert(d document.Document) ([]byte, error) {
 		return nil, ErrDuplicateDocument
 	}
 
-	var buf bytes.Buffer
-	enc := t.tx.db.Codec.NewEncoder(&buf)
-	defer enc.Close()
-	err = enc.EncodeDocument(fb)
+	if explicitKey {
+		err = t.advanceSequence(pk, fb)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	v, err := t.encodeDocument(info, fb)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode document: %w", err)
+		return nil, err
+	}
+
+	err = t.put(info, key, v, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.tx.deferIndexes {
+		t.tx.deferIndexUpdate(t.name, key)
+	} else {
+		err = t.setIndexes(fb, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = t.checkForeignKeys(info, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.fireTriggers(info, TriggerAfter, TriggerInsert, nil, fb)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// errStop is used to stop iterations early.
+var errStop = errors.New("stop")
+
+// ConflictingKey returns the key of the document of the table that prevents d from being inserted,
+// because both documents have the same primary key or the same value in a unique index.
+// It returns nil if d can be inserted.
+// Expired documents never conflict with d, they are deleted.
+func (t *Table) ConflictingKey(d document.Document) ([]byte, error) {
+	info, err := t.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	fb, err := info.FieldConstraints.ValidateDocument(t.tx, d)
+	if err != nil {
+		return nil, err
 	}
 
-	err = t.Store.Put(key, buf.Bytes())
+	err = t.deleteExpiredConflicts(info, fb)
 	if err != nil {
 		return nil, err
 	}
 
+	return t.conflictingKey(info, fb)
+}
+
+// conflictingKey returns the key of the document that prevents the validated document fb
+// from being inserted, or nil if there is none.
+func (t *Table) conflictingKey(info *TableInfo, fb *document.FieldBuffer) ([]byte, error) {
+
+	// tables without primary key generate a new key for every document,
+	// and so do tables whose autoincremented primary key is missing
+	if pk := info.GetPrimaryKey(); pk != nil && !needsAutoIncrement(pk, fb) {
+		key, err := t.generateKey(info, fb)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = t.Store.Get(key)
+		if err == nil {
+			return key, nil
+		}
+		if err != engine.ErrKeyNotFound {
+			return nil, err
+		}
+	}
+
 	indexes, err := t.Indexes()
 	if err != nil {
 		return nil, err
 	}
 
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(fb)
+		if !idx.Opts.Unique {
+			continue
+		}
+
+		v, ok, err := t.tx.indexedValue(idx, fb)
+		if err != nil {
+			return nil, err
+		}
+
+		// null values are never equal
+		if !ok || v.Type == document.NullValue {
+			continue
+		}
+
+		var key []byte
+		err = idx.AscendGreaterOrEqual(v, func(_, k []byte, isEqual bool) error {
+			if isEqual {
+				key = append([]byte{}, k...)
+			}
+			return errStop
+		})
+		if err != nil && err != errStop {
+			return nil, err
+		}
+		if key != nil {
+			return key, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// setIndexes adds the document to every index of the table.
+func (t *Table) setIndexes(d document.Document, key []byte) error {
+	indexes, err := t.Indexes()
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		v, ok, err := t.tx.indexedValue(idx, d)
 		if err != nil {
-			v = document.NewNullValue()
+			return err
+		}
+		if !ok {
+			continue
 		}
 
 		err = idx.Set(v, key)
 		if err != nil {
 			if err == index.ErrDuplicate {
-				return nil, ErrDuplicateDocument
+				return duplicateError(idx, v)
 			}
 
-			return nil, err
+			return err
 		}
 	}
 
-	return key, nil
+	return nil
+}
+
+// checkWritable returns an error if the documents of the table can't be written.
+func (t *Table) checkWritable(info *TableInfo) error {
+	if info.readOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	if info.ViewQuery != "" && !t.refreshing {
+		return fmt.Errorf("cannot write to materialized view %q", t.name)
+	}
+
+	return nil
 }
 
 // Delete a document by key.
 // Indexes are automatically updated.
+// If the key doesn't exist, it returns ErrDocumentNotFound,
+// never the engine.ErrKeyNotFound error returned by the underlying store.
 func (t *Table) Delete(key []byte) error {
 	info, err := t.Info()
 	if err != nil {
 		return err
 	}
 
-	if info.readOnly {
-		return errors.New("cannot write to read-only table")
+	if err := t.checkWritable(info); err != nil {
+		return err
 	}
 
 	d, err := t.GetDocument(key)
@@ -123,16 +334,46 @@ func (t *Table) Delete(key []byte) error {
 		return err
 	}
 
+	refs, err := t.referencingForeignKeys()
+	if err != nil {
+		return err
+	}
+
+	// the document is copied since triggers and cascading deletes
+	// can modify the store it was read from
+	var old *document.FieldBuffer
+	if info.hasTriggers(TriggerDelete) || len(refs) > 0 {
+		old = document.NewFieldBuffer()
+		err = old.Copy(d)
+		if err != nil {
+			return err
+		}
+		d = old
+
+		err = t.fireTriggers(info, TriggerBefore, TriggerDelete, old, nil)
+		if err != nil {
+			return err
+		}
+
+		err = t.checkReferences(refs, key, old, nil)
+		if err != nil {
+			return err
+		}
+	}
+
 	indexes, err := t.Indexes()
 	if err != nil {
 		return err
 	}
 
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(d)
+		v, ok, err := t.tx.indexedValue(idx, d)
 		if err != nil {
 			return err
 		}
+		if !ok {
+			continue
+		}
 
 		err = idx.Delete(v, key)
 		if err != nil {
@@ -140,7 +381,15 @@ func (t *Table) Delete(key []byte) error {
 		}
 	}
 
-	return t.Store.Delete(key)
+	err = t.Store.Delete(key)
+	if err == engine.ErrKeyNotFound {
+		return ErrDocumentNotFound
+	}
+	if err != nil || old == nil {
+		return err
+	}
+
+	return t.fireTriggers(info, TriggerAfter, TriggerDelete, old, nil)
 }
 
 // Replace a document by key.
@@ -152,24 +401,63 @@ func (t *Table) Replace(key []byte, d document.Document) error {
 		return err
 	}
 
-	if info.readOnly {
-		return errors.New("cannot write to read-only table")
+	if err := t.checkWritable(info); err != nil {
+		return err
 	}
 
-	d, err = info.FieldConstraints.ValidateDocument(d)
+	fb, err := info.FieldConstraints.ValidateDocument(t.tx, d)
 	if err != nil {
 		return err
 	}
 
+	refs, err := t.referencingForeignKeys()
+	if err != nil {
+		return err
+	}
+
+	var old *document.FieldBuffer
+	if info.hasTriggers(TriggerUpdate) || len(refs) > 0 {
+		cur, err := t.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		old = document.NewFieldBuffer()
+		err = old.Copy(cur)
+		if err != nil {
+			return err
+		}
+
+		err = t.fireTriggers(info, TriggerBefore, TriggerUpdate, old, fb)
+		if err != nil {
+			return err
+		}
+
+		err = t.checkReferences(refs, key, old, fb)
+		if err != nil {
+			return err
+		}
+	}
+
 	indexes, err := t.Indexes()
 	if err != nil {
 		return err
 	}
 
-	return t.replace(indexes, key, d)
+	err = t.replace(info, indexes, key, fb)
+	if err != nil {
+		return err
+	}
+
+	err = t.checkForeignKeys(info, fb)
+	if err != nil || old == nil {
+		return err
+	}
+
+	return t.fireTriggers(info, TriggerAfter, TriggerUpdate, old, fb)
 }
 
-func (t *Table) replace(indexes map[string]Index, key []byte, d document.Document) error {
+func (t *Table) replace(info *TableInfo, indexes map[string]Index, key []byte, fb *document.FieldBuffer) error {
 	// make sure key exists
 	old, err := t.GetDocument(key)
 	if err != nil {
@@ -178,10 +466,13 @@ func (t *Table) replace(indexes map[string]Index, key []byte, d document.Documen
 
 	// remove key from indexes
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(old)
+		v, ok, err := t.tx.indexedValue(idx, old)
 		if err != nil {
 			return err
 		}
+		if !ok {
+			continue
+		}
 
 		err = idx.Delete(v, key)
 		if err != nil {
@@ -190,29 +481,32 @@ func (t *Table) replace(indexes map[string]Index, key []byte, d document.Documen
 	}
 
 	// encode new document
-	var buf bytes.Buffer
-	enc := t.tx.db.Codec.NewEncoder(&buf)
-	defer enc.Close()
-	err = enc.EncodeDocument(d)
+	v, err := t.encodeDocument(info, fb)
 	if err != nil {
-		return fmt.Errorf("failed to encode document: %w", err)
+		return err
 	}
 
 	// replace old document with new document
-	err = t.Store.Put(key, buf.Bytes())
+	err = t.put(info, key, v, fb)
 	if err != nil {
 		return err
 	}
 
 	// update indexes
 	for _, idx := range indexes {
-		v, err := idx.Opts.Path.GetValueFromDocument(d)
+		v, ok, err := t.tx.indexedValue(idx, fb)
 		if err != nil {
+			return err
+		}
+		if !ok {
 			continue
 		}
 
 		err = idx.Set(v, key)
 		if err != nil {
+			if err == index.ErrDuplicate {
+				return duplicateError(idx, v)
+			}
 			return err
 		}
 	}
@@ -220,22 +514,132 @@ func (t *Table) replace(indexes map[string]Index, key []byte, d document.Documen
 	return err
 }
 
+// encodeDocument encodes the fields of fb stored by the table.
+func (t *Table) encodeDocument(info *TableInfo, fb *document.FieldBuffer) ([]byte, error) {
+	fb, err := info.FieldConstraints.storedDocument(fb)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := t.tx.db.Codec.NewEncoder(&buf)
+	defer enc.Close()
+	err = enc.EncodeDocument(fb)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// duplicateError returns the error reported when v can't be added to a unique index
+// because another document has the same value.
+func duplicateError(idx Index, v document.Value) error {
+	if idx.Opts.Constraint {
+		return &ConstraintViolationError{Constraint: "UNIQUE", Path: idx.Opts.Path, Value: v}
+	}
+
+	return ErrDuplicateDocument
+}
+
+// indexedValue returns the value of d indexed by idx.
+// Documents without the indexed field are indexed with a null value,
+// except by the indexes of UNIQUE constraints which, like typed indexes,
+// don't index null values since they are never equal.
+// Composite indexes index the array of the values of their paths.
+// It returns false if idx is a partial index whose predicate doesn't match d.
+func (tx *Transaction) indexedValue(idx Index, d document.Document) (document.Value, bool, error) {
+	if idx.Opts.IsPartial() {
+		ok, err := tx.matchesIndexPredicate(idx, d)
+		if err != nil || !ok {
+			return document.Value{}, false, err
+		}
+	}
+
+	if idx.Opts.IsExpression() {
+		v, err := tx.evalIndexedExpr(idx, d)
+		return v, err == nil, err
+	}
+
+	if !idx.Opts.IsComposite() {
+		v, err := valueAtPath(idx.Opts.Path, d)
+		if err == nil && idx.Opts.Constraint && v.Type == document.NullValue {
+			return v, false, nil
+		}
+		return v, err == nil, err
+	}
+
+	vb := document.NewValueBuffer()
+	for _, p := range idx.Opts.Paths() {
+		v, err := valueAtPath(p, d)
+		if err != nil {
+			return v, false, err
+		}
+
+		vb = vb.Append(v)
+	}
+
+	return document.NewArrayValue(vb), true, nil
+}
+
+// evalIndexedExpr evaluates the expression of an expression index on d.
+// Like the values of untyped fields, integers are indexed as doubles.
+func (tx *Transaction) evalIndexedExpr(idx Index, d document.Document) (document.Value, error) {
+	v, err := tx.evalExpr(idx.Opts.expr, idx.Opts.Expr, d)
+	if err != nil {
+		return v, fmt.Errorf("index %q: %w", idx.Opts.IndexName, err)
+	}
+
+	if v.Type == document.IntegerValue {
+		return v.CastAsDouble()
+	}
+
+	return v, nil
+}
+
+// matchesIndexPredicate evaluates the predicate of a partial index on d.
+func (tx *Transaction) matchesIndexPredicate(idx Index, d document.Document) (bool, error) {
+	v, err := tx.evalExpr(idx.Opts.where, idx.Opts.Where, d)
+	if err != nil {
+		return false, fmt.Errorf("index %q: %w", idx.Opts.IndexName, err)
+	}
+
+	return v.IsTruthy()
+}
+
+// valueAtPath returns the value of d at the given path, or null if there is none.
+func valueAtPath(p document.Path, d document.Document) (document.Value, error) {
+	v, err := p.GetValueFromDocument(d)
+	if err == document.ErrFieldNotFound {
+		return document.NewNullValue(), nil
+	}
+
+	return v, err
+}
+
 // Indexes returns a map of all the indexes of a table.
+// Deferred index updates are flushed before returning the indexes.
 func (t *Table) Indexes() (map[string]Index, error) {
-	s, err := t.tx.tx.GetStore([]byte(indexStoreName))
+	err := t.tx.FlushIndexes()
 	if err != nil {
 		return nil, err
 	}
 
+	return t.indexes()
+}
+
+func (t *Table) indexes() (map[string]Index, error) {
+	idxStore, etx := t.indexCatalog()
+
 	tb := Table{
 		tx:    t.tx,
-		Store: s,
+		Store: idxStore.st,
 		name:  indexStoreName,
 	}
 
 	indexes := make(map[string]Index)
 
-	err = document.NewStream(&tb).
+	err := document.NewStream(&tb).
 		Filter(func(d document.Document) (bool, error) {
 			v, err := d.GetByField("table_name")
 			if err != nil {
@@ -250,16 +654,9 @@ func (t *Table) Indexes() (map[string]Index, error) {
 			if err != nil {
 				return err
 			}
+			opts.parseExprs(t.tx.db)
 
-			idx := index.New(t.tx.tx, opts.IndexName, index.Options{
-				Unique: opts.Unique,
-				Type:   opts.Type,
-			})
-
-			indexes[opts.Path.String()] = Index{
-				Index: idx,
-				Opts:  opts,
-			}
+			indexes[opts.key()] = newIndex(etx, opts)
 
 			return nil
 		})
@@ -270,6 +667,16 @@ func (t *Table) Indexes() (map[string]Index, error) {
 	return indexes, nil
 }
 
+// indexCatalog returns the store holding the configuration of the indexes of the table
+// and the engine transaction of the indexes.
+func (t *Table) indexCatalog() (*indexStore, engine.Transaction) {
+	if t.temporary {
+		return t.tx.temp.indexStore, t.tx.temp.tx
+	}
+
+	return t.tx.indexStore, t.tx.tx
+}
+
 type encodedDocumentWithKey struct {
 	document.Document
 
@@ -282,12 +689,26 @@ func (e encodedDocumentWithKey) RawKey() []byte {
 }
 
 func (e encodedDocumentWithKey) Key() (document.Value, error) {
-	if e.pk == nil {
-		docid, _ := binary.Uvarint(e.key)
+	return decodeKey(e.pk, e.key)
+}
+
+// decodeKey decodes a key generated by generateKey into a value.
+// If the table has no primary key, the key is the docid.
+// If the primary key is typed, the key was encoded using MarshalBinary,
+// otherwise it was encoded with a ValueEncoder.
+func decodeKey(pk *FieldConstraint, key []byte) (document.Value, error) {
+	if pk == nil {
+		docid, _ := binary.Uvarint(key)
 		return document.NewIntegerValue(int64(docid)), nil
 	}
 
-	return e.pk.Path.GetValueFromDocument(&e)
+	if pk.Type != 0 {
+		v := document.Value{Type: pk.Type}
+		err := v.UnmarshalBinary(key)
+		return v, err
+	}
+
+	return document.DecodeValue(key)
 }
 
 // This document implementation waits until
@@ -301,9 +722,15 @@ type lazilyDecodedDocument struct {
 	buf   []byte
 	codec encoding.Codec
 	pk    *FieldConstraint
+	// if set, the value of this field is decoded from the key.
+	keyField string
 }
 
 func (d *lazilyDecodedDocument) GetByField(field string) (v document.Value, err error) {
+	if d.keyField != "" && field == d.keyField {
+		return decodeKey(d.pk, d.item.Key())
+	}
+
 	if len(d.buf) == 0 {
 		err = d.copyFromItem()
 		if err != nil {
@@ -325,18 +752,24 @@ func (d *lazilyDecodedDocument) Iterate(fn func(field string, value document.Val
 	return d.codec.NewDocument(d.buf).Iterate(fn)
 }
 
+// DecodeFields implements the encoding.FieldsDecoder interface.
+func (d *lazilyDecodedDocument) DecodeFields(fields []string, fn func(field string, value document.Value) error) error {
+	if len(d.buf) == 0 {
+		err := d.copyFromItem()
+		if err != nil {
+			return err
+		}
+	}
+
+	return decodeFields(d.codec.NewDocument(d.buf), fields, fn)
+}
+
 func (d *lazilyDecodedDocument) RawKey() []byte {
 	return d.item.Key()
 }
 
 func (d *lazilyDecodedDocument) Key() (document.Value, error) {
-	k := d.item.Key()
-	if d.pk == nil {
-		docid, _ := binary.Uvarint(k)
-		return document.NewIntegerValue(int64(docid)), nil
-	}
-
-	return d.pk.Path.GetValueFromDocument(d)
+	return decodeKey(d.pk, d.item.Key())
 }
 
 func (d *lazilyDecodedDocument) Reset() {
@@ -351,6 +784,208 @@ func (d *lazilyDecodedDocument) copyFromItem() error {
 	return err
 }
 
+// lazilyFetchedDocument only reads the document from the store
+// when GetByField or Iterate are called.
+type lazilyFetchedDocument struct {
+	tb  *Table
+	key []byte
+	pk  *FieldConstraint
+	d   document.Document
+	// if set, the value of this field is decoded from the key.
+	keyField string
+}
+
+func (d *lazilyFetchedDocument) fetch() error {
+	if d.d != nil {
+		return nil
+	}
+
+	v, err := d.tb.Store.Get(d.key)
+	if err != nil {
+		if err == engine.ErrKeyNotFound {
+			return ErrDocumentNotFound
+		}
+		return fmt.Errorf("failed to fetch document %q: %w", d.key, err)
+	}
+
+	d.d = d.tb.tx.db.Codec.NewDocument(v)
+
+	info, err := d.tb.Info()
+	if err != nil {
+		return err
+	}
+	if info.FieldConstraints.hasVirtualFields() {
+		d.d = &generatedDocument{d: d.d, tx: d.tb.tx, fields: info.FieldConstraints}
+	}
+
+	return nil
+}
+
+func (d *lazilyFetchedDocument) GetByField(field string) (document.Value, error) {
+	if d.keyField != "" && field == d.keyField {
+		return decodeKey(d.pk, d.key)
+	}
+
+	err := d.fetch()
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return d.d.GetByField(field)
+}
+
+func (d *lazilyFetchedDocument) Iterate(fn func(field string, value document.Value) error) error {
+	err := d.fetch()
+	if err != nil {
+		return err
+	}
+
+	return d.d.Iterate(fn)
+}
+
+func (d *lazilyFetchedDocument) RawKey() []byte {
+	return d.key
+}
+
+func (d *lazilyFetchedDocument) Key() (document.Value, error) {
+	return decodeKey(d.pk, d.key)
+}
+
+// keyField returns the name of the top-level field holding the primary key,
+// whose value can be decoded from the keys of the documents.
+// It returns an empty string if the primary key is not a top-level field.
+func keyField(pk *FieldConstraint) string {
+	if pk == nil || len(pk.Path) != 1 {
+		return ""
+	}
+
+	return pk.Path[0].FieldName
+}
+
+// fieldsDocument only contains a subset of the top-level fields
+// of another document. The fields are decoded once, when
+// GetByField or Iterate are called for the first time.
+type fieldsDocument struct {
+	document.Keyer
+
+	d       document.Document
+	fields  []string
+	fb      document.FieldBuffer
+	decoded bool
+}
+
+func (d *fieldsDocument) decode() error {
+	if d.decoded {
+		return nil
+	}
+
+	d.fb.Reset()
+	err := decodeFields(d.d, d.fields, func(field string, value document.Value) error {
+		d.fb.Add(field, value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.decoded = true
+	return nil
+}
+
+func (d *fieldsDocument) GetByField(field string) (document.Value, error) {
+	err := d.decode()
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return d.fb.GetByField(field)
+}
+
+func (d *fieldsDocument) Iterate(fn func(field string, value document.Value) error) error {
+	err := d.decode()
+	if err != nil {
+		return err
+	}
+
+	return d.fb.Iterate(fn)
+}
+
+func (d *fieldsDocument) Reset() {
+	d.decoded = false
+}
+
+// generatedDocument adds the values of the virtual generated fields
+// to a document read from the store. They are evaluated the first time the document is accessed.
+type generatedDocument struct {
+	document.Keyer
+
+	d         document.Document
+	tx        *Transaction
+	fields    FieldConstraints
+	fb        document.FieldBuffer
+	generated bool
+}
+
+func (d *generatedDocument) generate() error {
+	if d.generated {
+		return nil
+	}
+
+	d.fb.Reset()
+	err := d.fb.ScanDocument(d.d)
+	if err != nil {
+		return err
+	}
+
+	err = d.fields.generate(d.tx, &d.fb, true)
+	if err != nil {
+		return err
+	}
+
+	d.generated = true
+	return nil
+}
+
+func (d *generatedDocument) GetByField(field string) (document.Value, error) {
+	err := d.generate()
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return d.fb.GetByField(field)
+}
+
+func (d *generatedDocument) Iterate(fn func(field string, value document.Value) error) error {
+	err := d.generate()
+	if err != nil {
+		return err
+	}
+
+	return d.fb.Iterate(fn)
+}
+
+func (d *generatedDocument) Reset() {
+	d.generated = false
+}
+
+// decodeFields calls fn for each field of d whose name is in fields.
+// If d doesn't implement encoding.FieldsDecoder, all of its fields are decoded.
+func decodeFields(d document.Document, fields []string, fn func(field string, value document.Value) error) error {
+	if fd, ok := d.(encoding.FieldsDecoder); ok {
+		return fd.DecodeFields(fields, fn)
+	}
+
+	return d.Iterate(func(field string, value document.Value) error {
+		for _, f := range fields {
+			if f == field {
+				return fn(field, value)
+			}
+		}
+
+		return nil
+	})
+}
+
 // Iterate goes through all the documents of the table and calls the given function by passing each one of them.
 // If the given function returns an error, the iteration stops.
 func (t *Table) Iterate(fn func(d document.Document) error) error {
@@ -366,16 +1001,52 @@ func (t *Table) Iterate(fn func(d document.Document) error) error {
 	}
 	d.pk = info.GetPrimaryKey()
 
-	it := t.Store.Iterator(engine.IteratorOptions{})
+	// virtual generated fields are added to the documents
+	var doc document.Document = &d
+	var gd generatedDocument
+	if info.FieldConstraints.hasVirtualFields() {
+		gd = generatedDocument{Keyer: &d, d: &d, tx: t.tx, fields: info.FieldConstraints}
+		doc = &gd
+	}
+
+	// if only some fields are needed, the document is wrapped
+	// to make sure the other fields are never decoded.
+	var fd fieldsDocument
+	if t.fields != nil {
+		fd = fieldsDocument{Keyer: &d, d: doc, fields: t.fields}
+		doc = &fd
+	}
+
+	// prefix tables only read the keys starting with the prefix
+	// and add the fields decoded from the rest of the key.
+	opts := engine.IteratorOptions{ReadAhead: t.readAhead}
+	var pd prefixDocument
+	if t.prefix != nil {
+		opts.Prefix = []byte(t.prefix.Prefix)
+		pd = prefixDocument{Keyer: &d, d: doc, cfg: t.prefix}
+		doc = &pd
+	}
+
+	if t.keysOnly {
+		opts.KeysOnly = true
+		if t.prefix == nil {
+			d.keyField = keyField(d.pk)
+		}
+	}
+
+	it := t.Store.Iterator(opts)
 	defer it.Close()
 
 	for it.Seek(nil); it.Valid(); it.Next() {
 		d.Reset()
+		gd.Reset()
+		fd.Reset()
+		pd.Reset()
 		d.item = it.Item()
 		// d must be passed as pointer, not value,
 		// because passing a value to an interface
 		// requires an allocation, while it doesn't for a pointer.
-		err = fn(&d)
+		err = fn(doc)
 		if err != nil {
 			return err
 		}
@@ -389,6 +1060,25 @@ func (t *Table) Iterate(fn func(d document.Document) error) error {
 
 // GetDocument returns one document by key.
 func (t *Table) GetDocument(key []byte) (document.Document, error) {
+	if t.prefix != nil {
+		return t.getPrefixDocument(key)
+	}
+
+	if t.keysOnly {
+		info, err := t.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		pk := info.GetPrimaryKey()
+		return &lazilyFetchedDocument{
+			tb:       t,
+			key:      key,
+			pk:       pk,
+			keyField: keyField(pk),
+		}, nil
+	}
+
 	v, err := t.Store.Get(key)
 	if err != nil {
 		if err == engine.ErrKeyNotFound {
@@ -406,6 +1096,15 @@ func (t *Table) GetDocument(key []byte) (document.Document, error) {
 	d.Document = t.tx.db.Codec.NewDocument(v)
 	d.key = key
 	d.pk = info.GetPrimaryKey()
+
+	if info.FieldConstraints.hasVirtualFields() {
+		d.Document = &generatedDocument{d: d.Document, tx: t.tx, fields: info.FieldConstraints}
+	}
+
+	if t.fields != nil {
+		return &fieldsDocument{Keyer: &d, d: d.Document, fields: t.fields}, nil
+	}
+
 	return &d, err
 }
 
@@ -413,10 +1112,15 @@ func (t *Table) GetDocument(key []byte) (document.Document, error) {
 // if the table has a primary key, it extracts the field from
 // the document, converts it to the targeted type and returns
 // its encoded version.
+// if the primary key is missing and autoincremented, the next
+// value of the sequence of the table is set in the document.
 // if there are no primary key in the table, a default
 // key is generated, called the docid.
 func (t *Table) generateKey(info *TableInfo, fb *document.FieldBuffer) ([]byte, error) {
 	if pk := info.GetPrimaryKey(); pk != nil {
+		if needsAutoIncrement(pk, fb) {
+			return t.autoIncrementKey(pk, fb)
+		}
 
 		v, err := pk.Path.GetValueFromDocument(fb)
 		if err == document.ErrFieldNotFound {
@@ -426,30 +1130,170 @@ func (t *Table) generateKey(info *TableInfo, fb *document.FieldBuffer) ([]byte,
 			return nil, err
 		}
 
-		// if a primary key type is specified,
-		// encode the key using the optimized encoding solution
-		if pk.Type != 0 {
-			return v.MarshalBinary()
+		return encodeKey(pk, v)
+	}
+
+	docid, err := t.Store.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, docid)
+	return buf[:n], nil
+}
+
+// needsAutoIncrement returns true if pk is autoincremented and fb has no value for it.
+func needsAutoIncrement(pk *FieldConstraint, fb *document.FieldBuffer) bool {
+	if !pk.IsAutoIncrement {
+		return false
+	}
+
+	v, err := pk.Path.GetValueFromDocument(fb)
+	return err == document.ErrFieldNotFound || (err == nil && v.Type == document.NullValue)
+}
+
+// autoIncrementKey sets the next value of the sequence of the table as the primary key of fb
+// and returns the encoded key. The sequence is moved past the documents inserted with
+// an explicit primary key, generated keys are always greater than existing ones.
+func (t *Table) autoIncrementKey(pk *FieldConstraint, fb *document.FieldBuffer) ([]byte, error) {
+	seq, err := t.Store.NextSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	v := document.NewIntegerValue(int64(seq))
+	err = fb.Set(pk.Path, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeKey(pk, v)
+}
+
+// advanceSequence moves the sequence of the table past the explicit
+// primary key of fb, like SQLite does for AUTOINCREMENT columns.
+func (t *Table) advanceSequence(pk *FieldConstraint, fb *document.FieldBuffer) error {
+	v, err := pk.Path.GetValueFromDocument(fb)
+	if err != nil {
+		return err
+	}
+
+	if v.Type != document.IntegerValue || v.V.(int64) <= 0 {
+		return nil
+	}
+
+	return t.Store.AdvanceSequence(uint64(v.V.(int64)))
+}
+
+// rewrite replaces every document of the table by the one returned by fn.
+// If fn returns nil, the document is left untouched.
+// Indexes are not updated.
+func (t *Table) rewrite(fn func(d document.Document) (*document.FieldBuffer, error)) error {
+	info, err := t.Info()
+	if err != nil {
+		return err
+	}
+
+	// keys are collected first since the store can't be modified while iterating on it
+	var keys [][]byte
+	err = t.KeysOnly().Iterate(func(d document.Document) error {
+		keys = append(keys, append([]byte{}, d.(document.Keyer).RawKey()...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		d, err := t.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		fb, err := fn(d)
+		if err != nil {
+			return err
+		}
+		if fb == nil {
+			continue
 		}
 
-		// it no primary key type is specified,
-		// encode keys regardless of type.
-		var buf bytes.Buffer
-		err = document.NewValueEncoder(&buf).Encode(v)
+		v, err := t.encodeDocument(info, fb)
+		if err != nil {
+			return err
+		}
+
+		err = t.Store.Put(key, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EncodeKey returns the key of the document whose primary key is v,
+// as returned by the pk() function.
+// The value is converted to the type of the primary key first.
+// For tables without a primary key, v must be an integer document id.
+func (t *Table) EncodeKey(v document.Value) ([]byte, error) {
+	info, err := t.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	pk := info.GetPrimaryKey()
+	if pk == nil {
+		v, err = v.CastAsInteger()
 		if err != nil {
 			return nil, err
 		}
-		return buf.Bytes(), nil
+		docid := v.V.(int64)
+		if docid < 0 {
+			return nil, fmt.Errorf("invalid document id %d", docid)
+		}
+
+		buf := make([]byte, binary.MaxVarintLen64)
+		n := binary.PutUvarint(buf, uint64(docid))
+		return buf[:n], nil
 	}
 
-	docid, err := t.Store.NextSequence()
+	v, err = pk.convertComputedValue(v)
 	if err != nil {
 		return nil, err
 	}
 
-	buf := make([]byte, binary.MaxVarintLen64)
-	n := binary.PutUvarint(buf, docid)
-	return buf[:n], nil
+	return encodeKey(pk, v)
+}
+
+// DecodeKey returns the primary key of the document stored under key,
+// as returned by the pk() function. It is the inverse of EncodeKey.
+func (t *Table) DecodeKey(key []byte) (document.Value, error) {
+	info, err := t.Info()
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return decodeKey(info.GetPrimaryKey(), key)
+}
+
+// encodeKey returns the key of the document whose primary key is v.
+func encodeKey(pk *FieldConstraint, v document.Value) ([]byte, error) {
+	// if a primary key type is specified,
+	// encode the key using the optimized encoding solution
+	if pk.Type != 0 {
+		return v.MarshalBinary()
+	}
+
+	// it no primary key type is specified,
+	// encode keys regardless of type.
+	var buf bytes.Buffer
+	err := document.NewValueEncoder(&buf).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // ReIndex all the indexes of the table.
@@ -11,6 +11,7 @@ import (
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/memoryengine"
 	"github.com/genjidb/genji/sql/parser"
 	"github.com/stretchr/testify/require"
@@ -130,6 +131,243 @@ func TestTableGetDocument(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, vc, fc)
 	})
+
+	t.Run("Should distinguish null fields from absent fields", func(t *testing.T) {
+		tb, cleanup := newTestTable(t)
+		defer cleanup()
+
+		doc := document.NewFieldBuffer().
+			Add("present", document.NewTextValue("a")).
+			Add("null", document.NewNullValue())
+
+		key, err := tb.Insert(doc)
+		require.NoError(t, err)
+
+		res, err := tb.GetDocument(key)
+		require.NoError(t, err)
+
+		v, err := res.GetByField("present")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("a"), v)
+
+		v, err = res.GetByField("null")
+		require.NoError(t, err)
+		require.Equal(t, document.NewNullValue(), v)
+
+		_, err = res.GetByField("absent")
+		require.Equal(t, document.ErrFieldNotFound, err)
+
+		var fields []string
+		err = res.Iterate(func(field string, v document.Value) error {
+			fields = append(fields, field)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"present", "null"}, fields)
+	})
+}
+
+func TestTableKeysOnly(t *testing.T) {
+	var gets int
+	ng := engine.NewLoggingEngine(memoryengine.NewEngine(), engine.LoggerFunc(func(op engine.Operation) {
+		if op.Name == "Get" && op.Store != "__genji_tables" {
+			gets++
+		}
+	}))
+	db, err := database.New(context.Background(), ng, database.Options{
+		Codec: msgpack.NewCodec(),
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.CreateTable("test", nil)
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	key, err := tb.Insert(document.NewFieldBuffer().Add("a", document.NewTextValue("foo")))
+	require.NoError(t, err)
+
+	gets = 0
+	ko := tb.KeysOnly()
+
+	d, err := ko.GetDocument(key)
+	require.NoError(t, err)
+	k, err := d.(document.Keyer).Key()
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(1), k)
+	require.Equal(t, key, d.(document.Keyer).RawKey())
+	require.Zero(t, gets)
+
+	// the document is fetched once one of its fields is accessed
+	v, err := d.GetByField("a")
+	require.NoError(t, err)
+	require.Equal(t, document.NewTextValue("foo"), v)
+	require.Equal(t, 1, gets)
+
+	// unknown keys are only reported when the document is fetched
+	d, err = ko.GetDocument([]byte("unknown"))
+	require.NoError(t, err)
+	_, err = d.GetByField("a")
+	require.Equal(t, database.ErrDocumentNotFound, err)
+
+	// the original table is unchanged
+	_, err = tb.GetDocument([]byte("unknown"))
+	require.Equal(t, database.ErrDocumentNotFound, err)
+
+	// top-level primary keys are decoded from the keys
+	err = tx.CreateTable("withpk", &database.TableInfo{
+		FieldConstraints: []database.FieldConstraint{
+			{Path: parsePath(t, "id"), Type: document.TextValue, IsPrimaryKey: true},
+		},
+	})
+	require.NoError(t, err)
+	tb, err = tx.GetTable("withpk")
+	require.NoError(t, err)
+	key, err = tb.Insert(document.NewFieldBuffer().Add("id", document.NewTextValue("foo")).Add("a", document.NewIntegerValue(1)))
+	require.NoError(t, err)
+
+	gets = 0
+	ko = tb.KeysOnly()
+	d, err = ko.GetDocument(key)
+	require.NoError(t, err)
+	v, err = d.GetByField("id")
+	require.NoError(t, err)
+	require.Equal(t, document.NewTextValue("foo"), v)
+	require.Zero(t, gets)
+
+	var n int
+	err = ko.Iterate(func(d document.Document) error {
+		n++
+		v, err := d.GetByField("id")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("foo"), v)
+		v, err = d.GetByField("a")
+		require.NoError(t, err)
+		require.Equal(t, document.NewDoubleValue(1), v)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestTableWithFields(t *testing.T) {
+	tb, cleanup := newTestTable(t)
+	defer cleanup()
+
+	doc := newDocument().Add("fieldc", document.NewIntegerValue(10))
+	key, err := tb.Insert(doc)
+	require.NoError(t, err)
+
+	wf := tb.WithFields([]string{"fieldc", "fielda", "unknown"})
+
+	check := func(d document.Document) {
+		t.Helper()
+
+		data, err := document.MarshalJSON(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"fielda": "a", "fieldc": 10}`, string(data))
+
+		_, err = d.GetByField("fieldb")
+		require.Equal(t, document.ErrFieldNotFound, err)
+
+		k, err := d.(document.Keyer).Key()
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(1), k)
+		require.Equal(t, key, d.(document.Keyer).RawKey())
+	}
+
+	var i int
+	err = wf.Iterate(func(d document.Document) error {
+		i++
+		check(d)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+
+	d, err := wf.GetDocument(key)
+	require.NoError(t, err)
+	check(d)
+
+	// no fields
+	d, err = tb.WithFields(nil).GetDocument(key)
+	require.NoError(t, err)
+	l, err := document.Length(d)
+	require.NoError(t, err)
+	require.Zero(t, l)
+
+	// the original table is unchanged
+	d, err = tb.GetDocument(key)
+	require.NoError(t, err)
+	_, err = d.GetByField("fieldb")
+	require.NoError(t, err)
+}
+
+// TestTableKey verifies that keys are decoded using the primary key type.
+func TestTableKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		pk       *database.FieldConstraint
+		value    document.Value
+		expected document.Value
+	}{
+		{"docid", nil, document.NewTextValue("foo"), document.NewIntegerValue(1)},
+		{"text", &database.FieldConstraint{Type: document.TextValue}, document.NewTextValue("foo"), document.NewTextValue("foo")},
+		{"blob", &database.FieldConstraint{Type: document.BlobValue}, document.NewBlobValue([]byte("foo")), document.NewBlobValue([]byte("foo"))},
+		{"double", &database.FieldConstraint{Type: document.DoubleValue}, document.NewIntegerValue(10), document.NewDoubleValue(10)},
+		{"untyped", &database.FieldConstraint{}, document.NewBlobValue([]byte("foo")), document.NewBlobValue([]byte("foo"))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx, cleanup := newTestDB(t)
+			defer cleanup()
+
+			var info database.TableInfo
+			if test.pk != nil {
+				test.pk.Path = parsePath(t, "a")
+				test.pk.IsPrimaryKey = true
+				info.FieldConstraints = database.FieldConstraints{*test.pk}
+			}
+
+			err := tx.CreateTable("test", &info)
+			require.NoError(t, err)
+			tb, err := tx.GetTable("test")
+			require.NoError(t, err)
+
+			key, err := tb.Insert(document.NewFieldBuffer().Add("a", test.value))
+			require.NoError(t, err)
+
+			for _, tb := range []*database.Table{tb, tb.KeysOnly()} {
+				d, err := tb.GetDocument(key)
+				require.NoError(t, err)
+				k, err := d.(document.Keyer).Key()
+				require.NoError(t, err)
+				require.Equal(t, test.expected, k)
+			}
+
+			err = tb.Iterate(func(d document.Document) error {
+				k, err := d.(document.Keyer).Key()
+				require.NoError(t, err)
+				require.Equal(t, test.expected, k)
+				return nil
+			})
+			require.NoError(t, err)
+
+			encoded, err := tb.EncodeKey(test.expected)
+			require.NoError(t, err)
+			require.Equal(t, key, encoded)
+
+			decoded, err := tb.DecodeKey(key)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, decoded)
+		})
+	}
 }
 
 // TestTableInsert verifies Insert behaviour.
@@ -342,8 +580,8 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, false, document.Value{}},
-				{parsePath(t, "bar"), document.IntegerValue, false, false, document.Value{}},
+				{Path: parsePath(t, "foo"), Type: document.IntegerValue},
+				{Path: parsePath(t, "bar"), Type: document.IntegerValue},
 			},
 		})
 		require.NoError(t, err)
@@ -383,7 +621,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.DoubleValue, false, false, document.Value{}},
+				{Path: parsePath(t, "foo"), Type: document.DoubleValue},
 			},
 		})
 		require.NoError(t, err)
@@ -405,7 +643,7 @@ func TestTableInsert(t *testing.T) {
 		// no enforced type, not null
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), 0, false, true, document.Value{}},
+				{Path: parsePath(t, "foo"), IsNotNull: true},
 			},
 		})
 		require.NoError(t, err)
@@ -415,7 +653,7 @@ func TestTableInsert(t *testing.T) {
 		// enforced type, not null
 		err = tx.CreateTable("test2", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, true, document.Value{}},
+				{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsNotNull: true},
 			},
 		})
 		require.NoError(t, err)
@@ -460,7 +698,7 @@ func TestTableInsert(t *testing.T) {
 		// no enforced type, not null
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), 0, false, true, document.NewIntegerValue(42)},
+				{Path: parsePath(t, "foo"), IsNotNull: true, DefaultValue: document.NewIntegerValue(42)},
 			},
 		})
 		require.NoError(t, err)
@@ -470,7 +708,7 @@ func TestTableInsert(t *testing.T) {
 		// enforced type, not null
 		err = tx.CreateTable("test2", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo"), document.IntegerValue, false, true, document.NewIntegerValue(42)},
+				{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsNotNull: true, DefaultValue: document.NewIntegerValue(42)},
 			},
 		})
 		require.NoError(t, err)
@@ -526,7 +764,7 @@ func TestTableInsert(t *testing.T) {
 
 		err := tx.CreateTable("test1", &database.TableInfo{
 			FieldConstraints: []database.FieldConstraint{
-				{parsePath(t, "foo[1]"), 0, false, true, document.Value{}},
+				{Path: parsePath(t, "foo[1]"), IsNotNull: true},
 			},
 		})
 		require.NoError(t, err)
@@ -542,6 +780,78 @@ func TestTableInsert(t *testing.T) {
 				Append(document.NewIntegerValue(1)).Append(document.NewIntegerValue(2)))))
 		require.NoError(t, err)
 	})
+
+	t.Run("Should evaluate default and generating expressions", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		err := tx.CreateTable("test", &database.TableInfo{
+			FieldConstraints: []database.FieldConstraint{
+				{Path: parsePath(t, "a"), Type: document.IntegerValue, DefaultExpr: "1 + 1"},
+				{Path: parsePath(t, "b"), Type: document.IntegerValue, GeneratedExpr: "a * `order`"},
+			},
+		})
+		require.NoError(t, err)
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+
+		key, err := tb.Insert(document.NewFieldBuffer().Add("order", document.NewIntegerValue(3)))
+		require.NoError(t, err)
+
+		d, err := tb.GetDocument(key)
+		require.NoError(t, err)
+		data, err := document.MarshalJSON(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"order": 3, "a": 2, "b": 6}`, string(data))
+	})
+
+	t.Run("Should evaluate the expressions of partial and expression indexes", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		err := tx.CreateTable("test", nil)
+		require.NoError(t, err)
+		err = tx.CreateIndex(database.IndexConfig{
+			IndexName: "idx_partial",
+			TableName: "test",
+			Path:      parsePath(t, "a"),
+			Where:     "`order` > 1",
+		})
+		require.NoError(t, err)
+		err = tx.CreateIndex(database.IndexConfig{
+			IndexName: "idx_expr",
+			TableName: "test",
+			Expr:      "a + `order`",
+		})
+		require.NoError(t, err)
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+
+		for i := int64(0); i < 4; i++ {
+			_, err = tb.Insert(document.NewFieldBuffer().
+				Add("a", document.NewIntegerValue(i)).
+				Add("order", document.NewIntegerValue(i)))
+			require.NoError(t, err)
+		}
+
+		countIndexElems := func(name string, pivot document.Value) int {
+			idx, err := tx.GetIndex(name)
+			require.NoError(t, err)
+
+			var i int
+			err = idx.AscendGreaterOrEqual(pivot, func(v, k []byte, isEqual bool) error {
+				i++
+				return nil
+			})
+			require.NoError(t, err)
+			return i
+		}
+
+		// only a = 2 and a = 3 match the predicate
+		require.Equal(t, 2, countIndexElems("idx_partial", document.Value{Type: document.DoubleValue}))
+		// a + order is 0, 2, 4 and 6
+		require.Equal(t, 2, countIndexElems("idx_expr", document.NewDoubleValue(4)))
+	})
 }
 
 // TestTableDelete verifies Delete behaviour.
@@ -554,6 +864,14 @@ func TestTableDelete(t *testing.T) {
 		require.Equal(t, database.ErrDocumentNotFound, err)
 	})
 
+	t.Run("Should fail if not found with a keys only table", func(t *testing.T) {
+		tb, cleanup := newTestTable(t)
+		defer cleanup()
+
+		err := tb.KeysOnly().Delete([]byte("id"))
+		require.Equal(t, database.ErrDocumentNotFound, err)
+	})
+
 	t.Run("Should delete the right document", func(t *testing.T) {
 		tb, cleanup := newTestTable(t)
 		defer cleanup()
@@ -838,6 +1156,43 @@ func BenchmarkTableInsert(b *testing.B) {
 	}
 }
 
+// BenchmarkTableDelete benchmarks deleting 1000 documents of a table
+// in a database with 1, 10 and 100 other tables.
+func BenchmarkTableDelete(b *testing.B) {
+	for size := 1; size <= 100; size *= 10 {
+		b.Run(fmt.Sprintf("%.03d", size), func(b *testing.B) {
+			var fb document.FieldBuffer
+			fb.Add("a", document.NewIntegerValue(1))
+
+			b.ResetTimer()
+			b.StopTimer()
+			for i := 0; i < b.N; i++ {
+				tb, cleanup := newTestTable(b)
+
+				for j := 0; j < size; j++ {
+					err := tb.Tx().CreateTable(fmt.Sprintf("other%d", j), nil)
+					require.NoError(b, err)
+				}
+
+				keys := make([][]byte, 1000)
+				for j := range keys {
+					var err error
+					keys[j], err = tb.Insert(&fb)
+					require.NoError(b, err)
+				}
+
+				b.StartTimer()
+				for _, k := range keys {
+					err := tb.Delete(k)
+					require.NoError(b, err)
+				}
+				b.StopTimer()
+				cleanup()
+			}
+		})
+	}
+}
+
 // BenchmarkTableScan benchmarks the Scan method with 1, 10, 1000 and 10000 successive insertions.
 func BenchmarkTableScan(b *testing.B) {
 	for size := 1; size <= 10000; size *= 10 {
@@ -866,3 +1221,52 @@ func BenchmarkTableScan(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkTableScanFields compares reading two fields of documents of 100 fields
+// with and without restricting the decoded fields with WithFields.
+func BenchmarkTableScanFields(b *testing.B) {
+	tb, cleanup := newTestTable(b)
+	defer cleanup()
+
+	var fb document.FieldBuffer
+	for i := int64(0); i < 100; i++ {
+		fb.Add(fmt.Sprintf("name-%d", i), document.NewIntegerValue(i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		_, err := tb.Insert(&fb)
+		require.NoError(b, err)
+	}
+
+	fields := []string{"name-10", "name-50"}
+	read := func(d document.Document) error {
+		for _, f := range fields {
+			_, err := d.GetByField(f)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	b.Run("all fields", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tb.Iterate(func(d document.Document) error {
+				return d.Iterate(func(string, document.Value) error { return nil })
+			})
+		}
+	})
+
+	b.Run("lookup", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tb.Iterate(read)
+		}
+	})
+
+	b.Run("with fields", func(b *testing.B) {
+		wf := tb.WithFields(fields)
+		for i := 0; i < b.N; i++ {
+			wf.Iterate(read)
+		}
+	})
+}
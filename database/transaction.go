@@ -1,12 +1,14 @@
 package database
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
 	"github.com/genjidb/genji/index"
 )
 
@@ -29,6 +31,73 @@ type Transaction struct {
 
 	tableInfoStore *tableInfoStore
 	indexStore     *indexStore
+
+	// foreign keys referencing each table, by referenced table name.
+	// Loaded the first time they are needed and reset every time
+	// the information of a table is modified.
+	foreignKeyRefs map[string][]foreignKeyRef
+
+	// number of temporary stores created by this transaction.
+	// used to generate unique store names.
+	tempStoreCount int
+	// engine and transaction in which a read-only transaction creates
+	// its temporary stores and in which temporary tables are created.
+	// See Database.NewTempEngine.
+	tempEngine engine.Engine
+	tempTx     engine.Transaction
+
+	// primary key of the last document inserted by a statement of this transaction.
+	lastInsertKey document.Value
+
+	// number of iterators currently open.
+	openIterators int
+
+	// temporary tables created during this transaction, nil until the first one is created.
+	temp *tempCatalog
+
+	// if true, index updates of inserted documents are deferred
+	// until the indexes are flushed.
+	deferIndexes bool
+	// keys of the documents whose index updates were deferred, by table name.
+	deferredKeys map[string][][]byte
+	// names of the tables of deferredKeys, in the order of their first insertion.
+	deferredTables []string
+
+	// number of triggers currently running.
+	triggerDepth int
+
+	// savepoints of the transaction, from the oldest to the most recent.
+	savepoints []savepoint
+	// changes made to the engine since the oldest savepoint was created.
+	journal []journalEntry
+}
+
+// OpenIterators returns the number of store iterators opened by this transaction
+// that are not closed yet.
+func (tx *Transaction) OpenIterators() int {
+	return tx.openIterators
+}
+
+// LastInsertKey returns the primary key of the last document inserted by a statement
+// of this transaction. If the table doesn't have a primary key, it is the generated key
+// as an integer. When several documents are inserted by the same statement, it is
+// the key of the last one.
+// If no document was inserted, it returns NULL.
+func (tx *Transaction) LastInsertKey() document.Value {
+	if tx.lastInsertKey.Type == 0 {
+		return document.NewNullValue()
+	}
+
+	return tx.lastInsertKey
+}
+
+// SetLastInsertKey sets the primary key of the last inserted document.
+// It is called once per statement by the package running the statements,
+// documents inserted by triggers or by cascading actions are not taken into account.
+// It is discarded when the transaction is rolled back, or restored when it is rolled back
+// to a savepoint.
+func (tx *Transaction) SetLastInsertKey(v document.Value) {
+	tx.lastInsertKey = v
 }
 
 // DB returns the underlying database that created the transaction.
@@ -38,11 +107,20 @@ func (tx *Transaction) DB() *Database {
 
 // Rollback the transaction. Can be used safely after commit.
 func (tx *Transaction) Rollback() error {
-	err := tx.tx.Rollback()
+	err := tx.closeTempEngine()
+	if err != nil {
+		_ = tx.tx.Rollback()
+		return err
+	}
+
+	err = tx.tx.Rollback()
 	if err != nil {
 		return err
 	}
 
+	tx.deferredTables, tx.deferredKeys = nil, nil
+	tx.lastInsertKey = document.Value{}
+
 	if tx.attached {
 		tx.db.attachedTxMu.Lock()
 		defer tx.db.attachedTxMu.Unlock()
@@ -56,8 +134,22 @@ func (tx *Transaction) Rollback() error {
 }
 
 // Commit the transaction.
+// Deferred index updates are flushed before committing and the temporary tables
+// created during the transaction are discarded.
 func (tx *Transaction) Commit() error {
-	err := tx.tx.Commit()
+	err := tx.FlushIndexes()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.closeTempEngine()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.tx.Commit()
 	if err != nil {
 		return err
 	}
@@ -87,12 +179,21 @@ func (tx *Transaction) CreateTable(name string, info *TableInfo) error {
 		return fmt.Errorf("table name must not start with %s", internalPrefix)
 	}
 
+	if _, ok := tx.db.prefixTable(name); ok {
+		return ErrTableAlreadyExists
+	}
+
 	if info == nil {
 		info = new(TableInfo)
 	}
 
 	info.tableName = name
-	err := tx.tableInfoStore.Insert(tx, name, info)
+	err := tx.validateForeignKeys(name, info)
+	if err != nil {
+		return err
+	}
+
+	err = tx.tableInfoStore.Insert(tx, name, info)
 	if err != nil {
 		return err
 	}
@@ -102,11 +203,222 @@ func (tx *Transaction) CreateTable(name string, info *TableInfo) error {
 		return fmt.Errorf("failed to create table %q: %w", name, err)
 	}
 
+	for _, fc := range info.FieldConstraints {
+		err = tx.createConstraintIndex(name, fc)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// createConstraintIndex creates the unique index that enforces the UNIQUE constraint
+// of a field, if any. Documents already in the table must be indexed by the caller.
+// Its name is generated to avoid conflicting with other indexes.
+func (tx *Transaction) createConstraintIndex(tableName string, fc FieldConstraint) error {
+	// primary keys are already unique
+	if !fc.IsUnique || fc.IsPrimaryKey {
+		return nil
+	}
+
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	idxStore, _ := t.indexCatalog()
+	seq, err := idxStore.st.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%sautoindex_%s_%d", internalPrefix, tableName, seq)
+	err = tx.CreateIndex(IndexConfig{
+		TableName:  tableName,
+		IndexName:  name,
+		Path:       fc.Path,
+		Unique:     true,
+		Constraint: true,
+	})
+	return err
+}
+
+// A tempCatalog holds the temporary tables of a transaction and their indexes.
+// It is stored in the temporary engine of the transaction, never in the catalog of the database.
+type tempCatalog struct {
+	tx             engine.Transaction
+	tableInfoStore *tableInfoStore
+	indexStore     *indexStore
+
+	// foreign keys referencing each table, by referenced table name.
+	// Loaded the first time they are needed and reset every time
+	// the information of a table is modified.
+	foreignKeyRefs map[string][]foreignKeyRef
+}
+
+// CreateTemporaryTable creates a table that only exists until the end of the transaction.
+// Temporary tables and their indexes are created in the temporary engine of the transaction,
+// see Database.NewTempEngine, or in memory if it is nil. They are discarded with it when the
+// transaction is committed or rolled back and never touch the catalog of the database.
+// They are resolved before the tables of the database, which they hide for the rest of the transaction.
+// They cannot be altered, have triggers or foreign keys, and changes made to them are not
+// undone by RollbackToSavepoint.
+// If a temporary table with the same name already exists, returns ErrTableAlreadyExists.
+func (tx *Transaction) CreateTemporaryTable(name string, info *TableInfo) error {
+	if strings.HasPrefix(name, internalPrefix) {
+		return fmt.Errorf("table name must not start with %s", internalPrefix)
+	}
+
+	if info == nil {
+		info = new(TableInfo)
+	}
+
+	if len(info.ForeignKeys) > 0 {
+		return errors.New("temporary tables cannot have foreign keys")
+	}
+
+	cat, err := tx.getTempCatalog()
+	if err != nil {
+		return err
+	}
+
+	info.tableName = name
+	err = cat.tableInfoStore.Insert(tx, name, info)
+	if err != nil {
+		return err
+	}
+
+	err = cat.tx.CreateStore(info.storeName)
+	if err != nil {
+		return fmt.Errorf("failed to create table %q: %w", name, err)
+	}
+
+	for _, fc := range info.FieldConstraints {
+		err = tx.createConstraintIndex(name, fc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getTempCatalog returns the catalog of the temporary tables of the transaction,
+// creating it on first call.
+func (tx *Transaction) getTempCatalog() (*tempCatalog, error) {
+	if tx.temp != nil {
+		return tx.temp, nil
+	}
+
+	ttx, err := tx.getTempTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.db.initInternalStores(ttx)
+	if err != nil {
+		return nil, err
+	}
+
+	tis, err := ttx.GetStore([]byte(tableInfoStoreName))
+	if err != nil {
+		return nil, err
+	}
+
+	is, err := ttx.GetStore([]byte(indexStoreName))
+	if err != nil {
+		return nil, err
+	}
+
+	tx.temp = &tempCatalog{
+		tx:             ttx,
+		tableInfoStore: &tableInfoStore{db: tx.db, st: tis},
+		indexStore:     &indexStore{db: tx.db, st: is},
+	}
+	return tx.temp, nil
+}
+
+// isTemporaryTable returns true if a temporary table with the given name exists.
+func (tx *Transaction) isTemporaryTable(name string) bool {
+	if tx.temp == nil {
+		return false
+	}
+
+	_, err := tx.temp.tableInfoStore.st.Get([]byte(name))
+	return err == nil
+}
+
+// dropTemporaryTable deletes a temporary table and its indexes.
+func (tx *Transaction) dropTemporaryTable(name string) error {
+	ti, err := tx.temp.tableInfoStore.Get(tx, name)
+	if err != nil {
+		return err
+	}
+
+	tx.discardDeferredIndexUpdates(name)
+
+	list, err := tx.temp.indexStore.ListAll()
+	if err != nil {
+		return err
+	}
+
+	for _, opts := range list {
+		if opts.TableName != name {
+			continue
+		}
+
+		err = tx.temp.indexStore.Delete(opts.IndexName)
+		if err != nil {
+			return err
+		}
+
+		err = newIndex(tx.temp.tx, *opts).Truncate()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.temp.tableInfoStore.Delete(tx, name)
+	if err != nil {
+		return err
+	}
+
+	return tx.temp.tx.DropStore(ti.storeName)
+}
+
 // GetTable returns a table by name. The table instance is only valid for the lifetime of the transaction.
+// Temporary tables are looked up first.
 func (tx *Transaction) GetTable(name string) (*Table, error) {
+	if tx.isTemporaryTable(name) {
+		ti, err := tx.temp.tableInfoStore.Get(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := tx.temp.tx.GetStore(ti.storeName)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Table{
+			tx:        tx,
+			Store:     s,
+			name:      name,
+			infoStore: tx.temp.tableInfoStore,
+			temporary: true,
+		}, nil
+	}
+
+	return tx.getDatabaseTable(name)
+}
+
+// getDatabaseTable returns a table of the database by name, ignoring temporary tables.
+func (tx *Transaction) getDatabaseTable(name string) (*Table, error) {
+	if cfg, ok := tx.db.prefixTable(name); ok {
+		return tx.getPrefixTable(name, cfg)
+	}
+
 	ti, err := tx.tableInfoStore.Get(tx, name)
 	if err != nil {
 		return nil, err
@@ -126,8 +438,10 @@ func (tx *Transaction) GetTable(name string) (*Table, error) {
 }
 
 // AddField adds a field constraint to a table.
+// Existing documents are validated against the new constraint and rewritten
+// with the converted and default values.
 func (tx *Transaction) AddField(tableName string, fc FieldConstraint) error {
-	info, err := tx.tableInfoStore.Get(tx, tableName)
+	info, err := tx.alterableTableInfo(tableName)
 	if err != nil {
 		return err
 	}
@@ -142,16 +456,381 @@ func (tx *Transaction) AddField(tableName string, fc FieldConstraint) error {
 				field.Path.String(),
 			)
 		}
+		if field.IsTTL && fc.IsTTL {
+			return fmt.Errorf(
+				"multiple TTL fields are not allowed (%q is the TTL field)",
+				field.Path.String(),
+			)
+		}
 	}
 
 	info.FieldConstraints = append(info.FieldConstraints, fc)
 
-	return tx.tableInfoStore.Replace(tx, tableName, info)
+	err = tx.tableInfoStore.Replace(tx, tableName, info)
+	if err != nil {
+		return err
+	}
+
+	err = tx.createConstraintIndex(tableName, fc)
+	if err != nil {
+		return err
+	}
+
+	return tx.validateTable(tableName)
+}
+
+// DropField removes the field constraint of a table at the given path.
+// The values of the documents are left untouched.
+func (tx *Transaction) DropField(tableName string, path document.Path) error {
+	info, err := tx.alterableTableInfo(tableName)
+	if err != nil {
+		return err
+	}
+
+	i, err := info.fieldConstraintIndex(path)
+	if err != nil {
+		return err
+	}
+	fc := info.FieldConstraints[i]
+
+	if fc.IsPrimaryKey {
+		return fmt.Errorf("cannot drop primary key %q", path)
+	}
+
+	if fc.IsUnique {
+		err = tx.checkUnreferenced(tableName, path)
+		if err != nil {
+			return err
+		}
+
+		err = tx.dropConstraintIndex(tableName, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	info.FieldConstraints = append(info.FieldConstraints[:i], info.FieldConstraints[i+1:]...)
+
+	err = tx.tableInfoStore.Replace(tx, tableName, info)
+	if err != nil {
+		return err
+	}
+
+	// indexes of the field are no longer typed
+	return tx.validateTable(tableName)
+}
+
+// AlterField replaces the field constraint of a table at the path of fc.
+// Existing documents are validated against the new constraint and rewritten
+// with the converted and default values.
+func (tx *Transaction) AlterField(tableName string, fc FieldConstraint) error {
+	info, err := tx.alterableTableInfo(tableName)
+	if err != nil {
+		return err
+	}
+
+	i, err := info.fieldConstraintIndex(fc.Path)
+	if err != nil {
+		return err
+	}
+	old := info.FieldConstraints[i]
+
+	// documents are stored by primary key, it can't be changed without rewriting the table
+	if old.IsPrimaryKey != fc.IsPrimaryKey || (old.IsPrimaryKey && old.Type != fc.Type) {
+		return fmt.Errorf("cannot alter primary key %q", fc.Path)
+	}
+
+	if ttl := info.GetTTLField(); fc.IsTTL && ttl != nil && !ttl.Path.IsEqual(fc.Path) {
+		return fmt.Errorf("multiple TTL fields are not allowed (%q is the TTL field)", ttl.Path)
+	}
+
+	if old.IsUnique && !fc.IsUnique {
+		err = tx.checkUnreferenced(tableName, fc.Path)
+		if err != nil {
+			return err
+		}
+
+		err = tx.dropConstraintIndex(tableName, fc.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	info.FieldConstraints[i] = fc
+
+	err = tx.tableInfoStore.Replace(tx, tableName, info)
+	if err != nil {
+		return err
+	}
+
+	if !old.IsUnique {
+		err = tx.createConstraintIndex(tableName, fc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.validateTable(tableName)
+}
+
+// alterableTableInfo returns the information of a table whose field constraints can be modified.
+func (tx *Transaction) alterableTableInfo(tableName string) (*TableInfo, error) {
+	if tx.isTemporaryTable(tableName) {
+		return nil, fmt.Errorf("cannot alter temporary table %q", tableName)
+	}
+
+	info, err := tx.tableInfoStore.Get(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.readOnly {
+		return nil, errors.New("cannot write to read-only table")
+	}
+
+	if info.ViewQuery != "" {
+		return nil, fmt.Errorf("cannot alter materialized view %q", tableName)
+	}
+
+	return info, nil
+}
+
+// fieldConstraintIndex returns the position of the field constraint at the given path.
+func (ti *TableInfo) fieldConstraintIndex(path document.Path) (int, error) {
+	for i := range ti.FieldConstraints {
+		if ti.FieldConstraints[i].Path.IsEqual(path) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("field %q not found", path)
+}
+
+// checkUnreferenced returns an error if the field is referenced by a foreign key.
+func (tx *Transaction) checkUnreferenced(tableName string, path document.Path) error {
+	refs, err := tx.referencingForeignKeys(tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if ref.fk.ReferencedPath.IsEqual(path) {
+			return fmt.Errorf("field %q is referenced by a foreign key of table %q", path, ref.tableName)
+		}
+	}
+
+	return nil
+}
+
+// dropConstraintIndex drops the index enforcing the UNIQUE constraint of a field.
+func (tx *Transaction) dropConstraintIndex(tableName string, path document.Path) error {
+	list, err := tx.indexStore.ListAll()
+	if err != nil {
+		return err
+	}
+
+	for _, opts := range list {
+		if opts.Constraint && opts.TableName == tableName && opts.Path.IsEqual(path) {
+			return tx.DropIndex(opts.IndexName)
+		}
+	}
+
+	return nil
+}
+
+// validateTable validates every document of the table against its field constraints
+// and rewrites them with the converted and default values.
+// The indexes of the table are then typed after the field constraints and rebuilt.
+func (tx *Transaction) validateTable(tableName string) error {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	info, err := t.Info()
+	if err != nil {
+		return err
+	}
+
+	err = t.rewrite(func(d document.Document) (*document.FieldBuffer, error) {
+		return info.FieldConstraints.ValidateDocument(tx, d)
+	})
+	if err != nil {
+		return err
+	}
+
+	list, err := tx.indexStore.ListAll()
+	if err != nil {
+		return err
+	}
+
+	for _, opts := range list {
+		if opts.TableName != tableName {
+			continue
+		}
+
+		opts.Type = 0
+		if !opts.IsComposite() && !opts.IsExpression() && !opts.FullText {
+			opts.Type = indexType(info, opts.Path)
+		}
+
+		err = tx.indexStore.Replace(opts.IndexName, *opts)
+		if err != nil {
+			return err
+		}
+
+		err = tx.ReIndex(opts.IndexName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenameField renames the field at the given path in every document of the table,
+// along with the field constraints, indexes and foreign keys that refer to it.
+// Documents are rewritten immediately.
+func (tx *Transaction) RenameField(tableName string, path document.Path, newName string) error {
+	info, err := tx.alterableTableInfo(tableName)
+	if err != nil {
+		return err
+	}
+
+	last := path[len(path)-1]
+	if last.FieldName == "" {
+		return fmt.Errorf("cannot rename array index %q", path)
+	}
+
+	newPath := append(append(document.Path{}, path[:len(path)-1]...), document.PathFragment{FieldName: newName})
+	if newPath.IsEqual(path) {
+		return nil
+	}
+
+	for i := range info.FieldConstraints {
+		fc := &info.FieldConstraints[i]
+		if fc.Path.IsEqual(newPath) {
+			return fmt.Errorf("field %q already exists", newPath)
+		}
+
+		fc.Path = renamePath(fc.Path, path, newPath)
+	}
+
+	for i := range info.ForeignKeys {
+		fk := &info.ForeignKeys[i]
+		fk.Path = renamePath(fk.Path, path, newPath)
+		if fk.ReferencedTable == tableName {
+			fk.ReferencedPath = renamePath(fk.ReferencedPath, path, newPath)
+		}
+	}
+
+	err = tx.tableInfoStore.Replace(tx, tableName, info)
+	if err != nil {
+		return err
+	}
+
+	refs, err := tx.referencingForeignKeys(tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if ref.tableName == tableName {
+			continue
+		}
+
+		rinfo, err := tx.tableInfoStore.Get(tx, ref.tableName)
+		if err != nil {
+			return err
+		}
+
+		for i := range rinfo.ForeignKeys {
+			if rinfo.ForeignKeys[i].ReferencedTable == tableName {
+				rinfo.ForeignKeys[i].ReferencedPath = renamePath(rinfo.ForeignKeys[i].ReferencedPath, path, newPath)
+			}
+		}
+
+		err = tx.tableInfoStore.Replace(tx, ref.tableName, rinfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	list, err := tx.indexStore.ListAll()
+	if err != nil {
+		return err
+	}
+
+	// indexed values don't change, only the configuration of the indexes
+	for _, opts := range list {
+		if opts.TableName != tableName {
+			continue
+		}
+
+		opts.Path = renamePath(opts.Path, path, newPath)
+		for i := range opts.ExtraPaths {
+			opts.ExtraPaths[i] = renamePath(opts.ExtraPaths[i], path, newPath)
+		}
+		err = tx.indexStore.Replace(opts.IndexName, *opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	return t.rewrite(func(d document.Document) (*document.FieldBuffer, error) {
+		v, err := path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = newPath.GetValueFromDocument(d)
+		if err == nil {
+			return nil, fmt.Errorf("cannot rename field %q: field %q already exists", path, newPath)
+		}
+		if err != document.ErrFieldNotFound {
+			return nil, err
+		}
+
+		fb := document.NewFieldBuffer()
+		err = fb.Copy(d)
+		if err != nil {
+			return nil, err
+		}
+
+		err = fb.Delete(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return fb, fb.Set(newPath, v)
+	})
+}
+
+// renamePath replaces the prefix old of p by new.
+// If p doesn't start with old, it is returned as is.
+func renamePath(p, old, new document.Path) document.Path {
+	if len(p) < len(old) || !p[:len(old)].IsEqual(old) {
+		return p
+	}
+
+	return append(append(document.Path{}, new...), p[len(old):]...)
 }
 
 // RenameTable renames a table.
 // If it doesn't exist, it returns ErrTableNotFound.
 func (tx *Transaction) RenameTable(oldName, newName string) error {
+	if tx.isTemporaryTable(oldName) {
+		return fmt.Errorf("cannot alter temporary table %q", oldName)
+	}
+
 	ti, err := tx.tableInfoStore.Get(tx, oldName)
 	if err != nil {
 		return err
@@ -184,11 +863,20 @@ func (tx *Transaction) RenameTable(oldName, newName string) error {
 	}
 
 	// Delete the old reference from the tableInfoStore.
-	return tx.tableInfoStore.Delete(tx, oldName)
+	err = tx.tableInfoStore.Delete(tx, oldName)
+	if err != nil {
+		return err
+	}
+
+	return tx.renameForeignKeyReferences(oldName, newName)
 }
 
 // DropTable deletes a table from the database.
 func (tx *Transaction) DropTable(name string) error {
+	if tx.isTemporaryTable(name) {
+		return tx.dropTemporaryTable(name)
+	}
+
 	ti, err := tx.tableInfoStore.Get(tx, name)
 	if err != nil {
 		return err
@@ -198,6 +886,18 @@ func (tx *Transaction) DropTable(name string) error {
 		return errors.New("cannot write to read-only table")
 	}
 
+	refs, err := tx.referencingForeignKeys(name)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.tableName != name {
+			return fmt.Errorf("cannot drop table %q: it is referenced by a foreign key of table %q", name, ref.tableName)
+		}
+	}
+
+	tx.discardDeferredIndexUpdates(name)
+
 	it := tx.indexStore.st.Iterator(engine.IteratorOptions{})
 	defer it.Close()
 
@@ -240,6 +940,15 @@ func (tx *Transaction) DropTable(name string) error {
 // CreateIndex creates an index with the given name.
 // If it already exists, returns ErrIndexAlreadyExists.
 func (tx *Transaction) CreateIndex(opts IndexConfig) error {
+	if opts.FullText && (opts.Unique || opts.IsComposite() || opts.IsExpression()) {
+		return errors.New("full-text indexes can only index a single path and cannot be unique")
+	}
+
+	err := tx.FlushIndexes()
+	if err != nil {
+		return err
+	}
+
 	t, err := tx.GetTable(opts.TableName)
 	if err != nil {
 		return err
@@ -252,54 +961,82 @@ func (tx *Transaction) CreateIndex(opts IndexConfig) error {
 
 	// if the index is created on a field on which we know the type,
 	// create a typed index.
-	for _, fc := range info.FieldConstraints {
-		if fc.Path.IsEqual(opts.Path) {
-			if fc.Type != 0 {
-				opts.Type = fc.Type
-			}
+	// composite, expression and full-text indexes are never typed.
+	if t := indexType(info, opts.Path); t != 0 && !opts.IsComposite() && !opts.IsExpression() && !opts.FullText {
+		opts.Type = t
+	}
 
-			break
+	idxStore, _ := t.indexCatalog()
+	return idxStore.Insert(opts)
+}
+
+// indexType returns the type of the indexes of the field at the given path,
+// or 0 if the field is not typed.
+func indexType(info *TableInfo, path document.Path) document.ValueType {
+	for _, fc := range info.FieldConstraints {
+		if fc.Path.IsEqual(path) {
+			return fc.Type
 		}
 	}
 
-	return tx.indexStore.Insert(opts)
+	return 0
 }
 
 // GetIndex returns an index by name.
 func (tx *Transaction) GetIndex(name string) (*Index, error) {
-	opts, err := tx.indexStore.Get(name)
+	err := tx.FlushIndexes()
 	if err != nil {
 		return nil, err
 	}
 
-	idx := index.New(tx.tx, opts.IndexName, index.Options{
-		Unique: opts.Unique,
-		Type:   opts.Type,
-	})
+	opts, _, etx, err := tx.getIndexConfig(name)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Index{
-		Index: idx,
-		Opts:  *opts,
-	}, nil
+	idx := newIndex(etx, *opts)
+	return &idx, nil
+}
+
+// getIndexConfig returns the configuration of an index by name, along with the store
+// it is stored in and the engine transaction of the index.
+// Indexes of temporary tables are looked up first.
+func (tx *Transaction) getIndexConfig(name string) (*IndexConfig, *indexStore, engine.Transaction, error) {
+	if tx.temp != nil {
+		opts, err := tx.temp.indexStore.Get(name)
+		if err == nil {
+			return opts, tx.temp.indexStore, tx.temp.tx, nil
+		}
+		if err != ErrIndexNotFound {
+			return nil, nil, nil, err
+		}
+	}
+
+	opts, err := tx.indexStore.Get(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return opts, tx.indexStore, tx.tx, nil
 }
 
 // DropIndex deletes an index from the database.
 func (tx *Transaction) DropIndex(name string) error {
-	opts, err := tx.indexStore.Get(name)
+	err := tx.FlushIndexes()
 	if err != nil {
 		return err
 	}
-	err = tx.indexStore.Delete(name)
+
+	opts, idxStore, etx, err := tx.getIndexConfig(name)
+	if err != nil {
+		return err
+	}
+	err = idxStore.Delete(name)
 	if err != nil {
 		return err
 	}
 
-	idx := index.New(tx.tx, opts.IndexName, index.Options{
-		Unique: opts.Unique,
-		Type:   opts.Type,
-	})
-
-	return idx.Truncate()
+	return newIndex(etx, *opts).Truncate()
 }
 
 // ListIndexes lists all indexes.
@@ -325,15 +1062,16 @@ func (tx *Transaction) ReIndex(indexName string) error {
 	}
 
 	return tb.Iterate(func(d document.Document) error {
-		v, err := idx.Opts.Path.GetValueFromDocument(d)
-		if err == document.ErrFieldNotFound {
-			return nil
-		}
-		if err != nil {
+		v, ok, err := tx.indexedValue(*idx, d)
+		if err != nil || !ok {
 			return err
 		}
 
-		return idx.Set(v, d.(document.Keyer).RawKey())
+		err = idx.Set(v, d.(document.Keyer).RawKey())
+		if err == index.ErrDuplicate {
+			return duplicateError(*idx, v)
+		}
+		return err
 	})
 }
 
@@ -370,6 +1108,138 @@ func (tx *Transaction) ReIndexAll() error {
 	return nil
 }
 
+// RefreshMaterializedView replaces the documents of the materialized view
+// with the documents returned by it.
+func (tx *Transaction) RefreshMaterializedView(name string, it document.Iterator) error {
+	tb, err := tx.GetTable(name)
+	if err != nil {
+		return err
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return err
+	}
+
+	if info.ViewQuery == "" {
+		return fmt.Errorf("%q is not a materialized view", name)
+	}
+
+	var keys [][]byte
+	err = tb.WithFields(nil).Iterate(func(d document.Document) error {
+		keys = append(keys, append([]byte(nil), d.(document.Keyer).RawKey()...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tb.refreshing = true
+
+	for _, key := range keys {
+		err = tb.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Iterate(func(d document.Document) error {
+		_, err := tb.Insert(d)
+		return err
+	})
+}
+
+// CreateTemporaryStore creates a store with a unique name that can be used to hold
+// intermediate data while executing a query, such as documents that don't fit in memory.
+// The store must be dropped by calling the returned function once it's not needed anymore.
+// Writable transactions create it in the engine of the database, and it is removed on rollback
+// if it wasn't dropped. Read-only transactions create it in an engine returned by
+// Database.NewTempEngine, which is closed when the transaction ends, and return
+// engine.ErrTransactionReadOnly if it is nil.
+func (tx *Transaction) CreateTemporaryStore() (engine.Store, func() error, error) {
+	if !tx.CanCreateTemporaryStore() {
+		return nil, nil, engine.ErrTransactionReadOnly
+	}
+
+	etx := tx.tx
+	if !tx.writable {
+		var err error
+		etx, err = tx.getTempTransaction()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tx.tempStoreCount++
+	name := []byte(fmt.Sprintf("%stmp_%d", internalPrefix, tx.tempStoreCount))
+
+	err := etx.CreateStore(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	st, err := etx.GetStore(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return st, func() error {
+		return etx.DropStore(name)
+	}, nil
+}
+
+// CanCreateTemporaryStore returns true if CreateTemporaryStore can be called
+// on this transaction.
+func (tx *Transaction) CanCreateTemporaryStore() bool {
+	return tx.writable || tx.db.NewTempEngine != nil
+}
+
+// getTempTransaction returns a writable transaction on the engine used to create
+// the temporary stores of a read-only transaction and the temporary tables,
+// creating both on first call. If Database.NewTempEngine is nil, the engine is in memory.
+func (tx *Transaction) getTempTransaction() (engine.Transaction, error) {
+	if tx.tempTx != nil {
+		return tx.tempTx, nil
+	}
+
+	var ng engine.Engine = memoryengine.NewEngine()
+	if tx.db.NewTempEngine != nil {
+		var err error
+		ng, err = tx.db.NewTempEngine()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ttx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	if err != nil {
+		_ = ng.Close()
+		return nil, err
+	}
+
+	tx.tempEngine, tx.tempTx = ng, ttx
+	return ttx, nil
+}
+
+// closeTempEngine discards the temporary stores of a read-only transaction
+// and the temporary tables, and closes the engine they were created in.
+func (tx *Transaction) closeTempEngine() error {
+	if tx.tempEngine == nil {
+		return nil
+	}
+
+	ng, ttx := tx.tempEngine, tx.tempTx
+	tx.tempEngine, tx.tempTx, tx.temp = nil, nil, nil
+
+	err := ttx.Rollback()
+	if err != nil {
+		_ = ng.Close()
+		return err
+	}
+
+	return ng.Close()
+}
+
 func (tx *Transaction) getTableInfoStore() (*tableInfoStore, error) {
 	st, err := tx.tx.GetStore([]byte(tableInfoStoreName))
 	if err != nil {
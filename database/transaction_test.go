@@ -73,6 +73,90 @@ func TestTxTable(t *testing.T) {
 		check()
 	})
 
+	t.Run("Create temporary", func(t *testing.T) {
+		db, err := database.New(context.Background(), memoryengine.NewEngine(), database.Options{
+			Codec: msgpack.NewCodec(),
+		})
+		require.NoError(t, err)
+		defer db.Close()
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+
+		err = tx.CreateTable("test", nil)
+		require.NoError(t, err)
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+		_, err = tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)))
+		require.NoError(t, err)
+
+		err = tx.CreateTemporaryTable("tmp", nil)
+		require.NoError(t, err)
+		err = tx.CreateTemporaryTable("tmp2", nil)
+		require.NoError(t, err)
+		err = tx.CreateIndex(database.IndexConfig{IndexName: "idx_tmp", TableName: "tmp", Path: parsePath(t, "a")})
+		require.NoError(t, err)
+
+		err = tx.CreateTemporaryTable("tmp", nil)
+		require.Equal(t, database.ErrTableAlreadyExists, err)
+
+		// temporary tables can be used like any other table
+		tb, err = tx.GetTable("tmp")
+		require.NoError(t, err)
+		_, err = tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)))
+		require.NoError(t, err)
+		idx, err := tx.GetIndex("idx_tmp")
+		require.NoError(t, err)
+		require.Len(t, indexKeys(t, idx), 1)
+
+		// but they are not in the catalog of the database
+		list, err := tx.ListIndexes()
+		require.NoError(t, err)
+		require.Empty(t, list)
+
+		err = tx.RenameTable("tmp2", "tmp3")
+		require.Error(t, err)
+		err = tx.DropTable("tmp2")
+		require.NoError(t, err)
+		_, err = tx.GetTable("tmp2")
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+
+		// temporary tables hide the tables of the database
+		err = tx.CreateTemporaryTable("test", nil)
+		require.NoError(t, err)
+		tb, err = tx.GetTable("test")
+		require.NoError(t, err)
+		err = tb.Iterate(func(d document.Document) error {
+			return errors.New("unexpected document")
+		})
+		require.NoError(t, err)
+
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		for _, name := range []string{"tmp", "tmp2", "tmp3"} {
+			_, err = tx.GetTable(name)
+			require.True(t, errors.Is(err, database.ErrTableNotFound), name)
+		}
+
+		_, err = tx.GetIndex("idx_tmp")
+		require.Equal(t, database.ErrIndexNotFound, err)
+
+		tb, err = tx.GetTable("test")
+		require.NoError(t, err)
+		n := 0
+		err = tb.Iterate(func(d document.Document) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	})
+
 	t.Run("Get", func(t *testing.T) {
 		tx, cleanup := newTestDB(t)
 		defer cleanup()
@@ -473,3 +557,230 @@ func TestReIndexAll(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestTxLastInsertKey(t *testing.T) {
+	db, err := database.New(context.Background(), memoryengine.NewEngine(), database.Options{
+		Codec: msgpack.NewCodec(),
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	require.Equal(t, document.NewNullValue(), tx.LastInsertKey())
+
+	// inserting documents doesn't change the last inserted key,
+	// it is set by the statements of the transaction
+	err = tx.CreateTable("test", nil)
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+	_, err = tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1)))
+	require.NoError(t, err)
+	require.Equal(t, document.NewNullValue(), tx.LastInsertKey())
+
+	tx.SetLastInsertKey(document.NewIntegerValue(1))
+	require.Equal(t, document.NewIntegerValue(1), tx.LastInsertKey())
+
+	// rolling back to a savepoint restores the key
+	err = tx.Savepoint("sp")
+	require.NoError(t, err)
+	tx.SetLastInsertKey(document.NewIntegerValue(2))
+	require.Equal(t, document.NewIntegerValue(2), tx.LastInsertKey())
+	err = tx.RollbackToSavepoint("sp")
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(1), tx.LastInsertKey())
+
+	// rolling back the transaction discards it
+	require.NoError(t, tx.Rollback())
+	require.Equal(t, document.NewNullValue(), tx.LastInsertKey())
+
+	// other transactions don't see it
+	tx, err = db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.Equal(t, document.NewNullValue(), tx.LastInsertKey())
+}
+
+func TestTxMaxOpenIterators(t *testing.T) {
+	tx, cleanup := newTestDB(t)
+	defer cleanup()
+
+	err := tx.CreateTable("test", nil)
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(int64(i))))
+		require.NoError(t, err)
+	}
+
+	// iterate over the table within the iteration of the table
+	nested := func() error {
+		return tb.Iterate(func(d document.Document) error {
+			require.Equal(t, 1, tx.OpenIterators())
+
+			return tb.Iterate(func(d document.Document) error {
+				require.Equal(t, 2, tx.OpenIterators())
+				return nil
+			})
+		})
+	}
+
+	require.Zero(t, tx.OpenIterators())
+	require.NoError(t, nested())
+	require.Zero(t, tx.OpenIterators())
+
+	tx.DB().MaxOpenIterators = 1
+	defer func() { tx.DB().MaxOpenIterators = 0 }()
+
+	require.Equal(t, database.ErrTooManyOpenIterators, nested())
+	require.Zero(t, tx.OpenIterators())
+
+	// sequential iterations are not limited
+	for i := 0; i < 3; i++ {
+		require.NoError(t, tb.Iterate(func(d document.Document) error { return nil }))
+	}
+}
+
+func TestTxSavepoint(t *testing.T) {
+	count := func(t *testing.T, tx *database.Transaction, name string) int {
+		tb, err := tx.GetTable(name)
+		require.NoError(t, err)
+
+		var n int
+		err = tb.Iterate(func(d document.Document) error {
+			n++
+			return nil
+		})
+		require.NoError(t, err)
+		return n
+	}
+
+	insert := func(t *testing.T, tx *database.Transaction, name string, a int64) []byte {
+		tb, err := tx.GetTable(name)
+		require.NoError(t, err)
+		k, err := tb.Insert(document.NewFieldBuffer().Add("a", document.NewIntegerValue(a)))
+		require.NoError(t, err)
+		return k
+	}
+
+	t.Run("Not found", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		require.True(t, errors.Is(tx.RollbackToSavepoint("sp"), database.ErrSavepointNotFound))
+		require.True(t, errors.Is(tx.ReleaseSavepoint("sp"), database.ErrSavepointNotFound))
+	})
+
+	t.Run("Documents", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, tx.CreateTable("test", nil))
+		k := insert(t, tx, "test", 1)
+		insert(t, tx, "test", 2)
+
+		require.NoError(t, tx.Savepoint("sp"))
+		insert(t, tx, "test", 3)
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+		require.NoError(t, tb.Replace(k, document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))))
+		require.NoError(t, tb.Delete(k))
+		require.Equal(t, 2, count(t, tx, "test"))
+
+		// rolling back twice to the same savepoint
+		for i := 0; i < 2; i++ {
+			require.NoError(t, tx.RollbackToSavepoint("sp"))
+			require.Equal(t, 2, count(t, tx, "test"))
+
+			d, err := tb.GetDocument(k)
+			require.NoError(t, err)
+			v, err := d.GetByField("a")
+			require.NoError(t, err)
+			require.Equal(t, document.NewDoubleValue(1), v)
+
+			require.NoError(t, tb.Truncate())
+			require.Zero(t, count(t, tx, "test"))
+		}
+
+		require.NoError(t, tx.ReleaseSavepoint("sp"))
+		require.True(t, errors.Is(tx.RollbackToSavepoint("sp"), database.ErrSavepointNotFound))
+		require.Zero(t, count(t, tx, "test"))
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, tx.CreateTable("test", nil))
+		require.NoError(t, tx.Savepoint("a"))
+		insert(t, tx, "test", 1)
+		require.NoError(t, tx.Savepoint("b"))
+		insert(t, tx, "test", 2)
+		require.NoError(t, tx.Savepoint("c"))
+		insert(t, tx, "test", 3)
+
+		require.NoError(t, tx.RollbackToSavepoint("b"))
+		require.Equal(t, 1, count(t, tx, "test"))
+		// savepoints created after b are released
+		require.True(t, errors.Is(tx.RollbackToSavepoint("c"), database.ErrSavepointNotFound))
+
+		// releasing b keeps its changes
+		insert(t, tx, "test", 4)
+		require.NoError(t, tx.ReleaseSavepoint("b"))
+		require.Equal(t, 2, count(t, tx, "test"))
+
+		require.NoError(t, tx.RollbackToSavepoint("a"))
+		require.Zero(t, count(t, tx, "test"))
+	})
+
+	t.Run("Tables and indexes", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, tx.CreateTable("foo", nil))
+		insert(t, tx, "foo", 1)
+
+		require.NoError(t, tx.Savepoint("sp"))
+		require.NoError(t, tx.CreateTable("bar", nil))
+		insert(t, tx, "bar", 1)
+		require.NoError(t, tx.CreateIndex(database.IndexConfig{
+			TableName: "foo",
+			IndexName: "idx_foo_a",
+			Path:      parsePath(t, "a"),
+		}))
+		require.NoError(t, tx.DropTable("foo"))
+
+		require.NoError(t, tx.RollbackToSavepoint("sp"))
+		_, err := tx.GetTable("bar")
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+		_, err = tx.GetIndex("idx_foo_a")
+		require.True(t, errors.Is(err, database.ErrIndexNotFound))
+		require.Equal(t, 1, count(t, tx, "foo"))
+	})
+
+	t.Run("Deferred indexes", func(t *testing.T) {
+		tx, cleanup := newTestDB(t)
+		defer cleanup()
+
+		require.NoError(t, tx.CreateTable("test", nil))
+		require.NoError(t, tx.CreateIndex(database.IndexConfig{
+			TableName: "test",
+			IndexName: "idx_test_a",
+			Path:      parsePath(t, "a"),
+			Unique:    true,
+		}))
+		require.NoError(t, tx.DeferIndexUpdates())
+		insert(t, tx, "test", 1)
+
+		require.NoError(t, tx.Savepoint("sp"))
+		insert(t, tx, "test", 1)
+		require.Error(t, tx.FlushIndexes())
+		require.NoError(t, tx.RollbackToSavepoint("sp"))
+
+		insert(t, tx, "test", 2)
+		require.NoError(t, tx.FlushIndexes())
+		require.Equal(t, 2, count(t, tx, "test"))
+	})
+}
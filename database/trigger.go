@@ -0,0 +1,227 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/document"
+)
+
+// maxTriggerDepth is the maximum number of nested triggers, i.e. triggers fired
+// by the statements of other triggers. It stops triggers that fire each other endlessly.
+const maxTriggerDepth = 32
+
+// TriggerTiming defines whether a trigger runs before or after a document is written.
+type TriggerTiming int
+
+// List of trigger timings.
+const (
+	TriggerBefore TriggerTiming = iota + 1
+	TriggerAfter
+)
+
+func (t TriggerTiming) String() string {
+	switch t {
+	case TriggerBefore:
+		return "BEFORE"
+	case TriggerAfter:
+		return "AFTER"
+	}
+
+	return ""
+}
+
+// TriggerEvent is the kind of write that fires a trigger.
+type TriggerEvent int
+
+// List of trigger events.
+const (
+	TriggerInsert TriggerEvent = iota + 1
+	TriggerUpdate
+	TriggerDelete
+)
+
+func (e TriggerEvent) String() string {
+	switch e {
+	case TriggerInsert:
+		return "INSERT"
+	case TriggerUpdate:
+		return "UPDATE"
+	case TriggerDelete:
+		return "DELETE"
+	}
+
+	return ""
+}
+
+// TriggerConfig holds the definition of a trigger.
+// A trigger runs a statement every time a document of its table is written,
+// within the transaction that writes it.
+type TriggerConfig struct {
+	TriggerName string
+	Timing      TriggerTiming
+	Event       TriggerEvent
+	// Statement is the text of the statement run by the trigger.
+	Statement string
+}
+
+// ToDocument returns a document from t.
+func (t *TriggerConfig) ToDocument() document.Document {
+	buf := document.NewFieldBuffer()
+
+	buf.Add("trigger_name", document.NewTextValue(t.TriggerName))
+	buf.Add("timing", document.NewIntegerValue(int64(t.Timing)))
+	buf.Add("event", document.NewIntegerValue(int64(t.Event)))
+	buf.Add("statement", document.NewTextValue(t.Statement))
+	return buf
+}
+
+// ScanDocument implements the document.Scanner interface.
+func (t *TriggerConfig) ScanDocument(d document.Document) error {
+	v, err := d.GetByField("trigger_name")
+	if err != nil {
+		return err
+	}
+	t.TriggerName = v.V.(string)
+
+	v, err = d.GetByField("timing")
+	if err != nil {
+		return err
+	}
+	t.Timing = TriggerTiming(v.V.(int64))
+
+	v, err = d.GetByField("event")
+	if err != nil {
+		return err
+	}
+	t.Event = TriggerEvent(v.V.(int64))
+
+	v, err = d.GetByField("statement")
+	if err != nil {
+		return err
+	}
+	t.Statement = v.V.(string)
+
+	return nil
+}
+
+// A TriggerRunner runs the statement of a trigger within the transaction that
+// fired it. The old and new versions of the written document are passed to the statement,
+// old is nil for inserted documents and new is nil for deleted documents.
+type TriggerRunner func(tx *Transaction, t *TriggerConfig, old, new document.Document) error
+
+// hasTriggers returns true if the table has triggers fired by the given event.
+func (ti *TableInfo) hasTriggers(event TriggerEvent) bool {
+	for _, t := range ti.Triggers {
+		if t.Event == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreateTrigger adds a trigger to a table.
+// If a trigger with the same name already exists on any table, returns ErrTriggerAlreadyExists.
+func (tx *Transaction) CreateTrigger(tableName string, cfg TriggerConfig) error {
+	if cfg.TriggerName == "" {
+		return errors.New("missing trigger name")
+	}
+
+	_, _, err := tx.findTrigger(cfg.TriggerName)
+	if err == nil {
+		return ErrTriggerAlreadyExists
+	}
+	if !errors.Is(err, ErrTriggerNotFound) {
+		return err
+	}
+
+	if _, ok := tx.db.prefixTable(tableName); ok {
+		return errors.New("cannot write to read-only table")
+	}
+
+	if tx.isTemporaryTable(tableName) {
+		return fmt.Errorf("cannot create trigger on temporary table %q", tableName)
+	}
+
+	info, err := tx.tableInfoStore.Get(tx, tableName)
+	if err != nil {
+		return err
+	}
+
+	if info.readOnly {
+		return errors.New("cannot write to read-only table")
+	}
+
+	if info.ViewQuery != "" {
+		return fmt.Errorf("cannot create trigger on materialized view %q", tableName)
+	}
+
+	info.Triggers = append(info.Triggers, cfg)
+
+	return tx.tableInfoStore.Replace(tx, tableName, info)
+}
+
+// DropTrigger removes a trigger from its table.
+// If it doesn't exist, returns ErrTriggerNotFound.
+func (tx *Transaction) DropTrigger(name string) error {
+	info, i, err := tx.findTrigger(name)
+	if err != nil {
+		return err
+	}
+
+	info.Triggers = append(info.Triggers[:i], info.Triggers[i+1:]...)
+
+	return tx.tableInfoStore.Replace(tx, info.tableName, info)
+}
+
+// findTrigger returns the information of the table of the trigger
+// and the position of the trigger in its list of triggers.
+func (tx *Transaction) findTrigger(name string) (*TableInfo, int, error) {
+	names, err := tx.tableInfoStore.ListAll()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, tableName := range names {
+		info, err := tx.tableInfoStore.Get(tx, tableName)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i, t := range info.Triggers {
+			if t.TriggerName == name {
+				return info, i, nil
+			}
+		}
+	}
+
+	return nil, 0, fmt.Errorf("%w: %q", ErrTriggerNotFound, name)
+}
+
+// fireTriggers runs the triggers of the table fired by the given event, at the given time.
+func (t *Table) fireTriggers(info *TableInfo, timing TriggerTiming, event TriggerEvent, old, new document.Document) error {
+	for i := range info.Triggers {
+		tr := &info.Triggers[i]
+		if tr.Timing != timing || tr.Event != event {
+			continue
+		}
+
+		if t.tx.db.RunTrigger == nil {
+			return fmt.Errorf("cannot run trigger %q: no trigger runner", tr.TriggerName)
+		}
+
+		if t.tx.triggerDepth >= maxTriggerDepth {
+			return fmt.Errorf("cannot run trigger %q: too many nested triggers", tr.TriggerName)
+		}
+
+		t.tx.triggerDepth++
+		err := t.tx.db.RunTrigger(t.tx, tr, old, new)
+		t.tx.triggerDepth--
+		if err != nil {
+			return fmt.Errorf("trigger %q: %w", tr.TriggerName, err)
+		}
+	}
+
+	return nil
+}
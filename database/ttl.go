@@ -0,0 +1,188 @@
+package database
+
+import (
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+)
+
+// isExpired returns true if the value of the TTL field of d is a timestamp before now.
+// Documents without a TTL value never expire.
+func isExpired(fc *FieldConstraint, d document.Document, now time.Time) (bool, error) {
+	v, err := fc.Path.GetValueFromDocument(d)
+	if err == document.ErrFieldNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if v.Type != document.TimestampValue {
+		return false, nil
+	}
+
+	return !v.V.(time.Time).After(now), nil
+}
+
+// ExpirationFilter returns a function that reports whether a document of the table expired
+// at the time ExpirationFilter was called. Expired documents are still stored until they are purged,
+// readers must skip them.
+// It returns nil if the documents of the table never expire.
+func (t *Table) ExpirationFilter() (func(d document.Document) (bool, error), error) {
+	info, err := t.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	fc := info.GetTTLField()
+	if fc == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	return func(d document.Document) (bool, error) {
+		// the TTL field may not be part of the selected fields
+		if fd, ok := d.(*fieldsDocument); ok {
+			d = fd.d
+		}
+
+		return isExpired(fc, d, now)
+	}, nil
+}
+
+// PurgeExpired deletes the expired documents of the table
+// and returns the number of deleted documents.
+func (t *Table) PurgeExpired() (int64, error) {
+	info, err := t.Info()
+	if err != nil {
+		return 0, err
+	}
+
+	fc := info.GetTTLField()
+	if fc == nil {
+		return 0, nil
+	}
+
+	// keys are collected first since the store can't be modified while iterating on it
+	now := time.Now()
+	var keys [][]byte
+	err = t.Iterate(func(d document.Document) error {
+		ok, err := isExpired(fc, d, now)
+		if err != nil || !ok {
+			return err
+		}
+
+		keys = append(keys, append([]byte{}, d.(document.Keyer).RawKey()...))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, key := range keys {
+		err = t.Delete(key)
+		// the document may have been deleted by a cascading delete
+		if err == ErrDocumentNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// PurgeExpired deletes the expired documents of every table
+// and returns the number of deleted documents.
+func (tx *Transaction) PurgeExpired() (int64, error) {
+	names, err := tx.tableInfoStore.ListAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, name := range names {
+		t, err := tx.getDatabaseTable(name)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := t.PurgeExpired()
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+
+	return count, nil
+}
+
+// deleteExpiredConflicts deletes the expired documents that prevent fb from being inserted,
+// because they have the same primary key or the same value in a unique index.
+func (t *Table) deleteExpiredConflicts(info *TableInfo, fb *document.FieldBuffer) error {
+	fc := info.GetTTLField()
+	if fc == nil {
+		return nil
+	}
+
+	for {
+		key, err := t.conflictingKey(info, fb)
+		if err != nil || key == nil {
+			return err
+		}
+
+		d, err := t.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		ok, err := isExpired(fc, d, time.Now())
+		if err != nil || !ok {
+			return err
+		}
+
+		err = t.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// put stores the encoded document v at key. If the engine is able to expire keys
+// by itself, the expiration of the document is delegated to it, unless the table has indexes
+// or is referenced by foreign keys, which must be updated when the document is deleted.
+func (t *Table) put(info *TableInfo, key, v []byte, fb *document.FieldBuffer) error {
+	es, ok := engine.AsExpiringStore(t.Store)
+	fc := info.GetTTLField()
+	if !ok || fc == nil {
+		return t.Store.Put(key, v)
+	}
+
+	exp, err := fc.Path.GetValueFromDocument(fb)
+	if err != nil || exp.Type != document.TimestampValue {
+		return t.Store.Put(key, v)
+	}
+
+	indexes, err := t.Indexes()
+	if err != nil {
+		return err
+	}
+	refs, err := t.tx.referencingForeignKeys(t.name)
+	if err != nil {
+		return err
+	}
+	if len(indexes) > 0 || len(refs) > 0 {
+		return t.Store.Put(key, v)
+	}
+
+	// writes made within savepoints must be journaled
+	// by the store of the transaction, which es bypasses
+	if js, ok := asJournalingStore(t.Store); ok {
+		return js.putWithExpiration(es, key, v, exp.V.(time.Time))
+	}
+
+	return es.PutWithExpiration(key, v, exp.V.(time.Time))
+}
@@ -0,0 +1,107 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding/msgpack"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+// expiringEngine records the keys stored with an expiration time
+// by the stores of the wrapped engine.
+type expiringEngine struct {
+	engine.Engine
+
+	expiring map[string]time.Time
+}
+
+func (ng *expiringEngine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Transaction, error) {
+	tx, err := ng.Engine.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expiringTransaction{Transaction: tx, ng: ng}, nil
+}
+
+type expiringTransaction struct {
+	engine.Transaction
+
+	ng *expiringEngine
+}
+
+func (tx *expiringTransaction) GetStore(name []byte) (engine.Store, error) {
+	st, err := tx.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expiringStore{Store: st, ng: tx.ng}, nil
+}
+
+type expiringStore struct {
+	engine.Store
+
+	ng *expiringEngine
+}
+
+func (s *expiringStore) PutWithExpiration(k, v []byte, expiresAt time.Time) error {
+	s.ng.expiring[string(k)] = expiresAt
+	return s.Store.Put(k, v)
+}
+
+func TestTableExpiringStore(t *testing.T) {
+	ng := &expiringEngine{Engine: memoryengine.NewEngine(), expiring: make(map[string]time.Time)}
+	db, err := database.New(context.Background(), ng, database.Options{Codec: msgpack.NewCodec()})
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.CreateTable("test", &database.TableInfo{
+		FieldConstraints: []database.FieldConstraint{
+			{Path: parsePath(t, "exp"), Type: document.TimestampValue, IsTTL: true},
+		},
+	})
+	require.NoError(t, err)
+	tb, err := tx.GetTable("test")
+	require.NoError(t, err)
+
+	insert := func(exp time.Time) []byte {
+		k, err := tb.Insert(document.NewFieldBuffer().Add("exp", document.NewTimestampValue(exp)))
+		require.NoError(t, err)
+		return k
+	}
+
+	// the expiration is delegated to the engine through the stores of the transaction
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	k := insert(exp)
+	require.Len(t, ng.expiring, 1)
+	require.True(t, exp.Equal(ng.expiring[string(k)]))
+
+	// keys stored with an expiration are restored when rolling back to a savepoint
+	require.NoError(t, tx.Savepoint("sp"))
+	k = insert(exp)
+	require.Len(t, ng.expiring, 2)
+	require.NoError(t, tx.RollbackToSavepoint("sp"))
+	_, err = tb.GetDocument(k)
+	require.Equal(t, database.ErrDocumentNotFound, err)
+
+	// documents of tables with indexes must be deleted by the database
+	err = tx.CreateIndex(database.IndexConfig{
+		TableName: "test",
+		IndexName: "idx_exp",
+		Path:      parsePath(t, "exp"),
+	})
+	require.NoError(t, err)
+	insert(exp)
+	require.Len(t, ng.expiring, 2)
+}
@@ -2,11 +2,16 @@ package genji
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
 )
 
 // DB represents a collection of tables stored in the underlying engine.
@@ -81,10 +86,95 @@ func (db *DB) Exec(q string, args ...interface{}) error {
 	return res.Close()
 }
 
+// RegisterFunc registers a scalar function that can be called by name from any query.
+// Arity is the number of arguments the function expects, or -1 for any number of arguments.
+// Builtin functions can't be overridden.
+func (db *DB) RegisterFunc(name string, arity int, fn database.ScalarFunc) error {
+	if _, ok := expr.BuiltinFunctions()[strings.ToLower(name)]; ok {
+		return fmt.Errorf("cannot override builtin function %q", name)
+	}
+
+	return db.DB.RegisterFunc(name, arity, fn)
+}
+
+// RegisterAggregateFunc registers an aggregate function that can be called by name from any query.
+// A new accumulator is created by fn for every group of documents, it receives the value
+// of the argument of the function for each document of the group, then its result is
+// read once the whole group has been processed.
+// Builtin functions can't be overridden.
+func (db *DB) RegisterAggregateFunc(name string, fn database.AggregateFunc) error {
+	if _, ok := expr.BuiltinFunctions()[strings.ToLower(name)]; ok {
+		return fmt.Errorf("cannot override builtin function %q", name)
+	}
+
+	return db.DB.RegisterAggregateFunc(name, fn)
+}
+
+// RegisterPrefixTable registers a read-only virtual table that can be queried by name.
+// It exposes the documents of another table whose TEXT primary key starts with a prefix,
+// with the rest of the key decoded into fields.
+// See database.PrefixTableConfig for details.
+func (db *DB) RegisterPrefixTable(name string, cfg database.PrefixTableConfig) error {
+	return db.DB.RegisterPrefixTable(name, cfg)
+}
+
+// Stats returns statistics about the database: its tables, indexes, an approximate
+// number of documents per table and, if the engine supports it, engine-specific metrics.
+func (db *DB) Stats() (*database.Stats, error) {
+	return db.DB.Stats(db.ctx)
+}
+
+// PurgeExpired deletes the expired documents of every table with a TTL field,
+// and returns the number of deleted documents.
+// Expired documents are never returned by queries but remain stored until they are purged,
+// unless the engine deletes them by itself.
+func (db *DB) PurgeExpired() (int64, error) {
+	var n int64
+	err := db.Update(func(tx *Tx) error {
+		var err error
+		n, err = tx.PurgeExpired()
+		return err
+	})
+
+	return n, err
+}
+
+// parseQuery parses q using the functions registered in db.
+func parseQuery(db *database.Database, q string) (query.Query, error) {
+	return parser.NewParserWithOptions(strings.NewReader(q), &parser.Options{
+		Functions: expr.NewFunctionsFor(db),
+	}).ParseQuery()
+}
+
+// runTrigger parses the statement of the trigger and runs it within tx, with the old and new
+// versions of the written document bound to the old and new tables.
+// It implements the database.TriggerRunner type.
+func runTrigger(tx *database.Transaction, t *database.TriggerConfig, old, new document.Document) error {
+	stmt, err := parser.NewParserWithOptions(strings.NewReader(t.Statement), &parser.Options{
+		Functions: expr.NewFunctionsFor(tx.DB()),
+	}).ParseTriggerStatement()
+	if err != nil {
+		return err
+	}
+
+	var params []expr.Param
+	if old != nil {
+		params = append(params, expr.OuterParam(query.OldTable, old))
+	}
+	if new != nil {
+		params = append(params, expr.OuterParam(query.NewTable, new))
+	}
+
+	_, err = stmt.Run(tx, params)
+	return err
+}
+
 // Query the database and return the result.
 // The returned result must always be closed after usage.
+// Positional parameters (?) are bound to args in order, named parameters ($name or :name)
+// are bound to the arguments created with sql.Named.
 func (db *DB) Query(q string, args ...interface{}) (*query.Result, error) {
-	pq, err := parser.ParseQuery(q)
+	pq, err := parseQuery(db.DB, q)
 	if err != nil {
 		return nil, err
 	}
@@ -119,10 +209,29 @@ func (db *DB) QueryDocument(q string, args ...interface{}) (document.Document, e
 	return &fb, nil
 }
 
+// Exists runs the SELECT query and reports whether it returns at least one document.
+// The scan stops at the first matching document.
+// See Tx.Exists for details.
+func (db *DB) Exists(q string, args ...interface{}) (bool, error) {
+	if tx := db.DB.GetAttachedTx(); tx != nil {
+		return (&Tx{Transaction: tx}).Exists(q, args...)
+	}
+
+	var ok bool
+	err := db.View(func(tx *Tx) error {
+		var err error
+		ok, err = tx.Exists(q, args...)
+		return err
+	})
+	return ok, err
+}
+
 // Tx represents a database transaction. It provides methods for managing the
 // collection of tables and the transaction itself.
 // Tx is either read-only or read/write. Read-only can be used to read tables
 // and read/write can be used to read, create, delete and modify tables.
+// Queries run within a read/write transaction see the uncommitted changes
+// made by that transaction.
 type Tx struct {
 	*database.Transaction
 }
@@ -130,7 +239,7 @@ type Tx struct {
 // Query the database withing the transaction and returns the result.
 // Closing the returned result after usage is not mandatory.
 func (tx *Tx) Query(q string, args ...interface{}) (*query.Result, error) {
-	pq, err := parser.ParseQuery(q)
+	pq, err := parseQuery(tx.DB(), q)
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +267,24 @@ func (tx *Tx) QueryDocument(q string, args ...interface{}) (document.Document, e
 	return r, nil
 }
 
+// Exists runs the SELECT query and reports whether it returns at least one document.
+// It is faster than running the query and reading its first document: the query
+// is optimized as if it was followed by LIMIT 1, which lets any ORDER BY clause only keep
+// the documents it needs, and the scan stops at the first matching document.
+// Indexes are used when possible, as with any other query.
+func (tx *Tx) Exists(q string, args ...interface{}) (bool, error) {
+	pq, err := parseQuery(tx.DB(), q)
+	if err != nil {
+		return false, err
+	}
+
+	if len(pq.Statements) != 1 {
+		return false, errors.New("Exists requires exactly one SELECT statement")
+	}
+
+	return planner.Exists(tx.Transaction, pq.Statements[0], argsToParams(args))
+}
+
 // Exec a query against the database within tx and without returning the result.
 func (tx *Tx) Exec(q string, args ...interface{}) error {
 	res, err := tx.Query(q, args...)
@@ -167,3 +294,70 @@ func (tx *Tx) Exec(q string, args ...interface{}) error {
 
 	return res.Close()
 }
+
+// Get returns the document whose primary key is pk from the table.
+// Like for DeleteKeys, the primary key is the value returned by the pk() function.
+// If no document is found, it returns database.ErrDocumentNotFound.
+func (tx *Tx) Get(tableName string, pk document.Value) (document.Document, error) {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := t.EncodeKey(pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.GetDocument(key)
+}
+
+// Replace the document whose primary key is pk in the table by d.
+// Like for DeleteKeys, the primary key is the value returned by the pk() function.
+// Field constraints are validated and indexes are automatically updated.
+// If no document is found, it returns database.ErrDocumentNotFound.
+func (tx *Tx) Replace(tableName string, pk document.Value, d document.Document) error {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+
+	key, err := t.EncodeKey(pk)
+	if err != nil {
+		return err
+	}
+
+	return t.Replace(key, d)
+}
+
+// DeleteKeys deletes the documents whose primary keys are pks from the table.
+// The primary keys are the values returned by the pk() function, which makes
+// it possible to delete the documents selected by a SELECT pk() query without
+// reading them again.
+// Indexes are automatically updated and missing documents are skipped.
+// It returns the number of deleted documents.
+func (tx *Tx) DeleteKeys(tableName string, pks ...document.Value) (int, error) {
+	t, err := tx.GetTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, pk := range pks {
+		key, err := t.EncodeKey(pk)
+		if err != nil {
+			return n, err
+		}
+
+		err = t.Delete(key)
+		if err == database.ErrDocumentNotFound {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
@@ -1,6 +1,8 @@
 package genji_test
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"log"
 	"testing"
@@ -11,6 +13,20 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// queryJSON runs the query and returns the documents of its result as a JSON array.
+func queryJSON(t testing.TB, db *genji.DB, q string) string {
+	t.Helper()
+
+	res, err := db.Query(q)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = document.IteratorToJSONArray(&buf, res)
+	require.NoError(t, err)
+	return buf.String()
+}
+
 func ExampleTx() {
 	db, err := genji.Open(":memory:")
 	if err != nil {
@@ -117,3 +133,381 @@ func TestQueryDocument(t *testing.T) {
 		require.Nil(t, r)
 	})
 }
+
+func TestExists(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// counts the documents read by the query
+	var calls int
+	err = db.RegisterFunc("seen", 1, func(args []document.Value) (document.Value, error) {
+		calls++
+		return document.NewBoolValue(true), nil
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		CREATE INDEX idx_a ON test(a);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar'), (3, 'baz'), (4, 'bar')
+	`)
+	require.NoError(t, err)
+
+	t.Run("Should report matching documents", func(t *testing.T) {
+		ok, err := db.Exists("SELECT * FROM test WHERE a > ?", 2)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = db.Exists("SELECT * FROM test WHERE a > 10")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		ok, err = db.Exists("SELECT * FROM test WHERE b = 'bar' ORDER BY a DESC OFFSET 1")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = db.Exists("SELECT * FROM test WHERE b = 'bar' OFFSET 2")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		ok, err = db.Exists("SELECT a FROM test EXCEPT SELECT a FROM test")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		err = db.View(func(tx *genji.Tx) error {
+			ok, err = tx.Exists("SELECT * FROM test WHERE b = 'baz'")
+			return err
+		})
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("Should stop at the first match", func(t *testing.T) {
+		calls = 0
+		ok, err := db.Exists("SELECT * FROM test WHERE seen(a)")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("Should only accept one SELECT statement", func(t *testing.T) {
+		_, err := db.Exists("DELETE FROM test")
+		require.Error(t, err)
+		_, err = db.Exists("SELECT * FROM test; SELECT * FROM test")
+		require.Error(t, err)
+
+		// the documents must not have been deleted
+		ok, err := db.Exists("SELECT * FROM test")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+}
+
+func TestTxGetReplace(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (a INTEGER PRIMARY KEY);
+		CREATE INDEX idx_b ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar')
+	`)
+	require.NoError(t, err)
+
+	key := document.NewIntegerValue(1)
+
+	err = db.Update(func(tx *genji.Tx) error {
+		d, err := tx.Get("test", key)
+		require.NoError(t, err)
+
+		var fb document.FieldBuffer
+		err = fb.Copy(d)
+		require.NoError(t, err)
+		require.NoError(t, fb.Replace("b", document.NewTextValue("baz")))
+
+		return tx.Replace("test", key, &fb)
+	})
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT a FROM test WHERE b = 'baz'")
+	require.NoError(t, err)
+	var a int
+	require.NoError(t, document.Scan(d, &a))
+	require.Equal(t, 1, a)
+
+	_, err = db.QueryDocument("SELECT a FROM test WHERE b = 'foo'")
+	require.Equal(t, database.ErrDocumentNotFound, err)
+
+	err = db.View(func(tx *genji.Tx) error {
+		// primary keys are converted to the type of the primary key
+		d, err := tx.Get("test", document.NewDoubleValue(2))
+		require.NoError(t, err)
+		v, err := d.GetByField("b")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("bar"), v)
+
+		_, err = tx.Get("test", document.NewIntegerValue(10))
+		require.Equal(t, database.ErrDocumentNotFound, err)
+
+		_, err = tx.Get("unknown", key)
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestTxDeleteKeys(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		CREATE INDEX idx_a ON test(a);
+		CREATE TABLE withpk (id TEXT PRIMARY KEY);
+		CREATE TABLE untyped (id PRIMARY KEY);
+		INSERT INTO test (a) VALUES (1), (2), (3), (2);
+		INSERT INTO withpk (id, a) VALUES ('x', 1), ('y', 2), ('z', 3);
+		INSERT INTO untyped (id) VALUES (1), (2);
+	`)
+	require.NoError(t, err)
+
+	selectPKs := func(tx *genji.Tx, q string) []document.Value {
+		res, err := tx.Query(q)
+		require.NoError(t, err)
+		defer res.Close()
+
+		var pks []document.Value
+		err = res.Iterate(func(d document.Document) error {
+			v, err := d.GetByField("pk()")
+			if err != nil {
+				return err
+			}
+			pks = append(pks, v)
+			return nil
+		})
+		require.NoError(t, err)
+		return pks
+	}
+
+	count := func(q string) int {
+		d, err := db.QueryDocument(q)
+		require.NoError(t, err)
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		return n
+	}
+
+	err = db.Update(func(tx *genji.Tx) error {
+		n, err := tx.DeleteKeys("test", selectPKs(tx, "SELECT pk() FROM test WHERE a = 2")...)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		n, err = tx.DeleteKeys("withpk", selectPKs(tx, "SELECT pk() FROM withpk WHERE id > 'x'")...)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		// integers are converted to the type of the primary key
+		n, err = tx.DeleteKeys("untyped", document.NewIntegerValue(1))
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		// missing documents are skipped
+		n, err = tx.DeleteKeys("test", document.NewIntegerValue(2), document.NewIntegerValue(10))
+		require.NoError(t, err)
+		require.Equal(t, 0, n)
+
+		_, err = tx.DeleteKeys("test", document.NewTextValue("foo"))
+		require.Error(t, err)
+
+		_, err = tx.DeleteKeys("unknown", document.NewIntegerValue(1))
+		require.True(t, errors.Is(err, database.ErrTableNotFound))
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM test"))
+	require.Equal(t, 0, count("SELECT COUNT(*) FROM test WHERE a = 2"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM withpk"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM untyped"))
+}
+
+func TestRegisterFunc(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.RegisterFunc("twice", 1, func(args []document.Value) (document.Value, error) {
+		return args[0].Mul(document.NewIntegerValue(2))
+	})
+	require.NoError(t, err)
+
+	err = db.RegisterFunc("glue", -1, func(args []document.Value) (document.Value, error) {
+		var s string
+		for _, a := range args {
+			s += fmt.Sprintf("%v", a.V)
+		}
+		return document.NewTextValue(s), nil
+	})
+	require.NoError(t, err)
+
+	// builtin and already registered functions can't be replaced
+	require.Error(t, db.RegisterFunc("COUNT", 1, func(args []document.Value) (document.Value, error) { return args[0], nil }))
+	require.Error(t, db.RegisterFunc("Twice", 1, func(args []document.Value) (document.Value, error) { return args[0], nil }))
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar'), (3, 'baz')
+	`)
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT TWICE(a) AS x, glue(b, '-', a) AS y FROM test WHERE twice(a) > 4")
+	require.NoError(t, err)
+	var x int
+	var y string
+	require.NoError(t, document.Scan(d, &x, &y))
+	require.Equal(t, 6, x)
+	require.Equal(t, "baz-3", y)
+
+	err = db.Exec("UPDATE test SET a = twice(a) WHERE b = ?", "foo")
+	require.NoError(t, err)
+	d, err = db.QueryDocument("SELECT a FROM test WHERE b = 'foo'")
+	require.NoError(t, err)
+	require.NoError(t, document.Scan(d, &x))
+	require.Equal(t, 2, x)
+
+	_, err = db.QueryDocument("SELECT twice(a, b) FROM test")
+	require.EqualError(t, err, "twice() takes 1 argument(s), got 2")
+
+	_, err = db.QueryDocument("SELECT triple(a) FROM test")
+	require.EqualError(t, err, `no such function: "triple"`)
+}
+
+// histogram counts the occurrences of each text value of a group.
+type histogram struct {
+	counts map[string]int64
+}
+
+func (h *histogram) Add(v document.Value) error {
+	if v.Type == document.TextValue {
+		h.counts[v.V.(string)]++
+	}
+	return nil
+}
+
+func (h *histogram) Result() (document.Value, error) {
+	fb := document.NewFieldBuffer()
+	for k, c := range h.counts {
+		fb.Add(k, document.NewIntegerValue(c))
+	}
+	return document.NewDocumentValue(fb), nil
+}
+
+func TestRegisterAggregateFunc(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var created int
+	err = db.RegisterAggregateFunc("histogram", func() database.Accumulator {
+		created++
+		return &histogram{counts: make(map[string]int64)}
+	})
+	require.NoError(t, err)
+
+	require.Error(t, db.RegisterAggregateFunc("sum", func() database.Accumulator { return nil }))
+	require.Error(t, db.RegisterFunc("HISTOGRAM", 1, func(args []document.Value) (document.Value, error) { return args[0], nil }))
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		INSERT INTO test (g, color) VALUES (1, 'red'), (1, 'blue'), (1, 'red'), (2, 'green'), (2, NULL);
+		INSERT INTO test (g) VALUES (2)
+	`)
+	require.NoError(t, err)
+
+	// one accumulator is created per group
+	created = 0
+	require.JSONEq(t,
+		`[{"g": 1, "h": {"red": 2, "blue": 1}}, {"g": 2, "h": {"green": 1}}]`,
+		queryJSON(t, db, "SELECT g, HISTOGRAM(color) AS h FROM test GROUP BY g"))
+	require.Equal(t, 2, created)
+
+	require.JSONEq(t,
+		`[{"histogram(color)": {"red": 2, "blue": 1, "green": 1}}]`,
+		queryJSON(t, db, "SELECT histogram(color) FROM test"))
+
+	_, err = db.Query("SELECT histogram(color, g) FROM test")
+	require.EqualError(t, err, "histogram() takes 1 argument")
+
+	_, err = db.QueryDocument("SELECT g FROM test WHERE histogram(color) = 1")
+	require.Error(t, err)
+
+	// aggregate functions are available to transactions
+	err = db.View(func(tx *genji.Tx) error {
+		d, err := tx.QueryDocument("SELECT histogram(color) AS h FROM test WHERE g = 2")
+		require.NoError(t, err)
+
+		v, err := d.GetByField("h")
+		require.NoError(t, err)
+		require.Equal(t, `{"green": 1}`, v.String())
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRegisterPrefixTable(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.RegisterPrefixTable("orders", database.PrefixTableConfig{
+		TableName: "kv",
+		Prefix:    "user:123:orders:",
+		Separator: ":",
+		Fields:    []string{"order_id", "item"},
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE kv(k TEXT PRIMARY KEY);
+		INSERT INTO kv (k, qty) VALUES
+			('user:123:name', 0),
+			('user:123:orders:2:book', 3),
+			('user:123:orders:1:pen', 1),
+			('user:123:orders:3', 5),
+			('user:124:orders:1:pen', 2);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT * FROM orders", `[{"order_id": "1", "item": "pen", "k": "user:123:orders:1:pen", "qty": 1}, {"order_id": "2", "item": "book", "k": "user:123:orders:2:book", "qty": 3}, {"order_id": "3", "k": "user:123:orders:3", "qty": 5}]`},
+		{"SELECT item, qty FROM orders WHERE order_id = '2'", `[{"item": "book", "qty": 3}]`},
+		{"SELECT order_id, pk() FROM orders ORDER BY qty DESC LIMIT 1", `[{"order_id": "3", "pk()": "user:123:orders:3"}]`},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+	}
+
+	// prefix tables are read-only
+	require.Error(t, db.Exec("INSERT INTO orders (k) VALUES ('user:123:orders:4')"))
+	require.Error(t, db.Exec("DELETE FROM orders"))
+	require.Error(t, db.Exec("CREATE TABLE orders"))
+
+	// the source table must have a TEXT primary key
+	err = db.RegisterPrefixTable("bad", database.PrefixTableConfig{TableName: "test", Prefix: "a"})
+	require.NoError(t, err)
+	err = db.Exec("CREATE TABLE test")
+	require.NoError(t, err)
+	_, err = db.Query("SELECT * FROM bad")
+	require.Error(t, err)
+
+	// names can only be registered once
+	require.Error(t, db.RegisterPrefixTable("orders", database.PrefixTableConfig{TableName: "kv", Prefix: "a"}))
+	require.Error(t, db.RegisterPrefixTable("other", database.PrefixTableConfig{TableName: "kv", Prefix: "a", Fields: []string{"a", "b"}}))
+}
@@ -3,6 +3,7 @@ package document
 import (
 	"encoding/base64"
 	"fmt"
+	"math"
 	"strconv"
 )
 
@@ -24,6 +25,10 @@ func (v Value) CastAs(t ValueType) (Value, error) {
 		return v.CastAsInteger()
 	case DoubleValue:
 		return v.CastAsDouble()
+	case DecimalValue:
+		return v.CastAsDecimal()
+	case TimestampValue:
+		return v.CastAsTimestamp()
 	case BlobValue:
 		return v.CastAsBlob()
 	case TextValue:
@@ -61,12 +66,16 @@ func (v Value) CastAsBool() (Value, error) {
 
 // CastAsInteger casts according to the following rules:
 // Bool: returns 1 if true, 0 if false.
-// Double: cuts off the decimal and remaining numbers.
+// Double: truncates toward zero, i.e. cuts off the fractional part: 5.9 becomes 5 and -5.9 becomes -5.
+// It fails if the double is NaN, infinite, or if the result doesn't fit in an integer.
+// Decimal: truncates toward zero, fails if the result doesn't fit in an integer.
 // Text: uses strconv.ParseInt to determine the integer value,
 // then casts it to an integer. If it fails uses strconv.ParseFloat
-// to determine the double value, then casts it to an integer
+// to determine the double value, then casts it to an integer following the rules of doubles.
 // It fails if the text doesn't contain a valid float value.
 // Any other type is considered an invalid cast.
+// These rules apply to every conversion of a number to an integer,
+// including CAST and the evaluation of LIMIT and OFFSET clauses.
 func (v Value) CastAsInteger() (Value, error) {
 	switch v.Type {
 	case IntegerValue:
@@ -77,7 +86,17 @@ func (v Value) CastAsInteger() (Value, error) {
 		}
 		return NewIntegerValue(0), nil
 	case DoubleValue:
-		return NewIntegerValue(int64(v.V.(float64))), nil
+		i, err := doubleToInt64(v.V.(float64))
+		if err != nil {
+			return Value{}, err
+		}
+		return NewIntegerValue(i), nil
+	case DecimalValue:
+		i, err := v.V.(Decimal).Int64()
+		if err != nil {
+			return Value{}, err
+		}
+		return NewIntegerValue(i), nil
 	case TextValue:
 		i, err := strconv.ParseInt(v.V.(string), 10, 64)
 		if err != nil {
@@ -86,7 +105,10 @@ func (v Value) CastAsInteger() (Value, error) {
 			if err != nil {
 				return Value{}, fmt.Errorf(`cannot cast %q as integer: %w`, v.V, intErr)
 			}
-			i = int64(f)
+			i, err = doubleToInt64(f)
+			if err != nil {
+				return Value{}, err
+			}
 		}
 		return NewIntegerValue(i), nil
 	}
@@ -94,8 +116,26 @@ func (v Value) CastAsInteger() (Value, error) {
 	return Value{}, fmt.Errorf("cannot cast %s as integer", v.Type)
 }
 
+// doubleToInt64 truncates f toward zero.
+// It fails if f is NaN, infinite, or if the result doesn't fit in an integer.
+func doubleToInt64(f float64) (int64, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("cannot convert double %v to integer", f)
+	}
+
+	f = math.Trunc(f)
+	// -2^63 is the smallest integer and can be represented exactly by a double,
+	// 2^63 is the smallest double greater than the biggest integer.
+	if f < math.MinInt64 || f >= -math.MinInt64 {
+		return 0, fmt.Errorf("cannot convert double %v to integer without overflowing", f)
+	}
+
+	return int64(f), nil
+}
+
 // CastAsDouble casts according to the following rules:
 // Integer: returns a double version of the integer.
+// Decimal: returns the nearest double.
 // Text: uses strconv.ParseFloat to determine the double value,
 // it fails if the text doesn't contain a valid float value.
 // Any other type is considered an invalid cast.
@@ -105,6 +145,8 @@ func (v Value) CastAsDouble() (Value, error) {
 		return v, nil
 	case IntegerValue:
 		return NewDoubleValue(float64(v.V.(int64))), nil
+	case DecimalValue:
+		return NewDoubleValue(v.V.(Decimal).Float64()), nil
 	case TextValue:
 		f, err := strconv.ParseFloat(v.V.(string), 64)
 		if err != nil {
@@ -116,8 +158,57 @@ func (v Value) CastAsDouble() (Value, error) {
 	return Value{}, fmt.Errorf("cannot cast %s as double", v.Type)
 }
 
+// CastAsDecimal casts according to the following rules:
+// Integer: returns the exact decimal version of the integer.
+// Double: returns the decimal with the fewest digits that converts back to the double,
+// it fails if the double is NaN or infinite.
+// Text: parses the text as a decimal number, it fails if the text doesn't contain a valid decimal.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsDecimal() (Value, error) {
+	switch v.Type {
+	case DecimalValue:
+		return v, nil
+	case IntegerValue:
+		return NewDecimalValue(NewDecimal(v.V.(int64), 0)), nil
+	case DoubleValue:
+		d, err := NewDecimalFromFloat64(v.V.(float64))
+		if err != nil {
+			return Value{}, err
+		}
+		return NewDecimalValue(d), nil
+	case TextValue:
+		d, err := ParseDecimal(v.V.(string))
+		if err != nil {
+			return Value{}, fmt.Errorf(`cannot cast %q as decimal: %w`, v.V, err)
+		}
+		return NewDecimalValue(d), nil
+	}
+
+	return Value{}, fmt.Errorf("cannot cast %s as decimal", v.Type)
+}
+
+// CastAsTimestamp casts according to the following rules:
+// Text: parses the text using ParseTimestamp, it fails if the text
+// doesn't contain a timestamp formatted using RFC 3339.
+// Any other type is considered an invalid cast.
+func (v Value) CastAsTimestamp() (Value, error) {
+	switch v.Type {
+	case TimestampValue:
+		return v, nil
+	case TextValue:
+		t, err := ParseTimestamp(v.V.(string))
+		if err != nil {
+			return Value{}, fmt.Errorf(`cannot cast %q as timestamp: %w`, v.V, err)
+		}
+		return NewTimestampValue(t), nil
+	}
+
+	return Value{}, fmt.Errorf("cannot cast %s as timestamp", v.Type)
+}
+
 // CastAsText returns a JSON representation of v.
 // If the representation is a string, it gets unquoted.
+// Timestamps are formatted using RFC 3339.
 func (v Value) CastAsText() (Value, error) {
 	if v.Type == TextValue {
 		return v, nil
@@ -130,7 +221,7 @@ func (v Value) CastAsText() (Value, error) {
 
 	s := string(d)
 
-	if v.Type == BlobValue {
+	if v.Type == BlobValue || v.Type == TimestampValue {
 		s, err = strconv.Unquote(s)
 		if err != nil {
 			return Value{}, err
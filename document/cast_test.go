@@ -1,6 +1,7 @@
 package document
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -62,6 +63,19 @@ func TestCastAs(t *testing.T) {
 			{textV, Value{}, true},
 			{NewTextValue("10"), integerV, false},
 			{NewTextValue("10.5"), integerV, false},
+			{NewDoubleValue(5.9), NewIntegerValue(5), false},
+			{NewDoubleValue(-5.9), NewIntegerValue(-5), false},
+			{NewDoubleValue(-0.5), NewIntegerValue(0), false},
+			{NewTextValue("-5.9"), NewIntegerValue(-5), false},
+			{NewDoubleValue(-9223372036854775808), NewIntegerValue(math.MinInt64), false},
+			{NewDoubleValue(9223372036854775807), Value{}, true},
+			{NewDoubleValue(1e19), Value{}, true},
+			{NewDoubleValue(-1e19), Value{}, true},
+			{NewDoubleValue(math.Inf(1)), Value{}, true},
+			{NewDoubleValue(math.Inf(-1)), Value{}, true},
+			{NewDoubleValue(math.NaN()), Value{}, true},
+			{NewTextValue("1e20"), Value{}, true},
+			{NewTextValue("NaN"), Value{}, true},
 			{blobV, Value{}, true},
 			{arrayV, Value{}, true},
 			{docV, Value{}, true},
@@ -89,10 +89,19 @@ func compare(op operator, l, r Value) (bool, error) {
 	case l.Type == IntegerValue && r.Type == IntegerValue:
 		return compareIntegers(op, l.V.(int64), r.V.(int64)), nil
 
+	// compare decimals with other numbers
+	case (l.Type == DecimalValue || r.Type == DecimalValue) && l.Type.IsNumber() && r.Type.IsNumber():
+		return compareDecimals(op, l, r)
+
 	// compare numbers together
 	case l.Type.IsNumber() && r.Type.IsNumber():
 		return compareNumbers(op, l, r)
 
+	// compare timestamps together or with texts
+	case (l.Type == TimestampValue && (r.Type == TimestampValue || r.Type == TextValue)) ||
+		(r.Type == TimestampValue && l.Type == TextValue):
+		return compareTimestamps(op, l, r)
+
 	// compare arrays together
 	case l.Type == ArrayValue && r.Type == ArrayValue:
 		return compareArrays(op, l.V.(Array), r.V.(Array))
@@ -217,6 +226,37 @@ func compareNumbers(op operator, l, r Value) (bool, error) {
 	return ok, nil
 }
 
+// compareDecimals compares numbers exactly.
+// NaN and infinite doubles can't be converted to decimals,
+// they are compared as doubles.
+func compareDecimals(op operator, l, r Value) (bool, error) {
+	dl, err := l.CastAsDecimal()
+	if err != nil {
+		return compareNumbers(op, l, r)
+	}
+	dr, err := r.CastAsDecimal()
+	if err != nil {
+		return compareNumbers(op, l, r)
+	}
+
+	cmp := dl.V.(Decimal).Cmp(dr.V.(Decimal))
+
+	switch op {
+	case operatorEq:
+		return cmp == 0, nil
+	case operatorGt:
+		return cmp > 0, nil
+	case operatorGte:
+		return cmp >= 0, nil
+	case operatorLt:
+		return cmp < 0, nil
+	case operatorLte:
+		return cmp <= 0, nil
+	}
+
+	return false, nil
+}
+
 func compareArrays(op operator, l Array, r Array) (bool, error) {
 	var i, j int
 
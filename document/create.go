@@ -170,7 +170,9 @@ func NewValue(x interface{}) (Value, error) {
 	case time.Duration:
 		return NewIntegerValue(v.Nanoseconds()), nil
 	case time.Time:
-		return NewTextValue(v.Format(time.RFC3339Nano)), nil
+		return NewTimestampValue(v), nil
+	case Decimal:
+		return NewDecimalValue(v), nil
 	case nil:
 		return NewNullValue(), nil
 	case Document:
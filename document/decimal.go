@@ -0,0 +1,341 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/genjidb/genji/binarysort"
+)
+
+// maxDecimalExponent is the largest absolute exponent accepted
+// when parsing a decimal, to bound the size of the numbers
+// created when aligning two decimals.
+const maxDecimalExponent = 1000
+
+var bigTen = big.NewInt(10)
+
+// Decimal is an arbitrary-precision decimal number, used to store values
+// that must not be affected by binary floating-point rounding, like amounts of money.
+// It is made of an integer coefficient and a base 10 exponent: the coefficient 199
+// with the exponent -2 represents 1.99.
+// Additions, subtractions and multiplications of decimals are exact and
+// keep the scale of their operands: 1.50 + 1 returns 2.50.
+// The zero value of Decimal represents 0.
+type Decimal struct {
+	coef *big.Int
+	exp  int
+}
+
+// NewDecimal returns the decimal coef * 10^exp.
+func NewDecimal(coef int64, exp int) Decimal {
+	return Decimal{coef: big.NewInt(coef), exp: exp}
+}
+
+// ParseDecimal parses a decimal number written in base 10,
+// with an optional sign, fractional part and exponent, like
+// "1.99", "-0.5" or "1e-3".
+func ParseDecimal(s string) (Decimal, error) {
+	return parseDecimal(s, maxDecimalExponent)
+}
+
+func parseDecimal(s string, maxExp int) (Decimal, error) {
+	invalid := func() (Decimal, error) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	mantissa := s
+	var exp int
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		x, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return invalid()
+		}
+		exp = x
+		mantissa = s[:i]
+	}
+
+	var neg bool
+	if len(mantissa) > 0 && (mantissa[0] == '-' || mantissa[0] == '+') {
+		neg = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+
+	digits := intPart + fracPart
+	if digits == "" {
+		return invalid()
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return invalid()
+		}
+	}
+
+	exp -= len(fracPart)
+	if exp > maxExp || exp < -maxExp {
+		return Decimal{}, fmt.Errorf("decimal %q is out of range", s)
+	}
+
+	coef, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return invalid()
+	}
+	if neg {
+		coef.Neg(coef)
+	}
+
+	return Decimal{coef: coef, exp: exp}, nil
+}
+
+// NewDecimalFromFloat64 returns the decimal with the fewest digits
+// that converts back to f. It fails if f is NaN or infinite.
+func NewDecimalFromFloat64(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("cannot convert %v to decimal", f)
+	}
+
+	return ParseDecimal(strconv.FormatFloat(f, 'e', -1, 64))
+}
+
+func (d Decimal) bigCoef() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+
+	return d.coef
+}
+
+// Sign returns -1 if d is negative, 0 if d is zero and 1 if d is positive.
+func (d Decimal) Sign() int {
+	return d.bigCoef().Sign()
+}
+
+// align returns the coefficients of a and b scaled to the same exponent.
+func align(a, b Decimal) (*big.Int, *big.Int, int) {
+	ca, cb := a.bigCoef(), b.bigCoef()
+
+	switch {
+	case a.exp > b.exp:
+		ca = new(big.Int).Mul(ca, pow10(a.exp-b.exp))
+		return ca, cb, b.exp
+	case a.exp < b.exp:
+		cb = new(big.Int).Mul(cb, pow10(b.exp-a.exp))
+		return ca, cb, a.exp
+	}
+
+	return ca, cb, a.exp
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// Add returns d + x.
+func (d Decimal) Add(x Decimal) Decimal {
+	ca, cb, exp := align(d, x)
+	return Decimal{coef: new(big.Int).Add(ca, cb), exp: exp}
+}
+
+// Sub returns d - x.
+func (d Decimal) Sub(x Decimal) Decimal {
+	ca, cb, exp := align(d, x)
+	return Decimal{coef: new(big.Int).Sub(ca, cb), exp: exp}
+}
+
+// Mul returns d * x.
+func (d Decimal) Mul(x Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.bigCoef(), x.bigCoef()), exp: d.exp + x.exp}
+}
+
+// Cmp compares d and x and returns -1 if d < x, 0 if d == x and 1 if d > x.
+// The scale is ignored: 1.5 and 1.50 are equal.
+func (d Decimal) Cmp(x Decimal) int {
+	ca, cb, _ := align(d, x)
+	return ca.Cmp(cb)
+}
+
+// Float64 returns the double nearest to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.bigCoef().String()+"e"+strconv.Itoa(d.exp), 64)
+	return f
+}
+
+// Int64 returns the integer part of d. It fails if it doesn't fit in an int64.
+func (d Decimal) Int64() (int64, error) {
+	c := d.bigCoef()
+	if d.exp > 0 {
+		c = new(big.Int).Mul(c, pow10(d.exp))
+	} else if d.exp < 0 {
+		c = new(big.Int).Quo(c, pow10(-d.exp))
+	}
+
+	if !c.IsInt64() {
+		return 0, fmt.Errorf("cannot convert decimal %s to integer without overflowing", d)
+	}
+
+	return c.Int64(), nil
+}
+
+// String returns the representation of d in base 10, without exponent.
+func (d Decimal) String() string {
+	c := d.bigCoef()
+	digits := new(big.Int).Abs(c).String()
+
+	var sb strings.Builder
+	if c.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+
+	switch {
+	case d.exp >= 0:
+		sb.WriteString(digits)
+		if c.Sign() != 0 {
+			sb.WriteString(strings.Repeat("0", d.exp))
+		}
+	case len(digits) > -d.exp:
+		sb.WriteString(digits[:len(digits)+d.exp])
+		sb.WriteByte('.')
+		sb.WriteString(digits[len(digits)+d.exp:])
+	default:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", -d.exp-len(digits)))
+		sb.WriteString(digits)
+	}
+
+	return sb.String()
+}
+
+// MarshalText returns the representation of d returned by String.
+// It implements the encoding.TextMarshaler interface.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a decimal like ParseDecimal, without limiting its exponent.
+// It implements the encoding.TextUnmarshaler interface.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	x, err := parseDecimal(string(text), math.MaxInt32)
+	if err != nil {
+		return err
+	}
+
+	*d = x
+	return nil
+}
+
+// Decimals are encoded so that the lexicographic order of their
+// encoded form follows their numeric order, regardless of their scale:
+// a sign byte, followed for non-zero values by the exponent of the
+// normalized form 0.d1d2...dn * 10^e and one byte per digit,
+// terminated by a marker byte.
+// For negative values, the exponent and the digits are inverted.
+const (
+	decimalNegative = 0x01
+	decimalZero     = 0x02
+	decimalPositive = 0x03
+)
+
+func appendDecimal(buf []byte, d Decimal) []byte {
+	c := d.bigCoef()
+	if c.Sign() == 0 {
+		return append(buf, decimalZero)
+	}
+
+	// |d| = 0.abs * 10^e
+	abs := new(big.Int).Abs(c).String()
+	e := int64(len(abs) + d.exp)
+	digits := strings.TrimRight(abs, "0")
+
+	neg := c.Sign() < 0
+	if neg {
+		buf = append(buf, decimalNegative)
+	} else {
+		buf = append(buf, decimalPositive)
+	}
+
+	start := len(buf)
+	buf = binarysort.AppendInt64(buf, e)
+	for i := 0; i < len(digits); i++ {
+		buf = append(buf, digits[i]-'0'+1)
+	}
+	buf = append(buf, 0)
+
+	if neg {
+		for i := start; i < len(buf); i++ {
+			buf[i] = ^buf[i]
+		}
+	}
+
+	return buf
+}
+
+var errMalformedDecimal = errors.New("malformed decimal")
+
+// decodeDecimal decodes a decimal encoded with appendDecimal
+// and returns the number of bytes read.
+func decodeDecimal(data []byte) (Decimal, int, error) {
+	if len(data) == 0 {
+		return Decimal{}, 0, errMalformedDecimal
+	}
+
+	var neg bool
+	switch data[0] {
+	case decimalZero:
+		return Decimal{coef: new(big.Int)}, 1, nil
+	case decimalNegative:
+		neg = true
+	case decimalPositive:
+	default:
+		return Decimal{}, 0, errMalformedDecimal
+	}
+
+	if len(data) < 10 {
+		return Decimal{}, 0, errMalformedDecimal
+	}
+
+	var eb [8]byte
+	copy(eb[:], data[1:9])
+	end := byte(0)
+	if neg {
+		for i := range eb {
+			eb[i] = ^eb[i]
+		}
+		end = 0xff
+	}
+
+	e, err := binarysort.DecodeInt64(eb[:])
+	if err != nil {
+		return Decimal{}, 0, err
+	}
+
+	var digits []byte
+	i := 9
+	for ; i < len(data) && data[i] != end; i++ {
+		b := data[i]
+		if neg {
+			b = ^b
+		}
+		if b < 1 || b > 10 {
+			return Decimal{}, 0, errMalformedDecimal
+		}
+		digits = append(digits, b-1+'0')
+	}
+	if i == len(data) || len(digits) == 0 {
+		return Decimal{}, 0, errMalformedDecimal
+	}
+
+	coef, _ := new(big.Int).SetString(string(digits), 10)
+	if neg {
+		coef.Neg(coef)
+	}
+
+	return Decimal{coef: coef, exp: int(e) - len(digits)}, i + 1, nil
+}
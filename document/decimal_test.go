@@ -0,0 +1,247 @@
+package document_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func parseDecimal(t testing.TB, s string) document.Decimal {
+	t.Helper()
+
+	d, err := document.ParseDecimal(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		fails bool
+	}{
+		{"0", "0", false},
+		{"1.99", "1.99", false},
+		{"1.50", "1.50", false},
+		{"-0.001", "-0.001", false},
+		{"+12", "12", false},
+		{".5", "0.5", false},
+		{"5.", "5", false},
+		{"1e3", "1000", false},
+		{"1.5E-3", "0.0015", false},
+		{"-2.5e1", "-25", false},
+		{"0.00", "0.00", false},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", false},
+		{"", "", true},
+		{".", "", true},
+		{"-", "", true},
+		{"1.2.3", "", true},
+		{"1e", "", true},
+		{"1e1.5", "", true},
+		{"abc", "", true},
+		{"NaN", "", true},
+		{"1e100000", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			d, err := document.ParseDecimal(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, d.String())
+		})
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		a, b          string
+		add, sub, mul string
+	}{
+		{"0.1", "0.2", "0.3", "-0.1", "0.02"},
+		{"1.50", "1", "2.50", "0.50", "1.50"},
+		{"19.99", "3", "22.99", "16.99", "59.97"},
+		{"1.10", "1.1", "2.20", "0.00", "1.210"},
+		{"-0.05", "0.05", "0.00", "-0.10", "-0.0025"},
+		{"9223372036854775807", "1", "9223372036854775808", "9223372036854775806", "9223372036854775807"},
+		{"1e3", "0.001", "1000.001", "999.999", "1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			a, b := parseDecimal(t, test.a), parseDecimal(t, test.b)
+			require.Equal(t, test.add, a.Add(b).String())
+			require.Equal(t, test.sub, a.Sub(b).String())
+			require.Equal(t, test.mul, a.Mul(b).String())
+		})
+	}
+
+	// the zero value is 0
+	var zero document.Decimal
+	require.Equal(t, "0", zero.String())
+	require.Equal(t, "1.5", zero.Add(parseDecimal(t, "1.5")).String())
+	require.Equal(t, 0, zero.Sign())
+}
+
+func TestDecimalConversions(t *testing.T) {
+	d, err := document.NewDecimalFromFloat64(1.99)
+	require.NoError(t, err)
+	require.Equal(t, "1.99", d.String())
+	require.Equal(t, 1.99, d.Float64())
+
+	x, y := 0.1, 0.2
+	d, err = document.NewDecimalFromFloat64(x + y)
+	require.NoError(t, err)
+	require.Equal(t, "0.30000000000000004", d.String())
+
+	_, err = document.NewDecimalFromFloat64(math.NaN())
+	require.Error(t, err)
+	_, err = document.NewDecimalFromFloat64(math.Inf(-1))
+	require.Error(t, err)
+
+	i, err := parseDecimal(t, "-12.99").Int64()
+	require.NoError(t, err)
+	require.EqualValues(t, -12, i)
+
+	i, err = parseDecimal(t, "1.2e3").Int64()
+	require.NoError(t, err)
+	require.EqualValues(t, 1200, i)
+
+	_, err = parseDecimal(t, "1e20").Int64()
+	require.Error(t, err)
+}
+
+func TestDecimalOrder(t *testing.T) {
+	// sorted in ascending order
+	sorted := []string{
+		"-1e999", "-123.45", "-100", "-99.999", "-10", "-1.5", "-1.25", "-1", "-0.5", "-0.05", "-1e-999",
+		"0",
+		"1e-999", "0.0001", "0.05", "0.1", "0.12", "0.123", "1", "1.01", "1.1", "9.99", "10", "10.5", "100", "1e999",
+	}
+
+	encode := func(d document.Decimal) ([]byte, []byte) {
+		v := document.NewDecimalValue(d)
+
+		bin, err := v.MarshalBinary()
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = document.NewValueEncoder(&buf).Encode(v)
+		require.NoError(t, err)
+
+		return bin, buf.Bytes()
+	}
+
+	var prevBin, prevEnc []byte
+	for i, s := range sorted {
+		d := parseDecimal(t, s)
+		bin, enc := encode(d)
+
+		if i > 0 {
+			require.Equal(t, -1, bytes.Compare(prevBin, bin), "%s < %s", sorted[i-1], s)
+			require.Equal(t, -1, bytes.Compare(prevEnc, enc), "%s < %s", sorted[i-1], s)
+
+			require.Equal(t, -1, parseDecimal(t, sorted[i-1]).Cmp(d))
+			ok, err := document.NewDecimalValue(parseDecimal(t, sorted[i-1])).IsLesserThan(document.NewDecimalValue(d))
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		prevBin, prevEnc = bin, enc
+
+		// round trip
+		v := document.Value{Type: document.DecimalValue}
+		err := v.UnmarshalBinary(bin)
+		require.NoError(t, err)
+		require.Equal(t, 0, v.V.(document.Decimal).Cmp(d))
+
+		v, err = document.DecodeValue(enc)
+		require.NoError(t, err)
+		require.Equal(t, 0, v.V.(document.Decimal).Cmp(d))
+	}
+
+	// the scale doesn't change the encoding
+	a, _ := encode(parseDecimal(t, "1.5"))
+	b, _ := encode(parseDecimal(t, "1.500"))
+	require.Equal(t, a, b)
+	a, _ = encode(parseDecimal(t, "-100"))
+	b, _ = encode(parseDecimal(t, "-1e2"))
+	require.Equal(t, a, b)
+}
+
+func TestDecimalValue(t *testing.T) {
+	price := document.NewDecimalValue(parseDecimal(t, "1.99"))
+
+	tests := []struct {
+		name string
+		fn   func() (document.Value, error)
+		want string
+	}{
+		{"decimal+decimal", func() (document.Value, error) { return price.Add(price) }, "3.98"},
+		{"decimal+integer", func() (document.Value, error) { return price.Add(document.NewIntegerValue(1)) }, "2.99"},
+		{"decimal+double", func() (document.Value, error) { return price.Add(document.NewDoubleValue(0.01)) }, "2.00"},
+		{"double-decimal", func() (document.Value, error) { return document.NewDoubleValue(2).Sub(price) }, "0.01"},
+		{"decimal*integer", func() (document.Value, error) { return price.Mul(document.NewIntegerValue(3)) }, "5.97"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := test.fn()
+			require.NoError(t, err)
+			require.Equal(t, document.DecimalValue, res.Type)
+			require.Equal(t, test.want, res.V.(document.Decimal).String())
+		})
+	}
+
+	// other operations use doubles
+	res, err := price.Div(document.NewIntegerValue(2))
+	require.NoError(t, err)
+	require.Equal(t, document.NewDoubleValue(0.995), res)
+
+	// NaN can't be converted
+	res, err = price.Add(document.NewDoubleValue(math.NaN()))
+	require.NoError(t, err)
+	require.Equal(t, document.NewNullValue(), res)
+
+	// comparisons are exact
+	for _, test := range []struct {
+		v    document.Value
+		eq   bool
+		less bool
+	}{
+		{document.NewDoubleValue(1.99), true, false},
+		{document.NewDecimalValue(parseDecimal(t, "1.990")), true, false},
+		{document.NewIntegerValue(2), false, true},
+		{document.NewDoubleValue(1.98), false, false},
+		{document.NewDoubleValue(math.Inf(1)), false, true},
+		{document.NewDoubleValue(math.NaN()), false, false},
+	} {
+		ok, err := price.IsEqual(test.v)
+		require.NoError(t, err)
+		require.Equal(t, test.eq, ok, test.v.String())
+
+		ok, err = price.IsLesserThan(test.v)
+		require.NoError(t, err)
+		require.Equal(t, test.less, ok, test.v.String())
+	}
+
+	b, err := price.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, "1.99", string(b))
+
+	var d document.Decimal
+	err = document.ScanValue(price, &d)
+	require.NoError(t, err)
+	require.Equal(t, "1.99", d.String())
+
+	var f float64
+	err = document.ScanValue(price, &f)
+	require.NoError(t, err)
+	require.Equal(t, 1.99, f)
+}
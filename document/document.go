@@ -4,11 +4,13 @@ package document
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/buger/jsonparser"
+	"github.com/genjidb/genji/sql/scanner"
 )
 
 // ErrFieldNotFound must be returned by Document implementations, when calling the GetByField method and
@@ -160,10 +162,17 @@ func (fb *FieldBuffer) setFieldValue(field string, reqValue Value) error {
 	return err
 }
 
-// setValueAtPath deep replaces or creates a field at the given path
-func setValueAtPath(v Value, p Path, newValue Value) (Value, error) {
+// setValueAtPath deep replaces or creates a field at the given path.
+// Only the last fragment of the path can be created, unless createParents is true,
+// in which case the missing documents leading to it are created as well.
+// If any other intermediate value doesn't exist, it returns ErrFieldNotFound.
+func setValueAtPath(v Value, p Path, newValue Value, createParents bool) (Value, error) {
 	switch v.Type {
 	case DocumentValue:
+		if p[0].FieldName == "" {
+			return v, ErrFieldNotFound
+		}
+
 		var buf FieldBuffer
 		err := buf.ScanDocument(v.V.(Document))
 		if err != nil {
@@ -175,12 +184,12 @@ func setValueAtPath(v Value, p Path, newValue Value) (Value, error) {
 			return NewDocumentValue(&buf), err
 		}
 
-		va, err := buf.GetByField(p[0].FieldName)
+		va, err := buf.getParent(p, createParents)
 		if err != nil {
 			return v, err
 		}
 
-		va, err = setValueAtPath(va, p[1:], newValue)
+		va, err = setValueAtPath(va, p[1:], newValue, createParents)
 		if err != nil {
 			return v, err
 		}
@@ -188,6 +197,10 @@ func setValueAtPath(v Value, p Path, newValue Value) (Value, error) {
 		err = buf.setFieldValue(p[0].FieldName, va)
 		return NewDocumentValue(&buf), err
 	case ArrayValue:
+		if p[0].FieldName != "" {
+			return v, ErrFieldNotFound
+		}
+
 		var vb ValueBuffer
 		err := vb.ScanArray(v.V.(Array))
 		if err != nil {
@@ -204,34 +217,62 @@ func setValueAtPath(v Value, p Path, newValue Value) (Value, error) {
 			return NewArrayValue(&vb), err
 		}
 
-		va, err = setValueAtPath(va, p[1:], newValue)
+		va, err = setValueAtPath(va, p[1:], newValue, createParents)
+		if err != nil {
+			return v, err
+		}
+
 		err = vb.Replace(p[0].ArrayIndex, va)
 		return NewArrayValue(&vb), err
 	}
 
-	return v, nil
+	return v, fmt.Errorf("cannot set %s on a value of type %s", p, v.Type)
+}
+
+// getParent returns the value of the first fragment of p, which must be a field name.
+// If the field doesn't exist and createParents is true, an empty document is returned
+// when the next fragment is a field name, since only documents can be created.
+func (fb *FieldBuffer) getParent(p Path, createParents bool) (Value, error) {
+	v, err := fb.GetByField(p[0].FieldName)
+	if err == ErrFieldNotFound && createParents && p[1].FieldName != "" {
+		return NewDocumentValue(NewFieldBuffer()), nil
+	}
+
+	return v, err
 }
 
 // Set replaces a field if it already exists or creates one if not.
+// Only the last fragment of the path can be created: if any intermediate
+// field or array index doesn't exist, it returns ErrFieldNotFound.
+// If an intermediate value is neither a document nor an array, it returns an error.
 func (fb *FieldBuffer) Set(path Path, v Value) error {
+	return fb.set(path, v, false)
+}
+
+// SetWithParents works like Set but also creates the missing documents of the path,
+// e.g. setting a.b.c on an empty document results in {"a": {"b": {"c": v}}}.
+// Arrays are never created nor extended: if the path goes through an array index
+// that doesn't exist, it returns ErrFieldNotFound.
+func (fb *FieldBuffer) SetWithParents(path Path, v Value) error {
+	return fb.set(path, v, true)
+}
+
+func (fb *FieldBuffer) set(path Path, v Value, createParents bool) error {
 	if len(path) == 1 {
 		return fb.setFieldValue(path[0].FieldName, v)
 	}
 
-	for i := range fb.fields {
-		if fb.fields[i].Field == path[0].FieldName {
-			va, err := setValueAtPath(fb.fields[i].Value, path[1:], v)
-			if err != nil {
-				return err
-			}
+	va, err := fb.getParent(path, createParents)
+	if err != nil {
+		return err
+	}
 
-			fb.fields[i].Value = va
-			return nil
-		}
+	va, err = setValueAtPath(va, path[1:], v, createParents)
+	if err != nil {
+		return err
 	}
 
-	fb.Add(path[0].FieldName, v)
-	return nil
+	return fb.setFieldValue(path[0].FieldName, va)
 }
 
 // Iterate goes through all the fields of the document and calls the given function by passing each one of them.
@@ -434,11 +475,12 @@ func (fb *FieldBuffer) Fields() []string {
 // A Path represents the path to a particular value within a document.
 type Path []PathFragment
 
-// PathFragment is a fragment of a path representing either a field name or
-// the index of an array.
+// PathFragment is a fragment of a path representing either a field name,
+// the index of an array or, if Wildcard is true, all the elements of an array.
 type PathFragment struct {
 	FieldName  string
 	ArrayIndex int
+	Wildcard   bool
 }
 
 // String representation of all the fragments of the path.
@@ -451,7 +493,9 @@ func (p Path) String() string {
 			if i != 0 {
 				b.WriteRune('.')
 			}
-			b.WriteString(p[i].FieldName)
+			writeFieldName(&b, p[i].FieldName)
+		} else if p[i].Wildcard {
+			b.WriteString("[*]")
 		} else {
 			b.WriteString("[" + strconv.Itoa(p[i].ArrayIndex) + "]")
 		}
@@ -459,6 +503,43 @@ func (p Path) String() string {
 	return b.String()
 }
 
+// writeFieldName writes the field name to b, between backquotes if the name
+// is not a valid bare identifier, so that paths are never ambiguous:
+// a.b is the field b of the document a, while `a.b` is the field named "a.b".
+// Keywords are quoted as well, so that the path can always be parsed back.
+func writeFieldName(b *strings.Builder, name string) {
+	if isBareIdent(name) {
+		b.WriteString(name)
+		return
+	}
+
+	b.WriteByte('`')
+	for _, r := range name {
+		if r == '`' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('`')
+}
+
+// isBareIdent returns true if name can be used in a path without quotes.
+func isBareIdent(name string) bool {
+	if name == "" || scanner.Lookup(name) != scanner.IDENT {
+		return false
+	}
+
+	for i, r := range name {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
 // IsEqual returns whether other is equal to p.
 func (p Path) IsEqual(other Path) bool {
 	if len(other) != len(p) {
@@ -474,7 +555,23 @@ func (p Path) IsEqual(other Path) bool {
 	return true
 }
 
+// HasWildcard returns true if one of the fragments of p is a wildcard.
+func (p Path) HasWildcard() bool {
+	for i := range p {
+		if p[i].Wildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetValue returns the value at path p.
+// If p contains wildcards, the value is an array containing all the values
+// matching p, in order. Elements that don't match the rest of the path are skipped,
+// and the matches of nested wildcards are flattened into the same array:
+// with {"a": [{"b": [1, 2]}, {"c": 3}, {"b": [4]}]}, a[*].b returns [[1, 2], [4]]
+// and a[*].b[*] returns [1, 2, 4].
 func (p Path) GetValue(v Value) (Value, error) {
 	return p.getValueFromValue(v)
 }
@@ -509,6 +606,10 @@ func (p Path) GetValueFromArray(a Array) (Value, error) {
 		return Value{}, ErrFieldNotFound
 	}
 
+	if p[0].Wildcard {
+		return p.getWildcardValue(a)
+	}
+
 	v, err := a.GetByIndex(p[0].ArrayIndex)
 	if err != nil {
 		if err == ErrValueNotFound {
@@ -525,6 +626,41 @@ func (p Path) GetValueFromArray(a Array) (Value, error) {
 	return p[1:].getValueFromValue(v)
 }
 
+// getWildcardValue returns an array with the values matching p[1:]
+// in every element of a.
+func (p Path) getWildcardValue(a Array) (Value, error) {
+	rest := p[1:]
+	flatten := rest.HasWildcard()
+
+	vb := NewValueBuffer()
+	err := a.Iterate(func(i int, v Value) error {
+		if len(rest) == 0 {
+			vb.Append(v)
+			return nil
+		}
+
+		v, err := rest.getValueFromValue(v)
+		if err == ErrFieldNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if flatten {
+			return vb.ScanArray(v.V.(Array))
+		}
+
+		vb.Append(v)
+		return nil
+	})
+	if err != nil {
+		return Value{}, err
+	}
+
+	return NewArrayValue(vb), nil
+}
+
 func (p Path) getValueFromValue(v Value) (Value, error) {
 	switch v.Type {
 	case DocumentValue:
@@ -114,7 +114,9 @@ func TestFieldBuffer(t *testing.T) {
 			{"number field", `{"a": {"0": [1, 2, 3]}}`, "a.`0`[0]", document.NewIntegerValue(6), `{"a": {"0": [6, 2, 3]}}`, false},
 			{"document in array", `{"a": [{"b":"foo"}, 2, 3]}`, `a[0].b`, document.NewTextValue("bar"), `{"a": [{"b": "bar"}, 2, 3]}`, false},
 			// with errors or request ignored doc unchanged
-			{"field not found", `{"a": {"b": [1, 2, 3]}}`, `a.b.c`, document.NewIntegerValue(1), `{"a": {"b": [1, 2, 3]}}`, false},
+			{"field on array", `{"a": {"b": [1, 2, 3]}}`, `a.b.c`, document.NewIntegerValue(1), ``, true},
+			{"unknown root", `{"a": {"b": [1, 2, 3]}}`, `c.d`, document.NewIntegerValue(1), ``, true},
+			{"scalar intermediate", `{"a": {"b": "foo"}}`, `a.b.c`, document.NewIntegerValue(1), ``, true},
 			{"unknown path", `{"a": {"b": [1, 2, 3]}}`, `a.e.f`, document.NewIntegerValue(1), ``, true},
 			{"index out of range", `{"a": {"b": [1, 2, 3]}}`, `a.b[1000]`, document.NewIntegerValue(1), ``, true},
 			{"document not array", `{"a": {"b": "foo"}}`, `a[0].b`, document.NewTextValue("bar"), ``, true},
@@ -143,6 +145,47 @@ func TestFieldBuffer(t *testing.T) {
 		}
 	})
 
+	t.Run("SetWithParents", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			data  string
+			path  string
+			want  string
+			fails bool
+		}{
+			{"root", `{}`, `a`, `{"a": 1}`, false},
+			{"missing documents", `{}`, `a.b.c`, `{"a": {"b": {"c": 1}}}`, false},
+			{"missing nested document", `{"a": {"b": 2}}`, `a.c.d`, `{"a": {"b": 2, "c": {"d": 1}}}`, false},
+			{"document in array", `{"a": [{"b": 2}]}`, `a[0].c.d`, `{"a": [{"b": 2, "c": {"d": 1}}]}`, false},
+			{"missing array", `{}`, `a[0]`, ``, true},
+			{"missing array in document", `{}`, `a.b[0].c`, ``, true},
+			{"index out of range", `{"a": [1]}`, `a[1].b`, ``, true},
+			{"field on array", `{"a": [1]}`, `a.b.c`, ``, true},
+			{"scalar intermediate", `{"a": 1}`, `a.b.c`, ``, true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var fb document.FieldBuffer
+
+				err := fb.Copy(document.NewFromJSON([]byte(tt.data)))
+				require.NoError(t, err)
+				p, err := parser.ParsePath(tt.path)
+				require.NoError(t, err)
+				err = fb.SetWithParents(p, document.NewIntegerValue(1))
+				if tt.fails {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				data, err := document.MarshalJSON(fb)
+				require.NoError(t, err)
+				require.Equal(t, tt.want, string(data))
+			})
+		}
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 		tests := []struct {
 			document   string
@@ -421,7 +464,7 @@ func TestNewFromStruct(t *testing.T) {
 			case 27:
 				require.EqualValues(t, document.IntegerValue, v.Type)
 			case 28:
-				require.EqualValues(t, document.TextValue, v.Type)
+				require.EqualValues(t, document.TimestampValue, v.Type)
 			default:
 				require.FailNowf(t, "", "unknown field %q", f)
 			}
@@ -517,7 +560,8 @@ func TestNewFromStruct(t *testing.T) {
 		require.NoError(t, v.Scan(&timeStr))
 		parsedTime, err := time.Parse(time.RFC3339Nano, timeStr)
 		require.NoError(t, err)
-		require.Equal(t, u.BB, parsedTime)
+		// timestamps are truncated to the microsecond
+		require.Equal(t, u.BB.Truncate(time.Microsecond), parsedTime)
 	})
 }
 
@@ -584,6 +628,13 @@ func TestPath(t *testing.T) {
 		{"number field", `{"a": {"0": [1, 2, 3]}}`, "a.`0`", `[1, 2, 3]`, false},
 		{"letter index", `{"a": {"b": [1, 2, 3]}}`, `a.b.c`, ``, true},
 		{"unknown path", `{"a": {"b": [1, 2, 3]}}`, `a.e.f`, ``, true},
+		{"wildcard", `{"a": {"b": [1, 2, 3]}}`, `a.b[*]`, `[1, 2, 3]`, false},
+		{"wildcard field", `{"a": [{"b": 1}, {"c": 2}, {"b": [3]}]}`, `a[*].b`, `[1, [3]]`, false},
+		{"wildcard index", `{"a": [[1, 2], [3], 4]}`, `a[*][1]`, `[2]`, false},
+		{"nested wildcards", `{"a": [{"b": [1, 2]}, {"c": 3}, {"b": [4]}]}`, `a[*].b[*]`, `[1, 2, 4]`, false},
+		{"no match", `{"a": [{"c": 1}]}`, `a[*].b`, `[]`, false},
+		{"wildcard on document", `{"a": {"b": 1}}`, `a[*]`, ``, true},
+		{"wildcard on missing field", `{"a": {"b": 1}}`, `c[*].b`, ``, true},
 	}
 
 	for _, test := range tests {
@@ -607,6 +658,35 @@ func TestPath(t *testing.T) {
 	}
 }
 
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		path     document.Path
+		expected string
+	}{
+		{document.Path{{FieldName: "a"}}, "a"},
+		{document.Path{{FieldName: "a"}, {FieldName: "b_1"}, {ArrayIndex: 2}}, "a.b_1[2]"},
+		{document.Path{{FieldName: "a"}, {Wildcard: true}, {FieldName: "b"}}, "a[*].b"},
+		{document.Path{{FieldName: "a.b"}}, "`a.b`"},
+		{document.Path{{FieldName: "a"}, {FieldName: "foo bar"}}, "a.`foo bar`"},
+		{document.Path{{FieldName: "0"}}, "`0`"},
+		{document.Path{{FieldName: "a`b\\c"}}, "`a\\`b\\\\c`"},
+		{document.Path{{FieldName: "order"}}, "`order`"},
+		{document.Path{{FieldName: "a"}, {FieldName: "SELECT"}, {FieldName: "null"}}, "a.`SELECT`.`null`"},
+		{document.Path{{FieldName: "decimal"}}, "`decimal`"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			require.Equal(t, test.expected, test.path.String())
+
+			// the string representation can be parsed back
+			p, err := parser.ParsePath(test.path.String())
+			require.NoError(t, err)
+			require.Equal(t, test.path, p)
+		})
+	}
+}
+
 func TestJSONDocument(t *testing.T) {
 	tests := []struct {
 		name     string
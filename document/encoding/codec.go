@@ -19,6 +19,16 @@ type Codec interface {
 	NewDocument([]byte) document.Document
 }
 
+// A FieldsDecoder is a document able to decode a subset of its top-level fields
+// in a single pass, without decoding the values of the other fields.
+// Documents returned by Codec.NewDocument may implement this interface.
+type FieldsDecoder interface {
+	// DecodeFields calls fn for each field of the document whose name is in fields,
+	// in the order they appear in the document. The values of the other fields
+	// are skipped without being decoded.
+	DecodeFields(fields []string, fn func(field string, value document.Value) error) error
+}
+
 // An Encoder encodes one document to the underlying writer.
 type Encoder interface {
 	EncodeDocument(d document.Document) error
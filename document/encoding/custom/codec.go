@@ -132,6 +132,10 @@ func EncodeValue(v document.Value) ([]byte, error) {
 		return encodeInt64(v.V.(int64)), nil
 	case document.DoubleValue:
 		return binarysort.AppendFloat64(nil, v.V.(float64)), nil
+	case document.DecimalValue:
+		return v.V.(document.Decimal).MarshalText()
+	case document.TimestampValue:
+		return v.MarshalBinary()
 	case document.NullValue:
 		return nil, nil
 	}
@@ -179,6 +183,37 @@ func (e EncodedDocument) Iterate(fn func(field string, value document.Value) err
 	return nil
 }
 
+// DecodeFields reads the header of the document and only decodes the selected fields.
+// It implements the encoding.FieldsDecoder interface.
+func (e EncodedDocument) DecodeFields(fields []string, fn func(field string, value document.Value) error) error {
+	var format Format
+	err := format.Decode(e)
+	if err != nil {
+		return err
+	}
+
+	for _, fh := range format.Header.FieldHeaders {
+		for _, f := range fields {
+			if string(fh.Name) != f {
+				continue
+			}
+
+			v, err := DecodeValue(document.ValueType(fh.Type), format.Body[fh.Offset:fh.Offset+fh.Size])
+			if err != nil {
+				return err
+			}
+
+			err = fn(f, v)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 func (e EncodedDocument) MarshalJSON() ([]byte, error) {
 	return document.MarshalJSON(e)
@@ -342,6 +377,20 @@ func DecodeValue(t document.ValueType, data []byte) (document.Value, error) {
 			return document.Value{}, err
 		}
 		return document.NewDoubleValue(x), nil
+	case document.DecimalValue:
+		var x document.Decimal
+		err := x.UnmarshalText(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return document.NewDecimalValue(x), nil
+	case document.TimestampValue:
+		v := document.Value{Type: t}
+		err := v.UnmarshalBinary(data)
+		if err != nil {
+			return document.Value{}, err
+		}
+		return v, nil
 	case document.NullValue:
 		return document.NewNullValue(), nil
 	}
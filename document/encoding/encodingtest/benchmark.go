@@ -21,6 +21,7 @@ func BenchmarkCodec(b *testing.B, codecBuilder func() encoding.Codec) {
 		{"Codec/Decode", benchmarkDecodeDocument},
 		{"Codec/Document/GetByField", benchmarkDocumentGetByField},
 		{"Codec/Document/Iterate", benchmarkDocumentIterate},
+		{"Codec/Document/DecodeFields", benchmarkDocumentDecodeFields},
 		{"ComparedWithJSON/Encode", benchmarkEncodeDocumentJSON},
 		{"ComparedWithJSON/Decode", benchmarkDecodeDocumentJSON},
 	}
@@ -140,3 +141,30 @@ func benchmarkDecodeDocumentJSON(b *testing.B, codecBuilder func() encoding.Code
 		json.Unmarshal(d, &mm)
 	}
 }
+
+func benchmarkDocumentDecodeFields(b *testing.B, codecBuilder func() encoding.Codec) {
+	var fb document.FieldBuffer
+
+	for i := int64(0); i < 100; i++ {
+		fb.Add(fmt.Sprintf("name-%d", i), document.NewIntegerValue(i))
+	}
+
+	codec := codecBuilder()
+	var buf bytes.Buffer
+	err := codec.NewEncoder(&buf).EncodeDocument(&fb)
+	require.NoError(b, err)
+
+	fd, ok := codec.NewDocument(buf.Bytes()).(encoding.FieldsDecoder)
+	if !ok {
+		b.Skip("codec doesn't implement encoding.FieldsDecoder")
+	}
+
+	fields := []string{"name-10", "name-50"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fd.DecodeFields(fields, func(string, document.Value) error {
+			return nil
+		})
+	}
+}
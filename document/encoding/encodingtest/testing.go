@@ -4,7 +4,10 @@ package encodingtest
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/document/encoding"
@@ -20,9 +23,12 @@ func TestCodec(t *testing.T, codecBuilder func() encoding.Codec) {
 		{"EncodeDecode", testEncodeDecode},
 		{"NewDocument", testDecodeDocument},
 		{"Document/GetByField", testDocumentGetByField},
+		{"Document/DecodeFields", testDocumentDecodeFields},
 		{"Document/JSON", testDocumentJSON},
 		{"Array/GetByIndex", testArrayGetByIndex},
 		{"Array/JSON", testArrayJSON},
+		{"Decimal", testDecimal},
+		{"Timestamp", testTimestamp},
 	}
 
 	for _, test := range tests {
@@ -128,6 +134,51 @@ func testDocumentGetByField(t *testing.T, codecBuilder func() encoding.Codec) {
 	require.Equal(t, document.ErrFieldNotFound, err)
 }
 
+func testDocumentDecodeFields(t *testing.T, codecBuilder func() encoding.Codec) {
+	codec := codecBuilder()
+
+	long := strings.Repeat("x", 300)
+	fb := document.NewFieldBuffer().
+		Add("a", document.NewIntegerValue(10)).
+		Add("b", document.NewNullValue()).
+		Add(long, document.NewTextValue("long")).
+		Add("c", document.NewTextValue("john"))
+
+	var buf bytes.Buffer
+
+	err := codec.NewEncoder(&buf).EncodeDocument(fb)
+	require.NoError(t, err)
+
+	d := codec.NewDocument(buf.Bytes())
+
+	fd, ok := d.(encoding.FieldsDecoder)
+	if !ok {
+		t.Skip("codec doesn't implement encoding.FieldsDecoder")
+	}
+
+	decode := func(fields ...string) *document.FieldBuffer {
+		var res document.FieldBuffer
+		err := fd.DecodeFields(fields, func(field string, value document.Value) error {
+			res.Add(field, value)
+			return nil
+		})
+		require.NoError(t, err)
+		return &res
+	}
+
+	require.Equal(t, document.NewFieldBuffer().
+		Add("a", document.NewIntegerValue(10)).
+		Add("c", document.NewTextValue("john")), decode("c", "a", "d"))
+	require.Equal(t, document.NewFieldBuffer().
+		Add(long, document.NewTextValue("long")), decode(long))
+	require.Equal(t, &document.FieldBuffer{}, decode())
+
+	// the long field name must also be readable with GetByField
+	v, err := d.GetByField(long)
+	require.NoError(t, err)
+	require.Equal(t, document.NewTextValue("long"), v)
+}
+
 func testDocumentJSON(t *testing.T, codecBuilder func() encoding.Codec) {
 	codec := codecBuilder()
 
@@ -261,3 +312,76 @@ func testDecodeDocument(t *testing.T, codecBuilder func() encoding.Codec) {
 	require.NoError(t, err)
 	require.Equal(t, 3, i)
 }
+
+func testDecimal(t *testing.T, codecBuilder func() encoding.Codec) {
+	codec := codecBuilder()
+
+	decimals := []string{"0", "1.50", "-0.001", "123456789012345678901234567890.123456789", "1e-1500"}
+
+	fb := document.NewFieldBuffer()
+	vb := document.NewValueBuffer()
+	for i, s := range decimals {
+		var x document.Decimal
+		err := x.UnmarshalText([]byte(s))
+		require.NoError(t, err)
+
+		fb.Add(fmt.Sprintf("d%d", i), document.NewDecimalValue(x))
+		vb.Append(document.NewDecimalValue(x))
+	}
+	fb.Add("array", document.NewArrayValue(vb))
+
+	var buf bytes.Buffer
+
+	err := codec.NewEncoder(&buf).EncodeDocument(fb)
+	require.NoError(t, err)
+
+	d := codec.NewDocument(buf.Bytes())
+
+	// the scale of decimals is preserved
+	for i, s := range decimals[:4] {
+		v, err := d.GetByField(fmt.Sprintf("d%d", i))
+		require.NoError(t, err)
+		require.Equal(t, document.DecimalValue, v.Type)
+		require.Equal(t, s, v.V.(document.Decimal).String())
+	}
+
+	v, err := d.GetByField("array")
+	require.NoError(t, err)
+	v, err = v.V.(document.Array).GetByIndex(1)
+	require.NoError(t, err)
+	require.Equal(t, "1.50", v.V.(document.Decimal).String())
+
+	v, err = d.GetByField("d4")
+	require.NoError(t, err)
+	ok, err := v.IsGreaterThan(document.NewIntegerValue(0))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func testTimestamp(t *testing.T, codecBuilder func() encoding.Codec) {
+	codec := codecBuilder()
+
+	ts := document.NewTimestampValue(time.Date(2021, 1, 2, 15, 4, 5, 123456000, time.UTC))
+	zero := document.NewTimestampValue(time.Time{})
+
+	fb := document.NewFieldBuffer().
+		Add("a", ts).
+		Add("b", document.NewArrayValue(document.NewValueBuffer(zero, ts)))
+
+	var buf bytes.Buffer
+
+	err := codec.NewEncoder(&buf).EncodeDocument(fb)
+	require.NoError(t, err)
+
+	d := codec.NewDocument(buf.Bytes())
+
+	v, err := d.GetByField("a")
+	require.NoError(t, err)
+	require.Equal(t, ts, v)
+
+	v, err = d.GetByField("b")
+	require.NoError(t, err)
+	v, err = v.V.(document.Array).GetByIndex(0)
+	require.NoError(t, err)
+	require.Equal(t, zero, v)
+}
@@ -10,6 +10,12 @@ import (
 	"github.com/vmihailenco/msgpack/v5/codes"
 )
 
+// DecimalExtType is the MessagePack extension type used to encode decimals.
+const DecimalExtType int8 = 1
+
+// TimestampExtType is the MessagePack extension type used to encode timestamps.
+const TimestampExtType int8 = 2
+
 // A Codec is a MessagePack implementation of an encoding.Codec.
 type Codec struct{}
 
@@ -109,6 +115,8 @@ func (e *Encoder) EncodeArray(a document.Array) error {
 // - int32 -> int32
 // - int64 -> int64
 // - float64 -> float64
+// - decimal -> extension containing the text representation of the decimal
+// - timestamp -> extension containing the binary representation of the timestamp
 func (e *Encoder) EncodeValue(v document.Value) error {
 	switch v.Type {
 	case document.DocumentValue:
@@ -127,6 +135,28 @@ func (e *Encoder) EncodeValue(v document.Value) error {
 		return e.enc.EncodeInt64(v.V.(int64))
 	case document.DoubleValue:
 		return e.enc.EncodeFloat64(v.V.(float64))
+	case document.DecimalValue:
+		text, err := v.V.(document.Decimal).MarshalText()
+		if err != nil {
+			return err
+		}
+		err = e.enc.EncodeExtHeader(DecimalExtType, len(text))
+		if err != nil {
+			return err
+		}
+		_, err = e.enc.Writer().Write(text)
+		return err
+	case document.TimestampValue:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		err = e.enc.EncodeExtHeader(TimestampExtType, len(data))
+		if err != nil {
+			return err
+		}
+		_, err = e.enc.Writer().Write(data)
+		return err
 	}
 
 	return e.enc.Encode(v.V)
@@ -195,6 +225,12 @@ func (d *Decoder) DecodeValue() (v document.Value, err error) {
 		return
 	}
 
+	// decode decimals and timestamps
+	if codes.IsExt(c) {
+		v, err = d.decodeExt()
+		return
+	}
+
 	// decode the rest
 	switch c {
 	case codes.Nil:
@@ -237,6 +273,37 @@ func (d *Decoder) DecodeValue() (v document.Value, err error) {
 	panic(fmt.Sprintf("unsupported type %v", c))
 }
 
+func (d *Decoder) decodeExt() (document.Value, error) {
+	id, n, err := d.dec.DecodeExtHeader()
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	if id != DecimalExtType && id != TimestampExtType {
+		return document.Value{}, fmt.Errorf("unsupported extension type %d", id)
+	}
+
+	data := make([]byte, n)
+	err = d.dec.ReadFull(data)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	if id == TimestampExtType {
+		v := document.Value{Type: document.TimestampValue}
+		err = v.UnmarshalBinary(data)
+		return v, err
+	}
+
+	var x document.Decimal
+	err = x.UnmarshalText(data)
+	if err != nil {
+		return document.Value{}, err
+	}
+
+	return document.NewDecimalValue(x), nil
+}
+
 // DecodeDocument decodes one document from the reader.
 // If the document is malformed, it will not return an error.
 // However, calls to Iterate or GetByField will fail.
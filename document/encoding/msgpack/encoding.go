@@ -2,6 +2,7 @@ package msgpack
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 
 	"github.com/genjidb/genji/document"
@@ -29,6 +30,19 @@ func bytesLen(c codes.Code, dec *msgpack.Decoder) (int, error) {
 		return int(c & codes.FixedStrMask), nil
 	}
 
+	var b [4]byte
+	switch c {
+	case codes.Str8, codes.Bin8:
+		err := dec.ReadFull(b[:1])
+		return int(b[0]), err
+	case codes.Str16, codes.Bin16:
+		err := dec.ReadFull(b[:2])
+		return int(binary.BigEndian.Uint16(b[:2])), err
+	case codes.Str32, codes.Bin32:
+		err := dec.ReadFull(b[:4])
+		return int(binary.BigEndian.Uint32(b[:4])), err
+	}
+
 	return 0, fmt.Errorf("msgpack: invalid code=%x decoding bytes length", c)
 }
 
@@ -107,6 +121,80 @@ func (e EncodedDocument) GetByField(field string) (v document.Value, err error)
 	return
 }
 
+// DecodeFields decodes the selected fields in a single pass over the buffer
+// and passes them to fn. The values of the other fields are skipped.
+// It implements the encoding.FieldsDecoder interface.
+func (e EncodedDocument) DecodeFields(fields []string, fn func(field string, value document.Value) error) error {
+	dec := NewDecoder(bytes.NewReader(e))
+	defer dec.Close()
+
+	l, err := dec.dec.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 32)
+
+	var found int
+	for i := 0; i < l && found < len(fields); i++ {
+		// like in GetByField, field names are read into
+		// a reusable buffer to avoid allocating a string
+		// for every field of the document.
+		c, err := dec.dec.PeekCode()
+		if err != nil {
+			return err
+		}
+
+		err = dec.dec.ReadFull(buf[:1])
+		if err != nil {
+			return err
+		}
+
+		n, err := bytesLen(c, dec.dec)
+		if err != nil {
+			return err
+		}
+
+		if len(buf) < n {
+			buf = make([]byte, n)
+		}
+
+		err = dec.dec.ReadFull(buf[:n])
+		if err != nil {
+			return err
+		}
+
+		field := -1
+		for j := range fields {
+			if string(buf[:n]) == fields[j] {
+				field = j
+				break
+			}
+		}
+
+		if field == -1 {
+			err = dec.dec.Skip()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		v, err := dec.DecodeValue()
+		if err != nil {
+			return err
+		}
+
+		found++
+		err = fn(fields[field], v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Iterate decodes each fields one by one and passes them to fn
 // until the end of the document or until fn returns an error.
 func (e EncodedDocument) Iterate(fn func(field string, value document.Value) error) error {
@@ -362,6 +362,88 @@ func (s Stream) Aggregate(aggregatorBuilders ...AggregatorBuilder) Stream {
 	}))
 }
 
+// AggregateSorted works like Aggregate but expects the documents of each group to be adjacent in the stream,
+// which is the case when the stream is sorted by the group value.
+// Each group is aggregated and passed to the next iterator as soon as the group value changes,
+// which means only the aggregators of the current group are kept in memory.
+func (s Stream) AggregateSorted(aggregatorBuilders ...AggregatorBuilder) Stream {
+	return NewStream(IteratorFunc(func(fn func(d Document) error) error {
+		var aggs []Aggregator
+		var groupKey []byte
+
+		nullValue := NewNullValue()
+
+		var b bytes.Buffer
+
+		enc := NewValueEncoder(&b)
+
+		mkGroup := func(g Value) {
+			groupKey = append(groupKey[:0], b.Bytes()...)
+			aggs = make([]Aggregator, len(aggregatorBuilders))
+			for i, builder := range aggregatorBuilders {
+				aggs[i] = builder.Aggregator(g)
+			}
+		}
+
+		flush := func() error {
+			fb := NewFieldBuffer()
+			for _, agg := range aggs {
+				err := agg.Aggregate(fb)
+				if err != nil {
+					return err
+				}
+			}
+
+			return fn(fb)
+		}
+
+		err := s.Iterate(func(d Document) error {
+			group := nullValue
+
+			if gd, ok := d.(*groupedDocument); ok {
+				group = gd.group
+			}
+
+			b.Reset()
+
+			err := enc.Encode(group)
+			if err != nil {
+				return err
+			}
+
+			if aggs == nil || !bytes.Equal(groupKey, b.Bytes()) {
+				if aggs != nil {
+					err = flush()
+					if err != nil {
+						return err
+					}
+				}
+
+				mkGroup(group)
+			}
+
+			for _, agg := range aggs {
+				err = agg.Add(d)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if aggs == nil {
+			// create one group by default for the null value
+			mkGroup(nullValue)
+		}
+
+		return flush()
+	}))
+}
+
 // An Aggregator aggregates documents into a single one.
 type Aggregator interface {
 	Add(d Document) error
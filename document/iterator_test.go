@@ -165,3 +165,73 @@ func TestIteratorToJSONArray(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `[{"a": 0}, {"a": 1}, {"a": 2}]`, buf.String())
 }
+
+// countAggregatorBuilder counts the documents of each group.
+type countAggregatorBuilder struct{}
+
+func (countAggregatorBuilder) Aggregator(group document.Value) document.Aggregator {
+	return &countAggregator{group: group}
+}
+
+type countAggregator struct {
+	group document.Value
+	count int64
+}
+
+func (c *countAggregator) Add(d document.Document) error {
+	c.count++
+	return nil
+}
+
+func (c *countAggregator) Aggregate(fb *document.FieldBuffer) error {
+	fb.Add("group", c.group)
+	fb.Add("count", document.NewIntegerValue(c.count))
+	return nil
+}
+
+func TestStreamAggregateSorted(t *testing.T) {
+	group := func(d document.Document) (document.Value, error) {
+		return d.GetByField("a")
+	}
+
+	tests := []struct {
+		name     string
+		docs     []string
+		expected string
+	}{
+		{"empty", nil, `[{"group": null, "count": 0}]`},
+		{"one group", []string{`{"a": 1}`, `{"a": 1}`}, `[{"group": 1, "count": 2}]`},
+		{"sorted", []string{`{"a": 1}`, `{"a": 1}`, `{"a": 2}`, `{"b": 1}`}, `[{"group": 1, "count": 2}, {"group": 2, "count": 1}, {"group": null, "count": 1}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var docs []document.Document
+			for _, s := range test.docs {
+				fb := document.NewFieldBuffer()
+				err := json.Unmarshal([]byte(s), fb)
+				require.NoError(t, err)
+				docs = append(docs, fb)
+			}
+
+			st := document.NewStream(document.NewIterator(docs...)).
+				GroupBy(group).
+				AggregateSorted(countAggregatorBuilder{})
+
+			var buf bytes.Buffer
+			err := document.IteratorToJSONArray(&buf, st)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, buf.String())
+
+			// results must be the same as the ones returned by Aggregate
+			st = document.NewStream(document.NewIterator(docs...)).
+				GroupBy(group).
+				Aggregate(countAggregatorBuilder{})
+
+			var expected bytes.Buffer
+			err = document.IteratorToJSONArray(&expected, st)
+			require.NoError(t, err)
+			require.Equal(t, expected.String(), buf.String())
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"time"
 )
 
+var decimalType = reflect.TypeOf(Decimal{})
+
 // A Scanner can iterate over a document and scan all the fields.
 type Scanner interface {
 	ScanDocument(Document) error
@@ -312,10 +314,23 @@ func scanValue(v Value, ref reflect.Value) error {
 		return nil
 	}
 
+	if ref.Type() == decimalType {
+		v, err := v.CastAsDecimal()
+		if err != nil {
+			return err
+		}
+		ref.Set(reflect.ValueOf(v.V))
+		return nil
+	}
+
 	// test with supported stdlib types
 	switch ref.Type().String() {
 	case "time.Time":
-		if v.Type == TextValue {
+		switch v.Type {
+		case TimestampValue:
+			ref.Set(reflect.ValueOf(v.V))
+			return nil
+		case TextValue:
 			parsed, err := time.Parse(time.RFC3339Nano, v.V.(string))
 			if err != nil {
 				return err
@@ -0,0 +1,157 @@
+package document
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/genjidb/genji/binarysort"
+)
+
+// Timestamps are stored in UTC, with a microsecond precision,
+// as the number of microseconds elapsed since January 1, 1970 UTC.
+// This covers dates from 290308 BC to 294247 AD.
+const (
+	microsPerSecond = int64(time.Second / time.Microsecond)
+	nanosPerMicro   = int64(time.Microsecond)
+)
+
+var errMalformedTimestamp = errors.New("malformed timestamp")
+
+// ParseTimestamp parses a timestamp formatted using RFC 3339,
+// with an optional fractional part, like "2021-01-02T15:04:05Z"
+// or "2021-01-02T15:04:05.999999+02:00".
+// The result is converted to UTC and truncated to the microsecond.
+func ParseTimestamp(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return normalizeTimestamp(t), nil
+}
+
+// normalizeTimestamp converts t to UTC and truncates it to the microsecond.
+// It also strips the monotonic clock reading of t.
+func normalizeTimestamp(t time.Time) time.Time {
+	return timestampFromMicros(timestampToMicros(t))
+}
+
+func timestampToMicros(t time.Time) int64 {
+	micros := t.Unix() * microsPerSecond
+	// Nanosecond is always positive, the result is rounded toward the past.
+	return micros + int64(t.Nanosecond())/nanosPerMicro
+}
+
+func timestampFromMicros(micros int64) time.Time {
+	sec, usec := micros/microsPerSecond, micros%microsPerSecond
+	if usec < 0 {
+		sec--
+		usec += microsPerSecond
+	}
+
+	return time.Unix(sec, usec*nanosPerMicro).UTC()
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// appendTimestamp appends a sort-ordered binary representation of t to buf.
+func appendTimestamp(buf []byte, t time.Time) []byte {
+	return binarysort.AppendInt64(buf, timestampToMicros(t))
+}
+
+func decodeTimestamp(data []byte) (time.Time, error) {
+	if len(data) != 8 {
+		return time.Time{}, errMalformedTimestamp
+	}
+
+	x, err := binarysort.DecodeInt64(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return timestampFromMicros(x), nil
+}
+
+// calculateTimestamps computes additions and subtractions involving timestamps.
+// Numbers added to or subtracted from timestamps represent a number of seconds,
+// and the difference between two timestamps is returned as a double number of seconds.
+// Other operations, or results that can't be represented, return NULL.
+func calculateTimestamps(a, b Value, operator byte) (Value, error) {
+	switch {
+	case a.Type == TimestampValue && b.Type == TimestampValue:
+		if operator != '-' {
+			return NewNullValue(), nil
+		}
+
+		ma, mb := timestampToMicros(a.V.(time.Time)), timestampToMicros(b.V.(time.Time))
+		if d, ok := subInt64(ma, mb); ok {
+			return NewDoubleValue(float64(d) / float64(microsPerSecond)), nil
+		}
+		return NewDoubleValue((float64(ma) - float64(mb)) / float64(microsPerSecond)), nil
+	case a.Type == TimestampValue && b.Type.IsNumber() && (operator == '+' || operator == '-'):
+		return addSecondsToTimestamp(a.V.(time.Time), b, operator == '-')
+	case a.Type.IsNumber() && b.Type == TimestampValue && operator == '+':
+		return addSecondsToTimestamp(b.V.(time.Time), a, false)
+	}
+
+	return NewNullValue(), nil
+}
+
+// addSecondsToTimestamp adds the number of seconds n to t, or subtracts it if neg is true.
+func addSecondsToTimestamp(t time.Time, n Value, neg bool) (Value, error) {
+	var delta int64
+
+	switch n.Type {
+	case IntegerValue:
+		d, ok := mulInt64(n.V.(int64), microsPerSecond)
+		if !ok {
+			return NewNullValue(), nil
+		}
+		delta = d
+	default:
+		f, err := n.CastAsDouble()
+		if err != nil {
+			return NewNullValue(), nil
+		}
+
+		d := math.Round(f.V.(float64) * float64(microsPerSecond))
+		if math.IsNaN(d) || d < math.MinInt64 || d >= math.MaxInt64 {
+			return NewNullValue(), nil
+		}
+		delta = int64(d)
+	}
+
+	m := timestampToMicros(t)
+
+	var res int64
+	var ok bool
+	if neg {
+		res, ok = subInt64(m, delta)
+	} else {
+		res, ok = addInt64(m, delta)
+	}
+	if !ok {
+		return NewNullValue(), nil
+	}
+
+	return NewTimestampValue(timestampFromMicros(res)), nil
+}
+
+// compareTimestamps compares a timestamp with another timestamp or with a text.
+// Texts are parsed using ParseTimestamp, texts that are not valid timestamps
+// are never equal, greater or lesser than a timestamp.
+func compareTimestamps(op operator, l, r Value) (bool, error) {
+	tl, err := l.CastAsTimestamp()
+	if err != nil {
+		return false, nil
+	}
+	tr, err := r.CastAsTimestamp()
+	if err != nil {
+		return false, nil
+	}
+
+	return compareIntegers(op, timestampToMicros(tl.V.(time.Time)), timestampToMicros(tr.V.(time.Time))), nil
+}
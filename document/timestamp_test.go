@@ -0,0 +1,173 @@
+package document_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTimestamp(t testing.TB, s string) time.Time {
+	t.Helper()
+
+	ts, err := document.ParseTimestamp(s)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		s     string
+		want  string
+		fails bool
+	}{
+		{"2021-01-02T15:04:05Z", "2021-01-02T15:04:05Z", false},
+		{"2021-01-02T15:04:05.123456Z", "2021-01-02T15:04:05.123456Z", false},
+		{"2021-01-02T15:04:05.1234569Z", "2021-01-02T15:04:05.123456Z", false},
+		{"2021-01-02T15:04:05+02:00", "2021-01-02T13:04:05Z", false},
+		{"1969-12-31T23:59:59.5Z", "1969-12-31T23:59:59.5Z", false},
+		{"0001-01-01T00:00:00Z", "0001-01-01T00:00:00Z", false},
+		{"2021-01-02", "", true},
+		{"2021-01-02 15:04:05", "", true},
+		{"foo", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			ts, err := document.ParseTimestamp(test.s)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.want, ts.Format(time.RFC3339Nano))
+		})
+	}
+}
+
+func TestTimestampOrder(t *testing.T) {
+	// sorted in ascending order
+	sorted := []string{
+		"0001-01-01T00:00:00Z",
+		"1969-12-31T23:59:59Z",
+		"1969-12-31T23:59:59.999999Z",
+		"1970-01-01T00:00:00Z",
+		"1970-01-01T00:00:00.000001Z",
+		"2021-01-02T15:04:05Z",
+		"2021-01-02T15:04:05.5Z",
+		"9999-12-31T23:59:59.999999Z",
+	}
+
+	var prevBin, prevEnc []byte
+	for i, s := range sorted {
+		v := document.NewTimestampValue(parseTimestamp(t, s))
+
+		bin, err := v.MarshalBinary()
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = document.NewValueEncoder(&buf).Encode(v)
+		require.NoError(t, err)
+		enc := buf.Bytes()
+
+		if i > 0 {
+			require.Equal(t, -1, bytes.Compare(prevBin, bin), "%s < %s", sorted[i-1], s)
+			require.Equal(t, -1, bytes.Compare(prevEnc, enc), "%s < %s", sorted[i-1], s)
+
+			ok, err := document.NewTimestampValue(parseTimestamp(t, sorted[i-1])).IsLesserThan(v)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		prevBin, prevEnc = bin, enc
+
+		// round trip
+		u := document.Value{Type: document.TimestampValue}
+		err = u.UnmarshalBinary(bin)
+		require.NoError(t, err)
+		require.Equal(t, v, u)
+
+		u, err = document.DecodeValue(enc)
+		require.NoError(t, err)
+		require.Equal(t, v, u)
+	}
+}
+
+func TestTimestampValue(t *testing.T) {
+	ts := document.NewTimestampValue(parseTimestamp(t, "2021-01-02T15:04:05Z"))
+
+	tests := []struct {
+		name string
+		fn   func() (document.Value, error)
+		want document.Value
+	}{
+		{"timestamp+integer", func() (document.Value, error) { return ts.Add(document.NewIntegerValue(60)) },
+			document.NewTimestampValue(parseTimestamp(t, "2021-01-02T15:05:05Z"))},
+		{"double+timestamp", func() (document.Value, error) { return document.NewDoubleValue(1.5).Add(ts) },
+			document.NewTimestampValue(parseTimestamp(t, "2021-01-02T15:04:06.5Z"))},
+		{"timestamp-integer", func() (document.Value, error) { return ts.Sub(document.NewIntegerValue(86400)) },
+			document.NewTimestampValue(parseTimestamp(t, "2021-01-01T15:04:05Z"))},
+		{"timestamp-timestamp", func() (document.Value, error) {
+			return ts.Sub(document.NewTimestampValue(parseTimestamp(t, "2021-01-02T15:03:04.5Z")))
+		}, document.NewDoubleValue(60.5)},
+		{"timestamp+timestamp", func() (document.Value, error) { return ts.Add(ts) }, document.NewNullValue()},
+		{"integer-timestamp", func() (document.Value, error) { return document.NewIntegerValue(1).Sub(ts) }, document.NewNullValue()},
+		{"timestamp*integer", func() (document.Value, error) { return ts.Mul(document.NewIntegerValue(2)) }, document.NewNullValue()},
+		{"timestamp+text", func() (document.Value, error) { return ts.Add(document.NewTextValue("1")) }, document.NewNullValue()},
+		{"overflow", func() (document.Value, error) { return ts.Add(document.NewIntegerValue(1 << 62)) }, document.NewNullValue()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := test.fn()
+			require.NoError(t, err)
+			require.Equal(t, test.want, res)
+		})
+	}
+
+	// timestamps can be compared with texts
+	for _, test := range []struct {
+		v    document.Value
+		eq   bool
+		less bool
+	}{
+		{document.NewTextValue("2021-01-02T17:04:05+02:00"), true, false},
+		{document.NewTextValue("2021-01-02T15:04:05.000001Z"), false, true},
+		{document.NewTextValue("2021-01-02"), false, false},
+		{document.NewIntegerValue(1), false, false},
+	} {
+		ok, err := ts.IsEqual(test.v)
+		require.NoError(t, err)
+		require.Equal(t, test.eq, ok, test.v.String())
+
+		ok, err = ts.IsLesserThan(test.v)
+		require.NoError(t, err)
+		require.Equal(t, test.less, ok, test.v.String())
+	}
+
+	b, err := ts.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, `"2021-01-02T15:04:05Z"`, string(b))
+
+	v, err := ts.CastAsText()
+	require.NoError(t, err)
+	require.Equal(t, document.NewTextValue("2021-01-02T15:04:05Z"), v)
+
+	v, err = document.NewTextValue("2021-01-02T16:04:05+01:00").CastAs(document.TimestampValue)
+	require.NoError(t, err)
+	require.Equal(t, ts, v)
+
+	_, err = document.NewIntegerValue(1).CastAsTimestamp()
+	require.Error(t, err)
+
+	var tm time.Time
+	err = document.ScanValue(ts, &tm)
+	require.NoError(t, err)
+	require.Equal(t, ts.V, tm)
+
+	ok, err := document.NewZeroValue(document.TimestampValue).IsZeroValue()
+	require.NoError(t, err)
+	require.True(t, ok)
+}
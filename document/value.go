@@ -3,10 +3,12 @@ package document
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/buger/jsonparser"
 	"github.com/genjidb/genji/binarysort"
@@ -22,6 +24,10 @@ var (
 	documentZeroValue = NewZeroValue(DocumentValue)
 )
 
+// ErrIntegerOverflow is returned when the result of an operation
+// on integers doesn't fit in an int64.
+var ErrIntegerOverflow = errors.New("integer overflow")
+
 // ErrUnsupportedType is used to skip struct or array fields that are not supported.
 type ErrUnsupportedType struct {
 	Value interface{}
@@ -43,12 +49,18 @@ const (
 
 	BoolValue ValueType = 0x81
 
+	// timestamp family: 0x88 to 0x8F
+	TimestampValue ValueType = 0x88
+
 	// integer family: 0x90 to 0x9F
 	IntegerValue ValueType = 0x90
 
 	// double family: 0xA0 to 0xAF
 	DoubleValue ValueType = 0xA0
 
+	// decimal family: 0xB0 to 0xBF
+	DecimalValue ValueType = 0xB0
+
 	// string family: 0xC0 to 0xCF
 	TextValue ValueType = 0xC0
 
@@ -68,10 +80,14 @@ func (t ValueType) String() string {
 		return "null"
 	case BoolValue:
 		return "bool"
+	case TimestampValue:
+		return "timestamp"
 	case IntegerValue:
 		return "integer"
 	case DoubleValue:
 		return "double"
+	case DecimalValue:
+		return "decimal"
 	case BlobValue:
 		return "blob"
 	case TextValue:
@@ -85,9 +101,9 @@ func (t ValueType) String() string {
 	return ""
 }
 
-// IsNumber returns true if t is either an integer of a float.
+// IsNumber returns true if t is either an integer, a float or a decimal.
 func (t ValueType) IsNumber() bool {
-	return t == IntegerValue || t == DoubleValue
+	return t == IntegerValue || t == DoubleValue || t == DecimalValue
 }
 
 // A Value stores encoded data alongside its type.
@@ -128,6 +144,23 @@ func NewDoubleValue(x float64) Value {
 	}
 }
 
+// NewDecimalValue encodes x and returns a value.
+func NewDecimalValue(x Decimal) Value {
+	return Value{
+		Type: DecimalValue,
+		V:    x,
+	}
+}
+
+// NewTimestampValue encodes x and returns a value.
+// x is converted to UTC and truncated to the microsecond.
+func NewTimestampValue(x time.Time) Value {
+	return Value{
+		Type: TimestampValue,
+		V:    normalizeTimestamp(x),
+	}
+}
+
 // NewBlobValue encodes x and returns a value.
 func NewBlobValue(x []byte) Value {
 	return Value{
@@ -172,6 +205,10 @@ func NewZeroValue(t ValueType) Value {
 		return NewIntegerValue(0)
 	case DoubleValue:
 		return NewDoubleValue(0)
+	case DecimalValue:
+		return NewDecimalValue(Decimal{})
+	case TimestampValue:
+		return NewTimestampValue(time.Time{})
 	case BlobValue:
 		return NewBlobValue(nil)
 	case TextValue:
@@ -205,6 +242,10 @@ func (v Value) IsZeroValue() (bool, error) {
 		return v.V == integerZeroValue.V, nil
 	case DoubleValue:
 		return v.V == doubleZeroValue.V, nil
+	case DecimalValue:
+		return v.V.(Decimal).Sign() == 0, nil
+	case TimestampValue:
+		return v.V.(time.Time).IsZero(), nil
 	case BlobValue:
 		return bytes.Compare(v.V.([]byte), blobZeroValue.V.([]byte)) == 0, nil
 	case TextValue:
@@ -264,6 +305,10 @@ func (v Value) MarshalJSON() ([]byte, error) {
 		prec := -1
 
 		return strconv.AppendFloat(nil, v.V.(float64), fmt, prec, 64), nil
+	case DecimalValue:
+		return []byte(v.V.(Decimal).String()), nil
+	case TimestampValue:
+		return []byte(strconv.Quote(formatTimestamp(v.V.(time.Time)))), nil
 	case TextValue:
 		return []byte(strconv.Quote(v.V.(string))), nil
 	case BlobValue:
@@ -283,20 +328,61 @@ func (v Value) MarshalJSON() ([]byte, error) {
 }
 
 // String returns a string representation of the value. It implements the fmt.Stringer interface.
+// See FormatValue for details. Blobs are never truncated, so that the representation
+// of the value can be parsed back, like in the expressions stored by the database.
 func (v Value) String() string {
+	return FormatValue(v, FormatOptions{MaxBlobSize: -1})
+}
+
+// DefaultMaxBlobSize is the number of bytes of a blob printed by FormatValue
+// before it gets truncated, if FormatOptions.MaxBlobSize is not set.
+const DefaultMaxBlobSize = 64
+
+// FormatOptions controls how values are represented by FormatValue.
+type FormatOptions struct {
+	// RawText disables quoting of text values, for outputs
+	// that handle quoting themselves, like CSV.
+	RawText bool
+
+	// MaxBlobSize is the maximum number of bytes of a blob that are printed.
+	// Longer blobs are truncated and followed by their size.
+	// If zero, DefaultMaxBlobSize is used. If negative, blobs are never truncated.
+	MaxBlobSize int
+}
+
+// FormatValue returns a human readable representation of v:
+// null values are represented as NULL, text values are quoted, numbers and booleans
+// are printed as is, doubles using the smallest number of digits necessary to represent
+// the value exactly, blobs are printed in hexadecimal and arrays and documents as JSON.
+func FormatValue(v Value, opts FormatOptions) string {
 	switch v.Type {
 	case NullValue:
 		return "NULL"
 	case TextValue:
+		if opts.RawText {
+			return v.V.(string)
+		}
 		return strconv.Quote(v.V.(string))
 	case BlobValue:
-		return fmt.Sprintf("%v", v.V)
+		return formatBlob(v.V.([]byte), opts.MaxBlobSize)
 	}
 
 	d, _ := v.MarshalJSON()
 	return string(d)
 }
 
+func formatBlob(b []byte, max int) string {
+	if max == 0 {
+		max = DefaultMaxBlobSize
+	}
+
+	if max < 0 || len(b) <= max {
+		return fmt.Sprintf("x'%x'", b)
+	}
+
+	return fmt.Sprintf("x'%x'... (%d bytes)", b[:max], len(b))
+}
+
 // Append appends to buf a binary representation of v.
 // The encoded value doesn't include type information.
 func (v Value) Append(buf []byte) ([]byte, error) {
@@ -311,6 +397,10 @@ func (v Value) Append(buf []byte) ([]byte, error) {
 		return binarysort.AppendInt64(buf, v.V.(int64)), nil
 	case DoubleValue:
 		return binarysort.AppendFloat64(buf, v.V.(float64)), nil
+	case DecimalValue:
+		return appendDecimal(buf, v.V.(Decimal)), nil
+	case TimestampValue:
+		return appendTimestamp(buf, v.V.(time.Time)), nil
 	case NullValue:
 		return buf, nil
 	case ArrayValue:
@@ -365,6 +455,21 @@ func (v *Value) UnmarshalBinary(data []byte) error {
 			return err
 		}
 		v.V = x
+	case DecimalValue:
+		x, n, err := decodeDecimal(data)
+		if err != nil {
+			return err
+		}
+		if n != len(data) {
+			return errMalformedDecimal
+		}
+		v.V = x
+	case TimestampValue:
+		x, err := decodeTimestamp(data)
+		if err != nil {
+			return err
+		}
+		v.V = x
 	case ArrayValue:
 		a, _, err := decodeArray(data)
 		if err != nil {
@@ -386,12 +491,15 @@ func (v *Value) UnmarshalBinary(data []byte) error {
 
 // Add u to v and return the result.
 // Only numeric values and booleans can be added together.
+// Numbers added to a timestamp are a number of seconds.
 func (v Value) Add(u Value) (res Value, err error) {
 	return calculateValues(v, u, '+')
 }
 
 // Sub calculates v - u and returns the result.
 // Only numeric values and booleans can be calculated together.
+// Numbers subtracted from a timestamp are a number of seconds,
+// and the difference between two timestamps is a double number of seconds.
 func (v Value) Sub(u Value) (res Value, err error) {
 	return calculateValues(v, u, '-')
 }
@@ -446,7 +554,15 @@ func calculateValues(a, b Value, operator byte) (res Value, err error) {
 		return NewNullValue(), nil
 	}
 
+	if a.Type == TimestampValue || b.Type == TimestampValue {
+		return calculateTimestamps(a, b, operator)
+	}
+
 	if a.Type.IsNumber() && b.Type.IsNumber() {
+		if a.Type == DecimalValue || b.Type == DecimalValue {
+			return calculateDecimals(a, b, operator)
+		}
+
 		if a.Type == DoubleValue || b.Type == DoubleValue {
 			return calculateFloats(a, b, operator)
 		}
@@ -494,33 +610,22 @@ func calculateIntegers(a, b Value, operator byte) (res Value, err error) {
 	}
 	xb = ib.V.(int64)
 
-	var xr int64
-
 	switch operator {
-	case '-':
-		xb = -xb
-		fallthrough
 	case '+':
-		xr = xa + xb
 		// if there is an integer overflow
 		// convert to float
-		if (xr > xa) != (xb > 0) {
-			return NewDoubleValue(float64(xa) + float64(xb)), nil
+		if xr, ok := addInt64(xa, xb); ok {
+			return NewIntegerValue(xr), nil
 		}
-		return NewIntegerValue(xr), nil
-	case '*':
-		if xa == 0 || xb == 0 {
-			return NewIntegerValue(0), nil
+		return NewDoubleValue(float64(xa) + float64(xb)), nil
+	case '-':
+		if xr, ok := subInt64(xa, xb); ok {
+			return NewIntegerValue(xr), nil
 		}
-
-		xr = xa * xb
-		// if there is no integer overflow
-		// return an int, otherwise
-		// convert to float
-		if (xr < 0) == ((xa < 0) != (xb < 0)) {
-			if xr/xb == xa {
-				return NewIntegerValue(xr), nil
-			}
+		return NewDoubleValue(float64(xa) - float64(xb)), nil
+	case '*':
+		if xr, ok := mulInt64(xa, xb); ok {
+			return NewIntegerValue(xr), nil
 		}
 		return NewDoubleValue(float64(xa) * float64(xb)), nil
 	case '/':
@@ -528,6 +633,11 @@ func calculateIntegers(a, b Value, operator byte) (res Value, err error) {
 			return NewNullValue(), nil
 		}
 
+		// the only overflowing division
+		if xa == math.MinInt64 && xb == -1 {
+			return NewDoubleValue(-float64(xa)), nil
+		}
+
 		return NewIntegerValue(xa / xb), nil
 	case '%':
 		if xb == 0 {
@@ -546,6 +656,68 @@ func calculateIntegers(a, b Value, operator byte) (res Value, err error) {
 	}
 }
 
+// calculateDecimals computes additions, subtractions and multiplications
+// exactly, other operations are computed using doubles.
+// Doubles are converted to the decimal with the fewest digits that represents them.
+func calculateDecimals(a, b Value, operator byte) (res Value, err error) {
+	switch operator {
+	case '+', '-', '*':
+	default:
+		return calculateFloats(a, b, operator)
+	}
+
+	da, err := a.CastAsDecimal()
+	if err != nil {
+		return NewNullValue(), nil
+	}
+	db, err := b.CastAsDecimal()
+	if err != nil {
+		return NewNullValue(), nil
+	}
+
+	xa, xb := da.V.(Decimal), db.V.(Decimal)
+
+	switch operator {
+	case '+':
+		return NewDecimalValue(xa.Add(xb)), nil
+	case '-':
+		return NewDecimalValue(xa.Sub(xb)), nil
+	}
+
+	return NewDecimalValue(xa.Mul(xb)), nil
+}
+
+// addInt64 returns a + b and false if the addition overflows.
+func addInt64(a, b int64) (int64, bool) {
+	r := a + b
+	return r, (r > a) == (b > 0)
+}
+
+// subInt64 returns a - b and false if the subtraction overflows.
+func subInt64(a, b int64) (int64, bool) {
+	r := a - b
+	return r, (r < a) == (b > 0)
+}
+
+// mulInt64 returns a * b and false if the multiplication overflows.
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	r := a * b
+	if (r < 0) != ((a < 0) != (b < 0)) || r/b != a {
+		return r, false
+	}
+
+	// MinInt64 * -1 wraps around to MinInt64 and passes the checks above
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return r, false
+	}
+
+	return r, true
+}
+
 func calculateFloats(a, b Value, operator byte) (res Value, err error) {
 	var xa, xb float64
 
@@ -596,6 +768,21 @@ func calculateFloats(a, b Value, operator byte) (res Value, err error) {
 	}
 }
 
+// ParseJSON decodes a JSON value. Objects are decoded as documents,
+// and numbers as integers if they fit in an int64, or doubles otherwise.
+func ParseJSON(data []byte) (Value, error) {
+	if !json.Valid(data) {
+		return Value{}, errors.New("invalid JSON")
+	}
+
+	v, dt, _, err := jsonparser.Get(data)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return parseJSONValue(dt, v)
+}
+
 func parseJSONValue(dataType jsonparser.ValueType, data []byte) (v Value, err error) {
 	switch dataType {
 	case jsonparser.Null:
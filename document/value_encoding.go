@@ -3,6 +3,7 @@ package document
 import (
 	"errors"
 	"io"
+	"time"
 
 	"github.com/genjidb/genji/binarysort"
 )
@@ -68,6 +69,10 @@ func (ve *ValueEncoder) appendValue(v Value) error {
 		ve.buf = binarysort.AppendInt64(ve.buf, v.V.(int64))
 	case DoubleValue:
 		ve.buf = binarysort.AppendFloat64(ve.buf, v.V.(float64))
+	case DecimalValue:
+		ve.buf = appendDecimal(ve.buf, v.V.(Decimal))
+	case TimestampValue:
+		ve.buf = appendTimestamp(ve.buf, v.V.(time.Time))
 	default:
 		return errors.New("cannot encode type " + v.Type.String() + " as key")
 	}
@@ -140,8 +145,8 @@ func (ve *ValueEncoder) appendDocument(d Document) error {
 	return ve.append(documentEnd)
 }
 
-// decodeValue decodes a value encoded with ValueEncoder.
-func decodeValue(data []byte) (Value, error) {
+// DecodeValue decodes a value encoded with ValueEncoder.
+func DecodeValue(data []byte) (Value, error) {
 	t := ValueType(data[0])
 	data = data[1:]
 
@@ -178,6 +183,18 @@ func decodeValue(data []byte) (Value, error) {
 			return Value{}, err
 		}
 		return NewDoubleValue(x), nil
+	case DecimalValue:
+		x, _, err := decodeDecimal(data)
+		if err != nil {
+			return Value{}, err
+		}
+		return NewDecimalValue(x), nil
+	case TimestampValue:
+		x, err := decodeTimestamp(data)
+		if err != nil {
+			return Value{}, err
+		}
+		return NewTimestampValue(x), nil
 	case ArrayValue:
 		a, _, err := decodeArray(data)
 		if err != nil {
@@ -217,12 +234,18 @@ func decodeValueUntil(data []byte, delim, end byte) (Value, int, error) {
 	case NullValue:
 	case BoolValue:
 		i++
-	case IntegerValue, DoubleValue:
-		if i+8 < len(data) && data[i+8] == delim {
+	case IntegerValue, DoubleValue, TimestampValue:
+		if i+8 < len(data) && (data[i+8] == delim || data[i+8] == end) {
 			i += 8
 		} else {
 			return Value{}, 0, errors.New("malformed " + t.String())
 		}
+	case DecimalValue:
+		_, n, err := decodeDecimal(data[i:])
+		if err != nil {
+			return Value{}, 0, err
+		}
+		i += n
 	case BlobValue, TextValue:
 		for i < len(data) && data[i] != delim && data[i] != end {
 			i++
@@ -231,7 +254,7 @@ func decodeValueUntil(data []byte, delim, end byte) (Value, int, error) {
 		return Value{}, 0, errors.New("invalid type character")
 	}
 
-	v, err := decodeValue(data[:i])
+	v, err := DecodeValue(data[:i])
 	return v, i, err
 }
 
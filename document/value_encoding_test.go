@@ -3,6 +3,7 @@ package document
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -18,6 +19,21 @@ func TestValueEncoder(t *testing.T) {
 		{"double", NewDoubleValue(-3.14)},
 		{"text", NewTextValue("foo")},
 		{"blob", NewBlobValue([]byte("bar"))},
+		{"decimal", NewDecimalValue(NewDecimal(-199, -2))},
+		{"array of decimals", NewArrayValue(NewValueBuffer(
+			NewDecimalValue(NewDecimal(15, -1)),
+			NewDecimalValue(NewDecimal(0, 0)),
+			NewDecimalValue(NewDecimal(-5, 2)),
+		))},
+		{"timestamp", NewTimestampValue(time.Date(2021, 1, 2, 15, 4, 5, 123456000, time.UTC))},
+		{"array of timestamps", NewArrayValue(NewValueBuffer(
+			NewTimestampValue(time.Date(2021, 1, 2, 15, 4, 5, 0, time.UTC)),
+			NewTimestampValue(time.Time{}),
+		))},
+		{"array ending with a number", NewArrayValue(NewValueBuffer(
+			NewTextValue("foo"),
+			NewIntegerValue(55),
+		))},
 		{"array", NewArrayValue(NewValueBuffer(
 			NewBoolValue(true),
 			NewIntegerValue(55),
@@ -69,9 +85,28 @@ func TestValueEncoder(t *testing.T) {
 			err := enc.Encode(test.v)
 			require.NoError(t, err)
 
-			got, err := decodeValue(buf.Bytes())
+			got, err := DecodeValue(buf.Bytes())
 			require.NoError(t, err)
 			require.Equal(t, test.v, got)
 		})
 	}
 }
+
+func TestValueEncoderBoolOrdering(t *testing.T) {
+	encode := func(v Value) []byte {
+		var buf bytes.Buffer
+
+		err := NewValueEncoder(&buf).Encode(v)
+		require.NoError(t, err)
+
+		got, err := DecodeValue(buf.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, v, got)
+
+		return buf.Bytes()
+	}
+
+	f := encode(NewBoolValue(false))
+	tr := encode(NewBoolValue(true))
+	require.Equal(t, -1, bytes.Compare(f, tr))
+}
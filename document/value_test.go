@@ -1,7 +1,9 @@
 package document_test
 
 import (
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,12 +18,16 @@ func TestValueString(t *testing.T) {
 		expected string
 	}{
 		{"null", document.NewNullValue(), "NULL"},
-		{"bytes", document.NewBlobValue([]byte("bar")), "[98 97 114]"},
+		{"bytes", document.NewBlobValue([]byte("bar")), "x'626172'"},
+		{"empty bytes", document.NewBlobValue([]byte{}), "x''"},
+		{"large bytes", document.NewBlobValue(make([]byte, document.DefaultMaxBlobSize+1)), "x'" + strings.Repeat("00", document.DefaultMaxBlobSize+1) + "'"},
 		{"string", document.NewTextValue("bar"), "\"bar\""},
 		{"bool", document.NewBoolValue(true), "true"},
 		{"int", document.NewIntegerValue(10), "10"},
 		{"double", document.NewDoubleValue(10.1), "10.1"},
 		{"double with no decimal", document.NewDoubleValue(10), "10"},
+		{"double precision", document.NewDoubleValue(math.Nextafter(0.3, 1)), "0.30000000000000004"},
+		{"small double", document.NewDoubleValue(1e-7), "1e-07"},
 		{"big double", document.NewDoubleValue(1e21), "1e+21"},
 		{"document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), "{\"a\": 10}"},
 		{"array", document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(10))), "[10]"},
@@ -34,6 +40,33 @@ func TestValueString(t *testing.T) {
 	}
 }
 
+func TestFormatValue(t *testing.T) {
+	large := make([]byte, 1<<20)
+	for i := range large {
+		large[i] = byte(i)
+	}
+
+	tests := []struct {
+		name     string
+		value    document.Value
+		opts     document.FormatOptions
+		expected string
+	}{
+		{"raw text", document.NewTextValue(`"bar"`), document.FormatOptions{RawText: true}, `"bar"`},
+		{"quoted text", document.NewTextValue(`"bar"`), document.FormatOptions{}, `"\"bar\""`},
+		{"large blob", document.NewBlobValue(large), document.FormatOptions{}, "x'" + fmt.Sprintf("%x", large[:document.DefaultMaxBlobSize]) + "'... (1048576 bytes)"},
+		{"max blob size", document.NewBlobValue([]byte("bar")), document.FormatOptions{MaxBlobSize: 2}, "x'6261'... (3 bytes)"},
+		{"no blob truncation", document.NewBlobValue(large[:100]), document.FormatOptions{MaxBlobSize: -1}, "x'" + fmt.Sprintf("%x", large[:100]) + "'"},
+		{"null", document.NewNullValue(), document.FormatOptions{RawText: true}, "NULL"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, document.FormatValue(test.value, test.opts))
+		})
+	}
+}
+
 func TestNewValue(t *testing.T) {
 	type st struct {
 		A int
@@ -75,7 +108,7 @@ func TestNewValue(t *testing.T) {
 		{"null", nil, nil},
 		{"document", document.NewFieldBuffer().Add("a", document.NewIntegerValue(10)), document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))},
 		{"array", document.NewValueBuffer(document.NewIntegerValue(10)), document.NewValueBuffer(document.NewIntegerValue(10))},
-		{"time", now, now.Format(time.RFC3339Nano)},
+		{"time", now, now.UTC().Truncate(time.Microsecond)},
 		{"bytes", myBytes("bar"), []byte("bar")},
 		{"string", myString("bar"), "bar"},
 		{"myUint", myUint(10), int64(10)},
@@ -115,6 +148,10 @@ func TestValueAdd(t *testing.T) {
 		{"integer(120)+float64(120.1)", document.NewIntegerValue(120), document.NewDoubleValue(120.1), document.NewDoubleValue(240.1), false},
 		{"int64(max)+integer(10)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(10), document.NewDoubleValue(math.MaxInt64 + 10), false},
 		{"int64(min)+integer(-10)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(-10), document.NewDoubleValue(math.MinInt64 - 10), false},
+		{"int64(max)+integer(0)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(0), document.NewIntegerValue(math.MaxInt64), false},
+		{"int64(max)+int64(min)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(-1), false},
+		{"int64(max-1)+integer(1)", document.NewIntegerValue(math.MaxInt64 - 1), document.NewIntegerValue(1), document.NewIntegerValue(math.MaxInt64), false},
+		{"int64(max)+integer(1)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(1), document.NewDoubleValue(math.MaxInt64 + 1), false},
 		{"integer(120)+text('120')", document.NewIntegerValue(120), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"text('120')+text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document+document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
@@ -151,6 +188,10 @@ func TestValueSub(t *testing.T) {
 		{"integer(120)-float64(120.1)", document.NewIntegerValue(120), document.NewDoubleValue(120.1), document.NewDoubleValue(-0.09999999999999432), false},
 		{"int64(min)-integer(10)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(10), document.NewDoubleValue(math.MinInt64 - 10), false},
 		{"int64(max)-integer(-10)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(-10), document.NewDoubleValue(math.MaxInt64 + 10), false},
+		{"int64(min)-integer(0)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(0), document.NewIntegerValue(math.MinInt64), false},
+		{"int64(min+1)-integer(1)", document.NewIntegerValue(math.MinInt64 + 1), document.NewIntegerValue(1), document.NewIntegerValue(math.MinInt64), false},
+		{"integer(-1)-int64(min)", document.NewIntegerValue(-1), document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(math.MaxInt64), false},
+		{"integer(0)-int64(min)", document.NewIntegerValue(0), document.NewIntegerValue(math.MinInt64), document.NewDoubleValue(-math.MinInt64), false},
 		{"integer(120)-text('120')", document.NewIntegerValue(120), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"text('120')-text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document-document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
@@ -185,6 +226,12 @@ func TestValueMult(t *testing.T) {
 		{"integer(10)*integer(80)", document.NewIntegerValue(10), document.NewIntegerValue(80), document.NewIntegerValue(800), false},
 		{"integer(10)*float64(80)", document.NewIntegerValue(10), document.NewDoubleValue(80), document.NewDoubleValue(800), false},
 		{"int64(max)*int64(max)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(math.MaxInt64), document.NewDoubleValue(math.MaxInt64 * math.MaxInt64), false},
+		{"int64(max)*integer(-1)", document.NewIntegerValue(math.MaxInt64), document.NewIntegerValue(-1), document.NewIntegerValue(-math.MaxInt64), false},
+		{"int64(min)*integer(1)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(1), document.NewIntegerValue(math.MinInt64), false},
+		{"int64(min)*integer(-1)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(-1), document.NewDoubleValue(-math.MinInt64), false},
+		{"integer(-1)*int64(min)", document.NewIntegerValue(-1), document.NewIntegerValue(math.MinInt64), document.NewDoubleValue(-math.MinInt64), false},
+		{"int64(max/2+1)*integer(2)", document.NewIntegerValue(math.MaxInt64/2 + 1), document.NewIntegerValue(2), document.NewDoubleValue(math.MaxInt64 + 1), false},
+		{"int64(min/2)*integer(2)", document.NewIntegerValue(math.MinInt64 / 2), document.NewIntegerValue(2), document.NewIntegerValue(math.MinInt64), false},
 		{"integer(120)*text('120')", document.NewIntegerValue(120), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"text('120')*text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document*document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
@@ -220,6 +267,8 @@ func TestValueDiv(t *testing.T) {
 		{"integer(10)/integer(8)", document.NewIntegerValue(10), document.NewIntegerValue(8), document.NewIntegerValue(1), false},
 		{"integer(10)/float64(8)", document.NewIntegerValue(10), document.NewDoubleValue(8), document.NewDoubleValue(1.25), false},
 		{"int64(maxint)/float64(maxint)", document.NewIntegerValue(math.MaxInt64), document.NewDoubleValue(math.MaxInt64), document.NewDoubleValue(1), false},
+		{"int64(min)/integer(1)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(1), document.NewIntegerValue(math.MinInt64), false},
+		{"int64(min)/integer(-1)", document.NewIntegerValue(math.MinInt64), document.NewIntegerValue(-1), document.NewDoubleValue(-math.MinInt64), false},
 		{"integer(120)/text('120')", document.NewIntegerValue(120), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"text('120')/text('120')", document.NewTextValue("120"), document.NewTextValue("120"), document.NewNullValue(), false},
 		{"document/document", document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(10))), document.NewNullValue(), false},
@@ -441,3 +490,36 @@ func TestValueBinaryMarshaling(t *testing.T) {
 		})
 	}
 }
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected document.Value
+		fails    bool
+	}{
+		{`null`, document.NewNullValue(), false},
+		{`true`, document.NewBoolValue(true), false},
+		{`10`, document.NewIntegerValue(10), false},
+		{`1.5`, document.NewDoubleValue(1.5), false},
+		{`"foo\n"`, document.NewTextValue("foo\n"), false},
+		{`[1, "a"]`, document.NewArrayValue(document.NewValueBuffer(document.NewIntegerValue(1), document.NewTextValue("a"))), false},
+		{`{"a": 1}`, document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewIntegerValue(1))), false},
+		{`foo`, document.Value{}, true},
+		{`{"a": 1} 2`, document.Value{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.data, func(t *testing.T) {
+			v, err := document.ParseJSON([]byte(test.data))
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			ok, err := v.IsEqual(test.expected)
+			require.NoError(t, err)
+			require.True(t, ok, v)
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package badgerengine_test
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/dgraph-io/badger/v2"
+	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/badgerengine"
 	"github.com/genjidb/genji/engine/enginetest"
@@ -29,6 +32,83 @@ func TestBadgerEngine(t *testing.T) {
 	enginetest.TestSuite(t, builder(t))
 }
 
+func TestBadgerEngineExpiration(t *testing.T) {
+	ng, cleanup := builder(t)()
+	defer cleanup()
+	defer ng.Close()
+
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.CreateStore([]byte("test"))
+	require.NoError(t, err)
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+
+	es, ok := st.(engine.ExpiringStore)
+	require.True(t, ok)
+
+	err = es.PutWithExpiration([]byte("a"), []byte("a"), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	err = es.PutWithExpiration([]byte("b"), []byte("b"), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	v, err := st.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), v)
+
+	_, err = st.Get([]byte("b"))
+	require.Equal(t, engine.ErrKeyNotFound, err)
+}
+
+func TestBadgerEngineTTL(t *testing.T) {
+	ng, cleanup := builder(t)()
+	defer cleanup()
+
+	db, err := genji.New(context.Background(), ng)
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(id INTEGER PRIMARY KEY, exp TIMESTAMP TTL)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO test (id, exp) VALUES (1, ?), (2, ?)", time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	count := func() int {
+		tx, err := db.Begin(false)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		tb, err := tx.GetTable("test")
+		require.NoError(t, err)
+
+		it := tb.Store.Iterator(engine.IteratorOptions{})
+		defer it.Close()
+
+		var n int
+		for it.Seek(nil); it.Valid(); it.Next() {
+			n++
+		}
+		require.NoError(t, it.Err())
+		return n
+	}
+
+	// expired documents are deleted by Badger
+	require.Equal(t, 1, count())
+
+	// rolling back to a savepoint removes the documents stored with an expiration
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.NoError(t, tx.Savepoint("sp"))
+	err = tx.Exec("INSERT INTO test (id, exp) VALUES (3, ?)", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, tx.RollbackToSavepoint("sp"))
+	require.NoError(t, tx.Commit())
+	require.Equal(t, 1, count())
+}
+
 func BenchmarkBadgerEngineStorePut(b *testing.B) {
 	enginetest.BenchmarkStorePut(b, builder(b))
 }
@@ -37,6 +117,10 @@ func BenchmarkBadgerEngineTableScan(b *testing.B) {
 	enginetest.BenchmarkStoreScan(b, builder(b))
 }
 
+func BenchmarkBadgerEngineStoreScanReadAhead(b *testing.B) {
+	enginetest.BenchmarkStoreScanReadAhead(b, builder(b))
+}
+
 func tempDir(t require.TestingT) (string, func()) {
 	dir, err := ioutil.TempDir("", "genji")
 	require.NoError(t, err)
@@ -3,7 +3,9 @@ package badgerengine
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
+	"time"
 
 	"github.com/dgraph-io/badger/v2"
 	"github.com/genjidb/genji/engine"
@@ -53,6 +55,34 @@ func (s *Store) Put(k, v []byte) error {
 	return s.tx.Set(buildKey(s.prefix, k), v)
 }
 
+// PutWithExpiration stores a key value pair that is deleted by Badger once expiresAt is reached.
+// Badger expires keys with a precision of one second.
+func (s *Store) PutWithExpiration(k, v []byte, expiresAt time.Time) error {
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	default:
+	}
+
+	if !s.writable {
+		return engine.ErrTransactionReadOnly
+	}
+
+	if len(k) == 0 {
+		return errors.New("cannot store empty key")
+	}
+
+	// an expiration time of 0 means the key never expires
+	exp := expiresAt.Unix()
+	if exp < 1 {
+		exp = 1
+	}
+
+	e := badger.NewEntry(buildKey(s.prefix, k), v)
+	e.ExpiresAt = uint64(exp)
+	return s.tx.SetEntry(e)
+}
+
 // Get returns a value associated with the given key. If not found, returns engine.ErrKeyNotFound.
 func (s *Store) Get(k []byte) ([]byte, error) {
 	select {
@@ -99,6 +129,7 @@ func (s *Store) Delete(k []byte) error {
 }
 
 // Truncate deletes all the records of the store.
+// It doesn't reset the sequence returned by NextSequence.
 func (s *Store) Truncate() error {
 	select {
 	case <-s.ctx.Done():
@@ -159,24 +190,77 @@ func (s *Store) NextSequence() (uint64, error) {
 	return nb + 1, nil
 }
 
+// AdvanceSequence makes sure the following calls to NextSequence return integers greater than seq.
+// Like NextSequence, it modifies the Badger sequence outside of the transaction.
+func (s *Store) AdvanceSequence(seq uint64) error {
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	default:
+	}
+
+	if !s.writable {
+		return engine.ErrTransactionReadOnly
+	}
+
+	// Badger sequences store the next number they return,
+	// which is returned by NextSequence incremented by one.
+	return s.ng.DB.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.name)
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err == nil {
+			var next uint64
+			err = item.Value(func(v []byte) error {
+				next = binary.BigEndian.Uint64(v)
+				return nil
+			})
+			if err != nil || next >= seq {
+				return err
+			}
+		}
+
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], seq)
+		return txn.Set(s.name, buf[:])
+	})
+}
+
 // Iterator uses a Badger iterator with default options.
 // Only one iterator is allowed per read-write transaction.
+// If the context is canceled, the iterator is invalid and Err returns the context error.
 func (s *Store) Iterator(opts engine.IteratorOptions) engine.Iterator {
 	prefix := buildKey(s.prefix, nil)
 
 	opt := badger.DefaultIteratorOptions
 	opt.Prefix = prefix
 	opt.Reverse = opts.Reverse
+	// Badger prefetches values natively
+	if opts.ReadAhead > 0 {
+		opt.PrefetchValues = true
+		opt.PrefetchSize = opts.ReadAhead
+	}
+	// values are read lazily when only keys are needed
+	if opts.KeysOnly {
+		opt.PrefetchValues = false
+	}
 	it := s.tx.NewIterator(opt)
 
-	return &iterator{
+	bit := &iterator{
 		ctx:         s.ctx,
+		err:         s.ctx.Err(),
 		storePrefix: s.prefix,
 		prefix:      prefix,
 		it:          it,
 		reverse:     opts.Reverse,
 		item:        badgerItem{prefix: prefix},
 	}
+
+	if len(opts.Prefix) > 0 {
+		return engine.NewPrefixIterator(bit, opts.Prefix, opts.Reverse)
+	}
+	return bit
 }
 
 type iterator struct {
@@ -221,6 +305,13 @@ func (it *iterator) Valid() bool {
 }
 
 func (it *iterator) Next() {
+	select {
+	case <-it.ctx.Done():
+		it.err = it.ctx.Err()
+		return
+	default:
+	}
+
 	it.it.Next()
 }
 
@@ -50,6 +50,44 @@ func (e *Engine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Trans
 	}, nil
 }
 
+// Stats contains statistics about the Bolt database.
+type Stats struct {
+	// FileSize is the size of the database file, in bytes.
+	FileSize int64
+	// PageSize is the size of a page, in bytes.
+	PageSize int
+	// FreePageN is the number of free pages on the freelist.
+	FreePageN int
+	// PendingPageN is the number of pending pages on the freelist.
+	PendingPageN int
+	// FreeAlloc is the number of bytes allocated in free pages.
+	FreeAlloc int
+	// TxN is the total number of started read transactions.
+	TxN int
+	// OpenTxN is the number of currently open read transactions.
+	OpenTxN int
+}
+
+// Stats returns statistics about the Bolt database.
+// It implements the engine.StatsEngine interface.
+func (e *Engine) Stats() (interface{}, error) {
+	fi, err := os.Stat(e.DB.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	st := e.DB.Stats()
+	return Stats{
+		FileSize:     fi.Size(),
+		PageSize:     e.DB.Info().PageSize,
+		FreePageN:    st.FreePageN,
+		PendingPageN: st.PendingPageN,
+		FreeAlloc:    st.FreeAlloc,
+		TxN:          st.TxN,
+		OpenTxN:      st.OpenTxN,
+	}, nil
+}
+
 // Close the engine and underlying Bolt database.
 func (e *Engine) Close() error {
 	return e.DB.Close()
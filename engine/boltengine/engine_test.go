@@ -33,6 +33,10 @@ func BenchmarkBoltEngineTableScan(b *testing.B) {
 	enginetest.BenchmarkStoreScan(b, builder(b))
 }
 
+func BenchmarkBoltEngineStoreScanReadAhead(b *testing.B) {
+	enginetest.BenchmarkStoreScanReadAhead(b, builder(b))
+}
+
 func tempDir(t require.TestingT) (string, func()) {
 	dir, err := ioutil.TempDir("", "genji")
 	require.NoError(t, err)
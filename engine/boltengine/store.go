@@ -47,7 +47,7 @@ func (s *Store) Get(k []byte) ([]byte, error) {
 	return v, nil
 }
 
-// Delete a record by key. If not found, returns table.ErrDocumentNotFound.
+// Delete a record by key. If not found, returns engine.ErrKeyNotFound.
 func (s *Store) Delete(k []byte) error {
 	select {
 	case <-s.ctx.Done():
@@ -68,6 +68,7 @@ func (s *Store) Delete(k []byte) error {
 }
 
 // Truncate deletes all the records of the store.
+// It doesn't reset the sequence returned by NextSequence.
 func (s *Store) Truncate() error {
 	select {
 	case <-s.ctx.Done():
@@ -79,13 +80,21 @@ func (s *Store) Truncate() error {
 		return engine.ErrTransactionReadOnly
 	}
 
+	// recreating the bucket resets its sequence,
+	// keep it to avoid reusing generated keys.
+	seq := s.bucket.Sequence()
+
 	err := s.tx.DeleteBucket(s.name)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.tx.CreateBucket(s.name)
-	return err
+	s.bucket, err = s.tx.CreateBucket(s.name)
+	if err != nil {
+		return err
+	}
+
+	return s.bucket.SetSequence(seq)
 }
 
 // NextSequence returns a monotonically increasing integer.
@@ -103,13 +112,67 @@ func (s *Store) NextSequence() (uint64, error) {
 	return s.bucket.NextSequence()
 }
 
+// AdvanceSequence makes sure the following calls to NextSequence return integers greater than seq.
+func (s *Store) AdvanceSequence(seq uint64) error {
+	select {
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	default:
+	}
+
+	if !s.bucket.Writable() {
+		return engine.ErrTransactionReadOnly
+	}
+
+	if s.bucket.Sequence() >= seq {
+		return nil
+	}
+
+	return s.bucket.SetSequence(seq)
+}
+
+// ApproximateKeyCount returns the number of keys of the bucket.
+// In read-only transactions, the keys are counted using the headers
+// of the pages of the bucket, without reading them.
+// Since these pages don't contain the changes of the current transaction,
+// the keys are iterated over in read-write transactions.
+// It implements the engine.KeyCounter interface.
+func (s *Store) ApproximateKeyCount() (int, error) {
+	select {
+	case <-s.ctx.Done():
+		return 0, s.ctx.Err()
+	default:
+	}
+
+	if !s.bucket.Writable() {
+		return s.bucket.Stats().KeyN, nil
+	}
+
+	var n int
+	c := s.bucket.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		n++
+	}
+
+	return n, nil
+}
+
 // Iterator uses the Bolt bucket cursor.
+// Bolt reads items directly from memory-mapped pages, so the ReadAhead option is ignored:
+// copying items in advance would only slow down the scan.
+// If the context is canceled, the iterator is invalid and Err returns the context error.
 func (s *Store) Iterator(opts engine.IteratorOptions) engine.Iterator {
-	return &iterator{
+	it := &iterator{
 		c:       s.bucket.Cursor(),
 		reverse: opts.Reverse,
 		ctx:     s.ctx,
+		err:     s.ctx.Err(),
+	}
+
+	if len(opts.Prefix) > 0 {
+		return engine.NewPrefixIterator(it, opts.Prefix, opts.Reverse)
 	}
+	return it
 }
 
 type iterator struct {
@@ -151,6 +214,13 @@ func (it *iterator) Valid() bool {
 }
 
 func (it *iterator) Next() {
+	select {
+	case <-it.ctx.Done():
+		it.err = it.ctx.Err()
+		return
+	default:
+	}
+
 	if it.reverse {
 		it.item.k, it.item.v = it.c.Prev()
 	} else {
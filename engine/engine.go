@@ -4,6 +4,7 @@ package engine
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // Common errors returned by the engine implementations.
@@ -18,7 +19,8 @@ var (
 	// same name as an existing one.
 	ErrStoreAlreadyExists = errors.New("store already exists")
 
-	// ErrKeyNotFound is returned when the targeted key doesn't exist.
+	// ErrKeyNotFound is returned by stores when the targeted key doesn't exist.
+	// The database package translates it to database.ErrDocumentNotFound.
 	ErrKeyNotFound = errors.New("key not found")
 )
 
@@ -35,6 +37,14 @@ type Engine interface {
 	Close() error
 }
 
+// A StatsEngine is an engine able to report statistics about itself.
+// Engines may implement this interface to expose metrics like the size of their files.
+type StatsEngine interface {
+	// Stats returns statistics about the engine.
+	// The type of the returned value depends on the implementation.
+	Stats() (interface{}, error)
+}
+
 // TxOptions is used to configure a transaction upon creation.
 type TxOptions struct {
 	Writable bool
@@ -70,17 +80,111 @@ type Store interface {
 	// Delete a key value pair. If the key is not found, returns ErrKeyNotFound.
 	Delete(k []byte) error
 	// Truncate deletes all the key value pairs from the store.
+	// It doesn't reset the sequence returned by NextSequence, to make sure
+	// keys generated after a truncation are never reused.
 	Truncate() error
 	// Iterator creates an iterator with the given options.
 	// The initial position depends on the implementation.
+	// If the context of the transaction is canceled, the iterator must be invalid
+	// and its Err method must return the context error.
 	Iterator(opts IteratorOptions) Iterator
 	// NextSequence returns a monotonically increasing integer.
 	NextSequence() (uint64, error)
+	// AdvanceSequence makes sure the following calls to NextSequence return
+	// integers greater than seq. It does nothing if the sequence is already past it.
+	AdvanceSequence(seq uint64) error
+}
+
+// A KeyCounter is a store able to count its keys without iterating over them.
+// Stores may implement this interface to provide cheap approximate counts.
+type KeyCounter interface {
+	// ApproximateKeyCount returns an estimation of the number of keys of the store.
+	ApproximateKeyCount() (int, error)
+}
+
+// An ExpiringStore is a store able to delete keys by itself once they expire.
+// Stores may implement this interface to let the database delegate
+// the deletion of expired documents to the engine.
+type ExpiringStore interface {
+	// PutWithExpiration stores a key value pair like Put, except that the key
+	// is automatically deleted once expiresAt is reached.
+	PutWithExpiration(k, v []byte, expiresAt time.Time) error
+}
+
+// AsStatsEngine returns the first engine of the chain of wrapped engines starting at ng
+// that implements the StatsEngine interface. Engines wrapping another one, like the ones
+// returned by NewLoggingEngine, must implement an Unwrap() Engine method to be traversed.
+func AsStatsEngine(ng Engine) (StatsEngine, bool) {
+	for ng != nil {
+		if se, ok := ng.(StatsEngine); ok {
+			return se, true
+		}
+
+		u, ok := ng.(interface{ Unwrap() Engine })
+		if !ok {
+			break
+		}
+		ng = u.Unwrap()
+	}
+
+	return nil, false
+}
+
+// AsKeyCounter returns the first store of the chain of wrapped stores starting at st
+// that implements the KeyCounter interface. Stores wrapping another one
+// must implement an Unwrap() Store method to be traversed.
+func AsKeyCounter(st Store) (KeyCounter, bool) {
+	for st != nil {
+		if kc, ok := st.(KeyCounter); ok {
+			return kc, true
+		}
+
+		u, ok := st.(interface{ Unwrap() Store })
+		if !ok {
+			break
+		}
+		st = u.Unwrap()
+	}
+
+	return nil, false
+}
+
+// AsExpiringStore returns the first store of the chain of wrapped stores starting at st
+// that implements the ExpiringStore interface. Stores wrapping another one
+// must implement an Unwrap() Store method to be traversed.
+// Keys stored using the returned store bypass the wrapping stores.
+func AsExpiringStore(st Store) (ExpiringStore, bool) {
+	for st != nil {
+		if es, ok := st.(ExpiringStore); ok {
+			return es, true
+		}
+
+		u, ok := st.(interface{ Unwrap() Store })
+		if !ok {
+			break
+		}
+		st = u.Unwrap()
+	}
+
+	return nil, false
 }
 
 // IteratorOptions is used to configure an iterator upon creation.
 type IteratorOptions struct {
 	Reverse bool
+	// If set, the iterator only returns keys starting with Prefix.
+	// Seeking before the first key of the prefix, or with an empty pivot,
+	// moves the iterator to the first key of the prefix (or the last one if Reverse is true).
+	Prefix []byte
+	// If greater than zero, the iterator fetches up to ReadAhead items
+	// ahead of the current one, trading memory for fewer round trips to the underlying storage
+	// during large sequential scans. Engines that don't support prefetching natively
+	// can use NewReadAheadIterator.
+	ReadAhead int
+	// If set, the caller mostly reads the keys of the items.
+	// Engines may avoid loading the values in advance, but Item.ValueCopy must
+	// still return the value of the item.
+	KeysOnly bool
 }
 
 // An Iterator iterates on keys of a store in lexicographic order.
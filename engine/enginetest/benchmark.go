@@ -61,3 +61,40 @@ func BenchmarkStoreScan(b *testing.B, builder Builder) {
 		})
 	}
 }
+
+// BenchmarkStoreScanReadAhead benchmarks a full scan of 10000 keys without read-ahead
+// and with various read-ahead sizes.
+func BenchmarkStoreScanReadAhead(b *testing.B, builder Builder) {
+	st, cleanup := storeBuilder(b, builder)
+	defer cleanup()
+
+	v := bytes.Repeat([]byte("v"), 512)
+
+	for i := 0; i < 10000; i++ {
+		k := []byte(fmt.Sprintf("k%d", i))
+		err := st.Put(k, v)
+		require.NoError(b, err)
+	}
+
+	for _, size := range []int{0, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("read-ahead=%d", size), func(b *testing.B) {
+			var buf []byte
+			for i := 0; i < b.N; i++ {
+				it := st.Iterator(engine.IteratorOptions{ReadAhead: size})
+				for it.Seek(nil); it.Valid(); it.Next() {
+					var err error
+					buf, err = it.Item().ValueCopy(buf)
+					if err != nil {
+						require.NoError(b, err)
+					}
+				}
+				if err := it.Err(); err != nil {
+					require.NoError(b, err)
+				}
+				if err := it.Close(); err != nil {
+					require.NoError(b, err)
+				}
+			}
+		})
+	}
+}
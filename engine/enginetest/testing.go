@@ -5,6 +5,7 @@ package enginetest
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/genjidb/genji"
@@ -17,9 +18,10 @@ import (
 // a function to cleanup up and remove any created state. Note that the engine
 // is not closed on cleanup.
 // Tests will use the builder like this:
-//     ng, cleanup := builder()
-//     defer cleanup()
-//     ...
+//
+//	ng, cleanup := builder()
+//	defer cleanup()
+//	...
 type Builder func() (engine.Engine, func())
 
 // TestSuite tests an entire engine, transaction and related types
@@ -40,6 +42,8 @@ func TestSuite(t *testing.T, builder Builder) {
 		{"Store/Delete", TestStoreDelete},
 		{"Store/Truncate", TestStoreTruncate},
 		{"Store/NextSequence", TestStoreNextSequence},
+		{"Store/AdvanceSequence", TestStoreAdvanceSequence},
+		{"Store/ApproximateKeyCount", TestStoreApproximateKeyCount},
 		{"TestQueries", TestQueries},
 		{"TestQueriesSameTransaction", TestQueriesSameTransaction},
 	}
@@ -678,6 +682,51 @@ func TestStoreIterator(t *testing.T, builder Builder) {
 		require.Zero(t, i)
 	})
 
+	t.Run("Should be invalid if created after context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		st, cleanup := storeBuilderWithContext(ctx, t, builder)
+		defer cleanup()
+
+		err := st.Put([]byte{1}, []byte{1})
+		require.NoError(t, err)
+
+		cancel()
+
+		it := st.Iterator(engine.IteratorOptions{})
+		defer it.Close()
+
+		require.False(t, it.Valid())
+		require.Equal(t, context.Canceled, it.Err())
+	})
+
+	t.Run("Should stop the iteration if context canceled while iterating", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		st, cleanup := storeBuilderWithContext(ctx, t, builder)
+		defer cleanup()
+
+		for i := 1; i <= 10; i++ {
+			err := st.Put([]byte{uint8(i)}, []byte{uint8(i + 20)})
+			require.NoError(t, err)
+		}
+
+		it := st.Iterator(engine.IteratorOptions{})
+		defer it.Close()
+
+		var i int
+		for it.Seek(nil); it.Valid(); it.Next() {
+			i++
+			if i == 3 {
+				cancel()
+			}
+		}
+		require.Equal(t, context.Canceled, it.Err())
+		require.Equal(t, 3, i)
+	})
+
 	t.Run("With no pivot, should iterate over all documents in order", func(t *testing.T) {
 		st, cleanup := storeBuilder(t, builder)
 		defer cleanup()
@@ -857,6 +906,97 @@ func TestStoreIterator(t *testing.T, builder Builder) {
 		require.True(t, it.Valid())
 		require.Equal(t, it.Item().Key(), k)
 	})
+
+	t.Run("With prefix, should only iterate over the keys starting with the prefix", func(t *testing.T) {
+		keys := [][]byte{{1}, {2}, {2, 0}, {2, 5}, {2, 0xFF}, {3}, {0xFF}, {0xFF, 1}}
+
+		tests := []struct {
+			name     string
+			prefix   []byte
+			reverse  bool
+			pivot    []byte
+			expected [][]byte
+		}{
+			{"no pivot", []byte{2}, false, nil, [][]byte{{2}, {2, 0}, {2, 5}, {2, 0xFF}}},
+			{"no pivot/reverse", []byte{2}, true, nil, [][]byte{{2, 0xFF}, {2, 5}, {2, 0}, {2}}},
+			{"pivot", []byte{2}, false, []byte{2, 1}, [][]byte{{2, 5}, {2, 0xFF}}},
+			{"pivot/reverse", []byte{2}, true, []byte{2, 1}, [][]byte{{2, 0}, {2}}},
+			{"pivot before prefix", []byte{2}, false, []byte{1}, [][]byte{{2}, {2, 0}, {2, 5}, {2, 0xFF}}},
+			{"pivot after prefix/reverse", []byte{2}, true, []byte{5}, [][]byte{{2, 0xFF}, {2, 5}, {2, 0}, {2}}},
+			{"pivot after prefix", []byte{2}, false, []byte{3}, nil},
+			{"last prefix", []byte{0xFF}, false, nil, [][]byte{{0xFF}, {0xFF, 1}}},
+			{"last prefix/reverse", []byte{0xFF}, true, nil, [][]byte{{0xFF, 1}, {0xFF}}},
+			{"no match", []byte{4}, false, nil, nil},
+			{"no match/reverse", []byte{4}, true, nil, nil},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				st, cleanup := storeBuilder(t, builder)
+				defer cleanup()
+
+				for _, k := range keys {
+					err := st.Put(k, k)
+					require.NoError(t, err)
+				}
+
+				it := st.Iterator(engine.IteratorOptions{Prefix: test.prefix, Reverse: test.reverse})
+				defer it.Close()
+
+				var got [][]byte
+				for it.Seek(test.pivot); it.Valid(); it.Next() {
+					got = append(got, append([]byte(nil), it.Item().Key()...))
+				}
+				require.NoError(t, it.Err())
+				require.Equal(t, test.expected, got)
+			})
+		}
+	})
+
+	t.Run("With read-ahead, should return the same items", func(t *testing.T) {
+		st, cleanup := storeBuilder(t, builder)
+		defer cleanup()
+
+		var keys [][]byte
+		for i := 0; i < 10; i++ {
+			k := []byte{byte(i)}
+			err := st.Put(k, append(k, 'v'))
+			require.NoError(t, err)
+			keys = append(keys, k)
+		}
+
+		for _, size := range []int{1, 3, 10, 20} {
+			for _, reverse := range []bool{false, true} {
+				t.Run(fmt.Sprintf("size=%d/reverse=%v", size, reverse), func(t *testing.T) {
+					it := st.Iterator(engine.IteratorOptions{ReadAhead: size, Reverse: reverse})
+					defer it.Close()
+
+					var got, values [][]byte
+					for it.Seek([]byte{2}); it.Valid(); it.Next() {
+						got = append(got, append([]byte(nil), it.Item().Key()...))
+						v, err := it.Item().ValueCopy(nil)
+						require.NoError(t, err)
+						values = append(values, v)
+					}
+					require.NoError(t, it.Err())
+
+					var expected, expectedValues [][]byte
+					if reverse {
+						for i := 2; i >= 0; i-- {
+							expected = append(expected, keys[i])
+						}
+					} else {
+						expected = keys[2:]
+					}
+					for _, k := range expected {
+						expectedValues = append(expectedValues, append(k, 'v'))
+					}
+					require.Equal(t, expected, got)
+					require.Equal(t, expectedValues, values)
+				})
+			}
+		}
+	})
 }
 
 // TestStorePut verifies Put behaviour.
@@ -1005,6 +1145,51 @@ func TestStoreDelete(t *testing.T, builder Builder) {
 		require.Equal(t, []byte("BAR"), v)
 	})
 
+	t.Run("Should keep a key put again after being deleted", func(t *testing.T) {
+		ng, cleanup := builder()
+		defer cleanup()
+		defer func() {
+			require.NoError(t, ng.Close())
+		}()
+
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{
+			Writable: true,
+		})
+		require.NoError(t, err)
+		err = tx.CreateStore([]byte("test"))
+		require.NoError(t, err)
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		err = st.Put([]byte("foo"), []byte("FOO"))
+		require.NoError(t, err)
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = ng.Begin(context.Background(), engine.TxOptions{
+			Writable: true,
+		})
+		require.NoError(t, err)
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		err = st.Delete([]byte("foo"))
+		require.NoError(t, err)
+		err = st.Put([]byte("foo"), []byte("BAR"))
+		require.NoError(t, err)
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = ng.Begin(context.Background(), engine.TxOptions{
+			Writable: false,
+		})
+		require.NoError(t, err)
+		defer tx.Rollback()
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		v, err := st.Get([]byte("foo"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("BAR"), v)
+	})
+
 	t.Run("Should fail if context canceled", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -1145,6 +1330,21 @@ func TestStoreNextSequence(t *testing.T, builder Builder) {
 		require.Equal(t, s1+1, s2)
 	})
 
+	t.Run("Should not be reset by Truncate", func(t *testing.T) {
+		st, cleanup := storeBuilder(t, builder)
+		defer cleanup()
+
+		s1, err := st.NextSequence()
+		require.NoError(t, err)
+
+		err = st.Truncate()
+		require.NoError(t, err)
+
+		s2, err := st.NextSequence()
+		require.NoError(t, err)
+		require.Equal(t, s1+1, s2)
+	})
+
 	t.Run("Should fail if context canceled", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -1158,6 +1358,132 @@ func TestStoreNextSequence(t *testing.T, builder Builder) {
 	})
 }
 
+// TestStoreAdvanceSequence verifies AdvanceSequence behaviour.
+func TestStoreAdvanceSequence(t *testing.T, builder Builder) {
+	t.Run("Should move the sequence forward", func(t *testing.T) {
+		st, cleanup := storeBuilder(t, builder)
+		defer cleanup()
+
+		_, err := st.NextSequence()
+		require.NoError(t, err)
+
+		err = st.AdvanceSequence(10)
+		require.NoError(t, err)
+
+		s, err := st.NextSequence()
+		require.NoError(t, err)
+		require.Equal(t, uint64(11), s)
+	})
+
+	t.Run("Should not move the sequence backward", func(t *testing.T) {
+		st, cleanup := storeBuilder(t, builder)
+		defer cleanup()
+
+		for i := 0; i < 5; i++ {
+			_, err := st.NextSequence()
+			require.NoError(t, err)
+		}
+
+		err := st.AdvanceSequence(3)
+		require.NoError(t, err)
+
+		s, err := st.NextSequence()
+		require.NoError(t, err)
+		require.Equal(t, uint64(6), s)
+	})
+
+	t.Run("Should store the sequence", func(t *testing.T) {
+		ng, cleanup := builder()
+		defer cleanup()
+		defer func() {
+			require.NoError(t, ng.Close())
+		}()
+
+		tx, err := ng.Begin(context.Background(), engine.TxOptions{
+			Writable: true,
+		})
+		require.NoError(t, err)
+		err = tx.CreateStore([]byte("test"))
+		require.NoError(t, err)
+		st, err := tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+
+		err = st.AdvanceSequence(10)
+		require.NoError(t, err)
+
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		tx, err = ng.Begin(context.Background(), engine.TxOptions{
+			Writable: true,
+		})
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		st, err = tx.GetStore([]byte("test"))
+		require.NoError(t, err)
+		s, err := st.NextSequence()
+		require.NoError(t, err)
+		require.Equal(t, uint64(11), s)
+	})
+
+	t.Run("Should fail if context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		st, cleanup := storeBuilderWithContext(ctx, t, builder)
+		defer cleanup()
+
+		cancel()
+		err := st.AdvanceSequence(10)
+		require.Equal(t, context.Canceled, err)
+	})
+}
+
+// TestStoreApproximateKeyCount verifies ApproximateKeyCount behaviour,
+// if the store implements the engine.KeyCounter interface.
+func TestStoreApproximateKeyCount(t *testing.T, builder Builder) {
+	t.Run("Should count the keys", func(t *testing.T) {
+		st, cleanup := storeBuilder(t, builder)
+		defer cleanup()
+
+		kc, ok := st.(engine.KeyCounter)
+		if !ok {
+			t.Skip("store doesn't implement engine.KeyCounter")
+		}
+
+		n, err := kc.ApproximateKeyCount()
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		for i := 1; i <= 10; i++ {
+			err := st.Put([]byte{uint8(i)}, []byte{uint8(i)})
+			require.NoError(t, err)
+		}
+
+		n, err = kc.ApproximateKeyCount()
+		require.NoError(t, err)
+		require.Equal(t, 10, n)
+	})
+
+	t.Run("Should fail if context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		st, cleanup := storeBuilderWithContext(ctx, t, builder)
+		defer cleanup()
+
+		kc, ok := st.(engine.KeyCounter)
+		if !ok {
+			t.Skip("store doesn't implement engine.KeyCounter")
+		}
+
+		cancel()
+		_, err := kc.ApproximateKeyCount()
+		require.Equal(t, context.Canceled, err)
+	})
+}
+
 // TestQueries test simple queries against the engine.
 func TestQueries(t *testing.T, builder Builder) {
 	t.Run("SELECT", func(t *testing.T) {
@@ -0,0 +1,224 @@
+package engine
+
+import "context"
+
+// An Operation describes a call made to a store or an iterator.
+type Operation struct {
+	// Store on which the operation was executed.
+	Store string
+	// Name of the operation: Get, Put, Delete, Truncate, NextSequence,
+	// AdvanceSequence, Seek or Next.
+	Name string
+	// Size of the key passed to the operation, or of the key the iterator
+	// is positioned on, in bytes.
+	KeySize int
+	// Size of the value read or written by the operation, in bytes.
+	ValueSize int
+	// Hit reports whether the key was found for Get and Delete,
+	// or whether the iterator is positioned on a valid item for Seek and Next.
+	Hit bool
+	// Err returned by the operation, if any.
+	Err error
+}
+
+// A Logger is called every time an operation is executed by a store
+// or an iterator of an engine wrapped with NewLoggingEngine.
+type Logger interface {
+	LogOperation(op Operation)
+}
+
+// The LoggerFunc type is an adapter to allow the use of ordinary functions as Loggers.
+type LoggerFunc func(op Operation)
+
+// LogOperation calls f(op).
+func (f LoggerFunc) LogOperation(op Operation) {
+	f(op)
+}
+
+// NewLoggingEngine returns an engine that logs every store and iterator operation
+// executed by ng. Engines that are not wrapped don't pay any cost for logging.
+// If l is nil, ng is returned as is.
+func NewLoggingEngine(ng Engine, l Logger) Engine {
+	if l == nil {
+		return ng
+	}
+
+	return &loggingEngine{Engine: ng, l: l}
+}
+
+type loggingEngine struct {
+	Engine
+
+	l Logger
+}
+
+// Unwrap returns the wrapped engine.
+func (ng *loggingEngine) Unwrap() Engine {
+	return ng.Engine
+}
+
+func (ng *loggingEngine) Begin(ctx context.Context, opts TxOptions) (Transaction, error) {
+	tx, err := ng.Engine.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loggingTransaction{Transaction: tx, l: ng.l}, nil
+}
+
+type loggingTransaction struct {
+	Transaction
+
+	l Logger
+}
+
+func (tx *loggingTransaction) GetStore(name []byte) (Store, error) {
+	st, err := tx.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loggingStore{Store: st, name: string(name), l: tx.l}, nil
+}
+
+type loggingStore struct {
+	Store
+
+	name string
+	l    Logger
+}
+
+// Unwrap returns the wrapped store.
+func (s *loggingStore) Unwrap() Store {
+	return s.Store
+}
+
+func (s *loggingStore) Get(k []byte) ([]byte, error) {
+	v, err := s.Store.Get(k)
+
+	s.l.LogOperation(Operation{
+		Store:     s.name,
+		Name:      "Get",
+		KeySize:   len(k),
+		ValueSize: len(v),
+		Hit:       err == nil,
+		Err:       ignoreKeyNotFound(err),
+	})
+
+	return v, err
+}
+
+func (s *loggingStore) Put(k, v []byte) error {
+	err := s.Store.Put(k, v)
+
+	s.l.LogOperation(Operation{
+		Store:     s.name,
+		Name:      "Put",
+		KeySize:   len(k),
+		ValueSize: len(v),
+		Err:       err,
+	})
+
+	return err
+}
+
+func (s *loggingStore) Delete(k []byte) error {
+	err := s.Store.Delete(k)
+
+	s.l.LogOperation(Operation{
+		Store:   s.name,
+		Name:    "Delete",
+		KeySize: len(k),
+		Hit:     err == nil,
+		Err:     ignoreKeyNotFound(err),
+	})
+
+	return err
+}
+
+func (s *loggingStore) Truncate() error {
+	err := s.Store.Truncate()
+
+	s.l.LogOperation(Operation{
+		Store: s.name,
+		Name:  "Truncate",
+		Err:   err,
+	})
+
+	return err
+}
+
+func (s *loggingStore) NextSequence() (uint64, error) {
+	seq, err := s.Store.NextSequence()
+
+	s.l.LogOperation(Operation{
+		Store: s.name,
+		Name:  "NextSequence",
+		Err:   err,
+	})
+
+	return seq, err
+}
+
+func (s *loggingStore) AdvanceSequence(seq uint64) error {
+	err := s.Store.AdvanceSequence(seq)
+
+	s.l.LogOperation(Operation{
+		Store: s.name,
+		Name:  "AdvanceSequence",
+		Err:   err,
+	})
+
+	return err
+}
+
+func (s *loggingStore) Iterator(opts IteratorOptions) Iterator {
+	return &loggingIterator{
+		Iterator: s.Store.Iterator(opts),
+		name:     s.name,
+		l:        s.l,
+	}
+}
+
+type loggingIterator struct {
+	Iterator
+
+	name string
+	l    Logger
+}
+
+func (it *loggingIterator) Seek(k []byte) {
+	it.Iterator.Seek(k)
+	it.log("Seek")
+}
+
+func (it *loggingIterator) Next() {
+	it.Iterator.Next()
+	it.log("Next")
+}
+
+// log reports the size of the key the iterator is positioned on, or 0 if it isn't valid.
+func (it *loggingIterator) log(name string) {
+	var size int
+	valid := it.Iterator.Valid()
+	if valid {
+		size = len(it.Iterator.Item().Key())
+	}
+
+	it.l.LogOperation(Operation{
+		Store:   it.name,
+		Name:    name,
+		KeySize: size,
+		Hit:     valid,
+		Err:     it.Iterator.Err(),
+	})
+}
+
+// a missing key is reported as a miss rather than an error.
+func ignoreKeyNotFound(err error) error {
+	if err == ErrKeyNotFound {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,172 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/enginetest"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingEngine(t *testing.T) {
+	enginetest.TestSuite(t, func() (engine.Engine, func()) {
+		ng := engine.NewLoggingEngine(memoryengine.NewEngine(), engine.LoggerFunc(func(op engine.Operation) {}))
+		return ng, func() { ng.Close() }
+	})
+}
+
+func TestLoggingEngineOperations(t *testing.T) {
+	var ops []engine.Operation
+
+	ng := engine.NewLoggingEngine(memoryengine.NewEngine(), engine.LoggerFunc(func(op engine.Operation) {
+		ops = append(ops, op)
+	}))
+	defer ng.Close()
+
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+
+	require.NoError(t, st.Put([]byte("foo"), []byte("bar")))
+	_, err = st.Get([]byte("foo"))
+	require.NoError(t, err)
+	_, err = st.Get([]byte("a"))
+	require.Equal(t, engine.ErrKeyNotFound, err)
+	require.Equal(t, engine.ErrKeyNotFound, st.Delete([]byte("a")))
+
+	it := st.Iterator(engine.IteratorOptions{})
+	it.Seek(nil)
+	it.Next()
+	// the size of the key the iterator is positioned on is logged, not the one of the pivot
+	it.Seek([]byte("f"))
+	it.Seek([]byte("zzzz"))
+	require.NoError(t, it.Close())
+
+	require.Equal(t, []engine.Operation{
+		{Store: "test", Name: "Put", KeySize: 3, ValueSize: 3},
+		{Store: "test", Name: "Get", KeySize: 3, ValueSize: 3, Hit: true},
+		{Store: "test", Name: "Get", KeySize: 1},
+		{Store: "test", Name: "Delete", KeySize: 1},
+		{Store: "test", Name: "Seek", KeySize: 3, Hit: true},
+		{Store: "test", Name: "Next"},
+		{Store: "test", Name: "Seek", KeySize: 3, Hit: true},
+		{Store: "test", Name: "Seek"},
+	}, ops)
+}
+
+func TestNewLoggingEngineNilLogger(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	defer ng.Close()
+
+	require.Equal(t, ng, engine.NewLoggingEngine(ng, nil))
+}
+
+// expiringEngine wraps the stores of an engine to make them implement
+// the engine.ExpiringStore interface.
+type expiringEngine struct {
+	engine.Engine
+
+	expiring map[string]time.Time
+}
+
+func (ng *expiringEngine) Unwrap() engine.Engine {
+	return ng.Engine
+}
+
+func (ng *expiringEngine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Transaction, error) {
+	tx, err := ng.Engine.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expiringTransaction{Transaction: tx, ng: ng}, nil
+}
+
+type expiringTransaction struct {
+	engine.Transaction
+
+	ng *expiringEngine
+}
+
+func (tx *expiringTransaction) GetStore(name []byte) (engine.Store, error) {
+	st, err := tx.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expiringStore{Store: st, ng: tx.ng}, nil
+}
+
+type expiringStore struct {
+	engine.Store
+
+	ng *expiringEngine
+}
+
+func (s *expiringStore) Unwrap() engine.Store {
+	return s.Store
+}
+
+func (s *expiringStore) PutWithExpiration(k, v []byte, expiresAt time.Time) error {
+	s.ng.expiring[string(k)] = expiresAt
+	return s.Store.Put(k, v)
+}
+
+func TestLoggingEngineWrappedInterfaces(t *testing.T) {
+	var ops []engine.Operation
+	l := engine.LoggerFunc(func(op engine.Operation) {
+		ops = append(ops, op)
+	})
+
+	exp := time.Now()
+	ng := &expiringEngine{Engine: memoryengine.NewEngine(), expiring: make(map[string]time.Time)}
+	lng := engine.NewLoggingEngine(ng, l)
+	defer lng.Close()
+
+	// memoryengine implements the engine.StatsEngine interface
+	_, ok := lng.(engine.StatsEngine)
+	require.False(t, ok)
+	_, ok = engine.AsStatsEngine(lng)
+	require.True(t, ok)
+
+	tx, err := lng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+
+	// memoryengine implements the engine.KeyCounter interface
+	_, ok = engine.AsKeyCounter(st)
+	require.True(t, ok)
+
+	// the logging store doesn't implement the engine.ExpiringStore interface itself
+	_, ok = st.(engine.ExpiringStore)
+	require.False(t, ok)
+	es, ok := engine.AsExpiringStore(st)
+	require.True(t, ok)
+	require.NoError(t, es.PutWithExpiration([]byte("foo"), []byte("bar"), exp))
+	require.Equal(t, map[string]time.Time{"foo": exp}, ng.expiring)
+	// keys stored by the returned store bypass the logging store
+	require.Empty(t, ops)
+
+	// memoryengine stores can't expire keys
+	mng := engine.NewLoggingEngine(memoryengine.NewEngine(), l)
+	defer mng.Close()
+	mtx, err := mng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer mtx.Rollback()
+	require.NoError(t, mtx.CreateStore([]byte("test")))
+	st, err = mtx.GetStore([]byte("test"))
+	require.NoError(t, err)
+	_, ok = engine.AsExpiringStore(st)
+	require.False(t, ok)
+}
@@ -53,6 +53,34 @@ func (ng *Engine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Tran
 	return &transaction{ctx: ctx, ng: ng, writable: opts.Writable}, nil
 }
 
+// Stats contains statistics about the memory engine.
+type Stats struct {
+	// StoreCount is the number of stores.
+	StoreCount int
+	// KeyCount is the total number of keys of all the stores.
+	KeyCount int
+}
+
+// Stats returns statistics about the engine.
+// It implements the engine.StatsEngine interface.
+func (ng *Engine) Stats() (interface{}, error) {
+	ng.mu.RLock()
+	defer ng.mu.RUnlock()
+
+	if ng.closed {
+		return nil, errors.New("engine closed")
+	}
+
+	st := Stats{
+		StoreCount: len(ng.stores),
+	}
+	for _, tr := range ng.stores {
+		st.KeyCount += tr.Len()
+	}
+
+	return st, nil
+}
+
 // Close the engine.
 func (ng *Engine) Close() error {
 	ng.mu.Lock()
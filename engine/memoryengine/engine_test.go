@@ -24,3 +24,7 @@ func BenchmarkMemoryEngineStorePut(b *testing.B) {
 func BenchmarkMemoryEngineStoreScan(b *testing.B) {
 	enginetest.BenchmarkStoreScan(b, builder)
 }
+
+func BenchmarkMemoryEngineStoreScanReadAhead(b *testing.B) {
+	enginetest.BenchmarkStoreScanReadAhead(b, builder)
+}
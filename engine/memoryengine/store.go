@@ -116,6 +116,7 @@ func (s *storeTx) Get(k []byte) ([]byte, error) {
 // every time we remove an item from it,
 // which causes iterators to behave incorrectly when looping
 // and deleting at the same time.
+// If k doesn't exist, it returns engine.ErrKeyNotFound.
 func (s *storeTx) Delete(k []byte) error {
 	select {
 	case <-s.tx.ctx.Done():
@@ -152,9 +153,12 @@ func (s *storeTx) Delete(k []byte) error {
 		i.deleted = false
 	})
 
-	// on commit, remove the item from the tree.
+	// on commit, remove the item from the tree,
+	// unless it was put again after being deleted.
 	s.tx.onCommit = append(s.tx.onCommit, func() {
-		s.tr.Delete(i)
+		if i.deleted {
+			s.tr.Delete(i)
+		}
 	})
 	return nil
 }
@@ -162,6 +166,7 @@ func (s *storeTx) Delete(k []byte) error {
 // Truncate replaces the current tree by a new
 // one. The current tree will be garbage collected
 // once the transaction is commited.
+// It doesn't reset the sequence returned by NextSequence.
 func (s *storeTx) Truncate() error {
 	select {
 	case <-s.tx.ctx.Done():
@@ -175,10 +180,13 @@ func (s *storeTx) Truncate() error {
 
 	old := s.tr
 	s.tr = btree.New(btreeDegree)
+	// stores fetched afterwards must use the new tree as well
+	s.tx.ng.stores[s.name] = s.tr
 
 	// on rollback replace the new tree by the old one.
 	s.tx.onRollback = append(s.tx.onRollback, func() {
 		s.tr = old
+		s.tx.ng.stores[s.name] = old
 	})
 
 	return nil
@@ -201,36 +209,86 @@ func (s *storeTx) NextSequence() (uint64, error) {
 	return s.tx.ng.sequences[s.name], nil
 }
 
+// AdvanceSequence makes sure the following calls to NextSequence return integers greater than seq.
+func (s *storeTx) AdvanceSequence(seq uint64) error {
+	select {
+	case <-s.tx.ctx.Done():
+		return s.tx.ctx.Err()
+	default:
+	}
+
+	if !s.tx.writable {
+		return engine.ErrTransactionReadOnly
+	}
+
+	if s.tx.ng.sequences[s.name] < seq {
+		s.tx.ng.sequences[s.name] = seq
+	}
+
+	return nil
+}
+
+// ApproximateKeyCount returns the number of items of the tree.
+// Items deleted by the current transaction are still counted until it is committed.
+// It implements the engine.KeyCounter interface.
+func (s *storeTx) ApproximateKeyCount() (int, error) {
+	select {
+	case <-s.tx.ctx.Done():
+		return 0, s.tx.ctx.Err()
+	default:
+	}
+
+	return s.tr.Len(), nil
+}
+
 // Iterator creates an iterator with the given options.
+// Items are read from the tree by a goroutine. If ReadAhead is set, the goroutine
+// reads up to ReadAhead items ahead of the current one instead of waiting for each call to Next.
+// If the context is canceled, the iterator is invalid and Err returns the context error.
 func (s *storeTx) Iterator(opts engine.IteratorOptions) engine.Iterator {
-	return &iterator{
-		tx:      s.tx,
-		tr:      s.tr,
-		reverse: opts.Reverse,
-		ch:      make(chan *item),
-		closed:  make(chan struct{}),
+	it := &iterator{
+		err:       s.tx.ctx.Err(),
+		tx:        s.tx,
+		tr:        s.tr,
+		reverse:   opts.Reverse,
+		readAhead: opts.ReadAhead,
+		ch:        make(chan *item, opts.ReadAhead),
+		closed:    make(chan struct{}),
 	}
+
+	if len(opts.Prefix) > 0 {
+		return engine.NewPrefixIterator(it, opts.Prefix, opts.Reverse)
+	}
+	return it
 }
 
 // iterator uses a goroutine to read from the tree on demand.
 type iterator struct {
-	tx      *transaction
-	reverse bool
-	tr      *btree.BTree
-	item    *item // current item
-	ch      chan *item
-	closed  chan struct{} // closed by the goroutine when it's shutdown
-	ctx     context.Context
-	cancel  func()
-	err     error
+	tx        *transaction
+	reverse   bool
+	readAhead int
+	tr        *btree.BTree
+	item      *item // current item
+	ch        chan *item
+	closed    chan struct{} // closed by the goroutine when it's shutdown
+	ctx       context.Context
+	cancel    func()
+	err       error
 }
 
 func (it *iterator) Seek(pivot []byte) {
+	select {
+	case <-it.tx.ctx.Done():
+		it.err = it.tx.ctx.Err()
+		return
+	default:
+	}
+
 	// make sure any opened goroutine
 	// is closed before creating a new one
 	if it.cancel != nil {
 		it.cancel()
-		it.ch = make(chan *item)
+		it.ch = make(chan *item, it.readAhead)
 		<-it.closed
 		it.closed = make(chan struct{})
 	}
@@ -296,9 +354,24 @@ func (it *iterator) Valid() bool {
 // Read the next item from the goroutine
 func (it *iterator) Next() {
 	select {
-	case it.item = <-it.ch:
 	case <-it.tx.ctx.Done():
 		it.err = it.tx.ctx.Err()
+		return
+	default:
+	}
+
+	for {
+		select {
+		case it.item = <-it.ch:
+		case <-it.tx.ctx.Done():
+			it.err = it.tx.ctx.Err()
+			return
+		}
+
+		// skip items deleted after being read by the goroutine
+		if it.item == nil || !it.item.deleted {
+			return
+		}
 	}
 }
 
@@ -0,0 +1,65 @@
+package engine
+
+import "bytes"
+
+// NewPrefixIterator wraps it to only return the keys starting with prefix.
+// It implements the Prefix option of IteratorOptions for engines that
+// don't support it natively. Reverse must match the direction of it.
+func NewPrefixIterator(it Iterator, prefix []byte, reverse bool) Iterator {
+	return &prefixIterator{
+		Iterator: it,
+		prefix:   prefix,
+		end:      prefixEnd(prefix),
+		reverse:  reverse,
+	}
+}
+
+type prefixIterator struct {
+	Iterator
+
+	prefix []byte
+	// smallest key greater than every key starting with prefix,
+	// nil if there is none.
+	end     []byte
+	reverse bool
+}
+
+func (it *prefixIterator) Seek(pivot []byte) {
+	if !it.reverse {
+		if bytes.Compare(pivot, it.prefix) < 0 {
+			pivot = it.prefix
+		}
+
+		it.Iterator.Seek(pivot)
+		return
+	}
+
+	if len(pivot) == 0 || (it.end != nil && bytes.Compare(pivot, it.end) >= 0) {
+		pivot = it.end
+	}
+
+	it.Iterator.Seek(pivot)
+	// the only key greater than the prefix that can be selected
+	// is end itself.
+	if pivot != nil && it.Iterator.Valid() && bytes.Equal(it.Iterator.Item().Key(), it.end) {
+		it.Iterator.Next()
+	}
+}
+
+func (it *prefixIterator) Valid() bool {
+	return it.Iterator.Valid() && bytes.HasPrefix(it.Iterator.Item().Key(), it.prefix)
+}
+
+// prefixEnd returns the smallest key greater than every key starting with prefix.
+// It returns nil if prefix only contains 0xFF bytes.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		end[i]++
+		if end[i] != 0 {
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
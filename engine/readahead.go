@@ -0,0 +1,100 @@
+package engine
+
+// NewReadAheadIterator wraps it to fetch up to n items ahead of the current one.
+// Keys and values are copied into a buffer in batches, which reduces the cost of each
+// call to Next for engines where reading items one by one is expensive.
+// It implements the ReadAhead option of IteratorOptions for engines that
+// don't support prefetching natively.
+func NewReadAheadIterator(it Iterator, n int) Iterator {
+	if n < 1 {
+		n = 1
+	}
+
+	return &readAheadIterator{
+		it:    it,
+		items: make([]readAheadItem, 0, n),
+	}
+}
+
+type readAheadIterator struct {
+	it    Iterator
+	items []readAheadItem
+	// position of the current item in items.
+	pos int
+	err error
+}
+
+// readAheadItem is a copy of an item of the underlying iterator.
+type readAheadItem struct {
+	key   []byte
+	value []byte
+}
+
+func (i *readAheadItem) Key() []byte {
+	return i.key
+}
+
+func (i *readAheadItem) ValueCopy(buf []byte) ([]byte, error) {
+	return append(buf[:0], i.value...), nil
+}
+
+func (it *readAheadIterator) Seek(pivot []byte) {
+	it.it.Seek(pivot)
+	it.fill()
+}
+
+func (it *readAheadIterator) Next() {
+	it.pos++
+	if it.pos < len(it.items) {
+		return
+	}
+
+	it.it.Next()
+	it.fill()
+}
+
+// fill copies the next items of the underlying iterator into the buffer,
+// starting with the current one.
+// The key and value slices of the previous items are reused.
+func (it *readAheadIterator) fill() {
+	it.pos = 0
+	it.items = it.items[:0]
+
+	for it.it.Valid() {
+		n := len(it.items)
+		it.items = it.items[:n+1]
+		item := &it.items[n]
+
+		cur := it.it.Item()
+		item.key = append(item.key[:0], cur.Key()...)
+		item.value, it.err = cur.ValueCopy(item.value[:0])
+		if it.err != nil {
+			it.items = it.items[:0]
+			return
+		}
+
+		if len(it.items) == cap(it.items) {
+			return
+		}
+
+		it.it.Next()
+	}
+
+	it.err = it.it.Err()
+}
+
+func (it *readAheadIterator) Err() error {
+	return it.err
+}
+
+func (it *readAheadIterator) Valid() bool {
+	return it.err == nil && it.pos < len(it.items)
+}
+
+func (it *readAheadIterator) Item() Item {
+	return &it.items[it.pos]
+}
+
+func (it *readAheadIterator) Close() error {
+	return it.it.Close()
+}
@@ -0,0 +1,53 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAheadIterator(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	defer ng.Close()
+
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{Writable: true})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, tx.CreateStore([]byte("test")))
+	st, err := tx.GetStore([]byte("test"))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, st.Put([]byte{byte(i)}, []byte{byte(i), 'v'}))
+	}
+
+	for _, size := range []int{0, 1, 3, 10, 20} {
+		it := engine.NewReadAheadIterator(st.Iterator(engine.IteratorOptions{}), size)
+
+		var keys, values [][]byte
+		for it.Seek([]byte{2}); it.Valid(); it.Next() {
+			keys = append(keys, append([]byte(nil), it.Item().Key()...))
+			v, err := it.Item().ValueCopy(nil)
+			require.NoError(t, err)
+			values = append(values, v)
+		}
+		require.NoError(t, it.Err())
+		require.Len(t, keys, 8)
+		for i := range keys {
+			require.Equal(t, []byte{byte(i + 2)}, keys[i])
+			require.Equal(t, []byte{byte(i + 2), 'v'}, values[i])
+		}
+
+		// seeking again resets the buffer
+		it.Seek([]byte{9})
+		require.True(t, it.Valid())
+		require.Equal(t, []byte{9}, it.Item().Key())
+		it.Next()
+		require.False(t, it.Valid())
+		require.NoError(t, it.Close())
+	}
+}
@@ -0,0 +1,274 @@
+// Package fulltext implements inverted indexes, used to search documents by the words of their texts.
+package fulltext
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/genjidb/genji/engine"
+)
+
+const (
+	// storePrefix is the prefix used to name the full-text index stores.
+	storePrefix = "f"
+)
+
+// An Index associates the terms of texts with the keys of the documents containing them.
+// Every entry of the store is made of a term, a zero byte and a key, and holds the number
+// of occurrences of the term in the text associated with the key.
+type Index struct {
+	tx        engine.Transaction
+	storeName []byte
+}
+
+// New creates a full-text index.
+func New(tx engine.Transaction, idxName string) *Index {
+	return &Index{
+		tx:        tx,
+		storeName: append([]byte(storePrefix), idxName...),
+	}
+}
+
+// Set indexes the terms of the text under the given key.
+func (idx *Index) Set(text string, k []byte) error {
+	if len(k) == 0 {
+		return errors.New("cannot index value without a key")
+	}
+
+	freqs := TermFrequencies(text)
+	if len(freqs) == 0 {
+		return nil
+	}
+
+	st, err := getOrCreateStore(idx.tx, idx.storeName)
+	if err != nil {
+		return err
+	}
+
+	for term, tf := range freqs {
+		v := make([]byte, binary.MaxVarintLen64)
+		n := binary.PutUvarint(v, uint64(tf))
+
+		err = st.Put(entryKey(term, k), v[:n])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the terms of the text indexed under the given key.
+func (idx *Index) Delete(text string, k []byte) error {
+	freqs := TermFrequencies(text)
+	if len(freqs) == 0 {
+		return nil
+	}
+
+	st, err := idx.tx.GetStore(idx.storeName)
+	if err == engine.ErrStoreNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for term := range freqs {
+		err = st.Delete(entryKey(term, k))
+		if err != nil && err != engine.ErrKeyNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Search looks for the keys associated with all the terms of the query
+// and calls fn for each of them, from the most to the least relevant.
+// The score of a key is the sum, for every term of the query, of the frequency of the term
+// in the indexed text weighted by the rarity of the term in the index.
+// If fn returns an error, the search stops and returns that error.
+func (idx *Index) Search(query string, fn func(k []byte, score float64) error) error {
+	terms := TermFrequencies(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	st, err := idx.tx.GetStore(idx.storeName)
+	if err == engine.ErrStoreNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	type result struct {
+		key     []byte
+		score   float64
+		matches int
+	}
+	results := make(map[string]*result)
+
+	for term := range terms {
+		postings, err := idx.postings(st, term)
+		if err != nil {
+			return err
+		}
+
+		idf := math.Log(1 + 1/float64(len(postings)))
+		for k, tf := range postings {
+			r, ok := results[k]
+			if !ok {
+				r = &result{key: []byte(k)}
+				results[k] = r
+			}
+
+			r.score += float64(tf) * idf
+			r.matches++
+		}
+	}
+
+	ranked := make([]*result, 0, len(results))
+	for _, r := range results {
+		if r.matches == len(terms) {
+			ranked = append(ranked, r)
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+
+		return bytes.Compare(ranked[i].key, ranked[j].key) < 0
+	})
+
+	for _, r := range ranked {
+		err = fn(r.key, r.score)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postings returns the frequencies of the term, indexed by key.
+func (idx *Index) postings(st engine.Store, term string) (map[string]uint64, error) {
+	prefix := entryKey(term, nil)
+	postings := make(map[string]uint64)
+
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var buf []byte
+	var err error
+	for it.Seek(prefix); it.Valid(); it.Next() {
+		itm := it.Item()
+		if !bytes.HasPrefix(itm.Key(), prefix) {
+			break
+		}
+
+		buf, err = itm.ValueCopy(buf[:0])
+		if err != nil {
+			return nil, err
+		}
+
+		tf, _ := binary.Uvarint(buf)
+		postings[string(itm.Key()[len(prefix):])] = tf
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return postings, nil
+}
+
+// Iterate calls fn for every entry of the index, with the term, the key associated with it
+// and the number of occurrences of the term in the text indexed under that key.
+// Entries are sorted by term, then by key.
+// If fn returns an error, the iteration stops and returns that error.
+func (idx *Index) Iterate(fn func(term string, k []byte, freq int) error) error {
+	st, err := idx.tx.GetStore(idx.storeName)
+	if err == engine.ErrStoreNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	it := st.Iterator(engine.IteratorOptions{})
+	defer it.Close()
+
+	var buf []byte
+	for it.Seek(nil); it.Valid(); it.Next() {
+		itm := it.Item()
+
+		buf, err = itm.ValueCopy(buf[:0])
+		if err != nil {
+			return err
+		}
+
+		key := itm.Key()
+		i := bytes.IndexByte(key, 0)
+		if i < 0 {
+			return errors.New("invalid full-text index entry")
+		}
+
+		tf, _ := binary.Uvarint(buf)
+		err = fn(string(key[:i]), key[i+1:], int(tf))
+		if err != nil {
+			return err
+		}
+	}
+
+	return it.Err()
+}
+
+// Truncate deletes all the index data.
+func (idx *Index) Truncate() error {
+	err := idx.tx.DropStore(idx.storeName)
+	if err != nil && err != engine.ErrStoreNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// termFrequencies returns the number of occurrences of each term of the text.
+func TermFrequencies(text string) map[string]int {
+	freqs := make(map[string]int)
+	for _, t := range Tokenize(text) {
+		freqs[t]++
+	}
+
+	return freqs
+}
+
+func entryKey(term string, k []byte) []byte {
+	buf := make([]byte, 0, len(term)+1+len(k))
+	buf = append(buf, term...)
+	buf = append(buf, 0)
+	return append(buf, k...)
+}
+
+func getOrCreateStore(tx engine.Transaction, name []byte) (engine.Store, error) {
+	st, err := tx.GetStore(name)
+	if err == nil {
+		return st, nil
+	}
+
+	if err != engine.ErrStoreNotFound {
+		return nil, err
+	}
+
+	err = tx.CreateStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.GetStore(name)
+}
@@ -0,0 +1,129 @@
+package fulltext_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/fulltext"
+	"github.com/stretchr/testify/require"
+)
+
+func getIndex(t testing.TB) (*fulltext.Index, func()) {
+	ng := memoryengine.NewEngine()
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{
+		Writable: true,
+	})
+	require.NoError(t, err)
+
+	idx := fulltext.New(tx, "foo")
+
+	return idx, func() {
+		tx.Rollback()
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"The quick, brown FOX!", []string{"quick", "brown", "fox"}},
+		{"indexes indexing indexed index", []string{"index", "index", "index", "index"}},
+		{"running classes databases", []string{"run", "class", "database"}},
+		{"l'été 2021", []string{"l", "été", "2021"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			require.Equal(t, test.expected, fulltext.Tokenize(test.text))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		text, query string
+		expected    bool
+	}{
+		{"Indexing documents", "document", true},
+		{"Indexing documents", "documents indexes", true},
+		{"Indexing documents", "documents fields", false},
+		{"Indexing documents", "", false},
+		{"Indexing documents", "the", false},
+		{"", "document", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text+" / "+test.query, func(t *testing.T) {
+			require.Equal(t, test.expected, fulltext.Match(test.text, test.query))
+		})
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	search := func(t *testing.T, idx *fulltext.Index, q string) []string {
+		keys := []string{}
+		err := idx.Search(q, func(k []byte, score float64) error {
+			require.Greater(t, score, 0.0)
+			keys = append(keys, string(k))
+			return nil
+		})
+		require.NoError(t, err)
+		return keys
+	}
+
+	t.Run("Empty index", func(t *testing.T) {
+		idx, cleanup := getIndex(t)
+		defer cleanup()
+
+		require.Empty(t, search(t, idx, "foo"))
+	})
+
+	t.Run("Set nil key fails", func(t *testing.T) {
+		idx, cleanup := getIndex(t)
+		defer cleanup()
+
+		require.Error(t, idx.Set("foo", nil))
+	})
+
+	t.Run("Ranked", func(t *testing.T) {
+		idx, cleanup := getIndex(t)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("a red apple", []byte("a")))
+		require.NoError(t, idx.Set("red apples and red cherries", []byte("b")))
+		require.NoError(t, idx.Set("a green apple", []byte("c")))
+		require.NoError(t, idx.Set("cherries", []byte("d")))
+
+		require.Equal(t, []string{"b", "a"}, search(t, idx, "red"))
+		require.Equal(t, []string{"a", "b", "c"}, search(t, idx, "apple"))
+		require.Equal(t, []string{"b", "a"}, search(t, idx, "Red apples"))
+		require.Equal(t, []string{"b"}, search(t, idx, "red cherry"))
+		require.Empty(t, search(t, idx, "green cherry"))
+		require.Empty(t, search(t, idx, "and"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		idx, cleanup := getIndex(t)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("a red apple", []byte("a")))
+		require.NoError(t, idx.Set("a red cherry", []byte("b")))
+		require.NoError(t, idx.Delete("a red apple", []byte("a")))
+
+		require.Equal(t, []string{"b"}, search(t, idx, "red"))
+		require.Empty(t, search(t, idx, "apple"))
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		idx, cleanup := getIndex(t)
+		defer cleanup()
+
+		require.NoError(t, idx.Set("a red apple", []byte("a")))
+		require.NoError(t, idx.Truncate())
+		require.Empty(t, search(t, idx, "apple"))
+	})
+}
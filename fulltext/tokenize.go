@@ -0,0 +1,139 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are common english words that are not indexed.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "such": true, "that": true, "the": true, "their": true,
+	"then": true, "there": true, "these": true, "they": true, "this": true, "to": true,
+	"was": true, "will": true, "with": true,
+}
+
+// Tokenize splits a text into the list of terms stored in full-text indexes.
+// Words are sequences of letters and digits, they are lowercased and stemmed.
+// Stop words are ignored.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.ToLower(w)
+		if stopWords[w] {
+			continue
+		}
+
+		terms = append(terms, Stem(w))
+	}
+
+	return terms
+}
+
+// suffixes removed by Stem, in the order they are tried.
+// The replacement is appended to the word once the suffix is removed.
+var suffixes = []struct {
+	suffix, replacement string
+}{
+	{"ational", "ate"},
+	{"fulness", "ful"},
+	{"iveness", "ive"},
+	{"ization", "ize"},
+	{"ousness", "ous"},
+	{"ations", "ate"},
+	{"ation", "ate"},
+	{"ities", ""},
+	{"sses", "ss"},
+	{"ies", "y"},
+	{"ing", ""},
+	{"ity", ""},
+	{"ed", ""},
+	{"ly", ""},
+	{"es", ""},
+	{"s", ""},
+}
+
+// Stem reduces an english word to its stem by removing the most common suffixes,
+// so that different forms of a word, like "index", "indexes" and "indexing", match each other.
+// Stems are not always words: the same rules are applied to indexed texts and queries.
+func Stem(word string) string {
+	// short words are kept as is
+	if len(word) <= 3 {
+		return word
+	}
+
+	for _, s := range suffixes {
+		if !strings.HasSuffix(word, s.suffix) {
+			continue
+		}
+
+		stem := word[:len(word)-len(s.suffix)]
+		// the stem must keep at least 3 letters, and a vowel
+		if len(stem) < 3 || !strings.ContainsAny(stem, "aeiouy") {
+			continue
+		}
+
+		// words ending with a double "s", like "class", are not plurals
+		if s.suffix == "s" && strings.HasSuffix(stem, "s") {
+			return word
+		}
+
+		// "es" is only the plural of words like "boxes" or "matches",
+		// other words only lose the final "s", like "databases"
+		if s.suffix == "es" && !hasAnySuffix(stem, "x", "z", "ch", "sh") {
+			continue
+		}
+
+		stem += s.replacement
+
+		// remove the doubled consonant of words like "running"
+		if (s.suffix == "ing" || s.suffix == "ed") && len(stem) > 3 {
+			n := len(stem)
+			if stem[n-1] == stem[n-2] && !strings.ContainsAny(stem[n-1:], "aeiouyslz") {
+				stem = stem[:n-1]
+			}
+		}
+
+		return stem
+	}
+
+	return word
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match returns true if the text contains all the terms of the query.
+// A query without terms doesn't match any text.
+func Match(text, query string) bool {
+	q := Tokenize(query)
+	if len(q) == 0 {
+		return false
+	}
+
+	terms := make(map[string]bool)
+	for _, t := range Tokenize(text) {
+		terms[t] = true
+	}
+
+	for _, t := range q {
+		if !terms[t] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/engine"
@@ -18,8 +19,10 @@ const (
 var valueTypes = []document.ValueType{
 	document.NullValue,
 	document.BoolValue,
+	document.TimestampValue,
 	document.IntegerValue,
 	document.DoubleValue,
+	document.DecimalValue,
 	document.TextValue,
 	document.BlobValue,
 	document.ArrayValue,
@@ -33,9 +36,16 @@ var (
 
 // An Index associates encoded values with keys.
 // It is sorted by value following the lexicographic order.
+//
+// Untyped indexes prefix every value with its type, null values
+// are thus stored before any other value. Typed indexes don't store
+// null values: since they can't be compared with any other value,
+// they can't be selected by a range scan and are simply ignored.
 type Index struct {
 	Unique bool
 	Type   document.ValueType
+	// number of items fetched ahead by the iterators of range scans.
+	ReadAhead int
 
 	tx        engine.Transaction
 	storeName []byte
@@ -71,6 +81,10 @@ func (idx *Index) Set(v document.Value, k []byte) error {
 		return errors.New("cannot index value without a key")
 	}
 
+	if idx.Type != 0 && v.Type == document.NullValue {
+		return nil
+	}
+
 	if idx.Type != 0 && idx.Type != v.Type {
 		return fmt.Errorf("cannot index value of type %s in %s index", v.Type, idx.Type)
 	}
@@ -127,6 +141,10 @@ func (idx *Index) Set(v document.Value, k []byte) error {
 
 // Delete all the references to the key from the index.
 func (idx *Index) Delete(v document.Value, k []byte) error {
+	if idx.Type != 0 && v.Type == document.NullValue {
+		return nil
+	}
+
 	st, err := getOrCreateStore(idx.tx, idx.storeName)
 	if err != nil {
 		return nil
@@ -186,12 +204,25 @@ func (idx *Index) iterateOnStore(pivot document.Value, reverse bool, fn func(val
 		return nil
 	}
 
-	var enc []byte
+	var enc, nan []byte
 	if pivot.V != nil {
+		// NaN is not equal, lesser or greater than any value, including itself:
+		// range scans never select it.
+		if pivot.Type == document.DoubleValue && math.IsNaN(pivot.V.(float64)) {
+			return nil
+		}
+
 		enc, err = idx.EncodeValue(pivot)
 		if err != nil {
 			return err
 		}
+
+		if idx.Type == 0 || idx.Type == document.DoubleValue {
+			nan, err = idx.EncodeValue(document.NewDoubleValue(math.NaN()))
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	var buf []byte
@@ -207,6 +238,10 @@ func (idx *Index) iterateOnStore(pivot document.Value, reverse bool, fn func(val
 			k = k[:len(k)-int(n)-1]
 		}
 
+		if nan != nil && bytes.Equal(k, nan) {
+			return nil
+		}
+
 		buf, err = item.ValueCopy(buf[:0])
 		if err != nil {
 			return err
@@ -243,6 +278,17 @@ func (idx *Index) EncodeValue(v document.Value) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// DecodeValue decodes a value encoded by EncodeValue.
+func (idx *Index) DecodeValue(data []byte) (document.Value, error) {
+	if idx.Type != 0 {
+		v := document.Value{Type: idx.Type}
+		err := v.UnmarshalBinary(data)
+		return v, err
+	}
+
+	return document.DecodeValue(data)
+}
+
 func getOrCreateStore(tx engine.Transaction, name []byte) (engine.Store, error) {
 	st, err := tx.GetStore(name)
 	if err == nil {
@@ -276,7 +322,10 @@ func (idx *Index) iterate(st engine.Store, pivot document.Value, reverse bool, f
 		}
 	}
 
-	if pivot.Type != 0 && pivot.V == nil {
+	// values of untyped indexes are prefixed by their type,
+	// seek the first value of the pivot type.
+	// typed indexes don't encode types, they are iterated from the start.
+	if idx.Type == 0 && pivot.Type != 0 && pivot.V == nil {
 		seek = []byte{byte(pivot.Type)}
 
 		if reverse {
@@ -284,7 +333,7 @@ func (idx *Index) iterate(st engine.Store, pivot document.Value, reverse bool, f
 		}
 	}
 
-	it := st.Iterator(engine.IteratorOptions{Reverse: reverse})
+	it := st.Iterator(engine.IteratorOptions{Reverse: reverse, ReadAhead: idx.ReadAhead})
 	defer it.Close()
 
 	for it.Seek(seek); it.Valid(); it.Next() {
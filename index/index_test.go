@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"testing"
 
@@ -66,6 +67,41 @@ func TestIndexSet(t *testing.T) {
 		require.NoError(t, idx.Set(document.NewIntegerValue(11), []byte("key")))
 		require.Equal(t, index.ErrDuplicate, idx.Set(document.NewIntegerValue(10), []byte("key")))
 	})
+
+	t.Run("Type: integer, null values are ignored", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false)
+		idx.Type = document.IntegerValue
+		defer cleanup()
+
+		require.NoError(t, idx.Set(document.NewNullValue(), []byte("key1")))
+		require.NoError(t, idx.Set(document.NewIntegerValue(10), []byte("key2")))
+		require.NoError(t, idx.Delete(document.NewNullValue(), []byte("key1")))
+
+		var keys []string
+		err := idx.AscendGreaterOrEqual(document.Value{}, func(v, k []byte, isEqual bool) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"key2"}, keys)
+	})
+
+	t.Run("Untyped, null values are stored first", func(t *testing.T) {
+		idx, cleanup := getIndex(t, false)
+		defer cleanup()
+
+		require.NoError(t, idx.Set(document.NewIntegerValue(-10), []byte("key1")))
+		require.NoError(t, idx.Set(document.NewNullValue(), []byte("key2")))
+		require.NoError(t, idx.Set(document.NewBoolValue(false), []byte("key3")))
+
+		var keys []string
+		err := idx.AscendGreaterOrEqual(document.Value{}, func(v, k []byte, isEqual bool) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"key2", "key3", "key1"}, keys)
+	})
 }
 
 func TestIndexDelete(t *testing.T) {
@@ -330,6 +366,56 @@ func TestIndexAscendGreaterThan(t *testing.T) {
 	})
 }
 
+func TestIndexDoubleEdgeCases(t *testing.T) {
+	for _, unique := range []bool{true, false} {
+		t.Run(fmt.Sprintf("Unique: %v", unique), func(t *testing.T) {
+			idx, cleanup := getIndex(t, unique)
+			defer cleanup()
+
+			require.NoError(t, idx.Set(document.NewDoubleValue(math.NaN()), []byte("nan")))
+			require.NoError(t, idx.Set(document.NewDoubleValue(math.Inf(1)), []byte("inf")))
+			require.NoError(t, idx.Set(document.NewDoubleValue(math.Copysign(0, -1)), []byte("-zero")))
+			require.NoError(t, idx.Set(document.NewDoubleValue(-1), []byte("neg")))
+
+			collect := func(pivot document.Value, reverse bool) []string {
+				var keys []string
+				fn := func(val, key []byte, isEqual bool) error {
+					keys = append(keys, string(key))
+					return nil
+				}
+
+				var err error
+				if reverse {
+					err = idx.DescendLessOrEqual(pivot, fn)
+				} else {
+					err = idx.AscendGreaterOrEqual(pivot, fn)
+				}
+				require.NoError(t, err)
+				return keys
+			}
+
+			// NaN is sorted after every other double
+			require.Equal(t, []string{"neg", "-zero", "inf", "nan"}, collect(document.Value{Type: document.DoubleValue}, false))
+
+			// range scans never select NaN
+			require.Equal(t, []string{"-zero", "inf"}, collect(document.NewDoubleValue(0), false))
+			require.Empty(t, collect(document.NewDoubleValue(math.NaN()), false))
+			require.Empty(t, collect(document.NewDoubleValue(math.NaN()), true))
+
+			// -0.0 and +0.0 are equal
+			var equal int
+			err := idx.AscendGreaterOrEqual(document.NewDoubleValue(0), func(val, key []byte, isEqual bool) error {
+				if isEqual {
+					equal++
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, 1, equal)
+		})
+	}
+}
+
 func TestIndexDescendLessOrEqual(t *testing.T) {
 	for _, unique := range []bool{true, false} {
 		text := fmt.Sprintf("Unique: %v, ", unique)
@@ -425,6 +511,31 @@ func TestIndexDescendLessOrEqual(t *testing.T) {
 	}
 }
 
+func TestIndexDecodeValue(t *testing.T) {
+	ng := memoryengine.NewEngine()
+	tx, err := ng.Begin(context.Background(), engine.TxOptions{
+		Writable: true,
+	})
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	for _, typ := range []document.ValueType{0, document.TextValue} {
+		idx := index.New(tx, "foo"+typ.String(), index.Options{Type: typ})
+		require.NoError(t, idx.Set(document.NewTextValue("hello"), []byte("a")))
+
+		var count int
+		err = idx.AscendGreaterOrEqual(document.Value{Type: document.TextValue}, func(val, key []byte, isEqual bool) error {
+			count++
+			v, err := idx.DecodeValue(val)
+			require.NoError(t, err)
+			require.Equal(t, document.NewTextValue("hello"), v)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	}
+}
+
 // BenchmarkIndexSet benchmarks the Set method with 1, 10, 1000 and 10000 successive insertions.
 func BenchmarkIndexSet(b *testing.B) {
 	for size := 10; size <= 10000; size *= 10 {
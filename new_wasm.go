@@ -17,6 +17,8 @@ func New(ctx context.Context, ng engine.Engine) (*DB, error) {
 		return nil, err
 	}
 
+	db.RunTrigger = runTrigger
+
 	return &DB{
 		DB:  db,
 		ctx: context.Background(),
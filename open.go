@@ -4,6 +4,8 @@ package genji
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
 
 	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/engine/boltengine"
@@ -13,6 +15,8 @@ import (
 // Open creates a Genji database at the given path.
 // If path is equal to ":memory:" it will open an in-memory database,
 // otherwise it will create an on-disk database using the BoltDB engine.
+// On-disk databases spill the temporary stores of read-only transactions
+// to temporary files.
 func Open(path string) (*DB, error) {
 	var ng engine.Engine
 	var err error
@@ -28,5 +32,54 @@ func Open(path string) (*DB, error) {
 	}
 
 	ctx := context.Background()
-	return New(ctx, ng)
+	db, err := New(ctx, ng)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != ":memory:" {
+		db.DB.NewTempEngine = newTempBoltEngine
+	}
+
+	return db, nil
+}
+
+// tempBoltEngine is a BoltDB engine whose file is removed when it is closed.
+type tempBoltEngine struct {
+	*boltengine.Engine
+
+	path string
+}
+
+func newTempBoltEngine() (engine.Engine, error) {
+	f, err := ioutil.TempFile("", "genji-tmp-*.db")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+
+	err = f.Close()
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	ng, err := boltengine.NewEngine(path, 0600, nil)
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	return &tempBoltEngine{Engine: ng, path: path}, nil
+}
+
+// Close the engine and remove its file.
+func (ng *tempBoltEngine) Close() error {
+	err := ng.Engine.Close()
+	rerr := os.Remove(ng.path)
+	if err != nil {
+		return err
+	}
+
+	return rerr
 }
@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"io"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/genjidb/genji"
@@ -83,6 +85,9 @@ func (c *connector) Close() error {
 type conn struct {
 	db *genji.DB
 	tx *genji.Tx
+	// true if tx was started by a BEGIN statement,
+	// false if it was started by BeginTx.
+	sqlTx bool
 }
 
 // Prepare returns a prepared statement, bound to this connection.
@@ -92,15 +97,17 @@ func (c *conn) Prepare(q string) (driver.Stmt, error) {
 
 // PrepareContext returns a prepared statement, bound to this connection.
 func (c *conn) PrepareContext(ctx context.Context, q string) (driver.Stmt, error) {
-	pq, err := parser.ParseQuery(q)
+	pq, err := parser.NewParserWithOptions(strings.NewReader(q), &parser.Options{
+		Functions: expr.NewFunctionsFor(c.db.DB),
+	}).ParseQuery()
 	if err != nil {
 		return nil, err
 	}
 
 	return stmt{
-		db: c.db,
-		tx: c.tx,
-		q:  pq,
+		db:   c.db,
+		conn: c,
+		q:    pq,
 	}, nil
 }
 
@@ -126,6 +133,10 @@ func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, e
 		return nil, errors.New("isolation levels are not supported")
 	}
 
+	if c.tx != nil {
+		return nil, errors.New("cannot begin a transaction within a transaction")
+	}
+
 	db := c.db.WithContext(ctx)
 
 	// if the ReadOnly flag is explicitly specified, create a read-only transaction,
@@ -148,12 +159,50 @@ func (c *conn) Rollback() error {
 	return err
 }
 
+// runTxStmt runs the BEGIN, COMMIT and ROLLBACK statements, which control
+// the transaction of the connection. The statements run by the connection
+// use that transaction until it is committed or rolled back.
+// It returns false if stmt is not one of these statements.
+// COMMIT and ROLLBACK statements run without a transaction started by BEGIN
+// are run by the database, to end the transaction attached to it, if any.
+func (c *conn) runTxStmt(ctx context.Context, stmt query.Statement) (bool, error) {
+	switch t := stmt.(type) {
+	case query.BeginStmt:
+		if c.tx != nil {
+			return true, errors.New("cannot begin a transaction within a transaction")
+		}
+
+		var err error
+		c.tx, err = c.db.WithContext(ctx).Begin(t.Writable)
+		c.sqlTx = err == nil
+		return true, err
+	case query.CommitStmt, query.RollbackStmt:
+		if c.tx == nil {
+			return false, nil
+		}
+
+		if !c.sqlTx {
+			return true, errors.New("cannot end a transaction started with BeginTx, use Commit or Rollback instead")
+		}
+
+		tx := c.tx
+		c.tx = nil
+		c.sqlTx = false
+		if _, ok := t.(query.CommitStmt); ok {
+			return true, tx.Commit()
+		}
+		return true, tx.Rollback()
+	}
+
+	return false, nil
+}
+
 // Stmt is a prepared statement. It is bound to a Conn and not
 // used by multiple goroutines concurrently.
 type stmt struct {
-	db *genji.DB
-	tx *genji.Tx
-	q  query.Query
+	db   *genji.DB
+	conn *conn
+	q    query.Query
 }
 
 // NumInput returns the number of placeholder parameters.
@@ -196,17 +245,7 @@ func (s stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver
 	default:
 	}
 
-	var res *query.Result
-	var err error
-
-	// if calling ExecContext within a transaction, use it,
-	// otherwise use DB.
-	if s.tx != nil {
-		res, err = s.q.Exec(s.tx.Transaction, driverNamedValueToParams(args))
-	} else {
-		res, err = s.q.Run(ctx, s.db.DB, driverNamedValueToParams(args))
-	}
-
+	res, err := s.run(ctx, driverNamedValueToParams(args))
 	if err != nil {
 		return nil, err
 	}
@@ -221,10 +260,18 @@ type result struct {
 	*query.Result
 }
 
-// LastInsertId is not supported and returns an error.
-// Use LastInsertKey instead.
+// LastInsertId returns the primary key of the last inserted document,
+// or its generated key if the table has no primary key.
+// It returns an error if no document was inserted or if the key is not an integer.
 func (r result) LastInsertId() (int64, error) {
-	return driver.RowsAffected(r.Result.RowsAffected).LastInsertId()
+	switch r.Result.LastInsertPK.Type {
+	case 0:
+		return 0, errors.New("no document was inserted")
+	case document.IntegerValue:
+		return r.Result.LastInsertPK.V.(int64), nil
+	}
+
+	return 0, fmt.Errorf("last inserted key is not an integer: %s", r.Result.LastInsertPK)
 }
 
 // RowsAffected returns the number of rows affected by the
@@ -246,17 +293,7 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	default:
 	}
 
-	var res *query.Result
-	var err error
-
-	// if calling QueryContext within a transaction, use it,
-	// otherwise use DB.
-	if s.tx != nil {
-		res, err = s.q.Exec(s.tx.Transaction, driverNamedValueToParams(args))
-	} else {
-		res, err = s.q.Run(ctx, s.db.DB, driverNamedValueToParams(args))
-	}
-
+	res, err := s.run(ctx, driverNamedValueToParams(args))
 	if err != nil {
 		return nil, err
 	}
@@ -266,14 +303,23 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 		return rs, nil
 	}
 
-	lastStmt := s.q.Statements[len(s.q.Statements)-1]
-
-	tree, ok := lastStmt.(*planner.Tree)
-	if !ok {
-		return rs, nil
+	var pn *planner.ProjectionNode
+	switch t := s.q.Statements[len(s.q.Statements)-1].(type) {
+	case *planner.Tree:
+		// the projection node can be followed by nodes that don't
+		// change the selected fields, like limit or sort nodes.
+		for n := t.Root; n != nil; n = n.Left() {
+			if p, ok := n.(*planner.ProjectionNode); ok {
+				pn = p
+				break
+			}
+		}
+	case *planner.ReturningStmt:
+		// INSERT, UPDATE and DELETE statements with a RETURNING clause
+		pn = t.Projection
 	}
 
-	if pn, ok := tree.Root.(*planner.ProjectionNode); ok && len(pn.Expressions) > 0 {
+	if pn != nil && len(pn.Expressions) > 0 {
 		rs.fields = make([]string, len(pn.Expressions))
 		for i := range pn.Expressions {
 			rs.fields[i] = pn.Expressions[i].Name()
@@ -283,6 +329,67 @@ func (s stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (drive
 	return rs, nil
 }
 
+// run executes the statements of the query and returns the result of the last one.
+// The statements are run within the transaction of the connection, if any, otherwise using DB.
+func (s stmt) run(ctx context.Context, params []expr.Param) (*query.Result, error) {
+	if !s.hasTxStmt() {
+		return s.runQuery(ctx, s.q, params)
+	}
+
+	// the statements are run one by one, as the transaction
+	// of the connection changes with BEGIN, COMMIT and ROLLBACK statements
+	var res *query.Result
+	for _, st := range s.q.Statements {
+		// the result of the previous statement is closed to release its transaction
+		if res != nil {
+			err := res.Close()
+			if err != nil {
+				return nil, err
+			}
+			res = nil
+		}
+
+		ok, err := s.conn.runTxStmt(ctx, st)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			continue
+		}
+
+		res, err = s.runQuery(ctx, query.New(st), params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if res == nil {
+		res = new(query.Result)
+	}
+
+	return res, nil
+}
+
+func (s stmt) runQuery(ctx context.Context, q query.Query, params []expr.Param) (*query.Result, error) {
+	if s.conn.tx != nil {
+		return q.Exec(s.conn.tx.Transaction, params)
+	}
+
+	return q.Run(ctx, s.db.DB, params)
+}
+
+// hasTxStmt returns true if the query contains a BEGIN, COMMIT or ROLLBACK statement.
+func (s stmt) hasTxStmt() bool {
+	for _, st := range s.q.Statements {
+		switch st.(type) {
+		case query.BeginStmt, query.CommitStmt, query.RollbackStmt:
+			return true
+		}
+	}
+
+	return false
+}
+
 func driverNamedValueToParams(args []driver.NamedValue) []expr.Param {
 	params := make([]expr.Param, len(args))
 	for i, arg := range args {
@@ -306,6 +413,8 @@ type documentStream struct {
 	c        chan doc
 	wg       sync.WaitGroup
 	fields   []string
+	// buffer reused to read the values of rows
+	row []document.Value
 }
 
 type doc struct {
@@ -358,11 +467,12 @@ func (rs *documentStream) iterate(ctx context.Context) {
 	if err == errStop || err == nil {
 		return
 	}
-	if err != nil {
-		rs.c <- doc{
-			err: err,
-		}
-		return
+
+	select {
+	case <-ctx.Done():
+	case rs.c <- doc{
+		err: err,
+	}:
 	}
 }
 
@@ -371,9 +481,12 @@ func (rs *documentStream) Columns() []string {
 	return rs.fields
 }
 
-// Close closes the rows iterator.
+// Close stops the iteration and waits for it to return
+// before closing the result, which releases the underlying transaction
+// if it is owned by the result.
 func (rs *documentStream) Close() error {
 	rs.cancelFn()
+	rs.wg.Wait()
 	return rs.res.Close()
 }
 
@@ -389,24 +502,62 @@ func (rs *documentStream) Next(dest []driver.Value) error {
 		return doc.err
 	}
 
+	return rs.scan(doc.d, dest)
+}
+
+// scan fills dest with the values of d.
+// If d is a row returned by a projection, its values are read by position,
+// otherwise every field is looked up by name.
+func (rs *documentStream) scan(d document.Document, dest []driver.Value) error {
+	if r, ok := d.(planner.Row); ok {
+		var err error
+		rs.row, err = r.Values(rs.row[:0])
+		if err != nil {
+			return err
+		}
+
+		if len(rs.row) == len(rs.fields) {
+			for i := range rs.row {
+				dest[i] = driverValue(rs.row[i])
+			}
+
+			return nil
+		}
+	}
+
+	return rs.scanFields(d, dest)
+}
+
+func (rs *documentStream) scanFields(d document.Document, dest []driver.Value) error {
 	for i := range rs.fields {
 		if rs.fields[i] == "*" {
-			dest[i] = doc.d
+			dest[i] = d
 
 			continue
 		}
 
-		f, err := doc.d.GetByField(rs.fields[i])
+		f, err := d.GetByField(rs.fields[i])
 		if err != nil {
 			return err
 		}
 
-		dest[i] = f.V
+		dest[i] = driverValue(f)
 	}
 
 	return nil
 }
 
+// driverValue returns the underlying value of v.
+// Decimals are returned as text, which can be scanned
+// into strings or back into decimals without losing precision.
+func driverValue(v document.Value) driver.Value {
+	if v.Type == document.DecimalValue {
+		return v.V.(document.Decimal).String()
+	}
+
+	return v.V
+}
+
 type valueScanner struct {
 	v interface{}
 }
@@ -3,9 +3,17 @@ package driver
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/boltengine"
 	"github.com/stretchr/testify/require"
 )
 
@@ -153,6 +161,20 @@ func TestDriver(t *testing.T) {
 		require.Equal(t, 10, count)
 	})
 
+	t.Run("Read your writes in transaction", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, err = tx.Exec("INSERT INTO test (a, b, c) VALUES (100, [], {})")
+		require.NoError(t, err)
+
+		var count int
+		err = tx.QueryRow("SELECT COUNT(*) FROM test WHERE a = 100").Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
 	t.Run("Multiple queries", func(t *testing.T) {
 		rows, err := db.Query(`
 			SELECT * FROM test;;;
@@ -212,3 +234,393 @@ func TestDriver(t *testing.T) {
 		require.Equal(t, err, engine.ErrTransactionReadOnly)
 	})
 }
+
+func TestDriverLimitReleasesTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genji")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ng, err := boltengine.NewEngine(filepath.Join(dir, "test.db"), 0600, nil)
+	require.NoError(t, err)
+
+	gdb, err := genji.New(context.Background(), ng)
+	require.NoError(t, err)
+
+	db := sql.OpenDB(&connector{db: gdb, driver: sqlDriver{}})
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test")
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		_, err = db.Exec("INSERT INTO test (a) VALUES (?)", i)
+		require.NoError(t, err)
+	}
+
+	// exec fails the test if the database is still locked
+	// by the transaction of a previous query.
+	exec := func(t *testing.T) {
+		done := make(chan error)
+		go func() {
+			_, err := db.Exec("INSERT INTO test (a) VALUES (1000)")
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("transaction not released")
+		}
+	}
+
+	t.Run("Fully consumed", func(t *testing.T) {
+		rows, err := db.QueryContext(context.Background(), "SELECT a FROM test LIMIT 5")
+		require.NoError(t, err)
+
+		var count int
+		for rows.Next() {
+			count++
+		}
+		require.NoError(t, rows.Err())
+		require.Equal(t, 5, count)
+
+		exec(t)
+	})
+
+	t.Run("Partially consumed", func(t *testing.T) {
+		rows, err := db.QueryContext(context.Background(), "SELECT a FROM test LIMIT 5")
+		require.NoError(t, err)
+
+		require.True(t, rows.Next())
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Close())
+
+		exec(t)
+	})
+
+	t.Run("Read only transaction", func(t *testing.T) {
+		tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		require.NoError(t, err)
+
+		rows, err := tx.Query("SELECT a FROM test LIMIT 5")
+		require.NoError(t, err)
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Close())
+		require.Equal(t, 1, ng.DB.Stats().OpenTxN)
+
+		require.NoError(t, tx.Rollback())
+		require.Equal(t, 0, ng.DB.Stats().OpenTxN)
+
+		exec(t)
+	})
+}
+
+func TestDriverPreparedPagination(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test; INSERT INTO test (a) VALUES (1), (2), (3), (4), (5)")
+	require.NoError(t, err)
+
+	stmt, err := db.Prepare("SELECT a FROM test ORDER BY a DESC LIMIT ? OFFSET ?")
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	// the pagination window is recomputed every time the statement is run
+	tests := []struct {
+		limit, offset int
+		expected      []int
+	}{
+		{2, 0, []int{5, 4}},
+		{1, 3, []int{2}},
+		{3, 1, []int{4, 3, 2}},
+		{0, 0, nil},
+		{10, 2, []int{3, 2, 1}},
+	}
+
+	for _, test := range tests {
+		rows, err := stmt.Query(test.limit, test.offset)
+		require.NoError(t, err)
+
+		var got []int
+		for rows.Next() {
+			var a int
+			require.NoError(t, rows.Scan(&a))
+			got = append(got, a)
+		}
+		require.NoError(t, rows.Err())
+		require.NoError(t, rows.Close())
+		require.Equal(t, test.expected, got)
+	}
+
+	_, err = stmt.Query(-1, 0)
+	require.EqualError(t, err, "limit expression must evaluate to a non-negative number, got -1")
+}
+
+func TestDriverNamedParams(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test")
+	require.NoError(t, err)
+
+	// the order of the arguments doesn't matter
+	_, err = db.Exec("INSERT INTO test (a, b) VALUES ($a, :b)", sql.Named("b", "foo"), sql.Named("a", 1))
+	require.NoError(t, err)
+
+	stmt, err := db.Prepare("SELECT b FROM test WHERE a = :a AND b = $b")
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	var b string
+	err = stmt.QueryRow(sql.Named("b", "foo"), sql.Named("a", 1)).Scan(&b)
+	require.NoError(t, err)
+	require.Equal(t, "foo", b)
+
+	err = stmt.QueryRow(sql.Named("b", "bar"), sql.Named("a", 1)).Scan(&b)
+	require.Equal(t, sql.ErrNoRows, err)
+
+	err = stmt.QueryRow(sql.Named("a", 1)).Scan(&b)
+	require.EqualError(t, err, "param b not found")
+}
+
+func TestDriverTimestamps(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(ts TIMESTAMP)")
+	require.NoError(t, err)
+
+	ts := time.Date(2021, 1, 2, 15, 4, 5, 123456789, time.FixedZone("", 3600))
+	_, err = db.Exec("INSERT INTO test (ts) VALUES (?), ('2021-01-01T00:00:00Z')", ts)
+	require.NoError(t, err)
+
+	var got time.Time
+	err = db.QueryRow("SELECT ts FROM test WHERE ts > ?", "2021-01-02T00:00:00Z").Scan(&got)
+	require.NoError(t, err)
+	// timestamps are returned in UTC, truncated to the microsecond
+	require.Equal(t, ts.UTC().Truncate(time.Microsecond), got)
+
+	var s string
+	err = db.QueryRow("SELECT ts FROM test WHERE ts < ?", ts).Scan(&s)
+	require.NoError(t, err)
+	require.Equal(t, "2021-01-01T00:00:00Z", s)
+}
+
+func TestDriverLastInsertId(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT, a TEXT); CREATE TABLE foo(a TEXT PRIMARY KEY)")
+	require.NoError(t, err)
+
+	res, err := db.Exec("INSERT INTO test (a) VALUES ('a'), ('b')")
+	require.NoError(t, err)
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, id)
+
+	res, err = db.Exec("INSERT INTO test (id, a) VALUES (10, 'c')")
+	require.NoError(t, err)
+	id, err = res.LastInsertId()
+	require.NoError(t, err)
+	require.EqualValues(t, 10, id)
+
+	res, err = db.Exec("INSERT INTO foo (a) VALUES ('a')")
+	require.NoError(t, err)
+	_, err = res.LastInsertId()
+	require.Error(t, err)
+
+	res, err = db.Exec("UPDATE test SET a = 'd'")
+	require.NoError(t, err)
+	_, err = res.LastInsertId()
+	require.Error(t, err)
+
+	// last_insert_key() returns the key inserted by the same transaction
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO test (id, a) VALUES (20, 'e')")
+	require.NoError(t, err)
+
+	var key int
+	err = tx.QueryRow("SELECT last_insert_key()").Scan(&key)
+	require.NoError(t, err)
+	require.Equal(t, 20, key)
+}
+
+func TestDriverReturning(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test(id INTEGER PRIMARY KEY, a TEXT)")
+	require.NoError(t, err)
+
+	type row struct {
+		ID int
+		A  string
+	}
+
+	scan := func(q string) ([]string, []row) {
+		rows, err := db.Query(q)
+		require.NoError(t, err)
+		defer rows.Close()
+
+		cols, err := rows.Columns()
+		require.NoError(t, err)
+
+		var res []row
+		for rows.Next() {
+			var r row
+			require.NoError(t, rows.Scan(&r.ID, &r.A))
+			res = append(res, r)
+		}
+		require.NoError(t, rows.Err())
+		return cols, res
+	}
+
+	cols, rows := scan("INSERT INTO test (id, a) VALUES (1, 'a'), (2, 'b') RETURNING id, a")
+	require.Equal(t, []string{"id", "a"}, cols)
+	require.Equal(t, []row{{1, "a"}, {2, "b"}}, rows)
+
+	cols, rows = scan("UPDATE test SET a = 'c' WHERE id = 2 RETURNING id AS k, a")
+	require.Equal(t, []string{"k", "a"}, cols)
+	require.Equal(t, []row{{2, "c"}}, rows)
+
+	cols, rows = scan("DELETE FROM test WHERE id = 1 RETURNING id, a")
+	require.Equal(t, []string{"id", "a"}, cols)
+	require.Equal(t, []row{{1, "a"}}, rows)
+
+	// the wildcard returns the whole documents
+	var r row
+	err = db.QueryRow("INSERT INTO test (id, a) VALUES (3, 'd') RETURNING *").Scan(Scanner(&r))
+	require.NoError(t, err)
+	require.Equal(t, row{3, "d"}, r)
+}
+
+func BenchmarkDriverScan(b *testing.B) {
+	db, err := genji.Open(":memory:")
+	require.NoError(b, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test; INSERT INTO test (a, b, c, d, e) VALUES (1, 2, 3, 4, 5)`)
+	require.NoError(b, err)
+
+	res, err := db.Query("SELECT a, b, c, d, e FROM test")
+	require.NoError(b, err)
+	defer res.Close()
+
+	rs := documentStream{fields: []string{"a", "b", "c", "d", "e"}}
+	dest := make([]driver.Value, len(rs.fields))
+
+	err = res.Iterate(func(d document.Document) error {
+		b.Run("Row values", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = rs.scan(d, dest)
+			}
+		})
+
+		b.Run("Fields", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = rs.scanFields(d, dest)
+			}
+		})
+		return nil
+	})
+	require.NoError(b, err)
+}
+
+func TestDriverTransactionStatements(t *testing.T) {
+	db, err := sql.Open("genji", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE test")
+	require.NoError(t, err)
+
+	count := func(t *testing.T, c *sql.Conn) int {
+		var n int
+		err := c.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM test").Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+
+	ctx := context.Background()
+	c, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer c.Close()
+
+	t.Run("Rollback", func(t *testing.T) {
+		_, err = c.ExecContext(ctx, "BEGIN")
+		require.NoError(t, err)
+		_, err = c.ExecContext(ctx, "INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+		require.Equal(t, 1, count(t, c))
+
+		_, err = c.ExecContext(ctx, "ROLLBACK")
+		require.NoError(t, err)
+		require.Equal(t, 0, count(t, c))
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		_, err = c.ExecContext(ctx, "BEGIN TRANSACTION")
+		require.NoError(t, err)
+		_, err = c.ExecContext(ctx, "INSERT INTO test (a) VALUES (1)")
+		require.NoError(t, err)
+		_, err = c.ExecContext(ctx, "BEGIN")
+		require.Error(t, err)
+
+		_, err = c.ExecContext(ctx, "COMMIT")
+		require.NoError(t, err)
+		require.Equal(t, 1, count(t, c))
+	})
+
+	t.Run("Same query", func(t *testing.T) {
+		_, err = c.ExecContext(ctx, "BEGIN; INSERT INTO test (a) VALUES (2); INSERT INTO test (a) VALUES (3); ROLLBACK")
+		require.NoError(t, err)
+		require.Equal(t, 1, count(t, c))
+
+		var n int
+		err = c.QueryRowContext(ctx, "BEGIN; INSERT INTO test (a) VALUES (2); SELECT COUNT(*) FROM test").Scan(&n)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		_, err = c.ExecContext(ctx, "COMMIT")
+		require.NoError(t, err)
+		require.Equal(t, 2, count(t, c))
+	})
+
+	t.Run("Read only", func(t *testing.T) {
+		_, err = c.ExecContext(ctx, "BEGIN READ ONLY")
+		require.NoError(t, err)
+		_, err = c.ExecContext(ctx, "INSERT INTO test (a) VALUES (4)")
+		require.Error(t, err)
+		_, err = c.ExecContext(ctx, "ROLLBACK")
+		require.NoError(t, err)
+	})
+
+	t.Run("No transaction", func(t *testing.T) {
+		_, err = c.ExecContext(ctx, "COMMIT")
+		require.Error(t, err)
+		_, err = c.ExecContext(ctx, "ROLLBACK")
+		require.Error(t, err)
+	})
+
+	t.Run("Within BeginTx", func(t *testing.T) {
+		tx, err := c.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		_, err = tx.Exec("BEGIN")
+		require.Error(t, err)
+		_, err = tx.Exec("COMMIT")
+		require.Error(t, err)
+
+		require.NoError(t, tx.Commit())
+	})
+}
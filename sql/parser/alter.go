@@ -5,7 +5,13 @@ import (
 	"github.com/genjidb/genji/sql/scanner"
 )
 
-func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ query.AlterStmt, err error) {
+func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ query.Statement, err error) {
+	// Parse "FIELD".
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.FIELD {
+		return p.parseAlterTableRenameFieldStatement(tableName)
+	}
+	p.Unscan()
+
 	var stmt query.AlterStmt
 	stmt.TableName = tableName
 
@@ -23,6 +29,32 @@ func (p *Parser) parseAlterTableRenameStatement(tableName string) (_ query.Alter
 	return stmt, nil
 }
 
+func (p *Parser) parseAlterTableRenameFieldStatement(tableName string) (_ query.AlterTableRenameField, err error) {
+	var stmt query.AlterTableRenameField
+	stmt.TableName = tableName
+
+	// Parse field name.
+	stmt.Path, err = p.parsePath()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse "TO".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.TO {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"TO"}, pos)
+	}
+
+	// Parse new field name.
+	stmt.NewName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"field_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
+
 func (p *Parser) parseAlterTableAddFieldStatement(tableName string) (_ query.AlterTableAddField, err error) {
 	var stmt query.AlterTableAddField
 	stmt.TableName = tableName
@@ -45,6 +77,42 @@ func (p *Parser) parseAlterTableAddFieldStatement(tableName string) (_ query.Alt
 	return stmt, nil
 }
 
+func (p *Parser) parseAlterTableDropFieldStatement(tableName string) (_ query.AlterTableDropField, err error) {
+	var stmt query.AlterTableDropField
+	stmt.TableName = tableName
+
+	// Parse "FIELD".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.FIELD {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"FIELD"}, pos)
+	}
+
+	// Parse field name.
+	stmt.Path, err = p.parsePath()
+	if err != nil {
+		return stmt, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) parseAlterTableAlterFieldStatement(tableName string) (_ query.AlterTableAlterField, err error) {
+	var stmt query.AlterTableAlterField
+	stmt.TableName = tableName
+
+	// Parse "FIELD".
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.FIELD {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"FIELD"}, pos)
+	}
+
+	// Parse the new field definition, which replaces the current one.
+	err = p.parseFieldDefinition(&stmt.Constraint)
+	if err != nil {
+		return stmt, err
+	}
+
+	return stmt, nil
+}
+
 // parseAlterStatement parses a Alter query string and returns a Statement AST object.
 // This function assumes the ALTER token has already been consumed.
 func (p *Parser) parseAlterStatement() (query.Statement, error) {
@@ -69,7 +137,11 @@ func (p *Parser) parseAlterStatement() (query.Statement, error) {
 		return p.parseAlterTableRenameStatement(tableName)
 	case scanner.ADD_KEYWORD:
 		return p.parseAlterTableAddFieldStatement(tableName)
+	case scanner.DROP:
+		return p.parseAlterTableDropFieldStatement(tableName)
+	case scanner.ALTER:
+		return p.parseAlterTableAlterFieldStatement(tableName)
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "RENAME"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ADD", "DROP", "ALTER", "RENAME"}, pos)
 }
@@ -20,6 +20,10 @@ func TestParserAlterTable(t *testing.T) {
 		{"With error / missing TABLE keyword", "ALTER foo RENAME TO bar", query.AlterStmt{}, true},
 		{"With error / two identifiers for table name", "ALTER TABLE foo baz RENAME TO bar", query.AlterStmt{}, true},
 		{"With error / two identifiers for new table name", "ALTER TABLE foo RENAME TO bar baz", query.AlterStmt{}, true},
+		{"Rename field", "ALTER TABLE foo RENAME FIELD bar TO baz", query.AlterTableRenameField{TableName: "foo", Path: parsePath(t, "bar"), NewName: "baz"}, false},
+		{"Rename nested field", "ALTER TABLE foo RENAME FIELD a.b TO c", query.AlterTableRenameField{TableName: "foo", Path: parsePath(t, "a.b"), NewName: "c"}, false},
+		{"With error / rename field to path", "ALTER TABLE foo RENAME FIELD a TO b.c", nil, true},
+		{"With error / rename field without TO", "ALTER TABLE foo RENAME FIELD a b", nil, true},
 	}
 
 	for _, test := range tests {
@@ -86,3 +90,68 @@ func TestParserAlterTableAddColumn(t *testing.T) {
 		})
 	}
 }
+
+func TestParserAlterTableDropField(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Basic", "ALTER TABLE foo DROP FIELD bar", query.AlterTableDropField{TableName: "foo", Path: parsePath(t, "bar")}, false},
+		{"With nested path", "ALTER TABLE foo DROP FIELD bar.baz", query.AlterTableDropField{TableName: "foo", Path: parsePath(t, "bar.baz")}, false},
+		{"With error / missing FIELD keyword", "ALTER TABLE foo DROP bar", nil, true},
+		{"With error / missing field name", "ALTER TABLE foo DROP FIELD", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserAlterTableAlterField(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Basic", "ALTER TABLE foo ALTER FIELD bar", query.AlterTableAlterField{TableName: "foo",
+			Constraint: database.FieldConstraint{
+				Path: parsePath(t, "bar"),
+			},
+		}, false},
+		{"With multiple constraints", "ALTER TABLE foo ALTER FIELD bar TEXT NOT NULL DEFAULT 'a'", query.AlterTableAlterField{TableName: "foo",
+			Constraint: database.FieldConstraint{
+				Path:         parsePath(t, "bar"),
+				Type:         document.TextValue,
+				IsNotNull:    true,
+				DefaultValue: document.NewTextValue("a"),
+			},
+		}, false},
+		{"With error / missing FIELD keyword", "ALTER TABLE foo ALTER bar", nil, true},
+		{"With error / missing field name", "ALTER TABLE foo ALTER FIELD", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
@@ -16,6 +19,14 @@ func (p *Parser) parseCreateStatement() (query.Statement, error) {
 	switch tok {
 	case scanner.TABLE:
 		return p.parseCreateTableStatement()
+	case scanner.TEMPORARY:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.TABLE {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE"}, pos)
+		}
+
+		stmt, err := p.parseCreateTableStatement()
+		stmt.Temporary = true
+		return stmt, err
 	case scanner.UNIQUE:
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INDEX {
 			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
@@ -24,9 +35,36 @@ func (p *Parser) parseCreateStatement() (query.Statement, error) {
 		return p.parseCreateIndexStatement(true)
 	case scanner.INDEX:
 		return p.parseCreateIndexStatement(false)
+	case scanner.FULLTEXT:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.INDEX {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INDEX"}, pos)
+		}
+
+		stmt, err := p.parseCreateIndexStatement(false)
+		if err != nil {
+			return stmt, err
+		}
+		if stmt.Expr != nil || len(stmt.ExtraPaths) > 0 {
+			return stmt, &ParseError{Message: "full-text indexes can only index a single path", Pos: pos}
+		}
+
+		stmt.FullText = true
+		return stmt, nil
+	case scanner.MATERIALIZED:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.VIEW {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VIEW"}, pos)
+		}
+
+		return p.parseCreateMaterializedViewStatement()
+	case scanner.TRIGGER:
+		return p.parseCreateTriggerStatement()
+	}
+
+	if isSequenceToken(tok, lit) {
+		return p.parseCreateSequenceStatement()
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "TEMPORARY", "INDEX", "FULLTEXT", "MATERIALIZED", "TRIGGER", "SEQUENCE"}, pos)
 }
 
 // parseCreateTableStatement parses a create table string and returns a Statement AST object.
@@ -90,6 +128,35 @@ func (p *Parser) parseFieldDefinition(fc *database.FieldConstraint) (err error)
 	return p.parseFieldConstraint(fc)
 }
 
+// scanForeignKey consumes the FOREIGN token of a FOREIGN KEY table constraint and returns true
+// if it is the next token. FOREIGN is only a keyword if it is followed by KEY,
+// otherwise it is the name of a field and nothing is consumed.
+func (p *Parser) scanForeignKey() bool {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.FOREIGN {
+		p.Unscan()
+		return false
+	}
+
+	// scan the next token without skipping more than one whitespace
+	// so that every scanned token can be unscanned
+	n := 1
+	tok, _, _ := p.Scan()
+	if tok == scanner.WS {
+		n++
+		tok, _, _ = p.Scan()
+	}
+
+	for i := 0; i < n; i++ {
+		p.Unscan()
+	}
+	if tok == scanner.KEY {
+		return true
+	}
+
+	p.Unscan()
+	return false
+}
+
 func (p *Parser) parseFieldConstraints(info *database.TableInfo) error {
 	// Parse ( token.
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
@@ -101,14 +168,25 @@ func (p *Parser) parseFieldConstraints(info *database.TableInfo) error {
 
 	// Parse constraints.
 	for {
-		var fc database.FieldConstraint
+		if p.scanForeignKey() {
+			var fk database.ForeignKeyConfig
 
-		err = p.parseFieldDefinition(&fc)
-		if err != nil {
-			return err
-		}
+			err = p.parseForeignKey(&fk)
+			if err != nil {
+				return err
+			}
+
+			info.ForeignKeys = append(info.ForeignKeys, fk)
+		} else {
+			var fc database.FieldConstraint
+
+			err = p.parseFieldDefinition(&fc)
+			if err != nil {
+				return err
+			}
 
-		info.FieldConstraints = append(info.FieldConstraints, fc)
+			info.FieldConstraints = append(info.FieldConstraints, fc)
+		}
 
 		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
 			p.Unscan()
@@ -121,20 +199,97 @@ func (p *Parser) parseFieldConstraints(info *database.TableInfo) error {
 		return newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
 	}
 
-	// ensure only one primary key
-	var pkCount int
+	// ensure only one primary key and one TTL field
+	var pkCount, ttlCount int
 	for _, fc := range info.FieldConstraints {
 		if fc.IsPrimaryKey {
 			pkCount++
 		}
+		if fc.IsTTL {
+			ttlCount++
+		}
 	}
 	if pkCount > 1 {
 		return &ParseError{Message: fmt.Sprintf("only one primary key is allowed, got %d", pkCount)}
 	}
+	if ttlCount > 1 {
+		return &ParseError{Message: fmt.Sprintf("only one TTL field is allowed, got %d", ttlCount)}
+	}
+
+	return nil
+}
+
+// parseForeignKey parses a foreign key constraint.
+// This function assumes the FOREIGN token has already been consumed.
+func (p *Parser) parseForeignKey(fk *database.ForeignKeyConfig) (err error) {
+	// Parse "KEY"
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.KEY {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"KEY"}, pos)
+	}
+
+	fk.Path, err = p.parseForeignKeyPath()
+	if err != nil {
+		return err
+	}
+
+	// Parse "REFERENCES"
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.REFERENCES {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"REFERENCES"}, pos)
+	}
+
+	fk.ReferencedTable, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return pErr
+	}
+
+	fk.ReferencedPath, err = p.parseForeignKeyPath()
+	if err != nil {
+		return err
+	}
+
+	// Parse optional "ON DELETE"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		p.Unscan()
+		return nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.DELETE {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"DELETE"}, pos)
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.RESTRICT:
+		fk.OnDelete = database.ForeignKeyRestrict
+	case scanner.CASCADE:
+		fk.OnDelete = database.ForeignKeyCascade
+	default:
+		return newParseError(scanner.Tokstr(tok, lit), []string{"RESTRICT", "CASCADE"}, pos)
+	}
 
 	return nil
 }
 
+// parseForeignKeyPath parses the path of either side of a foreign key.
+func (p *Parser) parseForeignKeyPath() (document.Path, error) {
+	paths, err := p.parsePathList()
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	if len(paths) != 1 {
+		return nil, &ParseError{Message: "foreign keys on more than one path are not supported"}
+	}
+
+	return paths[0], nil
+}
+
 func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 	for {
 		tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -151,6 +306,13 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 			}
 
 			fc.IsPrimaryKey = true
+		case scanner.AUTOINCREMENT:
+			// AUTOINCREMENT must follow PRIMARY KEY and can only be set once
+			if !fc.IsPrimaryKey || fc.IsAutoIncrement {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			fc.IsAutoIncrement = true
 		case scanner.NOT:
 			// Parse "NULL"
 			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.NULL {
@@ -163,31 +325,140 @@ func (p *Parser) parseFieldConstraint(fc *database.FieldConstraint) error {
 			}
 
 			fc.IsNotNull = true
+		case scanner.UNIQUE:
+			// if it's already unique we return an error
+			if fc.IsUnique {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			fc.IsUnique = true
 		case scanner.DEFAULT:
+			// the text of the expression is kept to evaluate it at insert time
+			// if it calls functions, like now()
+			raw, calls := p.raw, p.calls
+			p.raw = new(bytes.Buffer)
+
 			// Parse default value expression.
 			e, err := p.parseUnaryExpr()
+			text := p.raw.String()
+			if raw != nil {
+				raw.WriteString(text)
+			}
+			p.raw = raw
 			if err != nil {
 				return err
 			}
 
+			// if it's already default value we return an error
+			if fc.HasDefaultValue() {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+			}
+
+			if p.calls != calls {
+				fc.DefaultExpr = strings.TrimSpace(text)
+				continue
+			}
+
 			d, err := e.Eval(&expr.Environment{})
 			if err != nil {
 				return err
 			}
 
-			// if it's already default value we return an error
-			if fc.HasDefaultValue() {
+			fc.DefaultValue = d
+		case scanner.GENERATED:
+			// Parse "ALWAYS AS"
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.ALWAYS {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"ALWAYS"}, pos)
+			}
+			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.AS {
+				return newParseError(scanner.Tokstr(tok, lit), []string{"AS"}, pos)
+			}
+
+			fallthrough
+		case scanner.AS:
+			// if it's already generated we return an error
+			if fc.IsGenerated() {
 				return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
 			}
 
-			fc.DefaultValue = d
+			err := p.parseGeneratedExpr(fc)
+			if err != nil {
+				return err
+			}
 		default:
+			// TTL is not a keyword, to allow using it as a field name
+			if tok == scanner.IDENT && strings.EqualFold(lit, "TTL") {
+				// if it's already a TTL field we return an error
+				if fc.IsTTL {
+					return newParseError(scanner.Tokstr(tok, lit), []string{"CONSTRAINT", ")"}, pos)
+				}
+
+				fc.IsTTL = true
+				continue
+			}
+
 			p.Unscan()
+
+			if fc.IsTTL && fc.Type != document.TimestampValue {
+				return &ParseError{Message: fmt.Sprintf("TTL field %q must be a timestamp", fc.Path), Pos: pos}
+			}
+			if fc.IsGenerated() && fc.IsPrimaryKey {
+				return &ParseError{Message: fmt.Sprintf("generated field %q cannot be a primary key", fc.Path), Pos: pos}
+			}
+			if fc.IsAutoIncrement && fc.Type != document.IntegerValue {
+				return &ParseError{Message: fmt.Sprintf("autoincrement field %q must be an integer", fc.Path), Pos: pos}
+			}
+			if fc.IsAutoIncrement && fc.HasDefaultValue() {
+				return &ParseError{Message: fmt.Sprintf("autoincrement field %q cannot have a default value", fc.Path), Pos: pos}
+			}
+			if fc.IsGenerated() && fc.HasDefaultValue() {
+				return &ParseError{Message: fmt.Sprintf("generated field %q cannot have a default value", fc.Path), Pos: pos}
+			}
+
 			return nil
 		}
 	}
 }
 
+// parseGeneratedExpr parses the expression of a generated field: "(expr) [STORED | VIRTUAL]".
+// Generated fields are virtual unless STORED is specified.
+// This function assumes the AS token has already been consumed.
+func (p *Parser) parseGeneratedExpr(fc *database.FieldConstraint) error {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	params := p.orderedParams + p.namedParams
+	e, _, err := p.ParseExpr()
+	if err != nil {
+		return err
+	}
+	if p.orderedParams+p.namedParams != params {
+		return &ParseError{Message: "the expression of a generated field cannot use parameters", Pos: pos}
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	// the expression is stored with the table and evaluated every time a document is written,
+	// or read if the field is virtual
+	fc.GeneratedExpr = fmt.Sprint(e)
+	fc.IsVirtual = true
+
+	switch tok, _, _ := p.ScanIgnoreWhitespace(); tok {
+	case scanner.STORED:
+		fc.IsVirtual = false
+	case scanner.VIRTUAL:
+	default:
+		p.Unscan()
+	}
+
+	return nil
+}
+
 // parseCreateIndexStatement parses a create index string and returns a Statement AST object.
 // This function assumes the CREATE INDEX or CREATE UNIQUE INDEX tokens have already been consumed.
 func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt, error) {
@@ -230,20 +501,72 @@ func (p *Parser) parseCreateIndexStatement(unique bool) (query.CreateIndexStmt,
 		return stmt, err
 	}
 
-	paths, err := p.parsePathList()
+	err = p.parseIndexedExprs(&stmt)
 	if err != nil {
 		return stmt, err
 	}
-	if len(paths) == 0 {
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
-	}
 
-	if len(paths) != 1 {
-		return stmt, &ParseError{Message: "indexes on more than one path are not supported"}
+	// Parse the predicate of a partial index, it is stored with the index
+	// and evaluated every time a document is written.
+	tok, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	if tok != scanner.WHERE {
+		return stmt, nil
 	}
 
-	stmt.Path = paths[0]
+	params := p.orderedParams + p.namedParams
+	stmt.Where, err = p.parseCondition()
+	if err != nil {
+		return stmt, err
+	}
+	if p.orderedParams+p.namedParams != params {
+		return stmt, &ParseError{Message: "the predicate of an index cannot use parameters", Pos: pos}
+	}
 
 	return stmt, nil
 }
+
+// parseIndexedExprs parses the list of the paths indexed by an index,
+// or the expression indexed by an expression index.
+func (p *Parser) parseIndexedExprs(stmt *query.CreateIndexStmt) error {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.LPAREN {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	params := p.orderedParams + p.namedParams
+	exprs, err := p.parseExprListUntil(scanner.RPAREN)
+	if err != nil {
+		return err
+	}
+	if len(exprs) == 0 {
+		return &ParseError{Message: "missing indexed path", Pos: pos}
+	}
+	if p.orderedParams+p.namedParams != params {
+		return &ParseError{Message: "the expression of an index cannot use parameters", Pos: pos}
+	}
+
+	for i, e := range exprs {
+		e = expr.Unparenthesize(e)
+
+		// the value of a wildcard path is computed from the elements
+		// of an array, it is indexed like an expression
+		path, ok := e.(expr.Path)
+		if ok && !document.Path(path).HasWildcard() {
+			if i == 0 {
+				stmt.Path = document.Path(path)
+			} else {
+				stmt.ExtraPaths = append(stmt.ExtraPaths, document.Path(path))
+			}
+			continue
+		}
+
+		if len(exprs) > 1 {
+			return &ParseError{Message: "expression indexes cannot index more than one expression", Pos: pos}
+		}
+
+		stmt.Expr = e
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,6 +19,9 @@ func TestParserCreateTable(t *testing.T) {
 	}{
 		{"Basic", "CREATE TABLE test", query.CreateTableStmt{TableName: "test"}, false},
 		{"If not exists", "CREATE TABLE IF NOT EXISTS test", query.CreateTableStmt{TableName: "test", IfNotExists: true}, false},
+		{"Temporary", "CREATE TEMPORARY TABLE test", query.CreateTableStmt{TableName: "test", Temporary: true}, false},
+		{"Temporary / if not exists", "CREATE TEMPORARY TABLE IF NOT EXISTS test", query.CreateTableStmt{TableName: "test", IfNotExists: true, Temporary: true}, false},
+		{"Temporary without table", "CREATE TEMPORARY INDEX idx ON test(foo)", query.CreateTableStmt{}, true},
 		{"With primary key", "CREATE TABLE test(foo INTEGER PRIMARY KEY)",
 			query.CreateTableStmt{
 				TableName: "test",
@@ -56,6 +60,110 @@ func TestParserCreateTable(t *testing.T) {
 					},
 				},
 			}, false},
+		{"With default expression", "CREATE TABLE test(foo TEXT DEFAULT now(), bar DEFAULT (1 + 2))",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Type: document.TextValue, DefaultExpr: "now()"},
+						{Path: parsePath(t, "bar"), DefaultValue: document.NewIntegerValue(3)},
+					},
+				},
+			}, false},
+		{"With unique", "CREATE TABLE test(foo INTEGER UNIQUE NOT NULL)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsUnique: true, IsNotNull: true},
+					},
+				},
+			}, false},
+		{"With unique twice", "CREATE TABLE test(foo UNIQUE UNIQUE)",
+			query.CreateTableStmt{}, true},
+		{"With generated fields", "CREATE TABLE test(a, b DOUBLE GENERATED ALWAYS AS (a * 2), c AS (b + 1) STORED, d.e AS (lower(a)) VIRTUAL NOT NULL)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "a")},
+						{Path: parsePath(t, "b"), Type: document.DoubleValue, GeneratedExpr: "a * 2", IsVirtual: true},
+						{Path: parsePath(t, "c"), GeneratedExpr: "b + 1"},
+						{Path: parsePath(t, "d.e"), GeneratedExpr: "lower(a)", IsVirtual: true, IsNotNull: true},
+					},
+				},
+			}, false},
+		{"With generated twice", "CREATE TABLE test(foo AS (1) AS (2))",
+			query.CreateTableStmt{}, true},
+		{"With generated without parenthesis", "CREATE TABLE test(foo AS 1)",
+			query.CreateTableStmt{}, true},
+		{"With generated without always", "CREATE TABLE test(foo GENERATED AS (1))",
+			query.CreateTableStmt{}, true},
+		{"With generated primary key", "CREATE TABLE test(foo PRIMARY KEY AS (1))",
+			query.CreateTableStmt{}, true},
+		{"With generated and default", "CREATE TABLE test(foo AS (1) DEFAULT 1)",
+			query.CreateTableStmt{}, true},
+		{"With generated and param", "CREATE TABLE test(foo AS (a + ?))",
+			query.CreateTableStmt{}, true},
+		{"With autoincrement", "CREATE TABLE test(foo INTEGER PRIMARY KEY AUTOINCREMENT)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Type: document.IntegerValue, IsPrimaryKey: true, IsAutoIncrement: true},
+					},
+				},
+			}, false},
+		{"With autoincrement without primary key", "CREATE TABLE test(foo INTEGER AUTOINCREMENT)",
+			query.CreateTableStmt{}, true},
+		{"With autoincrement twice", "CREATE TABLE test(foo INTEGER PRIMARY KEY AUTOINCREMENT AUTOINCREMENT)",
+			query.CreateTableStmt{}, true},
+		{"With autoincrement on text", "CREATE TABLE test(foo TEXT PRIMARY KEY AUTOINCREMENT)",
+			query.CreateTableStmt{}, true},
+		{"With autoincrement and default", "CREATE TABLE test(foo INTEGER PRIMARY KEY AUTOINCREMENT DEFAULT 1)",
+			query.CreateTableStmt{}, true},
+		{"With TTL", "CREATE TABLE test(ttl TIMESTAMP TTL NOT NULL)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "ttl"), Type: document.TimestampValue, IsTTL: true, IsNotNull: true},
+					},
+				},
+			}, false},
+		{"With TTL twice", "CREATE TABLE test(foo TIMESTAMP TTL TTL)",
+			query.CreateTableStmt{}, true},
+		{"With TTL on text", "CREATE TABLE test(foo TEXT TTL)",
+			query.CreateTableStmt{}, true},
+		{"With multiple TTL fields", "CREATE TABLE test(foo TIMESTAMP TTL, bar TIMESTAMP TTL)",
+			query.CreateTableStmt{}, true},
+		{"With foreign key", "CREATE TABLE test(foo INTEGER, FOREIGN KEY (foo) REFERENCES bar (id))",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "foo"), Type: document.IntegerValue},
+					},
+					ForeignKeys: []database.ForeignKeyConfig{
+						{Path: parsePath(t, "foo"), ReferencedTable: "bar", ReferencedPath: parsePath(t, "id")},
+					},
+				},
+			}, false},
+		{"With foreign key on delete cascade", "CREATE TABLE test(FOREIGN KEY (a.b) REFERENCES bar (id) ON DELETE CASCADE)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					ForeignKeys: []database.ForeignKeyConfig{
+						{Path: parsePath(t, "a.b"), ReferencedTable: "bar", ReferencedPath: parsePath(t, "id"), OnDelete: database.ForeignKeyCascade},
+					},
+				},
+			}, false},
+		{"With foreign key on multiple paths", "CREATE TABLE test(FOREIGN KEY (a, b) REFERENCES bar (id))",
+			query.CreateTableStmt{}, true},
+		{"With foreign key without references", "CREATE TABLE test(FOREIGN KEY (a))",
+			query.CreateTableStmt{}, true},
+		{"With foreign key on update", "CREATE TABLE test(FOREIGN KEY (a) REFERENCES bar (id) ON UPDATE CASCADE)",
+			query.CreateTableStmt{}, true},
 		{"With default twice", "CREATE TABLE test(foo DEFAULT 10 DEFAULT 10)",
 			query.CreateTableStmt{}, true},
 		{"With not null twice", "CREATE TABLE test(foo NOT NULL NOT NULL)",
@@ -154,6 +262,26 @@ func TestParserCreateTable(t *testing.T) {
 					},
 				},
 			}, false},
+		{"With decimal type",
+			"CREATE TABLE test(price DECIMAL)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "price"), Type: document.DecimalValue},
+					},
+				},
+			}, false},
+		{"With timestamp type",
+			"CREATE TABLE test(created_at TIMESTAMP)",
+			query.CreateTableStmt{
+				TableName: "test",
+				Info: database.TableInfo{
+					FieldConstraints: []database.FieldConstraint{
+						{Path: parsePath(t, "created_at"), Type: document.TimestampValue},
+					},
+				},
+			}, false},
 
 		{"With text aliases types",
 			"CREATE TABLE test(v VARCHAR(255), c CHARACTER(64), t TEXT)",
@@ -204,8 +332,19 @@ func TestParserCreateIndex(t *testing.T) {
 		{"Basic", "CREATE INDEX idx ON test (foo)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo")}, false},
 		{"If not exists", "CREATE INDEX IF NOT EXISTS idx ON test (foo.bar[1])", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo.bar[1]"), IfNotExists: true}, false},
 		{"Unique", "CREATE UNIQUE INDEX IF NOT EXISTS idx ON test (foo[3].baz)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo[3].baz"), IfNotExists: true, Unique: true}, false},
+		{"Partial", "CREATE INDEX idx ON test (foo) WHERE bar IS NULL", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo"), Where: expr.Is(expr.Path(parsePath(t, "bar")), expr.NullValue())}, false},
+		{"Partial with params", "CREATE INDEX idx ON test (foo) WHERE bar = ?", nil, true},
+		{"Expression", "CREATE INDEX idx ON test (a.b + a.c)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Expr: expr.Add(expr.Path(parsePath(t, "a.b")), expr.Path(parsePath(t, "a.c")))}, false},
+		{"Parenthesized path", "CREATE INDEX idx ON test ((foo))", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo")}, false},
+		{"Wildcard path", "CREATE INDEX idx ON test (foo[*].bar)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Expr: expr.Path(parsePath(t, "foo[*].bar"))}, false},
+		{"Expression with params", "CREATE INDEX idx ON test (foo + ?)", nil, true},
+		{"More than 1 expression", "CREATE INDEX idx ON test (foo, bar + 1)", nil, true},
 		{"No fields", "CREATE INDEX idx ON test", nil, true},
-		{"More than 1 path", "CREATE INDEX idx ON test (foo, bar)", nil, true},
+		{"Empty list", "CREATE INDEX idx ON test ()", nil, true},
+		{"Full-text", "CREATE FULLTEXT INDEX idx ON test (foo.bar)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo.bar"), FullText: true}, false},
+		{"Full-text composite", "CREATE FULLTEXT INDEX idx ON test (foo, bar)", nil, true},
+		{"Full-text expression", "CREATE FULLTEXT INDEX idx ON test (foo + bar)", nil, true},
+		{"Composite", "CREATE INDEX idx ON test (foo, bar.baz)", query.CreateIndexStmt{IndexName: "idx", TableName: "test", Path: parsePath(t, "foo"), ExtraPaths: []document.Path{parsePath(t, "bar.baz")}}, false},
 	}
 
 	for _, test := range tests {
@@ -2,13 +2,14 @@ package parser
 
 import (
 	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
 )
 
 // parseDeleteStatement parses a delete string and returns a Statement AST object.
 // This function assumes the DELETE token has already been consumed.
-func (p *Parser) parseDeleteStatement() (*planner.Tree, error) {
+func (p *Parser) parseDeleteStatement() (query.Statement, error) {
 	var cfg deleteConfig
 	var err error
 
@@ -31,24 +32,60 @@ func (p *Parser) parseDeleteStatement() (*planner.Tree, error) {
 		return nil, err
 	}
 
-	return cfg.ToTree(), nil
+	// Parse order by: "ORDER BY expr [ASC|DESC], ..."
+	cfg.OrderBy, err = p.parseOrderBy()
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse limit: "LIMIT expr"
+	cfg.LimitExpr, err = p.parseLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	// ORDER BY only selects the documents to delete when combined with LIMIT
+	if cfg.OrderBy != nil && cfg.LimitExpr == nil {
+		return nil, &ParseError{Message: "ORDER BY requires a LIMIT in DELETE statements"}
+	}
+
+	t, err := cfg.ToTree()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseReturning(t, cfg.TableName)
 }
 
 // DeleteConfig holds DELETE configuration.
 type deleteConfig struct {
 	TableName string
 	WhereExpr expr.Expr
+	OrderBy   []planner.SortField
+	LimitExpr expr.Expr
 }
 
 // ToTree turns the statement into an expression tree.
-func (cfg deleteConfig) ToTree() *planner.Tree {
+func (cfg deleteConfig) ToTree() (*planner.Tree, error) {
 	t := planner.NewTableInputNode(cfg.TableName)
 
 	if cfg.WhereExpr != nil {
 		t = planner.NewSelectionNode(t, cfg.WhereExpr)
 	}
 
+	if cfg.OrderBy != nil {
+		t = planner.NewSortNode(t, cfg.OrderBy...)
+	}
+
+	if cfg.LimitExpr != nil {
+		var err error
+		t, err = newLimitNode(t, cfg.LimitExpr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	t = planner.NewDeletionNode(t, cfg.TableName)
 
-	return &planner.Tree{Root: t}
+	return &planner.Tree{Root: t}, nil
 }
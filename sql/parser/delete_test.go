@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
 	"github.com/stretchr/testify/require"
 )
 
@@ -12,7 +14,7 @@ func TestParserDelete(t *testing.T) {
 	tests := []struct {
 		name     string
 		s        string
-		expected *planner.Tree
+		expected query.Statement
 	}{
 		{"NoCond", "DELETE FROM test",
 			planner.NewTree(planner.NewDeletionNode(
@@ -24,6 +26,30 @@ func TestParserDelete(t *testing.T) {
 					planner.NewTableInputNode("test"),
 					expr.Eq(expr.Path(parsePath(t, "age")), expr.IntegerValue(10))),
 				"test"))},
+		{"WithReturning", "DELETE FROM test WHERE age = 10 RETURNING *, a",
+			mustNewReturningStmt(t, planner.NewTree(planner.NewDeletionNode(
+				planner.NewSelectionNode(
+					planner.NewTableInputNode("test"),
+					expr.Eq(expr.Path(parsePath(t, "age")), expr.IntegerValue(10))),
+				"test")),
+				[]planner.ProjectedField{planner.Wildcard{}, planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+				"test")},
+		{"WithOrderByAndLimit", "DELETE FROM test WHERE age = 10 ORDER BY created_at DESC LIMIT 1000",
+			planner.NewTree(planner.NewDeletionNode(
+				planner.NewLimitNode(
+					planner.NewSortNode(
+						planner.NewSelectionNode(
+							planner.NewTableInputNode("test"),
+							expr.Eq(expr.Path(parsePath(t, "age")), expr.IntegerValue(10))),
+						planner.SortField{Expr: expr.Path(parsePath(t, "created_at")), Direction: scanner.DESC}),
+					1000),
+				"test"))},
+		{"WithLimit", "DELETE FROM test LIMIT ?",
+			planner.NewTree(planner.NewDeletionNode(
+				planner.NewLimitExprNode(
+					planner.NewTableInputNode("test"),
+					expr.PositionalParam(1)),
+				"test"))},
 	}
 
 	for _, test := range tests {
@@ -34,4 +60,15 @@ func TestParserDelete(t *testing.T) {
 			require.EqualValues(t, test.expected, q.Statements[0])
 		})
 	}
+
+	t.Run("OrderByWithoutLimit", func(t *testing.T) {
+		_, err := ParseQuery("DELETE FROM test ORDER BY a")
+		require.Error(t, err)
+	})
+}
+
+func mustNewReturningStmt(t *testing.T, stmt query.Statement, fields []planner.ProjectedField, tableName string) query.Statement {
+	s, err := planner.NewReturningStmt(stmt, fields, tableName)
+	require.NoError(t, err)
+	return s
 }
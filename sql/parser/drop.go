@@ -14,9 +14,23 @@ func (p *Parser) parseDropStatement() (query.Statement, error) {
 		return p.parseDropTableStatement()
 	case scanner.INDEX:
 		return p.parseDropIndexStatement()
+	case scanner.MATERIALIZED:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.VIEW {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VIEW"}, pos)
+		}
+
+		stmt, err := p.parseDropTableStatement()
+		stmt.MaterializedView = true
+		return stmt, err
+	case scanner.TRIGGER:
+		return p.parseDropTriggerStatement()
+	}
+
+	if isSequenceToken(tok, lit) {
+		return p.parseDropSequenceStatement()
 	}
 
-	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX"}, pos)
+	return nil, newParseError(scanner.Tokstr(tok, lit), []string{"TABLE", "INDEX", "MATERIALIZED", "TRIGGER", "SEQUENCE"}, pos)
 }
 
 // parseDropTableStatement parses a drop table string and returns a Statement AST object.
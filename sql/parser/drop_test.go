@@ -16,6 +16,9 @@ func TestParserDrop(t *testing.T) {
 	}{
 		{"Drop table", "DROP TABLE test", query.DropTableStmt{TableName: "test"}, false},
 		{"Drop table If not exists", "DROP TABLE IF EXISTS test", query.DropTableStmt{TableName: "test", IfExists: true}, false},
+		{"Drop materialized view", "DROP MATERIALIZED VIEW test", query.DropTableStmt{TableName: "test", MaterializedView: true}, false},
+		{"Drop materialized view if exists", "DROP MATERIALIZED VIEW IF EXISTS test", query.DropTableStmt{TableName: "test", IfExists: true, MaterializedView: true}, false},
+		{"Drop materialized without view", "DROP MATERIALIZED test", nil, true},
 		{"Drop index", "DROP INDEX test", query.DropIndexStmt{IndexName: "test"}, false},
 		{"Drop index if exists", "DROP INDEX IF EXISTS test", query.DropIndexStmt{IndexName: "test", IfExists: true}, false},
 	}
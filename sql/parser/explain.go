@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/scanner"
@@ -8,9 +10,17 @@ import (
 
 // parseExplainStatement parses any statement and returns an ExplainStmt object.
 // This function assumes the EXPLAIN token has already been consumed.
+// ANALYZE is not a keyword, to allow using it as a field name.
 func (p *Parser) parseExplainStatement() (query.Statement, error) {
-	// ensure we don't have multiple EXPLAIN keywords
+	var analyze bool
+
 	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok == scanner.IDENT && strings.EqualFold(lit, "ANALYZE") {
+		analyze = true
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+	}
+
+	// ensure we don't have multiple EXPLAIN keywords
 	if tok == scanner.EXPLAIN {
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT", "UPDATE", "DELETE"}, pos)
 	}
@@ -21,5 +31,5 @@ func (p *Parser) parseExplainStatement() (query.Statement, error) {
 		return nil, err
 	}
 
-	return &planner.ExplainStmt{Statement: innerStmt}, nil
+	return &planner.ExplainStmt{Statement: innerStmt, Analyze: analyze}, nil
 }
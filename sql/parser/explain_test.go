@@ -17,6 +17,8 @@ func TestParserExplain(t *testing.T) {
 	}{
 		{"Explain create table", "EXPLAIN CREATE TABLE test", &planner.ExplainStmt{Statement: query.CreateTableStmt{TableName: "test"}}, false},
 		{"Multiple Explains", "EXPLAIN EXPLAIN CREATE TABLE test", nil, true},
+		{"Explain analyze", "EXPLAIN analyze CREATE TABLE test", &planner.ExplainStmt{Statement: query.CreateTableStmt{TableName: "test"}, Analyze: true}, false},
+		{"Explain analyze explain", "EXPLAIN ANALYZE EXPLAIN CREATE TABLE test", nil, true},
 	}
 
 	for _, test := range tests {
@@ -33,32 +33,64 @@ func (p *Parser) ParseExpr() (e expr.Expr, lit string, err error) {
 		defer func() { p.buf = nil }()
 	}
 
+	e, err = p.parseExprWithMinPrecedence(0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return e, strings.TrimSpace(p.buf.String()), nil
+}
+
+// parseExprWithMinPrecedence parses an expression, stopping at the first operator
+// whose precedence is lower than or equal to the given precedence.
+func (p *Parser) parseExprWithMinPrecedence(precedence int) (expr.Expr, error) {
 	// Dummy root node.
 	var root expr.Operator = new(dummyOperator)
 
 	// Parse a non-binary expression type to start.
 	// This variable will always be the root of the expression tree.
-	e, err = p.parseUnaryExpr()
+	e, err := p.parseUnaryExpr()
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	root.SetRightHandExpr(e)
 
 	// Loop over operations and unary exprs and build a tree based on precedence.
 	for {
+		// If the next token is an operator of lower precedence, leave it to the caller.
+		if precedence > 0 {
+			tok, _, _ := p.ScanIgnoreWhitespace()
+			p.Unscan()
+			// NOT IN, NOT LIKE and NOT BETWEEN have the precedence of IN, LIKE and BETWEEN
+			if tok == scanner.NOT {
+				tok = scanner.LIKE
+			}
+			if tok.IsOperator() && tok.Precedence() <= precedence {
+				return root.RightHand(), nil
+			}
+		}
+
 		// If the next token is NOT an operator then return the expression.
 		op, tok, err := p.parseOperator()
 		if err != nil {
-			return nil, "", err
+			return nil, err
 		}
 		if tok == 0 {
-			return root.RightHand(), strings.TrimSpace(p.buf.String()), nil
+			return root.RightHand(), nil
 		}
 
 		var rhs expr.Expr
 
-		if rhs, err = p.parseUnaryExpr(); err != nil {
-			return nil, "", err
+		switch tok {
+		case scanner.BETWEEN:
+			rhs, err = p.parseBetweenBounds()
+		case scanner.IS:
+			rhs, err = p.parseIsOperand()
+		default:
+			rhs, err = p.parseUnaryExpr()
+		}
+		if err != nil {
+			return nil, err
 		}
 
 		// Find the right spot in the tree to add the new expression by
@@ -77,6 +109,38 @@ func (p *Parser) ParseExpr() (e expr.Expr, lit string, err error) {
 	}
 }
 
+// parseIsOperand parses the right operand of the IS and IS NOT operators.
+// MISSING is not a keyword, to allow using it as a field name.
+func (p *Parser) parseIsOperand() (expr.Expr, error) {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok == scanner.IDENT && strings.EqualFold(lit, "MISSING") {
+		return expr.Missing(), nil
+	}
+	p.Unscan()
+
+	return p.parseUnaryExpr()
+}
+
+// parseBetweenBounds parses the "low AND high" bounds of the BETWEEN operator
+// and returns them as a list of expressions.
+// Bounds can't contain comparison or logical operators unless they are parenthesized.
+func (p *Parser) parseBetweenBounds() (expr.Expr, error) {
+	low, err := p.parseExprWithMinPrecedence(scanner.BETWEEN.Precedence())
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.AND {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"AND"}, pos)
+	}
+
+	high, err := p.parseExprWithMinPrecedence(scanner.BETWEEN.Precedence())
+	if err != nil {
+		return nil, err
+	}
+
+	return expr.LiteralExprList{low, high}, nil
+}
+
 func (p *Parser) parseOperator() (func(lhs, rhs expr.Expr) expr.Expr, scanner.Token, error) {
 	op, _, _ := p.ScanIgnoreWhitespace()
 	if !op.IsOperator() && op != scanner.NOT {
@@ -84,12 +148,6 @@ func (p *Parser) parseOperator() (func(lhs, rhs expr.Expr) expr.Expr, scanner.To
 		return nil, 0, nil
 	}
 
-	// Ignore currently unused operators.
-	if op == scanner.EQREGEX || op == scanner.NEQREGEX {
-		p.Unscan()
-		return nil, 0, nil
-	}
-
 	switch op {
 	case scanner.EQ:
 		return expr.Eq, op, nil
@@ -133,21 +191,42 @@ func (p *Parser) parseOperator() (func(lhs, rhs expr.Expr) expr.Expr, scanner.To
 		return expr.Is, op, nil
 	case scanner.NOT:
 		tok, pos, lit := p.ScanIgnoreWhitespace()
+		// NOT IN and NOT LIKE have the precedence of IN and LIKE
 		switch tok {
 		case scanner.IN:
-			return expr.NotIn, op, nil
+			return expr.NotIn, tok, nil
 		case scanner.LIKE:
-			return expr.NotLike, op, nil
+			return expr.NotLike, tok, nil
+		case scanner.BETWEEN:
+			return notBetween, tok, nil
 		}
 
-		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE"}, pos)
+		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"IN, LIKE, BETWEEN"}, pos)
 	case scanner.LIKE:
 		return expr.Like, op, nil
+	case scanner.EQREGEX:
+		return expr.Regexp, op, nil
+	case scanner.NEQREGEX:
+		return expr.NotRegexp, op, nil
+	case scanner.BETWEEN:
+		return between, op, nil
 	}
 
 	panic(fmt.Sprintf("unknown operator %q", op))
 }
 
+// between and notBetween create BETWEEN operators from the bounds
+// returned by parseBetweenBounds.
+func between(lhs, rhs expr.Expr) expr.Expr {
+	bounds := rhs.(expr.LiteralExprList)
+	return expr.Between(lhs, bounds[0], bounds[1])
+}
+
+func notBetween(lhs, rhs expr.Expr) expr.Expr {
+	bounds := rhs.(expr.LiteralExprList)
+	return expr.NotBetween(lhs, bounds[0], bounds[1])
+}
+
 // parseUnaryExpr parses an non-binary expression.
 func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
@@ -155,6 +234,8 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 	case scanner.CAST:
 		p.Unscan()
 		return p.parseCastExpression()
+	case scanner.CASE:
+		return p.parseCaseExpression()
 	case scanner.IDENT:
 		// if the next token is a left parenthesis, this is a function
 		if tok1, _, _ := p.Scan(); tok1 == scanner.LPAREN {
@@ -164,12 +245,20 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 		}
 		p.Unscan()
 		p.Unscan()
-		field, err := p.parsePath()
+		field, err := p.parseExprPath()
 		if err != nil {
 			return nil, err
 		}
-		fs := expr.Path(field)
-		return fs, nil
+		return p.resolvePath(field), nil
+	case scanner.TYPEARRAY:
+		// ARRAY is a keyword but it can also be used as a function name
+		if tok1, _, _ := p.Scan(); tok1 == scanner.LPAREN {
+			p.Unscan()
+			p.Unscan()
+			return p.parseFunction()
+		}
+		p.Unscan()
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
 	case scanner.NAMEDPARAM:
 		if len(lit) == 1 {
 			return nil, &ParseError{Message: "missing param name"}
@@ -205,6 +294,12 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 		return expr.IntegerValue(v), nil
 	case scanner.TRUE, scanner.FALSE:
 		return expr.BoolValue(tok == scanner.TRUE), nil
+	case scanner.NOT:
+		e, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Not(e), nil
 	case scanner.NULL:
 		return expr.NullValue(), nil
 	case scanner.LBRACKET:
@@ -214,7 +309,25 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 	case scanner.LSBRACKET:
 		p.Unscan()
 		return p.parseExprList(scanner.LSBRACKET, scanner.RSBRACKET)
+	case scanner.EXISTS:
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SELECT {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+		}
+
+		sq, err := p.parseSubquery()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Exists(sq), nil
 	case scanner.LPAREN:
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.SELECT {
+			return p.parseSubquery()
+		}
+		p.Unscan()
+
 		e, _, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
@@ -237,6 +350,15 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")", ","}, pos)
 	default:
+		if tok.IsUnreserved() {
+			p.Unscan()
+			field, err := p.parseExprPath()
+			if err != nil {
+				return nil, err
+			}
+			return p.resolvePath(field), nil
+		}
+
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier", "string", "number", "bool"}, pos)
 	}
 }
@@ -244,11 +366,25 @@ func (p *Parser) parseUnaryExpr() (expr.Expr, error) {
 // parseIdent parses an identifier.
 func (p *Parser) parseIdent() (string, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
-	if tok != scanner.IDENT {
+	ident, ok := p.identLit(tok, lit)
+	if !ok {
 		return "", newParseError(scanner.Tokstr(tok, lit), []string{"identifier"}, pos)
 	}
 
-	return lit, nil
+	return ident, nil
+}
+
+// identLit returns the name of the identifier that was just scanned.
+// Unreserved keywords are identifiers named after the text of the keyword.
+func (p *Parser) identLit(tok scanner.Token, lit string) (string, bool) {
+	switch {
+	case tok == scanner.IDENT:
+		return lit, true
+	case tok.IsUnreserved():
+		return p.s.Curr().Raw, true
+	}
+
+	return "", false
 }
 
 // parseIdentList parses a comma delimited list of identifiers.
@@ -312,6 +448,8 @@ func (p *Parser) parseType() (document.ValueType, error) {
 		return document.BlobValue, nil
 	case scanner.TYPEDOCUMENT:
 		return document.DocumentValue, nil
+	case scanner.TYPEDECIMAL:
+		return document.DecimalValue, nil
 	case scanner.TYPEREAL:
 		return document.DoubleValue, nil
 	case scanner.TYPEDOUBLE:
@@ -326,6 +464,8 @@ func (p *Parser) parseType() (document.ValueType, error) {
 		return document.IntegerValue, nil
 	case scanner.TYPETEXT:
 		return document.TextValue, nil
+	case scanner.TYPETIMESTAMP:
+		return document.TimestampValue, nil
 	case scanner.TYPEVARCHAR, scanner.TYPECHARACTER:
 		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
 			return 0, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
@@ -386,7 +526,9 @@ func (p *Parser) parseKV() (expr.KVPair, error) {
 	var k string
 
 	tok, pos, lit := p.ScanIgnoreWhitespace()
-	if tok == scanner.IDENT || tok == scanner.STRING {
+	if ident, ok := p.identLit(tok, lit); ok {
+		k = ident
+	} else if tok == scanner.STRING {
 		k = lit
 	} else {
 		return expr.KVPair{}, newParseError(scanner.Tokstr(tok, lit), []string{"ident", "string"}, pos)
@@ -411,6 +553,17 @@ func (p *Parser) parseKV() (expr.KVPair, error) {
 
 // parsePath parses a path to a specific value.
 func (p *Parser) parsePath() (document.Path, error) {
+	return p.parsePathFragments(false)
+}
+
+// parseExprPath parses a path used in an expression.
+// Unlike the paths of statements that modify documents or indexes,
+// it can contain wildcards to select all the elements of an array: a[*].b
+func (p *Parser) parseExprPath() (document.Path, error) {
+	return p.parsePathFragments(true)
+}
+
+func (p *Parser) parsePathFragments(wildcards bool) (document.Path, error) {
 	var path document.Path
 	// parse first mandatory ident
 	chunk, err := p.parseIdent()
@@ -431,25 +584,32 @@ LOOP:
 		case scanner.DOT:
 			// scan the next token for an ident
 			tok, pos, lit := p.Scan()
-			if tok != scanner.IDENT {
+			ident, ok := p.identLit(tok, lit)
+			if !ok {
 				return nil, newParseError(lit, []string{"identifier"}, pos)
 			}
 			path = append(path, document.PathFragment{
-				FieldName: lit,
+				FieldName: ident,
 			})
 		case scanner.LSBRACKET:
-			// scan the next token for an integer
+			// scan the next token for an integer or a wildcard
 			tok, pos, lit := p.Scan()
-			if tok != scanner.INTEGER || lit[0] == '-' {
-				return nil, newParseError(lit, []string{"array index"}, pos)
+			switch {
+			case tok == scanner.MUL && wildcards:
+				path = append(path, document.PathFragment{
+					Wildcard: true,
+				})
+			case tok != scanner.INTEGER || lit[0] == '-':
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"array index"}, pos)
+			default:
+				idx, err := strconv.Atoi(lit)
+				if err != nil {
+					return nil, newParseError(lit, []string{"integer"}, pos)
+				}
+				path = append(path, document.PathFragment{
+					ArrayIndex: idx,
+				})
 			}
-			idx, err := strconv.Atoi(lit)
-			if err != nil {
-				return nil, newParseError(lit, []string{"integer"}, pos)
-			}
-			path = append(path, document.PathFragment{
-				ArrayIndex: idx,
-			})
 			// scan the next token for a closing left bracket
 			tok, pos, lit = p.Scan()
 			if tok != scanner.RSBRACKET {
@@ -501,16 +661,31 @@ func (p *Parser) parseExprList(leftToken, rightToken scanner.Token) (expr.Litera
 	return p.parseExprListUntil(rightToken)
 }
 
-// parseFunction parses a function call.
-// a function is an identifier followed by a parenthesis,
-// an optional coma-separated list of expressions and a closing parenthesis.
+// parseFunction parses a function call, followed by an optional OVER clause.
 func (p *Parser) parseFunction() (expr.Expr, error) {
-	// Parse function name.
-	fname, err := p.parseIdent()
+	fn, err := p.parseFunctionCall()
 	if err != nil {
 		return nil, err
 	}
 
+	return p.parseOver(fn)
+}
+
+// parseFunctionCall parses a function call.
+// a function is an identifier followed by a parenthesis,
+// an optional coma-separated list of expressions and a closing parenthesis.
+func (p *Parser) parseFunctionCall() (expr.Expr, error) {
+	// Parse function name.
+	tok, pos, fname := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.IDENT:
+	case scanner.TYPEARRAY:
+		fname = "array"
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, fname), []string{"identifier"}, pos)
+	}
+	p.calls++
+
 	// Parse required ( token.
 	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
 		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
@@ -557,6 +732,85 @@ func (p *Parser) parseFunction() (expr.Expr, error) {
 	return p.functions.GetFunc(fname, exprs...)
 }
 
+// parseOver parses the OVER clause that may follow a window function:
+// "OVER ([PARTITION BY expr [, expr]*] [ORDER BY expr [ASC|DESC] [, expr [ASC|DESC]]*])".
+func (p *Parser) parseOver(fn expr.Expr) (expr.Expr, error) {
+	_, isOffsetFunc := fn.(expr.OffsetWindowFunc)
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.OVER {
+		p.Unscan()
+		if isOffsetFunc {
+			return nil, fmt.Errorf("%v must be used with an OVER clause", fn)
+		}
+		return fn, nil
+	}
+
+	if _, ok := fn.(expr.WindowFunc); !ok && !isOffsetFunc {
+		return nil, fmt.Errorf("%v is not a window function", fn)
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	var w expr.Window
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.PARTITION {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.BY {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
+		}
+
+		for {
+			e, _, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			w.PartitionBy = append(w.PartitionBy, e)
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+	} else {
+		p.Unscan()
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ORDER {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.BY {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
+		}
+
+		for {
+			e, _, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+
+			o := expr.WindowOrder{Expr: e, Direction: scanner.ASC}
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
+				o.Direction = tok
+			} else {
+				p.Unscan()
+			}
+			w.OrderBy = append(w.OrderBy, o)
+
+			if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+				p.Unscan()
+				break
+			}
+		}
+	} else {
+		p.Unscan()
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return expr.OverFunc{Func: fn, Window: w}, nil
+}
+
 // parseCastExpression parses a string of the form CAST(expr AS type).
 func (p *Parser) parseCastExpression() (expr.Expr, error) {
 	// Parse required CAST token.
@@ -599,3 +853,68 @@ func (p *Parser) parseCastExpression() (expr.Expr, error) {
 
 	return expr.CastFunc{Expr: e, CastAs: tp}, nil
 }
+
+// parseCaseExpression parses a simple or searched CASE expression.
+// This function assumes the CASE token has already been consumed.
+func (p *Parser) parseCaseExpression() (expr.Expr, error) {
+	var c expr.CaseExpr
+	var err error
+
+	// Parse optional operand.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.WHEN {
+		p.Unscan()
+		c.Operand, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse required WHEN ... THEN ... branches.
+	for {
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.WHEN {
+			if len(c.Whens) == 0 {
+				return nil, newParseError(scanner.Tokstr(tok, lit), []string{"WHEN"}, pos)
+			}
+			p.Unscan()
+			break
+		}
+
+		var w expr.WhenClause
+		w.When, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.THEN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"THEN"}, pos)
+		}
+
+		w.Then, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		c.Whens = append(c.Whens, w)
+	}
+
+	// Parse optional ELSE.
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ELSE {
+		c.Else, _, err = p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		p.Unscan()
+	}
+
+	// Parse required END.
+	// END is not a keyword, to allow using it as a field name
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "END") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"WHEN", "ELSE", "END"}, pos)
+	}
+
+	return c, nil
+}
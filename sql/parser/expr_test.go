@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
 	"github.com/stretchr/testify/require"
 )
 
@@ -130,6 +132,9 @@ func TestParserExpr(t *testing.T) {
 		{"IN", "age IN ages", expr.In(expr.Path(parsePath(t, "age")), expr.Path(parsePath(t, "ages"))), false},
 		{"IS", "age IS NULL", expr.Is(expr.Path(parsePath(t, "age")), expr.NullValue()), false},
 		{"IS NOT", "age IS NOT NULL", expr.IsNot(expr.Path(parsePath(t, "age")), expr.NullValue()), false},
+		{"IS MISSING", "age IS MISSING", expr.Is(expr.Path(parsePath(t, "age")), expr.Missing()), false},
+		{"IS NOT MISSING", "age is not missing", expr.IsNot(expr.Path(parsePath(t, "age")), expr.Missing()), false},
+		{"IS / precedence", "age IS NULL AND a = 1", expr.And(expr.Is(expr.Path(parsePath(t, "age")), expr.NullValue()), expr.Eq(expr.Path(parsePath(t, "a")), expr.IntegerValue(1))), false},
 		{"precedence", "4 > 1 + 2", expr.Gt(
 			expr.IntegerValue(4),
 			expr.Add(
@@ -155,11 +160,88 @@ func TestParserExpr(t *testing.T) {
 				),
 				expr.Lt(expr.Path(parsePath(t, "age")), expr.DoubleValue(10.4)),
 			), false},
+		{"NOT", "NOT active", expr.Not(expr.Path(parsePath(t, "active"))), false},
+		{"NOT precedence", "NOT age = 10 AND active",
+			expr.And(
+				expr.Not(expr.Eq(expr.Path(parsePath(t, "age")), expr.IntegerValue(10))),
+				expr.Path(parsePath(t, "active")),
+			), false},
+		{"IN with params", "status IN ('a', 'b', ?)",
+			expr.In(
+				expr.Path(parsePath(t, "status")),
+				expr.LiteralExprList{expr.TextValue("a"), expr.TextValue("b"), expr.PositionalParam(1)},
+			), false},
+		{"NOT IN precedence", "age = 10 AND age NOT IN ages",
+			expr.And(
+				expr.Eq(expr.Path(parsePath(t, "age")), expr.IntegerValue(10)),
+				expr.NotIn(expr.Path(parsePath(t, "age")), expr.Path(parsePath(t, "ages"))),
+			), false},
+		{"=~", "name =~ $re", expr.Regexp(expr.Path(parsePath(t, "name")), expr.NamedParam("re")), false},
+		{"!~", "name !~ '^a' AND active", expr.And(expr.NotRegexp(expr.Path(parsePath(t, "name")), expr.TextValue("^a")), expr.Path(parsePath(t, "active"))), false},
+		{"BETWEEN", "age BETWEEN 10 AND 20", expr.Between(expr.Path(parsePath(t, "age")), expr.IntegerValue(10), expr.IntegerValue(20)), false},
+		{"BETWEEN precedence", "age BETWEEN 10 + 1 AND $max AND active",
+			expr.And(
+				expr.Between(expr.Path(parsePath(t, "age")), expr.Add(expr.IntegerValue(10), expr.IntegerValue(1)), expr.NamedParam("max")),
+				expr.Path(parsePath(t, "active")),
+			), false},
+		{"NOT BETWEEN", "age NOT BETWEEN 10 AND 20 OR age = 1",
+			expr.Or(
+				expr.NotBetween(expr.Path(parsePath(t, "age")), expr.IntegerValue(10), expr.IntegerValue(20)),
+				expr.Eq(expr.Path(parsePath(t, "age")), expr.IntegerValue(1)),
+			), false},
+		{"BETWEEN without AND", "age BETWEEN 10 OR 20", nil, true},
+		{"bool comparison", "active = true", expr.Eq(expr.Path(parsePath(t, "active")), expr.BoolValue(true)), false},
 		{"with NULL", "age > NULL", expr.Gt(expr.Path(parsePath(t, "age")), expr.NullValue()), false},
 		{"pk() function", "pk()", &expr.PKFunc{}, false},
+		{"has() function", "has(a.b)", expr.HasFunc{Path: expr.Path(parsePath(t, "a.b"))}, false},
 		{"count(expr) function", "count(a)", &expr.CountFunc{Expr: expr.Path(parsePath(t, "a"))}, false},
 		{"count(*) function", "count(*)", &expr.CountFunc{Wildcard: true}, false},
 		{"CAST", "CAST(a.b[1][0] AS TEXT)", expr.CastFunc{Expr: expr.Path(parsePath(t, "a.b[1][0]")), CastAs: document.TextValue}, false},
+
+		// window functions
+		{"OVER", "row_number() OVER ()", expr.OverFunc{Func: expr.RowNumberFunc{}}, false},
+		{"OVER with PARTITION BY and ORDER BY", "rank() OVER (PARTITION BY a, b.c ORDER BY d DESC, e)",
+			expr.OverFunc{Func: expr.RankFunc{}, Window: expr.Window{
+				PartitionBy: []expr.Expr{expr.Path(parsePath(t, "a")), expr.Path(parsePath(t, "b.c"))},
+				OrderBy: []expr.WindowOrder{
+					{Expr: expr.Path(parsePath(t, "d")), Direction: scanner.DESC},
+					{Expr: expr.Path(parsePath(t, "e")), Direction: scanner.ASC},
+				},
+			}}, false},
+		{"lag", "lag(a, 2, 0) OVER (ORDER BY b)",
+			expr.OverFunc{
+				Func:   expr.LagFunc{Expr: expr.Path(parsePath(t, "a")), Offset: expr.IntegerValue(2), Default: expr.IntegerValue(0)},
+				Window: expr.Window{OrderBy: []expr.WindowOrder{{Expr: expr.Path(parsePath(t, "b")), Direction: scanner.ASC}}},
+			}, false},
+		{"lead", "lead(a) OVER (PARTITION BY b)",
+			expr.OverFunc{
+				Func:   expr.LeadFunc{Expr: expr.Path(parsePath(t, "a"))},
+				Window: expr.Window{PartitionBy: []expr.Expr{expr.Path(parsePath(t, "b"))}},
+			}, false},
+		{"lag without OVER", "lag(a)", nil, true},
+		{"lag without arguments", "lag() OVER ()", nil, true},
+		{"OVER with a scalar function", "pk() OVER ()", nil, true},
+		{"OVER without parenthesis", "rank() OVER ORDER BY a", nil, true},
+
+		// case
+		{"searched CASE", "CASE WHEN a > 1 THEN 'big' WHEN a > 0 THEN 'small' ELSE NULL END",
+			expr.CaseExpr{
+				Whens: []expr.WhenClause{
+					{When: expr.Gt(expr.Path(parsePath(t, "a")), expr.IntegerValue(1)), Then: expr.TextValue("big")},
+					{When: expr.Gt(expr.Path(parsePath(t, "a")), expr.IntegerValue(0)), Then: expr.TextValue("small")},
+				},
+				Else: expr.NullValue(),
+			}, false},
+		{"simple CASE", "CASE a + 1 WHEN 1 THEN b END = c",
+			expr.Eq(expr.CaseExpr{
+				Operand: expr.Add(expr.Path(parsePath(t, "a")), expr.IntegerValue(1)),
+				Whens: []expr.WhenClause{
+					{When: expr.IntegerValue(1), Then: expr.Path(parsePath(t, "b"))},
+				},
+			}, expr.Path(parsePath(t, "c"))), false},
+		{"CASE without WHEN", "CASE a ELSE 1 END", nil, true},
+		{"CASE without END", "CASE WHEN a THEN 1", nil, true},
+		{"CASE without THEN", "CASE WHEN a 1 END", nil, true},
 	}
 
 	for _, test := range tests {
@@ -203,7 +285,15 @@ func TestParsePath(t *testing.T) {
 			document.PathFragment{ArrayIndex: 5},
 			document.PathFragment{FieldName: "  \"quotes"},
 		}, false},
+		{"wildcards", `a[*].b[*][1]`, document.Path{
+			document.PathFragment{FieldName: "a"},
+			document.PathFragment{Wildcard: true},
+			document.PathFragment{FieldName: "b"},
+			document.PathFragment{Wildcard: true},
+			document.PathFragment{ArrayIndex: 1},
+		}, false},
 		{"negative index", `a.b[-100].c`, nil, true},
+		{"wildcard field", `a.*`, nil, true},
 		{"with spaces", `a.  b[100].  c`, nil, true},
 		{"starting with array", `[10].a`, nil, true},
 	}
@@ -240,7 +330,13 @@ func TestParserParams(t *testing.T) {
 				expr.Eq(expr.Path(parsePath(t, "age")), expr.NamedParam("foo")),
 				expr.Eq(expr.Path(parsePath(t, "age")), expr.NamedParam("bar")),
 			), false},
+		{"colon named", "age = :foo OR age = $bar",
+			expr.Or(
+				expr.Eq(expr.Path(parsePath(t, "age")), expr.NamedParam("foo")),
+				expr.Eq(expr.Path(parsePath(t, "age")), expr.NamedParam("bar")),
+			), false},
 		{"mixed", "age >= ? AND age > $foo OR age < ?", nil, true},
+		{"mixed colon", "age >= ? AND age > :foo", nil, true},
 	}
 
 	for _, test := range tests {
@@ -256,3 +352,62 @@ func TestParserParams(t *testing.T) {
 		})
 	}
 }
+
+func TestParserSubquery(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           string
+		correlated  bool
+		outerTables []string
+		fails       bool
+	}{
+		{"uncorrelated", "(SELECT user_id FROM orders)", false, []string{"users"}, false},
+		{"qualified uncorrelated", "(SELECT orders.user_id FROM orders WHERE orders.total > 10)", false, []string{"users"}, false},
+		{"correlated", "(SELECT 1 FROM orders WHERE user_id = users.id)", true, []string{"users"}, false},
+		{"nested correlated", "(SELECT 1 FROM orders WHERE EXISTS (SELECT 1 FROM items WHERE items.id = users.id))", true, []string{"users"}, false},
+		{"correlated result field", "(SELECT users.name FROM orders)", true, []string{"users"}, false},
+		{"compound", "(SELECT a FROM foo EXCEPT SELECT a FROM bar)", false, []string{"users"}, false},
+		{"missing parenthesis", "(SELECT user_id FROM orders", false, nil, true},
+		{"no select", "(SELECT)", false, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser(strings.NewReader(test.s))
+			p.pushScope().tables = []string{"users"}
+
+			ex, _, err := p.ParseExpr()
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			sq, ok := ex.(*expr.Subquery)
+			require.True(t, ok)
+			require.Equal(t, test.correlated, sq.Correlated)
+			require.Equal(t, test.outerTables, sq.OuterTables)
+		})
+	}
+
+	t.Run("exists", func(t *testing.T) {
+		p := NewParser(strings.NewReader("NOT EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id)"))
+		p.pushScope().tables = []string{"users"}
+
+		ex, _, err := p.ParseExpr()
+		require.NoError(t, err)
+		require.Equal(t, "NOT EXISTS (Table(orders) -> σ(cond: user_id = users.id) -> ∏(1))", ex.(interface{ String() string }).String())
+	})
+
+	t.Run("result fields", func(t *testing.T) {
+		// the tables of the statement are known after its result fields are parsed
+		q, err := ParseQuery("SELECT name, (SELECT COUNT(*) FROM orders WHERE orders.user_id = users.id) AS n FROM users")
+		require.NoError(t, err)
+
+		pf := q.Statements[0].(*planner.Tree).Root.(*planner.ProjectionNode).Expressions[1].(planner.ProjectedExpr)
+		sq, ok := pf.Expr.(*expr.Subquery)
+		require.True(t, ok)
+		require.True(t, sq.Correlated)
+		require.Equal(t, []string{"users"}, sq.OuterTables)
+	})
+}
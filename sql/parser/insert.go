@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
@@ -10,7 +11,7 @@ import (
 
 // parseInsertStatement parses an insert string and returns a Statement AST object.
 // This function assumes the INSERT token has already been consumed.
-func (p *Parser) parseInsertStatement() (query.InsertStmt, error) {
+func (p *Parser) parseInsertStatement() (query.Statement, error) {
 	var stmt query.InsertStmt
 	var err error
 
@@ -34,6 +35,18 @@ func (p *Parser) parseInsertStatement() (query.InsertStmt, error) {
 	if err != nil {
 		return stmt, err
 	}
+
+	// Parse SELECT statement: "SELECT ..."
+	if tok, pos, _ := p.ScanIgnoreWhitespace(); tok == scanner.SELECT {
+		err = p.parseInsertSelect(&stmt, fields, pos)
+		if err != nil {
+			return stmt, err
+		}
+
+		return p.parseInsertEnd(stmt)
+	}
+	p.Unscan()
+
 	if withFields {
 		valueParser = func() (expr.Expr, error) {
 			// expect an expression list
@@ -59,7 +72,101 @@ func (p *Parser) parseInsertStatement() (query.InsertStmt, error) {
 	}
 
 	stmt.Values = values
-	return stmt, nil
+
+	return p.parseInsertEnd(stmt)
+}
+
+// parseInsertEnd parses the optional ON CONFLICT and RETURNING clauses of an insert statement.
+func (p *Parser) parseInsertEnd(stmt query.InsertStmt) (query.Statement, error) {
+	err := p.parseOnConflict(&stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseReturning(stmt, stmt.TableName)
+}
+
+// parseOnConflict parses the "ON CONFLICT DO NOTHING" and "ON CONFLICT DO UPDATE SET ..." clauses, if they exist.
+func (p *Parser) parseOnConflict(stmt *query.InsertStmt) error {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		p.Unscan()
+		return nil
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.CONFLICT {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"CONFLICT"}, pos)
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.DO {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"DO"}, pos)
+	}
+
+	// NOTHING is not a keyword, to allow using it as a field name
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch {
+	case tok == scanner.IDENT && strings.EqualFold(lit, "NOTHING"):
+		stmt.OnConflict = query.OnConflictDoNothing
+		return nil
+	case tok != scanner.UPDATE:
+		return newParseError(scanner.Tokstr(tok, lit), []string{"NOTHING", "UPDATE"}, pos)
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SET {
+		return newParseError(scanner.Tokstr(tok, lit), []string{"SET"}, pos)
+	}
+
+	// paths of the excluded table refer to the document that was to be inserted,
+	// they are resolved like references to the table of an enclosing statement.
+	p.pushScope().tables = []string{query.ExcludedTable}
+	defer p.popScope()
+	p.pushScope().tables = []string{stmt.TableName}
+	defer p.popScope()
+
+	pairs, err := p.parseSetClause()
+	p.outerRef = -1
+	if err != nil {
+		return err
+	}
+
+	stmt.OnConflict = query.OnConflictDoUpdate
+	for _, sp := range pairs {
+		stmt.OnConflictSet = append(stmt.OnConflictSet, query.SetPair{Path: sp.path, E: sp.e})
+	}
+
+	return nil
+}
+
+// parseInsertSelect parses the SELECT statement whose documents are inserted.
+// This function assumes the SELECT token has already been consumed.
+func (p *Parser) parseInsertSelect(stmt *query.InsertStmt, fields []string, pos scanner.Pos) error {
+	p.readTables = nil
+
+	build, arity, err := p.parseSelectBuilder()
+	if err != nil {
+		return err
+	}
+
+	stmt.Select, err = build()
+	if err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		// wildcards can only be expanded at runtime
+		if arity >= 0 && arity != len(fields) {
+			return &ParseError{Message: fmt.Sprintf("%d values for %d fields", arity, len(fields)), Pos: pos}
+		}
+		stmt.FieldNames = fields
+	}
+
+	for _, t := range p.readTables {
+		if t == stmt.TableName {
+			stmt.ReadsTable = true
+			break
+		}
+	}
+
+	return nil
 }
 
 // parseFieldList parses a list of fields in the form: (path, path, ...), if exists
@@ -3,6 +3,7 @@ package parser
 import (
 	"testing"
 
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
@@ -75,6 +76,69 @@ func TestParserInsert(t *testing.T) {
 			nil, true},
 		{"Values / Without fields / Wrong values", "INSERT INTO test VALUES {a: 1}, ('e', 'f')",
 			nil, true},
+		{"Select", "INSERT INTO test SELECT * FROM foo",
+			query.InsertStmt{
+				TableName: "test",
+				Select:    planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"), []planner.ProjectedField{planner.Wildcard{}}, "foo")),
+			}, false},
+		{"Select / With fields", "INSERT INTO test (a, b) SELECT c, d FROM foo",
+			query.InsertStmt{
+				TableName:  "test",
+				FieldNames: []string{"a", "b"},
+				Select: planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"), []planner.ProjectedField{
+					planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "c")), ExprName: "c"},
+					planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "d")), ExprName: "d"},
+				}, "foo")),
+			}, false},
+		{"Select / Same table", "INSERT INTO test SELECT * FROM test",
+			query.InsertStmt{
+				TableName:  "test",
+				Select:     planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("test"), []planner.ProjectedField{planner.Wildcard{}}, "test")),
+				ReadsTable: true,
+			}, false},
+		{"On conflict do nothing", "INSERT INTO test (a) VALUES (1) ON CONFLICT DO NOTHING",
+			query.InsertStmt{
+				TableName:  "test",
+				FieldNames: []string{"a"},
+				Values:     expr.LiteralExprList{expr.LiteralExprList{expr.IntegerValue(1)}},
+				OnConflict: query.OnConflictDoNothing,
+			}, false},
+		{"On conflict do update", "INSERT INTO test VALUES {a: 1} ON CONFLICT DO UPDATE SET b = test.b + excluded.b, c = 2",
+			query.InsertStmt{
+				TableName:  "test",
+				Values:     expr.LiteralExprList{expr.KVPairs{expr.KVPair{K: "a", V: expr.IntegerValue(1)}}},
+				OnConflict: query.OnConflictDoUpdate,
+				OnConflictSet: []query.SetPair{
+					{Path: parsePath(t, "b"), E: expr.Add(expr.Path(parsePath(t, "b")), expr.OuterPath{Table: "excluded", Path: expr.Path(parsePath(t, "b"))})},
+					{Path: parsePath(t, "c"), E: expr.IntegerValue(2)},
+				},
+			}, false},
+		{"Select / On conflict", "INSERT INTO test SELECT * FROM foo ON CONFLICT DO NOTHING",
+			query.InsertStmt{
+				TableName:  "test",
+				Select:     planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"), []planner.ProjectedField{planner.Wildcard{}}, "foo")),
+				OnConflict: query.OnConflictDoNothing,
+			}, false},
+		{"Returning", "INSERT INTO test (a) VALUES (1) ON CONFLICT DO NOTHING RETURNING pk(), a",
+			mustNewReturningStmt(t, query.InsertStmt{
+				TableName:  "test",
+				FieldNames: []string{"a"},
+				Values:     expr.LiteralExprList{expr.LiteralExprList{expr.IntegerValue(1)}},
+				OnConflict: query.OnConflictDoNothing,
+			}, []planner.ProjectedField{
+				planner.ProjectedExpr{Expr: &expr.PKFunc{}, ExprName: "pk()"},
+				planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"},
+			}, "test"), false},
+		{"Returning / Missing fields", "INSERT INTO test (a) VALUES (1) RETURNING",
+			nil, true},
+		{"On conflict / Missing action", "INSERT INTO test (a) VALUES (1) ON CONFLICT",
+			nil, true},
+		{"On conflict / Missing SET", "INSERT INTO test (a) VALUES (1) ON CONFLICT DO UPDATE a = 1",
+			nil, true},
+		{"Select / With too many values", "INSERT INTO test (a) SELECT c, d FROM foo",
+			nil, true},
+		{"Select / Missing FROM field", "INSERT INTO test SELECT FROM foo",
+			nil, true},
 	}
 
 	for _, test := range tests {
@@ -6,7 +6,9 @@ import (
 	"io"
 	"strings"
 
+	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/planner"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
@@ -19,6 +21,24 @@ type Parser struct {
 	namedParams   int
 	buf           *bytes.Buffer
 	functions     expr.Functions
+
+	// tables of the SELECT statements being parsed, from the outermost
+	// to the innermost one.
+	scopes []*selectScope
+	// index of the outermost scope referred to by the current subquery,
+	// or -1 if it only refers to its own tables.
+	outerRef int
+	// tables read by the SELECT statements parsed so far.
+	readTables []string
+	// common table expressions defined by the WITH clauses being parsed.
+	ctes []*planner.CTE
+	// common table expressions read by the SELECT statements parsed so far.
+	cteRefs map[*planner.CTE]bool
+	// if not nil, the raw text of the scanned tokens is written to it,
+	// e.g. to store the query of a materialized view.
+	raw *bytes.Buffer
+	// number of function calls parsed so far.
+	calls int
 }
 
 // NewParser returns a new instance of Parser.
@@ -32,7 +52,7 @@ func NewParserWithOptions(r io.Reader, opts *Options) *Parser {
 		opts = defaultOptions()
 	}
 
-	return &Parser{s: scanner.NewBufScanner(r), functions: opts.Functions}
+	return &Parser{s: scanner.NewBufScanner(r), functions: opts.Functions, outerRef: -1}
 }
 
 // ParseQuery parses a query string and returns its AST representation.
@@ -41,8 +61,9 @@ func ParseQuery(s string) (query.Query, error) {
 }
 
 // ParsePath parses a path to a value in a document.
+// The path can contain wildcards, like in expressions.
 func ParsePath(s string) (document.Path, error) {
-	return NewParser(strings.NewReader(s)).parsePath()
+	return NewParser(strings.NewReader(s)).parseExprPath()
 }
 
 // ParseExpr parses an expression.
@@ -61,6 +82,39 @@ func MustParseExpr(s string) expr.Expr {
 	return e
 }
 
+func init() {
+	database.RegisterExprParser(parseStoredExpr)
+}
+
+// parseStoredExpr parses an expression stored with a table or an index.
+// It implements the database.ExprParser type.
+func parseStoredExpr(db *database.Database, s string) (database.Expr, error) {
+	e, _, err := NewParserWithOptions(strings.NewReader(s), &Options{
+		Functions: expr.NewFunctionsFor(db),
+	}).ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return &storedExpr{e: e}, nil
+}
+
+// storedExpr is an expression stored with a table or an index.
+type storedExpr struct {
+	e expr.Expr
+}
+
+// Eval evaluates the expression within tx, using d as the current document.
+// It implements the database.Expr interface.
+func (s *storedExpr) Eval(tx *database.Transaction, d document.Document) (document.Value, error) {
+	env := expr.Environment{Tx: tx}
+	if d != nil {
+		env.SetCurrentValue(document.NewDocumentValue(d))
+	}
+
+	return s.e.Eval(&env)
+}
+
 // ParseQuery parses a Genji SQL string and returns a Query.
 func (p *Parser) ParseQuery() (query.Query, error) {
 	var statements []query.Statement
@@ -110,14 +164,24 @@ func (p *Parser) ParseStatement() (query.Statement, error) {
 		return p.parseDropStatement()
 	case scanner.EXPLAIN:
 		return p.parseExplainStatement()
+	case scanner.REFRESH:
+		return p.parseRefreshStatement()
 	case scanner.REINDEX:
 		return p.parseReIndexStatement()
+	case scanner.RELEASE:
+		return p.parseReleaseStatement()
 	case scanner.ROLLBACK:
 		return p.parseRollbackStatement()
+	case scanner.SAVEPOINT:
+		return p.parseSavepointStatement()
+	case scanner.VERIFY:
+		return p.parseVerifyStatement()
+	case scanner.WITH:
+		return p.parseWithStatement()
 	}
 
 	return nil, newParseError(scanner.Tokstr(tok, lit), []string{
-		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REINDEX", "ROLLBACK",
+		"ALTER", "BEGIN", "COMMIT", "SELECT", "DELETE", "UPDATE", "INSERT", "CREATE", "DROP", "EXPLAIN", "REFRESH", "REINDEX", "RELEASE", "ROLLBACK", "SAVEPOINT", "VERIFY", "WITH",
 	}, pos)
 }
 
@@ -185,6 +249,9 @@ func (p *Parser) Scan() (tok scanner.Token, pos scanner.Pos, lit string) {
 	if p.buf != nil {
 		p.buf.WriteString(ti.Raw)
 	}
+	if p.raw != nil {
+		p.raw.WriteString(ti.Raw)
+	}
 
 	tok, pos, lit = ti.Tok, ti.Pos, ti.Lit
 	return
@@ -207,6 +274,10 @@ func (p *Parser) Unscan() {
 		ti := p.s.Curr()
 		p.buf.Truncate(p.buf.Len() - len(ti.Raw))
 	}
+	if p.raw != nil {
+		ti := p.s.Curr()
+		p.raw.Truncate(p.raw.Len() - len(ti.Raw))
+	}
 	p.s.Unscan()
 }
 
@@ -230,3 +301,22 @@ func (e *ParseError) Error() string {
 	}
 	return fmt.Sprintf("found %s, expected %s at line %d, char %d", e.Found, strings.Join(e.Expected, ", "), e.Pos.Line+1, e.Pos.Char+1)
 }
+
+// parseReturning parses the "RETURNING" clause of a write statement, if it exists,
+// and wraps stmt so that it returns the documents it wrote.
+func (p *Parser) parseReturning(stmt query.Statement, tableName string) (query.Statement, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.RETURNING {
+		p.Unscan()
+		return stmt, nil
+	}
+
+	p.pushScope().tables = []string{tableName}
+	defer p.popScope()
+
+	fields, err := p.parseResultFields()
+	if err != nil {
+		return nil, err
+	}
+
+	return planner.NewReturningStmt(stmt, fields, tableName)
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -50,3 +52,37 @@ func TestParserDivideByZero(t *testing.T) {
 		_, _ = ParseQuery("SELECT * FROM t LIMIT 0 % .5")
 	})
 }
+
+func TestParserUnreservedKeywords(t *testing.T) {
+	words := []string{"timestamp", "left", "right", "outer", "view", "materialized", "refresh", "after", "before", "trigger", "verify",
+		"decimal", "always", "autoincrement", "cascade", "conflict", "do", "foreign", "fulltext", "generated", "over",
+		"partition", "recursive", "references", "release", "restrict", "returning", "savepoint", "stored", "temporary",
+		"unnest", "virtual"}
+	queries := []string{
+		"SELECT %[1]s, a.%[1]s AS b, {%[1]s: 1} AS c FROM t WHERE %[1]s > 1 ORDER BY %[1]s",
+		"SELECT * FROM %[1]s",
+		"INSERT INTO t (%[1]s, a) VALUES (1, 2)",
+		"UPDATE t SET %[1]s = %[1]s + 1, a.%[1]s = 1",
+		"UPDATE t UNSET %[1]s",
+		"DELETE FROM t WHERE %[1]s = 1",
+		"CREATE TABLE t(%[1]s INTEGER, a.%[1]s TEXT)",
+		"CREATE INDEX idx ON t(%[1]s)",
+	}
+
+	for _, w := range words {
+		for _, q := range queries {
+			t.Run(fmt.Sprintf(q, w), func(t *testing.T) {
+				// the keyword must be parsed like a quoted identifier
+				expected, err := ParseQuery(fmt.Sprintf(q, "`"+w+"`"))
+				require.NoError(t, err)
+
+				actual, err := ParseQuery(fmt.Sprintf(q, w))
+				require.NoError(t, err)
+				require.EqualValues(t, expected, actual)
+
+				_, err = ParseQuery(fmt.Sprintf(q, strings.ToUpper(w)))
+				require.NoError(t, err)
+			})
+		}
+	}
+}
@@ -1,72 +1,236 @@
 package parser
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
 )
 
 // parseSelectStatement parses a select string and returns a Statement AST object.
+// Select statements can be combined using the UNION, EXCEPT and INTERSECT operators,
+// which have the same precedence and are evaluated from left to right.
+// The ORDER BY, LIMIT and OFFSET clauses apply to the select statement they belong to,
+// not to the result of the compound statement.
 // This function assumes the SELECT token has already been consumed.
-func (p *Parser) parseSelectStatement() (*planner.Tree, error) {
+func (p *Parser) parseSelectStatement() (query.Statement, error) {
+	build, _, err := p.parseSelectBuilder()
+	if err != nil {
+		return nil, err
+	}
+
+	return build()
+}
+
+// a selectBuilder creates a new statement every time it is called.
+type selectBuilder func() (query.Statement, error)
+
+// parseSelectBuilder parses a select statement and returns a function
+// that builds it, along with the number of selected fields, or -1 if it selects a wildcard.
+func (p *Parser) parseSelectBuilder() (selectBuilder, int, error) {
+	build, arity, err := p.parseSimpleSelectStatement()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for {
+		// Parse set operator: "UNION [ALL]", "EXCEPT [ALL]" or "INTERSECT [ALL]"
+		var op planner.SetOperator
+		tok, _, _ := p.ScanIgnoreWhitespace()
+		switch tok {
+		case scanner.UNION:
+			op = planner.Union
+		case scanner.EXCEPT:
+			op = planner.Except
+		case scanner.INTERSECT:
+			op = planner.Intersect
+		default:
+			p.Unscan()
+			return build, arity, nil
+		}
+
+		all := true
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ALL {
+			p.Unscan()
+			all = false
+		}
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok != scanner.SELECT {
+			return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+		}
+
+		right, n, err := p.parseSimpleSelectStatement()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// wildcards can only be expanded at runtime
+		if arity >= 0 && n >= 0 && arity != n {
+			return nil, 0, &ParseError{
+				Message: fmt.Sprintf("both sides of %s must select the same number of fields", op),
+				Pos:     pos,
+			}
+		}
+		if arity < 0 {
+			arity = n
+		}
+
+		left := build
+		build = func() (query.Statement, error) {
+			l, err := left()
+			if err != nil {
+				return nil, err
+			}
+
+			r, err := right()
+			if err != nil {
+				return nil, err
+			}
+
+			return &planner.CompoundSelectStmt{Left: l, Right: r, Operator: op, All: all}, nil
+		}
+	}
+}
+
+// parseSimpleSelectStatement parses a select statement without set operators.
+// It also returns the number of selected fields, or -1 if it selects a wildcard.
+func (p *Parser) parseSimpleSelectStatement() (selectBuilder, int, error) {
 	var cfg selectConfig
 	var err error
 
-	cfg.Distinct, err = p.parseDistinct()
+	// tables of the statement, used to resolve the paths of subqueries
+	scope := p.pushScope()
+	defer p.popScope()
+
+	// the result fields are parsed before the tables of the statement are known,
+	// their text is kept to parse them again if their subqueries may refer to these tables
+	raw, orderedParams, namedParams, calls := p.raw, p.orderedParams, p.namedParams, p.calls
+	p.raw = new(bytes.Buffer)
+
+	cfg.Distinct, cfg.DistinctOn, cfg.ProjectionExprs, err = p.parseProjection()
+	text := p.raw.String()
+	if raw != nil {
+		raw.WriteString(text)
+	}
+	p.raw = raw
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Parse path list or query.Wildcard
-	cfg.ProjectionExprs, err = p.parseResultFields()
-	if err != nil {
-		return nil, err
+	arity := len(cfg.ProjectionExprs)
+	for _, pe := range cfg.ProjectionExprs {
+		if _, ok := pe.(planner.Wildcard); ok {
+			arity = -1
+		}
 	}
 
 	// Parse "FROM".
 	var found bool
-	cfg.TableName, found, err = p.parseFrom()
+	cfg.TableName, cfg.Unnest, found, err = p.parseFrom()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if !found {
-		return cfg.ToTree()
+		return cfg.builder(), arity, nil
+	}
+	scope.tables = []string{cfg.TableName}
+	cfg.CTE = p.lookupCTE(cfg.TableName)
+
+	// Parse join: "[INNER] JOIN table_name ON expr"
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	cfg.Join, err = p.parseJoin(cfg.TableName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cfg.Join != nil && cfg.Unnest != nil {
+		return nil, 0, &ParseError{Message: "cannot join unnested documents, use a common table expression instead", Pos: pos}
+	}
+	if cfg.Join != nil {
+		cfg.Join.Left.CTE = p.lookupCTE(cfg.Join.Left.TableName)
+		cfg.Join.Right.CTE = p.lookupCTE(cfg.Join.Right.TableName)
+	}
+
+	if scope.unresolved {
+		cfg.Distinct, cfg.DistinctOn, cfg.ProjectionExprs, err = p.reparseProjection(text, orderedParams, namedParams, calls)
+		if err != nil {
+			return nil, 0, err
+		}
 	}
 
 	// Parse condition: "WHERE expr".
 	cfg.WhereExpr, err = p.parseCondition()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Parse group by: "GROUP BY expr"
 	cfg.GroupByExpr, err = p.parseGroupBy()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Parse order by: "ORDER BY path [ASC|DESC]?"
-	cfg.OrderBy, cfg.OrderByDirection, err = p.parseOrderBy()
+	// Parse having: "HAVING expr"
+	cfg.HavingExpr, err = p.parseHaving()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	// Parse order by: "ORDER BY expr [ASC|DESC]? [, expr [ASC|DESC]?]*"
+	cfg.OrderBy, err = p.parseOrderBy()
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Parse limit: "LIMIT expr"
 	cfg.LimitExpr, err = p.parseLimit()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Parse offset: "OFFSET expr"
 	cfg.OffsetExpr, err = p.parseOffset()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	return cfg.builder(), arity, nil
+}
+
+// parseProjection parses the optional DISTINCT clause and the list of result fields.
+func (p *Parser) parseProjection() (distinct bool, distinctOn []expr.Expr, fields []planner.ProjectedField, err error) {
+	distinct, distinctOn, err = p.parseDistinct()
+	if err != nil {
+		return
 	}
 
-	return cfg.ToTree()
+	// Parse path list or query.Wildcard
+	fields, err = p.parseResultFields()
+	return
+}
+
+// reparseProjection parses the text of the projection of a statement again,
+// once the tables its subqueries may refer to are known.
+// Parameters and function calls are numbered as they were the first time.
+func (p *Parser) reparseProjection(text string, orderedParams, namedParams, calls int) (bool, []expr.Expr, []planner.ProjectedField, error) {
+	s, buf := p.s, p.buf
+	lastOrdered, lastNamed, lastCalls := p.orderedParams, p.namedParams, p.calls
+	p.s, p.buf = scanner.NewBufScanner(strings.NewReader(text)), nil
+	p.orderedParams, p.namedParams, p.calls = orderedParams, namedParams, calls
+	defer func() {
+		p.s, p.buf = s, buf
+		p.orderedParams, p.namedParams, p.calls = lastOrdered, lastNamed, lastCalls
+	}()
+
+	return p.parseProjection()
 }
 
 // parseResultFields parses the list of result fields.
@@ -109,7 +273,7 @@ func (p *Parser) parseResultField() (planner.ProjectedField, error) {
 	// Paths may be quoted, we make sure we name the result path
 	// with the unquoted name instead.
 	if fs, ok := e.(expr.Path); ok {
-		lit = fs.String()
+		lit = unquotedPath(document.Path(fs))
 	}
 
 	rf := planner.ProjectedExpr{Expr: e, ExprName: lit}
@@ -128,30 +292,198 @@ func (p *Parser) parseResultField() (planner.ProjectedField, error) {
 	return rf, nil
 }
 
-func (p *Parser) parseDistinct() (bool, error) {
+// unquotedPath returns the string representation of p without quoting
+// the names of its fields.
+func unquotedPath(p document.Path) string {
+	var b strings.Builder
+
+	for i := range p {
+		if p[i].FieldName != "" {
+			if i != 0 {
+				b.WriteRune('.')
+			}
+			b.WriteString(p[i].FieldName)
+		} else if p[i].Wildcard {
+			b.WriteString("[*]")
+		} else {
+			b.WriteString("[" + strconv.Itoa(p[i].ArrayIndex) + "]")
+		}
+	}
+
+	return b.String()
+}
+
+// parseDistinct parses "DISTINCT [ON (expr [, expr...])]".
+func (p *Parser) parseDistinct() (bool, []expr.Expr, error) {
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.DISTINCT {
 		p.Unscan()
-		return false, nil
+		return false, nil, nil
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		p.Unscan()
+		return true, nil, nil
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.LPAREN {
+		return false, nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	exprs, err := p.parseExprListUntil(scanner.RPAREN)
+	if err != nil {
+		return false, nil, err
+	}
+	if len(exprs) == 0 {
+		return false, nil, &ParseError{Message: "DISTINCT ON requires at least one expression", Pos: pos}
 	}
 
-	return true, nil
+	return true, exprs, nil
 }
 
-func (p *Parser) parseFrom() (string, bool, error) {
+// parseFrom parses "FROM table_name" or "FROM UNNEST(table_name.path)".
+// If UNNEST is used, it also returns the path of the array to unnest, without the table name.
+func (p *Parser) parseFrom() (string, document.Path, bool, error) {
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.FROM {
 		p.Unscan()
-		return "", false, nil
+		return "", nil, false, nil
+	}
+
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.UNNEST {
+		// UNNEST is only a keyword if it is followed by a parenthesis,
+		// otherwise it is the name of the table
+		name := p.s.Curr().Raw
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+			p.Unscan()
+			return name, nil, true, nil
+		}
+		p.Unscan()
+
+		tableName, path, err := p.parseUnnest()
+		return tableName, path, true, err
 	}
+	p.Unscan()
 
 	// Parse table name
 	ident, err := p.parseIdent()
 	if err != nil {
 		pErr := err.(*ParseError)
 		pErr.Expected = []string{"table_name"}
-		return ident, true, pErr
+		return ident, nil, true, pErr
+	}
+
+	return ident, nil, true, nil
+}
+
+// parseUnnest parses "(table_name.path)".
+// The path must be qualified with the name of the table that contains the array.
+// This function assumes the UNNEST token has already been consumed.
+func (p *Parser) parseUnnest() (string, document.Path, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return "", nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	_, pos, lit := p.ScanIgnoreWhitespace()
+	p.Unscan()
+	path, err := p.parsePath()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(path) < 2 || path[0].FieldName == "" {
+		return "", nil, &ParseError{
+			Message: "the path of UNNEST must be qualified with the name of its table, e.g. table_name.a",
+			Found:   lit,
+			Pos:     pos,
+		}
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return "", nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return path[0].FieldName, path[1:], nil
+}
+
+// parseJoin parses a join with the given table: "[INNER | LEFT [OUTER] | RIGHT [OUTER]] JOIN".
+// The join condition must compare a field of each table using the = operator,
+// and both paths must be qualified with the name of their table.
+func (p *Parser) parseJoin(tableName string) (*joinConfig, error) {
+	var cfg joinConfig
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.JOIN:
+	case scanner.INNER, scanner.LEFT, scanner.RIGHT:
+		switch tok {
+		case scanner.LEFT:
+			cfg.Kind = planner.LeftJoin
+		case scanner.RIGHT:
+			cfg.Kind = planner.RightJoin
+		}
+
+		tok, pos, lit = p.ScanIgnoreWhitespace()
+		if tok == scanner.OUTER && cfg.Kind != planner.InnerJoin {
+			tok, pos, lit = p.ScanIgnoreWhitespace()
+		}
+		if tok != scanner.JOIN {
+			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"JOIN"}, pos)
+		}
+	default:
+		p.Unscan()
+		return nil, nil
+	}
+
+	// Parse table name
+	joined, err := p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return nil, pErr
+	}
+	if joined == tableName {
+		return nil, &ParseError{Message: fmt.Sprintf("cannot join table %q with itself", joined), Pos: pos}
+	}
+	if scope := p.currentScope(); scope != nil {
+		scope.tables = append(scope.tables, joined)
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	_, pos, lit = p.ScanIgnoreWhitespace()
+	p.Unscan()
+	e, _, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := e.(expr.Operator); ok && op.Token() == scanner.EQ {
+		for _, side := range []expr.Expr{op.LeftHand(), op.RightHand()} {
+			path, ok := side.(expr.Path)
+			if !ok || len(path) < 2 || path[0].FieldName == "" {
+				break
+			}
+
+			jt := planner.JoinedTable{TableName: path[0].FieldName, Path: document.Path(path[1:])}
+			switch jt.TableName {
+			case tableName:
+				cfg.Left = jt
+			case joined:
+				cfg.Right = jt
+			}
+		}
+	}
+
+	if cfg.Left.TableName == "" || cfg.Right.TableName == "" {
+		return nil, &ParseError{
+			Message: fmt.Sprintf("join condition must compare a field of each table with the = operator, e.g. %s.a = %s.b", tableName, joined),
+			Found:   lit,
+			Pos:     pos,
+		}
 	}
 
-	return ident, true, nil
+	return &cfg, nil
 }
 
 func (p *Parser) parseGroupBy() (expr.Expr, error) {
@@ -171,31 +503,54 @@ func (p *Parser) parseGroupBy() (expr.Expr, error) {
 	return e, err
 }
 
-func (p *Parser) parseOrderBy() (expr.Path, scanner.Token, error) {
+func (p *Parser) parseHaving() (expr.Expr, error) {
+	// parse HAVING token
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.HAVING {
+		p.Unscan()
+		return nil, nil
+	}
+
+	e, _, err := p.ParseExpr()
+	return e, err
+}
+
+func (p *Parser) parseOrderBy() ([]planner.SortField, error) {
 	// parse ORDER token
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ORDER {
 		p.Unscan()
-		return nil, 0, nil
+		return nil, nil
 	}
 
 	// parse BY token
 	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.BY {
-		return nil, 0, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"BY"}, pos)
 	}
 
-	// parse path
-	path, err := p.parsePath()
-	if err != nil {
-		return nil, 0, err
-	}
+	var fields []planner.SortField
+	for {
+		// parse expression
+		e, _, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
 
-	// parse optional ASC or DESC
-	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
-		return expr.Path(path), tok, nil
-	}
-	p.Unscan()
+		f := planner.SortField{Expr: e}
+
+		// parse optional ASC or DESC
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.ASC || tok == scanner.DESC {
+			f.Direction = tok
+		} else {
+			p.Unscan()
+		}
+
+		fields = append(fields, f)
 
-	return expr.Path(path), 0, nil
+		// parse the next path, if any
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			return fields, nil
+		}
+	}
 }
 
 func (p *Parser) parseLimit() (expr.Expr, error) {
@@ -220,27 +575,158 @@ func (p *Parser) parseOffset() (expr.Expr, error) {
 	return e, err
 }
 
+// hasParams returns true if e refers to a positional or a named parameter.
+// Such expressions can only be evaluated once the query is run.
+func hasParams(e expr.Expr) bool {
+	switch t := e.(type) {
+	case expr.PositionalParam, expr.NamedParam:
+		return true
+	case expr.Parentheses:
+		return hasParams(t.E)
+	case expr.CastFunc:
+		return hasParams(t.Expr)
+	case expr.Operator:
+		return hasParams(t.LeftHand()) || hasParams(t.RightHand())
+	}
+
+	return false
+}
+
 // SelectConfig holds SELECT configuration.
 type selectConfig struct {
-	TableName        string
-	Distinct         bool
-	WhereExpr        expr.Expr
-	GroupByExpr      expr.Expr
-	OrderBy          expr.Path
-	OrderByDirection scanner.Token
-	OffsetExpr       expr.Expr
-	LimitExpr        expr.Expr
-	ProjectionExprs  []planner.ProjectedField
+	TableName       string
+	Unnest          document.Path
+	CTE             *planner.CTE
+	Join            *joinConfig
+	Distinct        bool
+	DistinctOn      []expr.Expr
+	WhereExpr       expr.Expr
+	GroupByExpr     expr.Expr
+	HavingExpr      expr.Expr
+	OrderBy         []planner.SortField
+	OffsetExpr      expr.Expr
+	LimitExpr       expr.Expr
+	ProjectionExprs []planner.ProjectedField
+}
+
+// overFuncs returns the window functions followed by an OVER clause
+// used by the projected fields and the ORDER BY clause.
+func (cfg selectConfig) overFuncs() []expr.OverFunc {
+	var funcs []expr.OverFunc
+
+	var walk func(e expr.Expr)
+	walk = func(e expr.Expr) {
+		switch t := e.(type) {
+		case expr.OverFunc:
+			for _, f := range funcs {
+				if f.IsEqual(t) {
+					return
+				}
+			}
+			funcs = append(funcs, t)
+		case expr.Parentheses:
+			walk(t.E)
+		case expr.CastFunc:
+			walk(t.Expr)
+		case expr.LiteralExprList:
+			for _, e := range t {
+				walk(e)
+			}
+		case expr.CaseExpr:
+			walk(t.Operand)
+			for _, w := range t.Whens {
+				walk(w.When)
+				walk(w.Then)
+			}
+			walk(t.Else)
+		case expr.Operator:
+			walk(t.LeftHand())
+			walk(t.RightHand())
+		}
+	}
+
+	for _, pe := range cfg.ProjectionExprs {
+		if pre, ok := pe.(planner.ProjectedExpr); ok {
+			walk(pre.Expr)
+		}
+	}
+
+	for _, f := range cfg.OrderBy {
+		walk(f.Expr)
+	}
+
+	return funcs
+}
+
+// distinctOnSortExprs returns the leading ORDER BY expressions matching
+// the DISTINCT ON expressions, or an error if they don't match, in any order.
+func (cfg selectConfig) distinctOnSortExprs() ([]expr.Expr, error) {
+	on := make(map[string]bool, len(cfg.DistinctOn))
+	for _, e := range cfg.DistinctOn {
+		on[fmt.Sprint(expr.Unparenthesize(e))] = true
+	}
+
+	if len(cfg.OrderBy) < len(on) {
+		return nil, errDistinctOnOrderBy
+	}
+
+	exprs := make([]expr.Expr, len(on))
+	for i, f := range cfg.OrderBy[:len(on)] {
+		s := fmt.Sprint(expr.Unparenthesize(f.Expr))
+		if !on[s] {
+			return nil, errDistinctOnOrderBy
+		}
+		delete(on, s)
+
+		exprs[i] = f.Expr
+	}
+
+	return exprs, nil
+}
+
+var errDistinctOnOrderBy = errors.New("DISTINCT ON expressions must match the leading ORDER BY expressions")
+
+// joinConfig holds the configuration of a join.
+type joinConfig struct {
+	Kind        planner.JoinKind
+	Left, Right planner.JoinedTable
+}
+
+// builder returns a function that builds a new tree from the configuration.
+func (cfg selectConfig) builder() selectBuilder {
+	return func() (query.Statement, error) {
+		t, err := cfg.ToTree()
+		if err != nil {
+			return nil, err
+		}
+
+		return t, nil
+	}
 }
 
 // ToTree turns the statement into an expression tree.
 func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 	var n planner.Node
 
-	if cfg.TableName != "" {
+	// joined documents don't belong to a single table
+	tableName := cfg.TableName
+	if cfg.Join != nil {
+		n = planner.NewJoinNode(cfg.Join.Kind, cfg.Join.Left, cfg.Join.Right)
+		tableName = ""
+	} else if cfg.CTE != nil {
+		// the documents of common table expressions don't belong to a table
+		n = planner.NewCTEInputNode(cfg.CTE)
+		tableName = ""
+	} else if cfg.TableName != "" {
 		n = planner.NewTableInputNode(cfg.TableName)
 	}
 
+	// unnested documents share the key of their original document
+	if cfg.Unnest != nil {
+		n = planner.NewUnnestNode(n, cfg.Unnest)
+		tableName = ""
+	}
+
 	if cfg.WhereExpr != nil {
 		n = planner.NewSelectionNode(n, cfg.WhereExpr)
 	}
@@ -267,6 +753,12 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 				continue
 			}
 
+			// window functions are computed after the aggregation
+			switch e.(type) {
+			case expr.WindowFunc, expr.OverFunc:
+				continue
+			}
+
 			// check if this is the same expression as the one used in the GROUP BY clause
 			if expr.Equal(e, cfg.GroupByExpr) {
 				aggregators = append(aggregators, &planner.ProjectedGroupAggregatorBuilder{Expr: pre.Expr})
@@ -282,6 +774,12 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 			return nil, fmt.Errorf("field %q must appear in the GROUP BY clause or be used in an aggregate function", invalidProjectedField)
 		}
 
+		var err error
+		aggregators, err = cfg.havingAggregators(aggregators)
+		if err != nil {
+			return nil, err
+		}
+
 		// add Aggregation node
 		n = planner.NewAggregationNode(n, aggregators)
 	} else {
@@ -302,57 +800,293 @@ func (cfg selectConfig) ToTree() (*planner.Tree, error) {
 			}
 		}
 
+		var err error
+		aggregators, err = cfg.havingAggregators(aggregators)
+		if err != nil {
+			return nil, err
+		}
+
 		// add Aggregation node
 		if len(aggregators) > 0 {
 			n = planner.NewAggregationNode(n, aggregators)
 		}
 	}
 
-	n = planner.NewProjectionNode(n, cfg.ProjectionExprs, cfg.TableName)
-
-	if cfg.Distinct {
-		n = planner.NewDedupNode(n, cfg.TableName)
+	// the HAVING clause filters the aggregated documents
+	if cfg.HavingExpr != nil {
+		n = planner.NewSelectionNode(n, cfg.HavingExpr)
 	}
 
-	if cfg.OrderBy != nil {
-		n = planner.NewSortNode(n, cfg.OrderBy, cfg.OrderByDirection)
+	// window functions with an OVER clause are computed before the projection
+	if overFuncs := cfg.overFuncs(); n != nil && len(overFuncs) > 0 {
+		n = planner.NewOverNode(n, overFuncs)
 	}
 
-	if cfg.OffsetExpr != nil {
-		v, err := cfg.OffsetExpr.Eval(&expr.Environment{})
+	n = planner.NewProjectionNode(n, cfg.ProjectionExprs, tableName)
+
+	// DISTINCT ON returns the first document of each group once they are sorted
+	if cfg.DistinctOn != nil && cfg.OrderBy != nil {
+		exprs, err := cfg.distinctOnSortExprs()
 		if err != nil {
 			return nil, err
 		}
 
-		if !v.Type.IsNumber() {
-			return nil, fmt.Errorf("offset expression must evaluate to a number, got %q", v.Type)
+		n = planner.NewSortNode(n, cfg.OrderBy...)
+		n = planner.NewDedupOnNode(n, tableName, exprs)
+	} else {
+		if cfg.DistinctOn != nil {
+			n = planner.NewDedupOnNode(n, tableName, cfg.DistinctOn)
+		} else if cfg.Distinct {
+			n = planner.NewDedupNode(n, tableName)
 		}
 
-		v, err = v.CastAsInteger()
-		if err != nil {
-			return nil, err
+		if cfg.OrderBy != nil {
+			n = planner.NewSortNode(n, cfg.OrderBy...)
 		}
+	}
+
+	// window functions are computed once the stream is sorted
+	var windowFields []planner.WindowField
+	for _, pe := range cfg.ProjectionExprs {
+		if pre, ok := pe.(planner.ProjectedExpr); ok {
+			if wf, ok := pre.Expr.(expr.WindowFunc); ok {
+				windowFields = append(windowFields, planner.WindowField{Name: pre.ExprName, Func: wf})
+			}
+		}
+	}
 
-		n = planner.NewOffsetNode(n, int(v.V.(int64)))
+	if len(windowFields) > 0 {
+		n = planner.NewWindowNode(n, windowFields)
+	}
+
+	if cfg.OffsetExpr != nil {
+		if hasParams(cfg.OffsetExpr) {
+			n = planner.NewOffsetExprNode(n, cfg.OffsetExpr)
+		} else {
+			offset, err := planner.EvalPaginationExpr("offset", cfg.OffsetExpr, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			n = planner.NewOffsetNode(n, offset)
+		}
 	}
 
 	if cfg.LimitExpr != nil {
-		v, err := cfg.LimitExpr.Eval(&expr.Environment{})
+		var err error
+		n, err = newLimitNode(n, cfg.LimitExpr)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	return &planner.Tree{Root: n}, nil
+}
+
+// newLimitNode creates a limit node. The limit is evaluated when the query is run
+// if it refers to parameters, otherwise it is evaluated once.
+func newLimitNode(n planner.Node, e expr.Expr) (planner.Node, error) {
+	if hasParams(e) {
+		return planner.NewLimitExprNode(n, e), nil
+	}
+
+	limit, err := planner.EvalPaginationExpr("limit", e, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return planner.NewLimitNode(n, limit), nil
+}
+
+// havingAggregators returns the list of aggregators with the aggregation functions
+// used by the HAVING clause that are not already computed for the projection.
+// Outside of these functions, the HAVING clause can only refer to the GROUP BY expression.
+func (cfg selectConfig) havingAggregators(aggregators []document.AggregatorBuilder) ([]document.AggregatorBuilder, error) {
+	if cfg.HavingExpr == nil {
+		return aggregators, nil
+	}
+
+	var groupProjected bool
+	for _, agg := range aggregators {
+		if _, ok := agg.(*planner.ProjectedGroupAggregatorBuilder); ok {
+			groupProjected = true
+		}
+	}
+
+	var walk func(e expr.Expr) error
+	walk = func(e expr.Expr) error {
+		switch t := e.(type) {
+		case document.AggregatorBuilder:
+			// the aggregated document contains one field per aggregator,
+			// named after the function
+			name := fmt.Sprintf("%v", t)
+			for _, agg := range aggregators {
+				if _, ok := agg.(*planner.ProjectedGroupAggregatorBuilder); ok {
+					continue
+				}
+				if fmt.Sprintf("%v", agg) == name {
+					return nil
+				}
+			}
+
+			aggregators = append(aggregators, t)
+			return nil
+		case expr.WindowFunc:
+			return fmt.Errorf("window function %q cannot be used in the HAVING clause", t)
+		}
 
-		if !v.Type.IsNumber() {
-			return nil, fmt.Errorf("limit expression must evaluate to a number, got %q", v.Type)
+		if cfg.GroupByExpr != nil && expr.Equal(e, cfg.GroupByExpr) {
+			if !groupProjected {
+				aggregators = append(aggregators, &planner.ProjectedGroupAggregatorBuilder{Expr: cfg.GroupByExpr})
+				groupProjected = true
+			}
+			return nil
 		}
 
-		v, err = v.CastAsInteger()
-		if err != nil {
-			return nil, err
+		switch t := e.(type) {
+		case expr.Path:
+			return fmt.Errorf("field %q must appear in the GROUP BY clause or be used in an aggregate function", t)
+		case expr.Parentheses:
+			return walk(t.E)
+		case expr.CastFunc:
+			return walk(t.Expr)
+		case expr.LiteralExprList:
+			for _, e := range t {
+				if err := walk(e); err != nil {
+					return err
+				}
+			}
+		case expr.Operator:
+			if err := walk(t.LeftHand()); err != nil {
+				return err
+			}
+			return walk(t.RightHand())
 		}
 
-		n = planner.NewLimitNode(n, int(v.V.(int64)))
+		return nil
 	}
 
-	return &planner.Tree{Root: n}, nil
+	err := walk(cfg.HavingExpr)
+	return aggregators, err
+}
+
+// a selectScope holds the tables of a SELECT statement.
+type selectScope struct {
+	tables []string
+	// true if paths were parsed before the tables were known
+	// and may refer to them.
+	unresolved bool
+}
+
+func (s *selectScope) hasTable(name string) bool {
+	for _, t := range s.tables {
+		if t == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Parser) pushScope() *selectScope {
+	s := new(selectScope)
+	p.scopes = append(p.scopes, s)
+	return s
+}
+
+func (p *Parser) popScope() {
+	s := p.scopes[len(p.scopes)-1]
+	p.readTables = append(p.readTables, s.tables...)
+	p.scopes = p.scopes[:len(p.scopes)-1]
+}
+
+func (p *Parser) currentScope() *selectScope {
+	if len(p.scopes) == 0 {
+		return nil
+	}
+
+	return p.scopes[len(p.scopes)-1]
+}
+
+// resolvePath returns an OuterPath if the first field of path is the name of a table
+// of an enclosing SELECT statement and not of the current one.
+// Paths are only resolved once the tables of the current statement are known.
+func (p *Parser) resolvePath(path document.Path) expr.Expr {
+	n := len(p.scopes)
+	if n < 2 || len(path) < 2 || path[0].FieldName == "" {
+		return expr.Path(path)
+	}
+
+	name := path[0].FieldName
+	cur := p.scopes[n-1]
+	if cur.tables == nil {
+		cur.unresolved = true
+		return expr.Path(path)
+	}
+	if cur.hasTable(name) {
+		// without a join, the documents of the subquery are not nested by table
+		if len(cur.tables) == 1 {
+			return expr.Path(path[1:])
+		}
+		return expr.Path(path)
+	}
+
+	for i := n - 2; i >= 0; i-- {
+		if p.scopes[i].tables == nil {
+			p.scopes[i].unresolved = true
+			continue
+		}
+		if p.scopes[i].hasTable(name) {
+			if p.outerRef < 0 || i < p.outerRef {
+				p.outerRef = i
+			}
+
+			return expr.OuterPath{Table: name, Path: expr.Path(path[1:])}
+		}
+	}
+
+	return expr.Path(path)
+}
+
+// parseSubquery parses a SELECT statement used as an expression, followed by a right parenthesis.
+// The subquery is correlated if it refers to the tables of the enclosing statements.
+// This function assumes the left parenthesis and the SELECT token have already been consumed.
+func (p *Parser) parseSubquery() (*expr.Subquery, error) {
+	depth := len(p.scopes)
+	var outerTables []string
+	if depth > 0 {
+		outerTables = append(outerTables, p.scopes[depth-1].tables...)
+	}
+
+	outerRef := p.outerRef
+	p.outerRef = -1
+
+	build, _, err := p.parseSelectBuilder()
+	if err != nil {
+		return nil, err
+	}
+
+	// references to the tables of the enclosing statement make the subquery correlated,
+	// references to the tables of statements further out also apply to the enclosing subquery.
+	correlated := p.outerRef >= 0 && p.outerRef < depth
+	if p.outerRef >= 0 && (outerRef < 0 || p.outerRef < outerRef) {
+		outerRef = p.outerRef
+	}
+	p.outerRef = outerRef
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	// the statement is built once to report errors while parsing
+	_, err = build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &expr.Subquery{
+		Stmt:        planner.NewSubqueryStatement(build),
+		Correlated:  correlated,
+		OuterTables: outerTables,
+	}, nil
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
 	"github.com/stretchr/testify/require"
@@ -134,6 +135,27 @@ func TestParserSelect(t *testing.T) {
 					"test",
 				)),
 			false},
+		{"WithHaving", "SELECT a FROM test GROUP BY a HAVING COUNT(*) > 1",
+			planner.NewTree(
+				planner.NewProjectionNode(
+					planner.NewSelectionNode(
+						planner.NewAggregationNode(
+							planner.NewGroupingNode(
+								planner.NewTableInputNode("test"),
+								expr.Path(parsePath(t, "a")),
+							),
+							[]document.AggregatorBuilder{
+								&planner.ProjectedGroupAggregatorBuilder{Expr: expr.Path(parsePath(t, "a"))},
+								&expr.CountFunc{Wildcard: true},
+							},
+						),
+						expr.Gt(&expr.CountFunc{Wildcard: true}, expr.IntegerValue(1)),
+					),
+					[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+					"test",
+				)),
+			false},
+		{"With Invalid Having", "SELECT a FROM test GROUP BY a HAVING b > 1", nil, true},
 		{"With Invalid GroupBy: Wildcard", "SELECT * FROM test WHERE age = 10 GROUP BY a.b.c", nil, true},
 		{"With Invalid GroupBy: a.b", "SELECT a.b FROM test WHERE age = 10 GROUP BY a.b.c", nil, true},
 		{"WithOrderBy", "SELECT * FROM test WHERE age = 10 ORDER BY a.b.c",
@@ -147,8 +169,7 @@ func TestParserSelect(t *testing.T) {
 						[]planner.ProjectedField{planner.Wildcard{}},
 						"test",
 					),
-					expr.Path(parsePath(t, "a.b.c")),
-					scanner.ASC,
+					planner.SortField{Expr: expr.Path(parsePath(t, "a.b.c")), Direction: scanner.ASC},
 				)),
 			false},
 		{"WithOrderBy ASC", "SELECT * FROM test WHERE age = 10 ORDER BY a.b.c ASC",
@@ -162,8 +183,7 @@ func TestParserSelect(t *testing.T) {
 						[]planner.ProjectedField{planner.Wildcard{}},
 						"test",
 					),
-					expr.Path(parsePath(t, "a.b.c")),
-					scanner.ASC,
+					planner.SortField{Expr: expr.Path(parsePath(t, "a.b.c")), Direction: scanner.ASC},
 				)),
 			false},
 		{"WithOrderBy DESC", "SELECT * FROM test WHERE age = 10 ORDER BY a.b.c DESC",
@@ -177,10 +197,35 @@ func TestParserSelect(t *testing.T) {
 						[]planner.ProjectedField{planner.Wildcard{}},
 						"test",
 					),
-					expr.Path(parsePath(t, "a.b.c")),
-					scanner.DESC,
+					planner.SortField{Expr: expr.Path(parsePath(t, "a.b.c")), Direction: scanner.DESC},
+				)),
+			false},
+		{"WithOrderBy multiple fields", "SELECT * FROM test ORDER BY a.b.c, d DESC, e ASC",
+			planner.NewTree(
+				planner.NewSortNode(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"test",
+					),
+					planner.SortField{Expr: expr.Path(parsePath(t, "a.b.c")), Direction: scanner.ASC},
+					planner.SortField{Expr: expr.Path(parsePath(t, "d")), Direction: scanner.DESC},
+					planner.SortField{Expr: expr.Path(parsePath(t, "e")), Direction: scanner.ASC},
+				)),
+			false},
+		{"WithOrderBy expressions", "SELECT * FROM test ORDER BY price * quantity DESC, pk()",
+			planner.NewTree(
+				planner.NewSortNode(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.Wildcard{}},
+						"test",
+					),
+					planner.SortField{Expr: expr.Mul(expr.Path(parsePath(t, "price")), expr.Path(parsePath(t, "quantity"))), Direction: scanner.DESC},
+					planner.SortField{Expr: &expr.PKFunc{}, Direction: scanner.ASC},
 				)),
 			false},
+		{"WithOrderBy trailing comma", "SELECT * FROM test ORDER BY a, LIMIT 10", nil, true},
 		{"WithLimit", "SELECT * FROM test WHERE age = 10 LIMIT 20",
 			planner.NewTree(
 				planner.NewLimitNode(
@@ -227,6 +272,22 @@ func TestParserSelect(t *testing.T) {
 				)),
 			false},
 		{"WithOffsetThenLimit", "SELECT * FROM test WHERE age = 10 OFFSET 20 LIMIT 10", nil, true},
+		{"WithLimitParam", "SELECT * FROM test LIMIT ? OFFSET ?",
+			planner.NewTree(
+				planner.NewLimitExprNode(
+					planner.NewOffsetExprNode(
+						planner.NewProjectionNode(
+							planner.NewTableInputNode("test"),
+							[]planner.ProjectedField{planner.Wildcard{}},
+							"test",
+						),
+						expr.PositionalParam(2),
+					),
+					expr.PositionalParam(1),
+				)),
+			false},
+		{"WithNegativeLimit", "SELECT * FROM test LIMIT -1", nil, true},
+		{"WithTextOffset", "SELECT * FROM test OFFSET 'a'", nil, true},
 		{"With aggregation function", "SELECT COUNT(*) FROM test",
 			planner.NewTree(
 				planner.NewProjectionNode(
@@ -238,6 +299,106 @@ func TestParserSelect(t *testing.T) {
 					"test",
 				)),
 			false},
+		{"WithJoin", "SELECT a FROM foo JOIN bar ON foo.a = bar.b.c",
+			planner.NewTree(planner.NewProjectionNode(
+				planner.NewJoinNode(planner.InnerJoin,
+					planner.JoinedTable{TableName: "foo", Path: parsePath(t, "a")},
+					planner.JoinedTable{TableName: "bar", Path: parsePath(t, "b.c")},
+				),
+				[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+				"",
+			)),
+			false},
+		{"WithInnerJoinReversedCondition", "SELECT * FROM foo INNER JOIN bar ON bar.b = foo.a WHERE a > 1",
+			planner.NewTree(planner.NewProjectionNode(
+				planner.NewSelectionNode(
+					planner.NewJoinNode(planner.InnerJoin,
+						planner.JoinedTable{TableName: "foo", Path: parsePath(t, "a")},
+						planner.JoinedTable{TableName: "bar", Path: parsePath(t, "b")},
+					),
+					expr.Gt(expr.Path(parsePath(t, "a")), expr.IntegerValue(1)),
+				),
+				[]planner.ProjectedField{planner.Wildcard{}},
+				"",
+			)),
+			false},
+		{"WithLeftOuterJoin", "SELECT a FROM foo LEFT OUTER JOIN bar ON foo.a = bar.b",
+			planner.NewTree(planner.NewProjectionNode(
+				planner.NewJoinNode(planner.LeftJoin,
+					planner.JoinedTable{TableName: "foo", Path: parsePath(t, "a")},
+					planner.JoinedTable{TableName: "bar", Path: parsePath(t, "b")},
+				),
+				[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+				"",
+			)),
+			false},
+		{"WithRightJoin", "SELECT a FROM foo RIGHT JOIN bar ON foo.a = bar.b",
+			planner.NewTree(planner.NewProjectionNode(
+				planner.NewJoinNode(planner.RightJoin,
+					planner.JoinedTable{TableName: "foo", Path: parsePath(t, "a")},
+					planner.JoinedTable{TableName: "bar", Path: parsePath(t, "b")},
+				),
+				[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+				"",
+			)),
+			false},
+		{"WithInnerOuterJoin", "SELECT * FROM foo INNER OUTER JOIN bar ON foo.a = bar.b", nil, true},
+		{"WithLeftWithoutJoin", "SELECT * FROM foo LEFT OUTER bar ON foo.a = bar.b", nil, true},
+		{"WithJoinUnqualifiedCondition", "SELECT * FROM foo JOIN bar ON a = b", nil, true},
+		{"WithJoinSameTableCondition", "SELECT * FROM foo JOIN bar ON foo.a = foo.b", nil, true},
+		{"WithJoinNonEqualityCondition", "SELECT * FROM foo JOIN bar ON foo.a > bar.b", nil, true},
+		{"WithJoinMissingOn", "SELECT * FROM foo JOIN bar", nil, true},
+		{"WithSelfJoin", "SELECT * FROM foo JOIN foo ON foo.a = foo.b", nil, true},
+		{"WithInnerWithoutJoin", "SELECT * FROM foo INNER bar ON foo.a = bar.b", nil, true},
+		{"WithUnnest", "SELECT a, b.c FROM UNNEST(foo.b) WHERE b.c > 1",
+			planner.NewTree(planner.NewProjectionNode(
+				planner.NewSelectionNode(
+					planner.NewUnnestNode(planner.NewTableInputNode("foo"), parsePath(t, "b")),
+					expr.Gt(expr.Path(parsePath(t, "b.c")), expr.IntegerValue(1)),
+				),
+				[]planner.ProjectedField{
+					planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"},
+					planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "b.c")), ExprName: "b.c"},
+				},
+				"",
+			)),
+			false},
+		{"WithUnnestNestedPath", "SELECT * FROM UNNEST(foo.a.b[0])",
+			planner.NewTree(planner.NewProjectionNode(
+				planner.NewUnnestNode(planner.NewTableInputNode("foo"), parsePath(t, "a.b[0]")),
+				[]planner.ProjectedField{planner.Wildcard{}},
+				"",
+			)),
+			false},
+		{"WithUnnestUnqualifiedPath", "SELECT * FROM UNNEST(a)", nil, true},
+		{"WithUnnestWildcardPath", "SELECT * FROM UNNEST(foo.a[*])", nil, true},
+		{"WithUnnestMissingParenthesis", "SELECT * FROM UNNEST foo.a", nil, true},
+		{"WithUnnestJoin", "SELECT * FROM UNNEST(foo.a) JOIN bar ON foo.a = bar.b", nil, true},
+		{"WithDistinct", "SELECT DISTINCT a FROM test",
+			planner.NewTree(
+				planner.NewDedupNode(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+						"test",
+					),
+					"test",
+				)),
+			false},
+		{"WithDistinctOn", "SELECT DISTINCT ON (b, c + 1) a FROM test",
+			planner.NewTree(
+				planner.NewDedupOnNode(
+					planner.NewProjectionNode(
+						planner.NewTableInputNode("test"),
+						[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+						"test",
+					),
+					"test",
+					[]expr.Expr{expr.Path(parsePath(t, "b")), expr.Add(expr.Path(parsePath(t, "c")), expr.IntegerValue(1))},
+				)),
+			false},
+		{"WithDistinctOnWithoutExpr", "SELECT DISTINCT ON () a FROM test", nil, true},
+		{"WithDistinctOnWithoutParentheses", "SELECT DISTINCT ON b a FROM test", nil, true},
 		{"Invalid use of MIN() aggregator", "SELECT * FROM test LIMIT min(0)", nil, true},
 		{"Invalid use of COUNT() aggregator", "SELECT * FROM test OFFSET x(*)", nil, true},
 		{"Invalid use of MAX() aggregator", "SELECT * FROM test LIMIT max(0)", nil, true},
@@ -258,3 +419,62 @@ func TestParserSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestParserCompoundSelect(t *testing.T) {
+	tree := func(tableName string, fields ...string) *planner.Tree {
+		var pfs []planner.ProjectedField
+		for _, f := range fields {
+			pfs = append(pfs, planner.ProjectedExpr{Expr: expr.Path(parsePath(t, f)), ExprName: f})
+		}
+		return planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode(tableName), pfs, tableName))
+	}
+
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		mustFail bool
+	}{
+		{"Except", "SELECT a FROM foo EXCEPT SELECT b FROM bar",
+			&planner.CompoundSelectStmt{Left: tree("foo", "a"), Right: tree("bar", "b"), Operator: planner.Except}, false},
+		{"Intersect all", "SELECT a, b FROM foo INTERSECT ALL SELECT c, d FROM bar",
+			&planner.CompoundSelectStmt{Left: tree("foo", "a", "b"), Right: tree("bar", "c", "d"), Operator: planner.Intersect, All: true}, false},
+		{"Left associative", "SELECT a FROM foo EXCEPT SELECT a FROM bar INTERSECT SELECT a FROM baz",
+			&planner.CompoundSelectStmt{
+				Left:     &planner.CompoundSelectStmt{Left: tree("foo", "a"), Right: tree("bar", "a"), Operator: planner.Except},
+				Right:    tree("baz", "a"),
+				Operator: planner.Intersect,
+			}, false},
+		{"Wildcard", "SELECT * FROM foo EXCEPT SELECT a, b FROM bar",
+			&planner.CompoundSelectStmt{
+				Left:     planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("foo"), []planner.ProjectedField{planner.Wildcard{}}, "foo")),
+				Right:    tree("bar", "a", "b"),
+				Operator: planner.Except,
+			}, false},
+		{"Union", "SELECT a FROM foo UNION SELECT b FROM bar",
+			&planner.CompoundSelectStmt{Left: tree("foo", "a"), Right: tree("bar", "b"), Operator: planner.Union}, false},
+		{"Chained unions", "SELECT a FROM foo UNION ALL SELECT a FROM bar UNION SELECT a FROM baz",
+			&planner.CompoundSelectStmt{
+				Left:     &planner.CompoundSelectStmt{Left: tree("foo", "a"), Right: tree("bar", "a"), Operator: planner.Union, All: true},
+				Right:    tree("baz", "a"),
+				Operator: planner.Union,
+			}, false},
+		{"Arity mismatch", "SELECT a FROM foo EXCEPT SELECT a, b FROM bar", nil, true},
+		{"Union arity mismatch", "SELECT a FROM foo UNION SELECT a, b FROM bar", nil, true},
+		{"Missing SELECT", "SELECT a FROM foo INTERSECT FROM bar", nil, true},
+		{"Missing ALL", "SELECT a FROM foo EXCEPT DISTINCT SELECT a FROM bar", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if !test.mustFail {
+				require.NoError(t, err)
+				require.Len(t, q.Statements, 1)
+				require.EqualValues(t, test.expected, q.Statements[0])
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// isSequenceToken returns true if the token is the SEQUENCE word.
+// SEQUENCE is not a keyword, to allow using it as a field name.
+func isSequenceToken(tok scanner.Token, lit string) bool {
+	return tok == scanner.IDENT && strings.EqualFold(lit, "SEQUENCE")
+}
+
+// parseCreateSequenceStatement parses a create sequence string and returns a Statement AST object.
+// This function assumes the CREATE SEQUENCE tokens have already been consumed.
+func (p *Parser) parseCreateSequenceStatement() (query.CreateSequenceStmt, error) {
+	var stmt query.CreateSequenceStmt
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseIfNotExists()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse sequence name
+	stmt.SequenceName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"sequence_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
+
+// parseDropSequenceStatement parses a drop sequence string and returns a Statement AST object.
+// This function assumes the DROP SEQUENCE tokens have already been consumed.
+func (p *Parser) parseDropSequenceStatement() (query.DropSequenceStmt, error) {
+	var stmt query.DropSequenceStmt
+	var err error
+
+	// Parse "IF"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+		stmt.IfExists = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse sequence name
+	stmt.SequenceName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"sequence_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Create", "CREATE SEQUENCE seq", query.CreateSequenceStmt{SequenceName: "seq"}, false},
+		{"Create case insensitive", "create sequence seq", query.CreateSequenceStmt{SequenceName: "seq"}, false},
+		{"Create if not exists", "CREATE SEQUENCE IF NOT EXISTS seq", query.CreateSequenceStmt{SequenceName: "seq", IfNotExists: true}, false},
+		{"Create without name", "CREATE SEQUENCE", nil, true},
+		{"Drop", "DROP SEQUENCE seq", query.DropSequenceStmt{SequenceName: "seq"}, false},
+		{"Drop if exists", "DROP SEQUENCE IF EXISTS seq", query.DropSequenceStmt{SequenceName: "seq", IfExists: true}, false},
+		{"Drop without name", "DROP SEQUENCE IF EXISTS", nil, true},
+		{"Not a sequence", "CREATE SEQUENCES seq", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+
+	// sequence is not a keyword
+	_, err := ParseQuery("SELECT sequence FROM sequence")
+	require.NoError(t, err)
+}
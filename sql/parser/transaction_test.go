@@ -23,6 +23,15 @@ func TestParserTransactions(t *testing.T) {
 		{"ROLLBACK TRANSACTION", query.RollbackStmt{}, false},
 		{"COMMIT", query.CommitStmt{}, false},
 		{"COMMIT TRANSACTION", query.CommitStmt{}, false},
+		{"SAVEPOINT sp", query.SavepointStmt{Name: "sp"}, false},
+		{"SAVEPOINT", nil, true},
+		{"ROLLBACK TO sp", query.RollbackToSavepointStmt{Name: "sp"}, false},
+		{"ROLLBACK TO SAVEPOINT sp", query.RollbackToSavepointStmt{Name: "sp"}, false},
+		{"ROLLBACK TRANSACTION TO SAVEPOINT sp", query.RollbackToSavepointStmt{Name: "sp"}, false},
+		{"ROLLBACK TO", nil, true},
+		{"RELEASE sp", query.ReleaseSavepointStmt{Name: "sp"}, false},
+		{"RELEASE SAVEPOINT sp", query.ReleaseSavepointStmt{Name: "sp"}, false},
+		{"RELEASE", nil, true},
 	}
 
 	for _, test := range tests {
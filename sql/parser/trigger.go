@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parseCreateTriggerStatement parses a create trigger string and returns a Statement AST object.
+// This function assumes the CREATE TRIGGER tokens have already been consumed.
+func (p *Parser) parseCreateTriggerStatement() (query.CreateTriggerStmt, error) {
+	var stmt query.CreateTriggerStmt
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseIfNotExists()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse trigger name
+	stmt.TriggerName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"trigger_name"}
+		return stmt, pErr
+	}
+
+	// Parse BEFORE or AFTER
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.BEFORE:
+		stmt.Timing = database.TriggerBefore
+	case scanner.AFTER:
+		stmt.Timing = database.TriggerAfter
+	default:
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"BEFORE", "AFTER"}, pos)
+	}
+
+	// Parse the event
+	tok, pos, lit = p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.INSERT:
+		stmt.Event = database.TriggerInsert
+	case scanner.UPDATE:
+		stmt.Event = database.TriggerUpdate
+	case scanner.DELETE:
+		stmt.Event = database.TriggerDelete
+	default:
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"INSERT", "UPDATE", "DELETE"}, pos)
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.ON {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Parse table name
+	stmt.TableName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return stmt, pErr
+	}
+
+	err = p.parseForEachRow()
+	if err != nil {
+		return stmt, err
+	}
+
+	// the text of the statement is stored with the trigger, to be parsed again when it is fired
+	p.raw = new(bytes.Buffer)
+	defer func() { p.raw = nil }()
+
+	_, err = p.parseTriggerStatement()
+	if err != nil {
+		return stmt, err
+	}
+
+	stmt.Statement = strings.TrimSpace(p.raw.String())
+	return stmt, nil
+}
+
+// parseForEachRow parses the optional "FOR EACH ROW" clause. Triggers are always
+// fired once per document, the clause is only accepted for compatibility.
+// FOR, EACH and ROW are not keywords, to allow using them as field names.
+func (p *Parser) parseForEachRow() error {
+	if tok, _, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, "FOR") {
+		p.Unscan()
+		return nil
+	}
+
+	for _, word := range []string{"EACH", "ROW"} {
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.IDENT || !strings.EqualFold(lit, word) {
+			return newParseError(scanner.Tokstr(tok, lit), []string{word}, pos)
+		}
+	}
+
+	return nil
+}
+
+// ParseTriggerStatement parses the statement run by a trigger.
+func (p *Parser) ParseTriggerStatement() (query.Statement, error) {
+	stmt, err := p.parseTriggerStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EOF && tok != scanner.SEMICOLON {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"EOF"}, pos)
+	}
+
+	return stmt, nil
+}
+
+// parseTriggerStatement parses the INSERT, UPDATE or DELETE statement run by a trigger.
+// Paths of the old and new tables refer to the versions of the document that fired the trigger,
+// they are resolved like references to the tables of an enclosing statement.
+func (p *Parser) parseTriggerStatement() (query.Statement, error) {
+	p.pushScope().tables = []string{query.OldTable, query.NewTable}
+	defer p.popScope()
+	p.pushScope().tables = []string{}
+	defer p.popScope()
+	defer func() { p.outerRef = -1 }()
+
+	params := p.orderedParams + p.namedParams
+
+	var stmt query.Statement
+	var err error
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.INSERT:
+		stmt, err = p.parseInsertStatement()
+	case scanner.UPDATE:
+		stmt, err = p.parseUpdateStatement()
+	case scanner.DELETE:
+		stmt, err = p.parseDeleteStatement()
+	default:
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INSERT", "UPDATE", "DELETE"}, pos)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.orderedParams+p.namedParams != params {
+		return nil, &ParseError{Message: "the statement of a trigger cannot use parameters", Pos: pos}
+	}
+
+	return stmt, nil
+}
+
+// parseDropTriggerStatement parses a drop trigger string and returns a Statement AST object.
+// This function assumes the DROP TRIGGER tokens have already been consumed.
+func (p *Parser) parseDropTriggerStatement() (query.DropTriggerStmt, error) {
+	var stmt query.DropTriggerStmt
+	var err error
+
+	// Parse "IF"
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == scanner.IF {
+		// Parse "EXISTS"
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.EXISTS {
+			return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"EXISTS"}, pos)
+		}
+		stmt.IfExists = true
+	} else {
+		p.Unscan()
+	}
+
+	// Parse trigger name
+	stmt.TriggerName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"trigger_name"}
+		return stmt, pErr
+	}
+
+	return stmt, nil
+}
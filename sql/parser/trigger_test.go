@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserCreateTrigger(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"After insert", "CREATE TRIGGER trg AFTER INSERT ON test INSERT INTO log (id) VALUES (new.id)",
+			query.CreateTriggerStmt{TriggerName: "trg", TableName: "test", Timing: database.TriggerAfter, Event: database.TriggerInsert, Statement: "INSERT INTO log (id) VALUES (new.id)"}, false},
+		{"Before update", "CREATE TRIGGER trg BEFORE UPDATE ON test FOR EACH ROW UPDATE log SET n = n + 1 WHERE id = old.id",
+			query.CreateTriggerStmt{TriggerName: "trg", TableName: "test", Timing: database.TriggerBefore, Event: database.TriggerUpdate, Statement: "UPDATE log SET n = n + 1 WHERE id = old.id"}, false},
+		{"If not exists", "CREATE TRIGGER IF NOT EXISTS trg AFTER DELETE ON test DELETE FROM log WHERE id = old.id",
+			query.CreateTriggerStmt{TriggerName: "trg", TableName: "test", IfNotExists: true, Timing: database.TriggerAfter, Event: database.TriggerDelete, Statement: "DELETE FROM log WHERE id = old.id"}, false},
+		{"Followed by a statement", "CREATE TRIGGER trg AFTER DELETE ON test DELETE FROM log ; SELECT 1",
+			query.CreateTriggerStmt{TriggerName: "trg", TableName: "test", Timing: database.TriggerAfter, Event: database.TriggerDelete, Statement: "DELETE FROM log"}, false},
+		{"Missing timing", "CREATE TRIGGER trg INSERT ON test DELETE FROM log", nil, true},
+		{"Missing ON", "CREATE TRIGGER trg AFTER INSERT test DELETE FROM log", nil, true},
+		{"Bad FOR EACH ROW", "CREATE TRIGGER trg AFTER INSERT ON test FOR ROW DELETE FROM log", nil, true},
+		{"Select", "CREATE TRIGGER trg AFTER INSERT ON test SELECT * FROM log", nil, true},
+		{"Param", "CREATE TRIGGER trg AFTER INSERT ON test DELETE FROM log WHERE a = ?", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
+
+func TestParserTriggerStatement(t *testing.T) {
+	p := NewParser(strings.NewReader("INSERT INTO log VALUES {a: new.a, b: b, c: old.c.d}"))
+	stmt, err := p.ParseTriggerStatement()
+	require.NoError(t, err)
+
+	values := stmt.(query.InsertStmt).Values
+	require.EqualValues(t, expr.LiteralExprList{
+		expr.KVPairs{
+			{K: "a", V: expr.OuterPath{Table: query.NewTable, Path: expr.Path(parsePath(t, "a"))}},
+			{K: "b", V: expr.Path(parsePath(t, "b"))},
+			{K: "c", V: expr.OuterPath{Table: query.OldTable, Path: expr.Path(parsePath(t, "c.d"))}},
+		},
+	}, values)
+}
+
+func TestParserDropTrigger(t *testing.T) {
+	q, err := ParseQuery("DROP TRIGGER IF EXISTS trg")
+	require.NoError(t, err)
+	require.EqualValues(t, query.DropTriggerStmt{TriggerName: "trg", IfExists: true}, q.Statements[0])
+
+	q, err = ParseQuery("DROP TRIGGER trg")
+	require.NoError(t, err)
+	require.EqualValues(t, query.DropTriggerStmt{TriggerName: "trg"}, q.Statements[0])
+}
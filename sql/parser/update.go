@@ -3,13 +3,14 @@ package parser
 import (
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
 )
 
 // parseUpdateStatement parses a update string and returns a Statement AST object.
 // This function assumes the UPDATE token has already been consumed.
-func (p *Parser) parseUpdateStatement() (*planner.Tree, error) {
+func (p *Parser) parseUpdateStatement() (query.Statement, error) {
 	var cfg updateConfig
 	var err error
 
@@ -41,7 +42,7 @@ func (p *Parser) parseUpdateStatement() (*planner.Tree, error) {
 		return nil, err
 	}
 
-	return cfg.ToTree(), nil
+	return p.parseReturning(cfg.ToTree(), cfg.TableName)
 }
 
 // parseSetClause parses the "SET" clause of the query.
@@ -100,11 +101,11 @@ func (p *Parser) parseUnsetClause() ([]string, error) {
 		}
 
 		// Scan the identifier for the path to unset.
-		tok, pos, lit := p.ScanIgnoreWhitespace()
-		if tok != scanner.IDENT {
-			return nil, newParseError(scanner.Tokstr(tok, lit), []string{"identifier"}, pos)
+		field, err := p.parseIdent()
+		if err != nil {
+			return nil, err
 		}
-		fields = append(fields, lit)
+		fields = append(fields, field)
 
 		firstField = false
 	}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
 )
@@ -12,7 +13,7 @@ func TestParserUpdate(t *testing.T) {
 	tests := []struct {
 		name     string
 		s        string
-		expected *planner.Tree
+		expected query.Statement
 		errored  bool
 	}{
 		{"SET/No cond", "UPDATE test SET a = 1",
@@ -117,11 +118,24 @@ func TestParserUpdate(t *testing.T) {
 					"test",
 				)),
 			false},
+		{"SET/With returning", "UPDATE test SET a = 1 RETURNING a",
+			mustNewReturningStmt(t, planner.NewTree(
+				planner.NewReplacementNode(
+					planner.NewSetNode(
+						planner.NewTableInputNode("test"),
+						parsePath(t, "a"), expr.IntegerValue(1),
+					),
+					"test",
+				)),
+				[]planner.ProjectedField{planner.ProjectedExpr{Expr: expr.Path(parsePath(t, "a")), ExprName: "a"}},
+				"test"),
+			false},
 		{"Trailing comma", "UPDATE test SET a = 1, WHERE age = 10", nil, true},
 		{"No SET", "UPDATE test WHERE age = 10", nil, true},
 		{"No pair", "UPDATE test SET WHERE age = 10", nil, true},
 		{"query.Field only", "UPDATE test SET a WHERE age = 10", nil, true},
 		{"No value", "UPDATE test SET a = WHERE age = 10", nil, true},
+		{"Wildcard path", "UPDATE test SET a[*].b = 1", nil, true},
 	}
 
 	for _, test := range tests {
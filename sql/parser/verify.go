@@ -0,0 +1,20 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parseVerifyStatement parses "VERIFY INTEGRITY".
+// This function assumes the VERIFY token has already been consumed.
+// INTEGRITY is not a keyword, to allow using it as a field name.
+func (p *Parser) parseVerifyStatement() (query.Statement, error) {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.IDENT || !strings.EqualFold(lit, "INTEGRITY") {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"INTEGRITY"}, pos)
+	}
+
+	return query.VerifyIntegrityStmt{}, nil
+}
@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserVerifyIntegrity(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		expected query.Statement
+		errored  bool
+	}{
+		{"Integrity", "VERIFY INTEGRITY", query.VerifyIntegrityStmt{}, false},
+		{"Lowercase", "verify integrity", query.VerifyIntegrityStmt{}, false},
+		{"Missing integrity", "VERIFY", nil, true},
+		{"With extra", "VERIFY INTEGRITY test", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+			require.EqualValues(t, test.expected, q.Statements[0])
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parseCreateMaterializedViewStatement parses a create materialized view string and returns a Statement AST object.
+// This function assumes the CREATE MATERIALIZED VIEW tokens have already been consumed.
+func (p *Parser) parseCreateMaterializedViewStatement() (query.CreateMaterializedViewStmt, error) {
+	var stmt query.CreateMaterializedViewStmt
+	var err error
+
+	// Parse IF NOT EXISTS
+	stmt.IfNotExists, err = p.parseIfNotExists()
+	if err != nil {
+		return stmt, err
+	}
+
+	// Parse view name
+	stmt.ViewName, err = p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"view_name"}
+		return stmt, pErr
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.AS {
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"AS"}, pos)
+	}
+
+	// the text of the query is stored with the view, to be parsed again when it is refreshed
+	p.raw = new(bytes.Buffer)
+	defer func() { p.raw = nil }()
+
+	params := p.orderedParams + p.namedParams
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	switch tok {
+	case scanner.SELECT:
+		stmt.Select, err = p.parseSelectStatement()
+	case scanner.WITH:
+		stmt.Select, err = p.parseWithStatement()
+	default:
+		return stmt, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT", "WITH"}, pos)
+	}
+	if err != nil {
+		return stmt, err
+	}
+
+	if p.orderedParams+p.namedParams != params {
+		return stmt, &ParseError{Message: "the query of a materialized view cannot use parameters", Pos: pos}
+	}
+
+	stmt.Query = strings.TrimSpace(p.raw.String())
+	return stmt, nil
+}
+
+// parseRefreshStatement parses "REFRESH MATERIALIZED VIEW view_name".
+// This function assumes the REFRESH token has already been consumed.
+func (p *Parser) parseRefreshStatement() (query.Statement, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.MATERIALIZED {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"MATERIALIZED"}, pos)
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.VIEW {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"VIEW"}, pos)
+	}
+
+	viewName, err := p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"view_name"}
+		return nil, pErr
+	}
+
+	// the query of the view is parsed with the same functions
+	functions := p.functions
+
+	return query.RefreshMaterializedViewStmt{
+		ViewName: viewName,
+		Parse: func(q string) (query.Statement, error) {
+			return NewParserWithOptions(strings.NewReader(q), &Options{Functions: functions}).ParseStatement()
+		},
+	}, nil
+}
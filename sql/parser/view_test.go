@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/sql/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserCreateMaterializedView(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           string
+		viewName    string
+		ifNotExists bool
+		query       string
+		errored     bool
+	}{
+		{"Basic", "CREATE MATERIALIZED VIEW v AS SELECT a, COUNT(*) FROM test GROUP BY a", "v", false, "SELECT a, COUNT(*) FROM test GROUP BY a", false},
+		{"If not exists", "CREATE MATERIALIZED VIEW IF NOT EXISTS v AS SELECT * FROM test", "v", true, "SELECT * FROM test", false},
+		{"With", "CREATE MATERIALIZED VIEW v AS WITH x AS (SELECT * FROM test) SELECT * FROM x", "v", false, "WITH x AS (SELECT * FROM test) SELECT * FROM x", false},
+		{"Followed by a statement", "CREATE MATERIALIZED VIEW v AS SELECT a FROM test ; SELECT 1", "v", false, "SELECT a FROM test", false},
+		{"Missing AS", "CREATE MATERIALIZED VIEW v SELECT * FROM test", "", false, "", true},
+		{"Missing VIEW", "CREATE MATERIALIZED v AS SELECT * FROM test", "", false, "", true},
+		{"Not a select", "CREATE MATERIALIZED VIEW v AS DELETE FROM test", "", false, "", true},
+		{"Positional param", "CREATE MATERIALIZED VIEW v AS SELECT * FROM test WHERE a = ?", "", false, "", true},
+		{"Named param", "CREATE MATERIALIZED VIEW v AS SELECT * FROM test WHERE a = $a", "", false, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			stmt, ok := q.Statements[0].(query.CreateMaterializedViewStmt)
+			require.True(t, ok)
+			require.Equal(t, test.viewName, stmt.ViewName)
+			require.Equal(t, test.ifNotExists, stmt.IfNotExists)
+			require.Equal(t, test.query, stmt.Query)
+			require.NotNil(t, stmt.Select)
+		})
+	}
+}
+
+func TestParserRefreshMaterializedView(t *testing.T) {
+	q, err := ParseQuery("REFRESH MATERIALIZED VIEW v")
+	require.NoError(t, err)
+	require.Len(t, q.Statements, 1)
+
+	stmt, ok := q.Statements[0].(query.RefreshMaterializedViewStmt)
+	require.True(t, ok)
+	require.Equal(t, "v", stmt.ViewName)
+
+	// the stored query is parsed when the view is refreshed
+	s, err := stmt.Parse("SELECT a FROM test")
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	for _, s := range []string{"REFRESH v", "REFRESH MATERIALIZED v", "REFRESH MATERIALIZED VIEW"} {
+		_, err = ParseQuery(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// parseWithStatement parses a select statement preceded by common table expressions:
+// "WITH [RECURSIVE] name [(column [, column]*)] AS (select_stmt) [, ...]* select_stmt".
+// Common table expressions can be read by the statements that follow them.
+// If a list of columns is given, the fields returned by the statement of a common table expression
+// are renamed after the columns, in order.
+// With the RECURSIVE keyword, a common table expression can also read itself.
+// This function assumes the WITH token has already been consumed.
+func (p *Parser) parseWithStatement() (query.Statement, error) {
+	recursive := true
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.RECURSIVE {
+		p.Unscan()
+		recursive = false
+	}
+
+	// common table expressions are only visible to the statement
+	ctes := p.ctes
+	defer func() { p.ctes = ctes }()
+
+	var stmt planner.WithStmt
+	for {
+		_, pos, _ := p.ScanIgnoreWhitespace()
+		p.Unscan()
+
+		cte, err := p.parseCTE(recursive)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range stmt.CTEs {
+			if c.Name == cte.Name {
+				return nil, &ParseError{Message: fmt.Sprintf("common table expression %q is defined more than once", cte.Name), Pos: pos}
+			}
+		}
+		stmt.CTEs = append(stmt.CTEs, cte)
+
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.COMMA {
+			p.Unscan()
+			break
+		}
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SELECT {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	var err error
+	stmt.Stmt, err = p.parseSelectStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt, nil
+}
+
+// parseCTE parses "name [(column [, column]*)] AS (select_stmt)" and makes the common table expression
+// visible to the statements parsed next.
+// If recursive is true, the statement can read the common table expression if it is
+// made of an initial select statement, followed by UNION [ALL] and a select statement
+// that reads it.
+func (p *Parser) parseCTE(recursive bool) (*planner.CTE, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"table_name"}
+		return nil, pErr
+	}
+
+	columns, err := p.parseCTEColumns()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.AS {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"AS"}, pos)
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"("}, pos)
+	}
+
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != scanner.SELECT {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	cte := planner.CTE{Name: name, Columns: columns}
+	var arity int
+	if recursive {
+		arity, err = p.parseRecursiveCTE(&cte)
+	} else {
+		cte.Build, arity, err = p.parseSelectBuilder()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// wildcards can only be expanded at runtime
+	if len(columns) > 0 && arity >= 0 && arity != len(columns) {
+		return nil, &ParseError{
+			Message: fmt.Sprintf("common table expression %q has %d fields but %d columns", name, arity, len(columns)),
+			Pos:     pos,
+		}
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	// the statements are built once to report errors while parsing
+	if _, err = cte.Build(); err != nil {
+		return nil, err
+	}
+	if cte.BuildRecursive != nil {
+		if _, err = cte.BuildRecursive(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !recursive {
+		p.ctes = append(p.ctes, &cte)
+	}
+
+	return &cte, nil
+}
+
+// parseCTEColumns parses the optional list of columns of a common table expression:
+// "(column [, column]*)".
+func (p *Parser) parseCTEColumns() ([]string, error) {
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.LPAREN {
+		p.Unscan()
+		return nil, nil
+	}
+
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	columns, err := p.parseIdentList()
+	if err != nil {
+		pErr := err.(*ParseError)
+		pErr.Expected = []string{"column"}
+		return nil, pErr
+	}
+
+	for i := range columns {
+		for j := 0; j < i; j++ {
+			if columns[i] == columns[j] {
+				return nil, &ParseError{Message: fmt.Sprintf("column %q is specified more than once", columns[i]), Pos: pos}
+			}
+		}
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.RPAREN {
+		return nil, newParseError(scanner.Tokstr(tok, lit), []string{")"}, pos)
+	}
+
+	return columns, nil
+}
+
+// parseRecursiveCTE parses the statement of a recursive common table expression
+// and returns the number of fields it selects, or -1 if it selects a wildcard.
+// This function assumes the SELECT token has already been consumed.
+func (p *Parser) parseRecursiveCTE(cte *planner.CTE) (int, error) {
+	p.ctes = append(p.ctes, cte)
+
+	_, pos, _ := p.ScanIgnoreWhitespace()
+	p.Unscan()
+
+	build, arity, err := p.parseSimpleSelectStatement()
+	if err != nil {
+		return 0, err
+	}
+	cte.Build = build
+
+	// the initial statement is run before the common table expression has any document
+	if p.cteRefs[cte] {
+		return 0, &ParseError{Message: fmt.Sprintf("recursive reference to %q must be after UNION", cte.Name), Pos: pos}
+	}
+
+	tok, pos, _ := p.ScanIgnoreWhitespace()
+	if tok != scanner.UNION {
+		p.Unscan()
+		return arity, nil
+	}
+
+	all := true
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != scanner.ALL {
+		p.Unscan()
+		all = false
+	}
+
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != scanner.SELECT {
+		return 0, newParseError(scanner.Tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	right, n, err := p.parseSimpleSelectStatement()
+	if err != nil {
+		return 0, err
+	}
+
+	// wildcards can only be expanded at runtime
+	if arity >= 0 && n >= 0 && arity != n {
+		return 0, &ParseError{
+			Message: fmt.Sprintf("both sides of %s must select the same number of fields", planner.Union),
+			Pos:     pos,
+		}
+	}
+
+	// a statement that doesn't read itself is a regular union
+	if !p.cteRefs[cte] {
+		cte.Build = func() (query.Statement, error) {
+			l, err := build()
+			if err != nil {
+				return nil, err
+			}
+
+			r, err := right()
+			if err != nil {
+				return nil, err
+			}
+
+			return &planner.CompoundSelectStmt{Left: l, Right: r, Operator: planner.Union, All: all}, nil
+		}
+		return arity, nil
+	}
+
+	cte.BuildRecursive = right
+	cte.All = all
+	return arity, nil
+}
+
+// lookupCTE returns the common table expression with the given name,
+// or nil if there is none.
+func (p *Parser) lookupCTE(name string) *planner.CTE {
+	for i := len(p.ctes) - 1; i >= 0; i-- {
+		if p.ctes[i].Name == name {
+			if p.cteRefs == nil {
+				p.cteRefs = make(map[*planner.CTE]bool)
+			}
+			p.cteRefs[p.ctes[i]] = true
+			return p.ctes[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/sql/planner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserWith(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		ctes      []string
+		recursive []bool
+		errored   bool
+	}{
+		{"Simple", "WITH a AS (SELECT * FROM test) SELECT * FROM a", []string{"a"}, []bool{false}, false},
+		{"Multiple", "WITH a AS (SELECT * FROM test), b AS (SELECT * FROM a) SELECT * FROM b", []string{"a", "b"}, []bool{false, false}, false},
+		{"Union", "WITH a AS (SELECT x FROM test UNION SELECT y FROM test) SELECT * FROM a", []string{"a"}, []bool{false}, false},
+		{"Recursive", "WITH RECURSIVE a AS (SELECT id FROM test WHERE id = 1 UNION ALL SELECT test.id FROM test JOIN a ON test.parent_id = a.id) SELECT * FROM a", []string{"a"}, []bool{true}, false},
+		{"Recursive with subquery", "WITH RECURSIVE a AS (SELECT id FROM test WHERE id = 1 UNION SELECT id FROM test WHERE parent_id IN (SELECT id FROM a)) SELECT * FROM a", []string{"a"}, []bool{true}, false},
+		{"Recursive without reference", "WITH RECURSIVE a AS (SELECT id FROM test UNION SELECT id FROM foo) SELECT * FROM a", []string{"a"}, []bool{false}, false},
+		{"Recursive without union", "WITH RECURSIVE a AS (SELECT id FROM test) SELECT * FROM a", []string{"a"}, []bool{false}, false},
+		{"Reference in the initial statement", "WITH RECURSIVE a AS (SELECT id FROM a UNION SELECT id FROM test) SELECT * FROM a", nil, nil, true},
+		{"Different number of fields", "WITH RECURSIVE a AS (SELECT id FROM test UNION SELECT id, b FROM a) SELECT * FROM a", nil, nil, true},
+		{"Duplicate names", "WITH a AS (SELECT * FROM test), a AS (SELECT * FROM test) SELECT * FROM a", nil, nil, true},
+		{"Missing AS", "WITH a (SELECT * FROM test) SELECT * FROM a", nil, nil, true},
+		{"Missing parentheses", "WITH a AS SELECT * FROM test SELECT * FROM a", nil, nil, true},
+		{"Missing statement", "WITH a AS (SELECT * FROM test)", nil, nil, true},
+		{"Not a select", "WITH a AS (SELECT * FROM test) DELETE FROM a", nil, nil, true},
+		{"Columns", "WITH a(x, y) AS (SELECT b, c FROM test) SELECT x FROM a", []string{"a"}, []bool{false}, false},
+		{"Recursive with columns", "WITH RECURSIVE a(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM a WHERE n < 5) SELECT n FROM a", []string{"a"}, []bool{true}, false},
+		{"Columns with wildcard", "WITH a(x) AS (SELECT * FROM test) SELECT x FROM a", []string{"a"}, []bool{false}, false},
+		{"Empty columns", "WITH a() AS (SELECT b FROM test) SELECT * FROM a", nil, nil, true},
+		{"Duplicate columns", "WITH a(x, x) AS (SELECT b, c FROM test) SELECT * FROM a", nil, nil, true},
+		{"Too many columns", "WITH a(x, y) AS (SELECT b FROM test) SELECT * FROM a", nil, nil, true},
+		{"Too few columns", "WITH RECURSIVE a(n) AS (SELECT 1, 2 UNION SELECT n, 3 FROM a) SELECT * FROM a", nil, nil, true},
+		{"Unclosed columns", "WITH a(x AS (SELECT b FROM test) SELECT * FROM a", nil, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := ParseQuery(test.s)
+			if test.errored {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, q.Statements, 1)
+
+			stmt, ok := q.Statements[0].(*planner.WithStmt)
+			require.True(t, ok)
+			require.Len(t, stmt.CTEs, len(test.ctes))
+			for i, c := range stmt.CTEs {
+				require.Equal(t, test.ctes[i], c.Name)
+				require.Equal(t, test.recursive[i], c.BuildRecursive != nil)
+			}
+		})
+	}
+
+	t.Run("Columns", func(t *testing.T) {
+		q, err := ParseQuery("WITH a(x, y) AS (SELECT b, c FROM test), b AS (SELECT x FROM a) SELECT * FROM b")
+		require.NoError(t, err)
+
+		stmt := q.Statements[0].(*planner.WithStmt)
+		require.Equal(t, []string{"x", "y"}, stmt.CTEs[0].Columns)
+		require.Empty(t, stmt.CTEs[1].Columns)
+		require.Equal(t, "WITH a(x, y), b CTE(b) -> ∏(*)", stmt.String())
+	})
+
+	t.Run("Scope", func(t *testing.T) {
+		// common table expressions are only visible to their statement
+		q, err := ParseQuery("WITH a AS (SELECT * FROM test) SELECT * FROM a; SELECT * FROM a")
+		require.NoError(t, err)
+		require.Len(t, q.Statements, 2)
+		require.Equal(t, "Table(a) -> ∏(*)", q.Statements[1].(*planner.Tree).String())
+	})
+}
@@ -15,6 +15,9 @@ type AggregationNode struct {
 	node
 
 	Aggregators []document.AggregatorBuilder
+	// if true, the documents of each group are adjacent in the stream
+	// and groups are aggregated one at a time.
+	sorted bool
 }
 
 var _ operationNode = (*AggregationNode)(nil)
@@ -36,6 +39,10 @@ func (n *AggregationNode) Bind(tx *database.Transaction, params []expr.Param) (e
 }
 
 func (n *AggregationNode) toStream(st document.Stream) (document.Stream, error) {
+	if n.sorted {
+		return st.AggregateSorted(n.Aggregators...), nil
+	}
+
 	return st.Aggregate(n.Aggregators...), nil
 }
 
@@ -49,6 +56,10 @@ func (n *AggregationNode) String() string {
 		b.WriteString(fmt.Sprintf("%v", ex))
 	}
 
+	if n.sorted {
+		b.WriteString(", sorted")
+	}
+
 	return fmt.Sprintf("Aggregate(%s)", b.String())
 }
 
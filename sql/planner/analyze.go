@@ -0,0 +1,131 @@
+package planner
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/genjidb/genji/document"
+)
+
+// operatorStats holds the statistics of an operator, collected while running a tree.
+type operatorStats struct {
+	// number of documents returned by the operator
+	rows int64
+	// time spent producing the documents, including the time spent
+	// by the operators it reads from
+	time time.Duration
+}
+
+// treeStats holds the statistics collected while running a tree.
+type treeStats struct {
+	operators map[Node]*operatorStats
+	// number of documents returned by the tree
+	rows int64
+	// number of documents written by the tree
+	rowsAffected int64
+	time         time.Duration
+	// number of bytes allocated while running the tree
+	memory uint64
+}
+
+// analyze runs the tree, reads all the documents it returns
+// and reports the statistics of each of its operators.
+// The tree must have been bound and optimized.
+func (t *Tree) analyze() (*treeStats, error) {
+	stats := treeStats{
+		operators: make(map[Node]*operatorStats),
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	if t.Root != nil {
+		st, err := analyzeNodeToStream(t.Root, stats.operators)
+		if err != nil {
+			return nil, err
+		}
+
+		// documents are copied to make sure all their fields are computed
+		err = st.Iterate(func(d document.Document) error {
+			var fb document.FieldBuffer
+
+			stats.rows++
+			return fb.Copy(d)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if rn, ok := t.Root.(rowsAffectedNode); ok {
+			stats.rowsAffected = rn.rowsAffected()
+		}
+	}
+
+	stats.time = time.Since(start)
+	runtime.ReadMemStats(&after)
+	stats.memory = after.TotalAlloc - before.TotalAlloc
+
+	return &stats, nil
+}
+
+// analyzeNodeToStream builds the stream of the node like nodeToStream
+// and counts the documents passed by every operator, and the time spent producing them.
+func analyzeNodeToStream(n Node, stats map[Node]*operatorStats) (st document.Stream, err error) {
+	l := n.Left()
+	if l != nil {
+		st, err = analyzeNodeToStream(l, stats)
+		if err != nil {
+			return
+		}
+	}
+
+	switch t := n.(type) {
+	case inputNode:
+		st, err = t.buildStream()
+	case operationNode:
+		st, err = t.toStream(st)
+	default:
+		panic(fmt.Sprintf("incorrect node type %#v", n))
+	}
+	if err != nil {
+		return
+	}
+
+	s := new(operatorStats)
+	stats[n] = s
+
+	// an empty stream has a special meaning for some operators,
+	// e.g. a projection without input returns a single document
+	if st.IsEmpty() {
+		return
+	}
+
+	return document.NewStream(&analyzedIterator{it: st, stats: s}), nil
+}
+
+// analyzedIterator measures the documents passed by an iterator.
+type analyzedIterator struct {
+	it    document.Iterator
+	stats *operatorStats
+}
+
+// Iterate passes the documents of the underlying iterator to fn.
+// The time spent by fn is not counted, it belongs to the next operators.
+func (it *analyzedIterator) Iterate(fn func(d document.Document) error) error {
+	start := time.Now()
+	var next time.Duration
+
+	err := it.it.Iterate(func(d document.Document) error {
+		it.stats.rows++
+
+		t := time.Now()
+		err := fn(d)
+		next += time.Since(t)
+		return err
+	})
+
+	it.stats.time += time.Since(start) - next
+	return err
+}
@@ -0,0 +1,254 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// A SetOperator combines the results of two SELECT statements.
+type SetOperator int
+
+// List of set operators.
+const (
+	// Except selects the documents of the first statement that are not returned by the second one.
+	Except SetOperator = iota + 1
+	// Intersect selects the documents of the first statement that are also returned by the second one.
+	Intersect
+	// Union selects the documents of both statements.
+	Union
+)
+
+func (op SetOperator) String() string {
+	switch op {
+	case Except:
+		return "EXCEPT"
+	case Intersect:
+		return "INTERSECT"
+	case Union:
+		return "UNION"
+	}
+
+	return ""
+}
+
+// CompoundSelectStmt is a query.Statement that combines the results of two SELECT statements
+// using a set operator. Documents are compared using the values of their fields, in order,
+// regardless of the name of the fields. Numbers are equal if they have the same value,
+// regardless of their type.
+//
+// By default, duplicates are removed from the result. If All is true, a document that
+// appears m times in the first statement and n times in the second one appears
+// max(m - n, 0) times with EXCEPT ALL and min(m, n) times with INTERSECT ALL.
+//
+// The second statement is run first and entirely: a hash of every document it returns
+// is kept in memory, along with the number of times it was seen. Documents of the first
+// statement are then streamed and only their hashes are kept, to remove duplicates.
+// The memory used is thus proportional to the number of distinct documents, not to their size.
+//
+// UNION returns the documents of the first statement followed by those of the second one,
+// without duplicates unless All is true. Fields of the documents of the second statement
+// are aligned by name with the fields of the first document returned by the first statement,
+// fields with other names take the remaining names of the first document, in order.
+type CompoundSelectStmt struct {
+	Left, Right query.Statement
+	Operator    SetOperator
+	All         bool
+}
+
+// Run both statements and combine their results.
+func (s *CompoundSelectStmt) Run(tx *database.Transaction, params []expr.Param) (query.Result, error) {
+	if s.Operator == Union {
+		return s.union(tx, params)
+	}
+
+	right, err := s.Right.Run(tx, params)
+	if err != nil {
+		return query.Result{}, err
+	}
+
+	set := newDocumentHashSet(nil) // use default hashing algorithm
+	counts := make(map[uint64]int)
+	err = right.Iterate(func(d document.Document) error {
+		k, err := set.generateKey(d)
+		if err != nil {
+			return err
+		}
+
+		counts[k]++
+		return nil
+	})
+	if err != nil {
+		return query.Result{}, err
+	}
+
+	left, err := s.Left.Run(tx, params)
+	if err != nil {
+		return query.Result{}, err
+	}
+
+	// hashes of the documents already returned, used to remove duplicates
+	seen := make(map[uint64]struct{})
+
+	left.Stream = left.Stream.Filter(func(d document.Document) (bool, error) {
+		k, err := set.generateKey(d)
+		if err != nil {
+			return false, err
+		}
+
+		if !s.All {
+			if _, ok := seen[k]; ok {
+				return false, nil
+			}
+		}
+
+		n := counts[k]
+		if s.All && n > 0 {
+			counts[k]--
+		}
+
+		var ok bool
+		switch s.Operator {
+		case Except:
+			ok = n == 0
+		case Intersect:
+			ok = n > 0
+		default:
+			return false, fmt.Errorf("unknown set operator %d", s.Operator)
+		}
+
+		if ok && !s.All {
+			seen[k] = struct{}{}
+		}
+		return ok, nil
+	})
+
+	return left, nil
+}
+
+// union streams the documents of the left statement, then those of the right statement.
+func (s *CompoundSelectStmt) union(tx *database.Transaction, params []expr.Param) (query.Result, error) {
+	left, err := s.Left.Run(tx, params)
+	if err != nil {
+		return query.Result{}, err
+	}
+
+	st := left.Stream
+	left.Stream = document.NewStream(document.IteratorFunc(func(fn func(d document.Document) error) error {
+		set := newDocumentHashSet(nil) // use default hashing algorithm
+
+		emit := func(d document.Document) error {
+			if !s.All {
+				ok, err := set.Filter(d)
+				if err != nil || !ok {
+					return err
+				}
+			}
+
+			return fn(d)
+		}
+
+		// names of the fields of the first document, in order
+		var fields []string
+		err := st.Iterate(func(d document.Document) error {
+			if fields == nil {
+				fields = []string{}
+				err := d.Iterate(func(f string, _ document.Value) error {
+					fields = append(fields, f)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return emit(d)
+		})
+		if err != nil {
+			return err
+		}
+
+		right, err := s.Right.Run(tx, params)
+		if err != nil {
+			return err
+		}
+
+		return right.Iterate(func(d document.Document) error {
+			if len(fields) > 0 {
+				var err error
+				d, err = alignDocument(d, fields)
+				if err != nil {
+					return err
+				}
+			}
+
+			return emit(d)
+		})
+	}))
+
+	return left, nil
+}
+
+// alignDocument returns a document whose fields follow the given list of names.
+// Fields of d with a name of the list take its position, the other fields of d
+// are renamed after the remaining names of the list, in order.
+// Fields left over are returned after the fields of the list.
+func alignDocument(d document.Document, fields []string) (document.Document, error) {
+	var fb document.FieldBuffer
+	var others []string
+	var values []document.Value
+
+	err := d.Iterate(func(f string, v document.Value) error {
+		for _, name := range fields {
+			if name == f {
+				return nil
+			}
+		}
+
+		others = append(others, f)
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range fields {
+		v, err := d.GetByField(name)
+		if err == nil {
+			fb.Add(name, v)
+			continue
+		}
+		if err != document.ErrFieldNotFound {
+			return nil, err
+		}
+
+		if len(values) > 0 {
+			fb.Add(name, values[0])
+			others, values = others[1:], values[1:]
+		}
+	}
+
+	for i := range others {
+		fb.Add(others[i], values[i])
+	}
+
+	return &fb, nil
+}
+
+// IsReadOnly returns true if both statements are read-only.
+func (s *CompoundSelectStmt) IsReadOnly() bool {
+	return s.Left.IsReadOnly() && s.Right.IsReadOnly()
+}
+
+func (s *CompoundSelectStmt) String() string {
+	op := s.Operator.String()
+	if s.All {
+		op += " ALL"
+	}
+
+	return fmt.Sprintf("%v %s %v", s.Left, op, s.Right)
+}
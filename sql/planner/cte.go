@@ -0,0 +1,257 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// A CTE is a common table expression, defined by the WITH clause of a statement.
+// Its documents are computed once per execution of the statement and kept in memory,
+// then read by the statement like the documents of a table.
+//
+// A recursive CTE is made of an initial statement, followed by UNION or UNION ALL
+// and a recursive statement that reads the CTE. The recursive statement is run again
+// and again, reading only the documents returned by the previous iteration,
+// until it returns no documents. Like with UNION, its fields are aligned with the fields
+// of the first document of the initial statement. With UNION, documents that were already
+// returned are discarded, which stops the recursion of cyclic graphs.
+// The number of iterations is limited by the MaxRecursion option of the database.
+//
+// If the CTE has a list of columns, the fields of the documents returned by its statements
+// are renamed after the columns, in order, and they must have as many fields as there are columns.
+type CTE struct {
+	Name string
+	// Columns are the names given to the fields of the documents of the CTE, if any.
+	Columns []string
+	// Build returns the statement that computes the documents of the CTE,
+	// or its initial documents if it is recursive.
+	Build func() (query.Statement, error)
+	// BuildRecursive returns the recursive statement, or is nil
+	// if the CTE is not recursive.
+	BuildRecursive func() (query.Statement, error)
+	// All is true if the recursive statement is combined using UNION ALL.
+	All bool
+
+	docs []document.Document
+}
+
+// materialize computes the documents of the CTE.
+// Statements are built again for every execution, since trees are modified when they are optimized.
+func (c *CTE) materialize(tx *database.Transaction, params []expr.Param) error {
+	c.docs = nil
+
+	var set *documentHashSet
+	if c.BuildRecursive != nil && !c.All {
+		set = newDocumentHashSet(nil) // use default hashing algorithm
+	}
+
+	docs, err := c.run(c.Build, tx, params, set, nil)
+	if err != nil {
+		return err
+	}
+
+	if c.BuildRecursive == nil || len(docs) == 0 {
+		c.docs = docs
+		return nil
+	}
+
+	// names of the fields of the first document, in order
+	var fields []string
+	err = docs[0].Iterate(func(f string, _ document.Value) error {
+		fields = append(fields, f)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	max := tx.DB().MaxRecursion
+	if max <= 0 {
+		max = database.DefaultMaxRecursion
+	}
+
+	all := docs
+	for i := 0; len(docs) > 0; i++ {
+		if i == max {
+			c.docs = nil
+			return fmt.Errorf("recursive common table expression %q exceeded the maximum number of iterations (%d)", c.Name, max)
+		}
+
+		// the recursive statement only reads the documents of the previous iteration
+		c.docs = docs
+		docs, err = c.run(c.BuildRecursive, tx, params, set, fields)
+		if err != nil {
+			c.docs = nil
+			return err
+		}
+
+		all = append(all, docs...)
+	}
+
+	c.docs = all
+	return nil
+}
+
+// run builds and runs the statement, and returns a copy of the documents it returns.
+// If the CTE has a list of columns, the fields of the documents are renamed after the columns.
+// Otherwise, if fields is not empty, documents are aligned with the given field names.
+// If set is not nil, documents that were already returned are skipped.
+func (c *CTE) run(build func() (query.Statement, error), tx *database.Transaction, params []expr.Param, set *documentHashSet, fields []string) ([]document.Document, error) {
+	stmt, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := stmt.Run(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []document.Document
+	err = res.Iterate(func(d document.Document) error {
+		var err error
+		switch {
+		case len(c.Columns) > 0:
+			d, err = c.renameFields(d)
+		case len(fields) > 0:
+			d, err = alignDocument(d, fields)
+		}
+		if err != nil {
+			return err
+		}
+
+		if set != nil {
+			ok, err := set.Filter(d)
+			if err != nil || !ok {
+				return err
+			}
+		}
+
+		var fb document.FieldBuffer
+		err = fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, &fb)
+		return nil
+	})
+
+	return docs, err
+}
+
+// renameFields returns a document whose fields are renamed after the columns of the CTE, in order.
+// It returns an error if d doesn't have as many fields as there are columns.
+func (c *CTE) renameFields(d document.Document) (document.Document, error) {
+	var fb document.FieldBuffer
+	var n int
+
+	err := d.Iterate(func(f string, v document.Value) error {
+		if n < len(c.Columns) {
+			fb.Add(c.Columns[n], v)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if n != len(c.Columns) {
+		return nil, fmt.Errorf("common table expression %q has %d fields but %d columns", c.Name, n, len(c.Columns))
+	}
+
+	return &fb, nil
+}
+
+func (c *CTE) String() string {
+	if len(c.Columns) == 0 {
+		return c.Name
+	}
+
+	return fmt.Sprintf("%s(%s)", c.Name, strings.Join(c.Columns, ", "))
+}
+
+func (c *CTE) iterate(fn func(d document.Document) error) error {
+	for _, d := range c.docs {
+		err := fn(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WithStmt is a statement preceded by a WITH clause, which defines common table expressions.
+type WithStmt struct {
+	CTEs []*CTE
+	Stmt query.Statement
+}
+
+// Run computes the documents of the common table expressions, in order,
+// then runs the statement.
+func (s *WithStmt) Run(tx *database.Transaction, params []expr.Param) (query.Result, error) {
+	for _, c := range s.CTEs {
+		err := c.materialize(tx, params)
+		if err != nil {
+			return query.Result{}, err
+		}
+	}
+
+	return s.Stmt.Run(tx, params)
+}
+
+// IsReadOnly implements the query.Statement interface.
+func (s *WithStmt) IsReadOnly() bool {
+	return s.Stmt.IsReadOnly()
+}
+
+func (s *WithStmt) String() string {
+	var b strings.Builder
+
+	b.WriteString("WITH ")
+	for i, c := range s.CTEs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(c.String())
+	}
+
+	return fmt.Sprintf("%s %v", b.String(), s.Stmt)
+}
+
+type cteInputNode struct {
+	node
+
+	cte *CTE
+}
+
+var _ inputNode = (*cteInputNode)(nil)
+
+// NewCTEInputNode creates an input node that reads the documents of a common table expression.
+func NewCTEInputNode(cte *CTE) Node {
+	return &cteInputNode{
+		node: node{
+			op: Input,
+		},
+		cte: cte,
+	}
+}
+
+func (n *cteInputNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	return
+}
+
+func (n *cteInputNode) buildStream() (document.Stream, error) {
+	return document.NewStream(document.IteratorFunc(n.cte.iterate)), nil
+}
+
+func (n *cteInputNode) String() string {
+	return fmt.Sprintf("CTE(%s)", n.cte.Name)
+}
@@ -17,20 +17,40 @@ type deletionNode struct {
 
 	tableName string
 	table     *database.Table
+
+	// number of documents deleted by the last call to toStream.
+	deleted int64
+	// if true, toStream returns the deleted documents.
+	returning bool
+	// if true, the keys of all the documents are read before deleting them,
+	// since iterating again on a limited stream wouldn't select the same documents.
+	onePass bool
 }
 
 var _ operationNode = (*deletionNode)(nil)
 
 // NewDeletionNode creates a node that delete every document of a stream
 // from their respective table.
+// If the stream is sorted and limited, only the selected documents are deleted.
 func NewDeletionNode(n Node, tableName string) Node {
-	return &deletionNode{
+	dn := deletionNode{
 		node: node{
 			op:   Deletion,
 			left: n,
 		},
 		tableName: tableName,
 	}
+
+	for ; n != nil && (n.Operation() == Limit || n.Operation() == Sort); n = n.Left() {
+		dn.onePass = true
+
+		// sorted documents must keep their key to be deleted
+		if sn, ok := n.(*sortNode); ok {
+			sn.withKeys = true
+		}
+	}
+
+	return &dn
 }
 
 func (n *deletionNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
@@ -45,8 +65,16 @@ func (n *deletionNode) Bind(tx *database.Transaction, params []expr.Param) (err
 // to a buffer and delete them after the iteration is complete, and it will do that until there is no document
 // left to delete.
 // Increasing deleteBufferSize will occasionate less key searches (O(log n) for most engines) but will take more memory.
+// Documents that don't exist anymore when they are deleted are skipped: a DELETE statement
+// never fails because of a missing document, it only reports how many documents were deleted.
+// If the stream is limited, the keys of all the selected documents are read at once.
 func (n *deletionNode) toStream(st document.Stream) (document.Stream, error) {
-	st = st.Limit(deleteBufferSize)
+	if !n.onePass {
+		st = st.Limit(deleteBufferSize)
+	}
+	n.deleted = 0
+
+	var deleted []document.Document
 
 	keys := make([][]byte, deleteBufferSize)
 
@@ -59,7 +87,11 @@ func (n *deletionNode) toStream(st document.Stream) (document.Stream, error) {
 				return errors.New("attempt to delete document without key")
 			}
 			// copy the key and reuse the buffer
-			keys[i] = append(keys[i][0:0], k.RawKey()...)
+			if i < len(keys) {
+				keys[i] = append(keys[i][0:0], k.RawKey()...)
+			} else {
+				keys = append(keys, append([]byte{}, k.RawKey()...))
+			}
 			i++
 			return nil
 		})
@@ -70,20 +102,48 @@ func (n *deletionNode) toStream(st document.Stream) (document.Stream, error) {
 		keys = keys[:i]
 
 		for _, key := range keys {
+			var d document.Document
+			if n.returning {
+				// the key buffer is reused by the next batch
+				d, err = n.table.GetDocument(append([]byte{}, key...))
+				if err == database.ErrDocumentNotFound {
+					continue
+				}
+				if err != nil {
+					return document.Stream{}, err
+				}
+			}
+
 			err = n.table.Delete(key)
+			if err == database.ErrDocumentNotFound {
+				continue
+			}
 			if err != nil {
 				return document.Stream{}, err
 			}
+			n.deleted++
+
+			if d != nil {
+				deleted = append(deleted, d)
+			}
 		}
 
-		if i < deleteBufferSize {
+		if n.onePass || i < deleteBufferSize {
 			break
 		}
 	}
 
+	if n.returning {
+		return document.NewStream(document.NewIterator(deleted...)), nil
+	}
+
 	return document.Stream{}, nil
 }
 
+func (n *deletionNode) rowsAffected() int64 {
+	return n.deleted
+}
+
 func (n *deletionNode) String() string {
 	return fmt.Sprintf("Delete(%s)", n.tableName)
 }
@@ -1,8 +1,14 @@
 package planner
 
 import (
+	"bytes"
+	"fmt"
+	"hash/maphash"
+	"strings"
+
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/sql/query/expr"
 )
 
@@ -11,8 +17,18 @@ type dedupNode struct {
 
 	tableName string
 	indexes   map[string]database.Index
+	// if not empty, documents are deduplicated
+	// using the values of these expressions.
+	exprs []expr.Expr
+	// if not nil, the documents are sorted by the expressions,
+	// they are compared to the previous one using their sort key.
+	sortFields []SortField
+
+	tx     *database.Transaction
+	params []expr.Param
 }
 
+// NewDedupNode creates a node that removes duplicate documents from the stream.
 func NewDedupNode(n Node, tableName string) Node {
 	return &dedupNode{
 		node: node{
@@ -23,7 +39,38 @@ func NewDedupNode(n Node, tableName string) Node {
 	}
 }
 
+// NewDedupOnNode creates a node that only returns the first document
+// of the stream for each distinct list of values of the given expressions.
+// The expressions are evaluated against the documents before their projection.
+// If n is a sort node, the expressions must be the leading sort expressions: the
+// first document of each group in the sorted stream is returned.
+func NewDedupOnNode(n Node, tableName string, exprs []expr.Expr) Node {
+	dn := dedupNode{
+		node: node{
+			op:   Dedup,
+			left: n,
+		},
+		tableName: tableName,
+		exprs:     exprs,
+	}
+
+	if sn, ok := n.(*sortNode); ok {
+		sn.withSortKeys = true
+		dn.sortFields = sn.sortFields
+	}
+
+	return &dn
+}
+
 func (n *dedupNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	n.tx = tx
+	n.params = params
+
+	// joins don't have a single table
+	if n.tableName == "" {
+		return nil
+	}
+
 	table, err := tx.GetTable(n.tableName)
 	if err != nil {
 		return
@@ -34,10 +81,233 @@ func (n *dedupNode) Bind(tx *database.Transaction, params []expr.Param) (err err
 }
 
 func (n *dedupNode) toStream(st document.Stream) (document.Stream, error) {
-	set := newDocumentHashSet(nil) // use default hashing algorithm
-	return st.Filter(set.Filter), nil
+	if n.sortFields != nil {
+		return n.sortedStream(st), nil
+	}
+
+	var bufferSize int
+	if n.tx != nil && n.tx.CanCreateTemporaryStore() {
+		bufferSize = n.tx.DB().DistinctBufferSize
+	}
+
+	return document.NewStream(document.IteratorFunc(func(fn func(d document.Document) error) error {
+		set := newDistinctSet(n.tx, bufferSize)
+		defer set.drop()
+
+		env := expr.Environment{
+			Params: n.params,
+			Tx:     n.tx,
+		}
+
+		var buf bytes.Buffer
+		return st.Iterate(func(d document.Document) error {
+			buf.Reset()
+
+			err := n.encodeKey(&buf, &env, d)
+			if err != nil {
+				return err
+			}
+
+			ok, err := set.add(buf.Bytes())
+			if err != nil || !ok {
+				return err
+			}
+
+			return fn(d)
+		})
+	})), nil
+}
+
+// sortedStream returns the first document of every group of documents
+// sharing the same leading sort values.
+func (n *dedupNode) sortedStream(st document.Stream) document.Stream {
+	return document.NewStream(document.IteratorFunc(func(fn func(d document.Document) error) error {
+		var prev []byte
+		first := true
+
+		return st.Iterate(func(d document.Document) error {
+			var key []byte
+			if sd, ok := d.(*sortedDocument); ok {
+				key = sortKeyPrefix(sd.sortKey, n.sortFields, len(n.exprs))
+			}
+
+			if !first && bytes.Equal(key, prev) {
+				return nil
+			}
+			first = false
+			prev = append(prev[:0], key...)
+
+			return fn(d)
+		})
+	}))
+}
+
+// encodeKey writes the values the document is deduplicated by to buf.
+func (n *dedupNode) encodeKey(buf *bytes.Buffer, env *expr.Environment, d document.Document) error {
+	enc := document.NewValueEncoder(buf)
+
+	if len(n.exprs) == 0 {
+		fields, err := document.Fields(d)
+		if err != nil {
+			return err
+		}
+
+		for _, field := range fields {
+			value, err := d.GetByField(field)
+			if err != nil {
+				return err
+			}
+
+			err = enc.Encode(value)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// DISTINCT ON expressions can refer to fields that are not projected
+	if dm, ok := d.(*documentMask); ok {
+		d = dm.d
+	}
+	env.SetCurrentValue(document.NewDocumentValue(d))
+
+	for _, e := range n.exprs {
+		v, err := e.Eval(env)
+		if err != nil {
+			return err
+		}
+
+		err = enc.Encode(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (n *dedupNode) String() string {
-	return "Dedup()"
+	if len(n.exprs) == 0 {
+		return "Dedup()"
+	}
+
+	var b strings.Builder
+
+	for i, e := range n.exprs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", e))
+	}
+
+	return fmt.Sprintf("Dedup(on: %s)", b.String())
+}
+
+// distinctSet is a set of encoded values.
+// If bufferSize is zero, only the hash of every value is kept in memory.
+// Otherwise, values are kept in memory until there are bufferSize of them,
+// then moved to a temporary store.
+type distinctSet struct {
+	tx         *database.Transaction
+	bufferSize int
+
+	hash   maphash.Hash
+	hashes map[uint64]struct{}
+
+	values map[string]struct{}
+	st     engine.Store
+	dropFn func() error
+	// engines forbid empty keys, keys of the store
+	// are prefixed by a byte.
+	key []byte
+}
+
+func newDistinctSet(tx *database.Transaction, bufferSize int) *distinctSet {
+	s := distinctSet{
+		tx:         tx,
+		bufferSize: bufferSize,
+	}
+
+	if bufferSize > 0 {
+		s.values = make(map[string]struct{})
+	} else {
+		s.hashes = make(map[uint64]struct{})
+	}
+
+	return &s
+}
+
+// add the value to the set. It returns false if the set already contains it.
+func (s *distinctSet) add(v []byte) (bool, error) {
+	if s.bufferSize <= 0 {
+		defer s.hash.Reset()
+
+		_, _ = s.hash.Write(v)
+		k := s.hash.Sum64()
+		if _, ok := s.hashes[k]; ok {
+			return false, nil
+		}
+
+		s.hashes[k] = struct{}{}
+		return true, nil
+	}
+
+	if _, ok := s.values[string(v)]; ok {
+		return false, nil
+	}
+
+	if s.st != nil {
+		s.key = append(append(s.key[:0], 0), v...)
+		_, err := s.st.Get(s.key)
+		if err == nil {
+			return false, nil
+		}
+		if err != engine.ErrKeyNotFound {
+			return false, err
+		}
+	}
+
+	s.values[string(v)] = struct{}{}
+
+	if len(s.values) >= s.bufferSize {
+		return true, s.spill()
+	}
+
+	return true, nil
+}
+
+// spill moves the values kept in memory to the temporary store.
+func (s *distinctSet) spill() error {
+	if s.st == nil {
+		var err error
+		s.st, s.dropFn, err = s.tx.CreateTemporaryStore()
+		if err != nil {
+			return err
+		}
+	}
+
+	for v := range s.values {
+		// a new buffer must be used for every key, as the engine
+		// may keep a reference to it until the end of the transaction.
+		k := make([]byte, 0, len(v)+1)
+		k = append(append(k, 0), v...)
+		err := s.st.Put(k, []byte{1})
+		if err != nil {
+			return err
+		}
+
+		delete(s.values, v)
+	}
+
+	return nil
+}
+
+func (s *distinctSet) drop() error {
+	if s.dropFn == nil {
+		return nil
+	}
+
+	return s.dropFn()
 }
@@ -0,0 +1,62 @@
+package planner
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// Exists runs the given SELECT statement and reports whether it returns at least one document.
+// The statement is optimized as if it was followed by LIMIT 1: indexes are used when possible,
+// sort nodes only keep the documents they need and the stream is closed as soon
+// as the first document is returned.
+func Exists(tx *database.Transaction, stmt query.Statement, params []expr.Param) (bool, error) {
+	switch t := stmt.(type) {
+	case *Tree:
+		if !isSelectTree(t) {
+			return false, errors.New("Exists only works on SELECT statements")
+		}
+
+		err := Bind(t, tx, params)
+		if err != nil {
+			return false, err
+		}
+
+		t, err = Optimize(&Tree{Root: NewLimitNode(t.Root, 1)})
+		if err != nil {
+			return false, err
+		}
+
+		res, err := t.execute()
+		if err != nil {
+			return false, err
+		}
+
+		d, err := res.First()
+		return d != nil, err
+	case *CompoundSelectStmt:
+		res, err := t.Run(tx, params)
+		if err != nil {
+			return false, err
+		}
+
+		d, err := res.First()
+		return d != nil, err
+	}
+
+	return false, errors.New("Exists only works on SELECT statements")
+}
+
+// isSelectTree returns false if the tree modifies the documents it reads.
+func isSelectTree(t *Tree) bool {
+	for n := t.Root; n != nil; n = n.Left() {
+		switch n.Operation() {
+		case Deletion, Replacement, Set, Unset:
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,11 +2,14 @@ package planner
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
 )
 
 // ExplainStmt is a query.Statement that
@@ -14,6 +17,11 @@ import (
 // is going to be executed, without executing it.
 type ExplainStmt struct {
 	Statement query.Statement
+
+	// If set to true, the statement is executed and the plan reports
+	// the number of documents returned by each operator, and the time
+	// spent producing them.
+	Analyze bool
 }
 
 // Run analyses the inner statement and displays its execution plan.
@@ -21,35 +29,251 @@ type ExplainStmt struct {
 // displaying all the operations.
 // Explain currently only works on SELECT, UPDATE and DELETE statements.
 func (s *ExplainStmt) Run(tx *database.Transaction, params []expr.Param) (query.Result, error) {
-	switch t := s.Statement.(type) {
-	case *Tree:
-		err := Bind(t, tx, params)
+	// the operators of the plan are only listed for SELECT, UPDATE and DELETE statements
+	// reading a single table
+	if t, ok := s.Statement.(*Tree); ok {
+		t, err := s.optimize(t, tx, params)
 		if err != nil {
 			return query.Result{}, err
 		}
 
-		t, err = Optimize(t)
+		var stats *treeStats
+		if s.Analyze {
+			stats, err = t.analyze()
+			if err != nil {
+				return query.Result{}, err
+			}
+		}
+
+		return s.createResult(t.String(), explainOperators(t, stats), stats)
+	}
+
+	if s.Analyze {
+		return query.Result{}, errors.New("EXPLAIN ANALYZE only works on SELECT, UPDATE and DELETE statements without compound queries")
+	}
+
+	plan, err := s.explain(s.Statement, tx, params)
+	if err != nil {
+		return query.Result{}, err
+	}
+
+	return s.createResult(plan, nil, nil)
+}
+
+// optimize binds and optimizes the tree, to display the plan that would be executed.
+func (s *ExplainStmt) optimize(t *Tree, tx *database.Transaction, params []expr.Param) (*Tree, error) {
+	err := Bind(t, tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return Optimize(t)
+}
+
+func (s *ExplainStmt) explain(stmt query.Statement, tx *database.Transaction, params []expr.Param) (string, error) {
+	switch t := stmt.(type) {
+	case *Tree:
+		t, err := s.optimize(t, tx, params)
 		if err != nil {
-			return query.Result{}, err
+			return "", err
 		}
 
-		return s.createResult(t.String())
+		return t.String(), nil
+	case *CompoundSelectStmt:
+		left, err := s.explain(t.Left, tx, params)
+		if err != nil {
+			return "", err
+		}
+
+		right, err := s.explain(t.Right, tx, params)
+		if err != nil {
+			return "", err
+		}
+
+		op := t.Operator.String()
+		if t.All {
+			op += " ALL"
+		}
+
+		return fmt.Sprintf("(%s) %s (%s)", left, op, right), nil
+	case *WithStmt:
+		var b strings.Builder
+
+		b.WriteString("WITH ")
+		for i, c := range t.CTEs {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+
+			plan, err := s.explainCTE(c, tx, params)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "%s AS (%s)", c, plan)
+		}
+
+		plan, err := s.explain(t.Stmt, tx, params)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s %s", b.String(), plan), nil
 	}
 
-	return query.Result{}, errors.New("EXPLAIN only works on SELECT, UPDATE AND DELETE statements")
+	return "", errors.New("EXPLAIN only works on SELECT, UPDATE AND DELETE statements")
 }
 
-func (s *ExplainStmt) createResult(text string) (query.Result, error) {
+// explainCTE displays the plan of the statements of a common table expression.
+func (s *ExplainStmt) explainCTE(c *CTE, tx *database.Transaction, params []expr.Param) (string, error) {
+	stmt, err := c.Build()
+	if err != nil {
+		return "", err
+	}
+
+	plan, err := s.explain(stmt, tx, params)
+	if err != nil || c.BuildRecursive == nil {
+		return plan, err
+	}
+
+	stmt, err = c.BuildRecursive()
+	if err != nil {
+		return "", err
+	}
+
+	recursive, err := s.explain(stmt, tx, params)
+	if err != nil {
+		return "", err
+	}
+
+	op := Union.String()
+	if c.All {
+		op += " ALL"
+	}
+
+	return fmt.Sprintf("(%s) %s (%s)", plan, op, recursive), nil
+}
+
+func (s *ExplainStmt) createResult(text string, ops document.Array, stats *treeStats) (query.Result, error) {
+	fb := document.NewFieldBuffer().
+		Add("plan", document.NewTextValue(text))
+	if ops != nil {
+		fb.Add("operators", document.NewArrayValue(ops))
+	}
+	if stats != nil {
+		fb.Add("rows", document.NewIntegerValue(stats.rows))
+		if stats.rowsAffected > 0 {
+			fb.Add("rows_affected", document.NewIntegerValue(stats.rowsAffected))
+		}
+		fb.Add("time", document.NewTextValue(stats.time.String()))
+		fb.Add("memory", document.NewIntegerValue(int64(stats.memory)))
+	}
+
 	return query.Result{
-		Stream: document.NewStream(
-			document.NewIterator(
-				document.NewFieldBuffer().
-					Add("plan", document.NewTextValue(text)))),
+		Stream: document.NewStream(document.NewIterator(fb)),
 	}, nil
 }
 
+// explainOperators returns a document for each operator of the tree, in the order
+// in which they process the documents: the first one reads them from a table or an index.
+// Every document contains the name of the operator and its description, as displayed in the plan,
+// followed by the fields specific to the operator:
+//   - Table and Index: the table, the index and the direction of the scan
+//   - Selection: the filter
+//   - Sort: the list of the sort expressions, and the number of documents kept by a top-k sort
+//   - Limit and Offset: the number of documents
+//
+// If the tree was analyzed, they also contain the number of documents returned by the operator
+// and the time spent producing them.
+func explainOperators(t *Tree, stats *treeStats) document.Array {
+	var nodes []Node
+	for n := t.Root; n != nil; n = n.Left() {
+		nodes = append(nodes, n)
+	}
+
+	ops := document.NewValueBuffer()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		fb := explainOperator(nodes[i])
+		if stats != nil {
+			os := stats.operators[nodes[i]]
+			if os == nil {
+				os = new(operatorStats)
+			}
+
+			fb.Add("rows", document.NewIntegerValue(os.rows))
+			fb.Add("time", document.NewTextValue(os.time.String()))
+		}
+
+		ops = ops.Append(document.NewDocumentValue(fb))
+	}
+
+	return ops
+}
+
+func explainOperator(n Node) *document.FieldBuffer {
+	desc := fmt.Sprint(n)
+	fb := document.NewFieldBuffer()
+
+	switch t := n.(type) {
+	case *tableInputNode:
+		fb.Add("operator", document.NewTextValue("Table"))
+		fb.Add("description", document.NewTextValue(desc))
+		fb.Add("table", document.NewTextValue(t.tableName))
+		fb.Add("direction", document.NewTextValue(scanner.ASC.String()))
+	case *indexInputNode:
+		fb.Add("operator", document.NewTextValue("Index"))
+		fb.Add("description", document.NewTextValue(desc))
+		fb.Add("table", document.NewTextValue(t.tableName))
+		fb.Add("index", document.NewTextValue(t.indexName))
+		fb.Add("direction", document.NewTextValue(t.direction().String()))
+		// the operator is the condition that selected the index
+		if e, ok := t.iop.(expr.Expr); ok {
+			fb.Add("filter", document.NewTextValue(fmt.Sprint(e)))
+		} else if t.filter != nil {
+			fb.Add("filter", document.NewTextValue(fmt.Sprint(t.filter)))
+		}
+	case *selectionNode:
+		fb.Add("operator", document.NewTextValue("Selection"))
+		fb.Add("description", document.NewTextValue(desc))
+		fb.Add("filter", document.NewTextValue(fmt.Sprint(t.cond)))
+	case *ProjectionNode:
+		fb.Add("operator", document.NewTextValue("Projection"))
+		fb.Add("description", document.NewTextValue(desc))
+	case *sortNode:
+		fb.Add("operator", document.NewTextValue("Sort"))
+		fb.Add("description", document.NewTextValue(desc))
+
+		fields := document.NewValueBuffer()
+		for _, f := range t.sortFields {
+			fields = fields.Append(document.NewTextValue(f.String()))
+		}
+		fb.Add("sort", document.NewArrayValue(fields))
+		if t.k > 0 {
+			fb.Add("top", document.NewIntegerValue(int64(t.k)))
+		}
+	case *limitNode:
+		fb.Add("operator", document.NewTextValue("Limit"))
+		fb.Add("description", document.NewTextValue(desc))
+		fb.Add("limit", document.NewIntegerValue(int64(t.limit)))
+	case *offsetNode:
+		fb.Add("operator", document.NewTextValue("Offset"))
+		fb.Add("description", document.NewTextValue(desc))
+		fb.Add("offset", document.NewIntegerValue(int64(t.offset)))
+	default:
+		// the name of other operators is the beginning of their description
+		name := desc
+		if i := strings.IndexByte(desc, '('); i > 0 {
+			name = desc[:i]
+		}
+
+		fb.Add("operator", document.NewTextValue(name))
+		fb.Add("description", document.NewTextValue(desc))
+	}
+
+	return fb
+}
+
 // IsReadOnly indicates that this statement doesn't write anything into
-// the database.
+// the database, unless it analyzes a statement that does.
 func (s *ExplainStmt) IsReadOnly() bool {
-	return true
+	return !s.Analyze || s.Statement.IsReadOnly()
 }
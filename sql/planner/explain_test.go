@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,18 +17,58 @@ func TestExplainStmt(t *testing.T) {
 		{"EXPLAIN SELECT 1 + 1", false, `"∏(1 + 1)"`},
 		{"EXPLAIN SELECT * FROM noexist", true, ``},
 		{"EXPLAIN SELECT * FROM test", false, `"Table(test) -> ∏(*)"`},
-		{"EXPLAIN SELECT a + 1 FROM test", false, `"Table(test) -> ∏(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> ∏(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 AND d > 20", false, `"Table(test) -> σ(cond: d > 20) -> σ(cond: c > 10) -> ∏(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 OR d > 20", false, `"Table(test) -> σ(cond: c > 10 OR d > 20) -> ∏(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c IN [1 + 1, 2 + 2]", false, `"Table(test) -> σ(cond: c IN [2, 4]) -> ∏(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10", false, `"Index(idx_a) -> ∏(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10 AND b > 20 AND c > 30", false, `"Index(idx_b) -> σ(cond: c > 30) -> σ(cond: a > 10) -> ∏(a + 1)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"Table(test) -> σ(cond: c > 30) -> ∏(a + 1) -> Sort(a DESC) -> Offset(20) -> Limit(10)"`},
-		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 GROUP BY a + 1 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"Table(test) -> σ(cond: c > 30) -> Group(a + 1) -> Aggregate(a + 1) -> ∏(a + 1) -> Sort(a DESC) -> Offset(20) -> Limit(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test", false, `"Table(test, fields: a) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10", false, `"Table(test, fields: a, c) -> σ(cond: c > 10) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 AND d > 20", false, `"Table(test, fields: a, c, d) -> σ(cond: d > 20) -> σ(cond: c > 10) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 10 OR d > 20", false, `"Table(test, fields: a, c, d) -> σ(cond: c > 10 OR d > 20) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c IN [1 + 1, 2 + 2]", false, `"Table(test, fields: a, c) -> σ(cond: c IN [2, 4]) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT c FROM test WHERE c BETWEEN 1 + 1 AND 5", false, `"Table(test, fields: c) -> σ(cond: c BETWEEN 2 AND 5) -> ∏(c)"`},
+		{"EXPLAIN SELECT a FROM test WHERE a BETWEEN 1 AND 5", false, `"Index(idx_a, fields: a) -> ∏(a)"`},
+		{"EXPLAIN SELECT e FROM test WHERE e BETWEEN 1.5 AND 5", false, `"Index(idx_e, fields: e) -> ∏(e)"`},
+		{"EXPLAIN SELECT a FROM test WHERE a LIKE 'ab%'", false, `"Index(idx_a, fields: a) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test WHERE a LIKE '_b%'", false, `"Table(test, fields: a) -> σ(cond: a LIKE \"_b%\") -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test WHERE (a, c) = (1, 2)", false, `"Index(idx_a, fields: a, c) -> σ(cond: c = 2) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test WHERE (a, c) = (1, 2, 3)", false, `"Table(test, fields: a, c) -> σ(cond: (a, c) = (1, 2, 3)) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test WHERE a NOT BETWEEN 1 AND 5", false, `"Table(test, fields: a) -> σ(cond: a NOT BETWEEN 1 AND 5) -> ∏(a)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10", false, `"Index(idx_a, fields: a) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE a > 10 AND b > 20 AND c > 30", false, `"Index(idx_b, fields: a, c) -> σ(cond: c > 30) -> σ(cond: a > 10) -> ∏(a + 1)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"Table(test, fields: a, c) -> σ(cond: c > 30) -> ∏(a + 1) -> Sort(a DESC, top 30) -> Offset(20) -> Limit(10)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 ORDER BY a DESC, b LIMIT 10", false, `"Table(test, fields: a, b, c) -> σ(cond: c > 30) -> ∏(a + 1) -> Sort(a DESC, b ASC, top 10) -> Limit(10)"`},
+		{"EXPLAIN SELECT a FROM test ORDER BY b * c DESC", false, `"Table(test, fields: b, c, a) -> ∏(a) -> Sort(b * c DESC)"`},
+		{"EXPLAIN SELECT a + 1 FROM test WHERE c > 30 GROUP BY a + 1 ORDER BY a DESC LIMIT 10 OFFSET 20", false, `"Table(test) -> σ(cond: c > 30) -> Group(a + 1) -> Aggregate(a + 1) -> ∏(a + 1) -> Sort(a DESC, top 30) -> Offset(20) -> Limit(10)"`},
+		{"EXPLAIN SELECT a, rank() OVER (PARTITION BY b ORDER BY c DESC) FROM test WHERE a > 1 ORDER BY a LIMIT 10", false, `"Index(idx_a) -> Window(rank() OVER (PARTITION BY b ORDER BY c DESC)) -> ∏(a, rank() OVER (PARTITION BY b ORDER BY c DESC)) -> Sort(a ASC, top 10) -> Limit(10)"`},
+		{"EXPLAIN SELECT row_number() AS rn, a FROM test ORDER BY b LIMIT 10", false, `"Table(test) -> ∏(row_number(), a) -> Sort(b ASC, top 10) -> Window(row_number()) -> Limit(10)"`},
+		{"EXPLAIN SELECT pk() FROM test WHERE a > 10 LIMIT 5", false, `"Index(idx_a, keys only) -> ∏(pk()) -> Limit(5)"`},
+		{"EXPLAIN SELECT pk(), a FROM test WHERE a > 10", false, `"Index(idx_a, fields: a) -> ∏(pk(), a)"`},
+		{"EXPLAIN SELECT a FROM test JOIN other ON test.a = other.b", false, `"Join(test, other, on: test.a = other.b, index: idx_a) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test LEFT JOIN other ON test.a = other.b", false, `"LeftJoin(test, other, on: test.a = other.b) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM other LEFT JOIN test ON test.a = other.b", false, `"LeftJoin(other, test, on: other.b = test.a, index: idx_a) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test RIGHT JOIN other ON test.a = other.b", false, `"RightJoin(test, other, on: test.a = other.b, index: idx_a) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test JOIN other ON test.c = other.b", false, `"Join(test, other, on: test.c = other.b) -> ∏(a)"`},
+		{"EXPLAIN SELECT a FROM test EXCEPT ALL SELECT b FROM test", false, `"(Table(test, fields: a) -> ∏(a)) EXCEPT ALL (Table(test, fields: b) -> ∏(b))"`},
+		{"EXPLAIN SELECT a FROM test UNION SELECT b FROM test", false, `"(Table(test, fields: a) -> ∏(a)) UNION (Table(test, fields: b) -> ∏(b))"`},
+		{"EXPLAIN SELECT pk() FROM test", false, `"Table(test, keys only) -> ∏(pk())"`},
+		{"EXPLAIN SELECT pk() FROM test WHERE k > 10 AND pk() < 20 LIMIT 5", false, `"Table(test, keys only) -> σ(cond: pk() < 20) -> σ(cond: k > 10) -> ∏(pk()) -> Limit(5)"`},
+		{"EXPLAIN SELECT pk() FROM test WHERE k > 10 AND c > 30", false, `"Table(test, fields: k, c) -> σ(cond: c > 30) -> σ(cond: k > 10) -> ∏(pk())"`},
+		{"EXPLAIN SELECT pk() FROM test WHERE a > 10 AND k > 30", false, `"Index(idx_a, keys only) -> σ(cond: k > 30) -> ∏(pk())"`},
+		{"EXPLAIN SELECT *, a FROM test WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> ∏(*, a)"`},
+		{"EXPLAIN SELECT `a b` FROM test ORDER BY c", false, "\"Table(test, fields: c, `a b`) -> ∏(`a b`) -> Sort(c ASC)\""},
+		{"EXPLAIN SELECT COUNT(a) FROM test", false, `"Table(test) -> Aggregate(COUNT(a)) -> ∏(COUNT(a))"`},
+		{"EXPLAIN SELECT k, COUNT(a) FROM test GROUP BY k", false, `"Table(test) -> Group(k) -> Aggregate(k, COUNT(a), sorted) -> ∏(k, COUNT(a))"`},
+		{"EXPLAIN SELECT e, COUNT(a) FROM test WHERE e > 10 GROUP BY e", false, `"Index(idx_e) -> Group(e) -> Aggregate(e, COUNT(a), sorted) -> ∏(e, COUNT(a))"`},
+		{"EXPLAIN SELECT a, COUNT(c) FROM test WHERE a > 10 GROUP BY a", false, `"Index(idx_a) -> Group(a) -> Aggregate(a, COUNT(c)) -> ∏(a, COUNT(c))"`},
+		{"EXPLAIN SELECT a, COUNT(c) FROM test WHERE c > 10 GROUP BY a HAVING a > 20", false, `"Table(test) -> σ(cond: c > 10) -> Group(a) -> Aggregate(a, COUNT(c)) -> σ(cond: a > 20) -> ∏(a, COUNT(c))"`},
+		{"EXPLAIN SELECT DISTINCT a FROM test", false, `"Table(test, fields: a) -> ∏(a) -> Dedup()"`},
+		{"EXPLAIN SELECT DISTINCT ON (c, d) a FROM test", false, `"Table(test, fields: c, d, a) -> ∏(a) -> Dedup(on: c, d)"`},
+		{"EXPLAIN SELECT DISTINCT ON (a) k FROM test", false, `"Table(test, fields: a, k) -> ∏(k) -> Dedup(on: a)"`},
+		{"EXPLAIN SELECT DISTINCT ON (c) k FROM test ORDER BY c, k DESC", false, `"Table(test, fields: c, k) -> ∏(k) -> Sort(c ASC, k DESC) -> Dedup(on: c)"`},
+		{"EXPLAIN SELECT pk() FROM test WHERE a > 10 AND c > 30", false, `"Index(idx_a, fields: c) -> σ(cond: c > 30) -> ∏(pk())"`},
 		{"EXPLAIN UPDATE test SET a = 10", false, `"Table(test) -> Set(a = 10) -> Replace(test)"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> Set(a = 10) -> Replace(test)"`},
 		{"EXPLAIN UPDATE test SET a = 10 WHERE a > 10", false, `"Index(idx_a) -> Set(a = 10) -> Replace(test)"`},
+		{"EXPLAIN WITH x AS (SELECT a FROM test WHERE a > 10) SELECT * FROM x", false, `"WITH x AS (Index(idx_a, fields: a) -> ∏(a)) CTE(x) -> ∏(*)"`},
+		{"EXPLAIN WITH RECURSIVE x AS (SELECT a FROM test WHERE a = 1 UNION SELECT a FROM test WHERE b IN (SELECT a FROM x)) SELECT * FROM x", false, `"WITH x AS ((Index(idx_a, fields: a) -> ∏(a)) UNION (Table(test, fields: a, b) -> σ(cond: b IN (CTE(x) -> ∏(a))) -> ∏(a))) CTE(x) -> ∏(*)"`},
+		{"EXPLAIN WITH x(c) AS (SELECT a FROM test WHERE a > 10) SELECT c FROM x", false, `"WITH x(c) AS (Index(idx_a, fields: a) -> ∏(a)) CTE(x) -> ∏(c)"`},
 		{"EXPLAIN DELETE FROM test", false, `"Table(test) -> Delete(test)"`},
 		{"EXPLAIN DELETE FROM test WHERE c > 10", false, `"Table(test) -> σ(cond: c > 10) -> Delete(test)"`},
 		{"EXPLAIN DELETE FROM test WHERE a > 10", false, `"Index(idx_a) -> Delete(test)"`},
@@ -39,11 +80,12 @@ func TestExplainStmt(t *testing.T) {
 			require.NoError(t, err)
 			defer db.Close()
 
-			err = db.Exec("CREATE TABLE test (k INTEGER PRIMARY KEY)")
+			err = db.Exec("CREATE TABLE test (k INTEGER PRIMARY KEY, e INTEGER); CREATE TABLE other")
 			require.NoError(t, err)
 			err = db.Exec(`
 						CREATE INDEX idx_a ON test (a);
 						CREATE UNIQUE INDEX idx_b ON test (b);
+						CREATE INDEX idx_e ON test (e);
 					`)
 			require.NoError(t, err)
 
@@ -61,3 +103,161 @@ func TestExplainStmt(t *testing.T) {
 		})
 	}
 }
+
+func TestExplainOperators(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (k INTEGER PRIMARY KEY);
+		CREATE INDEX idx_a ON test (a);
+		CREATE INDEX idx_sum ON test (b + c);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"EXPLAIN SELECT a FROM test WHERE c > 10 ORDER BY b DESC LIMIT 5 OFFSET 2", `[
+			{"operator": "Table", "description": "Table(test, fields: b, a, c)", "table": "test", "direction": "ASC"},
+			{"operator": "Selection", "description": "σ(cond: c > 10)", "filter": "c > 10"},
+			{"operator": "Projection", "description": "∏(a)"},
+			{"operator": "Sort", "description": "Sort(b DESC, top 7)", "sort": ["b DESC"], "top": 7},
+			{"operator": "Offset", "description": "Offset(2)", "offset": 2},
+			{"operator": "Limit", "description": "Limit(5)", "limit": 5}
+		]`},
+		{"EXPLAIN SELECT a FROM test WHERE a = 1", `[
+			{"operator": "Index", "description": "Index(idx_a, fields: a)", "table": "test", "index": "idx_a", "direction": "ASC", "filter": "a = 1"},
+			{"operator": "Projection", "description": "∏(a)"}
+		]`},
+		{"EXPLAIN SELECT a FROM test ORDER BY b + c DESC", `[
+			{"operator": "Index", "description": "Index(idx_sum DESC, fields: a)", "table": "test", "index": "idx_sum", "direction": "DESC"},
+			{"operator": "Projection", "description": "∏(a)"}
+		]`},
+		{"EXPLAIN DELETE FROM test WHERE c > 10", `[
+			{"operator": "Table", "description": "Table(test)", "table": "test", "direction": "ASC"},
+			{"operator": "Selection", "description": "σ(cond: c > 10)", "filter": "c > 10"},
+			{"operator": "Delete", "description": "Delete(test)"}
+		]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			d, err := db.QueryDocument(test.query)
+			require.NoError(t, err)
+
+			v, err := d.GetByField("operators")
+			require.NoError(t, err)
+
+			data, err := v.MarshalJSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(data))
+		})
+	}
+
+	t.Run("compound statements", func(t *testing.T) {
+		d, err := db.QueryDocument("EXPLAIN SELECT a FROM test UNION SELECT b FROM test")
+		require.NoError(t, err)
+
+		_, err = d.GetByField("operators")
+		require.Equal(t, document.ErrFieldNotFound, err)
+	})
+}
+
+func TestExplainAnalyze(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (k INTEGER PRIMARY KEY);
+		CREATE INDEX idx_a ON test (a);
+		INSERT INTO test (k, a, b) VALUES (1, 1, 1), (2, 2, 2), (3, 2, 3), (4, 3, 4), (5, 4, 5);
+	`)
+	require.NoError(t, err)
+
+	// rows returns the name and the number of rows of each operator
+	rows := func(t *testing.T, d document.Document) [][]interface{} {
+		v, err := d.GetByField("operators")
+		require.NoError(t, err)
+
+		var res [][]interface{}
+		err = v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			op, err := v.V.(document.Document).GetByField("operator")
+			require.NoError(t, err)
+			n, err := v.V.(document.Document).GetByField("rows")
+			require.NoError(t, err)
+			tm, err := v.V.(document.Document).GetByField("time")
+			require.NoError(t, err)
+			require.Equal(t, document.TextValue, tm.Type)
+
+			res = append(res, []interface{}{op.V, n.V})
+			return nil
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("select", func(t *testing.T) {
+		d, err := db.QueryDocument("EXPLAIN ANALYZE SELECT k FROM test WHERE b > 1 ORDER BY b DESC LIMIT 2")
+		require.NoError(t, err)
+
+		require.Equal(t, [][]interface{}{
+			{"Table", int64(5)},
+			{"Selection", int64(4)},
+			{"Projection", int64(4)},
+			{"Sort", int64(2)},
+			{"Limit", int64(2)},
+		}, rows(t, d))
+
+		v, err := d.GetByField("rows")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), v.V)
+
+		v, err = d.GetByField("time")
+		require.NoError(t, err)
+		require.Equal(t, document.TextValue, v.Type)
+
+		v, err = d.GetByField("memory")
+		require.NoError(t, err)
+		require.Greater(t, v.V.(int64), int64(0))
+	})
+
+	t.Run("index", func(t *testing.T) {
+		d, err := db.QueryDocument("EXPLAIN ANALYZE SELECT k FROM test WHERE a = 2")
+		require.NoError(t, err)
+
+		require.Equal(t, [][]interface{}{
+			{"Index", int64(2)},
+			{"Projection", int64(2)},
+		}, rows(t, d))
+	})
+
+	t.Run("writes are executed", func(t *testing.T) {
+		d, err := db.QueryDocument("EXPLAIN ANALYZE DELETE FROM test WHERE b > 3")
+		require.NoError(t, err)
+
+		require.Equal(t, [][]interface{}{
+			{"Table", int64(5)},
+			{"Selection", int64(2)},
+			{"Delete", int64(0)},
+		}, rows(t, d))
+
+		v, err := d.GetByField("rows_affected")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), v.V)
+
+		n, err := db.QueryDocument("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		v, err = n.GetByField("COUNT(*)")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), v.V)
+	})
+
+	t.Run("compound statements", func(t *testing.T) {
+		_, err := db.QueryDocument("EXPLAIN ANALYZE SELECT a FROM test UNION SELECT b FROM test")
+		require.Error(t, err)
+	})
+}
@@ -3,6 +3,7 @@ package planner
 import (
 	"hash"
 	"hash/maphash"
+	"math"
 
 	"github.com/genjidb/genji/document"
 )
@@ -39,6 +40,11 @@ func (s documentHashSet) generateKey(d document.Document) (uint64, error) {
 			return 0, err
 		}
 
+		value, err = normalizeNumbers(value)
+		if err != nil {
+			return 0, err
+		}
+
 		err = enc.Encode(value)
 		if err != nil {
 			return 0, err
@@ -48,6 +54,49 @@ func (s documentHashSet) generateKey(d document.Document) (uint64, error) {
 	return s.hash.Sum64(), nil
 }
 
+// normalizeNumbers returns v with every number converted to the type
+// used to hash it, since numbers of different types can be equal.
+// Integral numbers are converted to integers and other decimals
+// to doubles, if the conversion is exact.
+// Arrays and documents are copied with their numbers converted.
+func normalizeNumbers(v document.Value) (document.Value, error) {
+	switch v.Type {
+	case document.DoubleValue:
+		f := v.V.(float64)
+		// -2^63 is the smallest integer and 2^63 is greater than the biggest one
+		if math.Trunc(f) == f && f >= math.MinInt64 && f < -math.MinInt64 {
+			return document.NewIntegerValue(int64(f)), nil
+		}
+	case document.DecimalValue:
+		d := v.V.(document.Decimal)
+		if i, err := d.Int64(); err == nil && document.NewDecimal(i, 0).Cmp(d) == 0 {
+			return document.NewIntegerValue(i), nil
+		}
+		f := d.Float64()
+		if fd, err := document.NewDecimalFromFloat64(f); err == nil && fd.Cmp(d) == 0 {
+			return document.NewDoubleValue(f), nil
+		}
+	case document.ArrayValue:
+		var vb document.ValueBuffer
+		err := v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			v, err := normalizeNumbers(v)
+			vb.Append(v)
+			return err
+		})
+		return document.NewArrayValue(&vb), err
+	case document.DocumentValue:
+		var fb document.FieldBuffer
+		err := v.V.(document.Document).Iterate(func(f string, v document.Value) error {
+			v, err := normalizeNumbers(v)
+			fb.Add(f, v)
+			return err
+		})
+		return document.NewDocumentValue(&fb), err
+	}
+
+	return v, nil
+}
+
 func (s documentHashSet) Filter(d document.Document) (bool, error) {
 	k, err := s.generateKey(d)
 	if err != nil {
@@ -1,8 +1,12 @@
 package planner
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -18,6 +22,12 @@ type tableInputNode struct {
 	indexes   map[string]database.Index
 	tx        *database.Transaction
 	params    []expr.Param
+	// if not nil, only these top-level fields
+	// of the documents are decoded.
+	fields []string
+	// if true, the values of the documents are not read
+	// unless one of their fields is accessed.
+	keysOnly bool
 }
 
 var _ inputNode = (*tableInputNode)(nil)
@@ -41,15 +51,77 @@ func (n *tableInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 		return err
 	}
 	n.indexes, err = n.table.Indexes()
+	if err != nil {
+		return err
+	}
+
+	if n.keysOnly {
+		n.table = n.table.KeysOnly()
+	}
+
+	if n.fields != nil {
+		n.table = n.table.WithFields(n.fields)
+	}
+
+	if size := tx.DB().ReadAheadSize; size > 0 {
+		n.table = n.table.WithReadAhead(size)
+	}
 	return
 }
 
+// setKeysOnly configures the node to only read the keys of the table,
+// without reading the values of the documents.
+func (n *tableInputNode) setKeysOnly() {
+	n.keysOnly = true
+	if n.table != nil {
+		n.table = n.table.KeysOnly()
+	}
+}
+
 func (n *tableInputNode) String() string {
+	if n.keysOnly {
+		return fmt.Sprintf("Table(%s, keys only)", n.tableName)
+	}
+
+	if n.fields != nil {
+		return fmt.Sprintf("Table(%s, %s)", n.tableName, fieldsString(n.fields))
+	}
+
 	return fmt.Sprintf("Table(%s)", n.tableName)
 }
 
+func fieldsString(fields []string) string {
+	if len(fields) == 0 {
+		return "no fields"
+	}
+
+	var b strings.Builder
+	b.WriteString("fields: ")
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(document.Path{document.PathFragment{FieldName: f}}.String())
+	}
+
+	return b.String()
+}
+
 func (n *tableInputNode) buildStream() (document.Stream, error) {
-	return document.NewStream(n.table), nil
+	return skipExpired(n.table, document.NewStream(n.table))
+}
+
+// skipExpired removes the expired documents of tb from st.
+func skipExpired(tb *database.Table, st document.Stream) (document.Stream, error) {
+	expired, err := tb.ExpirationFilter()
+	if err != nil || expired == nil {
+		return st, err
+	}
+
+	return st.Filter(func(d document.Document) (bool, error) {
+		ok, err := expired(d)
+		return !ok, err
+	}), nil
 }
 
 type indexInputNode struct {
@@ -67,6 +139,13 @@ type indexInputNode struct {
 	filter           expr.Expr
 	evaluatedFilter  document.Value
 	orderByDirection scanner.Token
+	stableOrder      bool
+	// if true, documents are not read from the table
+	// unless one of their fields is accessed.
+	keysOnly bool
+	// if not nil, only these top-level fields
+	// of the documents are decoded.
+	fields []string
 }
 
 var _ inputNode = (*indexInputNode)(nil)
@@ -92,6 +171,14 @@ func (n *indexInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 		if err != nil {
 			return
 		}
+
+		if n.keysOnly {
+			n.table = n.table.KeysOnly()
+		}
+
+		if n.fields != nil {
+			n.table = n.table.WithFields(n.fields)
+		}
 	}
 
 	if n.index == nil {
@@ -101,56 +188,228 @@ func (n *indexInputNode) Bind(tx *database.Transaction, params []expr.Param) (er
 		}
 	}
 
+	if size := tx.DB().ReadAheadSize; size > 0 {
+		n.index = n.index.WithReadAhead(size)
+	}
+
 	n.tx = tx
 	n.params = params
+	n.stableOrder = tx.DB().StableOrder
+
+	// without filter, the entire index is read
+	if n.filter == nil {
+		return
+	}
 
 	// evaluate the filter expression
 	n.evaluatedFilter, err = n.filter.Eval(&expr.Environment{
 		Params: n.params,
+		Tx:     tx,
 	})
 	if err != nil {
 		return
 	}
 
-	// if the indexed field has no constraint and the filter is an int, cast that int to a double.
-	if n.evaluatedFilter.Type == document.IntegerValue {
-		info, err := n.table.Info()
-		if err != nil {
-			return err
-		}
+	// numbers are converted to the type of the indexed field, if the conversion is lossless.
+	// if the indexed field has no constraint, integers are stored as doubles.
+	// texts compared to timestamps are parsed.
+	_, composite := n.iop.(*compositeIndexOperator)
+	if !isConvertibleFilter(n.evaluatedFilter) && !hasListFilter(n.iop) && !composite {
+		return
+	}
 
-		shouldBeConverted := true
-		for _, fc := range info.FieldConstraints {
-			if fc.Path.IsEqual(n.path) && fc.Type != 0 {
-				shouldBeConverted = false
-				break
+	info, err := n.table.Info()
+	if err != nil {
+		return err
+	}
+
+	// each value of the filter of a composite index is converted to the type of its path
+	if composite {
+		paths := n.index.Opts.Paths()
+
+		var vb document.ValueBuffer
+		err = n.evaluatedFilter.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			if isConvertibleFilter(v) {
+				v = convertFilter(v, indexedFieldType(info, paths[i]))
 			}
-		}
 
-		if shouldBeConverted {
-			n.evaluatedFilter, err = n.evaluatedFilter.CastAsDouble()
-			if err != nil {
-				return err
+			vb.Append(v)
+			return nil
+		})
+		n.evaluatedFilter = document.NewArrayValue(&vb)
+		return
+	}
+
+	target := indexedFieldType(info, n.path)
+
+	if isConvertibleFilter(n.evaluatedFilter) {
+		n.evaluatedFilter = convertFilter(n.evaluatedFilter, target)
+		return
+	}
+
+	// the values of the IN operator and the bounds of the BETWEEN operator
+	// are converted separately
+	if n.evaluatedFilter.Type == document.ArrayValue {
+		between := isBetweenOperator(n.iop)
+
+		var vb document.ValueBuffer
+		err = n.evaluatedFilter.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			if between {
+				v = convertBetweenBound(v, target, i == 0)
+			} else if isConvertibleFilter(v) {
+				v = convertFilter(v, target)
 			}
-		}
+
+			vb.Append(v)
+			return nil
+		})
+		n.evaluatedFilter = document.NewArrayValue(&vb)
 	}
 	return
 }
 
+// indexedFieldType returns the type of the values of the path stored in indexes.
+func indexedFieldType(info *database.TableInfo, path document.Path) document.ValueType {
+	for _, fc := range info.FieldConstraints {
+		if fc.Path.IsEqual(path) && fc.Type != 0 {
+			return fc.Type
+		}
+	}
+
+	return document.DoubleValue
+}
+
+// isConvertibleFilter returns true if v may have to be converted to the type of the indexed field.
+func isConvertibleFilter(v document.Value) bool {
+	return v.Type.IsNumber() || v.Type == document.TextValue
+}
+
+// convertFilter converts a number to the type of the indexed field,
+// if the conversion is lossless, or a text to a timestamp.
+func convertFilter(v document.Value, target document.ValueType) document.Value {
+	// NaN and infinite doubles can't be converted to decimals,
+	// they are left as is and don't match any value.
+	if canConvertFilter(v.Type, target) {
+		if cv, err := v.CastAs(target); err == nil {
+			return cv
+		}
+	}
+
+	return v
+}
+
+// convertBetweenBound converts a bound of the BETWEEN operator to the type of the indexed field.
+// Doubles compared to integers are rounded towards the inside of the range, so that
+// the range selects the same integers.
+func convertBetweenBound(v document.Value, target document.ValueType, low bool) document.Value {
+	if !isConvertibleFilter(v) {
+		return v
+	}
+
+	v = convertFilter(v, target)
+	if v.Type != document.DoubleValue || target != document.IntegerValue {
+		return v
+	}
+
+	f := v.V.(float64)
+	if low {
+		f = math.Ceil(f)
+	} else {
+		f = math.Floor(f)
+	}
+
+	// NaN and out of range doubles are left as is and don't match any value.
+	if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return v
+	}
+
+	return document.NewIntegerValue(int64(f))
+}
+
+// hasListFilter returns true if iop is the IN or the BETWEEN operator,
+// whose filter is a list of values.
+func hasListFilter(iop IndexIteratorOperator) bool {
+	op, ok := iop.(expr.Operator)
+	return ok && (expr.IsInOperator(op) || op.Token() == scanner.BETWEEN)
+}
+
+// isBetweenOperator returns true if iop is the BETWEEN operator.
+func isBetweenOperator(iop IndexIteratorOperator) bool {
+	op, ok := iop.(expr.Operator)
+	return ok && op.Token() == scanner.BETWEEN
+}
+
+// canConvertFilter returns whether a value of type from can be converted
+// to the type of an indexed field without changing the result of the comparison.
+func canConvertFilter(from, to document.ValueType) bool {
+	switch to {
+	case document.TimestampValue:
+		return from == document.TextValue
+	case document.DoubleValue:
+		return from == document.IntegerValue
+	case document.DecimalValue:
+		return from == document.IntegerValue || from == document.DoubleValue
+	}
+
+	return false
+}
+
 func (n *indexInputNode) buildStream() (document.Stream, error) {
-	return document.NewStream(&indexIterator{
-		tx:     n.tx,
-		tb:     n.table,
-		params: n.params,
-		index:  n.index,
-		path:   n.path,
-		filter: n.evaluatedFilter,
-		iop:    n.iop,
-	}), nil
+	return skipExpired(n.table, document.NewStream(&indexIterator{
+		tx:               n.tx,
+		tb:               n.table,
+		params:           n.params,
+		index:            n.index,
+		path:             n.path,
+		filter:           n.evaluatedFilter,
+		iop:              n.iop,
+		orderByDirection: n.orderByDirection,
+		stableOrder:      n.stableOrder,
+	}))
+}
+
+// setKeysOnly configures the node to only read keys from the index,
+// without fetching the documents from the table.
+func (n *indexInputNode) setKeysOnly() {
+	n.keysOnly = true
+	if n.table != nil {
+		n.table = n.table.KeysOnly()
+	}
+}
+
+// setFields configures the node to only decode the given
+// top-level fields of the documents.
+func (n *indexInputNode) setFields(fields []string) {
+	n.fields = fields
+	if n.table != nil {
+		n.table = n.table.WithFields(fields)
+	}
 }
 
 func (n *indexInputNode) String() string {
-	return fmt.Sprintf("Index(%s)", n.indexName)
+	name := n.indexName
+	if n.direction() == scanner.DESC {
+		name += " DESC"
+	}
+
+	if n.keysOnly {
+		return fmt.Sprintf("Index(%s, keys only)", name)
+	}
+
+	if n.fields != nil {
+		return fmt.Sprintf("Index(%s, %s)", name, fieldsString(n.fields))
+	}
+
+	return fmt.Sprintf("Index(%s)", name)
+}
+
+// direction returns the direction in which the index is read.
+func (n *indexInputNode) direction() scanner.Token {
+	if n.orderByDirection == scanner.DESC {
+		return scanner.DESC
+	}
+
+	return scanner.ASC
 }
 
 // IndexIteratorOperator is an operator that can be used
@@ -168,6 +427,7 @@ type indexIterator struct {
 	iop              IndexIteratorOperator
 	filter           document.Value
 	orderByDirection scanner.Token
+	stableOrder      bool
 }
 
 var errStop = errors.New("stop")
@@ -199,5 +459,193 @@ func (it indexIterator) Iterate(fn func(d document.Document) error) error {
 		return err
 	}
 
+	if it.stableOrder {
+		return it.iterateInKeyOrder(fn)
+	}
+
 	return it.iop.IterateIndex(it.index, it.tb, it.filter, fn)
 }
+
+// iterateInKeyOrder collects the keys of the documents selected by the index,
+// without reading the documents, and reads them once in primary key order.
+func (it indexIterator) iterateInKeyOrder(fn func(d document.Document) error) error {
+	var keys [][]byte
+
+	err := it.iop.IterateIndex(it.index, it.tb.KeysOnly(), it.filter, func(d document.Document) error {
+		k, ok := d.(document.Keyer)
+		if !ok {
+			return errors.New("missing key")
+		}
+
+		keys = append(keys, append([]byte{}, k.RawKey()...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	for _, k := range keys {
+		d, err := it.tb.GetDocument(k)
+		if err != nil {
+			return err
+		}
+
+		err = fn(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compositeIndexOperator reads a composite index using equality filters
+// on the first paths of the index, and optionally a range filter on the next path,
+// or on the next paths if they are compared as a row value, such as (b, c) > (1, 2).
+// The filter is an array of the values compared to these paths, in the order of the index.
+type compositeIndexOperator struct {
+	// number of equality filters
+	eq int
+	// operator of the range filter, if any
+	rangeTok scanner.Token
+	// number of elements of the row value compared by the range filter, if any
+	row int
+}
+
+// IterateIndex reads the documents whose indexed values match the filters.
+// The range filter is only used to reduce the number of entries read from the index,
+// it must still be evaluated on the selected documents.
+func (op *compositeIndexOperator) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	var values []document.Value
+	err := v.V.(document.Array).Iterate(func(i int, v document.Value) error {
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pivot := document.NewValueBuffer()
+	for _, v := range values[:op.eq] {
+		pivot = pivot.Append(v)
+	}
+	if op.rangeTok == scanner.GT || op.rangeTok == scanner.GTE {
+		// elements of a row value that follow a NULL don't limit the range
+		for _, v := range values[op.eq : op.eq+op.rangeLen()] {
+			if v.Type == document.NullValue && op.row > 0 {
+				break
+			}
+			pivot = pivot.Append(v)
+		}
+	}
+
+	// an empty pivot reads all the entries of the index
+	pv := document.Value{Type: document.ArrayValue}
+	if pivot.Len() > 0 {
+		pv = document.NewArrayValue(pivot)
+	}
+
+	err = idx.AscendGreaterOrEqual(pv, func(val, key []byte, isEqual bool) error {
+		iv, err := idx.DecodeValue(val)
+		if err != nil {
+			return err
+		}
+
+		ok, err := op.match(iv.V.(document.Array), values)
+		if err != nil || !ok {
+			if err == nil {
+				err = errStop
+			}
+			return err
+		}
+
+		d, err := tb.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		return fn(d)
+	})
+	if err != nil && err != errStop {
+		return err
+	}
+
+	return nil
+}
+
+// match returns false once the indexed values are past the range selected by the filters.
+func (op *compositeIndexOperator) match(a document.Array, values []document.Value) (bool, error) {
+	for i := 0; i < op.eq; i++ {
+		v, err := a.GetByIndex(i)
+		if err != nil {
+			return false, err
+		}
+
+		ok, err := v.IsEqual(values[i])
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if op.rangeTok != scanner.LT && op.rangeTok != scanner.LTE {
+		return true, nil
+	}
+
+	if op.row > 0 {
+		return op.matchRow(a, values[op.eq:op.eq+op.row])
+	}
+
+	v, err := a.GetByIndex(op.eq)
+	if err != nil {
+		return false, err
+	}
+
+	if op.rangeTok == scanner.LT {
+		ok, err := v.IsGreaterThanOrEqual(values[op.eq])
+		return !ok, err
+	}
+
+	ok, err := v.IsGreaterThan(values[op.eq])
+	return !ok, err
+}
+
+// matchRow returns false once the indexed values are greater than the row value,
+// compared element by element.
+// Elements following a NULL are not compared, as the row value is then only compared to the previous elements.
+func (op *compositeIndexOperator) matchRow(a document.Array, row []document.Value) (bool, error) {
+	for i, rv := range row {
+		if rv.Type == document.NullValue {
+			return true, nil
+		}
+
+		v, err := a.GetByIndex(op.eq + i)
+		if err != nil {
+			return false, err
+		}
+
+		ok, err := v.IsEqual(rv)
+		if err != nil || !ok {
+			if err != nil {
+				return false, err
+			}
+
+			ok, err = v.IsGreaterThan(rv)
+			return !ok, err
+		}
+	}
+
+	return true, nil
+}
+
+// rangeLen returns the number of values of the range filter.
+func (op *compositeIndexOperator) rangeLen() int {
+	if op.row > 0 {
+		return op.row
+	}
+
+	return 1
+}
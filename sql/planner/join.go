@@ -0,0 +1,558 @@
+package planner
+
+import (
+	"fmt"
+	"hash/maphash"
+	"strings"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// A JoinedTable is one side of a join: a table and the path of the field
+// compared with the other side, relative to the documents of the table.
+// If CTE is not nil, the documents are read from the common table expression
+// named TableName instead.
+type JoinedTable struct {
+	TableName string
+	Path      document.Path
+	CTE       *CTE
+}
+
+func (t JoinedTable) String() string {
+	return fmt.Sprintf("%s.%s", document.Path{document.PathFragment{FieldName: t.TableName}}, t.Path)
+}
+
+// JoinKind determines which documents are returned by a join
+// when no matching document is found on the other side.
+type JoinKind int
+
+// List of join kinds.
+const (
+	// InnerJoin only returns documents that have a match on both sides.
+	InnerJoin JoinKind = iota
+	// LeftJoin also returns the documents of the left table that have no match,
+	// with null values for the fields of the right table.
+	LeftJoin
+	// RightJoin also returns the documents of the right table that have no match,
+	// with null values for the fields of the left table.
+	RightJoin
+)
+
+func (k JoinKind) String() string {
+	switch k {
+	case LeftJoin:
+		return "LeftJoin"
+	case RightJoin:
+		return "RightJoin"
+	}
+
+	return "Join"
+}
+
+type joinNode struct {
+	node
+
+	kind        JoinKind
+	left, right JoinedTable
+
+	tx *database.Transaction
+	// if not nil, the documents of the inner table are selected
+	// using this index, which is built on the path of the inner table.
+	index *database.Index
+	// if true, the right table is the outer table.
+	swapped bool
+}
+
+var _ inputNode = (*joinNode)(nil)
+
+// NewJoinNode creates an input node that returns the documents of both tables
+// whose values at the given paths are equal. Depending on kind, documents without
+// a match on the other side are also returned, joined with a document whose fields are null.
+// These fields are the top-level fields declared by the table constraints and the joined field.
+//
+// If the table whose documents are looked up has an index on its path, the documents
+// of the other table are read in order and matching documents are looked up using the index.
+// Otherwise, the keys of its documents are first loaded in memory, grouped by the hash
+// of their value. Inner joins can use the index of either table, while outer joins
+// always read the table whose documents are all returned.
+// Documents of common table expressions are already in memory and are grouped the same way.
+//
+// The fields of the documents returned by the node are prefixed by the name of their table,
+// i.e. "users.id", to avoid collisions. Unqualified fields can also be read,
+// in which case the left document takes precedence.
+func NewJoinNode(kind JoinKind, left, right JoinedTable) Node {
+	return &joinNode{
+		node: node{
+			op: Join,
+		},
+		kind:  kind,
+		left:  left,
+		right: right,
+	}
+}
+
+func (n *joinNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	n.tx = tx
+	n.index = nil
+	n.swapped = false
+
+	// the index is looked up on the right table first,
+	// to read the left table in order when possible.
+	// outer joins must read every document of the preserved table.
+	for i, jt := range []JoinedTable{n.right, n.left} {
+		swapped := i == 1
+		if (n.kind == LeftJoin && swapped) || (n.kind == RightJoin && !swapped) {
+			continue
+		}
+
+		// common table expressions have no indexes
+		if jt.CTE != nil {
+			continue
+		}
+
+		tb, err := tx.GetTable(jt.TableName)
+		if err != nil {
+			return err
+		}
+
+		indexes, err := tb.Indexes()
+		if err != nil {
+			return err
+		}
+
+		if idx, ok := indexes[jt.Path.String()]; ok && n.index == nil {
+			n.index = &idx
+			n.swapped = swapped
+		}
+	}
+
+	if n.kind == RightJoin {
+		n.swapped = true
+	}
+
+	return nil
+}
+
+func (n *joinNode) String() string {
+	s := fmt.Sprintf("%s(%s, %s, on: %s = %s", n.kind, n.left.TableName, n.right.TableName, n.left, n.right)
+	if n.index != nil {
+		s += fmt.Sprintf(", index: %s", n.index.Opts.IndexName)
+	}
+
+	return s + ")"
+}
+
+func (n *joinNode) buildStream() (document.Stream, error) {
+	outer, inner := n.left, n.right
+	if n.swapped {
+		outer, inner = inner, outer
+	}
+
+	iterate := func(fn func(d document.Document) error) error {
+		return outer.CTE.iterate(fn)
+	}
+	if outer.CTE == nil {
+		outerTable, err := n.tx.GetTable(outer.TableName)
+		if err != nil {
+			return document.Stream{}, err
+		}
+		st, err := skipExpired(outerTable, document.NewStream(outerTable))
+		if err != nil {
+			return document.Stream{}, err
+		}
+		iterate = st.Iterate
+	}
+
+	var innerTable *database.Table
+	if inner.CTE == nil {
+		var err error
+		innerTable, err = n.tx.GetTable(inner.TableName)
+		if err != nil {
+			return document.Stream{}, err
+		}
+	}
+
+	return document.NewStream(document.IteratorFunc(func(fn func(d document.Document) error) error {
+		var lookup joinLookup
+		var err error
+		switch {
+		case inner.CTE != nil:
+			lookup, err = cteLookup(inner)
+		case n.index != nil:
+			lookup = n.indexLookup(innerTable, inner)
+		default:
+			lookup, err = hashLookup(innerTable, inner)
+		}
+		if err != nil {
+			return err
+		}
+		if innerTable != nil {
+			lookup, err = skipExpiredLookup(innerTable, lookup)
+			if err != nil {
+				return err
+			}
+		}
+
+		// used when an outer document has no match
+		var nullDoc document.Document
+		if n.kind != InnerJoin {
+			if inner.CTE != nil {
+				nullDoc = cteNullDocument(inner)
+			} else {
+				nullDoc, err = nullDocument(innerTable, inner)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		var jd joinedDocument
+		jd.left.name, jd.right.name = n.left.TableName, n.right.TableName
+
+		emit := func(od, id document.Document) error {
+			if n.swapped {
+				jd.left.d, jd.right.d = id, od
+			} else {
+				jd.left.d, jd.right.d = od, id
+			}
+
+			return fn(&jd)
+		}
+
+		return iterate(func(od document.Document) error {
+			var matched bool
+
+			v, err := outer.Path.GetValueFromDocument(od)
+			if err != nil && err != document.ErrFieldNotFound {
+				return err
+			}
+
+			// NULL is not equal to anything
+			if err == nil && v.Type != document.NullValue {
+				err = lookup(v, func(id document.Document) error {
+					matched = true
+					return emit(od, id)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if !matched && nullDoc != nil {
+				return emit(od, nullDoc)
+			}
+
+			return nil
+		})
+	})), nil
+}
+
+// nullDocument returns a document containing the top-level fields declared
+// by the constraints of the table and the first field of the joined path, set to null.
+func nullDocument(tb *database.Table, jt JoinedTable) (document.Document, error) {
+	info, err := tb.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	var fb document.FieldBuffer
+	add := func(field string) {
+		if field == "" {
+			return
+		}
+		if _, err := fb.GetByField(field); err == document.ErrFieldNotFound {
+			fb.Add(field, document.NewNullValue())
+		}
+	}
+
+	for _, fc := range info.FieldConstraints {
+		add(fc.Path[0].FieldName)
+	}
+	add(jt.Path[0].FieldName)
+
+	return &fb, nil
+}
+
+// cteNullDocument returns a document containing the top-level fields of the first
+// document of the common table expression and the first field of the joined path, set to null.
+func cteNullDocument(jt JoinedTable) document.Document {
+	var fb document.FieldBuffer
+	add := func(field string) {
+		if _, err := fb.GetByField(field); err == document.ErrFieldNotFound {
+			fb.Add(field, document.NewNullValue())
+		}
+	}
+
+	if len(jt.CTE.docs) > 0 {
+		_ = jt.CTE.docs[0].Iterate(func(field string, _ document.Value) error {
+			add(field)
+			return nil
+		})
+	}
+	add(jt.Path[0].FieldName)
+
+	return &fb
+}
+
+// a joinLookup calls fn for every document of the inner table
+// whose value is equal to v.
+type joinLookup func(v document.Value, fn func(d document.Document) error) error
+
+// skipExpiredLookup wraps lookup so that the expired documents of tb are skipped.
+func skipExpiredLookup(tb *database.Table, lookup joinLookup) (joinLookup, error) {
+	expired, err := tb.ExpirationFilter()
+	if err != nil || expired == nil {
+		return lookup, err
+	}
+
+	return func(v document.Value, fn func(d document.Document) error) error {
+		return lookup(v, func(d document.Document) error {
+			ok, err := expired(d)
+			if err != nil || ok {
+				return err
+			}
+
+			return fn(d)
+		})
+	}, nil
+}
+
+// indexLookup selects the documents of the inner table using the index of the node.
+func (n *joinNode) indexLookup(tb *database.Table, inner JoinedTable) joinLookup {
+	// numbers are converted to the type of the indexed field, if the conversion is lossless.
+	// if the indexed field has no constraint, integers are stored as doubles.
+	// texts compared to timestamps are parsed.
+	target := document.DoubleValue
+	if info, err := tb.Info(); err == nil {
+		target = indexedFieldType(info, inner.Path)
+	}
+
+	return func(v document.Value, fn func(d document.Document) error) error {
+		if isConvertibleFilter(v) && canConvertFilter(v.Type, target) {
+			if cv, err := v.CastAs(target); err == nil {
+				v = cv
+			}
+		}
+
+		err := n.index.AscendGreaterOrEqual(v, func(val, key []byte, isEqual bool) error {
+			if !isEqual {
+				return errStop
+			}
+
+			d, err := tb.GetDocument(key)
+			if err != nil {
+				return err
+			}
+
+			return fn(d)
+		})
+		if err == errStop {
+			err = nil
+		}
+		return err
+	}
+}
+
+// hashLookup reads the inner table once and keeps the keys of its documents in memory,
+// grouped by the hash of their value. Only the joined field is decoded.
+// Documents are fetched again when their hash matches, and their value is compared
+// to make sure they are equal.
+func hashLookup(tb *database.Table, inner JoinedTable) (joinLookup, error) {
+	var h maphash.Hash
+	keys := make(map[uint64][][]byte)
+
+	err := tb.WithFields([]string{inner.Path[0].FieldName}).Iterate(func(d document.Document) error {
+		v, err := inner.Path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if v.Type == document.NullValue {
+			return nil
+		}
+
+		k, err := hashJoinValue(&h, v)
+		if err != nil {
+			return err
+		}
+
+		keys[k] = append(keys[k], append([]byte(nil), d.(document.Keyer).RawKey()...))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v document.Value, fn func(d document.Document) error) error {
+		k, err := hashJoinValue(&h, v)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys[k] {
+			d, err := tb.GetDocument(key)
+			if err != nil {
+				return err
+			}
+
+			iv, err := inner.Path.GetValueFromDocument(d)
+			if err != nil {
+				return err
+			}
+
+			ok, err := v.IsEqual(iv)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			err = fn(d)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+// cteLookup groups the documents of the common table expression
+// by the hash of their value.
+func cteLookup(inner JoinedTable) (joinLookup, error) {
+	var h maphash.Hash
+	docs := make(map[uint64][]document.Document)
+
+	err := inner.CTE.iterate(func(d document.Document) error {
+		v, err := inner.Path.GetValueFromDocument(d)
+		if err == document.ErrFieldNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if v.Type == document.NullValue {
+			return nil
+		}
+
+		k, err := hashJoinValue(&h, v)
+		if err != nil {
+			return err
+		}
+
+		docs[k] = append(docs[k], d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v document.Value, fn func(d document.Document) error) error {
+		k, err := hashJoinValue(&h, v)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range docs[k] {
+			iv, err := inner.Path.GetValueFromDocument(d)
+			if err != nil {
+				return err
+			}
+
+			ok, err := v.IsEqual(iv)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			err = fn(d)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+// hashJoinValue returns the hash of v. Numbers are converted to doubles
+// so that equal numbers of different types have the same hash.
+func hashJoinValue(h *maphash.Hash, v document.Value) (uint64, error) {
+	defer h.Reset()
+
+	if v.Type.IsNumber() {
+		dv, err := v.CastAsDouble()
+		if err == nil {
+			v = dv
+		}
+	}
+
+	err := document.NewValueEncoder(h).Encode(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.Sum64(), nil
+}
+
+// joinedDocument is a document made of the fields of two documents.
+type joinedDocument struct {
+	left, right struct {
+		name string
+		d    document.Document
+	}
+}
+
+// GetByField returns the left or right document if field is the name of its table,
+// or the value of one of its fields if field is prefixed by the name of its table.
+// Otherwise, it returns the value of the field of the left document,
+// or of the right one if the left document doesn't contain it.
+func (d *joinedDocument) GetByField(field string) (document.Value, error) {
+	switch field {
+	case d.left.name:
+		return document.NewDocumentValue(d.left.d), nil
+	case d.right.name:
+		return document.NewDocumentValue(d.right.d), nil
+	}
+
+	if f := strings.TrimPrefix(field, d.left.name+"."); f != field {
+		return d.left.d.GetByField(f)
+	}
+	if f := strings.TrimPrefix(field, d.right.name+"."); f != field {
+		return d.right.d.GetByField(f)
+	}
+
+	v, err := d.left.d.GetByField(field)
+	if err != document.ErrFieldNotFound {
+		return v, err
+	}
+
+	return d.right.d.GetByField(field)
+}
+
+// Iterate over the fields of the left document, then over the fields
+// of the right document, prefixed by the name of their table.
+func (d *joinedDocument) Iterate(fn func(field string, value document.Value) error) error {
+	err := d.left.d.Iterate(func(field string, value document.Value) error {
+		return fn(d.left.name+"."+field, value)
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.right.d.Iterate(func(field string, value document.Value) error {
+		return fn(d.right.name+"."+field, value)
+	})
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d *joinedDocument) MarshalJSON() ([]byte, error) {
+	return document.MarshalJSON(d)
+}
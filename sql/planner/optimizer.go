@@ -1,9 +1,13 @@
 package planner
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/query/glob"
 	"github.com/genjidb/genji/sql/scanner"
 )
 
@@ -13,6 +17,12 @@ var optimizerRules = []func(t *Tree) (*Tree, error){
 	RemoveUnnecessarySelectionNodesRule,
 	RemoveUnnecessaryDedupNodeRule,
 	UseIndexBasedOnSelectionNodeRule,
+	UseIndexBasedOnSortNodeRule,
+	UseSortedAggregationRule,
+	SortGroupsRule,
+	UseTopKSortRule,
+	UseKeysOnlyInputRule,
+	PushProjectedFieldsToInputRule,
 }
 
 // Optimize takes a tree, applies a list of optimization rules
@@ -36,13 +46,17 @@ func Optimize(t *Tree) (*Tree, error) {
 // is one or more AND operators into one or more selection nodes.
 // The condition won't be split if the expression tree contains an OR
 // operation.
+// Equalities of row values are split into one equality per element,
+// so that the elements can be looked up in indexes.
 // Example:
-//   this:
-//     σ(a > 2 AND b != 3 AND c < 2)
-//   becomes this:
-//     σ(a > 2)
-//     σ(b != 3)
-//     σ(c < 2)
+//
+//	this:
+//	  σ(a > 2 AND b != 3 AND (c, d) = (2, 4))
+//	becomes this:
+//	  σ(a > 2)
+//	  σ(b != 3)
+//	  σ(c = 2)
+//	  σ(d = 4)
 func SplitANDConditionRule(t *Tree) (*Tree, error) {
 	n := t.Root
 	var prev Node
@@ -56,18 +70,19 @@ func SplitANDConditionRule(t *Tree) (*Tree, error) {
 				// only OR has a lower precedence,
 				// which means that if AND is used without OR, it will be at
 				// the top of the expression tree.
-				if op, ok := cond.(expr.Operator); ok && expr.IsAndOperator(op) {
-					exprs := splitANDExpr(cond)
-
+				if exprs := splitANDExpr(cond); len(exprs) > 1 {
 					cur := n.Left()
 					i := len(exprs) - 1
-					var newNode Node
+					var newNode, last Node
 					for i >= 0 {
 						newNode = NewSelectionNode(cur, exprs[i])
 						err := newNode.Bind(sn.tx, sn.params)
 						if err != nil {
 							return nil, err
 						}
+						if last == nil {
+							last = newNode
+						}
 						cur = newNode
 
 						i--
@@ -78,6 +93,9 @@ func SplitANDConditionRule(t *Tree) (*Tree, error) {
 					} else {
 						t.Root = newNode
 					}
+
+					// the next node is attached to the last selection node
+					n = last
 				}
 			}
 		}
@@ -90,6 +108,7 @@ func SplitANDConditionRule(t *Tree) (*Tree, error) {
 }
 
 // splitANDExpr takes an expression and splits it by AND operator.
+// Equalities of row values are split by element.
 func splitANDExpr(cond expr.Expr) (exprs []expr.Expr) {
 	op, ok := cond.(expr.Operator)
 	if ok && expr.IsAndOperator(op) {
@@ -98,6 +117,19 @@ func splitANDExpr(cond expr.Expr) (exprs []expr.Expr) {
 		return
 	}
 
+	if ok && op.Token() == scanner.EQ {
+		l, lok := op.LeftHand().(expr.LiteralExprList)
+		r, rok := op.RightHand().(expr.LiteralExprList)
+		// rows of different sizes can't be compared,
+		// the error is returned when running the query
+		if lok && rok && len(l) == len(r) {
+			for i := range l {
+				exprs = append(exprs, splitANDExpr(expr.Eq(l[i], r[i]))...)
+			}
+			return
+		}
+	}
+
 	exprs = append(exprs, cond)
 	return
 }
@@ -107,15 +139,16 @@ func splitANDExpr(cond expr.Expr) (exprs []expr.Expr) {
 // The result of constant sub-expressions, like "3 + 4", is always the same and thus
 // can be precalculated.
 // Examples:
-//   3 + 4 --> 7
-//   3 + 1 > 10 - a --> 4 > 10 - a
+//
+//	3 + 4 --> 7
+//	3 + 1 > 10 - a --> 4 > 10 - a
 func PrecalculateExprRule(t *Tree) (*Tree, error) {
 	n := t.Root
 
 	for n != nil {
 		if n.Operation() == Selection {
 			sn := n.(*selectionNode)
-			sn.cond = precalculateExpr(sn.cond)
+			sn.cond = precalculateExpr(sn.cond, sn.tx)
 		}
 
 		n = n.Left()
@@ -128,14 +161,14 @@ func PrecalculateExprRule(t *Tree) (*Tree, error) {
 // expression nodes when possible.
 // it returns a new expression with simplified nodes.
 // if no simplification is possible it returns the same expression.
-func precalculateExpr(e expr.Expr) expr.Expr {
+func precalculateExpr(e expr.Expr, tx *database.Transaction) expr.Expr {
 	switch t := e.(type) {
 	case expr.LiteralExprList:
 		// we assume that the list of expressions contains only literals
 		// until proven wrong.
 		literalsOnly := true
 		for i, te := range t {
-			newExpr := precalculateExpr(te)
+			newExpr := precalculateExpr(te, tx)
 			if _, ok := newExpr.(expr.LiteralValue); !ok {
 				literalsOnly = false
 			}
@@ -159,7 +192,7 @@ func precalculateExpr(e expr.Expr) expr.Expr {
 		literalsOnly := true
 
 		for i, kv := range t {
-			kv.V = precalculateExpr(kv.V)
+			kv.V = precalculateExpr(kv.V, tx)
 			if _, ok := kv.V.(expr.LiteralValue); !ok {
 				literalsOnly = false
 			}
@@ -188,8 +221,8 @@ func precalculateExpr(e expr.Expr) expr.Expr {
 			return e
 		}
 
-		lh := precalculateExpr(t.LeftHand())
-		rh := precalculateExpr(t.RightHand())
+		lh := precalculateExpr(t.LeftHand(), tx)
+		rh := precalculateExpr(t.RightHand(), tx)
 		t.SetLeftHandExpr(lh)
 		t.SetRightHandExpr(rh)
 
@@ -197,10 +230,11 @@ func precalculateExpr(e expr.Expr) expr.Expr {
 		_, rightIsLit := rh.(expr.LiteralValue)
 		// if both operands are literals, we can precalculate them now
 		if leftIsLit && rightIsLit {
-			v, err := t.Eval(&expr.Environment{})
-			// any error encountered here is unexpected
+			v, err := t.Eval(&expr.Environment{Tx: tx})
+			// leave the expression as is, the error
+			// will be returned when running the query
 			if err != nil {
-				panic(err)
+				return e
 			}
 			// we replace this expression with the result of its evaluation
 			return expr.LiteralValue(v)
@@ -256,19 +290,11 @@ func RemoveUnnecessaryDedupNodeRule(t *Tree) (*Tree, error) {
 	var prev Node
 
 	for n != nil {
-		if n.Operation() == Dedup {
-			d, ok := n.(*dedupNode)
-			if !ok {
-				continue
-			}
-
-			pn, ok := d.left.(*ProjectionNode)
-			if !ok {
-				continue
-			}
-
+		// DISTINCT ON nodes only compare some values of the documents,
+		// they are kept even if the projection is unique.
+		if d, ok := n.(*dedupNode); ok && len(d.exprs) == 0 {
 			// if the projection is unique, we remove the node from the tree
-			if isProjectionUnique(d.indexes, pn) {
+			if pn, ok := d.left.(*ProjectionNode); ok && isProjectionUnique(d.indexes, pn) {
 				if prev != nil {
 					prev.SetLeft(n.Left())
 				} else {
@@ -285,6 +311,11 @@ func RemoveUnnecessaryDedupNodeRule(t *Tree) (*Tree, error) {
 }
 
 func isProjectionUnique(indexes map[string]database.Index, pn *ProjectionNode) bool {
+	// projections of joined documents are never unique
+	if pn.info == nil {
+		return false
+	}
+
 	pk := pn.info.GetPrimaryKey()
 	for _, field := range pn.Expressions {
 		e, ok := field.(ProjectedExpr)
@@ -336,9 +367,11 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 		return t, nil
 	}
 
-	// Here we will assume that at this point
-	// inputNodes can only be instances of tableInputNode.
-	inpn := inputNode.(*tableInputNode)
+	// only the documents of tables can be read using an index
+	inpn, ok := inputNode.(*tableInputNode)
+	if !ok {
+		return t, nil
+	}
 
 	type candidate struct {
 		prevNode, nextNode Node
@@ -346,13 +379,25 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 	}
 
 	var candidates []candidate
+	var selections []*selectionNode
+
+	// partial indexes can only be used if one of the conditions is their predicate
+	conds := selectionConditions(t)
 
 	n = t.Root
 	// look for all selection nodes that satisfy our requirements
 	for n != nil {
+		// selection nodes that filter aggregated or unnested documents
+		// don't apply to the documents of the table
+		if n.Operation() == Aggregation || n.Operation() == Unnest {
+			candidates = candidates[:0]
+			selections = selections[:0]
+		}
+
 		if n.Operation() == Selection {
 			sn := n.(*selectionNode)
-			indexedNode := selectionNodeValidForIndex(sn, inpn.tableName, inpn.indexes)
+			selections = append(selections, sn)
+			indexedNode := selectionNodeValidForIndex(sn, inpn.tableName, inpn.indexes, conds)
 			if indexedNode != nil {
 				candidates = append(candidates, candidate{
 					prevNode: prev,
@@ -385,6 +430,20 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 		}
 	}
 
+	// a composite index is preferred if it filters more than one path,
+	// or if no other index can be used.
+	in, eqNodes, score := compositeIndexCandidate(selections, inpn.tableName, inpn.indexes, conds)
+	if in != nil && (score > 1 || selectedCandidate == nil) {
+		if err := in.Bind(inpn.tx, inpn.params); err != nil {
+			return nil, err
+		}
+
+		// the selection nodes of the equalities are removed from the tree,
+		// the range filter only limits the scan of the index and is kept.
+		removeSelectionNodes(t, eqNodes)
+		return replaceInputNode(t, in), nil
+	}
+
 	if selectedCandidate == nil {
 		return t, nil
 	}
@@ -401,8 +460,14 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 		selectedCandidate.prevNode.SetLeft(selectedCandidate.nextNode)
 	}
 
-	n = t.Root
-	prev = nil
+	return replaceInputNode(t, selectedCandidate.in), nil
+}
+
+// replaceInputNode replaces the input node of the tree by the given node.
+func replaceInputNode(t *Tree, in Node) *Tree {
+	var prev Node
+	n := t.Root
+
 	// we lookup again for the input node and the node that is right before.
 	for n != nil {
 		if n.Operation() == Input {
@@ -415,19 +480,267 @@ func UseIndexBasedOnSelectionNodeRule(t *Tree) (*Tree, error) {
 
 	// we replace the table input node by the selected indexInputNode
 	if prev == nil {
-		t.Root = selectedCandidate.in
+		t.Root = in
 	} else {
-		prev.SetLeft(selectedCandidate.in)
+		prev.SetLeft(in)
 	}
 
-	return t, nil
+	return t
+}
+
+// removeSelectionNodes removes the given selection nodes from the tree.
+func removeSelectionNodes(t *Tree, nodes []*selectionNode) {
+	var prev Node
+	n := t.Root
+
+	for n != nil {
+		next := n.Left()
+
+		removed := false
+		for _, sn := range nodes {
+			if n == Node(sn) {
+				removed = true
+				break
+			}
+		}
+
+		if !removed {
+			prev = n
+		} else if prev == nil {
+			t.Root = next
+		} else {
+			prev.SetLeft(next)
+		}
+
+		n = next
+	}
+}
+
+// compositeIndexCandidate looks for the composite index whose leading paths are compared
+// with the most equality operators, optionally followed by a path compared with a range operator.
+// It returns an input node reading that index, the selection nodes of the equalities and the number
+// of paths of the index filtered by the selection nodes.
+func compositeIndexCandidate(selections []*selectionNode, tableName string, indexes map[string]database.Index, conds map[string]bool) (*indexInputNode, []*selectionNode, int) {
+	// indexes are sorted by name to always select the same index
+	names := make([]string, 0, len(indexes))
+	byName := make(map[string]database.Index, len(indexes))
+	for _, idx := range indexes {
+		if idx.Opts.IsComposite() && indexCoversConditions(idx, conds) {
+			names = append(names, idx.Opts.IndexName)
+			byName[idx.Opts.IndexName] = idx
+		}
+	}
+	sort.Strings(names)
+
+	var best *indexInputNode
+	var bestNodes []*selectionNode
+	var bestScore int
+
+	for _, name := range names {
+		idx := byName[name]
+		paths := idx.Opts.Paths()
+
+		var eqNodes []*selectionNode
+		var filter expr.LiteralExprList
+		for _, p := range paths {
+			sn, e := findIndexableSelection(selections, p, scanner.EQ)
+			if sn == nil {
+				break
+			}
+
+			eqNodes = append(eqNodes, sn)
+			filter = append(filter, e)
+		}
+
+		iop := compositeIndexOperator{eq: len(eqNodes)}
+		if len(eqNodes) < len(paths) {
+			for _, tok := range []scanner.Token{scanner.GT, scanner.GTE, scanner.LT, scanner.LTE} {
+				if sn, e := findIndexableSelection(selections, paths[len(eqNodes)], tok); sn != nil {
+					iop.rangeTok = tok
+					filter = append(filter, e)
+					break
+				}
+			}
+
+			// a row value compared to the next paths filters more paths than a single path
+			if tok, row := findRowRangeSelection(selections, paths[len(eqNodes):]); len(row) > 1 {
+				if iop.rangeTok != 0 {
+					filter = filter[:len(filter)-1]
+				}
+				iop.rangeTok = tok
+				iop.row = len(row)
+				filter = append(filter, row...)
+			}
+		}
+
+		if len(filter) <= bestScore {
+			continue
+		}
+
+		in := NewIndexInputNode(tableName, idx.Opts.IndexName, &iop, expr.Path(paths[0]), filter, scanner.ASC).(*indexInputNode)
+		in.index = &idx
+		best, bestNodes, bestScore = in, eqNodes, len(filter)
+	}
+
+	return best, bestNodes, bestScore
+}
+
+// findIndexableSelection returns the first selection node whose condition compares
+// the path to a literal or a parameter using the given operator, and the compared expression.
+// Range operators are only matched if the path is the left operand.
+func findIndexableSelection(selections []*selectionNode, p document.Path, tok scanner.Token) (*selectionNode, expr.Expr) {
+	for _, sn := range selections {
+		op, ok := sn.cond.(expr.Operator)
+		if !ok || op.Token() != tok {
+			continue
+		}
+
+		ok, path, e := opCanUseIndex(op)
+		if !ok || !document.Path(path).IsEqual(p) || !isLiteralOrParam(e) {
+			continue
+		}
+
+		if tok != scanner.EQ {
+			if lp, ok := op.LeftHand().(expr.Path); !ok || !document.Path(lp).IsEqual(p) {
+				continue
+			}
+		}
+
+		return sn, e
+	}
+
+	return nil, nil
+}
+
+// findRowRangeSelection returns the first selection node whose condition compares
+// a row value made of the leading paths of the given list, such as (a, b) > (1, 2),
+// to a row value of literals or parameters using a range operator.
+// It returns the operator and the elements of the compared row value.
+func findRowRangeSelection(selections []*selectionNode, paths []document.Path) (scanner.Token, []expr.Expr) {
+	for _, sn := range selections {
+		op, ok := sn.cond.(expr.Operator)
+		if !ok {
+			continue
+		}
+
+		switch op.Token() {
+		case scanner.GT, scanner.GTE, scanner.LT, scanner.LTE:
+		default:
+			continue
+		}
+
+		l, ok := op.LeftHand().(expr.LiteralExprList)
+		if !ok || len(l) > len(paths) {
+			continue
+		}
+
+		matches := true
+		for i, e := range l {
+			if p, ok := e.(expr.Path); !ok || !document.Path(p).IsEqual(paths[i]) {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		if row := rowElements(op.RightHand()); len(row) == len(l) {
+			return op.Token(), row
+		}
+	}
+
+	return 0, nil
+}
+
+// rowElements returns the elements of a row value of literals or parameters.
+// Rows of literals are precalculated as arrays.
+func rowElements(e expr.Expr) []expr.Expr {
+	switch t := e.(type) {
+	case expr.LiteralExprList:
+		for _, e := range t {
+			if !isLiteralOrParam(e) {
+				return nil
+			}
+		}
+		return t
+	case expr.LiteralValue:
+		if t.Type != document.ArrayValue {
+			return nil
+		}
+
+		var row []expr.Expr
+		_ = t.V.(document.Array).Iterate(func(_ int, v document.Value) error {
+			row = append(row, expr.LiteralValue(v))
+			return nil
+		})
+		return row
+	}
+
+	return nil
 }
 
-func selectionNodeValidForIndex(sn *selectionNode, tableName string, indexes map[string]database.Index) *indexInputNode {
+// selectionConditions returns the conditions of the selection nodes
+// that filter the documents of the input node of the tree.
+func selectionConditions(t *Tree) map[string]bool {
+	conds := make(map[string]bool)
+
+	for n := t.Root; n != nil; n = n.Left() {
+		// selection nodes that filter aggregated or unnested documents
+		// don't apply to the documents of the table
+		if n.Operation() == Aggregation || n.Operation() == Unnest {
+			conds = make(map[string]bool)
+		}
+
+		if sn, ok := n.(*selectionNode); ok && sn.cond != nil {
+			conds[fmt.Sprint(sn.cond)] = true
+		}
+	}
+
+	return conds
+}
+
+// indexCoversConditions returns true if the index contains all the documents
+// matching the conditions: either the index isn't partial or its predicate
+// is one of the conditions.
+func indexCoversConditions(idx database.Index, conds map[string]bool) bool {
+	return !idx.Opts.IsPartial() || conds[idx.Opts.Where]
+}
+
+// lookupIndex returns an index of the path or of the expression represented by key
+// that can be used to select the documents matching the conditions.
+// Full indexes are preferred over partial indexes.
+func lookupIndex(indexes map[string]database.Index, key string, conds map[string]bool) (database.Index, bool) {
+	if idx, ok := indexes[key]; ok {
+		return idx, true
+	}
+
+	var found database.Index
+	var ok bool
+	for _, idx := range indexes {
+		if !idx.Opts.IsPartial() || idx.Opts.IsComposite() || idx.Opts.FullText || idx.Opts.PathsString() != key || !indexCoversConditions(idx, conds) {
+			continue
+		}
+
+		// indexes are compared by name to always select the same index
+		if !ok || idx.Opts.IndexName < found.Opts.IndexName {
+			found, ok = idx, true
+		}
+	}
+
+	return found, ok
+}
+
+func selectionNodeValidForIndex(sn *selectionNode, tableName string, indexes map[string]database.Index, conds map[string]bool) *indexInputNode {
 	if sn.cond == nil {
 		return nil
 	}
 
+	// the match() function reads full-text indexes
+	if m, ok := sn.cond.(expr.MatchFunc); ok {
+		return matchNodeValidForIndex(m, tableName, indexes, conds)
+	}
+
 	// the root of the condition must be an operator
 	op, ok := sn.cond.(expr.Operator)
 	if !ok {
@@ -440,29 +753,87 @@ func selectionNodeValidForIndex(sn *selectionNode, tableName string, indexes map
 		return nil
 	}
 
-	// determine if the operator can benefit from an index
+	// determine if the operator can benefit from an index:
+	// we look if an index exists for the compared path
+	// or for the compared expression
 	ok, path, e := opCanUseIndex(op)
+	var idx database.Index
+	if ok {
+		idx, ok = lookupIndex(indexes, path.String(), conds)
+	}
+	if !ok {
+		idx, e, ok = exprIndexOperand(op, indexes, conds)
+	}
 	if !ok {
 		return nil
 	}
 
 	// analyse the other operand to make sure it's a literal or a param
-	if !isLiteralOrParam(e) {
+	if !isLiteralOrParam(e) && !isLiteralOrParamList(op, e) {
 		return nil
 	}
 
-	// now, we look if an index exists for that path
-	idx, ok := indexes[path.String()]
-	if !ok {
+	// the LIKE operator only reads the index if the pattern has a literal prefix
+	if expr.IsLikeOperator(op) && !hasLikePrefix(e) {
 		return nil
 	}
 
-	in := NewIndexInputNode(tableName, idx.Opts.IndexName, iop, path, e, scanner.ASC).(*indexInputNode)
+	in := NewIndexInputNode(tableName, idx.Opts.IndexName, iop, expr.Path(idx.Opts.Path), e, scanner.ASC).(*indexInputNode)
 	in.index = &idx
 
 	return in
 }
 
+// matchNodeValidForIndex returns a node reading a full-text index of the path
+// searched by the match() function, if the query is a literal or a param.
+// Indexes are compared by name to always select the same index, full indexes are preferred over partial indexes.
+func matchNodeValidForIndex(m expr.MatchFunc, tableName string, indexes map[string]database.Index, conds map[string]bool) *indexInputNode {
+	p, ok := m.Expr.(expr.Path)
+	if !ok || !isLiteralOrParam(m.Query) {
+		return nil
+	}
+
+	var found database.Index
+	ok = false
+	for _, idx := range indexes {
+		if !idx.Opts.FullText || !idx.Opts.Path.IsEqual(document.Path(p)) || !indexCoversConditions(idx, conds) {
+			continue
+		}
+
+		if !ok || (found.Opts.IsPartial() && !idx.Opts.IsPartial()) ||
+			(found.Opts.IsPartial() == idx.Opts.IsPartial() && idx.Opts.IndexName < found.Opts.IndexName) {
+			found, ok = idx, true
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	in := NewIndexInputNode(tableName, found.Opts.IndexName, m, p, m.Query, scanner.ASC).(*indexInputNode)
+	in.index = &found
+
+	return in
+}
+
+// exprIndexOperand returns the expression index of one of the operands of op,
+// and the other operand.
+// Like paths, expressions compared with the IN and LIKE operators must be the left operand.
+func exprIndexOperand(op expr.Operator, indexes map[string]database.Index, conds map[string]bool) (database.Index, expr.Expr, bool) {
+	if idx, ok := lookupIndex(indexes, fmt.Sprint(expr.Unparenthesize(op.LeftHand())), conds); ok && idx.Opts.IsExpression() {
+		return idx, op.RightHand(), true
+	}
+
+	if expr.IsInOperator(op) || expr.IsLikeOperator(op) {
+		return database.Index{}, nil, false
+	}
+
+	if idx, ok := lookupIndex(indexes, fmt.Sprint(expr.Unparenthesize(op.RightHand())), conds); ok && idx.Opts.IsExpression() {
+		return idx, op.LeftHand(), true
+	}
+
+	return database.Index{}, nil, false
+}
+
 func opCanUseIndex(op expr.Operator) (bool, expr.Path, expr.Expr) {
 	lf, leftIsField := op.LeftHand().(expr.Path)
 	rf, rightIsField := op.RightHand().(expr.Path)
@@ -473,10 +844,10 @@ func opCanUseIndex(op expr.Operator) (bool, expr.Path, expr.Expr) {
 	}
 
 	// expr OP path
-	// Special case for IN operator: only left operand is valid for index usage
+	// Special case for IN and LIKE operators: only left operand is valid for index usage
 	// valid:   a IN [1, 2, 3]
 	// invalid: 1 IN a
-	if rightIsField && !leftIsField && !expr.IsInOperator(op) {
+	if rightIsField && !leftIsField && !expr.IsInOperator(op) && !expr.IsLikeOperator(op) {
 		return true, rf, op.LeftHand()
 	}
 
@@ -485,9 +856,515 @@ func opCanUseIndex(op expr.Operator) (bool, expr.Path, expr.Expr) {
 
 func isLiteralOrParam(e expr.Expr) (ok bool) {
 	switch e.(type) {
-	case expr.LiteralValue, expr.NamedParam, expr.PositionalParam:
+	// values of outer documents are passed to correlated subqueries as parameters
+	case expr.LiteralValue, expr.NamedParam, expr.PositionalParam, expr.OuterPath:
 		return true
 	}
 
 	return false
 }
+
+// hasLikePrefix returns true if e is a text pattern that starts with
+// a literal prefix, such as 'abc%'.
+func hasLikePrefix(e expr.Expr) bool {
+	l, ok := e.(expr.LiteralValue)
+	if !ok || l.Type != document.TextValue {
+		return false
+	}
+
+	min, _ := glob.LikePrefixBounds(l.V.(string))
+	return min != ""
+}
+
+// isLiteralOrParamList returns true if e is the list of an IN operator, or the bounds
+// of a BETWEEN operator, that could not be precalculated because it contains parameters.
+func isLiteralOrParamList(op expr.Operator, e expr.Expr) bool {
+	l, ok := e.(expr.LiteralExprList)
+	if !ok || (!expr.IsInOperator(op) && op.Token() != scanner.BETWEEN) {
+		return false
+	}
+
+	for _, e := range l {
+		if !isLiteralOrParam(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// UseIndexBasedOnSortNodeRule removes the sort node of the tree if the documents
+// are sorted by the expression of an expression index, and reads them from the index instead.
+// Example:
+//
+//	this:
+//	  Table(foo) -> σ(cond: b > 1) -> ∏(a) -> Sort(a.x + a.y DESC)
+//	becomes this:
+//	  Index(idx_foo_sum) -> σ(cond: b > 1) -> ∏(a)
+func UseIndexBasedOnSortNodeRule(t *Tree) (*Tree, error) {
+	var prev Node
+	n := t.Root
+	for n != nil && n.Operation() != Sort {
+		prev = n
+		n = n.Left()
+	}
+
+	sn, ok := n.(*sortNode)
+	if !ok || len(sn.sortFields) != 1 || sn.withSortKeys {
+		return t, nil
+	}
+	f := sn.sortFields[0]
+
+	// only selection and projection nodes can be between the sort node and the table,
+	// the sort expression is evaluated on the projected documents.
+	n = sn.Left()
+	for n != nil && (n.Operation() == Selection || n.Operation() == Projection) {
+		if pn, ok := n.(*ProjectionNode); ok && isShadowedByProjection(pn, f.Expr) {
+			return t, nil
+		}
+
+		n = n.Left()
+	}
+
+	inpn, ok := n.(*tableInputNode)
+	if !ok {
+		return t, nil
+	}
+
+	idx, ok := lookupIndex(inpn.indexes, fmt.Sprint(expr.Unparenthesize(f.Expr)), selectionConditions(t))
+	if !ok || !idx.Opts.IsExpression() {
+		return t, nil
+	}
+
+	in := NewIndexInputNode(inpn.tableName, idx.Opts.IndexName, nil, expr.Path(idx.Opts.Path), nil, f.Direction).(*indexInputNode)
+	in.index = &idx
+	if err := in.Bind(inpn.tx, inpn.params); err != nil {
+		return nil, err
+	}
+
+	// we remove the sort node from the tree
+	if prev == nil {
+		t.Root = sn.Left()
+	} else {
+		prev.SetLeft(sn.Left())
+	}
+
+	return replaceInputNode(t, in), nil
+}
+
+// isShadowedByProjection returns true if the projection may replace the value
+// of a field referenced by e, when e is evaluated on the projected documents.
+func isShadowedByProjection(pn *ProjectionNode, e expr.Expr) bool {
+	var fields []string
+	known := collectFields(&fields, e)
+
+	for _, pf := range pn.Expressions {
+		switch t := pf.(type) {
+		case Wildcard:
+			continue
+		case ProjectedExpr:
+			// the field is projected as is
+			if p, ok := t.Expr.(expr.Path); ok && len(p) == 1 && p[0].FieldName == t.ExprName {
+				continue
+			}
+
+			if known && !containsField(fields, t.ExprName) {
+				continue
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UseTopKSortRule limits the number of documents kept in memory by a sort node
+// when it is followed by a limit node. The sort node only keeps the first
+// LIMIT + OFFSET documents during the scan instead of sorting the entire stream.
+// Example:
+//
+//	this:
+//	  Sort(a ASC) -> Offset(10) -> Limit(20)
+//	becomes this:
+//	  Sort(a ASC, top 30) -> Offset(10) -> Limit(20)
+func UseTopKSortRule(t *Tree) (*Tree, error) {
+	n := t.Root
+	var k int
+
+	for n != nil {
+		switch nn := n.(type) {
+		case *limitNode:
+			k = nn.limit
+		case *offsetNode:
+			// an offset that is not followed by a limit
+			// requires the entire stream
+			if k == 0 {
+				return t, nil
+			}
+			k += nn.offset
+		case *windowNode:
+			// window functions don't change the number of documents
+		case *sortNode:
+			// the limit can change between executions of the same query
+			nn.k = k
+			return t, nil
+		default:
+			return t, nil
+		}
+
+		n = n.Left()
+	}
+
+	return t, nil
+}
+
+// UseSortedAggregationRule aggregates groups one at a time when the input node
+// returns the documents sorted by the GROUP BY expression, instead of keeping
+// every group in memory until the end of the stream.
+// This is the case when the documents are read from a typed index on the grouped path,
+// or from a table whose typed primary key is the grouped path.
+// Example:
+//
+//	this:
+//	  Index(idx_a) -> Group(a) -> Aggregate(COUNT(*))
+//	becomes this:
+//	  Index(idx_a) -> Group(a) -> Aggregate(COUNT(*), sorted)
+func UseSortedAggregationRule(t *Tree) (*Tree, error) {
+	var an *AggregationNode
+
+	n := t.Root
+	for n != nil && an == nil {
+		an, _ = n.(*AggregationNode)
+		n = n.Left()
+	}
+
+	gn, ok := n.(*GroupingNode)
+	if !ok {
+		return t, nil
+	}
+
+	p, ok := gn.Expr.(expr.Path)
+	if !ok {
+		return t, nil
+	}
+
+	n = gn.Left()
+	for n != nil && n.Operation() == Selection {
+		n = n.Left()
+	}
+
+	sorted, err := isSortedByPath(n, document.Path(p))
+	if err != nil {
+		return nil, err
+	}
+	an.sorted = sorted
+
+	return t, nil
+}
+
+// SortGroupsRule sorts the documents by the GROUP BY expression before grouping them
+// when the aggregation is not already sorted and the sort buffer of the database is limited.
+// Groups are then aggregated one at a time, and the documents that don't fit
+// in the sort buffer are spilled to a temporary store instead of keeping
+// the aggregators of every group in memory.
+// Example:
+//
+//	this:
+//	  Table(foo) -> Group(a) -> Aggregate(COUNT(*))
+//	becomes this:
+//	  Table(foo) -> Sort(a ASC) -> Group(a) -> Aggregate(COUNT(*), sorted)
+func SortGroupsRule(t *Tree) (*Tree, error) {
+	var an *AggregationNode
+
+	n := t.Root
+	for n != nil && an == nil {
+		an, _ = n.(*AggregationNode)
+		n = n.Left()
+	}
+
+	gn, ok := n.(*GroupingNode)
+	if !ok || an.sorted || gn.Tx == nil {
+		return t, nil
+	}
+
+	if gn.Tx.DB().SortBufferSize <= 0 || !gn.Tx.CanCreateTemporaryStore() {
+		return t, nil
+	}
+
+	sn := NewSortNode(gn.Left(), SortField{Expr: gn.Expr})
+	err := sn.(*sortNode).Bind(gn.Tx, gn.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	gn.left = sn
+	an.sorted = true
+
+	return t, nil
+}
+
+// isSortedByPath returns true if the input node returns documents
+// whose values at the given path are adjacent when equal.
+// Only typed values are considered, as numbers of different types are equal
+// but not encoded next to each other.
+func isSortedByPath(n Node, p document.Path) (bool, error) {
+	switch in := n.(type) {
+	case *indexInputNode:
+		// the IN operator iterates over the values of its array in order,
+		// which may contain duplicates.
+		if e, ok := in.iop.(expr.Expr); ok && expr.IsInOperator(e) {
+			return false, nil
+		}
+
+		return !in.stableOrder && in.index != nil && in.index.Opts.Type != 0 && in.path.IsEqual(p), nil
+	case *tableInputNode:
+		if in.table == nil {
+			return false, nil
+		}
+
+		info, err := in.table.Info()
+		if err != nil {
+			return false, err
+		}
+
+		pk := info.GetPrimaryKey()
+		return pk != nil && pk.Type != 0 && pk.Path.IsEqual(p), nil
+	}
+
+	return false, nil
+}
+
+// UseKeysOnlyInputRule prevents input nodes from reading documents
+// when the query only selects the primary key of the documents
+// and only filters them using their primary key, which can be decoded from their keys
+// if it is a top-level field.
+// The keys are read from the table or the index and documents are only read from the table
+// if the primary key must be extracted from them.
+// Example:
+//
+//	this:
+//	  Index(idx_a) -> ∏(pk())
+//	becomes this:
+//	  Index(idx_a, keys only) -> ∏(pk())
+//
+//	this:
+//	  Table(foo) -> σ(cond: id > 10) -> ∏(pk())
+//	becomes this:
+//	  Table(foo, keys only) -> σ(cond: id > 10) -> ∏(pk())
+func UseKeysOnlyInputRule(t *Tree) (*Tree, error) {
+	var fields []string
+	var projected bool
+
+	n := t.Root
+	for n != nil {
+		switch nn := n.(type) {
+		case *limitNode, *offsetNode:
+		case *ProjectionNode:
+			if !isPKProjection(nn) {
+				return t, nil
+			}
+			projected = true
+		case *selectionNode:
+			if !projected || !collectFields(&fields, nn.cond) {
+				return t, nil
+			}
+		case *tableInputNode:
+			if projected && nn.table != nil && onlyReadsPK(nn.table, fields) {
+				nn.setKeysOnly()
+			}
+			return t, nil
+		case *indexInputNode:
+			if projected && nn.table != nil && onlyReadsPK(nn.table, fields) {
+				nn.setKeysOnly()
+			}
+			return t, nil
+		default:
+			return t, nil
+		}
+
+		n = n.Left()
+	}
+
+	return t, nil
+}
+
+// onlyReadsPK returns true if the given top-level fields are
+// either empty or only contain the primary key of the table.
+func onlyReadsPK(tb *database.Table, fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return false
+	}
+
+	pk := info.GetPrimaryKey()
+	return len(fields) == 1 && pk != nil && len(pk.Path) == 1 && pk.Path[0].FieldName == fields[0]
+}
+
+// isPKProjection returns true if pk() is the only projected expression.
+func isPKProjection(pn *ProjectionNode) bool {
+	if len(pn.Expressions) != 1 {
+		return false
+	}
+
+	pe, ok := pn.Expressions[0].(ProjectedExpr)
+	if !ok {
+		return false
+	}
+
+	switch pe.Expr.(type) {
+	case expr.PKFunc, *expr.PKFunc:
+		return true
+	}
+
+	return false
+}
+
+// PushProjectedFieldsToInputRule configures the input node to only decode the top-level fields
+// of the documents that are used by the rest of the tree, if they can all be determined.
+// The other fields are skipped without being decoded, which reduces the cost of
+// selecting a few fields of large documents.
+// Example:
+//
+//	this:
+//	  Table(foo) -> σ(cond: c > 10) -> ∏(a + 1)
+//	becomes this:
+//	  Table(foo, fields: a, c) -> σ(cond: c > 10) -> ∏(a + 1)
+func PushProjectedFieldsToInputRule(t *Tree) (*Tree, error) {
+	fields := []string{}
+	var projected bool
+
+	n := t.Root
+	for n != nil {
+		switch nn := n.(type) {
+		case *limitNode, *offsetNode:
+		case *dedupNode:
+			// DISTINCT ON expressions are evaluated against
+			// the original document
+			for _, e := range nn.exprs {
+				if !collectFields(&fields, e) {
+					return t, nil
+				}
+			}
+		case *sortNode:
+			// the sort node can read fields that are not projected
+			// from the original document
+			for _, f := range nn.sortFields {
+				if !collectFields(&fields, f.Expr) {
+					return t, nil
+				}
+			}
+		case *ProjectionNode:
+			for _, pf := range nn.Expressions {
+				switch p := pf.(type) {
+				case ProjectedExpr:
+					if !collectFields(&fields, p.Expr) {
+						return t, nil
+					}
+				case RenameField:
+					fields = appendField(fields, p.Field)
+				default:
+					return t, nil
+				}
+			}
+			projected = true
+		case *selectionNode:
+			if !projected || !collectFields(&fields, nn.cond) {
+				return t, nil
+			}
+		case *tableInputNode:
+			if projected && !nn.keysOnly {
+				nn.fields = fields
+			}
+			return t, nil
+		case *indexInputNode:
+			if projected && !nn.keysOnly {
+				nn.setFields(fields)
+			}
+			return t, nil
+		default:
+			return t, nil
+		}
+
+		n = n.Left()
+	}
+
+	return t, nil
+}
+
+// collectFields adds the top-level fields read by e to fields.
+// It returns false if e may read fields that can't be determined,
+// e.g. if it uses a function that reads the entire document.
+func collectFields(fields *[]string, e expr.Expr) bool {
+	switch t := e.(type) {
+	case nil, expr.LiteralValue, expr.NamedParam, expr.PositionalParam, expr.PKFunc, *expr.PKFunc:
+		return true
+	case expr.Path:
+		return addPathField(fields, t)
+	case expr.Parentheses:
+		return collectFields(fields, t.E)
+	case expr.CastFunc:
+		return collectFields(fields, t.Expr)
+	case expr.MatchFunc:
+		return collectFields(fields, t.Expr) && collectFields(fields, t.Query)
+	case expr.LiteralExprList:
+		for _, e := range t {
+			if !collectFields(fields, e) {
+				return false
+			}
+		}
+		return true
+	case expr.KVPairs:
+		for _, kv := range t {
+			if !collectFields(fields, kv.V) {
+				return false
+			}
+		}
+		return true
+	case expr.Operator:
+		return collectFields(fields, t.LeftHand()) && collectFields(fields, t.RightHand())
+	case expr.OuterPath:
+		return true
+	case *expr.Subquery:
+		// correlated subqueries receive the entire document
+		return !t.Correlated
+	case interface{ Subquery() *expr.Subquery }:
+		return !t.Subquery().Correlated
+	}
+
+	return false
+}
+
+// addPathField adds the first field of p to fields.
+func addPathField(fields *[]string, p expr.Path) bool {
+	if len(p) == 0 || p[0].FieldName == "" {
+		return false
+	}
+
+	*fields = appendField(*fields, p[0].FieldName)
+	return true
+}
+
+func appendField(fields []string, field string) []string {
+	for _, f := range fields {
+		if f == field {
+			return fields
+		}
+	}
+
+	return append(fields, field)
+}
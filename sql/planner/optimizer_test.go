@@ -476,6 +476,135 @@ func TestUseIndexBasedOnSelectionNodeRule(t *testing.T) {
 				scanner.ASC,
 			),
 		},
+		{
+			"FROM foo WHERE a BETWEEN $p1 AND $p2",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.Between(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.NamedParam("p1"),
+					expr.NamedParam("p2"),
+				),
+			),
+			planner.NewIndexInputNode(
+				"foo",
+				"idx_foo_a",
+				expr.Between(nil, nil, nil).(planner.IndexIteratorOperator),
+				expr.Path(parsePath(t, "a")),
+				expr.LiteralExprList{expr.NamedParam("p1"), expr.NamedParam("p2")},
+				scanner.ASC,
+			),
+		},
+		{
+			"FROM foo WHERE a NOT BETWEEN 1 AND 2",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.NotBetween(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.IntegerValue(1),
+					expr.IntegerValue(2),
+				),
+			),
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.NotBetween(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.IntegerValue(1),
+					expr.IntegerValue(2),
+				),
+			),
+		},
+		{
+			"FROM foo WHERE a LIKE 'ab%'",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.Like(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.TextValue("ab%"),
+				),
+			),
+			planner.NewIndexInputNode(
+				"foo",
+				"idx_foo_a",
+				expr.Like(nil, nil).(planner.IndexIteratorOperator),
+				expr.Path(parsePath(t, "a")),
+				expr.TextValue("ab%"),
+				scanner.ASC,
+			),
+		},
+		{
+			"FROM foo WHERE a LIKE '%b'",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.Like(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.TextValue("%b"),
+				),
+			),
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.Like(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.TextValue("%b"),
+				),
+			),
+		},
+		{
+			"FROM foo WHERE a NOT LIKE 'ab%'",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.NotLike(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.TextValue("ab%"),
+				),
+			),
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.NotLike(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.TextValue("ab%"),
+				),
+			),
+		},
+		{
+			"FROM foo WHERE 'ab' LIKE a",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.Like(
+					expr.TextValue("ab"),
+					expr.Path{document.PathFragment{FieldName: "a"}},
+				),
+			),
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.Like(
+					expr.TextValue("ab"),
+					expr.Path{document.PathFragment{FieldName: "a"}},
+				),
+			),
+		},
+		{
+			"FROM foo WHERE a IN [$p1, 3]",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.In(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.LiteralExprList{expr.NamedParam("p1"), expr.IntegerValue(3)},
+				),
+			),
+			planner.NewIndexInputNode(
+				"foo",
+				"idx_foo_a",
+				expr.In(nil, nil).(planner.IndexIteratorOperator),
+				expr.Path(parsePath(t, "a")),
+				expr.LiteralExprList{expr.NamedParam("p1"), expr.IntegerValue(3)},
+				scanner.ASC,
+			),
+		},
+		{
+			"FROM foo WHERE a NOT IN $p1",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.NotIn(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.NamedParam("p1"),
+				),
+			),
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
+				expr.NotIn(
+					expr.Path{document.PathFragment{FieldName: "a"}},
+					expr.NamedParam("p1"),
+				),
+			),
+		},
 		{
 			"FROM foo WHERE 1 IN a",
 			planner.NewSelectionNode(planner.NewTableInputNode("foo"),
@@ -531,3 +660,119 @@ func TestUseIndexBasedOnSelectionNodeRule(t *testing.T) {
 		})
 	}
 }
+
+func TestUseTopKSortRule(t *testing.T) {
+	sortNode := func() planner.Node {
+		return planner.NewSortNode(planner.NewTableInputNode("foo"), planner.SortField{Expr: expr.Path(parsePath(t, "a")), Direction: scanner.DESC})
+	}
+
+	tests := []struct {
+		name     string
+		root     planner.Node
+		expected string
+	}{
+		{
+			"no limit",
+			sortNode(),
+			"Table(foo) -> Sort(a DESC)",
+		},
+		{
+			"limit",
+			planner.NewLimitNode(sortNode(), 10),
+			"Table(foo) -> Sort(a DESC, top 10) -> Limit(10)",
+		},
+		{
+			"limit and offset",
+			planner.NewLimitNode(planner.NewOffsetNode(sortNode(), 5), 10),
+			"Table(foo) -> Sort(a DESC, top 15) -> Offset(5) -> Limit(10)",
+		},
+		{
+			"offset only",
+			planner.NewOffsetNode(sortNode(), 5),
+			"Table(foo) -> Sort(a DESC) -> Offset(5)",
+		},
+		{
+			"limit without sort",
+			planner.NewLimitNode(planner.NewTableInputNode("foo"), 10),
+			"Table(foo) -> Limit(10)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := planner.UseTopKSortRule(planner.NewTree(test.root))
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res.String())
+		})
+	}
+}
+
+func TestPushProjectedFieldsToInputRule(t *testing.T) {
+	parseExpr := func(s string) expr.Expr {
+		e, err := parser.ParseExpr(s)
+		require.NoError(t, err)
+		return e
+	}
+
+	projected := func(exprs ...string) []planner.ProjectedField {
+		var pf []planner.ProjectedField
+		for _, e := range exprs {
+			if e == "*" {
+				pf = append(pf, planner.Wildcard{})
+				continue
+			}
+			pf = append(pf, planner.ProjectedExpr{Expr: parseExpr(e), ExprName: e})
+		}
+		return pf
+	}
+
+	tests := []struct {
+		name     string
+		root     planner.Node
+		expected string
+	}{
+		{
+			"paths",
+			planner.NewProjectionNode(planner.NewTableInputNode("foo"), projected("a.b", "c[0] + 1", "pk()"), "foo"),
+			"Table(foo, fields: a, c) -> ∏(a.b, c[0] + 1, pk())",
+		},
+		{
+			"selection and sort",
+			planner.NewSortNode(
+				planner.NewProjectionNode(
+					planner.NewSelectionNode(planner.NewTableInputNode("foo"), parseExpr("d IN [1, e]")),
+					append(projected("a"), planner.RenameField{Field: "b", Alias: "x"}), "foo"),
+				planner.SortField{Expr: expr.Path(parsePath(t, "c")), Direction: scanner.ASC},
+				planner.SortField{Expr: expr.Path(parsePath(t, "f")), Direction: scanner.DESC}),
+			"Table(foo, fields: c, f, a, b, d, e) -> σ(cond: d IN [1, e]) -> ∏(a, b AS x) -> Sort(c ASC, f DESC)",
+		},
+		{
+			"no fields",
+			planner.NewProjectionNode(planner.NewTableInputNode("foo"), projected("1", "CAST(2 AS TEXT)"), "foo"),
+			"Table(foo, no fields) -> ∏(1, CAST(2 AS text))",
+		},
+		{
+			"wildcard",
+			planner.NewProjectionNode(planner.NewTableInputNode("foo"), projected("a", "*"), "foo"),
+			"Table(foo) -> ∏(a, *)",
+		},
+		{
+			"unknown function",
+			planner.NewProjectionNode(planner.NewTableInputNode("foo"), projected("a", "has(b)"), "foo"),
+			"Table(foo) -> ∏(a, has(b))",
+		},
+		{
+			"no projection",
+			planner.NewSelectionNode(planner.NewTableInputNode("foo"), parseExpr("a > 1")),
+			"Table(foo) -> σ(cond: a > 1)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := planner.PushProjectedFieldsToInputRule(planner.NewTree(test.root))
+			require.NoError(t, err)
+			require.Equal(t, test.expected, res.String())
+		})
+	}
+}
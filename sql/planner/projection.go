@@ -19,8 +19,10 @@ type ProjectionNode struct {
 	Expressions []ProjectedField
 	tableName   string
 
-	info *database.TableInfo
-	tx   *database.Transaction
+	info   *database.TableInfo
+	tx     *database.Transaction
+	params []expr.Param
+	strict bool
 }
 
 var _ operationNode = (*ProjectionNode)(nil)
@@ -40,6 +42,13 @@ func NewProjectionNode(n Node, expressions []ProjectedField, tableName string) N
 // Bind database resources to this node.
 func (n *ProjectionNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
 	n.tx = tx
+	n.params = params
+	n.strict = tx.DB().StrictProjections
+	for _, pf := range n.Expressions {
+		if pe, ok := pf.(ProjectedExpr); ok {
+			resetSubqueries(pe.Expr)
+		}
+	}
 	if n.tableName == "" {
 		return
 	}
@@ -57,6 +66,8 @@ func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 	if st.IsEmpty() {
 		d := documentMask{
 			resultFields: n.Expressions,
+			tx:           n.tx,
+			params:       n.params,
 		}
 		var fb document.FieldBuffer
 		err := fb.ScanDocument(d)
@@ -71,6 +82,9 @@ func (n *ProjectionNode) toStream(st document.Stream) (document.Stream, error) {
 			dm.info = n.info
 			dm.d = d
 			dm.resultFields = n.Expressions
+			dm.strict = n.strict
+			dm.tx = n.tx
+			dm.params = n.params
 
 			return &dm, nil
 		})
@@ -92,26 +106,67 @@ func (n *ProjectionNode) String() string {
 	return fmt.Sprintf("∏(%s)", b.String())
 }
 
+// A Row is a document returned by a projection whose values can be read by position,
+// in the order of the projected fields, instead of being looked up by name.
+type Row interface {
+	document.Document
+
+	// Values appends the value of every projected field to dst, in order,
+	// and returns the extended slice. Wildcards are returned as a single
+	// document value containing the entire document.
+	Values(dst []document.Value) ([]document.Value, error)
+}
+
 type documentMask struct {
-	info         *database.TableInfo
+	info *database.TableInfo
+	tx   *database.Transaction
+	// parameters of the statement, used by the subqueries of the projected fields.
+	params       []expr.Param
 	d            document.Document
 	resultFields []ProjectedField
+	// if true, selecting a path that doesn't exist
+	// in d returns an error.
+	strict bool
 }
 
-var _ document.Document = documentMask{}
+var _ Row = documentMask{}
+
+// isPassthroughField returns true if the projected field returns
+// the field of the underlying document with the same name.
+func isPassthroughField(rf ProjectedField) bool {
+	switch t := rf.(type) {
+	case Wildcard:
+		return true
+	case ProjectedExpr:
+		p, ok := t.Expr.(expr.Path)
+		return ok && len(p) == 1 && p[0].FieldName == t.ExprName
+	}
+
+	return false
+}
 
 func (d documentMask) GetByField(field string) (v document.Value, err error) {
 	for _, rf := range d.resultFields {
 		if rf.Name() == field || rf.Name() == "*" {
-			v, err = d.d.GetByField(field)
-			if err != document.ErrFieldNotFound {
-				return
+			// only wildcards and paths return the field of the underlying document,
+			// other expressions may be named after one of its fields
+			if isPassthroughField(rf) {
+				v, err = d.d.GetByField(field)
+				if err != document.ErrFieldNotFound {
+					return
+				}
 			}
 
-			var env expr.Environment
+			env := expr.Environment{Tx: d.tx, Params: d.params}
 			if d.d != nil {
 				env.SetCurrentValue(document.NewDocumentValue(d.d))
 			}
+			if d.strict {
+				err = checkProjectedPath(&env, rf)
+				if err != nil {
+					return
+				}
+			}
 			var found bool
 			err = rf.Iterate(&env, func(f string, value document.Value) error {
 				if f == field {
@@ -132,12 +187,19 @@ func (d documentMask) GetByField(field string) (v document.Value, err error) {
 }
 
 func (d documentMask) Iterate(fn func(field string, value document.Value) error) error {
-	var env expr.Environment
+	env := expr.Environment{Tx: d.tx, Params: d.params}
 	if d.d != nil {
 		env.SetCurrentValue(document.NewDocumentValue(d.d))
 	}
 
 	for _, rf := range d.resultFields {
+		if d.strict {
+			err := checkProjectedPath(&env, rf)
+			if err != nil {
+				return err
+			}
+		}
+
 		err := rf.Iterate(&env, fn)
 		if err != nil {
 			return err
@@ -147,6 +209,116 @@ func (d documentMask) Iterate(fn func(field string, value document.Value) error)
 	return nil
 }
 
+// Values evaluates every projected field once and appends the results to dst.
+// Fields of the document that are selected without being part of an expression
+// are read in a single pass over the document, instead of being looked up one by one.
+// It implements the Row interface.
+func (d documentMask) Values(dst []document.Value) ([]document.Value, error) {
+	start := len(dst)
+	for range d.resultFields {
+		dst = append(dst, document.Value{})
+	}
+	row := dst[start:]
+
+	env := expr.Environment{Tx: d.tx, Params: d.params}
+	if d.d != nil {
+		env.SetCurrentValue(document.NewDocumentValue(d.d))
+	}
+
+	var pending bool
+	for i, rf := range d.resultFields {
+		if _, ok := rf.(Wildcard); ok {
+			if d.d == nil {
+				return nil, errors.New("no table specified")
+			}
+
+			row[i] = document.NewDocumentValue(d.d)
+			continue
+		}
+
+		// in strict mode, missing fields must be reported
+		if _, ok := selectedField(rf); ok && d.d != nil && !d.strict {
+			row[i] = document.NewNullValue()
+			pending = true
+			continue
+		}
+
+		if d.strict {
+			err := checkProjectedPath(&env, rf)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err := rf.Iterate(&env, func(field string, value document.Value) error {
+			row[i] = value
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !pending {
+		return dst, nil
+	}
+
+	err := d.d.Iterate(func(field string, value document.Value) error {
+		for i, rf := range d.resultFields {
+			if name, ok := selectedField(rf); ok && name == field {
+				row[i] = value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// selectedField returns the name of the field of the document
+// selected by rf, if rf selects a top-level field as is.
+func selectedField(rf ProjectedField) (string, bool) {
+	switch t := rf.(type) {
+	case RenameField:
+		return t.Field, true
+	case ProjectedExpr:
+		if p, ok := t.Expr.(expr.Path); ok && len(p) == 1 && p[0].FieldName != "" {
+			return p[0].FieldName, true
+		}
+	}
+
+	return "", false
+}
+
+// checkProjectedPath returns an error if rf selects a path
+// that doesn't exist in the current document.
+func checkProjectedPath(env *expr.Environment, rf ProjectedField) error {
+	pe, ok := rf.(ProjectedExpr)
+	if !ok {
+		return nil
+	}
+
+	p, ok := pe.Expr.(expr.Path)
+	if !ok {
+		return nil
+	}
+
+	v, ok := env.GetCurrentValue()
+	if !ok {
+		return nil
+	}
+
+	_, err := document.Path(p).GetValue(v)
+	if err == document.ErrFieldNotFound || err == document.ErrValueNotFound {
+		return fmt.Errorf("%s: %w", p, document.ErrFieldNotFound)
+	}
+
+	return err
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 func (d documentMask) MarshalJSON() ([]byte, error) {
 	return document.MarshalJSON(d)
@@ -205,3 +377,39 @@ func (w Wildcard) Iterate(env *expr.Environment, fn func(field string, value doc
 
 	return v.V.(document.Document).Iterate(fn)
 }
+
+// A RenameField is a ProjectedField that outputs a field of the document
+// under a different name, without evaluating an expression.
+type RenameField struct {
+	// Field of the document to select.
+	Field string
+	// Alias under which the field is returned.
+	Alias string
+}
+
+// Name returns the alias.
+func (r RenameField) Name() string {
+	return r.Alias
+}
+
+func (r RenameField) String() string {
+	return fmt.Sprintf("%s AS %s", r.Field, r.Alias)
+}
+
+// Iterate calls fn once with the value of the field, or NULL if the field doesn't exist.
+func (r RenameField) Iterate(env *expr.Environment, fn func(field string, value document.Value) error) error {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return errors.New("no table specified")
+	}
+
+	fv, err := v.V.(document.Document).GetByField(r.Field)
+	if err == document.ErrFieldNotFound {
+		return fn(r.Alias, document.NewNullValue())
+	}
+	if err != nil {
+		return err
+	}
+
+	return fn(r.Alias, fv)
+}
@@ -0,0 +1,102 @@
+package planner_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/planner"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameField(t *testing.T) {
+	d := document.NewFieldBuffer().
+		Add("a", document.NewIntegerValue(1)).
+		Add("b", document.NewTextValue("foo"))
+
+	iterate := func(rf planner.RenameField, env *expr.Environment) (*document.FieldBuffer, error) {
+		var fb document.FieldBuffer
+		err := rf.Iterate(env, func(field string, value document.Value) error {
+			fb.Add(field, value)
+			return nil
+		})
+		return &fb, err
+	}
+
+	env := expr.NewEnvironment(document.NewDocumentValue(d))
+
+	fb, err := iterate(planner.RenameField{Field: "a", Alias: "c"}, env)
+	require.NoError(t, err)
+	require.Equal(t, document.NewFieldBuffer().Add("c", document.NewIntegerValue(1)), fb)
+
+	fb, err = iterate(planner.RenameField{Field: "d", Alias: "c"}, env)
+	require.NoError(t, err)
+	require.Equal(t, document.NewFieldBuffer().Add("c", document.NewNullValue()), fb)
+
+	_, err = iterate(planner.RenameField{Field: "a", Alias: "c"}, expr.NewEnvironment(document.NewIntegerValue(1)))
+	require.Error(t, err)
+
+	t.Run("in a tree", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test; INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar')")
+		require.NoError(t, err)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		tree := planner.NewTree(planner.NewProjectionNode(planner.NewTableInputNode("test"), []planner.ProjectedField{
+			planner.RenameField{Field: "b", Alias: "name"},
+			planner.RenameField{Field: "c", Alias: "other"},
+		}, "test"))
+
+		res, err := tree.Run(tx.Transaction, nil)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, &res)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"name": "foo", "other": null}, {"name": "bar", "other": null}]`, buf.String())
+	})
+}
+
+func TestRowValues(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`CREATE TABLE test; INSERT INTO test (a, b) VALUES (1, 'foo')`)
+	require.NoError(t, err)
+
+	st, err := db.Query("SELECT b, *, a + 1, a AS c, d FROM test")
+	require.NoError(t, err)
+	defer st.Close()
+
+	var called bool
+	err = st.Iterate(func(d document.Document) error {
+		called = true
+
+		r, ok := d.(planner.Row)
+		require.True(t, ok)
+
+		values, err := r.Values(nil)
+		require.NoError(t, err)
+		require.Len(t, values, 5)
+		require.Equal(t, document.NewTextValue("foo"), values[0])
+		require.Equal(t, document.DocumentValue, values[1].Type)
+		data, err := document.MarshalJSON(values[1].V.(document.Document))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a": 1, "b": "foo"}`, string(data))
+		require.Equal(t, document.NewDoubleValue(2), values[2])
+		require.Equal(t, document.NewDoubleValue(1), values[3])
+		require.Equal(t, document.NewNullValue(), values[4])
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, called)
+}
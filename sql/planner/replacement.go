@@ -20,6 +20,11 @@ type replacementNode struct {
 	tableName string
 	table     *database.Table
 	codec     encoding.Codec
+
+	// number of documents replaced by the last call to toStream.
+	replaced int64
+	// if true, toStream returns the replaced documents, as they are stored.
+	returning bool
 }
 
 var _ operationNode = (*replacementNode)(nil)
@@ -58,7 +63,9 @@ func (n *replacementNode) toStream(st document.Stream) (document.Stream, error)
 
 	keys := make([][]byte, replaceBufferSize)
 	docs := make([]document.FieldBuffer, replaceBufferSize)
+	n.replaced = 0
 
+	var replaced []document.Document
 	var err error
 	for {
 		var i int
@@ -81,12 +88,26 @@ func (n *replacementNode) toStream(st document.Stream) (document.Stream, error)
 
 			return nil
 		})
+		if err != nil {
+			return document.Stream{}, err
+		}
 
 		for j := 0; j < i; j++ {
 			err = n.table.Replace(keys[j], docs[j])
 			if err != nil {
 				return document.Stream{}, err
 			}
+			n.replaced++
+
+			if n.returning {
+				// the key buffer is reused by the next batch
+				d, err := n.table.GetDocument(append([]byte{}, keys[j]...))
+				if err != nil {
+					return document.Stream{}, err
+				}
+
+				replaced = append(replaced, d)
+			}
 		}
 
 		if i < replaceBufferSize {
@@ -96,9 +117,17 @@ func (n *replacementNode) toStream(st document.Stream) (document.Stream, error)
 		rit.curKey = keys[i-1]
 	}
 
+	if n.returning {
+		return document.NewStream(document.NewIterator(replaced...)), nil
+	}
+
 	return document.Stream{}, err
 }
 
+func (n *replacementNode) rowsAffected() int64 {
+	return n.replaced
+}
+
 func (n *replacementNode) String() string {
 	return fmt.Sprintf("Replace(%s)", n.tableName)
 }
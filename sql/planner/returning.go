@@ -0,0 +1,70 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// ReturningStmt is a query.Statement that runs an INSERT, UPDATE or DELETE statement
+// and returns the documents it wrote, using the expressions of the RETURNING clause.
+// Written documents are kept in memory until the statement completes, then projected
+// as the result is read.
+type ReturningStmt struct {
+	Stmt       query.Statement
+	Projection *ProjectionNode
+}
+
+// NewReturningStmt configures stmt to return the documents it writes and projects them
+// using the given fields.
+func NewReturningStmt(stmt query.Statement, fields []ProjectedField, tableName string) (*ReturningStmt, error) {
+	switch t := stmt.(type) {
+	case query.InsertStmt:
+		t.Returning = true
+		stmt = t
+	case *Tree:
+		switch n := t.Root.(type) {
+		case *deletionNode:
+			n.returning = true
+		case *replacementNode:
+			n.returning = true
+		default:
+			return nil, errors.New("RETURNING is only supported by INSERT, UPDATE and DELETE statements")
+		}
+	default:
+		return nil, errors.New("RETURNING is only supported by INSERT, UPDATE and DELETE statements")
+	}
+
+	return &ReturningStmt{
+		Stmt:       stmt,
+		Projection: NewProjectionNode(nil, fields, tableName).(*ProjectionNode),
+	}, nil
+}
+
+// Run the statement and project the documents it wrote.
+func (s *ReturningStmt) Run(tx *database.Transaction, params []expr.Param) (query.Result, error) {
+	res, err := s.Stmt.Run(tx, params)
+	if err != nil {
+		return res, err
+	}
+
+	err = s.Projection.Bind(tx, params)
+	if err != nil {
+		return res, err
+	}
+
+	res.Stream, err = s.Projection.toStream(res.Stream)
+	return res, err
+}
+
+// IsReadOnly always returns false.
+func (s *ReturningStmt) IsReadOnly() bool {
+	return false
+}
+
+func (s *ReturningStmt) String() string {
+	return fmt.Sprintf("%v -> %v", s.Stmt, s.Projection)
+}
@@ -3,28 +3,62 @@ package planner
 import (
 	"bytes"
 	"container/heap"
+	"encoding/binary"
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/document/encoding"
+	"github.com/genjidb/genji/engine"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/genjidb/genji/sql/scanner"
 )
 
+// A SortField is an expression used to sort a stream, along with its direction.
+type SortField struct {
+	Expr      expr.Expr
+	Direction scanner.Token
+}
+
+func (f SortField) String() string {
+	if f.Direction == scanner.DESC {
+		return fmt.Sprintf("%v DESC", f.Expr)
+	}
+
+	return fmt.Sprintf("%v ASC", f.Expr)
+}
+
 type sortNode struct {
 	node
 
-	sortField expr.Path
-	direction scanner.Token
+	sortFields []SortField
+	// if true, documents are returned with the encoded
+	// value they were sorted by.
+	withSortKeys bool
+	// if true, documents are returned with the key
+	// of the document they were copied from.
+	withKeys bool
+	// if greater than zero, only the first k documents
+	// are returned. Set by the UseTopKSortRule.
+	k int
+
+	tx     *database.Transaction
+	params []expr.Param
 }
 
 var _ operationNode = (*sortNode)(nil)
 
-// NewSortNode creates a node that sorts a stream according to a given
-// document path and a sort direction.
-func NewSortNode(n Node, sortField expr.Path, direction scanner.Token) Node {
-	if direction == 0 {
-		direction = scanner.ASC
+// NewSortNode creates a node that sorts a stream according to the given
+// expressions and sort directions. Documents are sorted by the value of the first
+// expression, then documents with the same value are sorted by the second one, and so on.
+func NewSortNode(n Node, sortFields ...SortField) Node {
+	fields := make([]SortField, len(sortFields))
+	for i, f := range sortFields {
+		if f.Direction == 0 {
+			f.Direction = scanner.ASC
+		}
+		fields[i] = f
 	}
 
 	return &sortNode{
@@ -32,47 +66,111 @@ func NewSortNode(n Node, sortField expr.Path, direction scanner.Token) Node {
 			op:   Sort,
 			left: n,
 		},
-		sortField: sortField,
-		direction: direction,
+		sortFields: fields,
 	}
 }
 
 func (n *sortNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	n.tx = tx
+	n.params = params
 	return
 }
 
 func (n *sortNode) toStream(st document.Stream) (document.Stream, error) {
-	return document.NewStream(&sortIterator{
-		st:        st,
-		sortField: n.sortField,
-		direction: n.direction,
-	}), nil
+	it := sortIterator{
+		st:           st,
+		sortFields:   n.sortFields,
+		withSortKeys: n.withSortKeys,
+		withKeys:     n.withKeys,
+		k:            n.k,
+		evalTx:       n.tx,
+		params:       n.params,
+	}
+
+	// spilled documents lose their key, documents that must keep it are sorted in memory
+	if n.tx != nil && n.tx.CanCreateTemporaryStore() && !n.withKeys {
+		it.tx = n.tx
+		it.bufferSize = n.tx.DB().SortBufferSize
+	}
+
+	return document.NewStream(&it), nil
 }
 
 func (n *sortNode) String() string {
-	dir := "ASC"
-	if n.direction == scanner.DESC {
-		dir = "DESC"
+	var b strings.Builder
+
+	for i, f := range n.sortFields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(f.String())
+	}
+
+	if n.k > 0 {
+		fmt.Fprintf(&b, ", top %d", n.k)
 	}
 
-	return fmt.Sprintf("Sort(%s %s)", n.sortField, dir)
+	return fmt.Sprintf("Sort(%s)", b.String())
 }
 
 type sortIterator struct {
-	st        document.Stream
-	sortField expr.Path
-	direction scanner.Token
+	st           document.Stream
+	sortFields   []SortField
+	withSortKeys bool
+	withKeys     bool
+	k            int
+
+	// transaction and parameters used to evaluate the sort expressions
+	evalTx *database.Transaction
+	params []expr.Param
+
+	// if bufferSize is greater than zero, documents are spilled
+	// to a temporary store every time the heap reaches that size.
+	tx         *database.Transaction
+	bufferSize int
+	spill      *sortSpill
 }
 
 func (it *sortIterator) Iterate(fn func(d document.Document) error) error {
+	// the top-k heap is bounded, there is no need to spill
+	// it unless it is larger than the sort buffer.
+	if it.k > 0 && (it.bufferSize <= 0 || it.k < it.bufferSize) {
+		nodes, err := it.topK(it.st)
+		if err != nil {
+			return err
+		}
+
+		for i := range nodes {
+			err = fn(it.sortedDocument(&nodes[i]))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
 	h, err := it.sortStream(it.st)
+	if it.spill != nil {
+		defer it.spill.drop()
+	}
 	if err != nil {
 		return err
 	}
 
+	if it.spill != nil {
+		err = it.spill.write(h)
+		if err != nil {
+			return err
+		}
+
+		return it.spill.iterate(it.desc(), it.withSortKeys, fn)
+	}
+
 	for h.Len() > 0 {
 		node := heap.Pop(h).(heapNode)
-		err := fn(&(node.data))
+
+		err := fn(it.sortedDocument(&node))
 		if err != nil {
 			return err
 		}
@@ -81,104 +179,442 @@ func (it *sortIterator) Iterate(fn func(d document.Document) error) error {
 	return nil
 }
 
-// sortStream operates a partial sort on the iterator using a heap.
-// This ensures a O(k+n log n) time complexity, where k is the sum of
-// OFFSET + LIMIT clauses, if provided, otherwise k = n.
+// desc returns true if the stream is sorted in descending order.
+// The order of the other sort fields is encoded in the sort value, see iterateNodes.
+func (it *sortIterator) desc() bool {
+	return it.sortFields[0].Direction == scanner.DESC
+}
+
+func (it *sortIterator) sortedDocument(node *heapNode) document.Document {
+	if it.withSortKeys {
+		return &sortedDocument{Document: &node.data, sortKey: node.value}
+	}
+	if it.withKeys {
+		return &keyedDocument{Document: &node.data, rawKey: node.rawKey, key: node.key}
+	}
+
+	return &node.data
+}
+
+// sortedDocument is a document returned by the sort node,
+// along with the encoded value it was sorted by.
+type sortedDocument struct {
+	document.Document
+
+	sortKey []byte
+}
+
+// keyedDocument is a document returned by the sort node,
+// along with the key of the document it was copied from.
+type keyedDocument struct {
+	document.Document
+
+	rawKey []byte
+	key    document.Value
+}
+
+func (d *keyedDocument) RawKey() []byte {
+	return d.rawKey
+}
+
+func (d *keyedDocument) Key() (document.Value, error) {
+	return d.key, nil
+}
+
+// sortStream sorts the entire stream using a heap.
 // If the sorting is in ascending order, a min-heap will be used
 // otherwise a max-heap will be used instead.
 // Once the heap is filled entirely with the content of the table a stream is returned.
-// During iteration, the stream will pop the k-smallest or k-largest elements, depending on
+// During iteration, the stream will pop the smallest or largest elements, depending on
 // the chosen sorting order (ASC or DESC).
-// This function is not memory efficient as it's loading the entire stream in memory before
-// returning the k-smallest or k-largest elements.
+// This function is not memory efficient as it's loading the entire stream in memory,
+// unless a sort buffer size is configured, in which case documents are spilled to disk.
+// When the number of documents to return is known, topK should be used instead.
 func (it *sortIterator) sortStream(st document.Stream) (heap.Interface, error) {
-	path := document.Path(it.sortField)
+	h := &sortHeap{desc: it.desc()}
+
+	return h, it.iterateNodes(st, func(node heapNode) error {
+		heap.Push(h, node)
+
+		if it.bufferSize > 0 && h.Len() >= it.bufferSize {
+			var err error
+			if it.spill == nil {
+				it.spill, err = newSortSpill(it.tx)
+				if err != nil {
+					return err
+				}
+			}
+
+			return it.spill.write(h)
+		}
 
-	var h heap.Interface
-	if it.direction == scanner.ASC {
-		h = new(minHeap)
-	} else {
-		h = new(maxHeap)
+		return nil
+	})
+}
+
+// topK returns the first k documents of the stream, in order.
+// It uses a heap sorted in the opposite order that never holds more than k documents:
+// every document is compared with the root of the heap, which is the last of the
+// k documents kept so far, and replaces it if it must be returned before it.
+// This ensures a O(n log k) time complexity and a O(k) memory usage.
+// Documents that are equal are returned in the same order as a full sort would,
+// since ties are broken using the position of the document in the stream.
+func (it *sortIterator) topK(st document.Stream) ([]heapNode, error) {
+	h := &sortHeap{desc: !it.desc()}
+
+	err := it.iterateNodes(st, func(node heapNode) error {
+		if h.Len() < it.k {
+			heap.Push(h, node)
+			return nil
+		}
+
+		// the heap is reversed, a document must replace the root
+		// if the root is "less" than it.
+		if !h.less(&h.nodes[0], &node) {
+			return nil
+		}
+
+		h.nodes[0] = node
+		heap.Fix(h, 0)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	heap.Init(h)
+	nodes := make([]heapNode, h.Len())
+	for i := len(nodes) - 1; i >= 0; i-- {
+		nodes[i] = heap.Pop(h).(heapNode)
+	}
+
+	return nodes, nil
+}
 
-	return h, st.Iterate(func(d document.Document) error {
-		// It is possible to sort by any projected field
-		// or field of the original document.
-		v, err := path.GetValueFromDocument(d)
-		if err != nil && err != document.ErrFieldNotFound {
+// iterateNodes calls fn for every document of the stream, along with
+// the encoded value it must be sorted by.
+// The values of all the sort fields are concatenated in a single sort value,
+// which is compared in the direction of the first sort field.
+// Each value is escaped and terminated so that values of different lengths
+// are compared correctly, and the bytes of the values that must be sorted
+// in the other direction are inverted.
+func (it *sortIterator) iterateNodes(st document.Stream, fn func(node heapNode) error) error {
+	var seq uint64
+
+	return st.Iterate(func(d document.Document) error {
+		var key []byte
+
+		for _, f := range it.sortFields {
+			v, err := it.sortValue(d, f.Expr)
+			if err != nil {
+				return err
+			}
+
+			key, err = appendSortValue(key, v, f.Direction != it.sortFields[0].Direction)
+			if err != nil {
+				return err
+			}
+		}
+
+		seq++
+		node := heapNode{
+			value: key,
+			seq:   seq,
+		}
+		err := node.data.Copy(d)
+		if err != nil {
 			return err
 		}
 
-		// If a field is not found in the projected fields
-		// Look for fields in the original document.
-		if err == document.ErrFieldNotFound {
-			if dm, ok := d.(*documentMask); ok {
-				v, err = path.GetValueFromDocument(dm.d)
-				if err != nil && err != document.ErrFieldNotFound {
-					return err
-				}
-				if err == document.ErrFieldNotFound {
-					v = document.NewNullValue()
-				}
-			} else {
-				v = document.NewNullValue()
+		if k, ok := d.(document.Keyer); ok && it.withKeys {
+			node.rawKey = append([]byte{}, k.RawKey()...)
+			node.key, err = k.Key()
+			if err != nil {
+				return err
+			}
+		}
+
+		return fn(node)
+	})
+}
+
+// sortValue evaluates the expression against the document.
+// It is possible to sort by any projected field
+// or field of the original document.
+func (it *sortIterator) sortValue(d document.Document, e expr.Expr) (document.Value, error) {
+	p, ok := e.(expr.Path)
+	if !ok {
+		if dm, ok := d.(*documentMask); ok {
+			d = sortDocument{projected: d, original: dm.d}
+		}
+
+		env := expr.NewEnvironment(document.NewDocumentValue(d), it.params...)
+		env.Tx = it.evalTx
+		return e.Eval(env)
+	}
+
+	path := document.Path(p)
+	v, err := path.GetValueFromDocument(d)
+	if err != document.ErrFieldNotFound {
+		return v, err
+	}
+
+	// If a field is not found in the projected fields
+	// Look for fields in the original document.
+	if dm, ok := d.(*documentMask); ok {
+		v, err = path.GetValueFromDocument(dm.d)
+		if err != document.ErrFieldNotFound {
+			return v, err
+		}
+	}
+
+	return document.NewNullValue(), nil
+}
+
+// sortDocument is used to evaluate sort expressions.
+// Its fields are the projected fields, followed by the fields of the original
+// document that are not projected.
+type sortDocument struct {
+	projected, original document.Document
+}
+
+func (d sortDocument) GetByField(field string) (document.Value, error) {
+	v, err := d.projected.GetByField(field)
+	if err != document.ErrFieldNotFound {
+		return v, err
+	}
+
+	return d.original.GetByField(field)
+}
+
+// RawKey returns the key of the original document, if any.
+func (d sortDocument) RawKey() []byte {
+	if k, ok := d.original.(document.Keyer); ok {
+		return k.RawKey()
+	}
+
+	return nil
+}
+
+// Key returns the primary key of the original document, or NULL if it doesn't have one.
+func (d sortDocument) Key() (document.Value, error) {
+	if k, ok := d.original.(document.Keyer); ok {
+		return k.Key()
+	}
+
+	return document.NewNullValue(), nil
+}
+
+func (d sortDocument) Iterate(fn func(field string, value document.Value) error) error {
+	err := d.projected.Iterate(fn)
+	if err != nil {
+		return err
+	}
+
+	return d.original.Iterate(func(field string, value document.Value) error {
+		_, err := d.projected.GetByField(field)
+		if err != document.ErrFieldNotFound {
+			return err
+		}
+
+		return fn(field, value)
+	})
+}
+
+// appendSortValue encodes the value and appends it to the sort value.
+// Zero bytes are escaped and the value is terminated by 0x00 0x01, so that
+// a value is always sorted before the values it is a prefix of.
+// If invert is true, every byte is inverted to reverse the order.
+func appendSortValue(key []byte, v document.Value, invert bool) ([]byte, error) {
+	// We need to make sure sort behaviour
+	// if the same with or without indexes.
+	// To achieve that, the value must be encoded using the same method
+	// as what the index package would do.
+	var buf bytes.Buffer
+
+	err := document.NewValueEncoder(&buf).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	start := len(key)
+
+	for _, c := range buf.Bytes() {
+		if c == 0 {
+			key = append(key, 0, 0xFF)
+		} else {
+			key = append(key, c)
+		}
+	}
+	key = append(key, 0, 1)
+
+	if invert {
+		for i := start; i < len(key); i++ {
+			key[i] = ^key[i]
+		}
+	}
+
+	return key, nil
+}
+
+// sortKeyPrefix returns the part of the sort key encoding the values
+// of the first n sort fields.
+// Values are terminated by 0x00 0x01, and zero bytes are escaped as 0x00 0xFF,
+// both inverted if the field is sorted in the other direction, see appendSortValue.
+func sortKeyPrefix(key []byte, sortFields []SortField, n int) []byte {
+	var i int
+
+	for f := 0; f < n && f < len(sortFields); f++ {
+		sep, term := byte(0), byte(1)
+		if sortFields[f].Direction != sortFields[0].Direction {
+			sep, term = ^sep, ^term
+		}
+
+		for i+1 < len(key) {
+			if key[i] != sep {
+				i++
+				continue
+			}
+
+			i += 2
+			if key[i-1] == term {
+				break
 			}
 		}
+	}
+
+	return key[:i]
+}
+
+// sortSpill writes sorted documents to a temporary store.
+// Documents are stored under their encoded sort value, followed by their
+// position in the stream, so that iterating over the store returns them in order.
+type sortSpill struct {
+	st     engine.Store
+	dropFn func() error
+	codec  encoding.Codec
+}
+
+func newSortSpill(tx *database.Transaction) (*sortSpill, error) {
+	st, dropFn, err := tx.CreateTemporaryStore()
+	if err != nil {
+		return nil, err
+	}
 
-		// We need to make sure sort behaviour
-		// if the same with or without indexes.
-		// To achieve that, the value must be encoded using the same method
-		// as what the index package would do.
+	return &sortSpill{
+		st:     st,
+		dropFn: dropFn,
+		codec:  tx.DB().Codec,
+	}, nil
+}
+
+// write empties the heap into the store.
+func (s *sortSpill) write(h heap.Interface) error {
+	for h.Len() > 0 {
+		node := heap.Pop(h).(heapNode)
+
+		k := make([]byte, len(node.value), len(node.value)+8)
+		copy(k, node.value)
+		k = append(k, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(k[len(node.value):], node.seq)
+
+		// engines may keep a reference to the value
+		// until the end of the transaction, a new buffer
+		// must be used for every document.
 		var buf bytes.Buffer
+		enc := s.codec.NewEncoder(&buf)
+		err := enc.EncodeDocument(&node.data)
+		enc.Close()
+		if err != nil {
+			return err
+		}
 
-		err = document.NewValueEncoder(&buf).Encode(v)
+		err = s.st.Put(k, buf.Bytes())
 		if err != nil {
 			return err
 		}
+	}
 
-		node := heapNode{
-			value: buf.Bytes(),
+	return nil
+}
+
+// iterate over the spilled documents in order.
+func (s *sortSpill) iterate(reverse, withSortKeys bool, fn func(d document.Document) error) error {
+	it := s.st.Iterator(engine.IteratorOptions{Reverse: reverse})
+	defer it.Close()
+
+	for it.Seek(nil); it.Valid(); it.Next() {
+		buf, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return err
 		}
-		err = node.data.Copy(d)
+
+		d := s.codec.NewDocument(buf)
+		if withSortKeys {
+			// remove the sequence number from the key
+			k := it.Item().Key()
+			d = &sortedDocument{Document: d, sortKey: append([]byte{}, k[:len(k)-8]...)}
+		}
+
+		err = fn(d)
 		if err != nil {
 			return err
 		}
+	}
 
-		heap.Push(h, node)
+	return it.Err()
+}
 
-		return nil
-	})
+func (s *sortSpill) drop() error {
+	return s.dropFn()
 }
 
 type heapNode struct {
 	value []byte
-	data  document.FieldBuffer
+	// position of the document in the stream,
+	// used to sort documents with the same value.
+	seq  uint64
+	data document.FieldBuffer
+	// key of the document, if the sort node keeps it.
+	rawKey []byte
+	key    document.Value
 }
 
-type minHeap []heapNode
+// sortHeap sorts nodes by value then by position in the stream,
+// in ascending order, or in descending order if desc is true.
+type sortHeap struct {
+	nodes []heapNode
+	desc  bool
+}
 
-func (h minHeap) Len() int           { return len(h) }
-func (h minHeap) Less(i, j int) bool { return bytes.Compare(h[i].value, h[j].value) < 0 }
-func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *sortHeap) less(a, b *heapNode) bool {
+	c := bytes.Compare(a.value, b.value)
+	if c == 0 {
+		switch {
+		case a.seq < b.seq:
+			c = -1
+		case a.seq > b.seq:
+			c = 1
+		}
+	}
 
-func (h *minHeap) Push(x interface{}) {
-	*h = append(*h, x.(heapNode))
-}
+	if h.desc {
+		return c > 0
+	}
 
-func (h *minHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	x := old[n-1]
-	*h = old[0 : n-1]
-	return x
+	return c < 0
 }
 
-type maxHeap struct {
-	minHeap
+func (h *sortHeap) Len() int           { return len(h.nodes) }
+func (h *sortHeap) Less(i, j int) bool { return h.less(&h.nodes[i], &h.nodes[j]) }
+func (h *sortHeap) Swap(i, j int)      { h.nodes[i], h.nodes[j] = h.nodes[j], h.nodes[i] }
+
+func (h *sortHeap) Push(x interface{}) {
+	h.nodes = append(h.nodes, x.(heapNode))
 }
 
-func (h maxHeap) Less(i, j int) bool {
-	return bytes.Compare(h.minHeap[i].value, h.minHeap[j].value) > 0
+func (h *sortHeap) Pop() interface{} {
+	n := len(h.nodes)
+	x := h.nodes[n-1]
+	h.nodes = h.nodes[0 : n-1]
+	return x
 }
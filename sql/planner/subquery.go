@@ -0,0 +1,107 @@
+package planner
+
+import (
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// subqueryStatement runs a SELECT statement used as an expression.
+// Trees are modified when they are optimized, the statement is built again
+// by calling build every time it is run in a new transaction.
+// Within the same transaction, an optimized tree is bound to the new parameters
+// and executed again, which avoids optimizing correlated subqueries for every document.
+type subqueryStatement struct {
+	build func() (query.Statement, error)
+
+	tx   *database.Transaction
+	tree *Tree
+}
+
+// NewSubqueryStatement creates a statement that can be used by a subquery expression.
+// The build function must return a new statement every time it is called.
+func NewSubqueryStatement(build func() (query.Statement, error)) expr.SubqueryStatement {
+	return &subqueryStatement{build: build}
+}
+
+// Stream implements the expr.SubqueryStatement interface.
+func (s *subqueryStatement) Stream(tx *database.Transaction, params []expr.Param) (document.Stream, error) {
+	if s.tree != nil && s.tx == tx {
+		err := Bind(s.tree, tx, params)
+		if err != nil {
+			return document.Stream{}, err
+		}
+
+		res, err := s.tree.execute()
+		return res.Stream, err
+	}
+
+	stmt, err := s.build()
+	if err != nil {
+		return document.Stream{}, err
+	}
+
+	t, ok := stmt.(*Tree)
+	if !ok {
+		res, err := stmt.Run(tx, params)
+		return res.Stream, err
+	}
+
+	err = Bind(t, tx, params)
+	if err != nil {
+		return document.Stream{}, err
+	}
+
+	t, err = Optimize(t)
+	if err != nil {
+		return document.Stream{}, err
+	}
+
+	s.tx, s.tree = tx, t
+
+	res, err := t.execute()
+	return res.Stream, err
+}
+
+func (s *subqueryStatement) String() string {
+	stmt, err := s.build()
+	if err != nil {
+		return err.Error()
+	}
+
+	if t, ok := stmt.(*Tree); ok {
+		return t.String()
+	}
+
+	return stmt.(interface{ String() string }).String()
+}
+
+// resetSubqueries clears the cached results of the subqueries used by e,
+// so that they are run again by the new execution of the statement.
+func resetSubqueries(e expr.Expr) {
+	switch t := e.(type) {
+	case *expr.Subquery:
+		t.Reset()
+	case interface{ Subquery() *expr.Subquery }:
+		t.Subquery().Reset()
+	case expr.Parentheses:
+		resetSubqueries(t.E)
+	case expr.CastFunc:
+		resetSubqueries(t.Expr)
+	case expr.CaseExpr:
+		resetSubqueries(t.Operand)
+		for _, w := range t.Whens {
+			resetSubqueries(w.When)
+			resetSubqueries(w.Then)
+		}
+		resetSubqueries(t.Else)
+	case expr.LiteralExprList:
+		for _, e := range t {
+			resetSubqueries(e)
+		}
+	case expr.Operator:
+		resetSubqueries(t.LeftHand())
+		resetSubqueries(t.RightHand())
+	}
+}
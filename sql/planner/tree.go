@@ -47,6 +47,15 @@ const (
 	Aggregation
 	// Dedup is an operation that removes duplicate documents from a stream
 	Dedup
+	// Window is an operation that computes the value of window functions
+	// based on the position of each document in a sorted stream.
+	Window
+	// Join is an input operation that combines the documents of two tables
+	// whose values at a given path are equal.
+	Join
+	// Unnest is an operation that creates one document per element
+	// of the array found at a given path.
+	Unnest
 )
 
 // A Tree describes the flow of a stream of documents.
@@ -95,9 +104,14 @@ func (t *Tree) execute() (query.Result, error) {
 		return query.Result{}, err
 	}
 
-	return query.Result{
+	res := query.Result{
 		Stream: st,
-	}, nil
+	}
+	if rn, ok := t.Root.(rowsAffectedNode); ok {
+		res.RowsAffected = rn.rowsAffected()
+	}
+
+	return res, nil
 }
 
 func (t *Tree) String() string {
@@ -166,6 +180,12 @@ type inputNode interface {
 	buildStream() (document.Stream, error)
 }
 
+// A rowsAffectedNode is an operation node that modifies documents
+// and reports how many of them were affected.
+type rowsAffectedNode interface {
+	rowsAffected() int64
+}
+
 type operationNode interface {
 	Node
 
@@ -222,6 +242,7 @@ func NewSelectionNode(n Node, cond expr.Expr) Node {
 func (n *selectionNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
 	n.tx = tx
 	n.params = params
+	resetSubqueries(n.cond)
 	return
 }
 
@@ -232,6 +253,7 @@ func (n *selectionNode) toStream(st document.Stream) (document.Stream, error) {
 
 	env := expr.Environment{
 		Params: n.params,
+		Tx:     n.tx,
 	}
 
 	return st.Filter(func(d document.Document) (bool, error) {
@@ -256,7 +278,10 @@ func (n *selectionNode) String() string {
 type limitNode struct {
 	node
 
-	limit  int
+	limit int
+	// if set, the limit is evaluated from the params every time
+	// the node is bound.
+	e      expr.Expr
 	tx     *database.Transaction
 	params []expr.Param
 }
@@ -274,9 +299,25 @@ func NewLimitNode(n Node, limit int) Node {
 	}
 }
 
+// NewLimitExprNode creates a limit node whose limit is evaluated from
+// the query parameters every time the query is run.
+func NewLimitExprNode(n Node, e expr.Expr) Node {
+	return &limitNode{
+		node: node{
+			op:   Limit,
+			left: n,
+		},
+		e: e,
+	}
+}
+
 func (n *limitNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
 	n.tx = tx
 	n.params = params
+
+	if n.e != nil {
+		n.limit, err = EvalPaginationExpr("limit", n.e, params)
+	}
 	return
 }
 
@@ -285,12 +326,19 @@ func (n *limitNode) toStream(st document.Stream) (document.Stream, error) {
 }
 
 func (n *limitNode) String() string {
+	if n.e != nil && n.tx == nil {
+		return fmt.Sprintf("Limit(%s)", n.e)
+	}
+
 	return fmt.Sprintf("Limit(%d)", n.limit)
 }
 
 type offsetNode struct {
 	node
 	offset int
+	// if set, the offset is evaluated from the params every time
+	// the node is bound.
+	e expr.Expr
 
 	tx     *database.Transaction
 	params []expr.Param
@@ -309,13 +357,33 @@ func NewOffsetNode(n Node, offset int) Node {
 	}
 }
 
+// NewOffsetExprNode creates an offset node whose offset is evaluated from
+// the query parameters every time the query is run.
+func NewOffsetExprNode(n Node, e expr.Expr) Node {
+	return &offsetNode{
+		node: node{
+			op:   Limit,
+			left: n,
+		},
+		e: e,
+	}
+}
+
 func (n *offsetNode) String() string {
+	if n.e != nil && n.tx == nil {
+		return fmt.Sprintf("Offset(%s)", n.e)
+	}
+
 	return fmt.Sprintf("Offset(%d)", n.offset)
 }
 
 func (n *offsetNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
 	n.tx = tx
 	n.params = params
+
+	if n.e != nil {
+		n.offset, err = EvalPaginationExpr("offset", n.e, params)
+	}
 	return
 }
 
@@ -323,6 +391,33 @@ func (n *offsetNode) toStream(st document.Stream) (document.Stream, error) {
 	return st.Offset(n.offset), nil
 }
 
+// EvalPaginationExpr evaluates the expression of a LIMIT or OFFSET clause
+// using the given params. The clause is used in error messages.
+// The expression must evaluate to a non-negative number.
+// Fractional numbers are truncated toward zero, following the rules of CAST:
+// LIMIT 5.9 is equivalent to LIMIT 5.
+func EvalPaginationExpr(clause string, e expr.Expr, params []expr.Param) (int, error) {
+	v, err := e.Eval(&expr.Environment{Params: params})
+	if err != nil {
+		return 0, err
+	}
+
+	if !v.Type.IsNumber() {
+		return 0, fmt.Errorf("%s expression must evaluate to a number, got %q", clause, v.Type)
+	}
+
+	v, err = v.CastAsInteger()
+	if err != nil {
+		return 0, err
+	}
+
+	if v.V.(int64) < 0 {
+		return 0, fmt.Errorf("%s expression must evaluate to a non-negative number, got %d", clause, v.V.(int64))
+	}
+
+	return int(v.V.(int64)), nil
+}
+
 type setNode struct {
 	node
 
@@ -336,6 +431,7 @@ type setNode struct {
 var _ operationNode = (*setNode)(nil)
 
 // NewSetNode creates a node that adds or replaces a value at the given path for every document of the stream.
+// Documents where an intermediate value of the path doesn't exist are left unchanged.
 func NewSetNode(n Node, path document.Path, e expr.Expr) Node {
 	return &setNode{
 		node: node{
@@ -362,6 +458,7 @@ func (n *setNode) toStream(st document.Stream) (document.Stream, error) {
 
 	env := expr.Environment{
 		Params: n.params,
+		Tx:     n.tx,
 	}
 
 	return st.Map(func(d document.Document) (document.Document, error) {
@@ -379,6 +476,10 @@ func (n *setNode) toStream(st document.Stream) (document.Stream, error) {
 		}
 
 		err = fb.Set(n.path, ev)
+		// documents where the parent of the path doesn't exist are left unchanged
+		if err == document.ErrFieldNotFound {
+			return d, nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -476,7 +577,9 @@ func (n *GroupingNode) Bind(tx *database.Transaction, params []expr.Param) (err
 // the result.
 func (n *GroupingNode) toStream(st document.Stream) (document.Stream, error) {
 	return st.GroupBy(func(d document.Document) (document.Value, error) {
-		return n.Expr.Eval(expr.NewEnvironment(document.NewDocumentValue(d), n.Params...))
+		env := expr.NewEnvironment(document.NewDocumentValue(d), n.Params...)
+		env.Tx = n.Tx
+		return n.Expr.Eval(env)
 	}), nil
 }
 
@@ -0,0 +1,71 @@
+package planner
+
+import (
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+type unnestNode struct {
+	node
+
+	path document.Path
+}
+
+var _ operationNode = (*unnestNode)(nil)
+
+// NewUnnestNode creates a node that returns one document per element of the array
+// found at the given path, in which the array is replaced by the element.
+// Documents where the value at path is not an array, or an empty array, are skipped.
+// The returned documents keep the key of the original document, which is thus
+// shared by all the documents of the same array.
+func NewUnnestNode(n Node, path document.Path) Node {
+	return &unnestNode{
+		node: node{
+			op:   Unnest,
+			left: n,
+		},
+		path: path,
+	}
+}
+
+func (n *unnestNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	return
+}
+
+func (n *unnestNode) toStream(st document.Stream) (document.Stream, error) {
+	return document.NewStream(document.IteratorFunc(func(fn func(d document.Document) error) error {
+		var fb document.FieldBuffer
+
+		return st.Iterate(func(d document.Document) error {
+			v, err := n.path.GetValueFromDocument(d)
+			if err == document.ErrFieldNotFound || (err == nil && v.Type != document.ArrayValue) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			fb.Reset()
+			err = fb.ScanDocument(d)
+			if err != nil {
+				return err
+			}
+
+			return v.V.(document.Array).Iterate(func(i int, elem document.Value) error {
+				err := fb.Set(n.path, elem)
+				if err != nil {
+					return err
+				}
+
+				return fn(&fb)
+			})
+		})
+	})), nil
+}
+
+func (n *unnestNode) String() string {
+	return fmt.Sprintf("Unnest(%s)", n.path)
+}
@@ -0,0 +1,379 @@
+package planner
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// A WindowField is a projected field whose value is computed by a window function.
+type WindowField struct {
+	Name string
+	Func expr.WindowFunc
+}
+
+type windowNode struct {
+	node
+
+	fields []WindowField
+}
+
+var _ operationNode = (*windowNode)(nil)
+
+// NewWindowNode creates a node that sets the value of the given fields using
+// the position of each document in the stream.
+// If n is a sort node, documents that have the same sort value are peers, otherwise
+// documents are numbered in the order of the stream and all of them are peers.
+func NewWindowNode(n Node, fields []WindowField) Node {
+	if sn, ok := n.(*sortNode); ok {
+		sn.withSortKeys = true
+	}
+
+	return &windowNode{
+		node: node{
+			op:   Window,
+			left: n,
+		},
+		fields: fields,
+	}
+}
+
+func (n *windowNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	return
+}
+
+func (n *windowNode) toStream(st document.Stream) (document.Stream, error) {
+	var pos expr.WindowPosition
+	var prevKey []byte
+
+	return st.Map(func(d document.Document) (document.Document, error) {
+		var key []byte
+		if sd, ok := d.(*sortedDocument); ok {
+			d = sd.Document
+			key = sd.sortKey
+		}
+
+		pos.RowNumber++
+		if pos.RowNumber == 1 || !bytes.Equal(key, prevKey) {
+			pos.Rank = pos.RowNumber
+			pos.DenseRank++
+		}
+		prevKey = key
+
+		var fb document.FieldBuffer
+		err := fb.ScanDocument(d)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range n.fields {
+			v := f.Func.WindowValue(pos)
+			err = fb.Replace(f.Name, v)
+			if err == document.ErrFieldNotFound {
+				fb.Add(f.Name, v)
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return &fb, nil
+	}), nil
+}
+
+func (n *windowNode) String() string {
+	var b strings.Builder
+
+	for i, f := range n.fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", f.Func))
+	}
+
+	return fmt.Sprintf("Window(%s)", b.String())
+}
+
+type overNode struct {
+	node
+
+	funcs []expr.OverFunc
+
+	tx     *database.Transaction
+	params []expr.Param
+}
+
+var _ operationNode = (*overNode)(nil)
+
+// NewOverNode creates a node that computes the value of window functions followed
+// by an OVER clause. The entire stream is loaded in memory and sorted for every window,
+// then documents are returned in the same order, along with the value of each function.
+// It must be placed before the projection, which reads the values using the name of the
+// functions.
+func NewOverNode(n Node, funcs []expr.OverFunc) Node {
+	return &overNode{
+		node: node{
+			op:   Window,
+			left: n,
+		},
+		funcs: funcs,
+	}
+}
+
+func (n *overNode) Bind(tx *database.Transaction, params []expr.Param) (err error) {
+	n.tx = tx
+	n.params = params
+	return
+}
+
+func (n *overNode) toStream(st document.Stream) (document.Stream, error) {
+	names := make([]string, len(n.funcs))
+	for i, f := range n.funcs {
+		names[i] = f.String()
+	}
+
+	return document.NewStream(document.IteratorFunc(func(fn func(d document.Document) error) error {
+		var docs []*windowDocument
+
+		err := st.Iterate(func(d document.Document) error {
+			wd := windowDocument{
+				names:  names,
+				values: make([]document.Value, len(names)),
+			}
+
+			err := wd.FieldBuffer.Copy(d)
+			if err != nil {
+				return err
+			}
+
+			if k, ok := d.(document.Keyer); ok {
+				wd.rawKey = append([]byte(nil), k.RawKey()...)
+				wd.key, err = k.Key()
+				if err != nil {
+					return err
+				}
+			}
+
+			docs = append(docs, &wd)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, f := range n.funcs {
+			err = n.computeWindow(docs, i, f)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, d := range docs {
+			err = fn(d)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})), nil
+}
+
+// windowRow is a document of a window, along with the encoded values
+// of its PARTITION BY and ORDER BY expressions.
+type windowRow struct {
+	doc       *windowDocument
+	partition []byte
+	order     []byte
+}
+
+// computeWindow computes the value of the i-th function for every document.
+func (n *overNode) computeWindow(docs []*windowDocument, i int, f expr.OverFunc) error {
+	rows := make([]windowRow, len(docs))
+
+	for j, d := range docs {
+		rows[j].doc = d
+
+		for _, e := range f.Window.PartitionBy {
+			v, err := n.eval(d, e)
+			if err != nil {
+				return err
+			}
+
+			rows[j].partition, err = appendSortValue(rows[j].partition, v, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, o := range f.Window.OrderBy {
+			v, err := n.eval(d, o.Expr)
+			if err != nil {
+				return err
+			}
+
+			rows[j].order, err = appendSortValue(rows[j].order, v, o.Direction == scanner.DESC)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// documents with the same values keep the order of the stream
+	sort.SliceStable(rows, func(a, b int) bool {
+		if c := bytes.Compare(rows[a].partition, rows[b].partition); c != 0 {
+			return c < 0
+		}
+
+		return bytes.Compare(rows[a].order, rows[b].order) < 0
+	})
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && bytes.Equal(rows[end].partition, rows[start].partition) {
+			end++
+		}
+
+		err := n.computePartition(rows[start:end], i, f)
+		if err != nil {
+			return err
+		}
+
+		start = end
+	}
+
+	return nil
+}
+
+// computePartition computes the value of the i-th function for every document of a partition.
+func (n *overNode) computePartition(rows []windowRow, i int, f expr.OverFunc) error {
+	switch t := f.Func.(type) {
+	case expr.WindowFunc:
+		var pos expr.WindowPosition
+
+		for j := range rows {
+			pos.RowNumber++
+			if j == 0 || !bytes.Equal(rows[j].order, rows[j-1].order) {
+				pos.Rank = pos.RowNumber
+				pos.DenseRank++
+			}
+
+			rows[j].doc.values[i] = t.WindowValue(pos)
+		}
+	case expr.OffsetWindowFunc:
+		e, offsetExpr, defExpr, backward := t.OffsetArgs()
+
+		for j := range rows {
+			offset := int64(1)
+			if offsetExpr != nil {
+				v, err := n.eval(rows[j].doc, offsetExpr)
+				if err != nil {
+					return err
+				}
+				if v.Type == document.NullValue {
+					rows[j].doc.values[i] = v
+					continue
+				}
+				if !v.Type.IsNumber() {
+					return fmt.Errorf("offset of %v must evaluate to a number, got %q", t, v.Type)
+				}
+				v, err = v.CastAsInteger()
+				if err != nil {
+					return err
+				}
+				offset = v.V.(int64)
+				if offset < 0 {
+					return fmt.Errorf("offset of %v must evaluate to a non-negative number, got %d", t, offset)
+				}
+			}
+
+			target := int64(j) + offset
+			if backward {
+				target = int64(j) - offset
+			}
+
+			var err error
+			var v document.Value
+			switch {
+			case target >= 0 && target < int64(len(rows)):
+				v, err = n.eval(rows[target].doc, e)
+			case defExpr != nil:
+				v, err = n.eval(rows[j].doc, defExpr)
+			default:
+				v = document.NewNullValue()
+			}
+			if err != nil {
+				return err
+			}
+
+			rows[j].doc.values[i] = v
+		}
+	default:
+		return fmt.Errorf("%v is not a window function", f.Func)
+	}
+
+	return nil
+}
+
+func (n *overNode) eval(d *windowDocument, e expr.Expr) (document.Value, error) {
+	env := expr.NewEnvironment(document.NewDocumentValue(d), n.params...)
+	env.Tx = n.tx
+	return e.Eval(env)
+}
+
+func (n *overNode) String() string {
+	var b strings.Builder
+
+	for i, f := range n.funcs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(f.String())
+	}
+
+	return fmt.Sprintf("Window(%s)", b.String())
+}
+
+// windowDocument is a document returned by the over node.
+// The values of the window functions can be read using their name,
+// but are not returned by Iterate.
+type windowDocument struct {
+	document.FieldBuffer
+
+	names  []string
+	values []document.Value
+
+	rawKey []byte
+	key    document.Value
+}
+
+func (d *windowDocument) GetByField(field string) (document.Value, error) {
+	for i, name := range d.names {
+		if name == field {
+			return d.values[i], nil
+		}
+	}
+
+	return d.FieldBuffer.GetByField(field)
+}
+
+// RawKey returns the key of the original document, if any.
+func (d *windowDocument) RawKey() []byte {
+	return d.rawKey
+}
+
+// Key returns the primary key of the original document, or NULL if it doesn't have one.
+func (d *windowDocument) Key() (document.Value, error) {
+	if d.rawKey == nil {
+		return document.NewNullValue(), nil
+	}
+
+	return d.key, nil
+}
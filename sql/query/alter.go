@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/expr"
 )
 
@@ -39,6 +40,40 @@ func (stmt AlterStmt) Run(tx *database.Transaction, _ []expr.Param) (Result, err
 	return res, err
 }
 
+// AlterTableRenameField is a DSL that allows creating a full ALTER TABLE ... RENAME FIELD statement.
+type AlterTableRenameField struct {
+	TableName string
+	Path      document.Path
+	NewName   string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableRenameField) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE RENAME FIELD statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableRenameField) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.Path == nil {
+		return res, errors.New("missing field name")
+	}
+
+	if stmt.NewName == "" {
+		return res, errors.New("missing new field name")
+	}
+
+	err := tx.RenameField(stmt.TableName, stmt.Path, stmt.NewName)
+	return res, err
+}
+
+// AlterTableAddField is a DSL that allows creating a full ALTER TABLE ... ADD FIELD statement.
 type AlterTableAddField struct {
 	TableName  string
 	Constraint database.FieldConstraint
@@ -65,3 +100,59 @@ func (stmt AlterTableAddField) Run(tx *database.Transaction, _ []expr.Param) (Re
 	err := tx.AddField(stmt.TableName, stmt.Constraint)
 	return res, err
 }
+
+// AlterTableDropField is a DSL that allows creating a full ALTER TABLE ... DROP FIELD statement.
+type AlterTableDropField struct {
+	TableName string
+	Path      document.Path
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableDropField) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE DROP FIELD statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableDropField) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.Path == nil {
+		return res, errors.New("missing field name")
+	}
+
+	err := tx.DropField(stmt.TableName, stmt.Path)
+	return res, err
+}
+
+// AlterTableAlterField is a DSL that allows creating a full ALTER TABLE ... ALTER FIELD statement.
+type AlterTableAlterField struct {
+	TableName  string
+	Constraint database.FieldConstraint
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt AlterTableAlterField) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the ALTER TABLE ALTER FIELD statement in the given transaction.
+// It implements the Statement interface.
+func (stmt AlterTableAlterField) Run(tx *database.Transaction, _ []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.Constraint.Path == nil {
+		return res, errors.New("missing field name")
+	}
+
+	err := tx.AlterField(stmt.TableName, stmt.Constraint)
+	return res, err
+}
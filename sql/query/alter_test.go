@@ -44,3 +44,146 @@ func TestAlterTable(t *testing.T) {
 	err = db.Exec("ALTER TABLE __genji_tables RENAME TO bar")
 	require.Error(t, err)
 }
+
+func TestAlterTableFields(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (id INTEGER PRIMARY KEY, a DOUBLE);
+		CREATE INDEX idx_foo_a ON foo (a);
+		INSERT INTO foo (id, a) VALUES (1, 10), (2, 20), (3, 20);
+	`)
+	require.NoError(t, err)
+
+	// existing documents are validated
+	err = db.Exec("ALTER TABLE foo ADD FIELD b NOT NULL")
+	require.Error(t, err)
+
+	// and backfilled with the default value
+	err = db.Exec("ALTER TABLE foo ADD FIELD b TEXT NOT NULL DEFAULT 'x'")
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT * FROM foo WHERE id = 1")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 1, "a": 10.0, "b": "x"}`, string(data))
+
+	// values are converted to the new type and indexes are rebuilt
+	err = db.Exec("ALTER TABLE foo ALTER FIELD a INTEGER")
+	require.NoError(t, err)
+
+	d, err = db.QueryDocument("SELECT a FROM foo WHERE a = 20 LIMIT 1")
+	require.NoError(t, err)
+	v, err := d.GetByField("a")
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(20), v)
+
+	err = db.View(func(tx *genji.Tx) error {
+		idx, err := tx.GetIndex("idx_foo_a")
+		require.NoError(t, err)
+		require.Equal(t, document.IntegerValue, idx.Opts.Type)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = db.Exec("ALTER TABLE foo ALTER FIELD a INTEGER UNIQUE")
+	require.Error(t, err)
+
+	err = db.Exec("ALTER TABLE foo ALTER FIELD b BOOL")
+	require.Error(t, err)
+
+	err = db.Exec("ALTER TABLE foo ALTER FIELD c INTEGER")
+	require.Error(t, err)
+
+	// the primary key can't be modified
+	err = db.Exec("ALTER TABLE foo ALTER FIELD id TEXT PRIMARY KEY")
+	require.Error(t, err)
+	err = db.Exec("ALTER TABLE foo DROP FIELD id")
+	require.Error(t, err)
+
+	// dropping a constraint keeps the values
+	err = db.Exec("ALTER TABLE foo DROP FIELD b")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO foo (id, a) VALUES (4, 40)")
+	require.NoError(t, err)
+
+	d, err = db.QueryDocument("SELECT * FROM foo WHERE id = 1")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 1, "a": 10, "b": "x"}`, string(data))
+
+	err = db.Exec("ALTER TABLE foo DROP FIELD b")
+	require.Error(t, err)
+}
+
+func TestAlterTableRenameField(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE foo (id INTEGER PRIMARY KEY, a.b INTEGER UNIQUE, name TEXT);
+		CREATE INDEX idx_foo_name ON foo (name);
+		CREATE TABLE bar (c INTEGER, FOREIGN KEY (c) REFERENCES foo (a.b));
+		INSERT INTO foo (id, a, name) VALUES (1, {b: 10}, 'x'), (2, {b: 20}, 'y');
+		INSERT INTO foo (id) VALUES (3);
+		INSERT INTO bar (c) VALUES (10);
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec("ALTER TABLE foo RENAME FIELD name TO label")
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT * FROM foo WHERE label = 'y'")
+	require.NoError(t, err)
+	data, err := document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 2, "a": {"b": 20}, "label": "y"}`, string(data))
+
+	err = db.View(func(tx *genji.Tx) error {
+		idx, err := tx.GetIndex("idx_foo_name")
+		require.NoError(t, err)
+		require.Equal(t, "label", idx.Opts.Path.String())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// constraints, indexes and foreign keys under the renamed path follow it
+	err = db.Exec("ALTER TABLE foo RENAME FIELD a TO d")
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO foo (id, d) VALUES (4, {b: 10})")
+	require.Error(t, err)
+
+	err = db.Exec("INSERT INTO bar (c) VALUES (20)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO bar (c) VALUES (30)")
+	require.Error(t, err)
+
+	err = db.View(func(tx *genji.Tx) error {
+		tb, err := tx.GetTable("foo")
+		require.NoError(t, err)
+		info, err := tb.Info()
+		require.NoError(t, err)
+		require.Equal(t, "d.b", info.FieldConstraints[1].Path.String())
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the primary key can be renamed too
+	err = db.Exec("ALTER TABLE foo RENAME FIELD id TO k")
+	require.NoError(t, err)
+
+	d, err = db.QueryDocument("SELECT * FROM foo WHERE k = 1")
+	require.NoError(t, err)
+	data, err = document.MarshalJSON(d)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"k": 1, "label": "x", "d": {"b": 10}}`, string(data))
+
+	err = db.Exec("ALTER TABLE foo RENAME FIELD k TO label")
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package query
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -12,7 +13,9 @@ import (
 type CreateTableStmt struct {
 	TableName   string
 	IfNotExists bool
-	Info        database.TableInfo
+	// Temporary tables are dropped when the transaction ends.
+	Temporary bool
+	Info      database.TableInfo
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -34,22 +37,77 @@ func (stmt CreateTableStmt) Run(tx *database.Transaction, args []expr.Param) (Re
 		return res, err
 	}
 
-	err = tx.CreateTable(stmt.TableName, &stmt.Info)
+	if stmt.Temporary {
+		err = tx.CreateTemporaryTable(stmt.TableName, &stmt.Info)
+	} else {
+		err = tx.CreateTable(stmt.TableName, &stmt.Info)
+	}
 	if stmt.IfNotExists && err == database.ErrTableAlreadyExists {
-		err = nil
+		err = stmt.checkCompatibility(tx)
 	}
 
 	return res, err
 }
 
+// checkCompatibility returns an error if the existing table
+// was created with different field constraints or foreign keys.
+func (stmt CreateTableStmt) checkCompatibility(tx *database.Transaction) error {
+	tb, err := tx.GetTable(stmt.TableName)
+	if err != nil {
+		return err
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return err
+	}
+
+	if !info.FieldConstraints.IsEqual(stmt.Info.FieldConstraints) || !sameForeignKeys(info.ForeignKeys, stmt.Info.ForeignKeys) {
+		return fmt.Errorf("%w: %q has a different definition", database.ErrTableAlreadyExists, stmt.TableName)
+	}
+
+	return nil
+}
+
+// sameForeignKeys returns true if a and b contain the same foreign keys, in any order.
+func sameForeignKeys(a, b []database.ForeignKeyConfig) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		var found bool
+		for j := range b {
+			if a[i].IsEqual(&b[j]) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 // CreateIndexStmt is a DSL that allows creating a full CREATE INDEX statement.
 // It is typically created using the CreateIndex function.
 type CreateIndexStmt struct {
-	IndexName   string
-	TableName   string
-	Path        document.Path
+	IndexName string
+	TableName string
+	Path      document.Path
+	// ExtraPaths are the paths following Path in a composite index.
+	ExtraPaths  []document.Path
 	IfNotExists bool
 	Unique      bool
+	// Where is the predicate of a partial index, nil if the index is not partial.
+	Where expr.Expr
+	// Expr is the expression indexed by an expression index, instead of Path.
+	Expr expr.Expr
+	// FullText creates a full-text index of the text stored at Path.
+	FullText bool
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -70,18 +128,38 @@ func (stmt CreateIndexStmt) Run(tx *database.Transaction, args []expr.Param) (Re
 		return res, errors.New("missing index name")
 	}
 
-	if len(stmt.Path) == 0 {
+	if len(stmt.Path) == 0 && stmt.Expr == nil {
 		return res, errors.New("missing path")
 	}
 
-	err := tx.CreateIndex(database.IndexConfig{
-		Unique:    stmt.Unique,
-		IndexName: stmt.IndexName,
-		TableName: stmt.TableName,
-		Path:      stmt.Path,
-	})
+	cfg := database.IndexConfig{
+		Unique:     stmt.Unique,
+		IndexName:  stmt.IndexName,
+		TableName:  stmt.TableName,
+		Path:       stmt.Path,
+		ExtraPaths: stmt.ExtraPaths,
+		FullText:   stmt.FullText,
+	}
+
+	// the text of the expressions is parsed again to evaluate them
+	if stmt.Where != nil {
+		cfg.Where = fmt.Sprint(stmt.Where)
+	}
+	if stmt.Expr != nil {
+		cfg.Expr = fmt.Sprint(stmt.Expr)
+	}
+
+	err := tx.CreateIndex(cfg)
 	if stmt.IfNotExists && err == database.ErrIndexAlreadyExists {
-		err = nil
+		var idx *database.Index
+		idx, err = tx.GetIndex(stmt.IndexName)
+		if err != nil {
+			return res, err
+		}
+
+		if !idx.Opts.IsEqual(&cfg) {
+			err = fmt.Errorf("%w: %q has a different definition", database.ErrIndexAlreadyExists, stmt.IndexName)
+		}
 	}
 
 	return res, err
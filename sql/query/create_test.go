@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/genjidb/genji"
@@ -26,6 +27,15 @@ func TestCreateTable(t *testing.T) {
 		{"Exists", "CREATE TABLE test;CREATE TABLE test", true},
 		{"If not exists", "CREATE TABLE IF NOT EXISTS test", false},
 		{"If not exists, twice", "CREATE TABLE IF NOT EXISTS test;CREATE TABLE IF NOT EXISTS test", false},
+		{"If not exists, same definition", "CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT NOT NULL DEFAULT 'foo');CREATE TABLE IF NOT EXISTS test(b TEXT NOT NULL DEFAULT 'foo', a INTEGER PRIMARY KEY)", false},
+		{"If not exists, different type", "CREATE TABLE test(a INTEGER);CREATE TABLE IF NOT EXISTS test(a TEXT)", true},
+		{"If not exists, different primary key", "CREATE TABLE test(a INTEGER PRIMARY KEY);CREATE TABLE IF NOT EXISTS test(a INTEGER)", true},
+		{"If not exists, different default", "CREATE TABLE test(a INTEGER DEFAULT 1);CREATE TABLE IF NOT EXISTS test(a INTEGER DEFAULT 2)", true},
+		{"If not exists, missing constraints", "CREATE TABLE test(a INTEGER);CREATE TABLE IF NOT EXISTS test", true},
+		{"If not exists, same foreign keys", "CREATE TABLE p(id INTEGER PRIMARY KEY, b TEXT UNIQUE);CREATE TABLE test(a INTEGER, b TEXT, FOREIGN KEY (a) REFERENCES p(id), FOREIGN KEY (b) REFERENCES p(b));CREATE TABLE IF NOT EXISTS test(a INTEGER, b TEXT, FOREIGN KEY (b) REFERENCES p(b), FOREIGN KEY (a) REFERENCES p(id))", false},
+		{"If not exists, missing foreign key", "CREATE TABLE p(id INTEGER PRIMARY KEY);CREATE TABLE test(a INTEGER);CREATE TABLE IF NOT EXISTS test(a INTEGER, FOREIGN KEY (a) REFERENCES p(id))", true},
+		{"If not exists, extra foreign key", "CREATE TABLE p(id INTEGER PRIMARY KEY);CREATE TABLE test(a INTEGER, FOREIGN KEY (a) REFERENCES p(id));CREATE TABLE IF NOT EXISTS test(a INTEGER)", true},
+		{"If not exists, different ON DELETE", "CREATE TABLE p(id INTEGER PRIMARY KEY);CREATE TABLE test(a INTEGER, FOREIGN KEY (a) REFERENCES p(id));CREATE TABLE IF NOT EXISTS test(a INTEGER, FOREIGN KEY (a) REFERENCES p(id) ON DELETE CASCADE)", true},
 		{"With primary key", "CREATE TABLE test(foo TEXT PRIMARY KEY)", false},
 		{"With field constraints", "CREATE TABLE test(foo.a[1][2] TEXT primary key, bar[4][0].bat INTEGER not null, baz not null)", false},
 		{"With no constraints", "CREATE TABLE test(a, b)", false},
@@ -172,6 +182,7 @@ func TestCreateTable(t *testing.T) {
 				{"With default, double type and integer default", "CREATE TABLE test(foo DOUBLE DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.DoubleValue, DefaultValue: document.NewDoubleValue(10)}}, false},
 				{"With default, some type and compatible default", "CREATE TABLE test(foo BOOL DEFAULT 10)", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.BoolValue, DefaultValue: document.NewBoolValue(true)}}, false},
 				{"With default, some type and incompatible default", "CREATE TABLE test(foo BOOL DEFAULT 10.5)", nil, true},
+				{"With default expression", "CREATE TABLE test(foo TEXT DEFAULT now())", database.FieldConstraints{{Path: parsePath(t, "foo"), Type: document.TextValue, DefaultExpr: "now()"}}, false},
 			}
 
 			for _, test := range tests {
@@ -194,7 +205,12 @@ func TestCreateTable(t *testing.T) {
 							return err
 						}
 
-						require.Equal(t, test.constraints, info.FieldConstraints)
+						// compare the stored constraints, default expressions are parsed
+						// when the table is loaded
+						require.Len(t, info.FieldConstraints, len(test.constraints))
+						for i := range test.constraints {
+							require.Equal(t, test.constraints[i].ToDocument(), info.FieldConstraints[i].ToDocument())
+						}
 						return err
 					})
 					require.NoError(t, err)
@@ -204,6 +220,346 @@ func TestCreateTable(t *testing.T) {
 	})
 }
 
+func TestCreateTemporaryTable(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo; INSERT INTO foo (a) VALUES (1)")
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *genji.Tx) error {
+		err := tx.Exec(`
+			CREATE TEMPORARY TABLE staging;
+			INSERT INTO staging (a) VALUES (1), (2), (3);
+		`)
+		require.NoError(t, err)
+
+		d, err := tx.QueryDocument("SELECT COUNT(*) AS n FROM staging WHERE a > 1")
+		require.NoError(t, err)
+		var n int
+		err = document.Scan(d, &n)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+
+		// temporary tables are not in the catalog of the database
+		d, err = tx.QueryDocument("SELECT COUNT(*) FROM __genji_tables")
+		require.NoError(t, err)
+		err = document.Scan(d, &n)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		// and hide the tables of the database until the end of the transaction
+		err = tx.Exec("CREATE TEMPORARY TABLE foo; INSERT INTO foo (a) VALUES (1), (2)")
+		require.NoError(t, err)
+		d, err = tx.QueryDocument("SELECT COUNT(*) FROM foo")
+		require.NoError(t, err)
+		err = document.Scan(d, &n)
+		require.NoError(t, err)
+		require.Equal(t, 2, n)
+		return nil
+	})
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT COUNT(*) FROM foo")
+	require.NoError(t, err)
+	var n int
+	err = document.Scan(d, &n)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	err = db.Exec("SELECT * FROM staging")
+	require.True(t, errors.Is(err, database.ErrTableNotFound))
+
+	// outside of an explicit transaction, every statement runs in its own transaction
+	err = db.Exec("CREATE TEMPORARY TABLE staging; INSERT INTO staging (a) VALUES (1)")
+	require.True(t, errors.Is(err, database.ErrTableNotFound))
+}
+
+func TestCreateTableUnique(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (id INTEGER PRIMARY KEY, email TEXT UNIQUE);
+		INSERT INTO test (id, email) VALUES (1, 'a'), (2, 'b'), (3, NULL), (4, NULL);
+	`)
+	require.NoError(t, err)
+
+	// the violation names the path and the value
+	err = db.Exec("INSERT INTO test (id, email) VALUES (5, 'a')")
+	var cerr *database.ConstraintViolationError
+	require.True(t, errors.As(err, &cerr))
+	require.Equal(t, "UNIQUE", cerr.Constraint)
+	require.Equal(t, "email", cerr.Path.String())
+	require.Equal(t, document.NewTextValue("a"), cerr.Value)
+	require.True(t, errors.Is(err, database.ErrDuplicateDocument))
+
+	err = db.Exec("UPDATE test SET email = 'b' WHERE id = 1")
+	require.True(t, errors.As(err, &cerr))
+
+	// ON CONFLICT sees the constraint
+	err = db.Exec("INSERT INTO test (id, email) VALUES (5, 'a') ON CONFLICT DO NOTHING")
+	require.NoError(t, err)
+
+	// the index is used by queries but can't be dropped
+	err = db.View(func(tx *genji.Tx) error {
+		indexes, err := tx.ListIndexes()
+		require.NoError(t, err)
+		require.Len(t, indexes, 1)
+		require.True(t, indexes[0].Constraint)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	indexes, err := db.QueryDocument("SELECT COUNT(*) AS n FROM test WHERE email = 'b'")
+	require.NoError(t, err)
+	var n int
+	err = document.Scan(indexes, &n)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	err = db.View(func(tx *genji.Tx) error {
+		indexes, err := tx.ListIndexes()
+		require.NoError(t, err)
+		return tx.Exec("DROP INDEX " + indexes[0].IndexName)
+	})
+	require.Error(t, err)
+
+	// adding a unique field checks the existing documents
+	err = db.Exec("ALTER TABLE test ADD FIELD name TEXT UNIQUE")
+	require.NoError(t, err)
+	err = db.Exec("UPDATE test SET name = 'x'")
+	require.Error(t, err)
+
+	err = db.Exec("CREATE TABLE dup; INSERT INTO dup (a) VALUES (1), (1)")
+	require.NoError(t, err)
+	err = db.Exec("ALTER TABLE dup ADD FIELD a UNIQUE")
+	require.Error(t, err)
+}
+
+func TestCreateTableUniqueUntyped(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// like typed fields, untyped unique fields accept any number of null or missing values
+	err = db.Exec(`
+		CREATE TABLE test (a UNIQUE);
+		INSERT INTO test (b) VALUES (1), (2);
+		INSERT INTO test (a) VALUES (NULL), (1);
+		UPDATE test SET a = NULL WHERE a = 1;
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec("INSERT INTO test (a) VALUES (2), (2)")
+	var cerr *database.ConstraintViolationError
+	require.True(t, errors.As(err, &cerr))
+
+	d, err := db.QueryDocument("SELECT COUNT(*) FROM test WHERE a IS NULL")
+	require.NoError(t, err)
+	var n int
+	err = document.Scan(d, &n)
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+
+	// the index is consistent with the table
+	res, err := db.Query("VERIFY INTEGRITY")
+	require.NoError(t, err)
+	defer res.Close()
+
+	n = 0
+	err = res.Iterate(func(d document.Document) error {
+		n++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestCreateTableGenerated(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test (
+			id INTEGER PRIMARY KEY, price DOUBLE, qty INTEGER,
+			total DOUBLE GENERATED ALWAYS AS (price * qty) STORED,
+			lname AS (lower(name)),
+			big AS (total >= 100) STORED
+		);
+		CREATE INDEX idx_total ON test(total);
+		CREATE INDEX idx_lname ON test(lname);
+		INSERT INTO test (id, name, price, qty) VALUES (1, 'Foo', 10, 2), (2, 'BAR', 50, 3);
+		INSERT INTO test (id, name, price, qty, total, lname) VALUES (3, 'baz', 1, 1, 1000, 'x');
+	`)
+	require.NoError(t, err)
+
+	plan := func(q string) string {
+		d, err := db.QueryDocument("EXPLAIN " + q)
+		require.NoError(t, err)
+
+		v, err := d.GetByField("plan")
+		require.NoError(t, err)
+		return v.V.(string)
+	}
+
+	// the values given to generated fields are ignored
+	require.Equal(t,
+		`[{"id": 1, "total": 20, "lname": "foo", "big": false}, {"id": 2, "total": 150, "lname": "bar", "big": true}, {"id": 3, "total": 1, "lname": "baz", "big": false}]`,
+		queryJSON(t, db, "SELECT id, total, lname, big FROM test"))
+
+	// generated fields are kept up to date
+	err = db.Exec("UPDATE test SET qty = 10, name = 'FOO' WHERE id = 1")
+	require.NoError(t, err)
+	require.Equal(t, `[{"id": 1, "total": 100, "lname": "foo", "big": true}]`, queryJSON(t, db, "SELECT id, total, lname, big FROM test WHERE id = 1"))
+
+	// generated fields can be indexed
+	require.Equal(t, "Index(idx_total, fields: id) -> ∏(id)", plan("SELECT id FROM test WHERE total > 50"))
+	require.Equal(t, `[{"id": 1}, {"id": 2}]`, queryJSON(t, db, "SELECT id FROM test WHERE total > 50"))
+	require.Equal(t, "Index(idx_lname, fields: id) -> ∏(id)", plan("SELECT id FROM test WHERE lname = 'bar'"))
+	require.Equal(t, `[{"id": 2}]`, queryJSON(t, db, "SELECT id FROM test WHERE lname = 'bar'"))
+	err = db.Exec("DELETE FROM test WHERE id = 2")
+	require.NoError(t, err)
+	require.Equal(t, `[]`, queryJSON(t, db, "SELECT id FROM test WHERE lname = 'bar'"))
+
+	// adding a generated field computes its value for the existing documents
+	err = db.Exec("ALTER TABLE test ADD FIELD half AS (total / 2) STORED")
+	require.NoError(t, err)
+	require.Equal(t, `[{"id": 1, "half": 50}, {"id": 3, "half": 0.5}]`, queryJSON(t, db, "SELECT id, half FROM test"))
+
+	// virtual fields are not stored
+	err = db.Exec("ALTER TABLE test DROP FIELD lname")
+	require.NoError(t, err)
+	require.Equal(t, `[{"id": 1, "name": "FOO", "price": 10, "qty": 10, "total": 100, "big": true, "half": 50}]`, queryJSON(t, db, "SELECT * FROM test WHERE id = 1"))
+
+	// generated fields can be required
+	err = db.Exec("CREATE TABLE nn (a, b AS (a + 1) NOT NULL)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO nn (a) VALUES (1)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO nn (a) VALUES (NULL)")
+	require.Error(t, err)
+}
+
+func TestCreateTableForeignKey(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE authors (id INTEGER PRIMARY KEY, email TEXT UNIQUE);
+		CREATE TABLE books (
+			id INTEGER PRIMARY KEY, author INTEGER, editor TEXT,
+			FOREIGN KEY (author) REFERENCES authors (id) ON DELETE CASCADE,
+			FOREIGN KEY (editor) REFERENCES authors (email)
+		);
+		INSERT INTO authors (id, email) VALUES (1, 'a'), (2, 'b');
+		INSERT INTO books (id, author, editor) VALUES (1, 1, 'b'), (2, 1, NULL), (3, 2, 'a'), (4, NULL, NULL);
+	`)
+	require.NoError(t, err)
+
+	// referenced documents must exist
+	err = db.Exec("INSERT INTO books (id, author) VALUES (5, 3)")
+	var ferr *database.ForeignKeyViolationError
+	require.True(t, errors.As(err, &ferr))
+	require.False(t, ferr.Referenced)
+	require.Equal(t, "authors", ferr.ReferencedTable)
+
+	err = db.Exec("INSERT INTO books (id, editor) VALUES (5, 'c')")
+	require.True(t, errors.As(err, &ferr))
+
+	err = db.Exec("UPDATE books SET author = 3 WHERE id = 1")
+	require.True(t, errors.As(err, &ferr))
+
+	// referenced values can't be modified
+	err = db.Exec("UPDATE authors SET id = 10 WHERE id = 1")
+	require.True(t, errors.As(err, &ferr))
+	require.True(t, ferr.Referenced)
+	require.Equal(t, "books", ferr.Table)
+
+	err = db.Exec("UPDATE authors SET email = 'c' WHERE id = 1")
+	require.True(t, errors.As(err, &ferr))
+
+	// other fields can
+	err = db.Exec("UPDATE authors SET name = 'foo'")
+	require.NoError(t, err)
+
+	// RESTRICT
+	err = db.Exec("DELETE FROM authors WHERE id = 1")
+	require.True(t, errors.As(err, &ferr))
+	require.Equal(t, "editor", ferr.Path.String())
+
+	err = db.Exec("DROP TABLE authors")
+	require.Error(t, err)
+
+	// CASCADE
+	err = db.Exec("UPDATE books SET editor = NULL; DELETE FROM authors WHERE id = 1")
+	require.NoError(t, err)
+
+	var ids []int
+	res, err := db.Query("SELECT id FROM books")
+	require.NoError(t, err)
+	err = res.Iterate(func(d document.Document) error {
+		var id int
+		err := document.Scan(d, &id)
+		ids = append(ids, id)
+		return err
+	})
+	require.NoError(t, err)
+	require.NoError(t, res.Close())
+	require.Equal(t, []int{3, 4}, ids)
+
+	// references must be primary keys or unique
+	err = db.Exec("CREATE TABLE foo (a, FOREIGN KEY (a) REFERENCES books (author))")
+	require.Error(t, err)
+	err = db.Exec("CREATE TABLE foo (a, FOREIGN KEY (a) REFERENCES bar (id))")
+	require.Error(t, err)
+
+	// tables can reference themselves
+	err = db.Exec(`
+		CREATE TABLE nodes (id INTEGER PRIMARY KEY, parent INTEGER, FOREIGN KEY (parent) REFERENCES nodes (id) ON DELETE CASCADE);
+		INSERT INTO nodes (id, parent) VALUES (1, NULL), (2, 1), (3, 2), (4, 4);
+		DELETE FROM nodes WHERE id = 1;
+		DELETE FROM nodes WHERE id = 4;
+	`)
+	require.NoError(t, err)
+
+	d, err := db.QueryDocument("SELECT COUNT(*) FROM nodes")
+	require.NoError(t, err)
+	var n int
+	err = document.Scan(d, &n)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	// foreign keys follow renamed tables
+	err = db.Exec("ALTER TABLE authors RENAME TO writers; INSERT INTO books (id, author) VALUES (6, 2)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO books (id, author) VALUES (7, 1)")
+	require.True(t, errors.As(err, &ferr))
+	require.Equal(t, "writers", ferr.ReferencedTable)
+
+	// foreign keys created during a transaction are enforced by the following statements
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	err = tx.Exec(`
+		CREATE TABLE tags (id INTEGER PRIMARY KEY);
+		INSERT INTO tags (id) VALUES (1), (2);
+		DELETE FROM tags WHERE id = 2;
+		CREATE TABLE labels (tag INTEGER, FOREIGN KEY (tag) REFERENCES tags (id));
+		INSERT INTO labels (tag) VALUES (1);
+	`)
+	require.NoError(t, err)
+	err = tx.Exec("DELETE FROM tags WHERE id = 1")
+	require.True(t, errors.As(err, &ferr))
+	require.Equal(t, "labels", ferr.Table)
+}
+
 func TestCreateIndex(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -213,8 +569,12 @@ func TestCreateIndex(t *testing.T) {
 		{"Basic", "CREATE INDEX idx ON test (foo)", false},
 		{"If not exists", "CREATE INDEX IF NOT EXISTS idx ON test (foo.bar)", false},
 		{"Unique", "CREATE UNIQUE INDEX IF NOT EXISTS idx ON test (foo[1])", false},
+		{"If not exists, same definition", "CREATE INDEX idx ON test (foo); CREATE INDEX IF NOT EXISTS idx ON test (foo)", false},
+		{"If not exists, different path", "CREATE INDEX idx ON test (foo); CREATE INDEX IF NOT EXISTS idx ON test (bar)", true},
+		{"If not exists, different uniqueness", "CREATE INDEX idx ON test (foo); CREATE UNIQUE INDEX IF NOT EXISTS idx ON test (foo)", true},
 		{"No fields", "CREATE INDEX idx ON test", true},
-		{"More than 1 field", "CREATE INDEX idx ON test (foo, bar)", true},
+		{"Composite", "CREATE INDEX idx ON test (foo, bar.baz)", false},
+		{"If not exists, different paths", "CREATE INDEX idx ON test (foo, bar); CREATE INDEX IF NOT EXISTS idx ON test (foo, baz)", true},
 	}
 
 	for _, test := range tests {
@@ -235,3 +595,73 @@ func TestCreateIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateTableTTL(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE sessions (id TEXT PRIMARY KEY, user_id INTEGER, token TEXT UNIQUE, expires_at TIMESTAMP TTL);
+		CREATE INDEX idx_user ON sessions(user_id);
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users (id, name) VALUES (1, 'foo'), (2, 'bar');
+		INSERT INTO sessions (id, user_id, token, expires_at) VALUES
+			('a', 1, 'ta', '2000-01-01T00:00:00Z'),
+			('b', 1, 'tb', '2999-01-01T00:00:00Z'),
+			('c', 2, 'tc', '2000-01-01T00:00:00Z'),
+			('d', 2, 'td', NULL);
+	`)
+	require.NoError(t, err)
+
+	// expired documents are skipped by table scans, index scans and joins
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT id FROM sessions", `[{"id": "b"}, {"id": "d"}]`},
+		{"SELECT id FROM sessions WHERE user_id = 1", `[{"id": "b"}]`},
+		{"SELECT id FROM sessions WHERE user_id = 2", `[{"id": "d"}]`},
+		{"SELECT id FROM sessions WHERE id = 'a'", `[]`},
+		{"SELECT COUNT(*) FROM sessions", `[{"COUNT(*)": 2}]`},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+	}
+
+	require.Equal(t,
+		`[{"sessions.id": "b", "users.name": "foo"}, {"sessions.id": "d", "users.name": "bar"}]`,
+		queryJSON(t, db, "SELECT sessions.id, users.name FROM users JOIN sessions ON users.id = sessions.user_id"))
+
+	// expired documents don't conflict with new ones
+	err = db.Exec("INSERT INTO sessions (id, user_id, token) VALUES ('a', 3, 'ta2'), ('e', 3, 'tc')")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO sessions (id, token) VALUES ('b', 'x')")
+	require.Equal(t, database.ErrDuplicateDocument, err)
+	require.Equal(t, `[{"id": "a", "token": "ta2"}, {"id": "b", "token": "tb"}, {"id": "d", "token": "td"}, {"id": "e", "token": "tc"}]`, queryJSON(t, db, "SELECT id, token FROM sessions"))
+
+	// expired documents are purged on demand
+	err = db.Exec("UPDATE sessions SET expires_at = '2000-01-01T00:00:00Z' WHERE id IN ('a', 'b')")
+	require.NoError(t, err)
+	n, err := db.PurgeExpired()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, n)
+	n, err = db.PurgeExpired()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, n)
+
+	err = db.View(func(tx *genji.Tx) error {
+		tb, err := tx.GetTable("sessions")
+		require.NoError(t, err)
+		count, err := document.NewStream(tb).Count()
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// only one TTL field is allowed
+	err = db.Exec("ALTER TABLE sessions ADD FIELD other TIMESTAMP TTL")
+	require.Error(t, err)
+}
@@ -60,3 +60,101 @@ func TestDeleteStmt(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteStmtRowsAffected(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		INSERT INTO test (a) VALUES (1), (2), (3);
+	`)
+	require.NoError(t, err)
+
+	t.Run("No match", func(t *testing.T) {
+		res, err := db.Query("DELETE FROM test WHERE a > 10")
+		require.NoError(t, err)
+		defer res.Close()
+		require.EqualValues(t, 0, res.RowsAffected)
+	})
+
+	t.Run("With match", func(t *testing.T) {
+		res, err := db.Query("DELETE FROM test WHERE a >= 2")
+		require.NoError(t, err)
+		defer res.Close()
+		require.EqualValues(t, 2, res.RowsAffected)
+	})
+
+	t.Run("Empty table", func(t *testing.T) {
+		err := db.Exec("DELETE FROM test")
+		require.NoError(t, err)
+
+		res, err := db.Query("DELETE FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+		require.EqualValues(t, 0, res.RowsAffected)
+	})
+}
+
+func TestDeleteStmtReturning(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test;
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		expected string
+		affected int64
+	}{
+		{"DELETE FROM test WHERE a >= 2 RETURNING pk(), *", `[{"pk()": 2, "a": 2, "b": "b"}, {"pk()": 3, "a": 3, "b": "c"}]`, 2},
+		{"DELETE FROM test WHERE a > 10 RETURNING *", `[]`, 0},
+	}
+
+	for _, test := range tests {
+		out, res := queryResult(t, db, test.query)
+		require.Equal(t, test.expected, out, test.query)
+		require.EqualValues(t, test.affected, res.RowsAffected, test.query)
+	}
+}
+
+func TestDeleteStmtOrderByLimit(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE test(id INTEGER PRIMARY KEY, a INTEGER)")
+	require.NoError(t, err)
+	// more documents than deleted in a single batch
+	for i := 1; i <= 300; i++ {
+		err = db.Exec("INSERT INTO test (id, a) VALUES (?, ?)", i, 1000-i)
+		require.NoError(t, err)
+	}
+
+	// the documents with the smallest values of a are deleted first
+	out, res := queryResult(t, db, "DELETE FROM test ORDER BY a LIMIT 3 RETURNING id")
+	require.Equal(t, `[{"id": 300}, {"id": 299}, {"id": 298}]`, out)
+	require.EqualValues(t, 3, res.RowsAffected)
+
+	_, res = queryResult(t, db, "DELETE FROM test WHERE id > 100 ORDER BY a DESC LIMIT 150")
+	require.EqualValues(t, 150, res.RowsAffected)
+	require.Equal(t, `[{"n": 47, "lo": 251, "hi": 297}]`, queryJSON(t, db, "SELECT COUNT(*) AS n, MIN(id) AS lo, MAX(id) AS hi FROM test WHERE id > 100"))
+
+	// without ORDER BY, LIMIT only bounds the number of deleted documents
+	_, res = queryResult(t, db, "DELETE FROM test LIMIT ?", 120)
+	require.EqualValues(t, 120, res.RowsAffected)
+	require.Equal(t, `[{"n": 27}]`, queryJSON(t, db, "SELECT COUNT(*) AS n FROM test"))
+
+	out, res = queryResult(t, db, "DELETE FROM test ORDER BY a DESC LIMIT 2 RETURNING id")
+	require.Equal(t, `[{"id": 271}, {"id": 272}]`, out)
+	require.EqualValues(t, 2, res.RowsAffected)
+
+	err = db.Exec("DELETE FROM test ORDER BY a")
+	require.Error(t, err)
+}
@@ -2,15 +2,18 @@ package query
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/sql/query/expr"
 )
 
 // DropTableStmt is a DSL that allows creating a DROP TABLE query.
+// If MaterializedView is true, it creates a DROP MATERIALIZED VIEW query instead.
 type DropTableStmt struct {
-	TableName string
-	IfExists  bool
+	TableName        string
+	IfExists         bool
+	MaterializedView bool
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -27,7 +30,10 @@ func (stmt DropTableStmt) Run(tx *database.Transaction, args []expr.Param) (Resu
 		return res, errors.New("missing table name")
 	}
 
-	err := tx.DropTable(stmt.TableName)
+	err := stmt.checkKind(tx)
+	if err == nil {
+		err = tx.DropTable(stmt.TableName)
+	}
 	if errors.Is(err, database.ErrTableNotFound) && stmt.IfExists {
 		err = nil
 	}
@@ -35,6 +41,29 @@ func (stmt DropTableStmt) Run(tx *database.Transaction, args []expr.Param) (Resu
 	return res, err
 }
 
+// checkKind returns an error if materialized views are dropped with DROP TABLE
+// or tables with DROP MATERIALIZED VIEW.
+func (stmt DropTableStmt) checkKind(tx *database.Transaction) error {
+	tb, err := tx.GetTable(stmt.TableName)
+	if err != nil {
+		return err
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case stmt.MaterializedView && info.ViewQuery == "":
+		return fmt.Errorf("%q is not a materialized view", stmt.TableName)
+	case !stmt.MaterializedView && info.ViewQuery != "":
+		return fmt.Errorf("%q is a materialized view, use DROP MATERIALIZED VIEW", stmt.TableName)
+	}
+
+	return nil
+}
+
 // DropIndexStmt is a DSL that allows creating a DROP INDEX query.
 type DropIndexStmt struct {
 	IndexName string
@@ -55,7 +84,13 @@ func (stmt DropIndexStmt) Run(tx *database.Transaction, args []expr.Param) (Resu
 		return res, errors.New("missing index name")
 	}
 
-	err := tx.DropIndex(stmt.IndexName)
+	idx, err := tx.GetIndex(stmt.IndexName)
+	if err == nil && idx.Opts.Constraint {
+		return res, fmt.Errorf("cannot drop index %q: it enforces the UNIQUE constraint of field %q", stmt.IndexName, idx.Opts.Path)
+	}
+	if err == nil {
+		err = tx.DropIndex(stmt.IndexName)
+	}
 	if err == database.ErrIndexNotFound && stmt.IfExists {
 		err = nil
 	}
@@ -19,6 +19,29 @@ func IsArithmeticOperator(op Operator) bool {
 	return false
 }
 
+// checkIntegerOverflow returns a function that forwards the result of an arithmetic
+// operation on a and b, unless the operation overflowed and the database
+// uses strict arithmetic, in which case it returns document.ErrIntegerOverflow.
+// Operations on two integers only return a double when they overflow.
+func checkIntegerOverflow(env *Environment, a, b document.Value) func(document.Value, error) (document.Value, error) {
+	return func(res document.Value, err error) (document.Value, error) {
+		if err != nil || res.Type != document.DoubleValue {
+			return res, err
+		}
+
+		if a.Type != document.IntegerValue || b.Type != document.IntegerValue {
+			return res, nil
+		}
+
+		tx := env.GetTx()
+		if tx != nil && tx.DB().StrictArithmetic {
+			return nullLitteral, document.ErrIntegerOverflow
+		}
+
+		return res, nil
+	}
+}
+
 type addOp struct {
 	*simpleOperator
 }
@@ -34,7 +57,7 @@ func (op addOp) Eval(env *Environment) (document.Value, error) {
 		return nullLitteral, err
 	}
 
-	return a.Add(b)
+	return checkIntegerOverflow(env, a, b)(a.Add(b))
 }
 
 func (op addOp) String() string {
@@ -56,7 +79,7 @@ func (op subOp) Eval(env *Environment) (document.Value, error) {
 		return nullLitteral, err
 	}
 
-	return a.Sub(b)
+	return checkIntegerOverflow(env, a, b)(a.Sub(b))
 }
 
 func (op subOp) String() string {
@@ -78,7 +101,7 @@ func (op mulOp) Eval(env *Environment) (document.Value, error) {
 		return nullLitteral, err
 	}
 
-	return a.Mul(b)
+	return checkIntegerOverflow(env, a, b)(a.Mul(b))
 }
 
 func (op mulOp) String() string {
@@ -100,7 +123,7 @@ func (op divOp) Eval(env *Environment) (document.Value, error) {
 		return nullLitteral, err
 	}
 
-	return a.Div(b)
+	return checkIntegerOverflow(env, a, b)(a.Div(b))
 }
 
 func (op divOp) String() string {
@@ -1,10 +1,14 @@
 package expr_test
 
 import (
+	"math"
 	"testing"
 
+	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
 )
 
 func TestArithmeticExpr(t *testing.T) {
@@ -74,3 +78,57 @@ func TestArithmeticExprNodocument(t *testing.T) {
 		})
 	}
 }
+
+func TestArithmeticExprOverflow(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	d := document.NewFieldBuffer().
+		Add("max", document.NewIntegerValue(math.MaxInt64)).
+		Add("min", document.NewIntegerValue(math.MinInt64))
+	env := expr.NewEnvironment(document.NewDocumentValue(d))
+	env.Tx = tx.Transaction
+
+	tests := []struct {
+		expr   string
+		res    document.Value
+		strict bool
+	}{
+		{"max + 0", document.NewIntegerValue(math.MaxInt64), false},
+		{"max - 1 + 1", document.NewIntegerValue(math.MaxInt64), false},
+		{"min + 1 - 1", document.NewIntegerValue(math.MinInt64), false},
+		{"max + min", document.NewIntegerValue(-1), false},
+		{"max * -1", document.NewIntegerValue(-math.MaxInt64), false},
+		{"min / 2 * 2", document.NewIntegerValue(math.MinInt64), false},
+		{"max + 1", document.NewDoubleValue(math.MaxInt64 + 1), true},
+		{"min - 1", document.NewDoubleValue(math.MinInt64 - 1), true},
+		{"0 - min", document.NewDoubleValue(-math.MinInt64), true},
+		{"max * 2", document.NewDoubleValue(math.MaxInt64 * 2), true},
+		{"min * -1", document.NewDoubleValue(-math.MinInt64), true},
+		{"min / -1", document.NewDoubleValue(-math.MinInt64), true},
+		{"max + 1.0", document.NewDoubleValue(math.MaxInt64 + 1), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, env, test.res, false)
+
+			db.DB.StrictArithmetic = true
+			defer func() { db.DB.StrictArithmetic = false }()
+
+			if test.strict {
+				e, err := parser.ParseExpr(test.expr)
+				require.NoError(t, err)
+				_, err = e.Eval(env)
+				require.Equal(t, document.ErrIntegerOverflow, err)
+			} else {
+				testExpr(t, test.expr, env, test.res, false)
+			}
+		})
+	}
+}
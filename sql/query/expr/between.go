@@ -0,0 +1,159 @@
+package expr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+type betweenOp struct {
+	*simpleOperator
+}
+
+// Between creates an expression that evaluates to the result of a BETWEEN low AND high.
+// The bounds are stored as a list of expressions in the right operand,
+// so that they can be precalculated and used to read an index.
+func Between(a, low, high Expr) Expr {
+	return betweenOp{&simpleOperator{a, LiteralExprList{low, high}, scanner.BETWEEN}}
+}
+
+// Eval returns true if a is greater than or equal to low and lesser than or equal to high.
+// Like a >= low AND a <= high, it returns NULL if the result depends on a NULL value.
+func (op betweenOp) Eval(env *Environment) (document.Value, error) {
+	v, bounds, err := op.simpleOperator.eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	low, high, err := betweenBounds(bounds)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	res := trueLitteral
+	for _, c := range []struct {
+		bound document.Value
+		cmp   func(document.Value) (bool, error)
+	}{
+		{low, v.IsGreaterThanOrEqual},
+		{high, v.IsLesserThanOrEqual},
+	} {
+		if c.bound.Type == document.NullValue {
+			res = nullLitteral
+			continue
+		}
+
+		ok, err := c.cmp(c.bound)
+		if err != nil {
+			return nullLitteral, err
+		}
+		if !ok {
+			return falseLitteral, nil
+		}
+	}
+
+	return res, nil
+}
+
+// IterateIndex reads the documents whose indexed value is between the bounds,
+// in increasing order. Bounds of different types never select any document.
+func (op betweenOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	low, high, err := betweenBounds(v)
+	if err != nil {
+		return err
+	}
+
+	if low.Type == document.NullValue || high.Type == document.NullValue || low.Type != high.Type {
+		return nil
+	}
+
+	enc, err := idx.EncodeValue(high)
+	if err != nil {
+		return err
+	}
+
+	err = idx.AscendGreaterOrEqual(low, func(val, key []byte, isEqual bool) error {
+		if bytes.Compare(enc, val) < 0 {
+			return errStop
+		}
+
+		d, err := tb.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		return fn(d)
+	})
+
+	if err != nil && err != errStop {
+		return err
+	}
+
+	return nil
+}
+
+// betweenBounds returns the low and high bounds of a BETWEEN operator
+// from the evaluation of its right operand.
+func betweenBounds(v document.Value) (low, high document.Value, err error) {
+	if v.Type != document.ArrayValue {
+		return low, high, errors.New("BETWEEN operator takes two bounds")
+	}
+
+	a := v.V.(document.Array)
+	low, err = a.GetByIndex(0)
+	if err != nil {
+		return
+	}
+	high, err = a.GetByIndex(1)
+	return
+}
+
+func (op betweenOp) String() string {
+	return fmt.Sprintf("%v BETWEEN %s", op.a, boundsString(op.b))
+}
+
+// notBetweenOp doesn't embed betweenOp, it must not be used to read an index.
+type notBetweenOp struct {
+	*simpleOperator
+}
+
+// NotBetween creates an expression that evaluates to the result of a NOT BETWEEN low AND high.
+func NotBetween(a, low, high Expr) Expr {
+	return notBetweenOp{&simpleOperator{a, LiteralExprList{low, high}, scanner.BETWEEN}}
+}
+
+func (op notBetweenOp) Eval(env *Environment) (document.Value, error) {
+	return invertBoolResult(betweenOp{op.simpleOperator}.Eval)(env)
+}
+
+func (op notBetweenOp) String() string {
+	return fmt.Sprintf("%v NOT BETWEEN %s", op.a, boundsString(op.b))
+}
+
+// boundsString returns the bounds of a BETWEEN operator as "low AND high",
+// whether they have been precalculated or not.
+func boundsString(e Expr) string {
+	switch t := e.(type) {
+	case LiteralExprList:
+		if len(t) == 2 {
+			return fmt.Sprintf("%v AND %v", t[0], t[1])
+		}
+	case LiteralValue:
+		if t.Type == document.ArrayValue {
+			low, high, err := betweenBounds(document.Value(t))
+			if err == nil {
+				return fmt.Sprintf("%v AND %v", LiteralValue(low), LiteralValue(high))
+			}
+		}
+	}
+
+	return fmt.Sprintf("%v", e)
+}
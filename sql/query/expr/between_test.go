@@ -0,0 +1,33 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+)
+
+func TestBetweenExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"a BETWEEN 1 AND 2", document.NewBoolValue(true), false},
+		{"a BETWEEN 0.5 AND 1.0", document.NewBoolValue(true), false},
+		{"a BETWEEN 2 AND 3", document.NewBoolValue(false), false},
+		{"a BETWEEN 2 AND 0", document.NewBoolValue(false), false},
+		{"a BETWEEN NULL AND 2", nullLitteral, false},
+		{"a BETWEEN NULL AND 0", document.NewBoolValue(false), false},
+		{"NULL BETWEEN 1 AND 2", nullLitteral, false},
+		{"'b' BETWEEN 'a' AND 'c'", document.NewBoolValue(true), false},
+		{"a NOT BETWEEN 2 AND 3", document.NewBoolValue(true), false},
+		{"a NOT BETWEEN 0 AND 3", document.NewBoolValue(false), false},
+		{"a NOT BETWEEN NULL AND 2", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
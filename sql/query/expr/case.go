@@ -0,0 +1,213 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+)
+
+// WhenClause represents a WHEN ... THEN ... branch of a CASE expression.
+type WhenClause struct {
+	When Expr
+	Then Expr
+}
+
+// CaseExpr represents a CASE expression.
+// If Operand is nil, it is a searched CASE and the first branch whose
+// condition is truthy is selected. Otherwise, it is a simple CASE and the
+// first branch whose value is equal to the operand is selected.
+// If no branch is selected, the Else expression is evaluated, or NULL is returned
+// if there is none.
+type CaseExpr struct {
+	Operand Expr
+	Whens   []WhenClause
+	Else    Expr
+}
+
+// Eval evaluates the branch selected by the current environment.
+func (c CaseExpr) Eval(env *Environment) (document.Value, error) {
+	var operand document.Value
+	var err error
+
+	if c.Operand != nil {
+		operand, err = c.Operand.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+	}
+
+	for _, w := range c.Whens {
+		v, err := w.When.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		var ok bool
+		if c.Operand != nil {
+			// NULL is never equal to any value
+			if operand.Type != document.NullValue && v.Type != document.NullValue {
+				ok, err = operand.IsEqual(v)
+			}
+		} else {
+			ok, err = v.IsTruthy()
+		}
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		if ok {
+			return w.Then.Eval(env)
+		}
+	}
+
+	if c.Else != nil {
+		return c.Else.Eval(env)
+	}
+
+	return nullLitteral, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c CaseExpr) IsEqual(other Expr) bool {
+	o, ok := other.(CaseExpr)
+	if !ok {
+		return false
+	}
+
+	if len(c.Whens) != len(o.Whens) {
+		return false
+	}
+
+	if !equalOrNil(c.Operand, o.Operand) || !equalOrNil(c.Else, o.Else) {
+		return false
+	}
+
+	for i := range c.Whens {
+		if !Equal(c.Whens[i].When, o.Whens[i].When) || !Equal(c.Whens[i].Then, o.Whens[i].Then) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalOrNil(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return Equal(a, b)
+}
+
+func (c CaseExpr) String() string {
+	var b strings.Builder
+
+	b.WriteString("CASE")
+	if c.Operand != nil {
+		fmt.Fprintf(&b, " %v", c.Operand)
+	}
+	for _, w := range c.Whens {
+		fmt.Fprintf(&b, " WHEN %v THEN %v", w.When, w.Then)
+	}
+	if c.Else != nil {
+		fmt.Fprintf(&b, " ELSE %v", c.Else)
+	}
+	b.WriteString(" END")
+
+	return b.String()
+}
+
+// CoalesceFunc is the coalesce() function. It returns the first of its
+// arguments that is not NULL, or NULL if all of them are NULL.
+// Missing fields evaluate to NULL and are skipped as well.
+// Arguments are evaluated from left to right and only until a value is found.
+type CoalesceFunc []Expr
+
+// Eval returns the first non-NULL value.
+func (c CoalesceFunc) Eval(env *Environment) (document.Value, error) {
+	for _, e := range c {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+		if v.Type != document.NullValue {
+			return v, nil
+		}
+	}
+
+	return nullLitteral, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c CoalesceFunc) IsEqual(other Expr) bool {
+	o, ok := other.(CoalesceFunc)
+	if !ok {
+		return false
+	}
+
+	return LiteralExprList(c).IsEqual(LiteralExprList(o))
+}
+
+func (c CoalesceFunc) String() string {
+	var b strings.Builder
+
+	b.WriteString("coalesce(")
+	for i, e := range c {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%v", e)
+	}
+	b.WriteRune(')')
+
+	return b.String()
+}
+
+// NullIfFunc is the nullif() function. It returns NULL if both of its
+// arguments are equal, and the first one otherwise.
+// As NULL is never equal to any value, nullif(NULL, x) returns NULL
+// and nullif(x, NULL) returns x.
+type NullIfFunc struct {
+	Expr  Expr
+	Other Expr
+}
+
+// Eval returns NULL or the value of the first argument.
+func (n NullIfFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := n.Expr.Eval(env)
+	if err != nil || v.Type == document.NullValue {
+		return nullLitteral, err
+	}
+
+	other, err := n.Other.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if other.Type == document.NullValue {
+		return v, nil
+	}
+
+	ok, err := v.IsEqual(other)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if ok {
+		return nullLitteral, nil
+	}
+
+	return v, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (n NullIfFunc) IsEqual(other Expr) bool {
+	o, ok := other.(NullIfFunc)
+	return ok && Equal(n.Expr, o.Expr) && Equal(n.Other, o.Other)
+}
+
+func (n NullIfFunc) String() string {
+	return fmt.Sprintf("nullif(%v, %v)", n.Expr, n.Other)
+}
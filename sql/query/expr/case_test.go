@@ -0,0 +1,80 @@
+package expr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"CASE WHEN a = 1 THEN 'one' ELSE 'other' END", document.NewTextValue("one"), false},
+		{"CASE WHEN a > 1 THEN 'big' WHEN a > 0 THEN 'small' END", document.NewTextValue("small"), false},
+		{"CASE WHEN a > 1 THEN 'big' END", nullLitteral, false},
+		{"CASE WHEN NULL THEN 1 ELSE 2 END", document.NewIntegerValue(2), false},
+		{"CASE a WHEN 2 THEN 'two' WHEN 1 THEN 'one' END", document.NewTextValue("one"), false},
+		{"CASE a WHEN 1.0 THEN 'one' END", document.NewTextValue("one"), false},
+		{"CASE NULL WHEN NULL THEN 1 ELSE 2 END", document.NewIntegerValue(2), false},
+		{"CASE b.`foo bar`[0] + 1 WHEN a + 1 THEN a * 10 END", document.NewIntegerValue(10), false},
+		{"CASE WHEN a = 1 THEN CAST('a' AS INTEGER) END", document.Value{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
+func TestCoalesceAndNullIf(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"coalesce(a)", document.NewIntegerValue(1), false},
+		{"coalesce(NULL, a, 2)", document.NewIntegerValue(1), false},
+		{"coalesce(notFound, b.foo, 'default')", document.NewTextValue("default"), false},
+		{"coalesce(notFound, NULL)", nullLitteral, false},
+		{"coalesce(c[5], c[0])", document.NewIntegerValue(1), false},
+		{"coalesce(a, CAST('a' AS INTEGER))", document.NewIntegerValue(1), false},
+		{"coalesce(NULL, CAST('a' AS INTEGER))", document.Value{}, true},
+		{"nullif(a, 1)", nullLitteral, false},
+		{"nullif(a, 1.0)", nullLitteral, false},
+		{"nullif(a, 2)", document.NewIntegerValue(1), false},
+		{"nullif(a, 'a')", document.NewIntegerValue(1), false},
+		{"nullif(a, NULL)", document.NewIntegerValue(1), false},
+		{"nullif(NULL, NULL)", nullLitteral, false},
+		{"nullif(notFound, 1)", nullLitteral, false},
+		{"coalesce(nullif(a, 1), 10)", document.NewIntegerValue(10), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+
+			// the string representation can be parsed back
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+			pe, _, err := parser.NewParser(strings.NewReader(fmt.Sprintf("%v", e))).ParseExpr()
+			require.NoError(t, err)
+			require.True(t, expr.Equal(e, pe))
+		})
+	}
+
+	t.Run("Arguments", func(t *testing.T) {
+		for _, s := range []string{"coalesce()", "nullif(a)", "nullif(a, b, c)"} {
+			_, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+			require.Error(t, err, s)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
@@ -14,11 +15,102 @@ import (
 // A cmpOp is a comparison operator.
 type cmpOp struct {
 	*simpleOperator
+
+	// row is true if the left operand is a row value.
+	row bool
 }
 
 // newCmpOp creates a comparison operator.
 func newCmpOp(a, b Expr, t scanner.Token) *cmpOp {
-	return &cmpOp{&simpleOperator{a, b, t}}
+	return &cmpOp{simpleOperator: &simpleOperator{a, b, t}, row: isRowValue(a)}
+}
+
+// isRowValue returns true if e is a list of expressions, such as (a, b).
+// When used as the left operand of a comparison or of the IN operator,
+// a list of expressions is compared as a row value: element by element,
+// following the SQL rules regarding NULL.
+func isRowValue(e Expr) bool {
+	_, ok := e.(LiteralExprList)
+	return ok
+}
+
+// rowValue is a list of values compared as a row value.
+// It is only used to print the operands of row value comparisons,
+// which are parsed as lists of expressions.
+type rowValue []interface{}
+
+// String returns the parenthesized form of the row value, such as (a, b).
+func (r rowValue) String() string {
+	var b strings.Builder
+
+	b.WriteRune('(')
+	for i, e := range r {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%v", e)
+	}
+	b.WriteRune(')')
+
+	return b.String()
+}
+
+// operands returns the operands of a comparison, with the lists of expressions
+// of row value comparisons represented as row values.
+func (op *cmpOp) operands() (a, b interface{}) {
+	if !op.row {
+		return op.a, op.b
+	}
+
+	return rowOperand(op.a), rowOperand(op.b)
+}
+
+// rowOperand returns e as a row value if it is a list of expressions
+// or an array, as lists of expressions are evaluated to arrays when precalculated.
+func rowOperand(e Expr) interface{} {
+	switch t := e.(type) {
+	case LiteralExprList:
+		r := make(rowValue, len(t))
+		for i := range t {
+			r[i] = t[i]
+		}
+		return r
+	case LiteralValue:
+		if t.Type != document.ArrayValue {
+			return e
+		}
+
+		var r rowValue
+		err := t.V.(document.Array).Iterate(func(i int, v document.Value) error {
+			r = append(r, LiteralValue(v))
+			return nil
+		})
+		if err != nil {
+			return e
+		}
+		return r
+	}
+
+	return e
+}
+
+// rowList returns the operands of the IN operator. If the left operand is a row value,
+// the right operand is printed as a list of row values, such as ((1, 2), (3, 4)).
+func rowList(row bool, a, b Expr) (interface{}, interface{}) {
+	if !row {
+		return a, b
+	}
+
+	rows, ok := rowOperand(b).(rowValue)
+	if !ok {
+		return rowOperand(a), b
+	}
+
+	for i := range rows {
+		rows[i] = rowOperand(rows[i].(Expr))
+	}
+
+	return rowOperand(a), rows
 }
 
 type eqOp struct {
@@ -77,7 +169,8 @@ func (op eqOp) IteratePK(tb *database.Table, v document.Value, pkType document.V
 }
 
 func (op eqOp) String() string {
-	return fmt.Sprintf("%v = %v", op.a, op.b)
+	a, b := op.operands()
+	return fmt.Sprintf("%v = %v", a, b)
 }
 
 type neqOp struct {
@@ -90,7 +183,8 @@ func Neq(a, b Expr) Expr {
 }
 
 func (op neqOp) String() string {
-	return fmt.Sprintf("%v != %v", op.a, op.b)
+	a, b := op.operands()
+	return fmt.Sprintf("%v != %v", a, b)
 }
 
 type gtOp struct {
@@ -162,7 +256,8 @@ func (op gtOp) IteratePK(tb *database.Table, v document.Value, pkType document.V
 }
 
 func (op gtOp) String() string {
-	return fmt.Sprintf("%v > %v", op.a, op.b)
+	a, b := op.operands()
+	return fmt.Sprintf("%v > %v", a, b)
 }
 
 type gteOp struct {
@@ -227,7 +322,8 @@ func (op gteOp) IteratePK(tb *database.Table, v document.Value, pkType document.
 }
 
 func (op gteOp) String() string {
-	return fmt.Sprintf("%v >= %v", op.a, op.b)
+	a, b := op.operands()
+	return fmt.Sprintf("%v >= %v", a, b)
 }
 
 type ltOp struct {
@@ -304,7 +400,8 @@ func (op ltOp) IteratePK(tb *database.Table, v document.Value, pkType document.V
 }
 
 func (op ltOp) String() string {
-	return fmt.Sprintf("%v < %v", op.a, op.b)
+	a, b := op.operands()
+	return fmt.Sprintf("%v < %v", a, b)
 }
 
 type lteOp struct {
@@ -381,7 +478,8 @@ func (op lteOp) IteratePK(tb *database.Table, v document.Value, pkType document.
 }
 
 func (op lteOp) String() string {
-	return fmt.Sprintf("%v <= %v", op.a, op.b)
+	a, b := op.operands()
+	return fmt.Sprintf("%v <= %v", a, b)
 }
 
 // Eval compares a and b together using the operator specified when constructing the CmpOp
@@ -397,6 +495,14 @@ func (op cmpOp) Eval(env *Environment) (document.Value, error) {
 		return nullLitteral, nil
 	}
 
+	if op.row {
+		if v2.Type != document.ArrayValue {
+			return falseLitteral, nil
+		}
+
+		return op.compareRows(v1.V.(document.Array), v2.V.(document.Array))
+	}
+
 	ok, err := op.compare(v1, v2)
 	if ok {
 		return trueLitteral, err
@@ -405,6 +511,81 @@ func (op cmpOp) Eval(env *Environment) (document.Value, error) {
 	return falseLitteral, err
 }
 
+// compareRows compares two row values element by element.
+// Both rows must have the same number of elements.
+// For = and !=, the result is NULL if none of the pairs of elements
+// are known to be different but at least one element is NULL.
+// For >, >=, < and <=, elements are compared from left to right until
+// a pair of elements differs, and that pair determines the result.
+// If a NULL element is reached before such a pair is found, the result is NULL.
+func (op cmpOp) compareRows(l, r document.Array) (document.Value, error) {
+	lv, err := rowElements(l)
+	if err != nil {
+		return nullLitteral, err
+	}
+	rv, err := rowElements(r)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if len(lv) != len(rv) {
+		return nullLitteral, fmt.Errorf("cannot compare row values of different sizes: %d and %d", len(lv), len(rv))
+	}
+
+	var hasNull bool
+	for i := range lv {
+		if lv[i].Type == document.NullValue || rv[i].Type == document.NullValue {
+			if op.Tok != scanner.EQ && op.Tok != scanner.NEQ {
+				return nullLitteral, nil
+			}
+
+			hasNull = true
+			continue
+		}
+
+		ok, err := lv[i].IsEqual(rv[i])
+		if err != nil {
+			return nullLitteral, err
+		}
+		if ok {
+			continue
+		}
+
+		switch op.Tok {
+		case scanner.EQ:
+			return falseLitteral, nil
+		case scanner.NEQ:
+			return trueLitteral, nil
+		}
+
+		ok, err = op.compare(lv[i], rv[i])
+		if ok {
+			return trueLitteral, err
+		}
+		return falseLitteral, err
+	}
+
+	if hasNull {
+		return nullLitteral, nil
+	}
+
+	switch op.Tok {
+	case scanner.EQ, scanner.GTE, scanner.LTE:
+		return trueLitteral, nil
+	}
+
+	return falseLitteral, nil
+}
+
+// rowElements returns the elements of a row value.
+func rowElements(a document.Array) ([]document.Value, error) {
+	var values []document.Value
+	err := a.Iterate(func(_ int, v document.Value) error {
+		values = append(values, v)
+		return nil
+	})
+	return values, err
+}
+
 func (op cmpOp) compare(l, r document.Value) (bool, error) {
 	switch op.Tok {
 	case scanner.EQ:
@@ -425,11 +606,12 @@ func (op cmpOp) compare(l, r document.Value) (bool, error) {
 }
 
 // IsComparisonOperator returns true if e is one of
-// =, !=, >, >=, <, <=, IS, IS NOT, IN, or NOT IN operators.
+// =, !=, >, >=, <, <=, IS, IS NOT, IN, NOT IN, LIKE, NOT LIKE, BETWEEN, NOT BETWEEN, =~ or !~ operators.
 func IsComparisonOperator(op Operator) bool {
 	switch op.(type) {
 	case eqOp, neqOp, gtOp, gteOp, ltOp, lteOp,
-		isOp, isNotOp, inOp, notInOp, likeOp, notLikeOp:
+		isOp, isNotOp, inOp, notInOp, likeOp, notLikeOp,
+		betweenOp, notBetweenOp, regexpOp, notRegexpOp:
 		return true
 	}
 
@@ -456,15 +638,19 @@ func IsInOperator(e Expr) bool {
 
 type inOp struct {
 	*simpleOperator
+
+	// row is true if the left operand is a row value.
+	row bool
 }
 
 // In creates an expression that evaluates to the result of a IN b.
+// If a is a row value, such as (a, b), b must be a list of row values.
 func In(a, b Expr) Expr {
-	return inOp{&simpleOperator{a, b, scanner.IN}}
+	return inOp{simpleOperator: &simpleOperator{a, b, scanner.IN}, row: isRowValue(a)}
 }
 
 func (op inOp) Eval(env *Environment) (document.Value, error) {
-	a, b, err := op.simpleOperator.eval(env)
+	a, b, err := op.eval(env)
 	if err != nil {
 		return nullLitteral, err
 	}
@@ -477,6 +663,10 @@ func (op inOp) Eval(env *Environment) (document.Value, error) {
 		return falseLitteral, nil
 	}
 
+	if op.row {
+		return op.rowIn(a.V.(document.Array), b.V.(document.Array))
+	}
+
 	ok, err := document.ArrayContains(b.V.(document.Array), a)
 	if err != nil {
 		return nullLitteral, err
@@ -488,13 +678,92 @@ func (op inOp) Eval(env *Environment) (document.Value, error) {
 	return falseLitteral, nil
 }
 
+// eval evaluates both operands. If the right operand is a subquery,
+// it evaluates to the list of values it selects.
+func (op inOp) eval(env *Environment) (document.Value, document.Value, error) {
+	sq, ok := op.b.(*Subquery)
+	if !ok {
+		return op.simpleOperator.eval(env)
+	}
+
+	a, err := op.a.Eval(env)
+	if err != nil {
+		return nullLitteral, nullLitteral, err
+	}
+
+	b, err := sq.EvalArray(env)
+	if err != nil {
+		return nullLitteral, nullLitteral, err
+	}
+
+	return a, b, nil
+}
+
+// rowIn returns true if row is equal to one of the row values of the list.
+// Elements of the list that are not row values of the same size are ignored.
+// If none of the rows are equal but at least one of the comparisons
+// evaluates to NULL, it returns NULL.
+func (op inOp) rowIn(row, list document.Array) (document.Value, error) {
+	size, err := document.ArrayLength(row)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	eq := cmpOp{simpleOperator: &simpleOperator{Tok: scanner.EQ}, row: true}
+	res := falseLitteral
+	err = list.Iterate(func(_ int, r document.Value) error {
+		if r.Type != document.ArrayValue {
+			return nil
+		}
+		n, err := document.ArrayLength(r.V.(document.Array))
+		if err != nil || n != size {
+			return err
+		}
+
+		v, err := eq.compareRows(row, r.V.(document.Array))
+		if err != nil {
+			return err
+		}
+		if v == trueLitteral {
+			res = trueLitteral
+			return errStop
+		}
+		if v.Type == document.NullValue {
+			res = nullLitteral
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nullLitteral, err
+	}
+
+	return res, nil
+}
+
+// IterateIndex looks up each value of the list in the index, in the order of the list.
+// Duplicate values are only looked up once.
 func (op inOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
 	if v.Type != document.ArrayValue {
 		return errors.New("IN operator takes an array")
 	}
 
 	var eq eqOp
+	seen := make(map[string]struct{})
 	return v.V.(document.Array).Iterate(func(i int, value document.Value) error {
+		// NULL is not equal to any value
+		if value.Type == document.NullValue {
+			return nil
+		}
+
+		enc, err := idx.EncodeValue(value)
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[string(enc)]; ok {
+			return nil
+		}
+		seen[string(enc)] = struct{}{}
+
 		return eq.IterateIndex(idx, tb, value, fn)
 	})
 }
@@ -532,24 +801,30 @@ func (op inOp) IteratePK(tb *database.Table, v document.Value, pkType document.V
 }
 
 func (op inOp) String() string {
-	return fmt.Sprintf("%v IN %v", op.a, op.b)
+	a, b := rowList(op.row, op.a, op.b)
+	return fmt.Sprintf("%v IN %v", a, b)
 }
 
+// notInOp doesn't embed inOp, it must not be used to read an index.
 type notInOp struct {
-	inOp
+	*simpleOperator
+
+	// row is true if the left operand is a row value.
+	row bool
 }
 
 // NotIn creates an expression that evaluates to the result of a NOT IN b.
 func NotIn(a, b Expr) Expr {
-	return &notInOp{inOp{&simpleOperator{a, b, scanner.IN}}}
+	return notInOp{simpleOperator: &simpleOperator{a, b, scanner.IN}, row: isRowValue(a)}
 }
 
 func (op notInOp) Eval(env *Environment) (document.Value, error) {
-	return invertBoolResult(op.inOp.Eval)(env)
+	return invertBoolResult(inOp{simpleOperator: op.simpleOperator, row: op.row}.Eval)(env)
 }
 
 func (op notInOp) String() string {
-	return fmt.Sprintf("%v NOT IN %v", op.a, op.b)
+	a, b := rowList(op.row, op.a, op.b)
+	return fmt.Sprintf("%v NOT IN %v", a, b)
 }
 
 type isOp struct {
@@ -557,17 +832,15 @@ type isOp struct {
 }
 
 // Is creates an expression that evaluates to the result of a IS b.
+// Unlike the = operator, it never returns NULL: a IS NULL is true if a is NULL
+// or if a is a path that doesn't exist in the document.
+// If b is Missing(), it only returns true if a is a path that doesn't exist in the document.
 func Is(a, b Expr) Expr {
-	return &isOp{&simpleOperator{a, b, scanner.IN}}
+	return isOp{&simpleOperator{a, b, scanner.IS}}
 }
 
 func (op isOp) Eval(env *Environment) (document.Value, error) {
-	a, b, err := op.simpleOperator.eval(env)
-	if err != nil {
-		return nullLitteral, err
-	}
-
-	ok, err := a.IsEqual(b)
+	ok, err := op.is(env)
 	if err != nil {
 		return nullLitteral, err
 	}
@@ -578,6 +851,19 @@ func (op isOp) Eval(env *Environment) (document.Value, error) {
 	return falseLitteral, nil
 }
 
+func (op isOp) is(env *Environment) (bool, error) {
+	if _, ok := op.b.(MissingLiteral); ok {
+		return isMissing(op.a, env)
+	}
+
+	a, b, err := op.simpleOperator.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	return a.IsEqual(b)
+}
+
 func (op isOp) String() string {
 	return fmt.Sprintf("%v IS %v", op.a, op.b)
 }
@@ -587,27 +873,64 @@ type isNotOp struct {
 }
 
 // IsNot creates an expression that evaluates to the result of a IS NOT b.
+// It never returns NULL, see Is.
 func IsNot(a, b Expr) Expr {
-	return &isNotOp{&simpleOperator{a, b, scanner.IN}}
+	return isNotOp{&simpleOperator{a, b, scanner.IS}}
 }
 
 func (op isNotOp) Eval(env *Environment) (document.Value, error) {
-	a, b, err := op.simpleOperator.eval(env)
-	if err != nil {
-		return nullLitteral, err
-	}
-
-	ok, err := a.IsNotEqual(b)
+	ok, err := isOp{op.simpleOperator}.is(env)
 	if err != nil {
 		return nullLitteral, err
 	}
 	if ok {
-		return trueLitteral, nil
+		return falseLitteral, nil
 	}
 
-	return falseLitteral, nil
+	return trueLitteral, nil
 }
 
 func (op isNotOp) String() string {
 	return fmt.Sprintf("%v IS NOT %v", op.a, op.b)
 }
+
+// MissingLiteral is the right operand of the IS MISSING and IS NOT MISSING predicates.
+type MissingLiteral struct{}
+
+// Missing returns the right operand of the IS MISSING and IS NOT MISSING predicates,
+// which test if a path doesn't exist in the document.
+// Unlike a IS NULL, a IS MISSING is false if a is explicitly set to NULL.
+func Missing() Expr {
+	return MissingLiteral{}
+}
+
+// Eval returns an error: MISSING is not a value.
+func (MissingLiteral) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, errors.New("MISSING can only be used with the IS and IS NOT operators")
+}
+
+func (MissingLiteral) String() string {
+	return "MISSING"
+}
+
+// isMissing returns true if e is a path that doesn't exist in the current document.
+// Any other expression is never missing.
+func isMissing(e Expr, env *Environment) (bool, error) {
+	p, ok := e.(Path)
+	if !ok {
+		_, err := e.Eval(env)
+		return false, err
+	}
+
+	v, ok := env.GetCurrentValue()
+	if !ok {
+		return false, document.ErrFieldNotFound
+	}
+
+	_, err := document.Path(p).GetValue(v)
+	if err == document.ErrFieldNotFound || err == document.ErrValueNotFound {
+		return true, nil
+	}
+
+	return false, err
+}
@@ -1,11 +1,14 @@
 package expr_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
 	"github.com/genjidb/genji/sql/query/expr"
 	"github.com/stretchr/testify/require"
 )
@@ -43,6 +46,103 @@ func TestComparisonExpr(t *testing.T) {
 	}
 }
 
+func TestComparisonBetweenFields(t *testing.T) {
+	d := document.NewFromJSON([]byte(`{
+		"start": 1,
+		"end": 2.5,
+		"same": 1.0,
+		"name": "foo",
+		"nothing": null
+	}`))
+	env := expr.NewEnvironment(document.NewDocumentValue(d))
+
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"start < end", document.NewBoolValue(true), false},
+		{"start > end", document.NewBoolValue(false), false},
+		{"start = same", document.NewBoolValue(true), false},
+		{"start != same", document.NewBoolValue(false), false},
+		{"start <= same", document.NewBoolValue(true), false},
+		{"end >= start", document.NewBoolValue(true), false},
+		{"start = name", document.NewBoolValue(false), false},
+		{"start < name", document.NewBoolValue(false), false},
+		{"start < notFound", nullLitteral, false},
+		{"notFound >= start", nullLitteral, false},
+		{"notFound = notFound", nullLitteral, false},
+		{"start = nothing", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, env, test.res, test.fails)
+		})
+	}
+}
+
+func TestComparisonRowValues(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"(a, 2) = (1, 2)", document.NewBoolValue(true), false},
+		{"(a, 2) = (1, 3)", document.NewBoolValue(false), false},
+		{"(a, 2) != (1, 3)", document.NewBoolValue(true), false},
+		{"(a, NULL) = (1, 2)", nullLitteral, false},
+		{"(a, NULL) = (2, 2)", document.NewBoolValue(false), false},
+		{"(a, NULL) != (2, 2)", document.NewBoolValue(true), false},
+		{"(a, NULL) != (1, 2)", nullLitteral, false},
+		{"(a, 2) > (1, 1)", document.NewBoolValue(true), false},
+		{"(a, 2) > (0, 3)", document.NewBoolValue(true), false},
+		{"(a, 2) > (1, 2)", document.NewBoolValue(false), false},
+		{"(a, 2) >= (1, 2)", document.NewBoolValue(true), false},
+		{"(a, 2) < (1, 3)", document.NewBoolValue(true), false},
+		{"(a, 2) < (2, 1)", document.NewBoolValue(true), false},
+		{"(a, 2) <= (1, 2)", document.NewBoolValue(true), false},
+		{"(a, NULL) > (0, 3)", document.NewBoolValue(true), false},
+		{"(a, NULL) > (1, 3)", nullLitteral, false},
+		{"(NULL, 2) > (0, 3)", nullLitteral, false},
+		{"(a, 2) = notFound", nullLitteral, false},
+		{"(a, 2) = 1", document.NewBoolValue(false), false},
+		{"(a, 2) = (1, 2, 3)", nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
+
+func TestComparisonRowValuesString(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"(a, 2) = (1, 2)", "(a, 2) = (1, 2)"},
+		{"(a, b) != (1, 2)", "(a, b) != (1, 2)"},
+		{"(a, b) > (1, 2)", "(a, b) > (1, 2)"},
+		{"(a, b) >= (1, 2)", "(a, b) >= (1, 2)"},
+		{"(a, b) < (1, 2)", "(a, b) < (1, 2)"},
+		{"(a, b) <= (1, 2)", "(a, b) <= (1, 2)"},
+		{"(a, b) = c", "(a, b) = c"},
+		{"(a, b) IN ((1, 2), (3, 4))", "(a, b) IN ((1, 2), (3, 4))"},
+		{"(a, b) NOT IN ((1, 2), (3, 4))", "(a, b) NOT IN ((1, 2), (3, 4))"},
+		{"a IN [1, 2]", "a IN [1, 2]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+			require.Equal(t, test.want, e.(fmt.Stringer).String())
+		})
+	}
+}
+
 func TestComparisonINExpr(t *testing.T) {
 	tests := []struct {
 		expr  string
@@ -59,6 +159,12 @@ func TestComparisonINExpr(t *testing.T) {
 		{"[1, 2] IN 1", document.NewBoolValue(false), false},
 		{"1 IN NULL", nullLitteral, false},
 		{"NULL IN [1, 2, NULL]", nullLitteral, false},
+		{"(a, 2) IN ((1, 2), (3, 4))", document.NewBoolValue(true), false},
+		{"(a, 2) IN ((1, 3), (3, 4))", document.NewBoolValue(false), false},
+		{"(a, 2) IN ((1, NULL), (3, 4))", nullLitteral, false},
+		{"(a, 2) IN ((1, NULL), (1, 2))", document.NewBoolValue(true), false},
+		{"(a, 2) IN ((2, NULL), (3, 4))", document.NewBoolValue(false), false},
+		{"(a, 2) IN ((1, 2, 3), 1)", document.NewBoolValue(false), false},
 	}
 
 	for _, test := range tests {
@@ -83,6 +189,9 @@ func TestComparisonNOTINExpr(t *testing.T) {
 		{"[1, 2] NOT IN 1", document.NewBoolValue(true), false},
 		{"1 NOT IN NULL", nullLitteral, false},
 		{"NULL NOT IN [1, 2, NULL]", nullLitteral, false},
+		{"(a, 2) NOT IN ((1, 2), (3, 4))", document.NewBoolValue(false), false},
+		{"(a, 2) NOT IN ((1, 3), (3, 4))", document.NewBoolValue(true), false},
+		{"(a, 2) NOT IN ((1, NULL), (3, 4))", nullLitteral, false},
 	}
 
 	for _, test := range tests {
@@ -103,6 +212,15 @@ func TestComparisonISExpr(t *testing.T) {
 		{"1 IS NULL", document.NewBoolValue(false), false},
 		{"NULL IS NULL", document.NewBoolValue(true), false},
 		{"NULL IS 1", document.NewBoolValue(false), false},
+		{"a IS NULL", document.NewBoolValue(false), false},
+		{"d IS NULL", document.NewBoolValue(true), false},
+		{"(a = NULL) IS NULL", document.NewBoolValue(true), false},
+		{"a IS MISSING", document.NewBoolValue(false), false},
+		{"d IS MISSING", document.NewBoolValue(true), false},
+		{"c[1].foo IS MISSING", document.NewBoolValue(false), false},
+		{"c[5] IS MISSING", document.NewBoolValue(true), false},
+		{"1 IS MISSING", document.NewBoolValue(false), false},
+		{"NULL IS MISSING", document.NewBoolValue(false), false},
 	}
 
 	for _, test := range tests {
@@ -123,6 +241,10 @@ func TestComparisonISNOTExpr(t *testing.T) {
 		{"1 IS NOT NULL", document.NewBoolValue(true), false},
 		{"NULL IS NOT NULL", document.NewBoolValue(false), false},
 		{"NULL IS NOT 1", document.NewBoolValue(true), false},
+		{"d IS NOT NULL", document.NewBoolValue(false), false},
+		{"a IS NOT MISSING", document.NewBoolValue(true), false},
+		{"d IS NOT MISSING", document.NewBoolValue(false), false},
+		{"NULL IS NOT MISSING", document.NewBoolValue(true), false},
 	}
 
 	for _, test := range tests {
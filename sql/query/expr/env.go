@@ -3,6 +3,7 @@ package expr
 import (
 	"fmt"
 
+	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 )
 
@@ -14,6 +15,7 @@ const (
 // the expression is evaluated.
 type Environment struct {
 	Params []Param
+	Tx     *database.Transaction
 	fb     *document.FieldBuffer
 
 	Outer *Environment
@@ -55,6 +57,29 @@ func (e *Environment) Set(name string, v document.Value) {
 	e.fb.Set(document.Path{document.PathFragment{FieldName: name}}, v)
 }
 
+// GetTx returns the transaction in which the expression is evaluated, if any.
+func (e *Environment) GetTx() *database.Transaction {
+	if e.Tx != nil {
+		return e.Tx
+	}
+
+	if e.Outer != nil {
+		return e.Outer.GetTx()
+	}
+
+	return nil
+}
+
+// GetParams returns the parameters of the environment,
+// or the ones of the outer environment if it has none.
+func (e *Environment) GetParams() []Param {
+	if len(e.Params) == 0 && e.Outer != nil {
+		return e.Outer.GetParams()
+	}
+
+	return e.Params
+}
+
 func (e *Environment) GetCurrentValue() (document.Value, bool) {
 	return e.Get(currentValueKey)
 }
@@ -122,6 +122,18 @@ func (p Parentheses) Eval(env *Environment) (document.Value, error) {
 	return p.E.Eval(env)
 }
 
+// Unparenthesize returns the expression enclosed by any number of parentheses.
+func Unparenthesize(e Expr) Expr {
+	for {
+		p, ok := e.(Parentheses)
+		if !ok {
+			return e
+		}
+
+		e = p.E
+	}
+}
+
 func invertBoolResult(f func(env *Environment) (document.Value, error)) func(env *Environment) (document.Value, error) {
 	return func(env *Environment) (document.Value, error) {
 		v, err := f(env)
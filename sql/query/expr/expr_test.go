@@ -55,6 +55,28 @@ func testExpr(t testing.TB, exprStr string, env *expr.Environment, want document
 	}
 }
 
+func TestNotExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		res  document.Value
+	}{
+		{"NOT true", document.NewBoolValue(false)},
+		{"NOT false", document.NewBoolValue(true)},
+		{"NOT 1", document.NewBoolValue(false)},
+		{"NOT 0", document.NewBoolValue(true)},
+		{"NOT NULL", nullLitteral},
+		{"NOT a = 1", document.NewBoolValue(false)},
+		{"NOT notfound", nullLitteral},
+		{"NOT a = 2 AND a = 1", document.NewBoolValue(true)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, false)
+		})
+	}
+}
+
 func TestString(t *testing.T) {
 	var operands = []string{
 		`10.4`,
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 )
 
@@ -52,6 +54,302 @@ func BuiltinFunctions() map[string]func(args ...Expr) (Expr, error) {
 			}
 			return &AvgFunc{Expr: args[0]}, nil
 		},
+		"array_agg": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("ARRAY_AGG() takes 1 argument")
+			}
+			return &ArrayAggFunc{Expr: args[0]}, nil
+		},
+		"group_concat": func(args ...Expr) (Expr, error) {
+			switch len(args) {
+			case 1:
+				return &GroupConcatFunc{Expr: args[0]}, nil
+			case 2:
+				return &GroupConcatFunc{Expr: args[0], Separator: args[1]}, nil
+			}
+			return nil, fmt.Errorf("GROUP_CONCAT() takes 1 or 2 arguments")
+		},
+		"object": func(args ...Expr) (Expr, error) {
+			return NewObjectFunc(args...)
+		},
+		"array": func(args ...Expr) (Expr, error) {
+			return ArrayFunc(args), nil
+		},
+		"array_append": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("array_append() takes 2 arguments")
+			}
+			return &ArrayAppendFunc{Array: args[0], Value: args[1]}, nil
+		},
+		"array_remove": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("array_remove() takes 2 arguments")
+			}
+			return &ArrayRemoveFunc{Array: args[0], Value: args[1]}, nil
+		},
+		"array_remove_at": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("array_remove_at() takes 2 arguments")
+			}
+			return &ArrayRemoveAtFunc{Array: args[0], Index: args[1]}, nil
+		},
+		"array_length": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("array_length() takes 1 argument")
+			}
+			return &ArrayLengthFunc{Array: args[0]}, nil
+		},
+		"array_contains": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("array_contains() takes 2 arguments")
+			}
+			return &ArrayContainsFunc{Array: args[0], Value: args[1]}, nil
+		},
+		"array_slice": func(args ...Expr) (Expr, error) {
+			switch len(args) {
+			case 2:
+				return &ArraySliceFunc{Array: args[0], Start: args[1]}, nil
+			case 3:
+				return &ArraySliceFunc{Array: args[0], Start: args[1], End: args[2]}, nil
+			}
+			return nil, fmt.Errorf("array_slice() takes 2 or 3 arguments")
+		},
+		"array_concat": func(args ...Expr) (Expr, error) {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("array_concat() takes at least 2 arguments")
+			}
+			return ArrayConcatFunc(args), nil
+		},
+		"json": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("json() takes 1 argument")
+			}
+			return &JSONFunc{Expr: args[0]}, nil
+		},
+		"json_valid": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("json_valid() takes 1 argument")
+			}
+			return &JSONValidFunc{Expr: args[0]}, nil
+		},
+		"json_extract": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("json_extract() takes 2 arguments")
+			}
+			return &JSONExtractFunc{Expr: args[0], Path: args[1]}, nil
+		},
+		"json_type": func(args ...Expr) (Expr, error) {
+			switch len(args) {
+			case 1:
+				return &JSONTypeFunc{Expr: args[0]}, nil
+			case 2:
+				return &JSONTypeFunc{Expr: args[0], Path: args[1]}, nil
+			}
+			return nil, fmt.Errorf("json_type() takes 1 or 2 arguments")
+		},
+		"json_set": func(args ...Expr) (Expr, error) {
+			if len(args) != 3 {
+				return nil, fmt.Errorf("json_set() takes 3 arguments")
+			}
+			return &JSONSetFunc{Expr: args[0], Path: args[1], Value: args[2]}, nil
+		},
+		"json_remove": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("json_remove() takes 2 arguments")
+			}
+			return &JSONRemoveFunc{Expr: args[0], Path: args[1]}, nil
+		},
+		"lower": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("lower() takes 1 argument")
+			}
+			return &LowerFunc{Expr: args[0]}, nil
+		},
+		"upper": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("upper() takes 1 argument")
+			}
+			return &UpperFunc{Expr: args[0]}, nil
+		},
+		"trim": func(args ...Expr) (Expr, error) {
+			switch len(args) {
+			case 1:
+				return &TrimFunc{Expr: args[0]}, nil
+			case 2:
+				return &TrimFunc{Expr: args[0], Chars: args[1]}, nil
+			}
+			return nil, fmt.Errorf("trim() takes 1 or 2 arguments")
+		},
+		"concat": func(args ...Expr) (Expr, error) {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("concat() takes at least 1 argument")
+			}
+			return ConcatFunc(args), nil
+		},
+		"substring": func(args ...Expr) (Expr, error) {
+			switch len(args) {
+			case 2:
+				return &SubstringFunc{Expr: args[0], Start: args[1]}, nil
+			case 3:
+				return &SubstringFunc{Expr: args[0], Start: args[1], Length: args[2]}, nil
+			}
+			return nil, fmt.Errorf("substring() takes 2 or 3 arguments")
+		},
+		"replace": func(args ...Expr) (Expr, error) {
+			if len(args) != 3 {
+				return nil, fmt.Errorf("replace() takes 3 arguments")
+			}
+			return &ReplaceFunc{Expr: args[0], Old: args[1], New: args[2]}, nil
+		},
+		"length": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("length() takes 1 argument")
+			}
+			return &LengthFunc{Expr: args[0]}, nil
+		},
+		"abs": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("abs() takes 1 argument")
+			}
+			return &AbsFunc{Expr: args[0]}, nil
+		},
+		"round": func(args ...Expr) (Expr, error) {
+			switch len(args) {
+			case 1:
+				return &RoundFunc{Expr: args[0]}, nil
+			case 2:
+				return &RoundFunc{Expr: args[0], Digits: args[1]}, nil
+			}
+			return nil, fmt.Errorf("round() takes 1 or 2 arguments")
+		},
+		"floor": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("floor() takes 1 argument")
+			}
+			return &FloorFunc{Expr: args[0]}, nil
+		},
+		"ceil": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("ceil() takes 1 argument")
+			}
+			return &CeilFunc{Expr: args[0]}, nil
+		},
+		"pow": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("pow() takes 2 arguments")
+			}
+			return &PowFunc{Base: args[0], Exponent: args[1]}, nil
+		},
+		"sqrt": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("sqrt() takes 1 argument")
+			}
+			return &SqrtFunc{Expr: args[0]}, nil
+		},
+		"mod": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("mod() takes 2 arguments")
+			}
+			return &ModFunc{Dividend: args[0], Divisor: args[1]}, nil
+		},
+		"coalesce": func(args ...Expr) (Expr, error) {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("coalesce() takes at least 1 argument")
+			}
+			return CoalesceFunc(args), nil
+		},
+		"nullif": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("nullif() takes 2 arguments")
+			}
+			return NullIfFunc{Expr: args[0], Other: args[1]}, nil
+		},
+		"has": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("has() takes 1 argument")
+			}
+			p, ok := args[0].(Path)
+			if !ok {
+				return nil, fmt.Errorf("has() expects a path, got %v", args[0])
+			}
+			return HasFunc{Path: p}, nil
+		},
+		"match": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("match() takes 2 arguments")
+			}
+			return MatchFunc{Expr: args[0], Query: args[1]}, nil
+		},
+		"last_insert_key": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("last_insert_key() takes no arguments")
+			}
+			return LastInsertKeyFunc{}, nil
+		},
+		"nextval": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("nextval() takes 1 argument")
+			}
+			return &NextValFunc{Expr: args[0]}, nil
+		},
+		"now": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("now() takes no arguments")
+			}
+			return NowFunc{}, nil
+		},
+		"date_trunc": func(args ...Expr) (Expr, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("date_trunc() takes 2 arguments")
+			}
+			return &DateTruncFunc{Unit: args[0], Timestamp: args[1]}, nil
+		},
+		"uuid":   newUUIDFunc("uuid"),
+		"uuidv4": newUUIDFunc("uuidv4"),
+		"random": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("random() takes no arguments")
+			}
+			return RandomFunc{}, nil
+		},
+		"randomblob": func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("randomblob() takes 1 argument")
+			}
+			return &RandomBlobFunc{Expr: args[0]}, nil
+		},
+		"row_number": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("row_number() takes no arguments")
+			}
+			return RowNumberFunc{}, nil
+		},
+		"rank": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("rank() takes no arguments")
+			}
+			return RankFunc{}, nil
+		},
+		"dense_rank": func(args ...Expr) (Expr, error) {
+			if len(args) != 0 {
+				return nil, fmt.Errorf("dense_rank() takes no arguments")
+			}
+			return DenseRankFunc{}, nil
+		},
+		"lag": func(args ...Expr) (Expr, error) {
+			e, offset, def, err := newOffsetFuncArgs("lag", args)
+			if err != nil {
+				return nil, err
+			}
+			return LagFunc{Expr: e, Offset: offset, Default: def}, nil
+		},
+		"lead": func(args ...Expr) (Expr, error) {
+			e, offset, def, err := newOffsetFuncArgs("lead", args)
+			if err != nil {
+				return nil, err
+			}
+			return LeadFunc{Expr: e, Offset: offset, Default: def}, nil
+		},
 	}
 }
 
@@ -61,92 +359,991 @@ func NewFunctions() Functions {
 	}
 }
 
+// NewFunctionsFor returns the builtin functions and the aggregate functions registered in db.
+// Builtin functions take precedence over registered ones.
+func NewFunctionsFor(db *database.Database) Functions {
+	f := NewFunctions()
+
+	for name, agg := range db.AggregateFuncs() {
+		if _, ok := f.m[name]; ok {
+			continue
+		}
+
+		name, agg := name, agg
+		f.AddFunc(name, func(args ...Expr) (Expr, error) {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("%s() takes 1 argument", name)
+			}
+			return &UserAggregateFunc{Name: name, Expr: args[0], New: agg}, nil
+		})
+	}
+
+	return f
+}
+
 // AddFunc adds function to the map.
 func (f Functions) AddFunc(name string, fn func(args ...Expr) (Expr, error)) {
 	f.m[name] = fn
 }
 
-// GetFunc return a function expression by name.
-func (f Functions) GetFunc(name string, args ...Expr) (Expr, error) {
-	fn, ok := f.m[strings.ToLower(name)]
-	if !ok {
-		return nil, fmt.Errorf("no such function: %q", name)
+// GetFunc return a function expression by name.
+func (f Functions) GetFunc(name string, args ...Expr) (Expr, error) {
+	fn, ok := f.m[strings.ToLower(name)]
+	if !ok {
+		// functions that are not builtin are looked up
+		// in the database when the expression is evaluated.
+		return &UserFunc{Name: name, Args: args}, nil
+	}
+
+	return fn(args...)
+}
+
+// UserFunc represents a call to a function registered
+// in the database with the RegisterFunc method.
+type UserFunc struct {
+	Name string
+	Args []Expr
+}
+
+// Eval evaluates the arguments and calls the function registered
+// in the database under the same name.
+func (u *UserFunc) Eval(env *Environment) (document.Value, error) {
+	tx := env.GetTx()
+	if tx == nil {
+		return nullLitteral, fmt.Errorf("no such function: %q", u.Name)
+	}
+
+	args := make([]document.Value, len(u.Args))
+	for i, e := range u.Args {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+		args[i] = v
+	}
+
+	return tx.DB().CallFunc(u.Name, args)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u *UserFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*UserFunc)
+	if !ok {
+		return false
+	}
+
+	return strings.EqualFold(u.Name, o.Name) && LiteralExprList(u.Args).IsEqual(LiteralExprList(o.Args))
+}
+
+func (u *UserFunc) String() string {
+	var b strings.Builder
+
+	b.WriteString(u.Name)
+	b.WriteRune('(')
+	for i, e := range u.Args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", e))
+	}
+	b.WriteRune(')')
+
+	return b.String()
+}
+
+// UserAggregateFunc represents a call to an aggregate function
+// registered in the database with the RegisterAggregateFunc method.
+type UserAggregateFunc struct {
+	Name  string
+	Expr  Expr
+	Alias string
+	New   database.AggregateFunc
+}
+
+// Eval extracts the aggregated value from the given document and returns it.
+func (u *UserAggregateFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, fmt.Errorf("misuse of aggregation function %s()", u.Name)
+	}
+
+	return v.V.(document.Document).GetByField(u.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (u *UserAggregateFunc) SetAlias(alias string) {
+	u.Alias = alias
+}
+
+// Aggregator implements the planner.AggregatorBuilder interface.
+// It creates a new accumulator for every group.
+func (u *UserAggregateFunc) Aggregator(group document.Value) document.Aggregator {
+	return &userAggregator{
+		fn:  u,
+		acc: u.New(),
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u *UserAggregateFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*UserAggregateFunc)
+	if !ok {
+		return false
+	}
+
+	return strings.EqualFold(u.Name, o.Name) && Equal(u.Expr, o.Expr)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the function call.
+func (u *UserAggregateFunc) String() string {
+	if u.Alias != "" {
+		return u.Alias
+	}
+
+	return fmt.Sprintf("%s(%v)", u.Name, u.Expr)
+}
+
+// userAggregator passes the values of a group to the accumulator
+// of a user defined aggregate function.
+type userAggregator struct {
+	fn  *UserAggregateFunc
+	acc database.Accumulator
+}
+
+// Add evaluates the argument of the function and passes it to the accumulator.
+// Missing fields are passed as NULL.
+func (u *userAggregator) Add(d document.Document) error {
+	v, err := u.fn.Expr.Eval(NewEnvironment(document.NewDocumentValue(d)))
+	if err == document.ErrFieldNotFound {
+		v, err = nullLitteral, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return u.acc.Add(v)
+}
+
+// Aggregate adds a field to the given buffer with the result of the accumulator.
+func (u *userAggregator) Aggregate(fb *document.FieldBuffer) error {
+	v, err := u.acc.Result()
+	if err != nil {
+		return err
+	}
+
+	fb.Add(u.fn.String(), v)
+	return nil
+}
+
+// PKFunc represents the pk() function.
+// It returns the primary key of the current document.
+type PKFunc struct{}
+
+// Eval returns the primary key of the current document.
+func (k PKFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return nullLitteral, nil
+	}
+
+	keyer, ok := v.V.(document.Keyer)
+	if !ok {
+		return nullLitteral, nil
+	}
+
+	return keyer.Key()
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (k PKFunc) IsEqual(other Expr) bool {
+	_, ok := other.(PKFunc)
+	return ok
+}
+
+func (k PKFunc) String() string {
+	return "pk()"
+}
+
+// LastInsertKeyFunc is the last_insert_key() function. It returns the primary key
+// of the last document inserted by a statement of the current transaction.
+// Documents inserted by triggers are ignored.
+type LastInsertKeyFunc struct{}
+
+// Eval returns the last inserted key, or NULL if there is no transaction
+// or if no document was inserted.
+func (k LastInsertKeyFunc) Eval(env *Environment) (document.Value, error) {
+	tx := env.GetTx()
+	if tx == nil {
+		return nullLitteral, nil
+	}
+
+	return tx.LastInsertKey(), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (k LastInsertKeyFunc) IsEqual(other Expr) bool {
+	_, ok := other.(LastInsertKeyFunc)
+	return ok
+}
+
+func (k LastInsertKeyFunc) String() string {
+	return "last_insert_key()"
+}
+
+// NextValFunc is the nextval() function. It increments the sequence
+// whose name is given as argument and returns its new value.
+type NextValFunc struct {
+	Expr Expr
+}
+
+// Eval returns the next value of the sequence, or NULL if the name is NULL.
+// It returns an error if the sequence doesn't exist or if there is no transaction.
+func (n *NextValFunc) Eval(env *Environment) (document.Value, error) {
+	name, ok, err := evalText("nextval", n.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	tx := env.GetTx()
+	if tx == nil {
+		return nullLitteral, fmt.Errorf("nextval(): sequence %q can only be used within a transaction", name)
+	}
+
+	v, err := tx.NextSequenceValue(name)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(v), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (n *NextValFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*NextValFunc)
+	return ok && Equal(n.Expr, o.Expr)
+}
+
+func (n *NextValFunc) String() string {
+	return fmt.Sprintf("nextval(%v)", n.Expr)
+}
+
+// NowFunc is the now() function. It returns the current time in UTC,
+// as a timestamp.
+type NowFunc struct{}
+
+// Eval returns the current time.
+func (n NowFunc) Eval(env *Environment) (document.Value, error) {
+	return document.NewTimestampValue(time.Now()), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (n NowFunc) IsEqual(other Expr) bool {
+	_, ok := other.(NowFunc)
+	return ok
+}
+
+func (n NowFunc) String() string {
+	return "now()"
+}
+
+// DateTruncFunc is the date_trunc() function.
+// It truncates a timestamp, or a text containing a timestamp formatted using RFC 3339,
+// to the given precision: 'microsecond', 'millisecond', 'second', 'minute', 'hour',
+// 'day', 'week', 'month', 'quarter' or 'year'. Weeks start on Monday.
+type DateTruncFunc struct {
+	Unit      Expr
+	Timestamp Expr
+}
+
+// Eval returns the truncated timestamp, or NULL if the timestamp is NULL.
+func (d *DateTruncFunc) Eval(env *Environment) (document.Value, error) {
+	u, err := d.Unit.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if u.Type != document.TextValue {
+		return nullLitteral, fmt.Errorf("date_trunc(): expected text unit, got %s", u.Type)
+	}
+
+	v, err := d.Timestamp.Eval(env)
+	if err != nil || v.Type == document.NullValue {
+		return nullLitteral, err
+	}
+
+	v, err = v.CastAsTimestamp()
+	if err != nil {
+		return nullLitteral, fmt.Errorf("date_trunc(): %w", err)
+	}
+
+	t := v.V.(time.Time)
+	year, month, day := t.Date()
+
+	switch strings.ToLower(u.V.(string)) {
+	case "microsecond":
+	case "millisecond":
+		t = t.Truncate(time.Millisecond)
+	case "second":
+		t = t.Truncate(time.Second)
+	case "minute":
+		t = t.Truncate(time.Minute)
+	case "hour":
+		t = t.Truncate(time.Hour)
+	case "day":
+		t = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	case "week":
+		t = time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "month":
+		t = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		t = time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		t = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return nullLitteral, fmt.Errorf("date_trunc(): unknown unit %q", u.V.(string))
+	}
+
+	return document.NewTimestampValue(t), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (d *DateTruncFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*DateTruncFunc)
+	return ok && Equal(d.Unit, o.Unit) && Equal(d.Timestamp, o.Timestamp)
+}
+
+func (d *DateTruncFunc) String() string {
+	return fmt.Sprintf("date_trunc(%v, %v)", d.Unit, d.Timestamp)
+}
+
+// HasFunc is the has() function. It returns true if the path
+// exists in the current document, even if its value is NULL.
+type HasFunc struct {
+	Path Path
+}
+
+// Eval returns true if the path resolves to a value in the current document.
+func (h HasFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok {
+		return falseLitteral, nil
+	}
+
+	_, err := document.Path(h.Path).GetValue(v)
+	if err == document.ErrFieldNotFound || err == document.ErrValueNotFound {
+		return falseLitteral, nil
+	}
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return trueLitteral, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (h HasFunc) IsEqual(other Expr) bool {
+	o, ok := other.(HasFunc)
+	return ok && h.Path.IsEqual(o.Path)
+}
+
+func (h HasFunc) String() string {
+	return fmt.Sprintf("has(%v)", h.Path)
+}
+
+// CastFunc represents the CAST expression.
+type CastFunc struct {
+	Expr   Expr
+	CastAs document.ValueType
+}
+
+// Eval evaluates the expression and converts the result to the target type,
+// following the rules of document.Value.CastAs.
+func (c CastFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := c.Expr.Eval(env)
+	if err != nil {
+		return v, err
+	}
+
+	return v.CastAs(c.CastAs)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c CastFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(CastFunc)
+	if !ok {
+		return false
+	}
+
+	if c.CastAs != o.CastAs {
+		return false
+	}
+
+	if c.Expr != nil {
+		return Equal(c.Expr, o.Expr)
+	}
+
+	return o.Expr == nil
+}
+
+func (c CastFunc) String() string {
+	return fmt.Sprintf("CAST(%v AS %v)", c.Expr, c.CastAs)
+}
+
+// ObjectFunc represents the object() function.
+// It builds a document from a list of expressions.
+type ObjectFunc struct {
+	Fields KVPairs
+}
+
+// NewObjectFunc creates an ObjectFunc from a list of expressions.
+// Each field of the resulting document is named after its expression:
+// paths use their last field name, other expressions use their string representation.
+func NewObjectFunc(args ...Expr) (*ObjectFunc, error) {
+	fields := make(KVPairs, len(args))
+	seen := make(map[string]struct{}, len(args))
+
+	for i, e := range args {
+		name := fmt.Sprintf("%v", e)
+		if p, ok := e.(Path); ok && len(p) > 0 && p[len(p)-1].FieldName != "" {
+			name = p[len(p)-1].FieldName
+		}
+
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("object(): duplicate field %q", name)
+		}
+		seen[name] = struct{}{}
+
+		fields[i] = KVPair{K: name, V: e}
+	}
+
+	return &ObjectFunc{Fields: fields}, nil
+}
+
+// Eval evaluates all the expressions and returns a document
+// whose fields are the results.
+func (o *ObjectFunc) Eval(env *Environment) (document.Value, error) {
+	var fb document.FieldBuffer
+
+	for _, kv := range o.Fields {
+		v, err := kv.V.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		fb.Add(kv.K, v)
+	}
+
+	return document.NewDocumentValue(&fb), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (o *ObjectFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	oo, ok := other.(*ObjectFunc)
+	if !ok {
+		return false
+	}
+
+	return o.Fields.IsEqual(oo.Fields)
+}
+
+func (o *ObjectFunc) String() string {
+	var b strings.Builder
+
+	b.WriteString("object(")
+	for i, kv := range o.Fields {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", kv.V))
+	}
+	b.WriteRune(')')
+
+	return b.String()
+}
+
+// ArrayFunc represents the array() function.
+// It builds an array from a list of expressions.
+type ArrayFunc []Expr
+
+// Eval evaluates all the expressions and returns an array
+// containing the results, in order.
+func (a ArrayFunc) Eval(env *Environment) (document.Value, error) {
+	return LiteralExprList(a).Eval(env)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a ArrayFunc) IsEqual(other Expr) bool {
+	o, ok := other.(ArrayFunc)
+	if !ok {
+		return false
+	}
+
+	return LiteralExprList(a).IsEqual(LiteralExprList(o))
+}
+
+func (a ArrayFunc) String() string {
+	var b strings.Builder
+
+	b.WriteString("array(")
+	for i, e := range a {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", e))
+	}
+	b.WriteRune(')')
+
+	return b.String()
+}
+
+// evalArray evaluates e and copies the resulting array in a new buffer.
+// NULL is treated as an empty array, any other type returns an error.
+func evalArray(fname string, e Expr, env *Environment) (*document.ValueBuffer, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	var vb document.ValueBuffer
+	switch v.Type {
+	case document.NullValue:
+		return &vb, nil
+	case document.ArrayValue:
+		err = vb.ScanArray(v.V.(document.Array))
+		return &vb, err
+	}
+
+	return nil, fmt.Errorf("%s(): expected array, got %s", fname, v.Type)
+}
+
+// ArrayAppendFunc is the array_append() function.
+// It returns a copy of an array with a value added at the end.
+// A NULL or missing array is treated as an empty array.
+type ArrayAppendFunc struct {
+	Array Expr
+	Value Expr
+}
+
+// Eval returns the array with the value appended.
+func (a *ArrayAppendFunc) Eval(env *Environment) (document.Value, error) {
+	vb, err := evalArray("array_append", a.Array, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v, err := a.Value.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(vb.Append(v)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAppendFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ArrayAppendFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Array, o.Array) && Equal(a.Value, o.Value)
+}
+
+func (a *ArrayAppendFunc) String() string {
+	return fmt.Sprintf("array_append(%v, %v)", a.Array, a.Value)
+}
+
+// ArrayRemoveFunc is the array_remove() function.
+// It returns a copy of an array without the elements equal to a value.
+// A NULL or missing array is treated as an empty array.
+type ArrayRemoveFunc struct {
+	Array Expr
+	Value Expr
+}
+
+// Eval returns the array without the elements equal to the value.
+func (a *ArrayRemoveFunc) Eval(env *Environment) (document.Value, error) {
+	vb, err := evalArray("array_remove", a.Array, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v, err := a.Value.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	var res document.ValueBuffer
+	err = vb.Iterate(func(i int, value document.Value) error {
+		ok, err := value.IsEqual(v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			res.Append(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(&res), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayRemoveFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ArrayRemoveFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Array, o.Array) && Equal(a.Value, o.Value)
+}
+
+func (a *ArrayRemoveFunc) String() string {
+	return fmt.Sprintf("array_remove(%v, %v)", a.Array, a.Value)
+}
+
+// ArrayRemoveAtFunc is the array_remove_at() function.
+// It returns a copy of an array without the element at the given index.
+// A NULL or missing array is treated as an empty array, and an index
+// out of range returns an error.
+type ArrayRemoveAtFunc struct {
+	Array Expr
+	Index Expr
+}
+
+// Eval returns the array without the element at the given index.
+func (a *ArrayRemoveAtFunc) Eval(env *Environment) (document.Value, error) {
+	vb, err := evalArray("array_remove_at", a.Array, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v, err := a.Index.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if v.Type != document.IntegerValue {
+		return nullLitteral, fmt.Errorf("array_remove_at(): expected integer index, got %s", v.Type)
+	}
+
+	idx := v.V.(int64)
+	if idx < 0 || idx >= int64(vb.Len()) {
+		return nullLitteral, fmt.Errorf("array_remove_at(): index %d out of range", idx)
+	}
+
+	var res document.ValueBuffer
+	err = vb.Iterate(func(i int, value document.Value) error {
+		if int64(i) != idx {
+			res.Append(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(&res), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayRemoveAtFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ArrayRemoveAtFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Array, o.Array) && Equal(a.Index, o.Index)
+}
+
+func (a *ArrayRemoveAtFunc) String() string {
+	return fmt.Sprintf("array_remove_at(%v, %v)", a.Array, a.Index)
+}
+
+// evalArrayOrNull evaluates e and returns the resulting array,
+// or nil if it is NULL. Any other type returns an error.
+func evalArrayOrNull(fname string, e Expr, env *Environment) (document.Array, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v.Type {
+	case document.NullValue:
+		return nil, nil
+	case document.ArrayValue:
+		return v.V.(document.Array), nil
+	}
+
+	return nil, fmt.Errorf("%s(): expected array, got %s", fname, v.Type)
+}
+
+// ArrayLengthFunc is the array_length() function.
+// It returns the number of elements of an array, or NULL if the array is NULL or missing.
+type ArrayLengthFunc struct {
+	Array Expr
+}
+
+// Eval returns the length of the array.
+func (a *ArrayLengthFunc) Eval(env *Environment) (document.Value, error) {
+	arr, err := evalArrayOrNull("array_length", a.Array, env)
+	if err != nil || arr == nil {
+		return nullLitteral, err
+	}
+
+	n, err := document.ArrayLength(arr)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(int64(n)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayLengthFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ArrayLengthFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Array, o.Array)
+}
+
+func (a *ArrayLengthFunc) String() string {
+	return fmt.Sprintf("array_length(%v)", a.Array)
+}
+
+// ArrayContainsFunc is the array_contains() function.
+// It returns true if one of the elements of an array is equal to a value,
+// or NULL if the array is NULL or missing.
+type ArrayContainsFunc struct {
+	Array Expr
+	Value Expr
+}
+
+// Eval returns true if the array contains the value.
+func (a *ArrayContainsFunc) Eval(env *Environment) (document.Value, error) {
+	arr, err := evalArrayOrNull("array_contains", a.Array, env)
+	if err != nil || arr == nil {
+		return nullLitteral, err
+	}
+
+	v, err := a.Value.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	found := false
+	err = arr.Iterate(func(i int, value document.Value) error {
+		ok, err := value.IsEqual(v)
+		if err != nil {
+			return err
+		}
+		if ok {
+			found = true
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nullLitteral, err
+	}
+
+	return document.NewBoolValue(found), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayContainsFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ArrayContainsFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Array, o.Array) && Equal(a.Value, o.Value)
+}
+
+func (a *ArrayContainsFunc) String() string {
+	return fmt.Sprintf("array_contains(%v, %v)", a.Array, a.Value)
+}
+
+// ArraySliceFunc is the array_slice() function.
+// It returns the elements of an array from the start index, included,
+// to the end index, excluded. If the end index is omitted, the slice goes to the end of the array.
+// Negative indexes are counted from the end of the array and indexes out of range
+// are clamped to its bounds.
+// It returns NULL if the array is NULL or missing.
+type ArraySliceFunc struct {
+	Array Expr
+	Start Expr
+	End   Expr
+}
+
+// Eval returns the slice of the array.
+func (a *ArraySliceFunc) Eval(env *Environment) (document.Value, error) {
+	arr, err := evalArrayOrNull("array_slice", a.Array, env)
+	if err != nil || arr == nil {
+		return nullLitteral, err
+	}
+
+	l, err := document.ArrayLength(arr)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	n := int64(l)
+	start, err := evalSliceIndex(a.Start, n, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	end := n
+	if a.End != nil {
+		end, err = evalSliceIndex(a.End, n, env)
+		if err != nil {
+			return nullLitteral, err
+		}
 	}
 
-	return fn(args...)
+	var res document.ValueBuffer
+	err = arr.Iterate(func(i int, value document.Value) error {
+		if int64(i) >= start && int64(i) < end {
+			res.Append(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(&res), nil
 }
 
-// PKFunc represents the pk() function.
-// It returns the primary key of the current document.
-type PKFunc struct{}
+// evalSliceIndex evaluates an index of array_slice() and clamps it
+// between 0 and the length of the array.
+func evalSliceIndex(e Expr, n int64, env *Environment) (int64, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if v.Type != document.IntegerValue {
+		return 0, fmt.Errorf("array_slice(): expected integer index, got %s", v.Type)
+	}
 
-// Eval returns the primary key of the current document.
-func (k PKFunc) Eval(env *Environment) (document.Value, error) {
-	v, ok := env.GetCurrentValue()
-	if !ok || v.Type != document.DocumentValue {
-		return nullLitteral, nil
+	idx := v.V.(int64)
+	if idx < 0 {
+		idx += n
 	}
 
-	keyer, ok := v.V.(document.Keyer)
-	if !ok {
-		return nullLitteral, nil
+	switch {
+	case idx < 0:
+		return 0, nil
+	case idx > n:
+		return n, nil
 	}
 
-	return keyer.Key()
+	return idx, nil
 }
 
 // IsEqual compares this expression with the other expression and returns
 // true if they are equal.
-func (k PKFunc) IsEqual(other Expr) bool {
-	_, ok := other.(PKFunc)
-	return ok
-}
+func (a *ArraySliceFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ArraySliceFunc)
+	if !ok {
+		return false
+	}
 
-func (k PKFunc) String() string {
-	return "pk()"
+	if (a.End == nil) != (o.End == nil) {
+		return false
+	}
+
+	return Equal(a.Array, o.Array) && Equal(a.Start, o.Start) && (a.End == nil || Equal(a.End, o.End))
 }
 
-// CastFunc represents the CAST expression.
-type CastFunc struct {
-	Expr   Expr
-	CastAs document.ValueType
+func (a *ArraySliceFunc) String() string {
+	if a.End == nil {
+		return fmt.Sprintf("array_slice(%v, %v)", a.Array, a.Start)
+	}
+
+	return fmt.Sprintf("array_slice(%v, %v, %v)", a.Array, a.Start, a.End)
 }
 
-// Eval returns the primary key of the current document.
-func (c CastFunc) Eval(env *Environment) (document.Value, error) {
-	v, err := c.Expr.Eval(env)
-	if err != nil {
-		return v, err
+// ArrayConcatFunc is the array_concat() function.
+// It returns an array containing the elements of all the given arrays, in order.
+// NULL or missing arrays are treated as empty arrays.
+type ArrayConcatFunc []Expr
+
+// Eval returns the concatenation of the arrays.
+func (a ArrayConcatFunc) Eval(env *Environment) (document.Value, error) {
+	var res document.ValueBuffer
+
+	for _, e := range a {
+		vb, err := evalArray("array_concat", e, env)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		err = vb.Iterate(func(i int, value document.Value) error {
+			res.Append(value)
+			return nil
+		})
+		if err != nil {
+			return nullLitteral, err
+		}
 	}
 
-	return v.CastAs(c.CastAs)
+	return document.NewArrayValue(&res), nil
 }
 
 // IsEqual compares this expression with the other expression and returns
 // true if they are equal.
-func (c CastFunc) IsEqual(other Expr) bool {
-	if other == nil {
-		return false
-	}
-
-	o, ok := other.(CastFunc)
+func (a ArrayConcatFunc) IsEqual(other Expr) bool {
+	o, ok := other.(ArrayConcatFunc)
 	if !ok {
 		return false
 	}
 
-	if c.CastAs != o.CastAs {
-		return false
-	}
+	return LiteralExprList(a).IsEqual(LiteralExprList(o))
+}
 
-	if c.Expr != nil {
-		return Equal(c.Expr, o.Expr)
-	}
+func (a ArrayConcatFunc) String() string {
+	var b strings.Builder
 
-	return o.Expr != nil
-}
+	b.WriteString("array_concat(")
+	for i, e := range a {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", e))
+	}
+	b.WriteRune(')')
 
-func (c CastFunc) String() string {
-	return fmt.Sprintf("CAST(%v AS %v)", c.Expr, c.CastAs)
+	return b.String()
 }
 
 // CountFunc is the COUNT aggregator function. It aggregates documents
@@ -223,7 +1420,9 @@ func (c *CountAggregator) Add(d document.Document) error {
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
-	if v != nullLitteral {
+	// absent fields are evaluated as NULL and
+	// must not be counted either.
+	if err == nil && v.Type != document.NullValue {
 		c.Count++
 	}
 
@@ -502,20 +1701,27 @@ type SumAggregator struct {
 	Fn   *SumFunc
 	SumI *int64
 	SumF *float64
+	SumD *document.Decimal
 }
 
 // Add stores the sum of all non-NULL numeric values in the group.
 // The result is an integer value if all summed values are integers.
-// If any of the value is a double, the returned result will be a double.
+// If any of the value is a decimal, the returned result will be a decimal.
+// Otherwise, if any of the value is a double, or if the sum overflows,
+// the returned result will be a double.
 func (s *SumAggregator) Add(d document.Document) error {
 	v, err := s.Fn.Expr.Eval(NewEnvironment(document.NewDocumentValue(d)))
 	if err != nil && err != document.ErrFieldNotFound {
 		return err
 	}
-	if v.Type != document.IntegerValue && v.Type != document.DoubleValue {
+	if !v.Type.IsNumber() {
 		return nil
 	}
 
+	if s.SumD != nil || v.Type == document.DecimalValue {
+		return s.addDecimal(v)
+	}
+
 	if s.SumF != nil {
 		if v.Type == document.IntegerValue {
 			*s.SumF += float64(v.V.(int64))
@@ -542,13 +1748,52 @@ func (s *SumAggregator) Add(d document.Document) error {
 		s.SumI = &sumI
 	}
 
-	*s.SumI += v.V.(int64)
+	// if the sum overflows, it is converted to a double
+	sum, err := document.NewIntegerValue(*s.SumI).Add(v)
+	if err != nil {
+		return err
+	}
+	if sum.Type == document.DoubleValue {
+		sumF := sum.V.(float64)
+		s.SumF = &sumF
+		return nil
+	}
+
+	*s.SumI = sum.V.(int64)
+	return nil
+}
+
+// addDecimal adds v to the sum, converted to a decimal.
+func (s *SumAggregator) addDecimal(v document.Value) error {
+	sum := document.NewDecimalValue(document.Decimal{})
+	switch {
+	case s.SumD != nil:
+		sum = document.NewDecimalValue(*s.SumD)
+	case s.SumF != nil:
+		sum = document.NewDoubleValue(*s.SumF)
+	case s.SumI != nil:
+		sum = document.NewIntegerValue(*s.SumI)
+	}
+
+	sum, err := sum.Add(v)
+	if err != nil {
+		return err
+	}
+	// NaN and infinite doubles can't be added to decimals
+	if sum.Type != document.DecimalValue {
+		return nil
+	}
+
+	x := sum.V.(document.Decimal)
+	s.SumD = &x
 	return nil
 }
 
 // Aggregate adds a field to the given buffer with the maximum value.
 func (s *SumAggregator) Aggregate(fb *document.FieldBuffer) error {
-	if s.SumF != nil {
+	if s.SumD != nil {
+		fb.Add(s.Fn.String(), document.NewDecimalValue(*s.SumD))
+	} else if s.SumF != nil {
 		fb.Add(s.Fn.String(), document.NewDoubleValue(*s.SumF))
 	} else if s.SumI != nil {
 		fb.Add(s.Fn.String(), document.NewIntegerValue(*s.SumI))
@@ -631,6 +1876,8 @@ func (s *AvgAggregator) Add(d document.Document) error {
 		s.Avg += float64(v.V.(int64))
 	case document.DoubleValue:
 		s.Avg += v.V.(float64)
+	case document.DecimalValue:
+		s.Avg += v.V.(document.Decimal).Float64()
 	default:
 		return nil
 	}
@@ -649,3 +1896,316 @@ func (s *AvgAggregator) Aggregate(fb *document.FieldBuffer) error {
 
 	return nil
 }
+
+// ArrayAggFunc is the ARRAY_AGG aggregator function.
+type ArrayAggFunc struct {
+	Expr  Expr
+	Alias string
+}
+
+// Eval extracts the aggregated array from the given document and returns it.
+func (a *ArrayAggFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, errors.New("misuse of aggregation function ARRAY_AGG()")
+	}
+
+	return v.V.(document.Document).GetByField(a.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (a *ArrayAggFunc) SetAlias(alias string) {
+	a.Alias = alias
+}
+
+// Aggregator implements the planner.AggregatorBuilder interface.
+func (a *ArrayAggFunc) Aggregator(group document.Value) document.Aggregator {
+	return &ArrayAggAggregator{
+		Fn: a,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *ArrayAggFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*ArrayAggFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(a.Expr, o.Expr)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the array_agg expression.
+func (a *ArrayAggFunc) String() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+
+	return fmt.Sprintf("ARRAY_AGG(%v)", a.Expr)
+}
+
+// ArrayAggAggregator is an aggregator that collects non-null values into an array.
+type ArrayAggAggregator struct {
+	Fn     *ArrayAggFunc
+	Values *document.ValueBuffer
+}
+
+// Add appends the value of the expression to the array, in the order
+// the documents are read. Documents and arrays are copied, as they may be
+// reused by the stream.
+func (a *ArrayAggAggregator) Add(d document.Document) error {
+	v, err := a.Fn.Expr.Eval(NewEnvironment(document.NewDocumentValue(d)))
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err != nil || v.Type == document.NullValue {
+		return nil
+	}
+
+	var vb document.ValueBuffer
+	err = vb.Copy(document.NewValueBuffer(v))
+	if err != nil {
+		return err
+	}
+	v, err = vb.GetByIndex(0)
+	if err != nil {
+		return err
+	}
+
+	if a.Values == nil {
+		a.Values = document.NewValueBuffer()
+	}
+	a.Values.Append(v)
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the array,
+// or NULL if there were no values.
+func (a *ArrayAggAggregator) Aggregate(fb *document.FieldBuffer) error {
+	if a.Values == nil {
+		fb.Add(a.Fn.String(), document.NewNullValue())
+	} else {
+		fb.Add(a.Fn.String(), document.NewArrayValue(a.Values))
+	}
+
+	return nil
+}
+
+// GroupConcatFunc is the GROUP_CONCAT aggregator function.
+type GroupConcatFunc struct {
+	Expr      Expr
+	Separator Expr
+	Alias     string
+}
+
+// Eval extracts the concatenated text from the given document and returns it.
+func (g *GroupConcatFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return document.Value{}, errors.New("misuse of aggregation function GROUP_CONCAT()")
+	}
+
+	return v.V.(document.Document).GetByField(g.String())
+}
+
+// SetAlias implements the planner.AggregatorBuilder interface.
+func (g *GroupConcatFunc) SetAlias(alias string) {
+	g.Alias = alias
+}
+
+// Aggregator implements the planner.AggregatorBuilder interface.
+func (g *GroupConcatFunc) Aggregator(group document.Value) document.Aggregator {
+	return &GroupConcatAggregator{
+		Fn: g,
+	}
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (g *GroupConcatFunc) IsEqual(other Expr) bool {
+	if other == nil {
+		return false
+	}
+
+	o, ok := other.(*GroupConcatFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(g.Expr, o.Expr) && equalOrNil(g.Separator, o.Separator)
+}
+
+// String returns the alias if non-zero, otherwise it returns a string representation
+// of the group_concat expression.
+func (g *GroupConcatFunc) String() string {
+	if g.Alias != "" {
+		return g.Alias
+	}
+
+	if g.Separator == nil {
+		return fmt.Sprintf("GROUP_CONCAT(%v)", g.Expr)
+	}
+
+	return fmt.Sprintf("GROUP_CONCAT(%v, %v)", g.Expr, g.Separator)
+}
+
+// GroupConcatAggregator is an aggregator that concatenates non-null values
+// into a text.
+type GroupConcatAggregator struct {
+	Fn   *GroupConcatFunc
+	Text *strings.Builder
+}
+
+// Add converts the value of the expression to text and appends it,
+// in the order the documents are read.
+// Values are separated by a comma, or by the text the separator expression
+// evaluates to for the document being added. A NULL separator is empty.
+func (g *GroupConcatAggregator) Add(d document.Document) error {
+	env := NewEnvironment(document.NewDocumentValue(d))
+
+	v, err := g.Fn.Expr.Eval(env)
+	if err != nil && err != document.ErrFieldNotFound {
+		return err
+	}
+	if err != nil || v.Type == document.NullValue {
+		return nil
+	}
+
+	v, err = v.CastAsText()
+	if err != nil {
+		return fmt.Errorf("GROUP_CONCAT(): %w", err)
+	}
+
+	if g.Text == nil {
+		g.Text = new(strings.Builder)
+	} else {
+		sep := ","
+		if g.Fn.Separator != nil {
+			sep, _, err = evalText("GROUP_CONCAT", g.Fn.Separator, env)
+			if err != nil {
+				return err
+			}
+		}
+		g.Text.WriteString(sep)
+	}
+
+	g.Text.WriteString(v.V.(string))
+	return nil
+}
+
+// Aggregate adds a field to the given buffer with the concatenated text,
+// or NULL if there were no values.
+func (g *GroupConcatAggregator) Aggregate(fb *document.FieldBuffer) error {
+	if g.Text == nil {
+		fb.Add(g.Fn.String(), document.NewNullValue())
+	} else {
+		fb.Add(g.Fn.String(), document.NewTextValue(g.Text.String()))
+	}
+
+	return nil
+}
+
+// A WindowFunc is a function whose value depends on the position of the document
+// in the result set, once sorted by the ORDER BY clause.
+// Its value is computed after the sort stage and must be selected directly
+// by the projection, evaluating it anywhere else returns NULL.
+// When followed by an OVER clause, its value depends on the position of the
+// document in its window instead, see OverFunc.
+type WindowFunc interface {
+	Expr
+
+	// WindowValue returns the value of the function for the document at the given position.
+	WindowValue(pos WindowPosition) document.Value
+}
+
+// WindowPosition describes the position of a document in a sorted result set.
+// Documents that have the same ORDER BY value are peers.
+type WindowPosition struct {
+	// RowNumber is the position of the document, starting at 1.
+	RowNumber int64
+	// Rank is the RowNumber of the first peer of the document.
+	Rank int64
+	// DenseRank is the number of distinct ORDER BY values up to the document.
+	DenseRank int64
+}
+
+// RowNumberFunc is the row_number() window function. It returns the position
+// of the document in the result set.
+type RowNumberFunc struct{}
+
+// Eval returns NULL, the value is computed by WindowValue.
+func (RowNumberFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, nil
+}
+
+// WindowValue implements the WindowFunc interface.
+func (RowNumberFunc) WindowValue(pos WindowPosition) document.Value {
+	return document.NewIntegerValue(pos.RowNumber)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (RowNumberFunc) IsEqual(other Expr) bool {
+	_, ok := other.(RowNumberFunc)
+	return ok
+}
+
+func (RowNumberFunc) String() string {
+	return "row_number()"
+}
+
+// RankFunc is the rank() window function. It returns the position of the document
+// in the result set, peers sharing the same rank and leaving gaps after them.
+type RankFunc struct{}
+
+// Eval returns NULL, the value is computed by WindowValue.
+func (RankFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, nil
+}
+
+// WindowValue implements the WindowFunc interface.
+func (RankFunc) WindowValue(pos WindowPosition) document.Value {
+	return document.NewIntegerValue(pos.Rank)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (RankFunc) IsEqual(other Expr) bool {
+	_, ok := other.(RankFunc)
+	return ok
+}
+
+func (RankFunc) String() string {
+	return "rank()"
+}
+
+// DenseRankFunc is the dense_rank() window function. It works like rank()
+// but without gaps.
+type DenseRankFunc struct{}
+
+// Eval returns NULL, the value is computed by WindowValue.
+func (DenseRankFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, nil
+}
+
+// WindowValue implements the WindowFunc interface.
+func (DenseRankFunc) WindowValue(pos WindowPosition) document.Value {
+	return document.NewIntegerValue(pos.DenseRank)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (DenseRankFunc) IsEqual(other Expr) bool {
+	_, ok := other.(DenseRankFunc)
+	return ok
+}
+
+func (DenseRankFunc) String() string {
+	return "dense_rank()"
+}
@@ -1,10 +1,16 @@
 package expr_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
 	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPkExpr(t *testing.T) {
@@ -24,3 +30,331 @@ func TestPkExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestCastExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"CAST(5.9 AS INTEGER)", document.NewIntegerValue(5), false},
+		{"CAST(-5.9 AS INTEGER)", document.NewIntegerValue(-5), false},
+		{"CAST('5.9' AS INTEGER)", document.NewIntegerValue(5), false},
+		{"CAST(10000000000000000000.0 AS INTEGER)", document.Value{}, true},
+		{"CAST(-10000000000000000000.0 AS INTEGER)", document.Value{}, true},
+		{"CAST('foo' AS INTEGER)", document.Value{}, true},
+		{"CAST(true AS INTEGER)", document.NewIntegerValue(1), false},
+		{"CAST(a AS DOUBLE)", document.NewDoubleValue(1), false},
+		{"CAST('1.5' AS DOUBLE PRECISION)", document.NewDoubleValue(1.5), false},
+		{"CAST(a AS TEXT)", document.NewTextValue("1"), false},
+		{"CAST(c AS VARCHAR(255))", document.NewTextValue(`[1, {"foo": "bar"}, [1, 2]]`), false},
+		{"CAST('true' AS BOOL)", document.NewBoolValue(true), false},
+		{"CAST(0 AS BOOL)", document.NewBoolValue(false), false},
+		{"CAST('YWJj' AS BLOB)", document.NewBlobValue([]byte("abc")), false},
+		{"CAST(a AS BLOB)", document.Value{}, true},
+		{"CAST(a AS DOCUMENT)", document.Value{}, true},
+		{"CAST(a AS ARRAY)", document.Value{}, true},
+		{"CAST(NULL AS INTEGER)", nullLitteral, false},
+		{"CAST(notFound AS TEXT)", nullLitteral, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+
+			// the string representation can be parsed back
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+			pe, _, err := parser.NewParser(strings.NewReader(fmt.Sprintf("%v", e))).ParseExpr()
+			require.NoError(t, err)
+			require.True(t, expr.Equal(e, pe))
+		})
+	}
+
+	require.False(t, expr.Equal(expr.CastFunc{CastAs: document.TextValue}, expr.CastFunc{Expr: parser.MustParseExpr("a"), CastAs: document.TextValue}))
+	require.False(t, expr.Equal(parser.MustParseExpr("CAST(a AS TEXT)"), parser.MustParseExpr("CAST(a AS BLOB)")))
+}
+
+func TestObjectExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   string
+		fails bool
+	}{
+		{"object()", `{}`, false},
+		{"object(a)", `{"a": 1}`, false},
+		{"object(a, c[1].foo, c[2])", `{"a": 1, "foo": "bar", "c[2]": [1, 2]}`, false},
+		{"object(a, notFound, a + 1)", `{"a": 1, "notFound": null, "a + 1": 2}`, false},
+		{"object(a, object(a))", `{"a": 1, "object(a)": {"a": 1}}`, false},
+		{"object(a, a)", ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			v, err := e.Eval(envWithDoc)
+			require.NoError(t, err)
+			require.Equal(t, document.DocumentValue, v.Type)
+
+			data, err := document.MarshalJSON(v.V.(document.Document))
+			require.NoError(t, err)
+			require.JSONEq(t, test.res, string(data))
+			require.Equal(t, test.expr, fmt.Sprintf("%v", e))
+		})
+	}
+}
+
+func TestArrayExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		res  string
+	}{
+		{"array()", `[]`},
+		{"array(a)", `[1]`},
+		{"ARRAY(a, c[1].foo, NULL, notFound, a + 1)", `[1, "bar", null, null, 2]`},
+		{"array(a, array(a), object(a))", `[1, [1], {"a": 1}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+
+			v, err := e.Eval(envWithDoc)
+			require.NoError(t, err)
+			require.Equal(t, document.ArrayValue, v.Type)
+
+			data, err := v.MarshalJSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.res, string(data))
+		})
+	}
+}
+
+func TestArrayUpdateFuncs(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   string
+		fails bool
+	}{
+		{"array_append(c, 3)", `[1, {"foo": "bar"}, [1, 2], 3]`, false},
+		{"array_append(notFound, 3)", `[3]`, false},
+		{"array_append(NULL, 2)", `[2]`, false},
+		{"array_append(a, 3)", ``, true},
+		{"array_remove(c, 1)", `[{"foo": "bar"}, [1, 2]]`, false},
+		{"array_remove(c, [1, 2])", `[1, {"foo": "bar"}]`, false},
+		{"array_remove(array(1, 2, 1), 1)", `[2]`, false},
+		{"array_remove(c, 10)", `[1, {"foo": "bar"}, [1, 2]]`, false},
+		{"array_remove(notFound, 1)", `[]`, false},
+		{"array_remove(a, 1)", ``, true},
+		{"array_remove_at(c, 1)", `[1, [1, 2]]`, false},
+		{"array_remove_at(c, 3)", ``, true},
+		{"array_remove_at(c, -1)", ``, true},
+		{"array_remove_at(c, 'a')", ``, true},
+		{"array_remove_at(notFound, 0)", ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+
+			v, err := e.Eval(envWithDoc)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, document.ArrayValue, v.Type)
+
+			data, err := v.MarshalJSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.res, string(data))
+			require.Equal(t, test.expr, fmt.Sprintf("%v", e))
+		})
+	}
+}
+
+func TestArrayFuncs(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   string
+		fails bool
+	}{
+		{"array_length(c)", `3`, false},
+		{"array_length(array())", `0`, false},
+		{"array_length(notFound)", `null`, false},
+		{"array_length(a)", ``, true},
+		{"array_contains(c, 1)", `true`, false},
+		{"array_contains(c, [1, 2])", `true`, false},
+		{`array_contains(c, {"foo": "bar"})`, `true`, false},
+		{"array_contains(c, 2)", `false`, false},
+		{"array_contains(notFound, 1)", `null`, false},
+		{"array_contains(a, 1)", ``, true},
+		{"array_slice(c, 1)", `[{"foo": "bar"}, [1, 2]]`, false},
+		{"array_slice(c, 0, 2)", `[1, {"foo": "bar"}]`, false},
+		{"array_slice(c, -2, -1)", `[{"foo": "bar"}]`, false},
+		{"array_slice(c, 1, 10)", `[{"foo": "bar"}, [1, 2]]`, false},
+		{"array_slice(c, 2, 1)", `[]`, false},
+		{"array_slice(c, -10)", `[1, {"foo": "bar"}, [1, 2]]`, false},
+		{"array_slice(notFound, 1)", `null`, false},
+		{"array_slice(c, 'a')", ``, true},
+		{"array_slice(a, 1)", ``, true},
+		{"array_concat(c, array(3), notFound, [4])", `[1, {"foo": "bar"}, [1, 2], 3, 4]`, false},
+		{"array_concat(c, a)", ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+
+			v, err := e.Eval(envWithDoc)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := v.MarshalJSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.res, string(data))
+			require.Equal(t, test.expr, fmt.Sprintf("%v", e))
+		})
+	}
+
+	t.Run("Arguments", func(t *testing.T) {
+		for _, s := range []string{"array_length()", "array_contains(c)", "array_slice(c)", "array_slice(c, 1, 2, 3)", "array_concat(c)"} {
+			_, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+			require.Error(t, err, s)
+		}
+	})
+}
+
+func TestDateFuncs(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   string
+		fails bool
+	}{
+		{`date_trunc('microsecond', '2021-05-12T15:04:05.123456Z')`, `"2021-05-12T15:04:05.123456Z"`, false},
+		{`date_trunc('millisecond', '2021-05-12T15:04:05.123456Z')`, `"2021-05-12T15:04:05.123Z"`, false},
+		{`date_trunc('second', '2021-05-12T15:04:05.123456Z')`, `"2021-05-12T15:04:05Z"`, false},
+		{`date_trunc('minute', '2021-05-12T15:04:05Z')`, `"2021-05-12T15:04:00Z"`, false},
+		{`date_trunc('HOUR', '2021-05-12T15:04:05Z')`, `"2021-05-12T15:00:00Z"`, false},
+		{`date_trunc('day', '2021-05-12T15:04:05+02:00')`, `"2021-05-12T00:00:00Z"`, false},
+		{`date_trunc('week', '2021-05-12T15:04:05Z')`, `"2021-05-10T00:00:00Z"`, false},
+		{`date_trunc('week', '2021-05-16T15:04:05Z')`, `"2021-05-10T00:00:00Z"`, false},
+		{`date_trunc('month', '2021-05-12T15:04:05Z')`, `"2021-05-01T00:00:00Z"`, false},
+		{`date_trunc('quarter', '2021-05-12T15:04:05Z')`, `"2021-04-01T00:00:00Z"`, false},
+		{`date_trunc('year', '2021-05-12T15:04:05Z')`, `"2021-01-01T00:00:00Z"`, false},
+		{`date_trunc('day', CAST('2021-05-12T15:04:05Z' AS TIMESTAMP) + 86400)`, `"2021-05-13T00:00:00Z"`, false},
+		{`date_trunc('day', notFound)`, `null`, false},
+		{`date_trunc('century', '2021-05-12T15:04:05Z')`, ``, true},
+		{`date_trunc(1, '2021-05-12T15:04:05Z')`, ``, true},
+		{`date_trunc('day', '2021-05-12')`, ``, true},
+		{`date_trunc('day', a)`, ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+
+			v, err := e.Eval(envWithDoc)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := v.MarshalJSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.res, string(data))
+
+			// the string representation can be parsed back
+			pe, _, err := parser.NewParser(strings.NewReader(fmt.Sprintf("%v", e))).ParseExpr()
+			require.NoError(t, err)
+			require.True(t, expr.Equal(e, pe))
+		})
+	}
+
+	t.Run("now()", func(t *testing.T) {
+		before := time.Now()
+
+		v, err := expr.NowFunc{}.Eval(envWithDoc)
+		require.NoError(t, err)
+		require.Equal(t, document.TimestampValue, v.Type)
+		require.False(t, v.V.(time.Time).Before(before.Truncate(time.Microsecond)))
+		require.False(t, v.V.(time.Time).After(time.Now()))
+	})
+}
+
+func TestUserFunc(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.RegisterFunc("inc", 1, func(args []document.Value) (document.Value, error) {
+		return args[0].Add(document.NewIntegerValue(1))
+	})
+	require.NoError(t, err)
+
+	tx, err := db.Begin(false)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	env := expr.NewEnvironment(document.NewDocumentValue(doc))
+	env.Tx = tx.Transaction
+
+	e, err := parser.ParseExpr("INC(a)")
+	require.NoError(t, err)
+	require.IsType(t, &expr.UserFunc{}, e)
+	require.Equal(t, "INC(a)", fmt.Sprintf("%v", e))
+
+	testExpr(t, "inc(a)", env, document.NewIntegerValue(2), false)
+	testExpr(t, "inc(inc(1))", env, document.NewIntegerValue(3), false)
+	testExpr(t, "inc()", env, nullLitteral, true)
+	testExpr(t, "unknown(a)", env, nullLitteral, true)
+
+	// functions are looked up in the database of the transaction
+	testExpr(t, "inc(a)", envWithDoc, nullLitteral, true)
+}
+
+func TestHasExpr(t *testing.T) {
+	withNull := expr.NewEnvironment(document.NewDocumentValue(document.NewFieldBuffer().Add("a", document.NewNullValue())))
+
+	tests := []struct {
+		expr  string
+		env   *expr.Environment
+		res   document.Value
+		fails bool
+	}{
+		{"has(a)", envWithDoc, document.NewBoolValue(true), false},
+		{"has(b.`foo bar`[1])", envWithDoc, document.NewBoolValue(true), false},
+		{"has(c[1].foo)", envWithDoc, document.NewBoolValue(true), false},
+		{"has(d)", envWithDoc, document.NewBoolValue(false), false},
+		{"has(a.b)", envWithDoc, document.NewBoolValue(false), false},
+		{"has(c[10])", envWithDoc, document.NewBoolValue(false), false},
+		{"has(a)", withNull, document.NewBoolValue(true), false},
+		{"has(a)", &expr.Environment{}, document.NewBoolValue(false), false},
+		{"NOT has(d)", envWithDoc, document.NewBoolValue(true), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, test.env, test.res, test.fails)
+		})
+	}
+
+	for _, s := range []string{"has()", "has(a, b)", "has(1)", "has(a + 1)"} {
+		_, err := parser.ParseExpr(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,418 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+)
+
+// evalJSON evaluates e and returns the resulting value.
+// Texts are decoded as JSON, other values are returned as is.
+// It also returns true if the value was decoded from a text.
+func evalJSON(fname string, e Expr, env *Environment) (document.Value, bool, error) {
+	v, err := e.Eval(env)
+	if err != nil || v.Type != document.TextValue {
+		return v, false, err
+	}
+
+	jv, err := document.ParseJSON([]byte(v.V.(string)))
+	if err != nil {
+		return nullLitteral, true, fmt.Errorf("%s(): %w", fname, err)
+	}
+
+	return jv, true, nil
+}
+
+// evalJSONPath evaluates e and parses the resulting text as a JSON path.
+// Paths start with an optional $, followed by field names, prefixed by a dot
+// if they are not the first fragment, and array indexes between brackets, e.g. $.a.b[0].
+// Field names containing other characters than letters, digits and underscores
+// must be surrounded by double quotes.
+func evalJSONPath(fname string, e Expr, env *Environment) (document.Path, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if v.Type != document.TextValue {
+		return nil, fmt.Errorf("%s(): expected text path, got %s", fname, v.Type)
+	}
+
+	p, err := parseJSONPath(v.V.(string))
+	if err != nil {
+		return nil, fmt.Errorf("%s(): invalid path %q: %w", fname, v.V.(string), err)
+	}
+
+	return p, nil
+}
+
+func parseJSONPath(s string) (document.Path, error) {
+	var p document.Path
+
+	s = strings.TrimPrefix(s, "$")
+	for i := 0; len(s) > 0; i++ {
+		switch {
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ]")
+			}
+
+			idx, err := strconv.Atoi(s[1:end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid array index %q", s[1:end])
+			}
+
+			p = append(p, document.PathFragment{ArrayIndex: idx})
+			s = s[end+1:]
+			continue
+		case s[0] == '.':
+			s = s[1:]
+		case i > 0:
+			return nil, fmt.Errorf("unexpected %q", s[0])
+		}
+
+		var name string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf(`missing "`)
+			}
+
+			name, s = s[1:end+1], s[end+2:]
+		} else {
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+
+			name, s = s[:end], s[end:]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("missing field name")
+		}
+
+		p = append(p, document.PathFragment{FieldName: name})
+	}
+
+	return p, nil
+}
+
+// getJSONValue returns the value at path p in v, or false if it doesn't exist.
+// An empty path returns v.
+func getJSONValue(v document.Value, p document.Path) (document.Value, bool, error) {
+	if len(p) == 0 {
+		return v, true, nil
+	}
+
+	if v.Type != document.DocumentValue && v.Type != document.ArrayValue {
+		return nullLitteral, false, nil
+	}
+
+	res, err := p.GetValue(v)
+	if err == document.ErrFieldNotFound || err == document.ErrValueNotFound {
+		return nullLitteral, false, nil
+	}
+
+	return res, err == nil, err
+}
+
+// jsonRootField is the field under which values are stored to be modified
+// by json_set() and json_remove(), whatever their type.
+const jsonRootField = "$"
+
+// toJSONResult returns v as a JSON text if the original value was a text.
+func toJSONResult(v document.Value, text bool) (document.Value, error) {
+	if !text {
+		return v, nil
+	}
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(string(data)), nil
+}
+
+// JSONFunc is the json() function.
+// It decodes a text containing JSON, other values are returned as is.
+type JSONFunc struct {
+	Expr Expr
+}
+
+// Eval returns the decoded value.
+func (j *JSONFunc) Eval(env *Environment) (document.Value, error) {
+	v, _, err := evalJSON("json", j.Expr, env)
+	return v, err
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (j *JSONFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*JSONFunc)
+	return ok && Equal(j.Expr, o.Expr)
+}
+
+func (j *JSONFunc) String() string {
+	return fmt.Sprintf("json(%v)", j.Expr)
+}
+
+// JSONValidFunc is the json_valid() function.
+// It returns true if a text contains valid JSON. Other values, except NULL, are always valid.
+type JSONValidFunc struct {
+	Expr Expr
+}
+
+// Eval returns true if the value is valid JSON, or NULL if it is NULL.
+func (j *JSONValidFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := j.Expr.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	switch v.Type {
+	case document.NullValue:
+		return nullLitteral, nil
+	case document.TextValue:
+		_, err = document.ParseJSON([]byte(v.V.(string)))
+		return document.NewBoolValue(err == nil), nil
+	}
+
+	return trueLitteral, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (j *JSONValidFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*JSONValidFunc)
+	return ok && Equal(j.Expr, o.Expr)
+}
+
+func (j *JSONValidFunc) String() string {
+	return fmt.Sprintf("json_valid(%v)", j.Expr)
+}
+
+// JSONExtractFunc is the json_extract() function.
+// It returns the value at the given path of a document, an array, or a text containing JSON.
+// It returns NULL if the path doesn't exist.
+type JSONExtractFunc struct {
+	Expr Expr
+	Path Expr
+}
+
+// Eval returns the value at the path.
+func (j *JSONExtractFunc) Eval(env *Environment) (document.Value, error) {
+	v, _, err := evalJSON("json_extract", j.Expr, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	p, err := evalJSONPath("json_extract", j.Path, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v, _, err = getJSONValue(v, p)
+	return v, err
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (j *JSONExtractFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*JSONExtractFunc)
+	return ok && Equal(j.Expr, o.Expr) && Equal(j.Path, o.Path)
+}
+
+func (j *JSONExtractFunc) String() string {
+	return fmt.Sprintf("json_extract(%v, %v)", j.Expr, j.Path)
+}
+
+// JSONTypeFunc is the json_type() function.
+// It returns the JSON type of a value, or of the value at the given path:
+// 'null', 'boolean', 'integer', 'double', 'text', 'array' or 'object'.
+// Timestamps, encoded as JSON strings, return 'text'.
+// Blobs, which can't be represented in JSON, return 'blob'.
+// It returns NULL if the path doesn't exist.
+type JSONTypeFunc struct {
+	Expr Expr
+	Path Expr
+}
+
+// Eval returns the type of the value.
+func (j *JSONTypeFunc) Eval(env *Environment) (document.Value, error) {
+	v, _, err := evalJSON("json_type", j.Expr, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if j.Path != nil {
+		p, err := evalJSONPath("json_type", j.Path, env)
+		if err != nil {
+			return nullLitteral, err
+		}
+
+		var ok bool
+		v, ok, err = getJSONValue(v, p)
+		if err != nil || !ok {
+			return nullLitteral, err
+		}
+	}
+
+	var t string
+	switch v.Type {
+	case document.NullValue:
+		t = "null"
+	case document.BoolValue:
+		t = "boolean"
+	case document.IntegerValue:
+		t = "integer"
+	case document.DoubleValue, document.DecimalValue:
+		t = "double"
+	case document.TextValue, document.TimestampValue:
+		t = "text"
+	case document.ArrayValue:
+		t = "array"
+	case document.DocumentValue:
+		t = "object"
+	default:
+		t = "blob"
+	}
+
+	return document.NewTextValue(t), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (j *JSONTypeFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*JSONTypeFunc)
+	if !ok || (j.Path == nil) != (o.Path == nil) {
+		return false
+	}
+
+	return Equal(j.Expr, o.Expr) && (j.Path == nil || Equal(j.Path, o.Path))
+}
+
+func (j *JSONTypeFunc) String() string {
+	if j.Path == nil {
+		return fmt.Sprintf("json_type(%v)", j.Expr)
+	}
+
+	return fmt.Sprintf("json_type(%v, %v)", j.Expr, j.Path)
+}
+
+// JSONSetFunc is the json_set() function.
+// It returns a copy of a document or an array with the value at the given path replaced,
+// or created if only the last fragment of the path doesn't exist.
+// If the original value is a text containing JSON, the result is encoded as JSON as well.
+type JSONSetFunc struct {
+	Expr  Expr
+	Path  Expr
+	Value Expr
+}
+
+// Eval returns the modified value.
+func (j *JSONSetFunc) Eval(env *Environment) (document.Value, error) {
+	v, text, err := evalJSON("json_set", j.Expr, env)
+	if err != nil || v.Type == document.NullValue {
+		return nullLitteral, err
+	}
+
+	p, err := evalJSONPath("json_set", j.Path, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	nv, err := j.Value.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	fb := document.NewFieldBuffer().Add(jsonRootField, v)
+	err = fb.Set(append(document.Path{{FieldName: jsonRootField}}, p...), nv)
+	if err != nil {
+		return nullLitteral, fmt.Errorf("json_set(): cannot set %s: %w", p, err)
+	}
+
+	v, err = fb.GetByField(jsonRootField)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return toJSONResult(v, text)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (j *JSONSetFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*JSONSetFunc)
+	return ok && Equal(j.Expr, o.Expr) && Equal(j.Path, o.Path) && Equal(j.Value, o.Value)
+}
+
+func (j *JSONSetFunc) String() string {
+	return fmt.Sprintf("json_set(%v, %v, %v)", j.Expr, j.Path, j.Value)
+}
+
+// JSONRemoveFunc is the json_remove() function.
+// It returns a copy of a document or an array without the value at the given path.
+// If the path doesn't exist, the value is returned unchanged.
+// If the original value is a text containing JSON, the result is encoded as JSON as well.
+type JSONRemoveFunc struct {
+	Expr Expr
+	Path Expr
+}
+
+// Eval returns the modified value.
+func (j *JSONRemoveFunc) Eval(env *Environment) (document.Value, error) {
+	v, text, err := evalJSON("json_remove", j.Expr, env)
+	if err != nil || v.Type == document.NullValue {
+		return nullLitteral, err
+	}
+
+	p, err := evalJSONPath("json_remove", j.Path, env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if len(p) == 0 {
+		return nullLitteral, fmt.Errorf("json_remove(): cannot remove the root value")
+	}
+
+	_, ok, err := getJSONValue(v, p)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if !ok {
+		return toJSONResult(v, text)
+	}
+
+	// the value is copied, as Delete modifies the documents and arrays in place
+	var fb document.FieldBuffer
+	err = fb.Copy(document.NewFieldBuffer().Add(jsonRootField, v))
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	err = fb.Delete(append(document.Path{{FieldName: jsonRootField}}, p...))
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	v, err = fb.GetByField(jsonRootField)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return toJSONResult(v, text)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (j *JSONRemoveFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*JSONRemoveFunc)
+	return ok && Equal(j.Expr, o.Expr) && Equal(j.Path, o.Path)
+}
+
+func (j *JSONRemoveFunc) String() string {
+	return fmt.Sprintf("json_remove(%v, %v)", j.Expr, j.Path)
+}
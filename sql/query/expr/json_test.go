@@ -0,0 +1,100 @@
+package expr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFuncs(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   string
+		fails bool
+	}{
+		{`json('{"a": [1, 2.5]}')`, `{"a": [1, 2.5]}`, false},
+		{`json('"foo"')`, `"foo"`, false},
+		{`json(c)`, `[1, {"foo": "bar"}, [1, 2]]`, false},
+		{`json(NULL)`, `null`, false},
+		{`json('foo')`, ``, true},
+		{`json_valid('{"a": 1}')`, `true`, false},
+		{`json_valid('{"a": 1')`, `false`, false},
+		{`json_valid(b)`, `true`, false},
+		{`json_valid(NULL)`, `null`, false},
+		{`json_extract(b, '$."foo bar"[1]')`, `2`, false},
+		{`json_extract(c, '$[1].foo')`, `"bar"`, false},
+		{`json_extract(c, '[2]')`, `[1, 2]`, false},
+		{`json_extract(c, '$')`, `[1, {"foo": "bar"}, [1, 2]]`, false},
+		{`json_extract('{"a": {"b": true}}', 'a.b')`, `true`, false},
+		{`json_extract('{"a": {"b": true}}', '$.a.c')`, `null`, false},
+		{`json_extract(a, '$.a')`, `null`, false},
+		{`json_extract(c, '$[')`, ``, true},
+		{`json_extract(c, '$.')`, ``, true},
+		{`json_extract(c, 1)`, ``, true},
+		{`json_type(a)`, `"integer"`, false},
+		{`json_type('1.5')`, `"double"`, false},
+		{`json_type('{"a": [null]}', '$.a')`, `"array"`, false},
+		{`json_type('{"a": [null]}', '$.a[0]')`, `"null"`, false},
+		{`json_type('{"a": [null]}', '$.b')`, `null`, false},
+		{`json_type(b)`, `"object"`, false},
+		{`json_type('"foo"')`, `"text"`, false},
+		{`json_type('false')`, `"boolean"`, false},
+		{`json_set(b, '$.foo', 1)`, `{"foo bar": [1, 2], "foo": 1}`, false},
+		{`json_set(b, '$."foo bar"[0]', 'a')`, `{"foo bar": ["a", 2]}`, false},
+		{`json_set(c, '$[1].foo', array(1))`, `[1, {"foo": [1]}, [1, 2]]`, false},
+		{`json_set('{"a": 1}', '$.b', 2)`, `"{\"a\": 1, \"b\": 2}"`, false},
+		{`json_set(b, '$', 1)`, `1`, false},
+		{`json_set(NULL, '$.a', 1)`, `null`, false},
+		{`json_set(b, '$.a.b', 1)`, ``, true},
+		{`json_remove(b, '$."foo bar"[0]')`, `{"foo bar": [2]}`, false},
+		{`json_remove(c, '$[1]')`, `[1, [1, 2]]`, false},
+		{`json_remove('{"a": 1, "b": 2}', 'a')`, `"{\"b\": 2}"`, false},
+		{`json_remove(b, '$.a')`, `{"foo bar": [1, 2]}`, false},
+		{`json_remove(c, '$.a')`, `[1, {"foo": "bar"}, [1, 2]]`, false},
+		{`json_remove(b, '$')`, ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+
+			v, err := e.Eval(envWithDoc)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := v.MarshalJSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.res, string(data))
+
+			// the string representation can be parsed back
+			pe, _, err := parser.NewParser(strings.NewReader(fmt.Sprintf("%v", e))).ParseExpr()
+			require.NoError(t, err)
+			require.True(t, expr.Equal(e, pe))
+		})
+	}
+
+	t.Run("Input is not modified", func(t *testing.T) {
+		e, _, err := parser.NewParser(strings.NewReader(`json_remove(b, '$."foo bar"[0]')`)).ParseExpr()
+		require.NoError(t, err)
+
+		fb := document.NewFieldBuffer()
+		require.NoError(t, fb.Copy(doc))
+		env := expr.NewEnvironment(document.NewDocumentValue(fb))
+
+		_, err = e.Eval(env)
+		require.NoError(t, err)
+
+		data, err := fb.MarshalJSON()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a": 1, "b": {"foo bar": [1, 2]}, "c": [1, {"foo": "bar"}, [1, 2]]}`, string(data))
+	})
+}
@@ -3,7 +3,9 @@ package expr
 import (
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/genjidb/genji/database"
 	"github.com/genjidb/genji/document"
 	"github.com/genjidb/genji/sql/query/glob"
 	"github.com/genjidb/genji/sql/scanner"
@@ -19,43 +21,104 @@ type likeOp struct {
 
 // Like creates an expression that evaluates to the result of a LIKE b.
 func Like(a, b Expr) Expr {
-	return &likeOp{&simpleOperator{a, b, scanner.LIKE}}
+	return likeOp{&simpleOperator{a, b, scanner.LIKE}}
 }
 
+// Eval returns true if a matches the pattern b.
+// It returns NULL if one of the operands is NULL, and false if a is not a text.
 func (op likeOp) Eval(env *Environment) (document.Value, error) {
 	a, b, err := op.simpleOperator.eval(env)
 	if err != nil {
 		return nullLitteral, err
 	}
 
-	if a.Type != document.TextValue || b.Type != document.TextValue {
+	if a.Type == document.NullValue || b.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	if b.Type != document.TextValue {
 		return nullLitteral, errors.New("LIKE operator takes a text")
 	}
 
-	if like(b.V.(string), a.V.(string)) {
+	if a.Type == document.TextValue && like(b.V.(string), a.V.(string)) {
 		return trueLitteral, nil
 	}
 
 	return falseLitteral, nil
 }
 
+// IterateIndex reads the documents whose indexed value matches the pattern.
+// Only the text values that start with the literal prefix of the pattern are read.
+func (op likeOp) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	if v.Type != document.TextValue {
+		if v.Type == document.NullValue {
+			return nil
+		}
+		return errors.New("LIKE operator takes a text")
+	}
+
+	pattern := v.V.(string)
+	min, max := glob.LikePrefixBounds(pattern)
+
+	err := idx.AscendGreaterOrEqual(document.NewTextValue(min), func(val, key []byte, isEqual bool) error {
+		iv, err := idx.DecodeValue(val)
+		if err != nil {
+			return err
+		}
+
+		// values are sorted by type, then by value
+		if iv.Type != document.TextValue {
+			return errStop
+		}
+
+		text := iv.V.(string)
+		if text > max && !strings.HasPrefix(text, max) {
+			return errStop
+		}
+
+		if !like(pattern, text) {
+			return nil
+		}
+
+		d, err := tb.GetDocument(key)
+		if err != nil {
+			return err
+		}
+
+		return fn(d)
+	})
+
+	if err != nil && err != errStop {
+		return err
+	}
+
+	return nil
+}
+
 func (op likeOp) String() string {
 	return fmt.Sprintf("%v LIKE %v", op.a, op.b)
 }
 
+// notLikeOp doesn't embed likeOp, it must not be used to read an index.
 type notLikeOp struct {
-	likeOp
+	*simpleOperator
 }
 
 // NotLike creates an expression that evaluates to the result of a NOT LIKE b.
 func NotLike(a, b Expr) Expr {
-	return &notLikeOp{likeOp{&simpleOperator{a, b, scanner.LIKE}}}
+	return notLikeOp{&simpleOperator{a, b, scanner.LIKE}}
 }
 
 func (op notLikeOp) Eval(env *Environment) (document.Value, error) {
-	return invertBoolResult(op.likeOp.Eval)(env)
+	return invertBoolResult(likeOp{op.simpleOperator}.Eval)(env)
 }
 
 func (op notLikeOp) String() string {
 	return fmt.Sprintf("%v NOT LIKE %v", op.a, op.b)
 }
+
+// IsLikeOperator reports if e is the LIKE operator.
+func IsLikeOperator(e Expr) bool {
+	_, ok := e.(likeOp)
+	return ok
+}
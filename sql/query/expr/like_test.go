@@ -0,0 +1,32 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+)
+
+func TestLikeExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"'abc' LIKE 'a%'", document.NewBoolValue(true), false},
+		{"'abc' LIKE 'A_C'", document.NewBoolValue(true), false},
+		{"'abc' LIKE 'b%'", document.NewBoolValue(false), false},
+		{"'abc' NOT LIKE 'b%'", document.NewBoolValue(true), false},
+		{"a LIKE '1'", document.NewBoolValue(false), false},
+		{"a NOT LIKE '1'", document.NewBoolValue(true), false},
+		{"NULL LIKE 'a%'", nullLitteral, false},
+		{"'abc' LIKE NULL", nullLitteral, false},
+		{"NULL NOT LIKE 'a%'", nullLitteral, false},
+		{"'abc' LIKE 1", nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+}
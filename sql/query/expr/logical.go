@@ -90,3 +90,47 @@ func (op *OrOp) Eval(env *Environment) (document.Value, error) {
 func (op *OrOp) String() string {
 	return fmt.Sprintf("%v OR %v", op.a, op.b)
 }
+
+// NotOp is the NOT unary operator.
+type NotOp struct {
+	*simpleOperator
+}
+
+// Not creates an expression that returns true if e is falsy.
+// The operand is stored as the right hand of the operator.
+func Not(e Expr) Expr {
+	return &NotOp{&simpleOperator{b: e, Tok: scanner.NOT}}
+}
+
+// Precedence returns the precedence of the NOT operator, which
+// is lower than the precedence of comparison operators and equal to the precedence of AND.
+func (op *NotOp) Precedence() int {
+	return scanner.AND.Precedence()
+}
+
+// Eval implements the Expr interface. It evaluates the operand and returns true if it is falsy.
+// If the operand evaluates to NULL, it returns NULL.
+func (op *NotOp) Eval(env *Environment) (document.Value, error) {
+	v, err := op.b.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if v.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	isTruthy, err := v.IsTruthy()
+	if err != nil {
+		return nullLitteral, err
+	}
+	if isTruthy {
+		return falseLitteral, nil
+	}
+
+	return trueLitteral, nil
+}
+
+// String implements the fmt.Stringer interface.
+func (op *NotOp) String() string {
+	return fmt.Sprintf("NOT %v", op.b)
+}
@@ -0,0 +1,81 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/fulltext"
+)
+
+// MatchFunc represents the match() function.
+// It returns true if a text contains all the words of a full-text query.
+// Words are compared once lowercased and stemmed, so that "Indexing" matches "indexes".
+type MatchFunc struct {
+	Expr  Expr
+	Query Expr
+}
+
+// Eval returns true if the text matches the query.
+// It returns NULL if one of the arguments is NULL, and false if the first argument is not a text.
+func (m MatchFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := m.Expr.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	q, err := m.Query.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if v.Type == document.NullValue || q.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	if q.Type != document.TextValue {
+		return nullLitteral, errors.New("match() takes a text query")
+	}
+
+	if v.Type == document.TextValue && fulltext.Match(v.V.(string), q.V.(string)) {
+		return trueLitteral, nil
+	}
+
+	return falseLitteral, nil
+}
+
+// IterateIndex reads the documents matching the query from a full-text index,
+// from the most to the least relevant.
+func (m MatchFunc) IterateIndex(idx *database.Index, tb *database.Table, v document.Value, fn func(d document.Document) error) error {
+	if v.Type != document.TextValue {
+		if v.Type == document.NullValue {
+			return nil
+		}
+		return errors.New("match() takes a text query")
+	}
+
+	if idx.FullText == nil {
+		return fmt.Errorf("index %q is not a full-text index", idx.Opts.IndexName)
+	}
+
+	return idx.FullText.Search(v.V.(string), func(k []byte, score float64) error {
+		d, err := tb.GetDocument(k)
+		if err != nil {
+			return err
+		}
+
+		return fn(d)
+	})
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (m MatchFunc) IsEqual(other Expr) bool {
+	o, ok := other.(MatchFunc)
+	return ok && Equal(m.Expr, o.Expr) && Equal(m.Query, o.Query)
+}
+
+func (m MatchFunc) String() string {
+	return fmt.Sprintf("match(%v, %v)", m.Expr, m.Query)
+}
@@ -0,0 +1,363 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/genjidb/genji/document"
+)
+
+// evalNumber evaluates e and returns the resulting number.
+// It returns false if the value is NULL and an error if it is not a number.
+func evalNumber(fname string, e Expr, env *Environment) (document.Value, bool, error) {
+	v, err := e.Eval(env)
+	if err != nil || v.Type == document.NullValue {
+		return nullLitteral, false, err
+	}
+	if !v.Type.IsNumber() {
+		return nullLitteral, false, fmt.Errorf("%s(): expected number, got %s", fname, v.Type)
+	}
+
+	return v, true, nil
+}
+
+// evalDouble evaluates e and converts the resulting number to a double.
+// It returns false if the value is NULL and an error if it is not a number.
+func evalDouble(fname string, e Expr, env *Environment) (float64, bool, error) {
+	v, ok, err := evalNumber(fname, e, env)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	v, err = v.CastAsDouble()
+	if err != nil {
+		return 0, false, err
+	}
+
+	return v.V.(float64), true, nil
+}
+
+// AbsFunc is the abs() function. It returns the absolute value of a number,
+// of the same type. The absolute value of the smallest integer doesn't fit
+// in an integer and is returned as a double.
+type AbsFunc struct {
+	Expr Expr
+}
+
+// Eval returns the absolute value, or NULL if the value is NULL.
+func (a *AbsFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok, err := evalNumber("abs", a.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	switch v.Type {
+	case document.IntegerValue:
+		x := v.V.(int64)
+		if x == math.MinInt64 {
+			return document.NewDoubleValue(-float64(x)), nil
+		}
+		if x < 0 {
+			x = -x
+		}
+		return document.NewIntegerValue(x), nil
+	case document.DecimalValue:
+		d := v.V.(document.Decimal)
+		if d.Sign() < 0 {
+			d = document.Decimal{}.Sub(d)
+		}
+		return document.NewDecimalValue(d), nil
+	}
+
+	return document.NewDoubleValue(math.Abs(v.V.(float64))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (a *AbsFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*AbsFunc)
+	return ok && Equal(a.Expr, o.Expr)
+}
+
+func (a *AbsFunc) String() string {
+	return fmt.Sprintf("abs(%v)", a.Expr)
+}
+
+// RoundFunc is the round() function. It rounds a number half away from zero,
+// to the given number of decimal digits, or to an integral value if omitted.
+// A negative number of digits rounds to the left of the decimal point:
+// round(1234, -2) returns 1200.
+// Integers return integers, other numbers return doubles.
+type RoundFunc struct {
+	Expr   Expr
+	Digits Expr
+}
+
+// Eval returns the rounded number, or NULL if one of the arguments is NULL.
+func (r *RoundFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok, err := evalNumber("round", r.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	var digits int64
+	if r.Digits != nil {
+		digits, ok, err = evalInteger("round", r.Digits, env)
+		if err != nil || !ok {
+			return nullLitteral, err
+		}
+	}
+
+	if v.Type == document.IntegerValue {
+		return roundInteger(v.V.(int64), digits), nil
+	}
+
+	v, err = v.CastAsDouble()
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewDoubleValue(roundDouble(v.V.(float64), digits)), nil
+}
+
+// roundInteger rounds x half away from zero to the given number of digits.
+// Results that don't fit in an integer are returned as doubles.
+func roundInteger(x, digits int64) document.Value {
+	if digits >= 0 {
+		return document.NewIntegerValue(x)
+	}
+
+	// 10^19 doesn't fit in an integer,
+	// only integers whose absolute value is at least 5*10^18 are not rounded to 0.
+	if digits < -18 {
+		switch {
+		case x >= 5e18:
+			return document.NewDoubleValue(1e19)
+		case x <= -5e18:
+			return document.NewDoubleValue(-1e19)
+		}
+		return document.NewIntegerValue(0)
+	}
+
+	p := int64(1)
+	for i := digits; i < 0; i++ {
+		p *= 10
+	}
+
+	q, rem := x/p, x%p
+	switch {
+	case rem >= p/2:
+		q++
+	case rem <= -p/2:
+		q--
+	}
+
+	if q > math.MaxInt64/p || q < math.MinInt64/p {
+		return document.NewDoubleValue(float64(q) * float64(p))
+	}
+
+	return document.NewIntegerValue(q * p)
+}
+
+// roundDouble rounds f half away from zero to the given number of digits.
+func roundDouble(f float64, digits int64) float64 {
+	if digits == 0 {
+		return math.Round(f)
+	}
+
+	if digits < 0 {
+		p := math.Pow(10, float64(-digits))
+		return math.Round(f/p) * p
+	}
+
+	p := math.Pow(10, float64(digits))
+	// f doesn't have that many digits
+	if math.IsInf(f*p, 0) {
+		return f
+	}
+
+	return math.Round(f*p) / p
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *RoundFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*RoundFunc)
+	if !ok || (r.Digits == nil) != (o.Digits == nil) {
+		return false
+	}
+
+	return Equal(r.Expr, o.Expr) && (r.Digits == nil || Equal(r.Digits, o.Digits))
+}
+
+func (r *RoundFunc) String() string {
+	if r.Digits == nil {
+		return fmt.Sprintf("round(%v)", r.Expr)
+	}
+
+	return fmt.Sprintf("round(%v, %v)", r.Expr, r.Digits)
+}
+
+// FloorFunc is the floor() function. It returns the greatest integral value
+// less than or equal to a number. Integers return integers, other numbers return doubles.
+type FloorFunc struct {
+	Expr Expr
+}
+
+// Eval returns the rounded number, or NULL if the value is NULL.
+func (f *FloorFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok, err := evalNumber("floor", f.Expr, env)
+	if err != nil || !ok || v.Type == document.IntegerValue {
+		return v, err
+	}
+
+	v, err = v.CastAsDouble()
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewDoubleValue(math.Floor(v.V.(float64))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f *FloorFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*FloorFunc)
+	return ok && Equal(f.Expr, o.Expr)
+}
+
+func (f *FloorFunc) String() string {
+	return fmt.Sprintf("floor(%v)", f.Expr)
+}
+
+// CeilFunc is the ceil() function. It returns the smallest integral value
+// greater than or equal to a number. Integers return integers, other numbers return doubles.
+type CeilFunc struct {
+	Expr Expr
+}
+
+// Eval returns the rounded number, or NULL if the value is NULL.
+func (c *CeilFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok, err := evalNumber("ceil", c.Expr, env)
+	if err != nil || !ok || v.Type == document.IntegerValue {
+		return v, err
+	}
+
+	v, err = v.CastAsDouble()
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewDoubleValue(math.Ceil(v.V.(float64))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c *CeilFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*CeilFunc)
+	return ok && Equal(c.Expr, o.Expr)
+}
+
+func (c *CeilFunc) String() string {
+	return fmt.Sprintf("ceil(%v)", c.Expr)
+}
+
+// PowFunc is the pow() function. It returns a number raised to the power
+// of another one, as a double.
+type PowFunc struct {
+	Base     Expr
+	Exponent Expr
+}
+
+// Eval returns the result, or NULL if one of the arguments is NULL
+// or if the result is not a real number, like pow(-8, 0.5).
+func (p *PowFunc) Eval(env *Environment) (document.Value, error) {
+	base, ok, err := evalDouble("pow", p.Base, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	exp, ok, err := evalDouble("pow", p.Exponent, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	res := math.Pow(base, exp)
+	if math.IsNaN(res) {
+		return nullLitteral, nil
+	}
+
+	return document.NewDoubleValue(res), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p *PowFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*PowFunc)
+	return ok && Equal(p.Base, o.Base) && Equal(p.Exponent, o.Exponent)
+}
+
+func (p *PowFunc) String() string {
+	return fmt.Sprintf("pow(%v, %v)", p.Base, p.Exponent)
+}
+
+// SqrtFunc is the sqrt() function. It returns the square root of a number, as a double.
+type SqrtFunc struct {
+	Expr Expr
+}
+
+// Eval returns the square root, or NULL if the value is NULL or negative.
+func (s *SqrtFunc) Eval(env *Environment) (document.Value, error) {
+	x, ok, err := evalDouble("sqrt", s.Expr, env)
+	if err != nil || !ok || x < 0 {
+		return nullLitteral, err
+	}
+
+	return document.NewDoubleValue(math.Sqrt(x)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *SqrtFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*SqrtFunc)
+	return ok && Equal(s.Expr, o.Expr)
+}
+
+func (s *SqrtFunc) String() string {
+	return fmt.Sprintf("sqrt(%v)", s.Expr)
+}
+
+// ModFunc is the mod() function. It returns the remainder of the division
+// of two numbers and follows the rules of the % operator: the result is an integer
+// if both numbers are integers and has the sign of the dividend.
+type ModFunc struct {
+	Dividend Expr
+	Divisor  Expr
+}
+
+// Eval returns the remainder, or NULL if one of the arguments is NULL
+// or if the divisor is zero.
+func (m *ModFunc) Eval(env *Environment) (document.Value, error) {
+	a, ok, err := evalNumber("mod", m.Dividend, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	b, ok, err := evalNumber("mod", m.Divisor, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	return a.Mod(b)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (m *ModFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ModFunc)
+	return ok && Equal(m.Dividend, o.Dividend) && Equal(m.Divisor, o.Divisor)
+}
+
+func (m *ModFunc) String() string {
+	return fmt.Sprintf("mod(%v, %v)", m.Dividend, m.Divisor)
+}
@@ -0,0 +1,90 @@
+package expr_test
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMathFuncs(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"abs(-5)", document.NewIntegerValue(5), false},
+		{"abs(5)", document.NewIntegerValue(5), false},
+		{"abs(-5.5)", document.NewDoubleValue(5.5), false},
+		{"abs(-9223372036854775808)", document.NewDoubleValue(9223372036854775808), false},
+		{"abs(NULL)", nullLitteral, false},
+		{"abs('a')", document.Value{}, true},
+		{"round(2.5)", document.NewDoubleValue(3), false},
+		{"round(-2.5)", document.NewDoubleValue(-3), false},
+		{"round(2.4)", document.NewDoubleValue(2), false},
+		{"round(3.14159, 2)", document.NewDoubleValue(3.14), false},
+		{"round(0.125, 2)", document.NewDoubleValue(0.13), false},
+		{"round(1234.5, -2)", document.NewDoubleValue(1200), false},
+		{"round(5)", document.NewIntegerValue(5), false},
+		{"round(5, 2)", document.NewIntegerValue(5), false},
+		{"round(1250, -2)", document.NewIntegerValue(1300), false},
+		{"round(-1250, -2)", document.NewIntegerValue(-1300), false},
+		{"round(1249, -2)", document.NewIntegerValue(1200), false},
+		{"round(9223372036854775807, -1)", document.NewDoubleValue(9223372036854775810), false},
+		{"round(1234, -20)", document.NewIntegerValue(0), false},
+		{"round(a / 3, 3)", document.NewIntegerValue(0), false},
+		{"round(1.5, NULL)", nullLitteral, false},
+		{"round(1.5, 'a')", document.Value{}, true},
+		{"floor(2.7)", document.NewDoubleValue(2), false},
+		{"floor(-2.2)", document.NewDoubleValue(-3), false},
+		{"floor(3)", document.NewIntegerValue(3), false},
+		{"floor(notFound)", nullLitteral, false},
+		{"floor(true)", document.Value{}, true},
+		{"ceil(2.2)", document.NewDoubleValue(3), false},
+		{"ceil(-2.7)", document.NewDoubleValue(-2), false},
+		{"ceil(3)", document.NewIntegerValue(3), false},
+		{"ceil('a')", document.Value{}, true},
+		{"pow(2, 10)", document.NewDoubleValue(1024), false},
+		{"pow(4, 0.5)", document.NewDoubleValue(2), false},
+		{"pow(2, -1)", document.NewDoubleValue(0.5), false},
+		{"pow(10, 400)", document.NewDoubleValue(math.Inf(1)), false},
+		{"pow(-8, 0.5)", nullLitteral, false},
+		{"pow(2, NULL)", nullLitteral, false},
+		{"pow('a', 2)", document.Value{}, true},
+		{"sqrt(16)", document.NewDoubleValue(4), false},
+		{"sqrt(2.25)", document.NewDoubleValue(1.5), false},
+		{"sqrt(-1)", nullLitteral, false},
+		{"sqrt([1])", document.Value{}, true},
+		{"mod(7, 3)", document.NewIntegerValue(1), false},
+		{"mod(-7, 3)", document.NewIntegerValue(-1), false},
+		{"mod(7.5, 2)", document.NewDoubleValue(1.5), false},
+		{"mod(7, 0)", nullLitteral, false},
+		{"mod(7, NULL)", nullLitteral, false},
+		{"mod('7', 2)", document.Value{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+
+			// the string representation can be parsed back
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+			pe, _, err := parser.NewParser(strings.NewReader(fmt.Sprintf("%v", e))).ParseExpr()
+			require.NoError(t, err)
+			require.True(t, expr.Equal(e, pe))
+		})
+	}
+
+	t.Run("Arguments", func(t *testing.T) {
+		for _, s := range []string{"abs()", "round()", "round(1, 2, 3)", "floor(1, 2)", "ceil()", "pow(1)", "sqrt()", "mod(1)"} {
+			_, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+			require.Error(t, err, s)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package expr
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/genjidb/genji/document"
+)
+
+// newUUIDFunc returns the constructor of the uuid() function, registered under the given name.
+func newUUIDFunc(name string) func(args ...Expr) (Expr, error) {
+	return func(args ...Expr) (Expr, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("%s() takes no arguments", name)
+		}
+		return UUIDFunc{}, nil
+	}
+}
+
+// UUIDFunc is the uuid() function, also available as uuidv4().
+// It returns a random version 4 UUID as a text, in its canonical
+// form, e.g. '0b7e4a1c-5f0d-4c3e-9a7b-2d1f6e8c4b90'.
+type UUIDFunc struct{}
+
+// Eval returns a new UUID.
+func (u UUIDFunc) Eval(env *Environment) (document.Value, error) {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	// set the version (4) and the variant (RFC 4122)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+
+	return document.NewTextValue(string(buf[:])), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u UUIDFunc) IsEqual(other Expr) bool {
+	_, ok := other.(UUIDFunc)
+	return ok
+}
+
+func (u UUIDFunc) String() string {
+	return "uuid()"
+}
+
+// RandomFunc is the random() function. It returns a random integer
+// between the smallest and the largest integer.
+type RandomFunc struct{}
+
+// Eval returns a new random integer.
+func (r RandomFunc) Eval(env *Environment) (document.Value, error) {
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewIntegerValue(int64(binary.BigEndian.Uint64(b[:]))), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r RandomFunc) IsEqual(other Expr) bool {
+	_, ok := other.(RandomFunc)
+	return ok
+}
+
+func (r RandomFunc) String() string {
+	return "random()"
+}
+
+// MaxRandomBlobSize is the largest number of bytes that can be returned by randomblob().
+const MaxRandomBlobSize = 1 << 20
+
+// RandomBlobFunc is the randomblob() function. It returns a blob
+// of n random bytes. If n is less than 1, a 1-byte blob is returned.
+// If n is greater than MaxRandomBlobSize, it returns an error.
+type RandomBlobFunc struct {
+	Expr Expr
+}
+
+// Eval returns a new random blob, or NULL if n is NULL.
+func (r *RandomBlobFunc) Eval(env *Environment) (document.Value, error) {
+	n, ok, err := evalInteger("randomblob", r.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > MaxRandomBlobSize {
+		return nullLitteral, fmt.Errorf("randomblob(): length %d exceeds the maximum of %d bytes", n, MaxRandomBlobSize)
+	}
+
+	b := make([]byte, n)
+	_, err = rand.Read(b)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewBlobValue(b), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *RandomBlobFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*RandomBlobFunc)
+	return ok && Equal(r.Expr, o.Expr)
+}
+
+func (r *RandomBlobFunc) String() string {
+	return fmt.Sprintf("randomblob(%v)", r.Expr)
+}
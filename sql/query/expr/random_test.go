@@ -0,0 +1,64 @@
+package expr_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomFuncs(t *testing.T) {
+	eval := func(t *testing.T, s string) document.Value {
+		t.Helper()
+
+		e, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+		require.NoError(t, err)
+		v, err := e.Eval(envWithDoc)
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("uuid", func(t *testing.T) {
+		re := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+		for _, s := range []string{"uuid()", "uuidv4()"} {
+			a, b := eval(t, s), eval(t, s)
+			require.Equal(t, document.TextValue, a.Type)
+			require.Regexp(t, re, a.V)
+			require.NotEqual(t, a, b)
+		}
+	})
+
+	t.Run("random", func(t *testing.T) {
+		a, b := eval(t, "random()"), eval(t, "random()")
+		require.Equal(t, document.IntegerValue, a.Type)
+		require.NotEqual(t, a, b)
+	})
+
+	t.Run("randomblob", func(t *testing.T) {
+		v := eval(t, "randomblob(16)")
+		require.Equal(t, document.BlobValue, v.Type)
+		require.Len(t, v.V, 16)
+		require.NotEqual(t, v, eval(t, "randomblob(16)"))
+
+		require.Len(t, eval(t, "randomblob(0)").V, 1)
+		require.Equal(t, nullLitteral, eval(t, "randomblob(NULL)"))
+
+		testExpr(t, "randomblob('a')", envWithDoc, document.Value{}, true)
+
+		require.Len(t, eval(t, "randomblob(1048576)").V, expr.MaxRandomBlobSize)
+		testExpr(t, "randomblob(1048577)", envWithDoc, document.Value{}, true)
+		testExpr(t, "randomblob(100000000000)", envWithDoc, document.Value{}, true)
+	})
+
+	t.Run("Arguments", func(t *testing.T) {
+		for _, s := range []string{"uuid(1)", "uuidv4(1)", "random(1)", "randomblob()", "randomblob(1, 2)"} {
+			_, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+			require.Error(t, err, s)
+		}
+	})
+}
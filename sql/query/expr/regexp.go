@@ -0,0 +1,95 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+type regexpOp struct {
+	*simpleOperator
+
+	// last compiled regular expression, shared by the
+	// copies of the operator.
+	cache *atomic.Value
+}
+
+type compiledRegexp struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// Regexp creates an expression that evaluates to the result of a =~ b.
+// b must be a text containing a regular expression using the Go regexp syntax.
+func Regexp(a, b Expr) Expr {
+	return regexpOp{&simpleOperator{a, b, scanner.EQREGEX}, new(atomic.Value)}
+}
+
+// Eval returns true if a matches the regular expression b.
+// It returns NULL if one of the operands is NULL, and false if a is not a text.
+// The regular expression is only compiled again if the pattern changes,
+// for example if it is a parameter.
+func (op regexpOp) Eval(env *Environment) (document.Value, error) {
+	a, b, err := op.simpleOperator.eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if a.Type == document.NullValue || b.Type == document.NullValue {
+		return nullLitteral, nil
+	}
+
+	if b.Type != document.TextValue {
+		return nullLitteral, errors.New("=~ operator takes a text")
+	}
+
+	re, err := op.compile(b.V.(string))
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if a.Type == document.TextValue && re.MatchString(a.V.(string)) {
+		return trueLitteral, nil
+	}
+
+	return falseLitteral, nil
+}
+
+func (op regexpOp) compile(pattern string) (*regexp.Regexp, error) {
+	if c, ok := op.cache.Load().(compiledRegexp); ok && c.pattern == pattern {
+		return c.re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	op.cache.Store(compiledRegexp{pattern: pattern, re: re})
+	return re, nil
+}
+
+func (op regexpOp) String() string {
+	return fmt.Sprintf("%v =~ %v", op.a, op.b)
+}
+
+type notRegexpOp struct {
+	regexpOp
+}
+
+// NotRegexp creates an expression that evaluates to the result of a !~ b.
+func NotRegexp(a, b Expr) Expr {
+	return notRegexpOp{regexpOp{&simpleOperator{a, b, scanner.NEQREGEX}, new(atomic.Value)}}
+}
+
+func (op notRegexpOp) Eval(env *Environment) (document.Value, error) {
+	return invertBoolResult(op.regexpOp.Eval)(env)
+}
+
+func (op notRegexpOp) String() string {
+	return fmt.Sprintf("%v !~ %v", op.a, op.b)
+}
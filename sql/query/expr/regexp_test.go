@@ -0,0 +1,54 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegexpExpr(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   document.Value
+		fails bool
+	}{
+		{"'abc' =~ '^a.c$'", document.NewBoolValue(true), false},
+		{"'abc' =~ 'b'", document.NewBoolValue(true), false},
+		{"'abc' =~ '^b'", document.NewBoolValue(false), false},
+		{"'ABC' =~ '(?i)^abc$'", document.NewBoolValue(true), false},
+		{"'abc' !~ '^b'", document.NewBoolValue(true), false},
+		{"a =~ '1'", document.NewBoolValue(false), false},
+		{"NULL =~ 'a'", nullLitteral, false},
+		{"'abc' =~ NULL", nullLitteral, false},
+		{"NULL !~ 'a'", nullLitteral, false},
+		{"'abc' =~ 1", nullLitteral, true},
+		{"'abc' =~ '('", nullLitteral, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			testExpr(t, test.expr, envWithDoc, test.res, test.fails)
+		})
+	}
+
+	t.Run("with a parameter", func(t *testing.T) {
+		e := expr.Regexp(expr.TextValue("abc"), expr.PositionalParam(1))
+
+		for _, test := range []struct {
+			pattern string
+			res     document.Value
+		}{
+			{"^a", document.NewBoolValue(true)},
+			{"^a", document.NewBoolValue(true)},
+			{"^b", document.NewBoolValue(false)},
+			{"c$", document.NewBoolValue(true)},
+		} {
+			env := expr.NewEnvironment(document.Value{}, expr.Param{Value: test.pattern})
+			res, err := e.Eval(env)
+			require.NoError(t, err)
+			require.Equal(t, test.res, res)
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/genjidb/genji/document"
+)
+
+// evalText evaluates e and returns the resulting text.
+// It returns false if the value is NULL and an error if it is not a text.
+func evalText(fname string, e Expr, env *Environment) (string, bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch v.Type {
+	case document.NullValue:
+		return "", false, nil
+	case document.TextValue:
+		return v.V.(string), true, nil
+	}
+
+	return "", false, fmt.Errorf("%s(): expected text, got %s", fname, v.Type)
+}
+
+// evalTexts evaluates each expression of exprs and returns the resulting texts.
+// It returns false if any of the values is NULL.
+func evalTexts(fname string, env *Environment, exprs ...Expr) ([]string, bool, error) {
+	texts := make([]string, len(exprs))
+	for i, e := range exprs {
+		s, ok, err := evalText(fname, e, env)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		texts[i] = s
+	}
+
+	return texts, true, nil
+}
+
+// evalInteger evaluates e and converts the resulting number to an integer.
+// It returns false if the value is NULL and an error if it is not a number.
+func evalInteger(fname string, e Expr, env *Environment) (int64, bool, error) {
+	v, err := e.Eval(env)
+	if err != nil || v.Type == document.NullValue {
+		return 0, false, err
+	}
+	if !v.Type.IsNumber() {
+		return 0, false, fmt.Errorf("%s(): expected integer, got %s", fname, v.Type)
+	}
+
+	v, err = v.CastAsInteger()
+	if err != nil {
+		return 0, false, fmt.Errorf("%s(): %w", fname, err)
+	}
+
+	return v.V.(int64), true, nil
+}
+
+// LowerFunc is the lower() function. It returns a text with all
+// its characters converted to lower case.
+type LowerFunc struct {
+	Expr Expr
+}
+
+// Eval returns the lower case text, or NULL if the text is NULL.
+func (l *LowerFunc) Eval(env *Environment) (document.Value, error) {
+	s, ok, err := evalText("lower", l.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(strings.ToLower(s)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l *LowerFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*LowerFunc)
+	return ok && Equal(l.Expr, o.Expr)
+}
+
+func (l *LowerFunc) String() string {
+	return fmt.Sprintf("lower(%v)", l.Expr)
+}
+
+// UpperFunc is the upper() function. It returns a text with all
+// its characters converted to upper case.
+type UpperFunc struct {
+	Expr Expr
+}
+
+// Eval returns the upper case text, or NULL if the text is NULL.
+func (u *UpperFunc) Eval(env *Environment) (document.Value, error) {
+	s, ok, err := evalText("upper", u.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(strings.ToUpper(s)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (u *UpperFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*UpperFunc)
+	return ok && Equal(u.Expr, o.Expr)
+}
+
+func (u *UpperFunc) String() string {
+	return fmt.Sprintf("upper(%v)", u.Expr)
+}
+
+// TrimFunc is the trim() function. It removes the leading and trailing
+// white spaces of a text, or the characters of the optional second argument.
+type TrimFunc struct {
+	Expr  Expr
+	Chars Expr
+}
+
+// Eval returns the trimmed text, or NULL if one of the arguments is NULL.
+func (t *TrimFunc) Eval(env *Environment) (document.Value, error) {
+	s, ok, err := evalText("trim", t.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	if t.Chars == nil {
+		return document.NewTextValue(strings.TrimFunc(s, unicode.IsSpace)), nil
+	}
+
+	chars, ok, err := evalText("trim", t.Chars, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	return document.NewTextValue(strings.Trim(s, chars)), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (t *TrimFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*TrimFunc)
+	if !ok || (t.Chars == nil) != (o.Chars == nil) {
+		return false
+	}
+
+	return Equal(t.Expr, o.Expr) && (t.Chars == nil || Equal(t.Chars, o.Chars))
+}
+
+func (t *TrimFunc) String() string {
+	if t.Chars == nil {
+		return fmt.Sprintf("trim(%v)", t.Expr)
+	}
+
+	return fmt.Sprintf("trim(%v, %v)", t.Expr, t.Chars)
+}
+
+// ConcatFunc is the concat() function. It concatenates its arguments,
+// converted to texts. NULL arguments are ignored.
+type ConcatFunc []Expr
+
+// Eval returns the concatenated text.
+func (c ConcatFunc) Eval(env *Environment) (document.Value, error) {
+	var sb strings.Builder
+
+	for _, e := range c {
+		v, err := e.Eval(env)
+		if err != nil {
+			return nullLitteral, err
+		}
+		if v.Type == document.NullValue {
+			continue
+		}
+
+		v, err = v.CastAsText()
+		if err != nil {
+			return nullLitteral, fmt.Errorf("concat(): %w", err)
+		}
+		sb.WriteString(v.V.(string))
+	}
+
+	return document.NewTextValue(sb.String()), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (c ConcatFunc) IsEqual(other Expr) bool {
+	o, ok := other.(ConcatFunc)
+	if !ok {
+		return false
+	}
+
+	return LiteralExprList(c).IsEqual(LiteralExprList(o))
+}
+
+func (c ConcatFunc) String() string {
+	var b strings.Builder
+
+	b.WriteString("concat(")
+	for i, e := range c {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fmt.Sprintf("%v", e))
+	}
+	b.WriteRune(')')
+
+	return b.String()
+}
+
+// SubstringFunc is the substring() function. It returns the part of a text
+// starting at the given position, the first character being at position 1,
+// and containing at most the given number of characters, or all the remaining
+// characters if the length is omitted.
+// Positions before the first character are allowed: substring('abc', 0, 2) returns 'a'.
+type SubstringFunc struct {
+	Expr   Expr
+	Start  Expr
+	Length Expr
+}
+
+// Eval returns the substring, or NULL if one of the arguments is NULL.
+func (s *SubstringFunc) Eval(env *Environment) (document.Value, error) {
+	text, ok, err := evalText("substring", s.Expr, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	start, ok, err := evalInteger("substring", s.Start, env)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	runes := []rune(text)
+	n := int64(len(runes))
+
+	// the end of the substring, exclusive
+	end := n + 1
+	if s.Length != nil {
+		length, ok, err := evalInteger("substring", s.Length, env)
+		if err != nil || !ok {
+			return nullLitteral, err
+		}
+		if length < 0 {
+			return nullLitteral, fmt.Errorf("substring(): negative length %d", length)
+		}
+
+		if start < end-length {
+			end = start + length
+		}
+	}
+
+	if start < 1 {
+		start = 1
+	}
+	if start >= end {
+		return document.NewTextValue(""), nil
+	}
+
+	return document.NewTextValue(string(runes[start-1 : end-1])), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (s *SubstringFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*SubstringFunc)
+	if !ok || (s.Length == nil) != (o.Length == nil) {
+		return false
+	}
+
+	return Equal(s.Expr, o.Expr) && Equal(s.Start, o.Start) && (s.Length == nil || Equal(s.Length, o.Length))
+}
+
+func (s *SubstringFunc) String() string {
+	if s.Length == nil {
+		return fmt.Sprintf("substring(%v, %v)", s.Expr, s.Start)
+	}
+
+	return fmt.Sprintf("substring(%v, %v, %v)", s.Expr, s.Start, s.Length)
+}
+
+// ReplaceFunc is the replace() function. It replaces all the occurrences
+// of a text by another one.
+type ReplaceFunc struct {
+	Expr Expr
+	Old  Expr
+	New  Expr
+}
+
+// Eval returns the modified text, or NULL if one of the arguments is NULL.
+// If the text to replace is empty, the original text is returned.
+func (r *ReplaceFunc) Eval(env *Environment) (document.Value, error) {
+	texts, ok, err := evalTexts("replace", env, r.Expr, r.Old, r.New)
+	if err != nil || !ok {
+		return nullLitteral, err
+	}
+
+	if texts[1] == "" {
+		return document.NewTextValue(texts[0]), nil
+	}
+
+	return document.NewTextValue(strings.ReplaceAll(texts[0], texts[1], texts[2])), nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (r *ReplaceFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*ReplaceFunc)
+	return ok && Equal(r.Expr, o.Expr) && Equal(r.Old, o.Old) && Equal(r.New, o.New)
+}
+
+func (r *ReplaceFunc) String() string {
+	return fmt.Sprintf("replace(%v, %v, %v)", r.Expr, r.Old, r.New)
+}
+
+// LengthFunc is the length() function. It returns the number
+// of characters of a text, or the number of bytes of a blob.
+type LengthFunc struct {
+	Expr Expr
+}
+
+// Eval returns the length, or NULL if the value is NULL.
+func (l *LengthFunc) Eval(env *Environment) (document.Value, error) {
+	v, err := l.Expr.Eval(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	switch v.Type {
+	case document.NullValue:
+		return nullLitteral, nil
+	case document.TextValue:
+		return document.NewIntegerValue(int64(utf8.RuneCountInString(v.V.(string)))), nil
+	case document.BlobValue:
+		return document.NewIntegerValue(int64(len(v.V.([]byte)))), nil
+	}
+
+	return nullLitteral, fmt.Errorf("length(): expected text or blob, got %s", v.Type)
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (l *LengthFunc) IsEqual(other Expr) bool {
+	o, ok := other.(*LengthFunc)
+	return ok && Equal(l.Expr, o.Expr)
+}
+
+func (l *LengthFunc) String() string {
+	return fmt.Sprintf("length(%v)", l.Expr)
+}
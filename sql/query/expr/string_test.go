@@ -0,0 +1,84 @@
+package expr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringFuncs(t *testing.T) {
+	tests := []struct {
+		expr  string
+		res   string
+		fails bool
+	}{
+		{`lower('Hello WORLD')`, `"hello world"`, false},
+		{`lower(NULL)`, `null`, false},
+		{`lower(a)`, ``, true},
+		{`upper('Hello world')`, `"HELLO WORLD"`, false},
+		{`upper('éa')`, `"ÉA"`, false},
+		{`upper(notFound)`, `null`, false},
+		{`trim('  foo bar \n')`, `"foo bar"`, false},
+		{`trim('xxfooxyx', 'xy')`, `"foo"`, false},
+		{`trim('foo', NULL)`, `null`, false},
+		{`trim(1)`, ``, true},
+		{`concat('foo', 'bar')`, `"foobar"`, false},
+		{`concat('a = ', a, ', c = ', c)`, `"a = 1, c = [1, {\"foo\": \"bar\"}, [1, 2]]"`, false},
+		{`concat('foo', NULL, notFound)`, `"foo"`, false},
+		{`concat(NULL)`, `""`, false},
+		{`substring('hello', 2)`, `"ello"`, false},
+		{`substring('hello', 2, 3)`, `"ell"`, false},
+		{`substring('héllo', 2, 1)`, `"é"`, false},
+		{`substring('hello', 0, 2)`, `"h"`, false},
+		{`substring('hello', -5, 3)`, `""`, false},
+		{`substring('hello', 4, 10)`, `"lo"`, false},
+		{`substring('hello', 10)`, `""`, false},
+		{`substring('hello', 2.9, 2)`, `"el"`, false},
+		{`substring('hello', NULL)`, `null`, false},
+		{`substring('hello', 1, -1)`, ``, true},
+		{`substring('hello', 'a')`, ``, true},
+		{`replace('foo bar foo', 'foo', 'baz')`, `"baz bar baz"`, false},
+		{`replace('foo', '', 'baz')`, `"foo"`, false},
+		{`replace('foo', 'o', NULL)`, `null`, false},
+		{`replace('foo', 'o', 1)`, ``, true},
+		{`length('hello')`, `5`, false},
+		{`length('héllo')`, `5`, false},
+		{`length('')`, `0`, false},
+		{`length(NULL)`, `null`, false},
+		{`length(c)`, ``, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, _, err := parser.NewParser(strings.NewReader(test.expr)).ParseExpr()
+			require.NoError(t, err)
+
+			v, err := e.Eval(envWithDoc)
+			if test.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := v.MarshalJSON()
+			require.NoError(t, err)
+			require.JSONEq(t, test.res, string(data))
+
+			// the string representation can be parsed back
+			pe, _, err := parser.NewParser(strings.NewReader(fmt.Sprintf("%v", e))).ParseExpr()
+			require.NoError(t, err)
+			require.True(t, expr.Equal(e, pe))
+		})
+	}
+
+	t.Run("Arguments", func(t *testing.T) {
+		for _, s := range []string{"lower()", "upper(a, b)", "trim()", "trim(a, b, c)", "concat()", "substring(a)", "replace(a, b)", "length()"} {
+			_, _, err := parser.NewParser(strings.NewReader(s)).ParseExpr()
+			require.Error(t, err, s)
+		}
+	})
+}
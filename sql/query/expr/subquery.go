@@ -0,0 +1,276 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+)
+
+// A SubqueryStatement is a SELECT statement that can be evaluated within an expression.
+type SubqueryStatement interface {
+	// Stream runs the statement and returns the documents it selects.
+	Stream(tx *database.Transaction, params []Param) (document.Stream, error)
+	String() string
+}
+
+// A Subquery is a SELECT statement used as an expression.
+// Evaluated on its own, it must return at most one document with a single field,
+// whose value is returned, or NULL if no document is selected.
+// On the right side of the IN operator, it can return any number of documents.
+//
+// An uncorrelated subquery is run once and its result is kept until Reset is called,
+// which happens every time the statement it belongs to is run.
+// A correlated subquery refers to the documents of the enclosing statement and is run
+// every time it is evaluated, with the current document of each table of OuterTables
+// available to the OuterPath expressions it contains.
+type Subquery struct {
+	Stmt        SubqueryStatement
+	Correlated  bool
+	OuterTables []string
+
+	// result of the last run of an uncorrelated subquery.
+	rows   []document.Value
+	multi  bool
+	cached bool
+	// result of the last EXISTS test of an uncorrelated subquery.
+	exists       bool
+	existsCached bool
+}
+
+// Eval runs the subquery and returns the value of the only field of the only document it selects.
+func (s *Subquery) Eval(env *Environment) (document.Value, error) {
+	rows, multi, err := s.run(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+	if multi {
+		return nullLitteral, errors.New("subquery must select a single field")
+	}
+
+	switch len(rows) {
+	case 0:
+		return nullLitteral, nil
+	case 1:
+		return rows[0], nil
+	}
+
+	return nullLitteral, errors.New("subquery returned more than one document")
+}
+
+// EvalArray runs the subquery and returns an array containing one element per document.
+// Each element is the value of the only field of the document, or an array of the values
+// of its fields if it has more than one, which can be compared to row values.
+func (s *Subquery) EvalArray(env *Environment) (document.Value, error) {
+	rows, _, err := s.run(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	return document.NewArrayValue(document.NewValueBuffer(rows...)), nil
+}
+
+// Exists runs the subquery and returns true if it selects at least one document.
+func (s *Subquery) Exists(env *Environment) (bool, error) {
+	if s.cached {
+		return len(s.rows) > 0, nil
+	}
+	if s.existsCached {
+		return s.exists, nil
+	}
+
+	st, err := s.stream(env)
+	if err != nil {
+		return false, err
+	}
+
+	d, err := st.First()
+	if err != nil {
+		return false, err
+	}
+
+	if !s.Correlated {
+		s.exists, s.existsCached = d != nil, true
+	}
+
+	return d != nil, nil
+}
+
+// Reset clears the result of the last run of the subquery.
+func (s *Subquery) Reset() {
+	s.rows = nil
+	s.multi = false
+	s.cached = false
+	s.existsCached = false
+}
+
+// run returns one value per document selected by the subquery,
+// and whether the documents have more than one field.
+func (s *Subquery) run(env *Environment) ([]document.Value, bool, error) {
+	if s.cached {
+		return s.rows, s.multi, nil
+	}
+
+	st, err := s.stream(env)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var rows []document.Value
+	var multi bool
+	err = st.Iterate(func(d document.Document) error {
+		// documents may be reused by the stream
+		var fb document.FieldBuffer
+		err := fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		var values []document.Value
+		err = fb.Iterate(func(field string, v document.Value) error {
+			values = append(values, v)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(values) == 1 {
+			rows = append(rows, values[0])
+			return nil
+		}
+
+		multi = true
+		rows = append(rows, document.NewArrayValue(document.NewValueBuffer(values...)))
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !s.Correlated {
+		s.rows, s.multi, s.cached = rows, multi, true
+	}
+
+	return rows, multi, nil
+}
+
+// stream runs the statement with the parameters of the environment.
+// Correlated subqueries also receive the current document of each outer table.
+func (s *Subquery) stream(env *Environment) (document.Stream, error) {
+	params := env.GetParams()
+
+	if s.Correlated {
+		v, ok := env.GetCurrentValue()
+		if !ok || v.Type != document.DocumentValue {
+			return document.Stream{}, errors.New("correlated subquery evaluated outside of a document")
+		}
+		d := v.V.(document.Document)
+
+		params = append(make([]Param, 0, len(params)+len(s.OuterTables)), params...)
+		for _, t := range s.OuterTables {
+			od := d
+			// the documents of joined tables are fields of the joined document
+			if len(s.OuterTables) > 1 {
+				tv, err := d.GetByField(t)
+				if err != nil {
+					return document.Stream{}, err
+				}
+				od = tv.V.(document.Document)
+			}
+
+			params = append(params, OuterParam(t, od))
+		}
+	}
+
+	return s.Stmt.Stream(env.GetTx(), params)
+}
+
+func (s *Subquery) String() string {
+	return fmt.Sprintf("(%s)", s.Stmt)
+}
+
+// outerParamName returns the name of the parameter containing
+// the current document of an outer table.
+// It contains a space, which can't be used in named parameters.
+func outerParamName(table string) string {
+	return "outer " + table
+}
+
+// OuterParam returns a parameter that makes d the current document of the given table
+// for the OuterPath expressions that refer to it.
+func OuterParam(table string, d document.Document) Param {
+	return Param{Name: outerParamName(table), Value: d}
+}
+
+// An OuterPath is a path to a value of the current document of a table
+// of an enclosing statement, referred to from a correlated subquery.
+type OuterPath struct {
+	Table string
+	Path  Path
+}
+
+// Eval returns the value of the path in the current document of the outer table.
+func (p OuterPath) Eval(env *Environment) (document.Value, error) {
+	v, err := env.GetParamByName(outerParamName(p.Table))
+	if err != nil {
+		return nullLitteral, fmt.Errorf("no document of table %q in the current context", p.Table)
+	}
+
+	v, err = document.Path(p.Path).GetValue(v)
+	if err == document.ErrFieldNotFound || err == document.ErrValueNotFound {
+		return nullLitteral, nil
+	}
+
+	return v, err
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (p OuterPath) IsEqual(other Expr) bool {
+	o, ok := other.(OuterPath)
+	return ok && p.Table == o.Table && p.Path.IsEqual(o.Path)
+}
+
+func (p OuterPath) String() string {
+	return Path(append(document.Path{document.PathFragment{FieldName: p.Table}}, p.Path...)).String()
+}
+
+type existsExpr struct {
+	sq *Subquery
+}
+
+// Exists creates an expression that evaluates to true
+// if the subquery selects at least one document.
+func Exists(sq *Subquery) Expr {
+	return existsExpr{sq: sq}
+}
+
+func (e existsExpr) Eval(env *Environment) (document.Value, error) {
+	ok, err := e.sq.Exists(env)
+	if err != nil {
+		return nullLitteral, err
+	}
+
+	if ok {
+		return trueLitteral, nil
+	}
+	return falseLitteral, nil
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (e existsExpr) IsEqual(other Expr) bool {
+	o, ok := other.(existsExpr)
+	return ok && o.sq == e.sq
+}
+
+// Subquery returns the subquery of the expression.
+func (e existsExpr) Subquery() *Subquery {
+	return e.sq
+}
+
+func (e existsExpr) String() string {
+	return fmt.Sprintf("EXISTS %s", e.sq)
+}
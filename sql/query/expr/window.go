@@ -0,0 +1,229 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/scanner"
+)
+
+// A Window describes the documents a window function is computed over,
+// as defined by the OVER clause.
+// Documents are split into partitions that have the same PARTITION BY values,
+// then sorted within each partition by the ORDER BY expressions.
+// Documents of the same partition that have the same ORDER BY values are peers.
+type Window struct {
+	PartitionBy []Expr
+	OrderBy     []WindowOrder
+}
+
+// WindowOrder is an expression of the ORDER BY clause of a window, along with its direction.
+type WindowOrder struct {
+	Expr      Expr
+	Direction scanner.Token
+}
+
+// IsEqual compares this window with the other window and returns
+// true if they are equal.
+func (w Window) IsEqual(other Window) bool {
+	if len(w.PartitionBy) != len(other.PartitionBy) || len(w.OrderBy) != len(other.OrderBy) {
+		return false
+	}
+
+	for i := range w.PartitionBy {
+		if !Equal(w.PartitionBy[i], other.PartitionBy[i]) {
+			return false
+		}
+	}
+
+	for i := range w.OrderBy {
+		if w.OrderBy[i].Direction != other.OrderBy[i].Direction || !Equal(w.OrderBy[i].Expr, other.OrderBy[i].Expr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (w Window) String() string {
+	var b strings.Builder
+
+	b.WriteString("(")
+	if len(w.PartitionBy) > 0 {
+		b.WriteString("PARTITION BY ")
+		for i, e := range w.PartitionBy {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%v", e)
+		}
+	}
+
+	if len(w.OrderBy) > 0 {
+		if len(w.PartitionBy) > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString("ORDER BY ")
+		for i, o := range w.OrderBy {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%v", o.Expr)
+			if o.Direction == scanner.DESC {
+				b.WriteString(" DESC")
+			}
+		}
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
+
+// OverFunc is a window function followed by an OVER clause.
+// Func is either a WindowFunc or an OffsetWindowFunc.
+// Its value is computed before the projection for every document of the stream,
+// and stored in the document under the name of the expression, like aggregate functions.
+type OverFunc struct {
+	Func   Expr
+	Window Window
+}
+
+// Eval returns the value computed for the current document.
+func (o OverFunc) Eval(env *Environment) (document.Value, error) {
+	v, ok := env.GetCurrentValue()
+	if !ok || v.Type != document.DocumentValue {
+		return nullLitteral, fmt.Errorf("misuse of window function %v", o.Func)
+	}
+
+	return v.V.(document.Document).GetByField(o.String())
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (o OverFunc) IsEqual(other Expr) bool {
+	oo, ok := other.(OverFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(o.Func, oo.Func) && o.Window.IsEqual(oo.Window)
+}
+
+func (o OverFunc) String() string {
+	return fmt.Sprintf("%v OVER %v", o.Func, o.Window)
+}
+
+// An OffsetWindowFunc is a window function that returns the value of an expression
+// for the document that is a given number of rows before or after the current
+// document within its partition, such as lag() and lead().
+// It can only be used with an OVER clause.
+type OffsetWindowFunc interface {
+	Expr
+
+	// OffsetArgs returns the expression to evaluate, the offset and the
+	// default value returned if there is no such document.
+	// The offset and the default value are nil if they were not specified.
+	// If backward is true, the document is looked up before the current one.
+	OffsetArgs() (e, offset, def Expr, backward bool)
+}
+
+var errOffsetFuncWithoutOver = errors.New("lag() and lead() must be used with an OVER clause")
+
+// LagFunc is the lag(expr [, offset [, default]]) window function.
+// It returns the value of expr for the document that is offset rows
+// before the current one within its partition, or default if there is none.
+// Offset defaults to 1 and default to NULL.
+type LagFunc struct {
+	Expr    Expr
+	Offset  Expr
+	Default Expr
+}
+
+// Eval returns an error, the value is computed by the OVER clause.
+func (f LagFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, errOffsetFuncWithoutOver
+}
+
+// OffsetArgs implements the OffsetWindowFunc interface.
+func (f LagFunc) OffsetArgs() (e, offset, def Expr, backward bool) {
+	return f.Expr, f.Offset, f.Default, true
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f LagFunc) IsEqual(other Expr) bool {
+	o, ok := other.(LagFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Expr, o.Expr) && equalOrNil(f.Offset, o.Offset) && equalOrNil(f.Default, o.Default)
+}
+
+func (f LagFunc) String() string {
+	return offsetFuncString("lag", f.Expr, f.Offset, f.Default)
+}
+
+// LeadFunc is the lead(expr [, offset [, default]]) window function.
+// It works like lag() but returns the value of expr for the document
+// that is offset rows after the current one.
+type LeadFunc struct {
+	Expr    Expr
+	Offset  Expr
+	Default Expr
+}
+
+// Eval returns an error, the value is computed by the OVER clause.
+func (f LeadFunc) Eval(env *Environment) (document.Value, error) {
+	return nullLitteral, errOffsetFuncWithoutOver
+}
+
+// OffsetArgs implements the OffsetWindowFunc interface.
+func (f LeadFunc) OffsetArgs() (e, offset, def Expr, backward bool) {
+	return f.Expr, f.Offset, f.Default, false
+}
+
+// IsEqual compares this expression with the other expression and returns
+// true if they are equal.
+func (f LeadFunc) IsEqual(other Expr) bool {
+	o, ok := other.(LeadFunc)
+	if !ok {
+		return false
+	}
+
+	return Equal(f.Expr, o.Expr) && equalOrNil(f.Offset, o.Offset) && equalOrNil(f.Default, o.Default)
+}
+
+func (f LeadFunc) String() string {
+	return offsetFuncString("lead", f.Expr, f.Offset, f.Default)
+}
+
+func offsetFuncString(name string, e, offset, def Expr) string {
+	switch {
+	case def != nil:
+		return fmt.Sprintf("%s(%v, %v, %v)", name, e, offset, def)
+	case offset != nil:
+		return fmt.Sprintf("%s(%v, %v)", name, e, offset)
+	}
+
+	return fmt.Sprintf("%s(%v)", name, e)
+}
+
+// newOffsetFuncArgs validates the arguments of lag() and lead().
+func newOffsetFuncArgs(name string, args []Expr) (e, offset, def Expr, err error) {
+	if len(args) < 1 || len(args) > 3 {
+		return nil, nil, nil, fmt.Errorf("%s() takes 1 to 3 arguments", name)
+	}
+
+	e = args[0]
+	if len(args) > 1 {
+		offset = args[1]
+	}
+	if len(args) > 2 {
+		def = args[2]
+	}
+
+	return
+}
@@ -0,0 +1,29 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query/expr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverFunc(t *testing.T) {
+	e, err := parser.ParseExpr("lag(a, 2) OVER (PARTITION BY b ORDER BY c DESC, d)")
+	require.NoError(t, err)
+	require.Equal(t, "lag(a, 2) OVER (PARTITION BY b ORDER BY c DESC, d)", e.(expr.OverFunc).String())
+
+	// the value is read from the document, using the name of the function
+	fb := document.NewFieldBuffer().Add(e.(expr.OverFunc).String(), document.NewIntegerValue(10))
+	v, err := e.Eval(expr.NewEnvironment(document.NewDocumentValue(fb)))
+	require.NoError(t, err)
+	require.Equal(t, document.NewIntegerValue(10), v)
+
+	_, err = e.Eval(&expr.Environment{})
+	require.Error(t, err)
+
+	// lag and lead can't be evaluated outside of an OVER clause
+	_, err = expr.LeadFunc{Expr: expr.IntegerValue(1)}.Eval(envWithDoc)
+	require.Error(t, err)
+}
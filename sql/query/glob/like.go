@@ -192,3 +192,55 @@ func MatchLike(pattern, s string) bool {
 	}
 	return true
 }
+
+// LikePrefixBounds returns the bounds of the strings that can match the literal prefix
+// of pattern, which is the part of pattern that precedes the first wildcard.
+// Since letters are compared regardless of case, min and max are respectively the smallest
+// and the largest strings that are equal to the prefix when ignoring case.
+// Any string matching pattern is greater than or equal to min, and either lesser than
+// or equal to max, or prefixed by max.
+func LikePrefixBounds(pattern string) (min, max string) {
+	var lo, hi []byte
+	var prevEscape bool
+
+	for len(pattern) != 0 {
+		r, size := utf8.DecodeRuneInString(pattern)
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		pattern = pattern[size:]
+
+		if !prevEscape {
+			if r == matchAll || r == matchOne {
+				break
+			}
+			if r == matchEsc {
+				prevEscape = true
+				continue
+			}
+		}
+		prevEscape = false
+
+		// SimpleFold iterates over the runes that are equal to r when ignoring case.
+		rlo, rhi := r, r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < rlo {
+				rlo = f
+			}
+			if f > rhi {
+				rhi = f
+			}
+		}
+
+		lo = appendRune(lo, rlo)
+		hi = appendRune(hi, rhi)
+	}
+
+	return string(lo), string(hi)
+}
+
+func appendRune(b []byte, r rune) []byte {
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
+	return append(b, buf[:n]...)
+}
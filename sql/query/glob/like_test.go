@@ -200,3 +200,30 @@ func TestMatchLike(t *testing.T) {
 		}
 	}
 }
+
+func TestLikePrefixBounds(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		min, max string
+	}{
+		{"", "", ""},
+		{"%", "", ""},
+		{"_abc", "", ""},
+		{"abc", "ABC", "abc"},
+		{"aBc%d", "ABC", "abc"},
+		{"a1_", "A1", "a1"},
+		{"a\\%b%", "A%B", "a%b"},
+		{"a\\_", "A_", "a_"},
+		{"k%", "K", "\u212a"}, // Kelvin sign
+		{"é%", "É", "é"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			min, max := LikePrefixBounds(tt.pattern)
+			if min != tt.min || max != tt.max {
+				t.Errorf("LikePrefixBounds(%q) = %q, %q; want %q, %q", tt.pattern, min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
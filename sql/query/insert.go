@@ -14,6 +14,48 @@ type InsertStmt struct {
 	TableName  string
 	FieldNames []string
 	Values     expr.LiteralExprList
+
+	// Select is the statement whose documents are inserted, instead of Values.
+	// If FieldNames is set, the fields of each document are renamed after it, in order.
+	Select Statement
+	// ReadsTable must be true if Select reads the table documents are inserted into.
+	// Its result is then loaded entirely before inserting, since tables can't be modified
+	// while they are being read. Otherwise, documents are inserted as they are streamed.
+	ReadsTable bool
+
+	// OnConflict defines what to do when a document has the same primary key
+	// or the same value in a unique index as an existing document.
+	// By default, the statement fails.
+	OnConflict OnConflictAction
+	// OnConflictSet holds the changes made to the existing document by ON CONFLICT DO UPDATE.
+	// The expressions are evaluated against the existing document, and the document
+	// that was to be inserted can be referred to using the "excluded" table.
+	OnConflictSet []SetPair
+
+	// Returning makes the statement return the inserted documents, or the documents updated
+	// by ON CONFLICT DO UPDATE, as they are stored.
+	Returning bool
+}
+
+// OnConflictAction is the action taken when an inserted document conflicts with an existing one.
+type OnConflictAction int
+
+// List of conflict actions.
+const (
+	// OnConflictDoNothing ignores the inserted document.
+	OnConflictDoNothing OnConflictAction = iota + 1
+	// OnConflictDoUpdate updates the existing document instead.
+	OnConflictDoUpdate
+)
+
+// ExcludedTable is the name used by ON CONFLICT DO UPDATE expressions
+// to refer to the document that was to be inserted.
+const ExcludedTable = "excluded"
+
+// A SetPair assigns the result of an expression to a path.
+type SetPair struct {
+	Path document.Path
+	E    expr.Expr
 }
 
 // IsReadOnly always returns false. It implements the Statement interface.
@@ -30,7 +72,7 @@ func (stmt InsertStmt) Run(tx *database.Transaction, args []expr.Param) (Result,
 		return res, errors.New("missing table name")
 	}
 
-	if stmt.Values == nil {
+	if stmt.Values == nil && stmt.Select == nil {
 		return res, errors.New("values are empty")
 	}
 
@@ -41,13 +83,24 @@ func (stmt InsertStmt) Run(tx *database.Transaction, args []expr.Param) (Result,
 
 	env := expr.Environment{
 		Params: args,
+		Tx:     tx,
 	}
 
-	if len(stmt.FieldNames) > 0 {
-		return stmt.insertExprList(t, &env)
+	switch {
+	case stmt.Select != nil:
+		res, err = stmt.insertSelect(t, &env)
+	case len(stmt.FieldNames) > 0:
+		res, err = stmt.insertExprList(t, &env)
+	default:
+		res, err = stmt.insertDocuments(t, &env)
+	}
+	if err != nil || !stmt.Returning {
+		return res, err
 	}
 
-	return stmt.insertDocuments(t, &env)
+	res.Stream = document.NewStream(document.NewIterator(res.returned...))
+	res.returned = nil
+	return res, nil
 }
 
 func (stmt InsertStmt) insertDocuments(t *database.Table, env *expr.Environment) (Result, error) {
@@ -63,12 +116,10 @@ func (stmt InsertStmt) insertDocuments(t *database.Table, env *expr.Environment)
 			return res, fmt.Errorf("expected document, got %s", v.Type)
 		}
 
-		res.LastInsertKey, err = t.Insert(v.V.(document.Document))
+		err = stmt.insert(t, env, v.V.(document.Document), &res)
 		if err != nil {
 			return res, err
 		}
-
-		res.RowsAffected++
 	}
 
 	return res, nil
@@ -103,13 +154,145 @@ func (stmt InsertStmt) insertExprList(t *database.Table, env *expr.Environment)
 			return nil
 		})
 
-		res.LastInsertKey, err = t.Insert(&fb)
+		err = stmt.insert(t, env, &fb, &res)
 		if err != nil {
 			return res, err
 		}
-
-		res.RowsAffected++
 	}
 
 	return res, nil
 }
+
+func (stmt InsertStmt) insertSelect(t *database.Table, env *expr.Environment) (Result, error) {
+	var res Result
+
+	sel, err := stmt.Select.Run(env.Tx, env.Params)
+	if err != nil {
+		return res, err
+	}
+
+	if stmt.ReadsTable {
+		err = sel.materialize()
+		if err != nil {
+			return res, err
+		}
+	}
+
+	err = sel.Iterate(func(d document.Document) error {
+		if len(stmt.FieldNames) > 0 {
+			var fb document.FieldBuffer
+			var i int
+
+			err := d.Iterate(func(_ string, v document.Value) error {
+				if i < len(stmt.FieldNames) {
+					fb.Add(stmt.FieldNames[i], v)
+				}
+				i++
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if i != len(stmt.FieldNames) {
+				return fmt.Errorf("%d values for %d fields", i, len(stmt.FieldNames))
+			}
+
+			d = &fb
+		}
+
+		return stmt.insert(t, env, d, &res)
+	})
+
+	return res, err
+}
+
+// insert the document in the table, unless it conflicts with an existing document
+// and the statement has an ON CONFLICT clause.
+func (stmt InsertStmt) insert(t *database.Table, env *expr.Environment, d document.Document, res *Result) error {
+	if stmt.OnConflict != 0 {
+		key, err := t.ConflictingKey(d)
+		if err != nil {
+			return err
+		}
+
+		if key != nil {
+			if stmt.OnConflict == OnConflictDoNothing {
+				return nil
+			}
+
+			err = stmt.update(t, env, key, d)
+			if err != nil {
+				return err
+			}
+
+			res.RowsAffected++
+			return stmt.addReturned(t, key, res)
+		}
+	}
+
+	var err error
+	res.LastInsertKey, err = t.Insert(d)
+	if err != nil {
+		return err
+	}
+	res.LastInsertPK, err = t.DecodeKey(res.LastInsertKey)
+	if err != nil {
+		return err
+	}
+
+	res.RowsAffected++
+	return stmt.addReturned(t, res.LastInsertKey, res)
+}
+
+// addReturned adds the document stored at key to the result, if the statement returns documents.
+func (stmt InsertStmt) addReturned(t *database.Table, key []byte, res *Result) error {
+	if !stmt.Returning {
+		return nil
+	}
+
+	d, err := t.GetDocument(key)
+	if err != nil {
+		return err
+	}
+
+	res.returned = append(res.returned, d)
+	return nil
+}
+
+// update applies the ON CONFLICT DO UPDATE changes to the document stored at key.
+func (stmt InsertStmt) update(t *database.Table, env *expr.Environment, key []byte, excluded document.Document) error {
+	old, err := t.GetDocument(key)
+	if err != nil {
+		return err
+	}
+
+	var fb document.FieldBuffer
+	err = fb.Copy(old)
+	if err != nil {
+		return err
+	}
+
+	uenv := expr.Environment{
+		Params: append(append([]expr.Param{}, env.Params...), expr.OuterParam(ExcludedTable, excluded)),
+		Tx:     env.Tx,
+	}
+	uenv.SetCurrentValue(document.NewDocumentValue(old))
+
+	for _, sp := range stmt.OnConflictSet {
+		v, err := sp.E.Eval(&uenv)
+		if err != nil && err != document.ErrFieldNotFound {
+			return err
+		}
+
+		err = fb.Set(sp.Path, v)
+		// the parent of the path doesn't exist
+		if err == document.ErrFieldNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return t.Replace(key, &fb)
+}
@@ -260,4 +260,349 @@ func TestInsertStmt(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("with explicit null values", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test (k INTEGER PRIMARY KEY, a INTEGER DEFAULT 10, b INTEGER NOT NULL DEFAULT 20)")
+		require.NoError(t, err)
+
+		// present-nonnull, present-null and absent
+		err = db.Exec("INSERT INTO test (k, a) VALUES (1, 1), (2, NULL)")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO test (k) VALUES (3)")
+		require.NoError(t, err)
+
+		// explicit nulls don't trigger defaults and violate NOT NULL constraints
+		err = db.Exec("INSERT INTO test (k, b) VALUES (4, NULL)")
+		require.Error(t, err)
+
+		res, err := db.Query("SELECT * FROM test")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, res)
+		require.NoError(t, err)
+		require.NoError(t, res.Close())
+		require.JSONEq(t, `[
+			{"k": 1, "a": 1, "b": 20},
+			{"k": 2, "a": null, "b": 20},
+			{"k": 3, "a": 10, "b": 20}
+		]`, buf.String())
+
+		d, err := db.QueryDocument("SELECT COUNT(*) AS c FROM test WHERE a IS NULL")
+		require.NoError(t, err)
+		v, err := d.GetByField("c")
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(1), v)
+	})
+
+	t.Run("with default expressions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test (k INTEGER PRIMARY KEY, created_at TEXT DEFAULT now(), status TEXT DEFAULT 'new', n DEFAULT array_append([1], 2))")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (k) VALUES (1)")
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO test (k, created_at) VALUES (2, 'foo')")
+		require.NoError(t, err)
+
+		// the default expression is evaluated for every document
+		d, err := db.QueryDocument("SELECT created_at, status, n FROM test WHERE k = 1")
+		require.NoError(t, err)
+		v, err := d.GetByField("created_at")
+		require.NoError(t, err)
+		require.Equal(t, document.TextValue, v.Type)
+		require.NotEmpty(t, v.V)
+		v, err = d.GetByField("status")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("new"), v)
+
+		v, err = d.GetByField("n")
+		require.NoError(t, err)
+		data, err := v.MarshalJSON()
+		require.NoError(t, err)
+		require.JSONEq(t, `[1, 2]`, string(data))
+
+		d, err = db.QueryDocument("SELECT created_at FROM test WHERE k = 2")
+		require.NoError(t, err)
+		v, err = d.GetByField("created_at")
+		require.NoError(t, err)
+		require.Equal(t, document.NewTextValue("foo"), v)
+	})
+	t.Run("with last_insert_key()", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test; CREATE TABLE foo (a INTEGER PRIMARY KEY)")
+		require.NoError(t, err)
+
+		tx, err := db.Begin(true)
+		require.NoError(t, err)
+		defer tx.Rollback()
+
+		lastKey := func() document.Value {
+			d, err := tx.QueryDocument("SELECT last_insert_key() AS k")
+			require.NoError(t, err)
+			v, err := d.GetByField("k")
+			require.NoError(t, err)
+			return v
+		}
+
+		require.Equal(t, document.NewNullValue(), lastKey())
+
+		err = tx.Exec("INSERT INTO test (a) VALUES (1), (2)")
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(2), lastKey())
+
+		err = tx.Exec("INSERT INTO foo (a) VALUES (10)")
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(10), lastKey())
+
+		d, err := tx.QueryDocument("SELECT a FROM foo WHERE a = last_insert_key()")
+		require.NoError(t, err)
+		v, err := d.GetByField("a")
+		require.NoError(t, err)
+		require.Equal(t, document.NewIntegerValue(10), v)
+	})
+
+	t.Run("with last_insert_key() across transactions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (a INTEGER PRIMARY KEY);
+			CREATE TABLE log;
+			CREATE TRIGGER log_insert AFTER INSERT ON test INSERT INTO log (a) VALUES (new.a);
+		`)
+		require.NoError(t, err)
+
+		lastKey := func(db *genji.DB) document.Value {
+			d, err := db.QueryDocument("SELECT last_insert_key() AS k")
+			require.NoError(t, err)
+			v, err := d.GetByField("k")
+			require.NoError(t, err)
+			return v
+		}
+
+		// the key is kept by the transaction, the documents inserted by the trigger are ignored
+		err = db.Exec(`
+			BEGIN;
+			INSERT INTO test (a) VALUES (1), (2);
+			INSERT INTO test (a) VALUES (last_insert_key() + 10);
+			INSERT INTO log (a) SELECT last_insert_key();
+			COMMIT;
+		`)
+		require.NoError(t, err)
+		d, err := db.QueryDocument("SELECT a FROM log ORDER BY a DESC LIMIT 1")
+		require.NoError(t, err)
+		var a int
+		err = document.Scan(d, &a)
+		require.NoError(t, err)
+		require.Equal(t, 12, a)
+
+		// statements that don't insert documents don't change it
+		err = db.Exec(`
+			BEGIN;
+			INSERT INTO test (a) VALUES (30);
+			INSERT INTO test (a) SELECT a FROM test WHERE a > 100;
+			INSERT INTO log (a) SELECT last_insert_key();
+			COMMIT;
+		`)
+		require.NoError(t, err)
+		d, err = db.QueryDocument("SELECT a FROM log ORDER BY a DESC LIMIT 1")
+		require.NoError(t, err)
+		err = document.Scan(d, &a)
+		require.NoError(t, err)
+		require.Equal(t, 30, a)
+
+		// every statement run outside of a transaction runs in its own,
+		// which doesn't see the keys inserted by the previous ones
+		err = db.Exec("INSERT INTO test (a) VALUES (40)")
+		require.NoError(t, err)
+		require.Equal(t, document.NewNullValue(), lastKey(db))
+	})
+
+	t.Run("with select", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE foo;
+			CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT NOT NULL);
+			INSERT INTO foo (c, d, e) VALUES (1, 'a', true), (2, 'b', false), (3, 'c', true);
+		`)
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (a, b) SELECT c, d FROM foo WHERE e")
+		require.NoError(t, err)
+		// values are converted to the types of the field constraints
+		require.Equal(t, `[{"a": 1, "b": "a"}, {"a": 3, "b": "c"}]`, queryJSON(t, db, "SELECT * FROM test"))
+
+		err = db.Exec("INSERT INTO test SELECT c AS a, d AS b FROM foo WHERE c = 2")
+		require.NoError(t, err)
+		require.Equal(t, `[{"a": 1, "b": "a"}, {"a": 2, "b": "b"}, {"a": 3, "b": "c"}]`, queryJSON(t, db, "SELECT * FROM test"))
+
+		// the primary key is checked
+		err = db.Exec("INSERT INTO test (a, b) SELECT c, d FROM foo WHERE c = 1")
+		require.Equal(t, database.ErrDuplicateDocument, err)
+
+		// field constraints are checked
+		err = db.Exec("INSERT INTO test (a) SELECT c + 10 FROM foo")
+		require.Error(t, err)
+
+		// wildcards are expanded at runtime
+		err = db.Exec("INSERT INTO test (a) SELECT * FROM foo")
+		require.Error(t, err)
+
+		// documents read from the same table
+		err = db.Exec("INSERT INTO test (a, b) SELECT a + 10, b FROM test")
+		require.NoError(t, err)
+		require.Equal(t, `[{"a": 1, "b": "a"}, {"a": 2, "b": "b"}, {"a": 3, "b": "c"}, {"a": 11, "b": "a"}, {"a": 12, "b": "b"}, {"a": 13, "b": "c"}]`, queryJSON(t, db, "SELECT * FROM test"))
+
+		res, err := db.Query("INSERT INTO foo SELECT a AS c FROM test WHERE a > 10")
+		require.NoError(t, err)
+		require.EqualValues(t, 3, res.RowsAffected)
+		require.NoError(t, res.Close())
+	})
+
+	t.Run("with on conflict", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT, n INTEGER);
+			CREATE UNIQUE INDEX idx_b ON test (b);
+			INSERT INTO test (a, b, n) VALUES (1, 'a', 1), (2, 'b', 1);
+		`)
+		require.NoError(t, err)
+
+		// without ON CONFLICT, the statement fails
+		err = db.Exec("INSERT INTO test (a, b) VALUES (1, 'c')")
+		require.Equal(t, database.ErrDuplicateDocument, err)
+
+		// primary key and unique index conflicts are ignored
+		res, err := db.Query("INSERT INTO test (a, b) VALUES (1, 'c'), (3, 'b'), (4, 'd'), (5, 'd') ON CONFLICT DO NOTHING")
+		require.NoError(t, err)
+		require.EqualValues(t, 1, res.RowsAffected)
+		require.NoError(t, res.Close())
+		require.Equal(t, `[{"a": 1, "b": "a", "n": 1}, {"a": 2, "b": "b", "n": 1}, {"a": 4, "b": "d"}]`, queryJSON(t, db, "SELECT * FROM test"))
+
+		// conflicting documents are updated
+		err = db.Exec("INSERT INTO test (a, b, n) VALUES (1, 'e', 10), (6, 'b', 5), (7, 'f', 1) ON CONFLICT DO UPDATE SET n = n + excluded.n, c = test.b")
+		require.NoError(t, err)
+		require.Equal(t, `[{"a": 1, "b": "a", "n": 11, "c": "a"}, {"a": 2, "b": "b", "n": 6, "c": "b"}, {"a": 4, "b": "d"}, {"a": 7, "b": "f", "n": 1}]`, queryJSON(t, db, "SELECT * FROM test"))
+
+		// the updated document must not conflict with another one
+		err = db.Exec("INSERT INTO test (a) VALUES (1) ON CONFLICT DO UPDATE SET b = 'b'")
+		require.Equal(t, database.ErrDuplicateDocument, err)
+
+		// with INSERT ... SELECT
+		err = db.Exec("INSERT INTO test SELECT a, b, 0 AS n FROM test ON CONFLICT DO UPDATE SET n = excluded.n")
+		require.NoError(t, err)
+		require.Equal(t, `[{"a": 1, "b": "a", "n": 0, "c": "a"}, {"a": 2, "b": "b", "n": 0, "c": "b"}, {"a": 4, "b": "d", "n": 0}, {"a": 7, "b": "f", "n": 0}]`, queryJSON(t, db, "SELECT * FROM test"))
+	})
+
+	t.Run("with returning", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER PRIMARY KEY, b TEXT);
+			CREATE TABLE foo;
+		`)
+		require.NoError(t, err)
+
+		out, res := queryResult(t, db, "INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b') RETURNING *")
+		require.Equal(t, `[{"a": 1, "b": "a"}, {"a": 2, "b": "b"}]`, out)
+		require.EqualValues(t, 2, res.RowsAffected)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"INSERT INTO foo (b) VALUES ('c') RETURNING pk(), b AS name", `[{"pk()": 1, "name": "c"}]`},
+			{"INSERT INTO test (a, b) VALUES (1, 'c'), (3, 'c') ON CONFLICT DO NOTHING RETURNING a", `[{"a": 3}]`},
+			{"INSERT INTO test (a, b) VALUES (1, 'd') ON CONFLICT DO UPDATE SET b = excluded.b RETURNING *", `[{"a": 1, "b": "d"}]`},
+			{"INSERT INTO test (a) VALUES (1) ON CONFLICT DO NOTHING RETURNING a", `[]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+
+	t.Run("with autoincrement", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(id INTEGER PRIMARY KEY AUTOINCREMENT, a TEXT)")
+		require.NoError(t, err)
+
+		out, res := queryResult(t, db, "INSERT INTO test (a) VALUES ('a'), ('b') RETURNING id")
+		require.Equal(t, `[{"id": 1}, {"id": 2}]`, out)
+		require.Equal(t, document.NewIntegerValue(2), res.LastInsertPK)
+
+		// explicit keys are kept and move the sequence past them
+		err = db.Exec("INSERT INTO test (id, a) VALUES (10, 'c')")
+		require.NoError(t, err)
+
+		out, res = queryResult(t, db, "INSERT INTO test (id, a) VALUES (NULL, 'd'), (NULL, 'e') RETURNING *")
+		require.Equal(t, `[{"id": 11, "a": "d"}, {"id": 12, "a": "e"}]`, out)
+		require.Equal(t, document.NewIntegerValue(12), res.LastInsertPK)
+
+		// smaller explicit keys don't move it backward
+		err = db.Exec("INSERT INTO test (id, a) VALUES (3, 'f')")
+		require.NoError(t, err)
+
+		// ON CONFLICT doesn't consume values of the sequence
+		err = db.Exec("INSERT INTO test (a) VALUES ('g') ON CONFLICT DO NOTHING")
+		require.NoError(t, err)
+
+		require.Equal(t, `[{"id": 1, "a": "a"}, {"id": 2, "a": "b"}, {"id": 3, "a": "f"}, {"id": 10, "a": "c"}, {"id": 11, "a": "d"}, {"id": 12, "a": "e"}, {"id": 13, "a": "g"}]`, queryJSON(t, db, "SELECT id, a FROM test"))
+
+		// keys of deleted documents are not reused
+		err = db.Exec("DELETE FROM test WHERE id = 13")
+		require.NoError(t, err)
+		require.Equal(t, `[{"id": 14}]`, queryJSON(t, db, "INSERT INTO test (a) VALUES ('h') RETURNING id"))
+	})
+
+	t.Run("with random primary keys", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(id TEXT PRIMARY KEY DEFAULT uuid(), a INTEGER)")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (a) VALUES (1), (2); INSERT INTO test (a) SELECT random() FROM test")
+		require.NoError(t, err)
+
+		res, err := db.Query("SELECT id FROM test")
+		require.NoError(t, err)
+		defer res.Close()
+
+		ids := make(map[string]bool)
+		err = res.Iterate(func(d document.Document) error {
+			v, err := d.GetByField("id")
+			if err != nil {
+				return err
+			}
+			ids[v.V.(string)] = true
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, ids, 4)
+	})
 }
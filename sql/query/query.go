@@ -71,6 +71,7 @@ func (q Query) Run(ctx context.Context, db *database.Database, args []expr.Param
 
 			return nil, err
 		}
+		setLastInsertKey(q.tx, &res)
 
 		// it there is an opened transaction but there are still statements
 		// to be executed, close the current transaction.
@@ -91,6 +92,23 @@ func (q Query) Run(ctx context.Context, db *database.Database, args []expr.Param
 	}
 
 	if q.autoCommit {
+		if q.tx != nil && q.tx.Writable() && db.MaterializeResults && !res.Stream.IsEmpty() {
+			// copy the documents and release the transaction
+			// before returning the result.
+			err = res.materialize()
+			if err != nil {
+				q.tx.Rollback()
+				return nil, err
+			}
+
+			err = q.tx.Commit()
+			if err != nil {
+				return nil, err
+			}
+
+			return &res, nil
+		}
+
 		// the returned result will now own the transaction.
 		// its Close method is expected to be called.
 		res.Tx = q.tx
@@ -109,11 +127,22 @@ func (q Query) Exec(tx *database.Transaction, args []expr.Param) (*Result, error
 		if err != nil {
 			return nil, err
 		}
+		setLastInsertKey(tx, &res)
 	}
 
 	return &res, nil
 }
 
+// setLastInsertKey stores the key of the last document inserted by a statement
+// in the transaction.
+// Statements run by triggers are not run by a query, the documents they insert
+// don't change the last inserted key.
+func setLastInsertKey(tx *database.Transaction, res *Result) {
+	if res.LastInsertPK.Type != 0 {
+		tx.SetLastInsertKey(res.LastInsertPK)
+	}
+}
+
 // New creates a new query with the given statements.
 func New(statements ...Statement) Query {
 	return Query{Statements: statements}
@@ -130,8 +159,39 @@ type Result struct {
 	document.Stream
 	RowsAffected  int64
 	LastInsertKey []byte
-	Tx            *database.Transaction
-	closed        bool
+	// LastInsertPK is the primary key of the last inserted document,
+	// or its generated key if the table has no primary key.
+	LastInsertPK document.Value
+	Tx           *database.Transaction
+	closed       bool
+
+	// documents written by a statement with a RETURNING clause.
+	returned []document.Document
+}
+
+// materialize reads the entire stream and replaces it
+// with a stream of copies of its documents, which remains
+// usable once the transaction is closed.
+func (r *Result) materialize() error {
+	var docs []document.Document
+
+	err := r.Iterate(func(d document.Document) error {
+		var fb document.FieldBuffer
+
+		err := fb.Copy(d)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, &fb)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.Stream = document.NewStream(document.NewIterator(docs...))
+	return nil
 }
 
 // Close the result stream.
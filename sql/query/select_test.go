@@ -2,16 +2,57 @@ package query_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/genjidb/genji"
 	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/engine"
+	"github.com/genjidb/genji/engine/memoryengine"
+	"github.com/genjidb/genji/sql/parser"
+	"github.com/genjidb/genji/sql/query"
 	"github.com/stretchr/testify/require"
 )
 
+// queryer runs queries, it is implemented by genji.DB and genji.Tx.
+type queryer interface {
+	Query(q string, args ...interface{}) (*query.Result, error)
+}
+
+// queryResult runs the query and returns the documents of its result as a JSON array,
+// along with the closed result.
+func queryResult(t testing.TB, db queryer, q string, args ...interface{}) (string, *query.Result) {
+	t.Helper()
+
+	res, err := db.Query(q, args...)
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = document.IteratorToJSONArray(&buf, res)
+	require.NoError(t, err)
+	return buf.String(), res
+}
+
+// queryJSON runs the query and returns the documents of its result as a JSON array.
+func queryJSON(t testing.TB, db queryer, q string, args ...interface{}) string {
+	t.Helper()
+
+	out, _ := queryResult(t, db, q, args...)
+	return out
+}
+
 func TestSelectStmt(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -31,11 +72,14 @@ func TestSelectStmt(t *testing.T) {
 		{"No table, field", "SELECT a", true, ``, nil},
 		{"No table, wildcard", "SELECT *", true, ``, nil},
 		{"No table, document", "SELECT {a: 1, b: 2 + 1}", false, `[{"{a: 1, b: 2 + 1}":{"a":1,"b":3}}]`, nil},
+		{"No table, object()", "SELECT object(1 + 1)", false, `[{"object(1 + 1)":{"1 + 1":2}}]`, nil},
+		{"No table, array()", "SELECT array(1, NULL, 'a') AS v", false, `[{"v":[1, null, "a"]}]`, nil},
 		{"No cond", "SELECT * FROM test", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
 		{"With DISTINCT", "SELECT DISTINCT * FROM test", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100},{"k":3,"height":100,"weight":200}]`, nil},
 		{"With DISTINCT and expr", "SELECT DISTINCT 'a' FROM test", false, `[{"'a'":"a"}]`, nil},
 		{"Multiple wildcards cond", "SELECT *, *, color FROM test", false, `[{"k":1,"color":"red","size":10,"shape":"square","k":1,"color":"red","size":10,"shape":"square","color":"red"},{"k":2,"color":"blue","size":10,"weight":100,"k":2,"color":"blue","size":10,"weight":100,"color":"blue"},{"k":3,"height":100,"weight":200,"k":3,"height":100,"weight":200,"color":null}]`, nil},
 		{"With fields", "SELECT color, shape FROM test", false, `[{"color":"red","shape":"square"},{"color":"blue","shape":null},{"color":null,"shape":null}]`, nil},
+		{"With object()", "SELECT k, object(color, size) AS attrs FROM test", false, `[{"k":1,"attrs":{"color":"red","size":10}},{"k":2,"attrs":{"color":"blue","size":10}},{"k":3,"attrs":{"color":null,"size":null}}]`, nil},
 		{"With expr fields", "SELECT color, color != 'red' AS notred FROM test", false, `[{"color":"red","notred":false},{"color":"blue","notred":true},{"color":null,"notred":null}]`, nil},
 		{"With eq op", "SELECT * FROM test WHERE size = 10", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":2,"color":"blue","size":10,"weight":100}]`, nil},
 		{"With neq op", "SELECT * FROM test WHERE color != 'red'", false, `[{"k":2,"color":"blue","size":10,"weight":100}]`, nil},
@@ -50,6 +94,7 @@ func TestSelectStmt(t *testing.T) {
 		{"With IN op on PK", "SELECT color FROM test WHERE k IN [1.1, 1.0] ORDER BY k", false, `[{"color":"red"}]`, nil},
 		{"With NOT IN op", "SELECT color FROM test WHERE color NOT IN ['red', 'purple'] ORDER BY k", false, `[{"color":"blue"}]`, nil},
 		{"With field comparison", "SELECT * FROM test WHERE color < shape", false, `[{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
+		{"With numeric field comparison, missing field", "SELECT k FROM test WHERE size < weight", false, `[{"k":2}]`, nil},
 		{"With group by", "SELECT color FROM test GROUP BY color", false, `[{"color":"red"},{"color":"blue"},{"color":null}]`, nil},
 		{"With group by and count", "SELECT COUNT(k) FROM test GROUP BY size", false, `[{"COUNT(k)":2},{"COUNT(k)":1}]`, nil},
 		{"With group by and count wildcard", "SELECT COUNT(*  ) FROM test GROUP BY size", false, `[{"COUNT(*  )":2},{"COUNT(*  )":1}]`, nil},
@@ -72,6 +117,10 @@ func TestSelectStmt(t *testing.T) {
 		{"With offset", "SELECT *, pk() FROM test WHERE size = 10 OFFSET 1", false, `[{"pk()":2,"color":"blue","size":10,"weight":100,"k":2}]`, nil},
 		{"With limit then offset", "SELECT * FROM test WHERE size = 10 LIMIT 1 OFFSET 1", false, `[{"k":2,"color":"blue","size":10,"weight":100,"k":2}]`, nil},
 		{"With offset then limit", "SELECT * FROM test WHERE size = 10 OFFSET 1 LIMIT 1", true, "", nil},
+		{"With fractional limit", "SELECT * FROM test WHERE size = 10 LIMIT 1.9", false, `[{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
+		{"With fractional offset", "SELECT * FROM test WHERE size = 10 LIMIT 1 OFFSET 0.9", false, `[{"k":1,"color":"red","size":10,"shape":"square"}]`, nil},
+		{"With negative limit", "SELECT * FROM test LIMIT -1.5", true, "", nil},
+		{"With overflowing limit", "SELECT * FROM test LIMIT 100000000000000000000.0", true, "", nil},
 		{"With positional params", "SELECT * FROM test WHERE color = ? OR height = ?", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, []interface{}{"red", 100}},
 		{"With named params", "SELECT * FROM test WHERE color = $a OR height = $d", false, `[{"k":1,"color":"red","size":10,"shape":"square"},{"k":3,"height":100,"weight":200}]`, []interface{}{sql.Named("a", "red"), sql.Named("d", 100)}},
 		{"With pk()", "SELECT pk(), color FROM test", false, `[{"pk()":1,"color":"red"},{"pk()":2,"color":"blue"},{"pk()":3,"color":null}]`, []interface{}{sql.Named("a", "red"), sql.Named("d", 100)}},
@@ -80,6 +129,8 @@ func TestSelectStmt(t *testing.T) {
 		{"With count", "SELECT COUNT(k) FROM test", false, `[{"COUNT(k)": 3}]`, nil},
 		{"With count wildcard", "SELECT COUNT(*) FROM test", false, `[{"COUNT(*)": 3}]`, nil},
 		{"With multiple counts", "SELECT COUNT(k), COUNT(color) FROM test", false, `[{"COUNT(k)": 3, "COUNT(color)": 2}]`, nil},
+		{"With count of missing fields", "SELECT COUNT(*), COUNT(weight), COUNT(shape), COUNT(notfound) FROM test", false, `[{"COUNT(*)": 3, "COUNT(weight)": 2, "COUNT(shape)": 1, "COUNT(notfound)": 0}]`, nil},
+		{"With count of missing fields and group by", "SELECT COUNT(*), COUNT(weight) FROM test GROUP BY size", false, `[{"COUNT(*)": 2, "COUNT(weight)": 1},{"COUNT(*)": 1, "COUNT(weight)": 1}]`, nil},
 		{"With min", "SELECT MIN(k) FROM test", false, `[{"MIN(k)": 1}]`, nil},
 		{"With multiple mins", "SELECT MIN(color), MIN(weight) FROM test", false, `[{"MIN(color)": "blue", "MIN(weight)": 100}]`, nil},
 		{"With max", "SELECT MAX(k) FROM test", false, `[{"MAX(k)": 3}]`, nil},
@@ -89,6 +140,23 @@ func TestSelectStmt(t *testing.T) {
 		{"With two non existing idents, =", "SELECT * FROM test WHERE z = y", false, `[]`, nil},
 		{"With two non existing idents, >", "SELECT * FROM test WHERE z > y", false, `[]`, nil},
 		{"With two non existing idents, !=", "SELECT * FROM test WHERE z != y", false, `[]`, nil},
+		{"With BETWEEN", "SELECT k FROM test WHERE weight BETWEEN 50 AND 150", false, `[{"k":2}]`, nil},
+		{"With BETWEEN and params", "SELECT k FROM test WHERE size BETWEEN ? AND ?", false, `[{"k":1},{"k":2}]`, []interface{}{5, 10.0}},
+		{"With BETWEEN and empty range", "SELECT k FROM test WHERE weight BETWEEN 150 AND 50", false, `[]`, nil},
+		{"With BETWEEN and mixed types", "SELECT k FROM test WHERE color BETWEEN 1 AND 'z'", false, `[]`, nil},
+		{"With NOT BETWEEN", "SELECT k FROM test WHERE size NOT BETWEEN 5 AND 9", false, `[{"k":1},{"k":2}]`, nil},
+		{"With LIKE prefix", "SELECT k FROM test WHERE color LIKE 'R%'", false, `[{"k":1}]`, nil},
+		{"With LIKE wildcards", "SELECT k FROM test WHERE color LIKE '%e%'", false, `[{"k":1},{"k":2}]`, nil},
+		{"With LIKE prefix and wildcards", "SELECT k FROM test WHERE color LIKE 'b_u%'", false, `[{"k":2}]`, nil},
+		{"With LIKE on non-text values", "SELECT k FROM test WHERE size LIKE '1%'", false, `[]`, nil},
+		{"With NOT LIKE", "SELECT k FROM test WHERE color NOT LIKE 'r%'", false, `[{"k":2}]`, nil},
+		{"With regexp", "SELECT k FROM test WHERE color =~ '^(red|green)$'", false, `[{"k":1}]`, nil},
+		{"With regexp and params", "SELECT k FROM test WHERE color =~ ?", false, `[{"k":1},{"k":2}]`, []interface{}{"e"}},
+		{"With not regexp", "SELECT k FROM test WHERE color !~ 'r'", false, `[{"k":2}]`, nil},
+		{"With IN op and params", "SELECT k FROM test WHERE color IN ('red', ?, 'red') ORDER BY k", false, `[{"k":1},{"k":2}]`, []interface{}{"blue"}},
+		{"With IN op and numeric params", "SELECT k FROM test WHERE size IN (?, 20)", false, `[{"k":1},{"k":2}]`, []interface{}{10}},
+		{"With IN op and NULL", "SELECT k FROM test WHERE color IN ('blue', NULL, ?)", false, `[{"k":2}]`, []interface{}{nil}},
+		{"With NOT IN op and array param", "SELECT k FROM test WHERE color NOT IN ?", false, `[{"k":2}]`, []interface{}{[]string{"red"}}},
 		// See issue https://github.com/genjidb/genji/issues/283
 		{"With empty WHERE and IN", "SELECT * FROM test WHERE [] IN [];", false, `[]`, nil},
 	}
@@ -259,79 +327,2170 @@ func TestSelectStmt(t *testing.T) {
 
 		require.JSONEq(t, `{"MAX(a)": null, "MIN(b)": null, "COUNT(*)": 0, "SUM(id)": null}`, string(enc))
 	})
-}
-
-func TestDistinct(t *testing.T) {
-	types := []struct {
-		name          string
-		generateValue func(i, notUniqueCount int) (unique interface{}, notunique interface{})
-	}{
-		{`integer`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
-			return i, i % notUniqueCount
-		}},
-		{`double`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
-			return float64(i), float64(i % notUniqueCount)
-		}},
-		{`text`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
-			return strconv.Itoa(i), strconv.Itoa(i % notUniqueCount)
-		}},
-		{`array`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
-			return []interface{}{i}, []interface{}{i % notUniqueCount}
-		}},
-	}
-
-	for _, typ := range types {
-		total := 100
-		notUnique := total / 10
 
-		t.Run(typ.name, func(t *testing.T) {
+	t.Run("range scans with null values", func(t *testing.T) {
+		for _, def := range []string{"CREATE TABLE test", "CREATE TABLE test(a INTEGER)"} {
 			db, err := genji.Open(":memory:")
 			require.NoError(t, err)
 			defer db.Close()
 
-			tx, err := db.Begin(true)
+			err = db.Exec(def + "; CREATE INDEX idx_a ON test(a)")
 			require.NoError(t, err)
-			defer tx.Rollback()
 
-			err = tx.Exec("CREATE TABLE test(a " + typ.name + " PRIMARY KEY, b " + typ.name + ", doc DOCUMENT, nullable " + typ.name + ");")
+			err = db.Exec("INSERT INTO test (k, a) VALUES (1, 1), (2, -1), (3, NULL); INSERT INTO test (k) VALUES (4)")
 			require.NoError(t, err)
 
-			err = tx.Exec("CREATE UNIQUE INDEX test_doc_index ON test(doc);")
+			tests := []struct {
+				query    string
+				expected string
+			}{
+				{"SELECT k FROM test WHERE a > 0", `[{"k": 1}]`},
+				{"SELECT k FROM test WHERE a < 5", `[{"k": 2}, {"k": 1}]`},
+				{"SELECT k FROM test WHERE a >= -10", `[{"k": 2}, {"k": 1}]`},
+				{"SELECT k FROM test WHERE a = NULL", `[]`},
+				{"SELECT k FROM test WHERE a IS NULL", `[{"k": 3}, {"k": 4}]`},
+			}
+
+			for _, test := range tests {
+				require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+			}
+
+			// documents without the indexed field can be updated and deleted
+			err = db.Exec("UPDATE test SET a = 10 WHERE k = 4")
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"k": 1}, {"k": 4}]`, queryJSON(t, db, "SELECT k FROM test WHERE a > 0"))
+			err = db.Exec("UPDATE test UNSET a WHERE k = 4")
 			require.NoError(t, err)
+			require.JSONEq(t, `[{"k": 1}]`, queryJSON(t, db, "SELECT k FROM test WHERE a > 0"))
+			err = db.Exec("DELETE FROM test WHERE a IS NULL; REINDEX; DELETE FROM test")
+			require.NoError(t, err)
+			require.JSONEq(t, `[]`, queryJSON(t, db, "SELECT k FROM test"))
+		}
+	})
 
-			for i := 0; i < total; i++ {
-				unique, nonunique := typ.generateValue(i, notUnique)
-				err = tx.Exec(`INSERT INTO test VALUES {a: ?, b: ?, doc: {a: ?, b: ?}, nullable: null}`, unique, nonunique, unique, nonunique)
-				require.NoError(t, err)
+	t.Run("count with null values", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test; INSERT INTO test (a) VALUES (1), (NULL), (3); INSERT INTO test (b) VALUES (1);")
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument("SELECT COUNT(*), COUNT(a), COUNT(a + 1) FROM test")
+		require.NoError(t, err)
+
+		enc, err := json.Marshal(d)
+		require.NoError(t, err)
+
+		require.JSONEq(t, `{"COUNT(*)": 4, "COUNT(a)": 2, "COUNT(a + 1)": 2}`, string(enc))
+	})
+
+	t.Run("stable order", func(t *testing.T) {
+		var gets int
+		ng := engine.NewLoggingEngine(memoryengine.NewEngine(), engine.LoggerFunc(func(op engine.Operation) {
+			if op.Name == "Get" && !strings.HasPrefix(op.Store, "__genji_") {
+				gets++
 			}
-			err = tx.Commit()
+		}))
+		db, err := genji.New(context.Background(), ng)
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY);
+			CREATE INDEX idx_a ON test (a);
+			INSERT INTO test (k, a) VALUES (1, 3), (2, 1), (3, 2);
+		`)
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[{"k": 2}, {"k": 3}, {"k": 1}]`, queryJSON(t, db, "SELECT k FROM test WHERE a > 0"))
+
+		db.DB.StableOrder = true
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT k FROM test WHERE a > 0", `[{"k": 1}, {"k": 2}, {"k": 3}]`},
+			{"SELECT k FROM test WHERE a >= 2", `[{"k": 1}, {"k": 3}]`},
+			{"SELECT k FROM test WHERE a > 0 ORDER BY a DESC", `[{"k": 1}, {"k": 3}, {"k": 2}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// every document is read once
+		gets = 0
+		require.JSONEq(t, `[{"a": 3}, {"a": 1}, {"a": 2}]`, queryJSON(t, db, "SELECT a FROM test WHERE a > 0"))
+		require.Equal(t, 3, gets)
+	})
+
+	t.Run("read ahead", func(t *testing.T) {
+		ng := readAheadEngine{Engine: memoryengine.NewEngine()}
+		db, err := genji.New(context.Background(), &ng)
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY);
+			CREATE INDEX idx_a ON test (a);
+			INSERT INTO test (k, a) VALUES (1, 3), (2, 1), (3, 2);
+		`)
+		require.NoError(t, err)
+
+		// items are read one at a time by default
+		ng.sizes = nil
+		require.JSONEq(t, `[{"k": 1}, {"k": 2}, {"k": 3}]`, queryJSON(t, db, "SELECT k FROM test"))
+		require.Equal(t, []int{0}, ng.sizes)
+
+		db.DB.ReadAheadSize = 64
+
+		// table and index scans fetch the items in batches
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT k FROM test", `[{"k": 1}, {"k": 2}, {"k": 3}]`},
+			{"SELECT k FROM test WHERE a > 1", `[{"k": 3}, {"k": 1}]`},
+		}
+
+		for _, test := range tests {
+			ng.sizes = nil
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+			require.Equal(t, []int{64}, ng.sizes, test.query)
+		}
+	})
+
+	t.Run("order by with spilling", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "genji")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		for _, path := range []string{":memory:", filepath.Join(dir, "test.db")} {
+			db, err := genji.Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test")
 			require.NoError(t, err)
 
+			values := []int{7, 3, 9, 1, 3, 8, 0, 5, 2, 6, 4, 3}
+			for _, v := range values {
+				err = db.Exec("INSERT INTO test (a) VALUES (?)", v)
+				require.NoError(t, err)
+			}
+
+			db.DB.SortBufferSize = 5
+
 			tests := []struct {
-				name          string
-				query         string
-				expectedCount int
+				query    string
+				expected string
 			}{
-				{`unique`, `SELECT DISTINCT a FROM test`, total},
-				{`non-unique`, `SELECT DISTINCT b FROM test`, notUnique},
-				{`documents`, `SELECT DISTINCT doc FROM test`, total},
-				{`null`, `SELECT DISTINCT nullable FROM test`, 1},
-				{`wildcard`, `SELECT DISTINCT * FROM test`, total},
-				{`literal`, `SELECT DISTINCT 'a' FROM test`, 1},
-				{`pk()`, `SELECT DISTINCT pk() FROM test`, total},
+				{"SELECT a FROM test ORDER BY a", `[{"a": 0}, {"a": 1}, {"a": 2}, {"a": 3}, {"a": 3}, {"a": 3}, {"a": 4}, {"a": 5}, {"a": 6}, {"a": 7}, {"a": 8}, {"a": 9}]`},
+				{"SELECT a FROM test ORDER BY a DESC", `[{"a": 9}, {"a": 8}, {"a": 7}, {"a": 6}, {"a": 5}, {"a": 4}, {"a": 3}, {"a": 3}, {"a": 3}, {"a": 2}, {"a": 1}, {"a": 0}]`},
+				{"SELECT a FROM test ORDER BY a LIMIT 3 OFFSET 2", `[{"a": 2}, {"a": 3}, {"a": 3}]`},
 			}
 
 			for _, test := range tests {
-				t.Run(test.name, func(t *testing.T) {
-					q, err := db.Query(test.query)
-					require.NoError(t, err)
-					defer q.Close()
+				require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+			}
 
-					c, err := q.Count()
-					require.NoError(t, err)
-					require.Equal(t, test.expectedCount, c)
-				})
+			// read-only transactions spill to a temporary file on disk,
+			// or sort in memory
+			err = db.View(func(tx *genji.Tx) error {
+				require.JSONEq(t,
+					`[{"a": 9}, {"a": 8}, {"a": 7}, {"a": 6}, {"a": 5}, {"a": 4}, {"a": 3}, {"a": 3}, {"a": 3}, {"a": 2}, {"a": 1}, {"a": 0}]`,
+					queryJSON(t, tx, "SELECT a FROM test ORDER BY a DESC"))
+				return nil
+			})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("group by with spilling", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			INSERT INTO test (a, b) VALUES
+				(3, 1), (1, 2), (2, 3), (3, 4), (1, 5),
+				(3, 6), (2.0, 7), (4, 8), (1, 9), (3, 10);
+			INSERT INTO test (b) VALUES (11), (12);
+		`)
+		require.NoError(t, err)
+
+		var engines int
+		db.DB.NewTempEngine = func() (engine.Engine, error) {
+			engines++
+			return memoryengine.NewEngine(), nil
+		}
+
+		for _, bufferSize := range []int{0, 3} {
+			db.DB.SortBufferSize = bufferSize
+
+			for _, writable := range []bool{true, false} {
+				tx, err := db.Begin(writable)
+				require.NoError(t, err)
+				got := queryJSON(t, tx, "SELECT a, COUNT(*), SUM(b) FROM test GROUP BY a ORDER BY a")
+				require.NoError(t, tx.Rollback())
+
+				require.JSONEq(t, `[{"a": null, "COUNT(*)": 2, "SUM(b)": 23}, {"a": 1, "COUNT(*)": 3, "SUM(b)": 16}, {"a": 2, "COUNT(*)": 2, "SUM(b)": 10}, {"a": 3, "COUNT(*)": 4, "SUM(b)": 21}, {"a": 4, "COUNT(*)": 1, "SUM(b)": 8}]`, got, "buffer: %d, writable: %v", bufferSize, writable)
 			}
-		})
-	}
+		}
+
+		// the read-only transaction with a limited buffer creates a single
+		// temporary engine for all of its temporary stores
+		require.Equal(t, 1, engines)
+
+		d, err := db.QueryDocument("EXPLAIN SELECT a, COUNT(*) FROM test GROUP BY a")
+		require.NoError(t, err)
+		v, err := d.GetByField("plan")
+		require.NoError(t, err)
+		require.Equal(t, `"Table(test) -> Sort(a ASC) -> Group(a) -> Aggregate(a, COUNT(*), sorted) -> ∏(a, COUNT(*))"`, v.String())
+	})
+
+	t.Run("order by multiple fields", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "genji")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		for _, path := range []string{":memory:", filepath.Join(dir, "test.db")} {
+			db, err := genji.Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(`
+				CREATE TABLE test;
+				INSERT INTO test (k, last_name, age) VALUES
+					(1, 'smith', 30), (2, 'doe', 40), (3, 'smith', 50),
+					(4, 'doe', 20), (5, 'smithson', 10), (6, 'smith', 30);
+				INSERT INTO test (k, age) VALUES (7, 60);
+			`)
+			require.NoError(t, err)
+
+			for _, bufferSize := range []int{0, 3} {
+				db.DB.SortBufferSize = bufferSize
+
+				tests := []struct {
+					query    string
+					expected string
+				}{
+					{"SELECT k FROM test ORDER BY last_name ASC, age DESC", `[{"k": 7}, {"k": 2}, {"k": 4}, {"k": 3}, {"k": 1}, {"k": 6}, {"k": 5}]`},
+					// ties are returned in reverse order when the first field is descending
+					{"SELECT k FROM test ORDER BY last_name DESC, age ASC", `[{"k": 5}, {"k": 6}, {"k": 1}, {"k": 3}, {"k": 4}, {"k": 2}, {"k": 7}]`},
+					{"SELECT k FROM test ORDER BY last_name DESC, age, k", `[{"k": 5}, {"k": 1}, {"k": 6}, {"k": 3}, {"k": 4}, {"k": 2}, {"k": 7}]`},
+					{"SELECT k FROM test ORDER BY last_name, age LIMIT 6", `[{"k": 7}, {"k": 4}, {"k": 2}, {"k": 1}, {"k": 6}, {"k": 3}]`},
+					{"SELECT k FROM test ORDER BY last_name, age DESC LIMIT 3 OFFSET 3", `[{"k": 3}, {"k": 1}, {"k": 6}]`},
+					// the sort fields don't have to be projected
+					{"SELECT k FROM test WHERE last_name = 'doe' ORDER BY last_name, age DESC", `[{"k": 2}, {"k": 4}]`},
+				}
+
+				for _, test := range tests {
+					require.JSONEq(t, test.expected, queryJSON(t, db, test.query), "buffer: %d, %s", bufferSize, test.query)
+				}
+			}
+		}
+	})
+
+	t.Run("order by expressions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			INSERT INTO test (k, name, price, quantity) VALUES
+				(1, 'b', 10, 3), (2, 'a', 5, 10), (3, 'c', 2, 5), (4, 'd', 20, 1);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+			params   []interface{}
+		}{
+			{"SELECT k FROM test ORDER BY price * quantity DESC", `[{"k": 2}, {"k": 1}, {"k": 4}, {"k": 3}]`, nil},
+			{"SELECT k, price * quantity AS total FROM test ORDER BY total LIMIT 2", `[{"k": 3, "total": 10}, {"k": 4, "total": 20}]`, nil},
+			{"SELECT k FROM test ORDER BY pk() DESC", `[{"k": 4}, {"k": 3}, {"k": 2}, {"k": 1}]`, nil},
+			{"SELECT name FROM test ORDER BY CASE WHEN price < ? THEN 0 ELSE 1 END, name DESC", `[{"name": "c"}, {"name": "a"}, {"name": "d"}, {"name": "b"}]`, []interface{}{10}},
+			{"SELECT k FROM test ORDER BY (price, k)", `[{"k": 3}, {"k": 2}, {"k": 1}, {"k": 4}]`, nil},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query, test.params...), test.query)
+		}
+	})
+
+	t.Run("distinct with spilling", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "genji")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		for _, path := range []string{":memory:", filepath.Join(dir, "test.db")} {
+			db, err := genji.Open(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test")
+			require.NoError(t, err)
+
+			values := []int{7, 3, 9, 1, 3, 8, 0, 5, 2, 6, 4, 3, 7, 0}
+			for i, v := range values {
+				err = db.Exec("INSERT INTO test (a, b) VALUES (?, ?)", v, i)
+				require.NoError(t, err)
+			}
+
+			db.DB.DistinctBufferSize = 3
+
+			tests := []struct {
+				query    string
+				expected string
+			}{
+				{"SELECT DISTINCT a FROM test", `[{"a": 7}, {"a": 3}, {"a": 9}, {"a": 1}, {"a": 8}, {"a": 0}, {"a": 5}, {"a": 2}, {"a": 6}, {"a": 4}]`},
+				{"SELECT DISTINCT a FROM test ORDER BY a", `[{"a": 0}, {"a": 1}, {"a": 2}, {"a": 3}, {"a": 4}, {"a": 5}, {"a": 6}, {"a": 7}, {"a": 8}, {"a": 9}]`},
+				{"SELECT DISTINCT ON (a) b FROM test LIMIT 6", `[{"b": 0}, {"b": 1}, {"b": 2}, {"b": 3}, {"b": 5}, {"b": 6}]`},
+			}
+
+			for _, test := range tests {
+				require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+			}
+
+			// read-only transactions keep the values in memory
+			err = db.View(func(tx *genji.Tx) error {
+				st, err := tx.Query("SELECT DISTINCT a FROM test")
+				require.NoError(t, err)
+				defer st.Close()
+
+				n, err := st.Count()
+				require.NoError(t, err)
+				require.Equal(t, 10, n)
+				return nil
+			})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("distinct on", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(k INTEGER PRIMARY KEY);
+			INSERT INTO test (k, a, b) VALUES (1, 1, 'x'), (2, 1, 'y'), (3, 2, 'x'), (4, 2, 'x'), (5, 3, 'y');
+		`)
+		require.NoError(t, err)
+
+		// the first document of each group is returned
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT DISTINCT ON (a) k FROM test", `[{"k": 1}, {"k": 3}, {"k": 5}]`},
+			{"SELECT DISTINCT ON (b) k, b FROM test", `[{"k": 1, "b": "x"}, {"k": 2, "b": "y"}]`},
+			{"SELECT DISTINCT ON (a, b) k FROM test", `[{"k": 1}, {"k": 2}, {"k": 3}, {"k": 5}]`},
+			{"SELECT DISTINCT ON (a % 2) k FROM test", `[{"k": 1}, {"k": 3}]`},
+			// documents are sorted before being deduplicated
+			{"SELECT DISTINCT ON (a) k FROM test ORDER BY a DESC, k DESC", `[{"k": 5}, {"k": 4}, {"k": 2}]`},
+			{"SELECT DISTINCT ON (a) k FROM test ORDER BY a, k DESC", `[{"k": 2}, {"k": 4}, {"k": 5}]`},
+			{"SELECT DISTINCT ON (b, a) k FROM test ORDER BY a DESC, b, k DESC", `[{"k": 5}, {"k": 4}, {"k": 1}, {"k": 2}]`},
+			{"SELECT DISTINCT ON (a) k FROM test ORDER BY a DESC, k LIMIT 2", `[{"k": 5}, {"k": 3}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// the first document of each group must be the one ORDER BY selects
+		err = db.Exec(`
+			CREATE TABLE scores(k INTEGER PRIMARY KEY);
+			INSERT INTO scores (k, g, score) VALUES (1, 'p', 10), (2, 'p', 30), (3, 'q', 20), (5, 'p', 30);
+		`)
+		require.NoError(t, err)
+
+		db.DB.SortBufferSize = 2
+		require.Equal(t, `[{"g": "p", "score": 30}, {"g": "q", "score": 20}]`,
+			queryJSON(t, db, "SELECT DISTINCT ON (g) g, score FROM scores ORDER BY g, score DESC"))
+		db.DB.SortBufferSize = 0
+
+		// DISTINCT ON expressions must be the leading ORDER BY expressions
+		for _, q := range []string{
+			"SELECT DISTINCT ON (a) k FROM test ORDER BY k DESC",
+			"SELECT DISTINCT ON (a, b) k FROM test ORDER BY a",
+			"SELECT DISTINCT ON (a, b) k FROM test ORDER BY a, a, b",
+		} {
+			_, err = db.Query(q)
+			require.Error(t, err, q)
+		}
+	})
+
+	t.Run("strict projections", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		db.DB.StrictProjections = true
+
+		err = db.Exec("CREATE TABLE test; INSERT INTO test (a, b) VALUES (1, {c: 2});")
+		require.NoError(t, err)
+
+		d, err := db.QueryDocument("SELECT a, b.c, a + 1 AS d, notfound = 1 AS e FROM test")
+		require.NoError(t, err)
+		enc, err := json.Marshal(d)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"a": 1, "b.c": 2, "d": 2, "e": null}`, string(enc))
+
+		_, err = db.QueryDocument("SELECT a, colour FROM test")
+		require.True(t, errors.Is(err, document.ErrFieldNotFound))
+
+		_, err = db.QueryDocument("SELECT b.d AS d FROM test")
+		require.True(t, errors.Is(err, document.ErrFieldNotFound))
+	})
+
+	t.Run("booleans", func(t *testing.T) {
+		for _, idx := range []string{"", "CREATE INDEX idx_a ON test (a);", "CREATE INDEX idx_b ON test (b);"} {
+			t.Run(idx, func(t *testing.T) {
+				db, err := genji.Open(":memory:")
+				require.NoError(t, err)
+				defer db.Close()
+
+				err = db.Exec("CREATE TABLE test (k INTEGER PRIMARY KEY, b BOOL);" + idx)
+				require.NoError(t, err)
+				err = db.Exec("INSERT INTO test (k, a, b) VALUES (1, true, true), (2, false, false), (3, TRUE, 1), (4, 1, 0)")
+				require.NoError(t, err)
+
+				tests := []struct {
+					query    string
+					expected string
+				}{
+					{"SELECT k FROM test WHERE a = true", `[{"k": 1}, {"k": 3}]`},
+					{"SELECT k FROM test WHERE a = false", `[{"k": 2}]`},
+					{"SELECT k FROM test WHERE a < true", `[{"k": 2}]`},
+					{"SELECT k FROM test WHERE a > false", `[{"k": 1}, {"k": 3}]`},
+					{"SELECT k FROM test WHERE a", `[{"k": 1}, {"k": 3}, {"k": 4}]`},
+					{"SELECT k FROM test WHERE NOT a", `[{"k": 2}]`},
+					{"SELECT k FROM test WHERE b = true", `[{"k": 1}, {"k": 3}]`},
+					{"SELECT k FROM test WHERE NOT b", `[{"k": 2}, {"k": 4}]`},
+					{"SELECT b FROM test ORDER BY b", `[{"b": false}, {"b": false}, {"b": true}, {"b": true}]`},
+					{"SELECT CAST(b AS INTEGER) AS i, CAST(NOT b AS INTEGER) AS j FROM test WHERE k = 1", `[{"i": 1, "j": 0}]`},
+				}
+
+				for _, test := range tests {
+					require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+				}
+			})
+		}
+	})
+
+	t.Run("window functions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test (k INTEGER PRIMARY KEY);
+			INSERT INTO test (k, score) VALUES (1, 10), (2, 20), (3, 10), (4, 30), (5, 20);
+		`)
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[
+			{"rn": 1, "rank()": 1, "dense_rank()": 1, "score": 30},
+			{"rn": 2, "rank()": 2, "dense_rank()": 2, "score": 20},
+			{"rn": 3, "rank()": 2, "dense_rank()": 2, "score": 20},
+			{"rn": 4, "rank()": 4, "dense_rank()": 3, "score": 10},
+			{"rn": 5, "rank()": 4, "dense_rank()": 3, "score": 10}
+		]`, queryJSON(t, db, "SELECT row_number() AS rn, rank(), dense_rank(), score FROM test ORDER BY score DESC"))
+
+		// sorting by a field that is not projected
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT rank() AS r FROM test ORDER BY score", `[{"r": 1}, {"r": 1}, {"r": 3}, {"r": 3}, {"r": 5}]`},
+			// peers have the same value for every sort field
+			{"SELECT rank() AS r FROM test ORDER BY score, k", `[{"r": 1}, {"r": 2}, {"r": 3}, {"r": 4}, {"r": 5}]`},
+			// numbers are assigned before OFFSET and LIMIT
+			{"SELECT row_number() AS rn, score FROM test ORDER BY score LIMIT 2 OFFSET 1", `[{"rn": 2, "score": 10}, {"rn": 3, "score": 20}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// without ORDER BY, documents are numbered in the order of the stream
+		// and they are all peers
+		require.JSONEq(t, `[
+			{"k": 1, "rn": 1, "r": 1},
+			{"k": 2, "rn": 2, "r": 1},
+			{"k": 3, "rn": 3, "r": 1},
+			{"k": 4, "rn": 4, "r": 1},
+			{"k": 5, "rn": 5, "r": 1}
+		]`, queryJSON(t, db, "SELECT k, row_number() AS rn, rank() AS r FROM test"))
+
+		require.JSONEq(t, `[{"score": 10, "r": 1}, {"score": 20, "r": 2}, {"score": 30, "r": 3}]`, queryJSON(t, db, "SELECT score, rank() AS r FROM test GROUP BY score ORDER BY score"))
+
+		_, err = db.Query("SELECT row_number(1) FROM test")
+		require.Error(t, err)
+	})
+
+	t.Run("window functions with OVER", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			INSERT INTO test (k, dept, salary) VALUES
+				(1, 'a', 10), (2, 'b', 20), (3, 'a', 30), (4, 'b', 20), (5, 'a', 10);
+		`)
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[
+			{"k": 1, "rn": 2, "r": 2, "dr": 2},
+			{"k": 2, "rn": 1, "r": 1, "dr": 1},
+			{"k": 3, "rn": 1, "r": 1, "dr": 1},
+			{"k": 4, "rn": 2, "r": 1, "dr": 1},
+			{"k": 5, "rn": 3, "r": 2, "dr": 2}
+		]`, queryJSON(t, db, `
+			SELECT k,
+				row_number() OVER (PARTITION BY dept ORDER BY salary DESC) AS rn,
+				rank() OVER (PARTITION BY dept ORDER BY salary DESC) AS r,
+				dense_rank() OVER (PARTITION BY dept ORDER BY salary DESC) AS dr
+			FROM test`))
+
+		// ranks have gaps after peers
+		require.JSONEq(t,
+			`[{"k": 1, "r": 1}, {"k": 5, "r": 1}, {"k": 2, "r": 3}, {"k": 4, "r": 3}, {"k": 3, "r": 5}]`,
+			queryJSON(t, db, "SELECT k, rank() OVER (ORDER BY salary) AS r FROM test ORDER BY r, k"))
+
+		// lag and lead
+		require.JSONEq(t, `[
+			{"k": 1, "prev": null, "next": 3},
+			{"k": 2, "prev": null, "next": 4},
+			{"k": 3, "prev": 10, "next": 5},
+			{"k": 4, "prev": 20, "next": -1},
+			{"k": 5, "prev": 30, "next": -1}
+		]`, queryJSON(t, db, "SELECT k, lag(salary) OVER (PARTITION BY dept ORDER BY k) AS prev, lead(k, 2, -1) OVER (ORDER BY k) AS next FROM test"))
+
+		// the windows are computed before LIMIT
+		require.JSONEq(t, `[{"k": 4, "n": 4}, {"k": 3, "n": 3}]`, queryJSON(t, db, "SELECT k, row_number() OVER (ORDER BY k) AS n FROM test ORDER BY k DESC LIMIT 2 OFFSET 1"))
+
+		// windows over aggregated documents
+		require.JSONEq(t,
+			`[{"dept": "a", "r": 1, "COUNT(*)": 3}, {"dept": "b", "r": 2, "COUNT(*)": 2}]`,
+			queryJSON(t, db, "SELECT dept, rank() OVER (ORDER BY COUNT(*) DESC) AS r, COUNT(*) FROM test GROUP BY dept"))
+
+		// lag and lead require an OVER clause
+		_, err = db.Query("SELECT lag(salary) FROM test")
+		require.Error(t, err)
+	})
+
+	t.Run("common table expressions", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE emp;
+			INSERT INTO emp (id, name, parent_id) VALUES
+				(1, 'root', NULL), (2, 'a', 1), (3, 'b', 1), (4, 'c', 2), (5, 'd', 4), (6, 'e', 3);
+		`)
+		require.NoError(t, err)
+
+		// non recursive
+		require.JSONEq(t, `[{"name": "a"}, {"name": "b"}]`, queryJSON(t, db, "WITH children AS (SELECT * FROM emp WHERE parent_id = 1) SELECT name FROM children ORDER BY name"))
+
+		// subtree using a subquery
+		require.JSONEq(t, `[{"id": 2}, {"id": 4}, {"id": 5}]`, queryJSON(t, db, `
+			WITH RECURSIVE sub AS (
+				SELECT id FROM emp WHERE id = 2
+				UNION
+				SELECT id FROM emp WHERE parent_id IN (SELECT id FROM sub)
+			)
+			SELECT id FROM sub ORDER BY id`))
+
+		// depth using a join
+		require.JSONEq(t, `[
+			{"name": "root", "depth": 0},
+			{"name": "a", "depth": 1},
+			{"name": "b", "depth": 1},
+			{"name": "c", "depth": 2},
+			{"name": "e", "depth": 2},
+			{"name": "d", "depth": 3}
+		]`, queryJSON(t, db, `
+			WITH RECURSIVE tree AS (
+				SELECT id, name, 0 AS depth FROM emp WHERE parent_id IS NULL
+				UNION ALL
+				SELECT emp.id, emp.name, tree.depth + 1 AS depth FROM emp JOIN tree ON emp.parent_id = tree.id
+			)
+			SELECT name, depth FROM tree ORDER BY depth, name`))
+
+		// a recursive statement that doesn't read itself is a regular union
+		require.JSONEq(t, `[{"id": 1}, {"id": 2}]`, queryJSON(t, db, "WITH RECURSIVE x AS (SELECT id FROM emp WHERE id = 1 UNION SELECT id FROM emp WHERE id <= 2) SELECT id FROM x ORDER BY id"))
+
+		// common table expressions can read the previous ones
+		require.JSONEq(t, `[{"n": 2}]`, queryJSON(t, db, "WITH a AS (SELECT id FROM emp WHERE parent_id = 1), b AS (SELECT COUNT(*) AS n FROM a) SELECT n FROM b"))
+
+		// the fields are renamed after the list of columns
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"WITH c(x, y) AS (SELECT id, name FROM emp WHERE id < 3) SELECT * FROM c", `[{"x": 1, "y": "root"}, {"x": 2, "y": "a"}]`},
+			{"WITH RECURSIVE r(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM r WHERE n < 5) SELECT n FROM r", `[{"n": 1}, {"n": 2}, {"n": 3}, {"n": 4}, {"n": 5}]`},
+			{"WITH RECURSIVE r(n, d) AS (SELECT id, 0 FROM emp WHERE id = 2 UNION ALL SELECT emp.id, r.d + 1 FROM emp JOIN r ON emp.parent_id = r.n) SELECT * FROM r", `[{"n": 2, "d": 0}, {"n": 4, "d": 1}, {"n": 5, "d": 2}]`},
+			// documents are compared after being renamed
+			{"WITH RECURSIVE r(n) AS (SELECT 1 UNION SELECT n % 3 + 1 FROM r) SELECT n FROM r", `[{"n": 1}, {"n": 2}, {"n": 3}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// wildcards must select as many fields as there are columns
+		st, err := db.Query("WITH c(x) AS (SELECT * FROM emp) SELECT * FROM c")
+		if err == nil {
+			err = st.Iterate(func(d document.Document) error { return nil })
+			st.Close()
+		}
+		require.Error(t, err)
+
+		// UNION stops the recursion of cycles
+		err = db.Exec("UPDATE emp SET parent_id = 5 WHERE id = 1")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"n": 6}]`, queryJSON(t, db, `
+			WITH RECURSIVE sub AS (
+				SELECT id FROM emp WHERE id = 1
+				UNION
+				SELECT emp.id FROM emp JOIN sub ON emp.parent_id = sub.id
+			)
+			SELECT COUNT(*) AS n FROM sub`))
+
+		// UNION ALL doesn't, the number of iterations is limited
+		db.DB.MaxRecursion = 10
+		st, err = db.Query(`
+			WITH RECURSIVE sub AS (
+				SELECT id FROM emp WHERE id = 1
+				UNION ALL
+				SELECT emp.id FROM emp JOIN sub ON emp.parent_id = sub.id
+			)
+			SELECT COUNT(*) AS n FROM sub`)
+		if err == nil {
+			err = st.Iterate(func(d document.Document) error { return nil })
+			st.Close()
+		}
+		require.Error(t, err)
+
+		// the initial statement can't read the common table expression
+		_, err = db.Query("WITH RECURSIVE sub AS (SELECT id FROM sub UNION SELECT id FROM emp) SELECT * FROM sub")
+		require.Error(t, err)
+	})
+
+	t.Run("order by with limit", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(id INTEGER PRIMARY KEY, a INTEGER)")
+		require.NoError(t, err)
+
+		// many ties to ensure the top-k sort returns the same
+		// documents as a full sort
+		values := []int{7, 3, 9, 1, 3, 8, 0, 5, 2, 6, 4, 3, 1, 9, 3, 0}
+		for i, v := range values {
+			err = db.Exec("INSERT INTO test (id, a) VALUES (?, ?)", i, v)
+			require.NoError(t, err)
+		}
+
+		query := func(q string) []int {
+			st, err := db.Query(q)
+			require.NoError(t, err)
+			defer st.Close()
+
+			var res []int
+			err = st.Iterate(func(d document.Document) error {
+				var id int
+				err := document.Scan(d, &id)
+				res = append(res, id)
+				return err
+			})
+			require.NoError(t, err)
+			return res
+		}
+
+		for _, bufferSize := range []int{0, 4, 100} {
+			db.DB.SortBufferSize = bufferSize
+
+			for _, dir := range []string{"ASC", "DESC"} {
+				all := query("SELECT id FROM test ORDER BY a " + dir)
+				require.Len(t, all, len(values))
+
+				for _, limit := range []int{1, 3, 5, 16, 20} {
+					for _, offset := range []int{0, 2, 7} {
+						want := all[min(offset, len(all)):min(offset+limit, len(all))]
+						got := query(fmt.Sprintf("SELECT id FROM test ORDER BY a %s LIMIT %d OFFSET %d", dir, limit, offset))
+						require.Equal(t, want, got, "buffer: %d, %s LIMIT %d OFFSET %d", bufferSize, dir, limit, offset)
+					}
+				}
+			}
+		}
+
+		// ties are returned in the order of the table, or in reverse order when descending
+		require.Equal(t, []int{6, 15, 3}, query("SELECT id FROM test ORDER BY a LIMIT 3"))
+		require.Equal(t, []int{13, 2, 5}, query("SELECT id FROM test ORDER BY a DESC LIMIT 3"))
+	})
+
+	t.Run("keys only", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER);
+			CREATE INDEX idx_test_a ON test(a);
+			CREATE TABLE withpk(id TEXT PRIMARY KEY, a INTEGER);
+			CREATE INDEX idx_withpk_a ON withpk(a);
+			INSERT INTO test (a) VALUES (1), (2), (3), (2);
+			INSERT INTO withpk (id, a) VALUES ('x', 1), ('y', 2), ('z', 3), ('w', 2);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT pk() FROM test WHERE a = 2", `[{"pk()": 2}, {"pk()": 4}]`},
+			{"SELECT pk() FROM test WHERE a > 2", `[{"pk()": 3}]`},
+			{"SELECT pk() FROM withpk WHERE a = 2", `[{"pk()": "y"}, {"pk()": "w"}]`},
+			{"SELECT pk() FROM withpk WHERE a < 3 LIMIT 2", `[{"pk()": "x"}, {"pk()": "y"}]`},
+			{"SELECT pk() FROM withpk", `[{"pk()": "w"}, {"pk()": "x"}, {"pk()": "y"}, {"pk()": "z"}]`},
+			{"SELECT pk() FROM withpk WHERE id > 'x'", `[{"pk()": "y"}, {"pk()": "z"}]`},
+			{"SELECT pk() FROM test LIMIT 2", `[{"pk()": 1}, {"pk()": 2}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+
+	t.Run("NaN and signed zeros", func(t *testing.T) {
+		for _, def := range []string{"CREATE TABLE test(a DOUBLE)", "CREATE TABLE test(a DOUBLE); CREATE INDEX idx_a ON test(a)"} {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec(def)
+			require.NoError(t, err)
+
+			for i, v := range []float64{math.NaN(), math.Copysign(0, -1), 0, 1} {
+				err = db.Exec("INSERT INTO test (k, a) VALUES (?, ?)", i+1, v)
+				require.NoError(t, err)
+			}
+
+			count := func(q string, args ...interface{}) int {
+				st, err := db.Query(q, args...)
+				require.NoError(t, err)
+				defer st.Close()
+
+				n, err := st.Count()
+				require.NoError(t, err)
+				return n
+			}
+
+			tests := []struct {
+				query    string
+				expected string
+				params   []interface{}
+			}{
+				{"SELECT k FROM test WHERE a = ?", `[]`, []interface{}{math.NaN()}},
+				{"SELECT k FROM test WHERE a > ?", `[]`, []interface{}{math.NaN()}},
+				{"SELECT k FROM test WHERE a = 0.0", `[{"k": 2}, {"k": 3}]`, nil},
+				{"SELECT k FROM test WHERE a = ?", `[{"k": 2}, {"k": 3}]`, []interface{}{math.Copysign(0, -1)}},
+				{"SELECT k FROM test WHERE a > 0.0", `[{"k": 4}]`, nil},
+				{"SELECT k FROM test WHERE a >= 0.0", `[{"k": 2}, {"k": 3}, {"k": 4}]`, nil},
+				{"SELECT k FROM test WHERE a >= -5.0 ORDER BY a", `[{"k": 2}, {"k": 3}, {"k": 4}]`, nil},
+				{"SELECT k FROM test ORDER BY a", `[{"k": 2}, {"k": 3}, {"k": 4}, {"k": 1}]`, nil},
+			}
+
+			for _, test := range tests {
+				require.JSONEq(t, test.expected, queryJSON(t, db, test.query, test.params...), test.query)
+			}
+
+			require.Equal(t, 3, count("SELECT DISTINCT a FROM test"))
+		}
+	})
+
+	t.Run("limit and offset params", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test; INSERT INTO test (a) VALUES (1), (2), (3), (4), (5)")
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+			params   []interface{}
+		}{
+			{"SELECT a FROM test LIMIT ?", `[{"a": 1}, {"a": 2}]`, []interface{}{2}},
+			{"SELECT a FROM test OFFSET ?", `[{"a": 4}, {"a": 5}]`, []interface{}{3}},
+			{"SELECT a FROM test LIMIT ? OFFSET ?", `[{"a": 3}, {"a": 4}]`, []interface{}{2, 2}},
+			{"SELECT a FROM test ORDER BY a DESC LIMIT $l OFFSET $o", `[{"a": 5}, {"a": 4}]`, []interface{}{sql.Named("l", 2), sql.Named("o", 0)}},
+			{"SELECT a FROM test LIMIT ? + 1 OFFSET 2", `[{"a": 3}]`, []interface{}{0}},
+			{"SELECT a FROM test LIMIT ?", `[]`, []interface{}{0}},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query, test.params...), test.query)
+		}
+
+		// params are validated like literals
+		for _, test := range []struct {
+			param interface{}
+			lit   string
+		}{
+			{"a", "'a'"},
+			{-1, "-1"},
+		} {
+			_, literalErr := db.Query("SELECT a FROM test LIMIT " + test.lit)
+			require.Error(t, literalErr)
+			_, err = db.Query("SELECT a FROM test LIMIT ?", test.param)
+			require.Error(t, err)
+			require.Equal(t, literalErr.Error(), err.Error())
+		}
+
+		_, err = db.Query("SELECT a FROM test OFFSET ?", true)
+		require.EqualError(t, err, `offset expression must evaluate to a number, got "bool"`)
+	})
+	t.Run("field existence", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			INSERT INTO test (k, middle_name) VALUES (1, 'foo'), (2, NULL);
+			INSERT INTO test (k) VALUES (3);
+			INSERT INTO test (k, name) VALUES (4, {middle: 'bar'});
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT k FROM test WHERE has(middle_name)", `[{"k": 1}, {"k": 2}]`},
+			{"SELECT k FROM test WHERE NOT has(middle_name)", `[{"k": 3}, {"k": 4}]`},
+			{"SELECT k FROM test WHERE middle_name IS NULL", `[{"k": 2}, {"k": 3}, {"k": 4}]`},
+			{"SELECT k FROM test WHERE has(name.middle)", `[{"k": 4}]`},
+			{"SELECT k, has(middle_name) AS h FROM test WHERE k IN [1, 3]", `[{"k": 1, "h": true}, {"k": 3, "h": false}]`},
+			{"SELECT k FROM test WHERE middle_name IS NOT NULL", `[{"k": 1}]`},
+			{"SELECT k FROM test WHERE middle_name IS MISSING", `[{"k": 3}, {"k": 4}]`},
+			{"SELECT k FROM test WHERE middle_name IS NOT MISSING", `[{"k": 1}, {"k": 2}]`},
+			{"SELECT k FROM test WHERE middle_name IS NULL AND middle_name IS NOT MISSING", `[{"k": 2}]`},
+			{"SELECT k FROM test WHERE name.middle IS MISSING", `[{"k": 1}, {"k": 2}, {"k": 3}]`},
+			{"SELECT k, middle_name IS MISSING AS m FROM test WHERE k > 2", `[{"k": 3, "m": true}, {"k": 4, "m": true}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		require.JSONEq(t,
+			`[{"k": 1, "m": "foo"}, {"k": 2, "m": "none"}, {"k": 3, "m": "none"}, {"k": 4, "m": "bar"}]`,
+			queryJSON(t, db, "SELECT k, COALESCE(middle_name, name.middle, 'none') AS m FROM test"))
+		require.JSONEq(t, `[{"k": 1, "m": null}, {"k": 2, "m": null}, {"k": 3, "m": null}]`, queryJSON(t, db, "SELECT k, NULLIF(middle_name, 'foo') AS m FROM test WHERE k < 4"))
+	})
+	t.Run("wildcard paths", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test;
+			INSERT INTO test (k, items) VALUES (1, [{name: 'a', price: 10, tags: ['x']}, {name: 'b', price: 25, tags: ['y', 'z']}]);
+			INSERT INTO test (k, items) VALUES (2, [{name: 'c'}]), (3, 'foo');
+			INSERT INTO test (k) VALUES (4);
+		`)
+		require.NoError(t, err)
+
+		require.JSONEq(t,
+			`[{"k": 1, "items[*].price": [10, 25]}, {"k": 2, "items[*].price": []}, {"k": 3, "items[*].price": null}, {"k": 4, "items[*].price": null}]`,
+			queryJSON(t, db, "SELECT k, items[*].price FROM test"))
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT k, items[*].tags[*] AS tags FROM test WHERE k = 1", `[{"k": 1, "tags": ["x", "y", "z"]}]`},
+			{"SELECT k FROM test WHERE 25 IN items[*].price", `[{"k": 1}]`},
+			{"SELECT k FROM test WHERE array_length(items[*].price) = 0", `[{"k": 2}]`},
+			{"SELECT k FROM test WHERE array_contains(items[*].tags[*], 'z')", `[{"k": 1}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// wildcard paths are indexed like expressions
+		err = db.Exec("CREATE INDEX idx_names ON test(items[*].name)")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"k": 2}]`, queryJSON(t, db, "SELECT k FROM test WHERE 'c' IN items[*].name"))
+
+		// wildcards can't be used to modify documents
+		err = db.Exec("UPDATE test SET items[*].price = 1")
+		require.Error(t, err)
+	})
+
+	t.Run("reserved words and quoted identifiers", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE `select`(`order` INTEGER, `from` TEXT);" +
+			"CREATE INDEX `index` ON `select`(`order`);" +
+			"CREATE INDEX idx_where ON `select`(a.`where`);" +
+			"CREATE INDEX idx_dot ON `select`(`a.where`);" +
+			"INSERT INTO `select` (`order`, `from`, a) VALUES (1, 'x', {`where`: 10}), (2, 'y', {`where`: 20});" +
+			"INSERT INTO `select` VALUES {`order`: 3, `from`: 'z', `a.where`: 10}")
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT `order` FROM `select` WHERE `order` < 3 ORDER BY `order` DESC", `[{"order": 2}, {"order": 1}]`},
+			{"SELECT `from` FROM `select` WHERE `order` = 2", `[{"from": "y"}]`},
+			{"SELECT `from` AS `group` FROM `select` WHERE `from` = 'x'", `[{"group": "x"}]`},
+			{"SELECT a.`where` FROM `select` WHERE a.`where` > 10", `[{"a.where": 20}]`},
+			// a quoted field name containing a dot is not a nested path
+			{"SELECT `order` FROM `select` WHERE a.`where` = 10", `[{"order": 1}]`},
+			{"SELECT `order` FROM `select` WHERE `a.where` = 10", `[{"order": 3}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		d, err := db.QueryDocument("EXPLAIN SELECT `order` FROM `select` WHERE `a.where` = 10")
+		require.NoError(t, err)
+		v, err := d.GetByField("plan")
+		require.NoError(t, err)
+		require.Equal(t, "Index(idx_dot, fields: `order`) -> ∏(`order`)", v.V.(string))
+
+		err = db.Exec("UPDATE `select` SET `from` = 'w' WHERE `order` = 3; DELETE FROM `select` WHERE `order` = 1")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"order": 2, "from": "y"}, {"order": 3, "from": "w"}]`, queryJSON(t, db, "SELECT `order`, `from` FROM `select`"))
+
+		// keywords that only have a meaning in specific clauses don't need to be quoted
+		err = db.Exec("CREATE TABLE unnest(decimal INTEGER, foreign TEXT); INSERT INTO unnest (decimal, foreign, stored) VALUES (1, 'a', true)")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"decimal": 1, "foreign": "a", "stored": true}]`, queryJSON(t, db, "SELECT decimal, foreign, stored FROM unnest WHERE decimal = 1"))
+	})
+
+	t.Run("materialized results", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test; INSERT INTO test (a, b) VALUES (1, {c: [1, 2]}), (2, {c: [3]})")
+		require.NoError(t, err)
+
+		db.DB.MaterializeResults = true
+
+		st, err := db.Query("SELECT * FROM test")
+		require.NoError(t, err)
+		defer st.Close()
+		require.Nil(t, st.Tx)
+
+		// the result doesn't hold the transaction anymore,
+		// writing must not wait for it to be closed.
+		done := make(chan error, 1)
+		go func() {
+			done <- db.Exec("INSERT INTO test (a) VALUES (3)")
+		}()
+
+		select {
+		case err = <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("write blocked by an open result")
+		}
+
+		var buf bytes.Buffer
+		err = document.IteratorToJSONArray(&buf, st)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"a": 1, "b": {"c": [1, 2]}}, {"a": 2, "b": {"c": [3]}}]`, buf.String())
+		require.NoError(t, st.Close())
+
+		// statements that don't return documents are unaffected
+		st, err = db.Query("INSERT INTO test (a) VALUES (4)")
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+
+		n, err := db.QueryDocument("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+		var count int
+		err = document.Scan(n, &count)
+		require.NoError(t, err)
+		require.Equal(t, 4, count)
+	})
+
+	t.Run("integer overflow", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec("CREATE TABLE test(a INTEGER); INSERT INTO test (a) VALUES (9223372036854775807), (1)")
+		require.NoError(t, err)
+
+		// overflowing results are converted to doubles by default
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a + 1 FROM test", `[{"a + 1": 9223372036854775808.0}, {"a + 1": 2}]`},
+			{"SELECT SUM(a) FROM test", `[{"SUM(a)": 9223372036854775808.0}]`},
+			{"SELECT a FROM test WHERE a < 9223372036854775807 + 1 AND a < 2", `[{"a": 1}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		db.DB.StrictArithmetic = true
+		defer func() { db.DB.StrictArithmetic = false }()
+
+		for _, q := range []string{
+			"SELECT a + 1 FROM test",
+			"SELECT a * 2 FROM test WHERE a = 1 OR a > 2",
+			"SELECT a FROM test WHERE a < 9223372036854775807 + 1",
+			"UPDATE test SET a = a + 1",
+			"INSERT INTO test (a) VALUES (-9223372036854775807 - 2)",
+		} {
+			st, err := db.Query(q)
+			if err == nil {
+				// projected fields are only evaluated when they are read
+				err = document.IteratorToJSONArray(ioutil.Discard, st)
+				st.Close()
+			}
+			require.Equal(t, document.ErrIntegerOverflow, err, q)
+		}
+
+		require.JSONEq(t, `[{"a": 9223372036854775807}, {"a": 1}]`, queryJSON(t, db, "SELECT a FROM test"))
+		require.JSONEq(t, `[{"a - 1": 9223372036854775806}, {"a - 1": 0}]`, queryJSON(t, db, "SELECT a - 1 FROM test"))
+	})
+
+	t.Run("decimals", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(price DECIMAL, d DOUBLE);
+			CREATE INDEX idx_price ON test(price);
+			CREATE INDEX idx_d ON test(d);
+			INSERT INTO test (price, d) VALUES (1.99, 1), (0.1, 2), (0.2, 3), (10, 4);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT price FROM test ORDER BY price", `[{"price": 0.1}, {"price": 0.2}, {"price": 1.99}, {"price": 10}]`},
+			{"SELECT SUM(price) FROM test", `[{"SUM(price)": 12.29}]`},
+			{"SELECT price + 0.2 AS price FROM test WHERE price = 0.1", `[{"price": 0.3}]`},
+			{"SELECT price FROM test WHERE price = 1.99", `[{"price": 1.99}]`},
+			{"SELECT price FROM test WHERE price > 1", `[{"price": 1.99}, {"price": 10}]`},
+			{"SELECT price FROM test WHERE price >= 10", `[{"price": 10}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		err = db.Exec("UPDATE test SET price = price * 3 WHERE price = 1.99")
+		require.NoError(t, err)
+
+		tests = []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT price FROM test WHERE price > 1 AND price < 10", `[{"price": 5.97}]`},
+			// integer filters on indexed doubles
+			{"SELECT d FROM test WHERE d = 2", `[{"d": 2}]`},
+			{"SELECT d FROM test WHERE d > 2", `[{"d": 3}, {"d": 4}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+
+	t.Run("timestamps", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(ts TIMESTAMP, n INTEGER);
+			CREATE INDEX idx_ts ON test(ts);
+			INSERT INTO test (ts, n) VALUES
+				('2021-01-02T15:04:05Z', 1),
+				('2021-01-01T23:30:00+02:00', 2),
+				('2021-03-15T08:00:00.5Z', 3);
+		`)
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (ts, n) VALUES ('2021-01-02', 4)")
+		require.Error(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT ts FROM test ORDER BY ts", `[{"ts": "2021-01-01T21:30:00Z"}, {"ts": "2021-01-02T15:04:05Z"}, {"ts": "2021-03-15T08:00:00.5Z"}]`},
+			{"SELECT n FROM test WHERE ts = '2021-01-02T16:04:05+01:00'", `[{"n": 1}]`},
+			{"SELECT n FROM test WHERE ts > '2021-01-02T00:00:00Z'", `[{"n": 1}, {"n": 3}]`},
+			{"SELECT n FROM test WHERE ts BETWEEN '2021-01-01T00:00:00Z' AND '2021-01-02T00:00:00Z'", `[{"n": 2}]`},
+			{"SELECT n FROM test WHERE ts = CAST('2021-03-15T08:00:00.5Z' AS TIMESTAMP)", `[{"n": 3}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		require.Equal(t, `[{"ts": "2021-01-02T15:05:05Z", "d": 6195355.5}]`, queryJSON(t, db, `
+			SELECT ts + 60 AS ts, CAST('2021-03-15T08:00:00.5Z' AS TIMESTAMP) - ts AS d
+			FROM test WHERE n = 1
+		`))
+		require.Equal(t, `[{"d": "2021-01-01T00:00:00Z"}, {"d": "2021-01-01T00:00:00Z"}, {"d": "2021-03-01T00:00:00Z"}]`, queryJSON(t, db, "SELECT date_trunc('month', ts) AS d FROM test ORDER BY ts"))
+		require.Equal(t, `[{"MIN(ts)": "2021-01-01T21:30:00Z", "MAX(ts)": "2021-03-15T08:00:00.5Z"}]`, queryJSON(t, db, "SELECT MIN(ts), MAX(ts) FROM test"))
+
+		err = db.Exec("UPDATE test SET ts = ts - 86400 WHERE n = 2")
+		require.NoError(t, err)
+		require.Equal(t, `[{"n": 2}]`, queryJSON(t, db, "SELECT n FROM test WHERE ts < '2021-01-01T00:00:00Z'"))
+
+		// TIMESTAMP can still be used as a field name
+		err = db.Exec(`
+			CREATE TABLE events(timestamp TIMESTAMP);
+			INSERT INTO events (timestamp, n) VALUES ('2021-01-02T15:04:05Z', 1);
+			UPDATE events SET timestamp = timestamp + 1;
+		`)
+		require.NoError(t, err)
+		require.Equal(t, `[{"timestamp": "2021-01-02T15:04:06Z"}]`, queryJSON(t, db, "SELECT timestamp FROM events WHERE timestamp > '2021-01-01T00:00:00Z'"))
+	})
+
+	t.Run("row values", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(city TEXT, status TEXT, a INTEGER, b INTEGER);
+			INSERT INTO test (city, status, a, b) VALUES
+				("NY", "open", 1, 1), ("NY", "closed", 1, 2), ("LA", "closed", 2, 1), ("SF", "open", 2, NULL);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{`SELECT city FROM test WHERE (city, status) IN (("NY", "open"), ("LA", "closed"))`, `[{"city": "NY"}, {"city": "LA"}]`},
+			{`SELECT city FROM test WHERE (city, status) NOT IN (("NY", "closed"), ("LA", "closed"))`, `[{"city": "NY"}, {"city": "SF"}]`},
+			{"SELECT a, b FROM test WHERE (a, b) > (1, 2)", `[{"a": 2, "b": 1}, {"a": 2, "b": null}]`},
+			{"SELECT a, b FROM test WHERE (a, b) >= (1, 2) AND (a, b) < (2, 0)", `[{"a": 1, "b": 2}]`},
+			{"SELECT a, b FROM test WHERE (a, b) = (1, 1)", `[{"a": 1, "b": 1}]`},
+			{"SELECT a, b FROM test WHERE (a, b) = (2, NULL)", `[]`},
+			{"SELECT a FROM test WHERE (1, NULL) < (2, 0)", `[{"a": 1}, {"a": 1}, {"a": 2}, {"a": 2}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+
+	t.Run("inner joins", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE users(id INTEGER PRIMARY KEY);
+			CREATE TABLE orders(id INTEGER PRIMARY KEY);
+			CREATE TABLE items;
+			CREATE INDEX idx_items_order_id ON items(order_id);
+			INSERT INTO users (id, name) VALUES (1, "a"), (2, "b"), (3, "c");
+			INSERT INTO orders (id, user_id, total) VALUES (10, 2, 5), (11, 1.0, 6), (12, 2, 7), (13, 4, 1), (14, NULL, 3);
+			INSERT INTO orders (id, total) VALUES (15, 2);
+			INSERT INTO items (order_id, name) VALUES (12, "x"), (10, "y"), (12, "z");
+		`)
+		require.NoError(t, err)
+
+		// without index
+		require.Equal(t,
+			`[{"users.id": 1, "users.name": "a", "orders.id": 11, "orders.user_id": 1, "orders.total": 6}, {"users.id": 2, "users.name": "b", "orders.id": 10, "orders.user_id": 2, "orders.total": 5}, {"users.id": 2, "users.name": "b", "orders.id": 12, "orders.user_id": 2, "orders.total": 7}]`,
+			queryJSON(t, db, "SELECT * FROM users JOIN orders ON users.id = orders.user_id"))
+		require.Equal(t,
+			`[{"name": "b", "orders.id": 12}, {"name": "a", "orders.id": 11}, {"name": "b", "orders.id": 10}]`,
+			queryJSON(t, db, "SELECT name, orders.id FROM orders INNER JOIN users ON orders.user_id = users.id ORDER BY total DESC"))
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT DISTINCT users.name FROM users JOIN orders ON users.id = orders.user_id WHERE orders.total > 4", `[{"users.name": "a"}, {"users.name": "b"}]`},
+			{"SELECT COUNT(*) FROM users JOIN orders ON users.name = orders.total", `[{"COUNT(*)": 0}]`},
+			// with index
+			{"SELECT orders.id, items.name FROM orders JOIN items ON orders.id = items.order_id", `[{"orders.id": 10, "items.name": "y"}, {"orders.id": 12, "items.name": "x"}, {"orders.id": 12, "items.name": "z"}]`},
+			// the index of the left table is used, the right table is read in order
+			{"SELECT orders.id, items.name FROM items JOIN orders ON orders.id = items.order_id", `[{"orders.id": 10, "items.name": "y"}, {"orders.id": 12, "items.name": "x"}, {"orders.id": 12, "items.name": "z"}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		_, err = db.Query("SELECT * FROM users JOIN unknown ON users.id = unknown.id")
+		require.Error(t, err)
+	})
+
+	t.Run("outer joins", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE users(id INTEGER PRIMARY KEY, name TEXT);
+			CREATE TABLE orders;
+			CREATE INDEX idx_orders_user_id ON orders(user_id);
+			INSERT INTO users (id, name) VALUES (1, "a"), (2, "b"), (3, "c");
+			INSERT INTO orders (id, user_id) VALUES (10, 2), (11, 1), (12, 2), (13, 4), (14, NULL);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT * FROM users LEFT JOIN orders ON users.id = orders.user_id", `[{"users.id": 1, "users.name": "a", "orders.id": 11, "orders.user_id": 1}, {"users.id": 2, "users.name": "b", "orders.id": 10, "orders.user_id": 2}, {"users.id": 2, "users.name": "b", "orders.id": 12, "orders.user_id": 2}, {"users.id": 3, "users.name": "c", "orders.user_id": null}]`},
+			{"SELECT orders.id, users.name FROM users RIGHT OUTER JOIN orders ON users.id = orders.user_id", `[{"orders.id": 10, "users.name": "b"}, {"orders.id": 11, "users.name": "a"}, {"orders.id": 12, "users.name": "b"}, {"orders.id": 13, "users.name": null}, {"orders.id": 14, "users.name": null}]`},
+			{"SELECT * FROM users RIGHT JOIN orders ON users.id = orders.user_id WHERE users.id IS NULL", `[{"users.id": null, "users.name": null, "orders.id": 13, "orders.user_id": 4}, {"users.id": null, "users.name": null, "orders.id": 14, "orders.user_id": null}]`},
+			{"SELECT name FROM users LEFT OUTER JOIN orders ON orders.user_id = users.id WHERE orders.id IS NULL", `[{"name": "c"}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// LEFT, RIGHT and OUTER can still be used as field names
+		err = db.Exec("CREATE TABLE sides(left INTEGER); INSERT INTO sides (left, right) VALUES (1, 2)")
+		require.NoError(t, err)
+		require.Equal(t, `[{"left": 1, "right": 2, "outer": 3}]`, queryJSON(t, db, "SELECT left, right, left + right AS outer FROM sides WHERE left = 1"))
+	})
+
+	t.Run("set operators", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE foo(a INTEGER);
+			CREATE TABLE bar(b INTEGER);
+			INSERT INTO foo (a) VALUES (1), (2), (2), (2), (3), (4);
+			INSERT INTO bar (b) VALUES (2), (3), (3), (5);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a FROM foo EXCEPT SELECT b FROM bar", `[{"a": 1}, {"a": 4}]`},
+			{"SELECT a FROM foo EXCEPT ALL SELECT b FROM bar", `[{"a": 1}, {"a": 2}, {"a": 2}, {"a": 4}]`},
+			{"SELECT a FROM foo INTERSECT SELECT b FROM bar", `[{"a": 2}, {"a": 3}]`},
+			{"SELECT a FROM foo INTERSECT ALL SELECT b FROM bar", `[{"a": 2}, {"a": 3}]`},
+			{"SELECT a FROM foo INTERSECT ALL SELECT a FROM foo WHERE a = 2", `[{"a": 2}, {"a": 2}, {"a": 2}]`},
+			{"SELECT a FROM foo EXCEPT SELECT b FROM bar WHERE b > 2 INTERSECT SELECT b FROM bar", `[{"a": 2}]`},
+			{"SELECT a FROM foo ORDER BY a DESC EXCEPT SELECT b FROM bar", `[{"a": 4}, {"a": 1}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// numbers are compared regardless of their type
+		err = db.Exec("CREATE TABLE baz; INSERT INTO baz (c) VALUES (2.0), (4.0), (4.5), ([2.0]);")
+		require.NoError(t, err)
+
+		tests = []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a FROM foo INTERSECT SELECT c FROM baz", `[{"a": 2}, {"a": 4}]`},
+			{"SELECT a FROM foo EXCEPT SELECT c FROM baz", `[{"a": 1}, {"a": 3}]`},
+			{"SELECT a FROM foo INTERSECT SELECT CAST(c AS DECIMAL) FROM baz WHERE c < 5", `[{"a": 2}, {"a": 4}]`},
+			{"SELECT [a] AS x FROM foo WHERE a = 2 INTERSECT SELECT c FROM baz", `[{"x": [2]}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		_, err = db.Query("SELECT a FROM foo EXCEPT SELECT a, b FROM bar")
+		require.Error(t, err)
+	})
+
+	t.Run("union", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE foo(a INTEGER, b INTEGER);
+			CREATE TABLE bar(a INTEGER, b INTEGER);
+			INSERT INTO foo (a, b) VALUES (1, 10), (2, 20), (2, 20);
+			INSERT INTO bar (a, b) VALUES (2, 20), (3, 30);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a FROM foo UNION SELECT a FROM bar", `[{"a": 1}, {"a": 2}, {"a": 3}]`},
+			{"SELECT a FROM foo UNION ALL SELECT a FROM bar", `[{"a": 1}, {"a": 2}, {"a": 2}, {"a": 2}, {"a": 3}]`},
+			{"SELECT a FROM foo UNION SELECT a FROM bar UNION SELECT b FROM bar UNION ALL SELECT b FROM foo WHERE a = 1", `[{"a": 1}, {"a": 2}, {"a": 3}, {"a": 20}, {"a": 30}, {"a": 10}]`},
+			// fields are aligned by name
+			{"SELECT a, b FROM foo UNION SELECT b, a FROM bar", `[{"a": 1, "b": 10}, {"a": 2, "b": 20}, {"a": 3, "b": 30}]`},
+			{"SELECT a AS x, b FROM foo WHERE a = 1 UNION SELECT b, a FROM bar WHERE a = 3", `[{"x": 1, "b": 10}, {"x": 3, "b": 30}]`},
+			{"SELECT a, b FROM foo WHERE a = 1 UNION SELECT a, a + 1 AS b FROM bar WHERE a = 3", `[{"a": 1, "b": 10}, {"a": 3, "b": 4}]`},
+			{"SELECT a FROM foo WHERE a > 5 UNION SELECT a FROM bar WHERE a = 3", `[{"a": 3}]`},
+			{"SELECT a FROM bar ORDER BY a DESC UNION SELECT a FROM foo WHERE a = 2", `[{"a": 3}, {"a": 2}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// numbers are compared regardless of their type
+		err = db.Exec("CREATE TABLE baz; INSERT INTO baz (a) VALUES (1.0), (3.0), (3.5);")
+		require.NoError(t, err)
+		require.Equal(t, `[{"a": 1}, {"a": 2}, {"a": 3}, {"a": 3.5}]`, queryJSON(t, db, "SELECT a FROM foo UNION SELECT a FROM baz UNION SELECT a FROM bar"))
+		require.Equal(t, `[{"a": 1}, {"a": 2}, {"a": 2}, {"a": 1}]`, queryJSON(t, db, "SELECT a FROM foo UNION ALL SELECT a FROM baz WHERE a = 1"))
+	})
+
+	t.Run("case", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER, b TEXT);
+			INSERT INTO test (a, b) VALUES (1, 'x'), (2, 'y'), (3, 'z');
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a, CASE WHEN a < 2 THEN 'small' WHEN a < 3 THEN 'medium' ELSE 'large' END AS size FROM test", `[{"a": 1, "size": "small"}, {"a": 2, "size": "medium"}, {"a": 3, "size": "large"}]`},
+			{"SELECT CASE b WHEN 'y' THEN a END FROM test", `[{"CASE b WHEN 'y' THEN a END": null}, {"CASE b WHEN 'y' THEN a END": 2}, {"CASE b WHEN 'y' THEN a END": null}]`},
+			{"SELECT a FROM test WHERE CASE b WHEN 'y' THEN false ELSE true END", `[{"a": 1}, {"a": 3}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+
+	t.Run("between with typed index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER, b DOUBLE);
+			CREATE INDEX idx_a ON test (a);
+			CREATE INDEX idx_b ON test (b);
+			INSERT INTO test (a, b) VALUES (1, 1.5), (2, 2.5), (3, 3.5), (4, 4.5);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+			params   []interface{}
+		}{
+			{"SELECT a FROM test WHERE a BETWEEN 2 AND 3", `[{"a": 2}, {"a": 3}]`, nil},
+			{"SELECT a FROM test WHERE a BETWEEN 2.0 AND ?", `[{"a": 2}, {"a": 3}]`, []interface{}{3}},
+			{"SELECT a FROM test WHERE a BETWEEN 1.5 AND 3.9", `[{"a": 2}, {"a": 3}]`, nil},
+			{"SELECT b FROM test WHERE b BETWEEN 2 AND 4", `[{"b": 2.5}, {"b": 3.5}]`, nil},
+			{"SELECT a FROM test WHERE a NOT BETWEEN 2 AND 3", `[{"a": 1}, {"a": 4}]`, nil},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query, test.params...), test.query)
+		}
+	})
+
+	t.Run("like with index", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(k INTEGER PRIMARY KEY, name TEXT);
+			CREATE INDEX idx_name ON test (name);
+			INSERT INTO test (k, name) VALUES (1, 'apple'), (2, 'Apricot'), (3, 'APPLE pie'), (4, 'banana'), (5, 'ap'), (6, 'été');
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT k FROM test WHERE name LIKE 'app%'", `[{"k": 3}, {"k": 1}]`},
+			{"SELECT k FROM test WHERE name LIKE 'Ap%'", `[{"k": 3}, {"k": 2}, {"k": 5}, {"k": 1}]`},
+			{"SELECT k FROM test WHERE name LIKE 'ap_i%'", `[{"k": 2}]`},
+			{"SELECT k FROM test WHERE name LIKE 'ÉT%'", `[{"k": 6}]`},
+			{"SELECT k FROM test WHERE name LIKE 'c%'", `[]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+
+	t.Run("sorted groups", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(k INTEGER PRIMARY KEY, a INTEGER);
+			CREATE INDEX idx_a ON test(a);
+			INSERT INTO test (k, a, b) VALUES (1, 3, 10), (2, 1, 20), (3, 3, 30), (4, 2, 40), (5, 1, 50);
+		`)
+		require.NoError(t, err)
+
+		// groups are read from the index in order
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a, COUNT(*), SUM(b) FROM test WHERE a > 0 GROUP BY a", `[{"a": 1, "COUNT(*)": 2, "SUM(b)": 70}, {"a": 2, "COUNT(*)": 1, "SUM(b)": 40}, {"a": 3, "COUNT(*)": 2, "SUM(b)": 40}]`},
+			{"SELECT a, MIN(b) FROM test WHERE a >= 2 GROUP BY a", `[{"a": 2, "MIN(b)": 40}, {"a": 3, "MIN(b)": 10}]`},
+			{"SELECT k, MAX(b) FROM test GROUP BY k", `[{"k": 1, "MAX(b)": 10}, {"k": 2, "MAX(b)": 20}, {"k": 3, "MAX(b)": 30}, {"k": 4, "MAX(b)": 40}, {"k": 5, "MAX(b)": 50}]`},
+			{"SELECT a, COUNT(*) FROM test WHERE a > 10 GROUP BY a", `[{"a": null, "COUNT(*)": 0}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+
+	t.Run("having", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE test(a INTEGER);
+			CREATE INDEX idx_a ON test(a);
+			INSERT INTO test (a, b) VALUES (1, 10), (2, 20), (2, 30), (3, 40), (3, 50), (3, 60);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a, COUNT(*) FROM test GROUP BY a HAVING COUNT(*) > 1", `[{"a": 2, "COUNT(*)": 2}, {"a": 3, "COUNT(*)": 3}]`},
+			{"SELECT a FROM test GROUP BY a HAVING SUM(b) > 100", `[{"a": 3}]`},
+			{"SELECT a FROM test GROUP BY a HAVING a > 1 AND MAX(b) < 40", `[{"a": 2}]`},
+			{"SELECT COUNT(*) AS c FROM test WHERE a > 0 GROUP BY a HAVING COUNT(*) >= 3", `[{"c": 3}]`},
+			{"SELECT COUNT(*) FROM test HAVING MIN(a) = 1", `[{"COUNT(*)": 6}]`},
+			{"SELECT COUNT(*) FROM test HAVING MIN(a) > 1", `[]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		_, err = db.Query("SELECT a FROM test GROUP BY a HAVING b > 10")
+		require.Error(t, err)
+	})
+
+	t.Run("array_agg and group_concat", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE users(id INTEGER PRIMARY KEY);
+			CREATE TABLE orders(id INTEGER PRIMARY KEY);
+			INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+			INSERT INTO orders (id, user_id, total, tags) VALUES (1, 1, 10, ['x']), (2, 1, 20, ['y', 'z']), (3, 3, 5, []);
+			INSERT INTO orders (id, user_id) VALUES (4, 3);
+		`)
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT user_id, ARRAY_AGG(total) FROM orders GROUP BY user_id", `[{"user_id": 1, "ARRAY_AGG(total)": [10, 20]}, {"user_id": 3, "ARRAY_AGG(total)": [5]}]`},
+			{"SELECT user_id, GROUP_CONCAT(id) FROM orders GROUP BY user_id", `[{"user_id": 1, "GROUP_CONCAT(id)": "1,2"}, {"user_id": 3, "GROUP_CONCAT(id)": "3,4"}]`},
+			{"SELECT GROUP_CONCAT(total, ' + ') FROM orders", `[{"GROUP_CONCAT(total, ' + ')": "10 + 20 + 5"}]`},
+			{"SELECT user_id, GROUP_CONCAT(tags, '; ') FROM orders WHERE user_id = 1 GROUP BY user_id", `[{"user_id": 1, "GROUP_CONCAT(tags, '; ')": "[\"x\"]; [\"y\", \"z\"]"}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		tests = []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT ARRAY_AGG(total), GROUP_CONCAT(total) FROM orders WHERE id > 10", `[{"ARRAY_AGG(total)": null, "GROUP_CONCAT(total)": null}]`},
+			{"SELECT COUNT(*) AS n FROM orders HAVING GROUP_CONCAT(id) = '1,2,3,4'", `[{"n": 4}]`},
+			// the grouped documents can be embedded
+			{"SELECT user_id, ARRAY_AGG({id: id, tags: tags}) AS orders FROM orders GROUP BY user_id", `[{"user_id": 1, "orders": [{"id": 1, "tags": ["x"]}, {"id": 2, "tags": ["y", "z"]}]}, {"user_id": 3, "orders": [{"id": 3, "tags": []}, {"id": 4, "tags": null}]}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// the separator must be a text
+		_, err = db.QueryDocument("SELECT GROUP_CONCAT(id, 1) FROM orders")
+		require.Error(t, err)
+	})
+
+	t.Run("unnest", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE orders(id INTEGER PRIMARY KEY);
+			CREATE INDEX idx_orders_user_id ON orders(user_id);
+			CREATE TABLE products(name TEXT PRIMARY KEY);
+			INSERT INTO orders (id, user_id, items) VALUES
+				(1, 1, [{name: 'a', qty: 2}, {name: 'b', qty: 1}]),
+				(2, 2, [{name: 'a', qty: 5}]),
+				(3, 1, []),
+				(4, 2, 'a');
+			INSERT INTO orders (id, user_id) VALUES (5, 1);
+			INSERT INTO products (name, price) VALUES ('a', 10), ('b', 3);
+		`)
+		require.NoError(t, err)
+
+		// empty arrays and other values are skipped
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT id, items FROM UNNEST(orders.items)", `[{"id": 1, "items": {"name": "a", "qty": 2}}, {"id": 1, "items": {"name": "b", "qty": 1}}, {"id": 2, "items": {"name": "a", "qty": 5}}]`},
+			{"SELECT id FROM UNNEST(orders.items) WHERE items.qty > 1", `[{"id": 1}, {"id": 2}]`},
+			{"SELECT id, items.name FROM UNNEST(orders.items) WHERE user_id = 1 AND items.qty = 1", `[{"id": 1, "items.name": "b"}]`},
+			{"SELECT user_id, SUM(items.qty) AS qty FROM UNNEST(orders.items) GROUP BY user_id", `[{"user_id": 1, "qty": 3}, {"user_id": 2, "qty": 5}]`},
+			// unnested documents share the key of their original document
+			{"SELECT DISTINCT id FROM UNNEST(orders.items)", `[{"id": 1}, {"id": 2}]`},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		// unnested documents can be joined using a common table expression
+		require.Equal(t, `[{"lines.id": 1, "products.price": 10}, {"lines.id": 1, "products.price": 3}, {"lines.id": 2, "products.price": 10}]`,
+			queryJSON(t, db, `
+				WITH lines AS (SELECT id, items.name AS name FROM UNNEST(orders.items))
+				SELECT lines.id, products.price FROM lines JOIN products ON lines.name = products.name ORDER BY lines.id`))
+
+		// the indexes of the table don't apply to unnested documents
+		d, err := db.QueryDocument("EXPLAIN SELECT id FROM UNNEST(orders.items) WHERE user_id = 1")
+		require.NoError(t, err)
+		v, err := d.GetByField("plan")
+		require.NoError(t, err)
+		require.Equal(t, "Table(orders) -> Unnest(items) -> σ(cond: user_id = 1) -> ∏(id)", v.V.(string))
+
+		_, err = db.QueryDocument("SELECT * FROM UNNEST(unknown.items)")
+		require.Error(t, err)
+	})
+
+	t.Run("subqueries", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE users(id INTEGER PRIMARY KEY);
+			CREATE TABLE orders(id INTEGER PRIMARY KEY);
+			CREATE INDEX idx_orders_user_id ON orders(user_id);
+			INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b'), (3, 'c'), (4, 'd');
+			INSERT INTO orders (id, user_id, total) VALUES (1, 1, 10), (2, 1, 20), (3, 3, 5), (4, 5, 1);
+		`)
+		require.NoError(t, err)
+
+		// uncorrelated
+		tests := []struct {
+			query    string
+			expected string
+			params   []interface{}
+		}{
+			{"SELECT id FROM users WHERE id IN (SELECT user_id FROM orders)", `[{"id": 1}, {"id": 3}]`, nil},
+			{"SELECT id FROM users WHERE id NOT IN (SELECT user_id FROM orders)", `[{"id": 2}, {"id": 4}]`, nil},
+			{"SELECT id FROM users WHERE id IN (SELECT user_id FROM orders WHERE total > ?)", `[{"id": 1}]`, []interface{}{15}},
+			{"SELECT id FROM users WHERE id = (SELECT user_id FROM orders WHERE total = 5)", `[{"id": 3}]`, nil},
+			{"SELECT id FROM users WHERE id = (SELECT user_id FROM orders WHERE total = 100)", `[]`, nil},
+			{"SELECT id FROM users WHERE EXISTS (SELECT * FROM orders)", `[{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}]`, nil},
+			{"SELECT id FROM users WHERE EXISTS (SELECT * FROM orders WHERE total > 100)", `[]`, nil},
+			{"SELECT id FROM users WHERE id IN (SELECT user_id FROM orders EXCEPT SELECT user_id FROM orders WHERE total < 10)", `[{"id": 1}]`, nil},
+			// correlated
+			{"SELECT id FROM users WHERE EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id)", `[{"id": 1}, {"id": 3}]`, nil},
+			{"SELECT id FROM users WHERE NOT EXISTS (SELECT 1 FROM orders WHERE user_id = users.id)", `[{"id": 2}, {"id": 4}]`, nil},
+			{"SELECT id FROM users WHERE (SELECT COUNT(*) FROM orders WHERE user_id = users.id) > 1", `[{"id": 1}]`, nil},
+			{"SELECT id FROM users WHERE id IN (SELECT user_id FROM orders WHERE orders.id >= users.id)", `[{"id": 1}, {"id": 3}]`, nil},
+		}
+
+		for _, test := range tests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query, test.params...), test.query)
+		}
+
+		require.Equal(t, `[{"id": 3}]`, queryJSON(t, db, `
+			SELECT id FROM users WHERE EXISTS (
+				SELECT 1 FROM orders WHERE user_id = users.id AND EXISTS (
+					SELECT 1 FROM users WHERE orders.total < 10
+				)
+			)`))
+
+		// correlated subqueries in the result fields
+		fieldTests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT name, (SELECT COUNT(*) FROM orders WHERE orders.user_id = users.id) AS n FROM users", `[{"name": "a", "n": 2}, {"name": "b", "n": 0}, {"name": "c", "n": 1}, {"name": "d", "n": 0}]`},
+			{"SELECT id, (SELECT SUM(total) FROM orders WHERE user_id = users.id) AS s FROM users", `[{"id": 1, "s": 30}, {"id": 2, "s": null}, {"id": 3, "s": 5}, {"id": 4, "s": null}]`},
+			{"SELECT id, EXISTS (SELECT 1 FROM orders WHERE user_id = users.id) AS e FROM users", `[{"id": 1, "e": true}, {"id": 2, "e": false}, {"id": 3, "e": true}, {"id": 4, "e": false}]`},
+		}
+
+		for _, test := range fieldTests {
+			require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+
+		require.Equal(t, `[{"id": 1, "t": 20}]`,
+			queryJSON(t, db, "SELECT id, (SELECT MAX(total) FROM orders WHERE user_id = users.id AND total > ?) AS t FROM users WHERE id = ?", 15, 1))
+		require.Equal(t,
+			`[{"u": 1, "c": "a"}, {"u": 3, "c": "c"}]`,
+			queryJSON(t, db, "SELECT DISTINCT user_id AS u, (SELECT name FROM users WHERE users.id = orders.user_id) AS c FROM orders WHERE user_id < 5 ORDER BY u"))
+
+		// statements run uncorrelated subqueries again every time they are executed
+		q, err := parser.ParseQuery("SELECT id FROM users WHERE id IN (SELECT user_id FROM orders)")
+		require.NoError(t, err)
+		count := func() int {
+			res, err := q.Run(context.Background(), db.DB, nil)
+			require.NoError(t, err)
+			defer res.Close()
+			n, err := res.Count()
+			require.NoError(t, err)
+			return n
+		}
+		require.Equal(t, 2, count())
+		err = db.Exec("INSERT INTO orders (id, user_id, total) VALUES (5, 2, 1)")
+		require.NoError(t, err)
+		require.Equal(t, 3, count())
+
+		for _, q := range []string{
+			"SELECT id FROM users WHERE id = (SELECT user_id FROM orders)",
+			"SELECT id FROM users WHERE id = (SELECT user_id, total FROM orders WHERE id = 1)",
+		} {
+			st, err := db.Query(q)
+			require.NoError(t, err)
+			_, err = st.Count()
+			require.Error(t, err)
+			require.NoError(t, st.Close())
+		}
+	})
+
+	t.Run("projected fields", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		long := strings.Repeat("x", 40)
+		err = db.Exec(`
+			CREATE TABLE test(k INTEGER PRIMARY KEY);
+			CREATE INDEX idx_b ON test(b);
+			INSERT INTO test (k, a, b, c, d) VALUES (1, {"x": [1, 2]}, 10, "foo", true);
+			INSERT INTO test (k, b, c) VALUES (2, 20, "bar");
+		`)
+		require.NoError(t, err)
+		err = db.Exec("INSERT INTO test (k, b, `" + long + "`) VALUES (3, 30, 'long')")
+		require.NoError(t, err)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"SELECT a.x[1], pk() FROM test WHERE k = 1", `[{"a.x[1]": 2, "pk()": 1}]`},
+			{"SELECT c FROM test WHERE b > 10 ORDER BY k DESC", `[{"c": null}, {"c": "bar"}]`},
+			{"SELECT b AS k FROM test WHERE d = true", `[{"k": 10}]`},
+			{"SELECT DISTINCT c IS NULL AS missing FROM test ORDER BY missing", `[{"missing": false}, {"missing": true}]`},
+			{"SELECT `" + long + "` AS l FROM test WHERE b = 30", `[{"l": "long"}]`},
+			{"SELECT 1 FROM test LIMIT 1", `[{"1": 1}]`},
+			{"SELECT * FROM test WHERE k = 2", `[{"k": 2, "b": 20.0, "c": "bar"}]`},
+		}
+
+		for _, test := range tests {
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query), test.query)
+		}
+	})
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestDistinct(t *testing.T) {
+	types := []struct {
+		name          string
+		generateValue func(i, notUniqueCount int) (unique interface{}, notunique interface{})
+	}{
+		{`integer`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
+			return i, i % notUniqueCount
+		}},
+		{`double`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
+			return float64(i), float64(i % notUniqueCount)
+		}},
+		{`text`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
+			return strconv.Itoa(i), strconv.Itoa(i % notUniqueCount)
+		}},
+		{`array`, func(i, notUniqueCount int) (unique interface{}, notunique interface{}) {
+			return []interface{}{i}, []interface{}{i % notUniqueCount}
+		}},
+	}
+
+	for _, typ := range types {
+		total := 100
+		notUnique := total / 10
+
+		t.Run(typ.name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			tx, err := db.Begin(true)
+			require.NoError(t, err)
+			defer tx.Rollback()
+
+			err = tx.Exec("CREATE TABLE test(a " + typ.name + " PRIMARY KEY, b " + typ.name + ", doc DOCUMENT, nullable " + typ.name + ");")
+			require.NoError(t, err)
+
+			err = tx.Exec("CREATE UNIQUE INDEX test_doc_index ON test(doc);")
+			require.NoError(t, err)
+
+			for i := 0; i < total; i++ {
+				unique, nonunique := typ.generateValue(i, notUnique)
+				err = tx.Exec(`INSERT INTO test VALUES {a: ?, b: ?, doc: {a: ?, b: ?}, nullable: null}`, unique, nonunique, unique, nonunique)
+				require.NoError(t, err)
+			}
+			err = tx.Commit()
+			require.NoError(t, err)
+
+			tests := []struct {
+				name          string
+				query         string
+				expectedCount int
+			}{
+				{`unique`, `SELECT DISTINCT a FROM test`, total},
+				{`non-unique`, `SELECT DISTINCT b FROM test`, notUnique},
+				{`documents`, `SELECT DISTINCT doc FROM test`, total},
+				{`null`, `SELECT DISTINCT nullable FROM test`, 1},
+				{`wildcard`, `SELECT DISTINCT * FROM test`, total},
+				{`literal`, `SELECT DISTINCT 'a' FROM test`, 1},
+				{`pk()`, `SELECT DISTINCT pk() FROM test`, total},
+			}
+
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					q, err := db.Query(test.query)
+					require.NoError(t, err)
+					defer q.Close()
+
+					c, err := q.Count()
+					require.NoError(t, err)
+					require.Equal(t, test.expectedCount, c)
+				})
+			}
+		})
+	}
+}
+
+func TestSelectCompositeIndex(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER, b.c TEXT);
+		CREATE INDEX idx_a ON test(a);
+		CREATE INDEX idx_a_b ON test(a, b.c, d);
+		INSERT INTO test (a, b, d) VALUES
+			(1, {c: 'x'}, 1), (1, {c: 'y'}, 2), (1, {c: 'z'}, 3),
+			(2, {c: 'x'}, 4), (2, {c: 'y'}, 5);
+		INSERT INTO test (a) VALUES (1);
+	`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		args     []interface{}
+		plan     string
+		expected string
+	}{
+		{"equalities", "SELECT d FROM test WHERE b.c = 'y' AND a = 1", nil,
+			"Index(idx_a_b, fields: d) -> ∏(d)",
+			`[{"d": 2}]`},
+		{"all paths", "SELECT d FROM test WHERE a = 2 AND b.c = 'x' AND d = 4.0", nil,
+			"Index(idx_a_b, fields: d) -> ∏(d)",
+			`[{"d": 4}]`},
+		{"params", "SELECT d FROM test WHERE a = ? AND b.c = ?", []interface{}{2, "y"},
+			"Index(idx_a_b, fields: d) -> ∏(d)",
+			`[{"d": 5}]`},
+		{"greater than", "SELECT d FROM test WHERE a = 1 AND b.c > 'x'", nil,
+			"Index(idx_a_b, fields: d, b) -> σ(cond: b.c > \"x\") -> ∏(d)",
+			`[{"d": 2}, {"d": 3}]`},
+		{"lesser than", "SELECT d FROM test WHERE a = 1 AND b.c <= 'y'", nil,
+			"Index(idx_a_b, fields: d, b) -> σ(cond: b.c <= \"y\") -> ∏(d)",
+			`[{"d": 1}, {"d": 2}]`},
+		{"row equality", "SELECT d FROM test WHERE (a, b.c) = (1, 'y')", nil,
+			"Index(idx_a_b, fields: d) -> ∏(d)",
+			`[{"d": 2}]`},
+		{"row greater than", "SELECT d FROM test WHERE (a, b.c) > (1, 'y')", nil,
+			"Index(idx_a_b, fields: d, a, b) -> σ(cond: (a, b.c) > (1, \"y\")) -> ∏(d)",
+			`[{"d": 3}, {"d": 4}, {"d": 5}]`},
+		{"row lesser than", "SELECT d FROM test WHERE (a, b.c) <= (1, 'y')", nil,
+			"Index(idx_a_b, fields: d, a, b) -> σ(cond: (a, b.c) <= (1, \"y\")) -> ∏(d)",
+			`[{"d": 1}, {"d": 2}]`},
+		{"row after equality", "SELECT d FROM test WHERE a = 1 AND (b.c, d) >= ('y', 3)", nil,
+			"Index(idx_a_b, fields: d, b) -> σ(cond: (b.c, d) >= (\"y\", 3)) -> ∏(d)",
+			`[{"d": 3}]`},
+		{"row params", "SELECT d FROM test WHERE (a, b.c) < (?, ?)", []interface{}{2, "y"},
+			"Index(idx_a_b, fields: d, a, b) -> σ(cond: (a, b.c) < (?, ?)) -> ∏(d)",
+			`[{"d": null}, {"d": 1}, {"d": 2}, {"d": 3}, {"d": 4}]`},
+		{"row with null", "SELECT d FROM test WHERE (a, b.c) > (1, NULL)", nil,
+			"Index(idx_a_b, fields: d, a, b) -> σ(cond: (a, b.c) > (1, NULL)) -> ∏(d)",
+			`[{"d": 4}, {"d": 5}]`},
+		{"leading path only", "SELECT d FROM test WHERE a = 2", nil,
+			"Index(idx_a, fields: d) -> ∏(d)",
+			`[{"d": 4}, {"d": 5}]`},
+		{"non-leading path", "SELECT d FROM test WHERE b.c = 'x'", nil,
+			"Table(test, fields: d, b) -> σ(cond: b.c = \"x\") -> ∏(d)",
+			`[{"d": 1}, {"d": 4}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := db.QueryDocument("EXPLAIN "+test.query, test.args...)
+			require.NoError(t, err)
+			v, err := d.GetByField("plan")
+			require.NoError(t, err)
+			require.Equal(t, test.plan, v.V.(string))
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query, test.args...))
+		})
+	}
+
+	t.Run("unique", func(t *testing.T) {
+		err = db.Exec("CREATE UNIQUE INDEX idx_unique ON test(a, d)")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (a, d) VALUES (3, 1)")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (a, d) VALUES (3, 1.0)")
+		require.Error(t, err)
+
+		err = db.Exec("INSERT INTO test (a, d) VALUES (3, 2)")
+		require.NoError(t, err)
+	})
+}
+
+func TestSelectPartialIndex(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(id INTEGER PRIMARY KEY, email TEXT);
+		CREATE UNIQUE INDEX idx_email ON test(email) WHERE deleted_at IS NULL;
+		INSERT INTO test (id, email) VALUES (1, 'a@x'), (2, 'b@x');
+		INSERT INTO test (id, email, deleted_at) VALUES (3, 'a@x', 10);
+	`)
+	require.NoError(t, err)
+
+	plan := func(q string) string {
+		d, err := db.QueryDocument("EXPLAIN " + q)
+		require.NoError(t, err)
+
+		v, err := d.GetByField("plan")
+		require.NoError(t, err)
+		return v.V.(string)
+	}
+
+	// the index is only used if the predicate is one of the conditions
+	q := "SELECT id FROM test WHERE email = 'a@x' AND deleted_at IS NULL"
+	require.Equal(t, "Index(idx_email, fields: id, deleted_at) -> σ(cond: deleted_at IS NULL) -> ∏(id)", plan(q))
+	require.JSONEq(t, `[{"id": 1}]`, queryJSON(t, db, q))
+
+	q = "SELECT id FROM test WHERE email = 'a@x'"
+	require.Equal(t, "Table(test, fields: id, email) -> σ(cond: email = \"a@x\") -> ∏(id)", plan(q))
+	require.JSONEq(t, `[{"id": 1}, {"id": 3}]`, queryJSON(t, db, q))
+
+	// documents that don't match the predicate are not checked for uniqueness
+	err = db.Exec("INSERT INTO test (id, email, deleted_at) VALUES (4, 'b@x', 20)")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO test (id, email) VALUES (5, 'b@x')")
+	require.Error(t, err)
+
+	// documents are added to and removed from the index when they are updated
+	err = db.Exec("UPDATE test SET deleted_at = 30 WHERE id = 1")
+	require.NoError(t, err)
+	err = db.Exec("UPDATE test UNSET deleted_at WHERE id = 3")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id": 3}]`, queryJSON(t, db, "SELECT id FROM test WHERE email = 'a@x' AND deleted_at IS NULL"))
+
+	err = db.Exec("DELETE FROM test WHERE id = 3")
+	require.NoError(t, err)
+	require.JSONEq(t, `[]`, queryJSON(t, db, "SELECT id FROM test WHERE email = 'a@x' AND deleted_at IS NULL"))
+
+	err = db.Exec("REINDEX idx_email")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id": 2}]`, queryJSON(t, db, "SELECT id FROM test WHERE email = 'b@x' AND deleted_at IS NULL"))
+
+	// indexes with different predicates are different indexes
+	err = db.Exec("CREATE INDEX idx_email_deleted ON test(email) WHERE deleted_at IS NOT NULL")
+	require.NoError(t, err)
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_email ON test(email) WHERE deleted_at IS NOT NULL")
+	require.Error(t, err)
+
+	// predicates on fields named after keywords can be evaluated
+	err = db.Exec("CREATE INDEX idx_order ON test(email) WHERE `order` > 1")
+	require.NoError(t, err)
+	err = db.Exec("INSERT INTO test (id, email, `order`) VALUES (6, 'c@x', 2)")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"id": 6}]`, queryJSON(t, db, "SELECT id FROM test WHERE email = 'c@x' AND `order` > 1"))
+}
+
+func TestSelectExpressionIndex(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.RegisterFunc("fold", 1, func(args []document.Value) (document.Value, error) {
+		if args[0].Type != document.TextValue {
+			return document.NewNullValue(), nil
+		}
+		return document.NewTextValue(strings.ToLower(args[0].V.(string))), nil
+	})
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		CREATE TABLE test(id INTEGER PRIMARY KEY);
+		CREATE INDEX idx_sum ON test(a.x + a.y);
+		CREATE UNIQUE INDEX idx_email ON test((fold(email)));
+		INSERT INTO test (id, a, email) VALUES
+			(1, {x: 1, y: 2}, 'Foo@x'), (2, {x: 5, y: -3}, 'bar@x'), (3, {x: 10, y: 1}, 'baz@x');
+		INSERT INTO test (id) VALUES (4);
+	`)
+	require.NoError(t, err)
+
+	plan := func(q string) string {
+		d, err := db.QueryDocument("EXPLAIN " + q)
+		require.NoError(t, err)
+
+		v, err := d.GetByField("plan")
+		require.NoError(t, err)
+		return v.V.(string)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		plan     string
+		expected string
+	}{
+		{"equality", "SELECT id FROM test WHERE a.x + a.y = 3",
+			"Index(idx_sum, fields: id) -> ∏(id)",
+			`[{"id": 1}]`},
+		{"reversed operands", "SELECT id FROM test WHERE 2 = (a.x + a.y)",
+			"Index(idx_sum, fields: id) -> ∏(id)",
+			`[{"id": 2}]`},
+		{"range", "SELECT id FROM test WHERE a.x + a.y > 2",
+			"Index(idx_sum, fields: id) -> ∏(id)",
+			`[{"id": 1}, {"id": 3}]`},
+		{"function", "SELECT id FROM test WHERE fold(email) = 'foo@x'",
+			"Index(idx_email, fields: id) -> ∏(id)",
+			`[{"id": 1}]`},
+		{"order by", "SELECT id FROM test ORDER BY a.x + a.y DESC",
+			"Index(idx_sum DESC, fields: id) -> ∏(id)",
+			`[{"id": 3}, {"id": 1}, {"id": 2}, {"id": 4}]`},
+		{"order by with filter", "SELECT id FROM test WHERE id > 1 ORDER BY fold(email)",
+			"Index(idx_email, fields: id) -> σ(cond: id > 1) -> ∏(id)",
+			`[{"id": 4}, {"id": 2}, {"id": 3}]`},
+		{"order by aliased field", "SELECT id AS email FROM test ORDER BY fold(email)",
+			"Table(test) -> ∏(id) -> Sort(fold(email) ASC)",
+			`[{"email": 1}, {"email": 2}, {"email": 3}, {"email": 4}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.plan, plan(test.query))
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query))
+		})
+	}
+
+	t.Run("writes", func(t *testing.T) {
+		err = db.Exec("INSERT INTO test (id, email) VALUES (5, 'FOO@X')")
+		require.Error(t, err)
+
+		err = db.Exec("UPDATE test SET a.y = 20 WHERE id = 1")
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, queryJSON(t, db, "SELECT id FROM test WHERE a.x + a.y = 3"))
+		require.JSONEq(t, `[{"id": 1}]`, queryJSON(t, db, "SELECT id FROM test WHERE a.x + a.y = 21"))
+
+		err = db.Exec("DELETE FROM test WHERE fold(email) = 'foo@x'")
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, queryJSON(t, db, "SELECT id FROM test WHERE a.x + a.y = 21"))
+	})
+}
+
+func TestSelectFullTextIndex(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(id INTEGER PRIMARY KEY);
+		CREATE FULLTEXT INDEX idx_body ON test(body);
+		INSERT INTO test (id, body, title) VALUES
+			(1, 'Indexing documents with Genji', 'first'),
+			(2, 'Genji indexes documents and indexes fields', 'second'),
+			(3, 'A database of documents', 'third'),
+			(4, 42, 'fourth');
+		INSERT INTO test (id) VALUES (5);
+	`)
+	require.NoError(t, err)
+
+	plan := func(q string) string {
+		d, err := db.QueryDocument("EXPLAIN " + q)
+		require.NoError(t, err)
+
+		v, err := d.GetByField("plan")
+		require.NoError(t, err)
+		return v.V.(string)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		plan     string
+		expected string
+	}{
+		{"ranked", "SELECT id FROM test WHERE match(body, 'index')",
+			"Index(idx_body, fields: id) -> ∏(id)",
+			`[{"id": 2}, {"id": 1}]`},
+		{"all terms", "SELECT id FROM test WHERE match(body, 'Documents, genji!')",
+			"Index(idx_body, fields: id) -> ∏(id)",
+			`[{"id": 1}, {"id": 2}]`},
+		{"no match", "SELECT id FROM test WHERE match(body, 'database fields')",
+			"Index(idx_body, fields: id) -> ∏(id)",
+			`[]`},
+		{"not indexed", "SELECT id FROM test WHERE match(title, 'Firsts')",
+			`Table(test, fields: id, title) -> σ(cond: match(title, "Firsts")) -> ∏(id)`,
+			`[{"id": 1}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.plan, plan(test.query))
+			require.JSONEq(t, test.expected, queryJSON(t, db, test.query))
+		})
+	}
+
+	t.Run("params", func(t *testing.T) {
+		require.JSONEq(t, `[{"id": 3}]`, queryJSON(t, db, "SELECT id FROM test WHERE match(body, ?)", "databases"))
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		err = db.Exec("UPDATE test SET body = 'indexed database' WHERE id = 3")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 2}, {"id": 1}, {"id": 3}]`, queryJSON(t, db, "SELECT id FROM test WHERE match(body, 'index')"))
+		require.JSONEq(t, `[{"id": 1}, {"id": 2}]`, queryJSON(t, db, "SELECT id FROM test WHERE match(body, 'documents')"))
+
+		err = db.Exec("DELETE FROM test WHERE id = 2")
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"id": 1}, {"id": 3}]`, queryJSON(t, db, "SELECT id FROM test WHERE match(body, 'index')"))
+	})
+}
+
+// readAheadEngine records the ReadAhead option of the iterators
+// of the stores of tables and indexes.
+type readAheadEngine struct {
+	engine.Engine
+
+	sizes []int
+}
+
+func (ng *readAheadEngine) Begin(ctx context.Context, opts engine.TxOptions) (engine.Transaction, error) {
+	tx, err := ng.Engine.Begin(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readAheadTransaction{Transaction: tx, ng: ng}, nil
+}
+
+type readAheadTransaction struct {
+	engine.Transaction
+
+	ng *readAheadEngine
+}
+
+func (tx *readAheadTransaction) GetStore(name []byte) (engine.Store, error) {
+	st, err := tx.Transaction.GetStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readAheadStore{Store: st, name: string(name), ng: tx.ng}, nil
+}
+
+type readAheadStore struct {
+	engine.Store
+
+	name string
+	ng   *readAheadEngine
+}
+
+func (s *readAheadStore) Iterator(opts engine.IteratorOptions) engine.Iterator {
+	if !strings.HasPrefix(s.name, "__genji_") {
+		s.ng.sizes = append(s.ng.sizes, opts.ReadAhead)
+	}
+
+	return s.Store.Iterator(opts)
 }
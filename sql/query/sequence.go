@@ -0,0 +1,64 @@
+package query
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// CreateSequenceStmt is a DSL that allows creating a full CREATE SEQUENCE statement.
+type CreateSequenceStmt struct {
+	SequenceName string
+	IfNotExists  bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt CreateSequenceStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the Create sequence statement in the given transaction.
+// It implements the Statement interface.
+func (stmt CreateSequenceStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.SequenceName == "" {
+		return res, errors.New("missing sequence name")
+	}
+
+	err := tx.CreateSequence(stmt.SequenceName)
+	if stmt.IfNotExists && errors.Is(err, database.ErrSequenceAlreadyExists) {
+		err = nil
+	}
+
+	return res, err
+}
+
+// DropSequenceStmt is a DSL that allows creating a DROP SEQUENCE query.
+type DropSequenceStmt struct {
+	SequenceName string
+	IfExists     bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropSequenceStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropSequence statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropSequenceStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.SequenceName == "" {
+		return res, errors.New("missing sequence name")
+	}
+
+	err := tx.DropSequence(stmt.SequenceName)
+	if stmt.IfExists && errors.Is(err, database.ErrSequenceNotFound) {
+		err = nil
+	}
+
+	return res, err
+}
@@ -0,0 +1,71 @@
+package query_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequence(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE SEQUENCE seq;
+		CREATE SEQUENCE ids;
+		CREATE TABLE test (id INTEGER PRIMARY KEY DEFAULT nextval('ids'), n INTEGER);
+		INSERT INTO test (n) VALUES (nextval('seq')), (nextval('seq'));
+		INSERT INTO test (id, n) VALUES (10, nextval('seq'));
+		INSERT INTO test (n) VALUES (nextval('seq'));
+	`)
+	require.NoError(t, err)
+
+	// default values are only evaluated when the field is missing
+	require.Equal(t, `[{"id": 1, "n": 1}, {"id": 2, "n": 2}, {"id": 3, "n": 4}, {"id": 10, "n": 3}]`, queryJSON(t, db, "SELECT id, n FROM test"))
+
+	// sequences are shared by the statements and never return the same value twice
+	require.Equal(t, `[{"a": 5, "b": 6}]`, queryJSON(t, db, "SELECT nextval('seq') AS a, nextval('seq') AS b"))
+	err = db.Exec("BEGIN; SELECT nextval('seq'); ROLLBACK")
+	require.NoError(t, err)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT nextval('seq') AS v", `[{"v": 8}]`},
+		{"SELECT nextval(NULL) AS v", `[{"v": null}]`},
+		// sequences are independent
+		{"SELECT nextval('ids') AS v", `[{"v": 4}]`},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, queryJSON(t, db, test.query), test.query)
+	}
+
+	err = db.Exec("CREATE SEQUENCE seq")
+	require.True(t, errors.Is(err, database.ErrSequenceAlreadyExists))
+	err = db.Exec("CREATE SEQUENCE IF NOT EXISTS seq")
+	require.NoError(t, err)
+
+	err = db.Exec("DROP SEQUENCE seq")
+	require.NoError(t, err)
+	_, err = db.QueryDocument("SELECT nextval('seq')")
+	require.True(t, errors.Is(err, database.ErrSequenceNotFound))
+	err = db.Exec("DROP SEQUENCE seq")
+	require.True(t, errors.Is(err, database.ErrSequenceNotFound))
+	err = db.Exec("DROP SEQUENCE IF EXISTS seq")
+	require.NoError(t, err)
+
+	// a dropped sequence can be created again
+	err = db.Exec("CREATE SEQUENCE seq")
+	require.NoError(t, err)
+	_, err = db.QueryDocument("SELECT nextval('seq')")
+	require.NoError(t, err)
+
+	_, err = db.QueryDocument("SELECT nextval(1)")
+	require.Error(t, err)
+}
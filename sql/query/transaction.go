@@ -82,3 +82,46 @@ func (stmt CommitStmt) IsReadOnly() bool {
 func (stmt CommitStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
 	return Result{}, errors.New("cannot commit with no active transaction")
 }
+
+// SavepointStmt is a statement that creates a savepoint in the current transaction.
+// Outside of an explicit transaction, the statement runs in its own transaction
+// and the savepoint is released immediately.
+type SavepointStmt struct {
+	Name string
+}
+
+func (stmt SavepointStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt SavepointStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	return Result{}, tx.Savepoint(stmt.Name)
+}
+
+// RollbackToSavepointStmt is a statement that cancels the changes made
+// since a savepoint was created, without ending the transaction.
+type RollbackToSavepointStmt struct {
+	Name string
+}
+
+func (stmt RollbackToSavepointStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt RollbackToSavepointStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	return Result{}, tx.RollbackToSavepoint(stmt.Name)
+}
+
+// ReleaseSavepointStmt is a statement that removes a savepoint,
+// keeping the changes made since it was created.
+type ReleaseSavepointStmt struct {
+	Name string
+}
+
+func (stmt ReleaseSavepointStmt) IsReadOnly() bool {
+	return true
+}
+
+func (stmt ReleaseSavepointStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	return Result{}, tx.ReleaseSavepoint(stmt.Name)
+}
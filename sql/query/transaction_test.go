@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
 	"github.com/stretchr/testify/require"
 )
 
@@ -46,3 +47,130 @@ func TestTransactionRun(t *testing.T) {
 		})
 	}
 }
+
+func TestReadYourWrites(t *testing.T) {
+	count := func(t *testing.T, tx *genji.Tx, q string) int {
+		t.Helper()
+
+		d, err := tx.QueryDocument(q)
+		require.NoError(t, err)
+
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		return n
+	}
+
+	for _, withIndex := range []bool{false, true} {
+		name := "No Index"
+		if withIndex {
+			name = "With Index"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			db, err := genji.Open(":memory:")
+			require.NoError(t, err)
+			defer db.Close()
+
+			err = db.Exec("CREATE TABLE test")
+			require.NoError(t, err)
+			if withIndex {
+				err = db.Exec("CREATE INDEX idx_a ON test(a)")
+				require.NoError(t, err)
+			}
+
+			err = db.Update(func(tx *genji.Tx) error {
+				err := tx.Exec("INSERT INTO test (a) VALUES (1), (2)")
+				require.NoError(t, err)
+				require.Equal(t, 2, count(t, tx, "SELECT COUNT(*) FROM test"))
+				require.Equal(t, 1, count(t, tx, "SELECT COUNT(*) FROM test WHERE a = 2"))
+
+				err = tx.Exec("UPDATE test SET a = 3 WHERE a = 2")
+				require.NoError(t, err)
+				require.Equal(t, 0, count(t, tx, "SELECT COUNT(*) FROM test WHERE a = 2"))
+				require.Equal(t, 1, count(t, tx, "SELECT COUNT(*) FROM test WHERE a = 3"))
+
+				err = tx.Exec("DELETE FROM test WHERE a = 1")
+				require.NoError(t, err)
+				require.Equal(t, 1, count(t, tx, "SELECT COUNT(*) FROM test"))
+				require.Equal(t, 0, count(t, tx, "SELECT COUNT(*) FROM test WHERE a = 1"))
+				return nil
+			})
+			require.NoError(t, err)
+
+			// same thing with a transaction started with BEGIN
+			err = db.Exec("BEGIN; INSERT INTO test (a) VALUES (4)")
+			require.NoError(t, err)
+			d, err := db.QueryDocument("SELECT COUNT(*) FROM test WHERE a = 4")
+			require.NoError(t, err)
+			var n int
+			require.NoError(t, document.Scan(d, &n))
+			require.Equal(t, 1, n)
+			err = db.Exec("ROLLBACK")
+			require.NoError(t, err)
+
+			d, err = db.QueryDocument("SELECT COUNT(*) FROM test")
+			require.NoError(t, err)
+			require.NoError(t, document.Scan(d, &n))
+			require.Equal(t, 1, n)
+		})
+	}
+}
+
+func TestSavepoints(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	count := func(t *testing.T) int {
+		t.Helper()
+
+		d, err := db.QueryDocument("SELECT COUNT(*) FROM test")
+		require.NoError(t, err)
+
+		var n int
+		require.NoError(t, document.Scan(d, &n))
+		return n
+	}
+
+	err = db.Exec("CREATE TABLE test(a INTEGER UNIQUE)")
+	require.NoError(t, err)
+
+	// savepoints require a transaction
+	err = db.Exec("SAVEPOINT sp; ROLLBACK TO sp")
+	require.Error(t, err)
+
+	err = db.Exec("BEGIN; INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+
+	// failed statements are cancelled without aborting the transaction
+	for _, a := range []int{2, 1, 3} {
+		err = db.Exec("SAVEPOINT doc")
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO test (a) VALUES (?), (?)", a+10, a)
+		if err != nil {
+			err = db.Exec("ROLLBACK TO SAVEPOINT doc")
+			require.NoError(t, err)
+		}
+
+		err = db.Exec("RELEASE doc")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 5, count(t))
+
+	err = db.Exec("SAVEPOINT a; DELETE FROM test; SAVEPOINT b; INSERT INTO test (a) VALUES (1)")
+	require.NoError(t, err)
+	require.Equal(t, 1, count(t))
+	err = db.Exec("ROLLBACK TO b")
+	require.NoError(t, err)
+	require.Zero(t, count(t))
+	err = db.Exec("ROLLBACK TO a")
+	require.NoError(t, err)
+	require.Equal(t, 5, count(t))
+	err = db.Exec("ROLLBACK TO b")
+	require.Error(t, err)
+
+	err = db.Exec("COMMIT")
+	require.NoError(t, err)
+	require.Equal(t, 5, count(t))
+}
@@ -0,0 +1,85 @@
+package query
+
+import (
+	"errors"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// Names used by the statements of triggers to refer to the old and new versions
+// of the document that fired them.
+const (
+	OldTable = "old"
+	NewTable = "new"
+)
+
+// CreateTriggerStmt is a DSL that allows creating a full CREATE TRIGGER statement.
+type CreateTriggerStmt struct {
+	TriggerName string
+	TableName   string
+	IfNotExists bool
+	Timing      database.TriggerTiming
+	Event       database.TriggerEvent
+	// Statement is the text of the statement run by the trigger, stored with it.
+	Statement string
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt CreateTriggerStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the Create trigger statement in the given transaction.
+// It implements the Statement interface.
+func (stmt CreateTriggerStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TableName == "" {
+		return res, errors.New("missing table name")
+	}
+
+	if stmt.Statement == "" {
+		return res, errors.New("missing trigger statement")
+	}
+
+	err := tx.CreateTrigger(stmt.TableName, database.TriggerConfig{
+		TriggerName: stmt.TriggerName,
+		Timing:      stmt.Timing,
+		Event:       stmt.Event,
+		Statement:   stmt.Statement,
+	})
+	if stmt.IfNotExists && err == database.ErrTriggerAlreadyExists {
+		err = nil
+	}
+
+	return res, err
+}
+
+// DropTriggerStmt is a DSL that allows creating a DROP TRIGGER query.
+type DropTriggerStmt struct {
+	TriggerName string
+	IfExists    bool
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt DropTriggerStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the DropTrigger statement in the given transaction.
+// It implements the Statement interface.
+func (stmt DropTriggerStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.TriggerName == "" {
+		return res, errors.New("missing trigger name")
+	}
+
+	err := tx.DropTrigger(stmt.TriggerName)
+	if errors.Is(err, database.ErrTriggerNotFound) && stmt.IfExists {
+		err = nil
+	}
+
+	return res, err
+}
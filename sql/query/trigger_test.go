@@ -0,0 +1,98 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrigger(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY);
+		CREATE TABLE log;
+		CREATE TRIGGER log_insert AFTER INSERT ON users INSERT INTO log (op, id, name) VALUES ('insert', new.id, new.name);
+		CREATE TRIGGER log_update BEFORE UPDATE ON users FOR EACH ROW INSERT INTO log (op, id, old, new) VALUES ('update', old.id, old.name, new.name);
+		CREATE TRIGGER log_delete AFTER DELETE ON users INSERT INTO log (op, id) VALUES ('delete', old.id);
+	`)
+	require.NoError(t, err)
+
+	err = db.Exec(`
+		INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b');
+		UPDATE users SET name = 'c' WHERE id = 1;
+		DELETE FROM users WHERE id = 2;
+	`)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `[
+		{"op": "insert", "id": 1, "name": "a"},
+		{"op": "insert", "id": 2, "name": "b"},
+		{"op": "update", "id": 1, "old": "a", "new": "c"},
+		{"op": "delete", "id": 2}
+	]`, queryJSON(t, db, "SELECT * FROM log"))
+
+	t.Run("Keywords as identifiers", func(t *testing.T) {
+		// AFTER, BEFORE and TRIGGER can still be used as identifiers
+		err = db.Exec(`
+			CREATE TABLE trigger (before INTEGER);
+			CREATE TRIGGER after AFTER INSERT ON trigger INSERT INTO log (op, before, after) VALUES ('trigger', new.before, new.after);
+			INSERT INTO trigger (before, after) VALUES (1, 2);
+		`)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"before": 1, "after": 2}]`, queryJSON(t, db, "SELECT before, after FROM log WHERE op = 'trigger'"))
+
+		err = db.Exec("DROP TRIGGER after")
+		require.NoError(t, err)
+	})
+
+	t.Run("Same transaction", func(t *testing.T) {
+		// errors raised by triggers roll back the write
+		err = db.Exec(`
+			CREATE TABLE audit (id INTEGER PRIMARY KEY);
+			INSERT INTO audit (id) VALUES (1);
+			CREATE TABLE counter (id INTEGER PRIMARY KEY);
+			CREATE TRIGGER count_insert AFTER INSERT ON counter INSERT INTO audit (id) VALUES (new.id);
+		`)
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO counter (id) VALUES (1)")
+		require.Error(t, err)
+		require.JSONEq(t, `[]`, queryJSON(t, db, "SELECT * FROM counter"))
+	})
+
+	t.Run("Nested triggers", func(t *testing.T) {
+		err = db.Exec(`
+			CREATE TABLE loop;
+			CREATE TRIGGER loop_insert AFTER INSERT ON loop INSERT INTO loop (n) VALUES (new.n + 1);
+		`)
+		require.NoError(t, err)
+
+		err = db.Exec("INSERT INTO loop (n) VALUES (1)")
+		require.Error(t, err)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		err = db.Exec(`
+			DROP TRIGGER log_insert;
+			DROP TRIGGER IF EXISTS log_insert;
+			INSERT INTO users (id, name) VALUES (3, 'd');
+		`)
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, queryJSON(t, db, "SELECT * FROM log WHERE id = 3"))
+
+		err = db.Exec("DROP TRIGGER log_insert")
+		require.Error(t, err)
+	})
+
+	t.Run("Already exists", func(t *testing.T) {
+		err = db.Exec("CREATE TRIGGER log_delete AFTER INSERT ON log DELETE FROM users")
+		require.Error(t, err)
+
+		err = db.Exec("CREATE TRIGGER IF NOT EXISTS log_delete AFTER INSERT ON log DELETE FROM users")
+		require.NoError(t, err)
+	})
+}
@@ -89,9 +89,15 @@ func TestUpdateStmt(t *testing.T) {
 			{"SET / No cond / with index array", `UPDATE foo SET a[1] = 10`, false, `[{"a": [1, 10, 0]}, {"a": [2, 10]}]`, nil},
 			{"SET / No cond / with path on non existing field", `UPDATE foo SET a.foo[1] = 10`, false, `[{"a": [1, 0, 0]}, {"a": [2, 0]}]`, nil},
 			{"SET / With cond / index array", `UPDATE foo SET a[0] = 1 WHERE a[0] = 2`, false, `[{"a": [1, 0, 0]}, {"a": [1, 0]}]`, nil},
-			{"SET / No cond / index out of range", `UPDATE foo SET a[10] = 1`, true, `[{"a": [1, 0, 0]}, {"a": [1, 0]}]`, nil},
+			{"SET / No cond / index out of range", `UPDATE foo SET a[10] = 1`, false, `[{"a": [1, 0, 0]}, {"a": [2, 0]}]`, nil},
+			{"SET / No cond / with path on scalar", `UPDATE foo SET a[0].b = 1`, true, ``, nil},
 			{"SET / No cond / Nested array", `UPDATE foo SET a[1] = [1, 0, 0]`, false, `[{"a": [1, [1, 0, 0], 0]}, {"a": [2, [1, 0, 0]]}]`, nil},
 			{"SET / No cond / with multiple idents", `UPDATE foo SET a[1] = [1, 0, 0], a[1][2] = 9`, false, `[{"a": [1, [1, 0, 9], 0]}, {"a": [2, [1, 0, 9]]}]`, nil},
+			{"SET / array_append", `UPDATE foo SET a = array_append(a, 5)`, false, `[{"a": [1, 0, 0, 5]}, {"a": [2, 0, 5]}]`, nil},
+			{"SET / array_append / missing field", `UPDATE foo SET b = array_append(b, 5)`, false, `[{"a": [1, 0, 0], "b": [5]}, {"a": [2, 0], "b": [5]}]`, nil},
+			{"SET / array_append / not an array", `UPDATE foo SET a = array_append(a[0], 5)`, true, ``, nil},
+			{"SET / array_remove", `UPDATE foo SET a = array_remove(a, 0)`, false, `[{"a": [1]}, {"a": [2]}]`, nil},
+			{"SET / array_remove_at", `UPDATE foo SET a = array_remove_at(a, 0)`, false, `[{"a": [0, 0]}, {"a": [0]}]`, nil},
 			{"SET / No cond / add doc / with multiple idents with multiple indexes", `UPDATE foo SET a[1] = [1, 0, 0], a[1][2] = {"b": "foo"}`, false, `[{"a": [1, [1, 0, {"b":"foo"}], 0]}, {"a": [2, [1, 0, {"b":"foo"}]]}]`, nil},
 		}
 
@@ -123,4 +129,88 @@ func TestUpdateStmt(t *testing.T) {
 			require.JSONEq(t, tt.expected, buf.String())
 		}
 	})
+
+	t.Run("with nested documents", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			query    string
+			fails    bool
+			expected string
+		}{
+			{"SET / existing field", `UPDATE foo SET address.city = 'Berlin' WHERE id = 1`, false, `[{"id": 1, "address": {"city": "Berlin", "zip": "75001"}}, {"id": 2, "address": {"city": "Lyon"}}, {"id": 3}]`},
+			{"SET / new field", `UPDATE foo SET address.country = 'FR'`, false, `[{"id": 1, "address": {"city": "Paris", "zip": "75001", "country": "FR"}}, {"id": 2, "address": {"city": "Lyon", "country": "FR"}}, {"id": 3}]`},
+			{"SET / missing intermediate", `UPDATE foo SET address.geo.lat = 10`, false, `[{"id": 1, "address": {"city": "Paris", "zip": "75001"}}, {"id": 2, "address": {"city": "Lyon"}}, {"id": 3}]`},
+			{"SET / scalar intermediate", `UPDATE foo SET address.city.name = 'Berlin'`, true, ``},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				db, err := genji.Open(":memory:")
+				require.NoError(t, err)
+				defer db.Close()
+
+				err = db.Exec(`CREATE TABLE foo (id INTEGER PRIMARY KEY)`)
+				require.NoError(t, err)
+				err = db.Exec(`INSERT INTO foo (id, address) VALUES (1, {city: 'Paris', zip: '75001'}), (2, {city: 'Lyon'})`)
+				require.NoError(t, err)
+				err = db.Exec(`INSERT INTO foo (id) VALUES (3)`)
+				require.NoError(t, err)
+
+				err = db.Exec(tt.query)
+				if tt.fails {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+
+				st, err := db.Query("SELECT * FROM foo")
+				require.NoError(t, err)
+				defer st.Close()
+
+				var buf bytes.Buffer
+				err = document.IteratorToJSONArray(&buf, st)
+				require.NoError(t, err)
+				require.JSONEq(t, tt.expected, buf.String())
+			})
+		}
+	})
+
+	t.Run("with an index on a nested path", func(t *testing.T) {
+		db, err := genji.Open(":memory:")
+		require.NoError(t, err)
+		defer db.Close()
+
+		err = db.Exec(`
+			CREATE TABLE foo (id INTEGER PRIMARY KEY);
+			CREATE INDEX idx_foo_city ON foo (address.city);
+			INSERT INTO foo (id, address) VALUES (1, {city: 'Paris'}), (2, {city: 'Lyon'});
+			UPDATE foo SET address.city = 'Berlin' WHERE id = 1;
+		`)
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[{"id": 1, "address": {"city": "Berlin"}}]`, queryJSON(t, db, `SELECT * FROM foo WHERE address.city = 'Berlin'`))
+		require.JSONEq(t, `[]`, queryJSON(t, db, `SELECT * FROM foo WHERE address.city = 'Paris'`))
+	})
+}
+
+func TestUpdateStmtReturning(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER);
+		INSERT INTO test (a, b) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+	`)
+	require.NoError(t, err)
+
+	res, err := db.Query("UPDATE test SET a = a * 10 WHERE a > 1 RETURNING a, b")
+	require.NoError(t, err)
+	defer res.Close()
+
+	var buf bytes.Buffer
+	err = document.IteratorToJSONArray(&buf, res)
+	require.NoError(t, err)
+	require.Equal(t, `[{"a": 20, "b": "b"}, {"a": 30, "b": "c"}]`, buf.String())
+	require.EqualValues(t, 2, res.RowsAffected)
 }
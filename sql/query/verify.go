@@ -0,0 +1,41 @@
+package query
+
+import (
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/document"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// VerifyIntegrityStmt is a DSL that allows creating a VERIFY INTEGRITY statement.
+// It returns one document per inconsistency found between the tables and their indexes,
+// which can be fixed by running REINDEX.
+type VerifyIntegrityStmt struct{}
+
+// IsReadOnly always returns true. It implements the Statement interface.
+func (stmt VerifyIntegrityStmt) IsReadOnly() bool {
+	return true
+}
+
+// Run verifies the integrity of every index in the given transaction.
+// Each document of the result contains the kind of inconsistency,
+// the name of the table and of the index, and the key of the document.
+// It implements the Statement interface.
+func (stmt VerifyIntegrityStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	errs, err := tx.VerifyIntegrity()
+	if err != nil {
+		return Result{}, err
+	}
+
+	docs := make([]document.Document, 0, len(errs))
+	for _, e := range errs {
+		docs = append(docs, document.NewFieldBuffer().
+			Add("kind", document.NewTextValue(e.Kind.String())).
+			Add("table_name", document.NewTextValue(e.TableName)).
+			Add("index_name", document.NewTextValue(e.IndexName)).
+			Add("key", document.NewBlobValue(e.Key)))
+	}
+
+	return Result{
+		Stream: document.NewStream(document.NewIterator(docs...)),
+	}, nil
+}
@@ -0,0 +1,52 @@
+package query_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/genjidb/genji/document"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyIntegrity(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE test(a INTEGER PRIMARY KEY);
+		CREATE INDEX idx_b ON test(b);
+		INSERT INTO test (a, b) VALUES (1, 'foo'), (2, 'bar');
+	`)
+	require.NoError(t, err)
+
+	err = db.Update(func(tx *genji.Tx) error {
+		require.JSONEq(t, `[]`, queryJSON(t, tx, "VERIFY INTEGRITY"))
+
+		idx, err := tx.GetIndex("idx_b")
+		require.NoError(t, err)
+
+		key, err := document.NewIntegerValue(1).MarshalBinary()
+		require.NoError(t, err)
+
+		// the index references a document that doesn't exist
+		// and no longer references the document 1
+		err = idx.Set(document.NewTextValue("baz"), []byte{0})
+		require.NoError(t, err)
+		err = idx.Delete(document.NewTextValue("foo"), key)
+		require.NoError(t, err)
+
+		require.JSONEq(t, `[
+			{"kind": "orphaned index entry", "table_name": "test", "index_name": "idx_b", "key": "AA=="},
+			{"kind": "missing index entry", "table_name": "test", "index_name": "idx_b", "key": "`+base64.StdEncoding.EncodeToString(key)+`"}
+		]`, queryJSON(t, tx, "VERIFY INTEGRITY"))
+
+		// reindexing repairs the index
+		err = tx.Exec("REINDEX test")
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, queryJSON(t, tx, "VERIFY INTEGRITY"))
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/genjidb/genji/database"
+	"github.com/genjidb/genji/sql/query/expr"
+)
+
+// CreateMaterializedViewStmt is a DSL that allows creating a full CREATE MATERIALIZED VIEW statement.
+// The view is a table that holds the documents returned by the query. The query is stored
+// with the view so that it can be run again by REFRESH MATERIALIZED VIEW.
+type CreateMaterializedViewStmt struct {
+	ViewName    string
+	IfNotExists bool
+	// Query is the text of the statement, stored with the view.
+	Query string
+	// Select is the parsed statement.
+	Select Statement
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt CreateMaterializedViewStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run creates the view and inserts the documents returned by the query.
+// It implements the Statement interface.
+func (stmt CreateMaterializedViewStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.ViewName == "" {
+		return res, errors.New("missing view name")
+	}
+
+	err := tx.CreateTable(stmt.ViewName, &database.TableInfo{ViewQuery: stmt.Query})
+	if stmt.IfNotExists && err == database.ErrTableAlreadyExists {
+		return res, stmt.checkCompatibility(tx)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	sel, err := stmt.Select.Run(tx, args)
+	if err != nil {
+		return res, err
+	}
+
+	return res, tx.RefreshMaterializedView(stmt.ViewName, sel)
+}
+
+// checkCompatibility returns an error if the existing table
+// is not a view created with the same query.
+func (stmt CreateMaterializedViewStmt) checkCompatibility(tx *database.Transaction) error {
+	tb, err := tx.GetTable(stmt.ViewName)
+	if err != nil {
+		return err
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return err
+	}
+
+	if info.ViewQuery != stmt.Query {
+		return fmt.Errorf("%w: %q has a different definition", database.ErrTableAlreadyExists, stmt.ViewName)
+	}
+
+	return nil
+}
+
+// RefreshMaterializedViewStmt is a DSL that allows creating a full REFRESH MATERIALIZED VIEW statement.
+type RefreshMaterializedViewStmt struct {
+	ViewName string
+	// Parse parses the query stored with the view.
+	Parse func(q string) (Statement, error)
+}
+
+// IsReadOnly always returns false. It implements the Statement interface.
+func (stmt RefreshMaterializedViewStmt) IsReadOnly() bool {
+	return false
+}
+
+// Run runs the query of the view again and replaces its documents
+// with the result, within the transaction.
+// It implements the Statement interface.
+func (stmt RefreshMaterializedViewStmt) Run(tx *database.Transaction, args []expr.Param) (Result, error) {
+	var res Result
+
+	if stmt.ViewName == "" {
+		return res, errors.New("missing view name")
+	}
+
+	tb, err := tx.GetTable(stmt.ViewName)
+	if err != nil {
+		return res, err
+	}
+
+	info, err := tb.Info()
+	if err != nil {
+		return res, err
+	}
+
+	if info.ViewQuery == "" {
+		return res, fmt.Errorf("%q is not a materialized view", stmt.ViewName)
+	}
+
+	sel, err := stmt.Parse(info.ViewQuery)
+	if err != nil {
+		return res, err
+	}
+
+	r, err := sel.Run(tx, nil)
+	if err != nil {
+		return res, err
+	}
+
+	return res, tx.RefreshMaterializedView(stmt.ViewName, r)
+}
@@ -0,0 +1,96 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/genjidb/genji"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaterializedView(t *testing.T) {
+	db, err := genji.Open(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec(`
+		CREATE TABLE sales;
+		INSERT INTO sales (region, amount) VALUES ('a', 10), ('b', 20), ('a', 30);
+		CREATE MATERIALIZED VIEW totals AS SELECT region, SUM(amount) AS total FROM sales GROUP BY region;
+		CREATE INDEX idx_totals_region ON totals(region);
+		REINDEX totals;
+	`)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `[{"region": "a", "total": 40}, {"region": "b", "total": 20}]`, queryJSON(t, db, "SELECT * FROM totals ORDER BY region"))
+
+	// the view is not updated until it is refreshed
+	err = db.Exec("INSERT INTO sales (region, amount) VALUES ('c', 5), ('a', 1)")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"region": "a", "total": 40}, {"region": "b", "total": 20}]`, queryJSON(t, db, "SELECT * FROM totals ORDER BY region"))
+
+	err = db.Exec("REFRESH MATERIALIZED VIEW totals")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"region": "a", "total": 41}, {"region": "b", "total": 20}, {"region": "c", "total": 5}]`, queryJSON(t, db, "SELECT * FROM totals ORDER BY region"))
+
+	// indexes are kept up to date
+	require.JSONEq(t, `[{"total": 5}]`, queryJSON(t, db, "SELECT total FROM totals WHERE region = 'c'"))
+
+	// the documents of the view can't be modified
+	for _, q := range []string{
+		"INSERT INTO totals (region, total) VALUES ('d', 1)",
+		"UPDATE totals SET total = 0",
+		"DELETE FROM totals",
+	} {
+		err = db.Exec(q)
+		require.Error(t, err, q)
+	}
+
+	// refreshing is transactional
+	tx, err := db.Begin(true)
+	require.NoError(t, err)
+	err = tx.Exec("DELETE FROM sales; REFRESH MATERIALIZED VIEW totals")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	require.JSONEq(t, `[{"n": 3}]`, queryJSON(t, db, "SELECT COUNT(*) AS n FROM totals"))
+
+	// only views can be refreshed
+	err = db.Exec("REFRESH MATERIALIZED VIEW sales")
+	require.Error(t, err)
+
+	// VIEW, MATERIALIZED and REFRESH can still be used as identifiers
+	err = db.Exec(`
+		CREATE TABLE pages(view INTEGER);
+		INSERT INTO pages (view, refresh) VALUES (1, 2);
+		CREATE MATERIALIZED VIEW materialized AS SELECT view, refresh FROM pages WHERE view = 1;
+	`)
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"view": 1, "refresh": 2}]`, queryJSON(t, db, "SELECT view, refresh FROM materialized"))
+
+	t.Run("If not exists", func(t *testing.T) {
+		err = db.Exec("CREATE MATERIALIZED VIEW IF NOT EXISTS totals AS SELECT region, SUM(amount) AS total FROM sales GROUP BY region")
+		require.NoError(t, err)
+
+		err = db.Exec("CREATE MATERIALIZED VIEW IF NOT EXISTS totals AS SELECT * FROM sales")
+		require.Error(t, err)
+
+		err = db.Exec("CREATE MATERIALIZED VIEW totals AS SELECT * FROM sales")
+		require.Error(t, err)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		err = db.Exec("DROP TABLE totals")
+		require.Error(t, err)
+
+		err = db.Exec("DROP MATERIALIZED VIEW sales")
+		require.Error(t, err)
+
+		err = db.Exec("DROP MATERIALIZED VIEW totals")
+		require.NoError(t, err)
+
+		err = db.Exec("DROP MATERIALIZED VIEW IF EXISTS totals")
+		require.NoError(t, err)
+
+		_, err = db.Query("SELECT * FROM totals")
+		require.Error(t, err)
+	})
+}
@@ -177,10 +177,19 @@ func (s *Scanner) Scan() TokenInfo {
 	case ';':
 		return TokenInfo{SEMICOLON, pos, "", s.unbuffer()}
 	case ':':
-		if ch1, _ := s.read(); ch1 == ':' {
+		ch1, _ := s.read()
+		if ch1 == ':' {
 			return TokenInfo{DOUBLECOLON, pos, "", s.unbuffer()}
 		}
 		s.unread()
+		// a colon directly followed by a name is a named param
+		if isLetter(ch1) || ch1 == '_' || ch1 == '`' {
+			ti := s.scanIdent(false)
+			if ti.Tok != IDENT {
+				return TokenInfo{ti.Tok, pos, ":" + ti.Lit, ti.Raw}
+			}
+			return TokenInfo{NAMEDPARAM, pos, ":" + ti.Lit, ti.Raw}
+		}
 		return TokenInfo{COLON, pos, "", s.unbuffer()}
 	}
 
@@ -85,6 +85,9 @@ func TestScanner_Scan(t *testing.T) {
 		{s: "`test", tok: scanner.BADSTRING, lit: "test", raw: "`test"},
 		{s: "$host", tok: scanner.NAMEDPARAM, lit: "$host", raw: "$host"},
 		{s: "$`host param`", tok: scanner.NAMEDPARAM, lit: "$host param", raw: "$`host param`"},
+		{s: ":host", tok: scanner.NAMEDPARAM, lit: ":host", raw: ":host"},
+		{s: ":_host", tok: scanner.NAMEDPARAM, lit: ":_host", raw: ":_host"},
+		{s: ":`host param`", tok: scanner.NAMEDPARAM, lit: ":host param", raw: ":`host param`"},
 		{s: "?", tok: scanner.POSITIONALPARAM, lit: "", raw: "?"},
 
 		// Booleans
@@ -118,43 +121,77 @@ func TestScanner_Scan(t *testing.T) {
 
 		// Keywords
 		{s: `ADD`, tok: scanner.ADD_KEYWORD, raw: `ADD`},
+		{s: `AFTER`, tok: scanner.AFTER, raw: `AFTER`},
 		{s: `ALTER`, tok: scanner.ALTER, raw: `ALTER`},
+		{s: `ALWAYS`, tok: scanner.ALWAYS, raw: `ALWAYS`},
 		{s: `AS`, tok: scanner.AS, raw: `AS`},
 		{s: `ASC`, tok: scanner.ASC, raw: `ASC`},
+		{s: `AUTOINCREMENT`, tok: scanner.AUTOINCREMENT, raw: `AUTOINCREMENT`},
 		{s: `BY`, tok: scanner.BY, raw: `BY`},
+		{s: `BEFORE`, tok: scanner.BEFORE, raw: `BEFORE`},
 		{s: `BEGIN`, tok: scanner.BEGIN, raw: `BEGIN`},
+		{s: `BETWEEN`, tok: scanner.BETWEEN, raw: `BETWEEN`},
+		{s: `CASCADE`, tok: scanner.CASCADE, raw: `CASCADE`},
+		{s: `CASE`, tok: scanner.CASE, raw: `CASE`},
 		{s: `CAST`, tok: scanner.CAST, raw: `CAST`},
+		{s: `WHEN`, tok: scanner.WHEN, raw: `WHEN`},
+		{s: `THEN`, tok: scanner.THEN, raw: `THEN`},
+		{s: `ELSE`, tok: scanner.ELSE, raw: `ELSE`},
 		{s: `COMMIT`, tok: scanner.COMMIT, raw: `COMMIT`},
+		{s: `CONFLICT`, tok: scanner.CONFLICT, raw: `CONFLICT`},
+		{s: `RETURNING`, tok: scanner.RETURNING, raw: `RETURNING`},
 		{s: `CREATE`, tok: scanner.CREATE, raw: `CREATE`},
 		{s: `EXPLAIN`, tok: scanner.EXPLAIN, raw: `EXPLAIN`},
 		{s: `DEFAULT`, tok: scanner.DEFAULT, raw: `DEFAULT`},
 		{s: `DELETE`, tok: scanner.DELETE, raw: `DELETE`},
 		{s: `DESC`, tok: scanner.DESC, raw: `DESC`},
 		{s: `DISTINCT`, tok: scanner.DISTINCT, raw: `DISTINCT`},
+		{s: `DO`, tok: scanner.DO, raw: `DO`},
 		{s: `DROP`, tok: scanner.DROP, raw: `DROP`},
 		{s: `FIELD`, tok: scanner.FIELD, raw: `FIELD`},
+		{s: `FOREIGN`, tok: scanner.FOREIGN, raw: `FOREIGN`},
 		{s: `FROM`, tok: scanner.FROM, raw: `FROM`},
+		{s: `FULLTEXT`, tok: scanner.FULLTEXT, raw: `FULLTEXT`},
+		{s: `GENERATED`, tok: scanner.GENERATED, raw: `GENERATED`},
 		{s: `GROUP`, tok: scanner.GROUP, raw: `GROUP`},
+		{s: `HAVING`, tok: scanner.HAVING, raw: `HAVING`},
 		{s: `INSERT`, tok: scanner.INSERT, raw: `INSERT`},
 		{s: `INTO`, tok: scanner.INTO, raw: `INTO`},
 		{s: `LIMIT`, tok: scanner.LIMIT, raw: `LIMIT`},
+		{s: `MATERIALIZED`, tok: scanner.MATERIALIZED, raw: `MATERIALIZED`},
 		{s: `ONLY`, tok: scanner.ONLY, raw: `ONLY`},
 		{s: `OFFSET`, tok: scanner.OFFSET, raw: `OFFSET`},
 		{s: `ORDER`, tok: scanner.ORDER, raw: `ORDER`},
+		{s: `OVER`, tok: scanner.OVER, raw: `OVER`},
+		{s: `PARTITION`, tok: scanner.PARTITION, raw: `PARTITION`},
 		{s: `PRIMARY`, tok: scanner.PRIMARY, raw: `PRIMARY`},
 		{s: `READ`, tok: scanner.READ, raw: `READ`},
+		{s: `RECURSIVE`, tok: scanner.RECURSIVE, raw: `RECURSIVE`},
+		{s: `REFERENCES`, tok: scanner.REFERENCES, raw: `REFERENCES`},
+		{s: `REFRESH`, tok: scanner.REFRESH, raw: `REFRESH`},
 		{s: `REINDEX`, tok: scanner.REINDEX, raw: `REINDEX`},
+		{s: `VERIFY`, tok: scanner.VERIFY, raw: `VERIFY`},
+		{s: `RELEASE`, tok: scanner.RELEASE, raw: `RELEASE`},
 		{s: `RENAME`, tok: scanner.RENAME, raw: `RENAME`},
+		{s: `RESTRICT`, tok: scanner.RESTRICT, raw: `RESTRICT`},
 		{s: `ROLLBACK`, tok: scanner.ROLLBACK, raw: `ROLLBACK`},
+		{s: `SAVEPOINT`, tok: scanner.SAVEPOINT, raw: `SAVEPOINT`},
 		{s: `SELECT`, tok: scanner.SELECT, raw: `SELECT`},
 		{s: `SET`, tok: scanner.SET, raw: `SET`},
+		{s: `STORED`, tok: scanner.STORED, raw: `STORED`},
 		{s: `TABLE`, tok: scanner.TABLE, raw: `TABLE`},
 		{s: `TO`, tok: scanner.TO, raw: `TO`},
 		{s: `TRANSACTION`, tok: scanner.TRANSACTION, raw: `TRANSACTION`},
+		{s: `TRIGGER`, tok: scanner.TRIGGER, raw: `TRIGGER`},
 		{s: `UPDATE`, tok: scanner.UPDATE, raw: `UPDATE`},
+		{s: `UNNEST`, tok: scanner.UNNEST, raw: `UNNEST`},
 		{s: `UNSET`, tok: scanner.UNSET, raw: `UNSET`},
+		{s: `UNION`, tok: scanner.UNION, raw: `UNION`},
 		{s: `VALUES`, tok: scanner.VALUES, raw: `VALUES`},
+		{s: `VIEW`, tok: scanner.VIEW, raw: `VIEW`},
+		{s: `VIRTUAL`, tok: scanner.VIRTUAL, raw: `VIRTUAL`},
 		{s: `WHERE`, tok: scanner.WHERE, raw: `WHERE`},
+		{s: `WITH`, tok: scanner.WITH, raw: `WITH`},
 		{s: `WRITE`, tok: scanner.WRITE, raw: `WRITE`},
 		{s: `seLECT`, tok: scanner.SELECT, raw: `seLECT`}, // case insensitive
 
@@ -18,7 +18,7 @@ const (
 	literalBeg
 	// IDENT and the following are Genji SQL literal tokens.
 	IDENT           // main
-	NAMEDPARAM      // $param
+	NAMEDPARAM      // $param or :param
 	POSITIONALPARAM // ?
 	NUMBER          // 12345.67
 	INTEGER         // 12345
@@ -57,6 +57,7 @@ const (
 	IN       // IN
 	IS       // IS
 	LIKE     // LIKE
+	BETWEEN  // BETWEEN
 	operatorEnd
 
 	LPAREN      // (
@@ -74,51 +75,93 @@ const (
 	keywordBeg
 	// ALL and the following are Genji SQL Keywords
 	ADD_KEYWORD
+	AFTER
+	ALL
 	ALTER
+	ALWAYS
 	AS
 	ASC
+	AUTOINCREMENT
+	BEFORE
 	BEGIN
 	BY
+	CASCADE
+	CASE
 	CAST
 	COMMIT
+	CONFLICT
 	CREATE
 	DEFAULT
 	DELETE
 	DESC
 	DISTINCT
+	DO
 	DROP
+	ELSE
+	EXCEPT
 	EXISTS
 	EXPLAIN
 	FIELD
+	FOREIGN
 	FROM
+	FULLTEXT
+	GENERATED
 	GROUP
+	HAVING
 	IF
 	INDEX
+	INNER
 	INSERT
+	INTERSECT
 	INTO
+	JOIN
 	KEY
+	LEFT
 	LIMIT
+	MATERIALIZED
 	NOT
 	OFFSET
 	ON
 	ONLY
 	ORDER
+	OUTER
+	OVER
+	PARTITION
 	PRECISION
 	PRIMARY
 	READ
+	RECURSIVE
+	REFERENCES
+	REFRESH
 	REINDEX
+	RELEASE
 	RENAME
+	RESTRICT
+	RETURNING
+	RIGHT
 	ROLLBACK
+	SAVEPOINT
 	SELECT
 	SET
+	STORED
 	TABLE
+	TEMPORARY
+	THEN
 	TO
 	TRANSACTION
+	TRIGGER
+	UNION
 	UNIQUE
+	UNNEST
 	UNSET
 	UPDATE
 	VALUES
+	VERIFY
+	VIEW
+	VIRTUAL
+	WHEN
 	WHERE
+	WITH
 	WRITE
 
 	// Aliases
@@ -128,6 +171,7 @@ const (
 	TYPEBOOL
 	TYPEBYTES
 	TYPECHARACTER
+	TYPEDECIMAL
 	TYPEDOCUMENT
 	TYPEDOUBLE
 	TYPEINT
@@ -137,6 +181,7 @@ const (
 	TYPEMEDIUMINT
 	TYPESMALLINT
 	TYPETEXT
+	TYPETIMESTAMP
 	TYPETINYINT
 	TYPEREAL
 	TYPEVARCHAR
@@ -183,6 +228,7 @@ var tokens = [...]string{
 	IN:       "IN",
 	IS:       "IS",
 	LIKE:     "LIKE",
+	BETWEEN:  "BETWEEN",
 
 	LPAREN:      "(",
 	RPAREN:      ")",
@@ -196,53 +242,95 @@ var tokens = [...]string{
 	SEMICOLON:   ";",
 	DOT:         ".",
 
-	ADD_KEYWORD: "ADD",
-	ALTER:       "ALTER",
-	AS:          "AS",
-	ASC:         "ASC",
-	BEGIN:       "BEGIN",
-	COMMIT:      "COMMIT",
-	GROUP:       "GROUP",
-	BY:          "BY",
-	CREATE:      "CREATE",
-	CAST:        "CAST",
-	DEFAULT:     "DEFAULT",
-	DELETE:      "DELETE",
-	DESC:        "DESC",
-	DISTINCT:    "DISTINCT",
-	DROP:        "DROP",
-	EXISTS:      "EXISTS",
-	EXPLAIN:     "EXPLAIN",
-	KEY:         "KEY",
-	FIELD:       "FIELD",
-	FROM:        "FROM",
-	IF:          "IF",
-	INDEX:       "INDEX",
-	INSERT:      "INSERT",
-	INTO:        "INTO",
-	LIMIT:       "LIMIT",
-	NOT:         "NOT",
-	OFFSET:      "OFFSET",
-	ON:          "ON",
-	ONLY:        "ONLY",
-	ORDER:       "ORDER",
-	PRECISION:   "PRECISION",
-	PRIMARY:     "PRIMARY",
-	READ:        "READ",
-	REINDEX:     "REINDEX",
-	RENAME:      "RENAME",
-	ROLLBACK:    "ROLLBACK",
-	SELECT:      "SELECT",
-	SET:         "SET",
-	TABLE:       "TABLE",
-	TO:          "TO",
-	TRANSACTION: "TRANSACTION",
-	UNIQUE:      "UNIQUE",
-	UNSET:       "UNSET",
-	UPDATE:      "UPDATE",
-	VALUES:      "VALUES",
-	WHERE:       "WHERE",
-	WRITE:       "WRITE",
+	ADD_KEYWORD:   "ADD",
+	AFTER:         "AFTER",
+	ALL:           "ALL",
+	ALTER:         "ALTER",
+	ALWAYS:        "ALWAYS",
+	AS:            "AS",
+	ASC:           "ASC",
+	AUTOINCREMENT: "AUTOINCREMENT",
+	BEFORE:        "BEFORE",
+	BEGIN:         "BEGIN",
+	COMMIT:        "COMMIT",
+	GROUP:         "GROUP",
+	HAVING:        "HAVING",
+	BY:            "BY",
+	CASCADE:       "CASCADE",
+	CASE:          "CASE",
+	CONFLICT:      "CONFLICT",
+	CREATE:        "CREATE",
+	CAST:          "CAST",
+	DEFAULT:       "DEFAULT",
+	DELETE:        "DELETE",
+	DESC:          "DESC",
+	DISTINCT:      "DISTINCT",
+	DO:            "DO",
+	DROP:          "DROP",
+	ELSE:          "ELSE",
+	EXCEPT:        "EXCEPT",
+	EXISTS:        "EXISTS",
+	EXPLAIN:       "EXPLAIN",
+	KEY:           "KEY",
+	FIELD:         "FIELD",
+	FOREIGN:       "FOREIGN",
+	FROM:          "FROM",
+	FULLTEXT:      "FULLTEXT",
+	GENERATED:     "GENERATED",
+	IF:            "IF",
+	INDEX:         "INDEX",
+	INNER:         "INNER",
+	INSERT:        "INSERT",
+	INTERSECT:     "INTERSECT",
+	INTO:          "INTO",
+	JOIN:          "JOIN",
+	LEFT:          "LEFT",
+	LIMIT:         "LIMIT",
+	MATERIALIZED:  "MATERIALIZED",
+	NOT:           "NOT",
+	OFFSET:        "OFFSET",
+	ON:            "ON",
+	ONLY:          "ONLY",
+	ORDER:         "ORDER",
+	OUTER:         "OUTER",
+	OVER:          "OVER",
+	PARTITION:     "PARTITION",
+	PRECISION:     "PRECISION",
+	PRIMARY:       "PRIMARY",
+	READ:          "READ",
+	RECURSIVE:     "RECURSIVE",
+	REFERENCES:    "REFERENCES",
+	REFRESH:       "REFRESH",
+	REINDEX:       "REINDEX",
+	RELEASE:       "RELEASE",
+	RENAME:        "RENAME",
+	RESTRICT:      "RESTRICT",
+	RETURNING:     "RETURNING",
+	RIGHT:         "RIGHT",
+	ROLLBACK:      "ROLLBACK",
+	SAVEPOINT:     "SAVEPOINT",
+	SELECT:        "SELECT",
+	SET:           "SET",
+	STORED:        "STORED",
+	TABLE:         "TABLE",
+	TEMPORARY:     "TEMPORARY",
+	THEN:          "THEN",
+	TO:            "TO",
+	TRANSACTION:   "TRANSACTION",
+	TRIGGER:       "TRIGGER",
+	UNION:         "UNION",
+	UNIQUE:        "UNIQUE",
+	UNNEST:        "UNNEST",
+	UNSET:         "UNSET",
+	UPDATE:        "UPDATE",
+	VALUES:        "VALUES",
+	VERIFY:        "VERIFY",
+	VIEW:          "VIEW",
+	VIRTUAL:       "VIRTUAL",
+	WHEN:          "WHEN",
+	WHERE:         "WHERE",
+	WITH:          "WITH",
+	WRITE:         "WRITE",
 
 	TYPEARRAY:     "ARRAY",
 	TYPEBIGINT:    "BIGINT",
@@ -250,6 +338,7 @@ var tokens = [...]string{
 	TYPEBOOL:      "BOOL",
 	TYPEBYTES:     "BYTES",
 	TYPECHARACTER: "CHARACTER",
+	TYPEDECIMAL:   "DECIMAL",
 	TYPEDOCUMENT:  "DOCUMENT",
 	TYPEDOUBLE:    "DOUBLE",
 	TYPEINT:       "INT",
@@ -259,6 +348,7 @@ var tokens = [...]string{
 	TYPEMEDIUMINT: "MEDIUMINT",
 	TYPESMALLINT:  "SMALLINT",
 	TYPETEXT:      "TEXT",
+	TYPETIMESTAMP: "TIMESTAMP",
 	TYPETINYINT:   "TINYINT",
 	TYPEREAL:      "REAL",
 	TYPEVARCHAR:   "VARCHAR",
@@ -271,7 +361,7 @@ func initKeywords() {
 	for tok := keywordBeg + 1; tok < keywordEnd; tok++ {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
-	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE} {
+	for _, tok := range []Token{AND, OR, TRUE, FALSE, NULL, IN, IS, LIKE, BETWEEN} {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
 }
@@ -293,7 +383,7 @@ func (tok Token) Precedence() int {
 		return 2
 	case IN:
 		return 3
-	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, IS, LIKE:
+	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, IS, LIKE, BETWEEN:
 		return 4
 	case ADD, SUB, BITWISEOR, BITWISEXOR:
 		return 5
@@ -306,6 +396,19 @@ func (tok Token) Precedence() int {
 // IsOperator returns true for operator tokens.
 func (tok Token) IsOperator() bool { return tok > operatorBeg && tok < operatorEnd }
 
+// IsUnreserved returns true for keywords that only have a meaning in specific places
+// and can be used as identifiers everywhere else, e.g. as the name of a field.
+func (tok Token) IsUnreserved() bool {
+	switch tok {
+	case TYPETIMESTAMP, TYPEDECIMAL, LEFT, RIGHT, OUTER, VIEW, MATERIALIZED, REFRESH, AFTER, BEFORE, TRIGGER, VERIFY,
+		ALWAYS, AUTOINCREMENT, CASCADE, CONFLICT, DO, FOREIGN, FULLTEXT, GENERATED, OVER, PARTITION, RECURSIVE,
+		REFERENCES, RELEASE, RESTRICT, RETURNING, SAVEPOINT, STORED, TEMPORARY, UNNEST, VIRTUAL:
+		return true
+	}
+
+	return false
+}
+
 // Tokstr returns a literal if provided, otherwise returns the token string.
 func Tokstr(tok Token, lit string) string {
 	if lit != "" {